@@ -0,0 +1,995 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.9
+// 	protoc        (unknown)
+// source: sai/v1/control.proto
+
+package saiv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type CommandRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Text          string                 `protobuf:"bytes,1,opt,name=text,proto3" json:"text,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CommandRequest) Reset() {
+	*x = CommandRequest{}
+	mi := &file_sai_v1_control_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CommandRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CommandRequest) ProtoMessage() {}
+
+func (x *CommandRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sai_v1_control_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CommandRequest.ProtoReflect.Descriptor instead.
+func (*CommandRequest) Descriptor() ([]byte, []int) {
+	return file_sai_v1_control_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *CommandRequest) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+type CommandResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Text          string                 `protobuf:"bytes,1,opt,name=text,proto3" json:"text,omitempty"`
+	Sentiment     float64                `protobuf:"fixed64,2,opt,name=sentiment,proto3" json:"sentiment,omitempty"`
+	Confidence    float64                `protobuf:"fixed64,3,opt,name=confidence,proto3" json:"confidence,omitempty"`
+	Timestamp     *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CommandResponse) Reset() {
+	*x = CommandResponse{}
+	mi := &file_sai_v1_control_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CommandResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CommandResponse) ProtoMessage() {}
+
+func (x *CommandResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_sai_v1_control_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CommandResponse.ProtoReflect.Descriptor instead.
+func (*CommandResponse) Descriptor() ([]byte, []int) {
+	return file_sai_v1_control_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *CommandResponse) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+func (x *CommandResponse) GetSentiment() float64 {
+	if x != nil {
+		return x.Sentiment
+	}
+	return 0
+}
+
+func (x *CommandResponse) GetConfidence() float64 {
+	if x != nil {
+		return x.Confidence
+	}
+	return 0
+}
+
+func (x *CommandResponse) GetTimestamp() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Timestamp
+	}
+	return nil
+}
+
+type GetMotorsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetMotorsRequest) Reset() {
+	*x = GetMotorsRequest{}
+	mi := &file_sai_v1_control_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetMotorsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetMotorsRequest) ProtoMessage() {}
+
+func (x *GetMotorsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sai_v1_control_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetMotorsRequest.ProtoReflect.Descriptor instead.
+func (*GetMotorsRequest) Descriptor() ([]byte, []int) {
+	return file_sai_v1_control_proto_rawDescGZIP(), []int{2}
+}
+
+type Motor struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Type          string                 `protobuf:"bytes,2,opt,name=type,proto3" json:"type,omitempty"`
+	Position      float64                `protobuf:"fixed64,3,opt,name=position,proto3" json:"position,omitempty"`
+	Speed         float64                `protobuf:"fixed64,4,opt,name=speed,proto3" json:"speed,omitempty"`
+	MaxSpeed      float64                `protobuf:"fixed64,5,opt,name=max_speed,json=maxSpeed,proto3" json:"max_speed,omitempty"`
+	MinPosition   float64                `protobuf:"fixed64,6,opt,name=min_position,json=minPosition,proto3" json:"min_position,omitempty"`
+	MaxPosition   float64                `protobuf:"fixed64,7,opt,name=max_position,json=maxPosition,proto3" json:"max_position,omitempty"`
+	Target        float64                `protobuf:"fixed64,8,opt,name=target,proto3" json:"target,omitempty"`
+	Enabled       bool                   `protobuf:"varint,9,opt,name=enabled,proto3" json:"enabled,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Motor) Reset() {
+	*x = Motor{}
+	mi := &file_sai_v1_control_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Motor) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Motor) ProtoMessage() {}
+
+func (x *Motor) ProtoReflect() protoreflect.Message {
+	mi := &file_sai_v1_control_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Motor.ProtoReflect.Descriptor instead.
+func (*Motor) Descriptor() ([]byte, []int) {
+	return file_sai_v1_control_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Motor) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Motor) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *Motor) GetPosition() float64 {
+	if x != nil {
+		return x.Position
+	}
+	return 0
+}
+
+func (x *Motor) GetSpeed() float64 {
+	if x != nil {
+		return x.Speed
+	}
+	return 0
+}
+
+func (x *Motor) GetMaxSpeed() float64 {
+	if x != nil {
+		return x.MaxSpeed
+	}
+	return 0
+}
+
+func (x *Motor) GetMinPosition() float64 {
+	if x != nil {
+		return x.MinPosition
+	}
+	return 0
+}
+
+func (x *Motor) GetMaxPosition() float64 {
+	if x != nil {
+		return x.MaxPosition
+	}
+	return 0
+}
+
+func (x *Motor) GetTarget() float64 {
+	if x != nil {
+		return x.Target
+	}
+	return 0
+}
+
+func (x *Motor) GetEnabled() bool {
+	if x != nil {
+		return x.Enabled
+	}
+	return false
+}
+
+type GetMotorsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Motors        []*Motor               `protobuf:"bytes,1,rep,name=motors,proto3" json:"motors,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetMotorsResponse) Reset() {
+	*x = GetMotorsResponse{}
+	mi := &file_sai_v1_control_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetMotorsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetMotorsResponse) ProtoMessage() {}
+
+func (x *GetMotorsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_sai_v1_control_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetMotorsResponse.ProtoReflect.Descriptor instead.
+func (*GetMotorsResponse) Descriptor() ([]byte, []int) {
+	return file_sai_v1_control_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *GetMotorsResponse) GetMotors() []*Motor {
+	if x != nil {
+		return x.Motors
+	}
+	return nil
+}
+
+type GetSensorDataRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Type  string                 `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
+	// limit returns only newest readings, 0 means all
+	Limit         int32 `protobuf:"varint,2,opt,name=limit,proto3" json:"limit,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetSensorDataRequest) Reset() {
+	*x = GetSensorDataRequest{}
+	mi := &file_sai_v1_control_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetSensorDataRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetSensorDataRequest) ProtoMessage() {}
+
+func (x *GetSensorDataRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sai_v1_control_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetSensorDataRequest.ProtoReflect.Descriptor instead.
+func (*GetSensorDataRequest) Descriptor() ([]byte, []int) {
+	return file_sai_v1_control_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *GetSensorDataRequest) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *GetSensorDataRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+type GetSensorDataResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Type          string                 `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
+	Values        []float64              `protobuf:"fixed64,2,rep,packed,name=values,proto3" json:"values,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetSensorDataResponse) Reset() {
+	*x = GetSensorDataResponse{}
+	mi := &file_sai_v1_control_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetSensorDataResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetSensorDataResponse) ProtoMessage() {}
+
+func (x *GetSensorDataResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_sai_v1_control_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetSensorDataResponse.ProtoReflect.Descriptor instead.
+func (*GetSensorDataResponse) Descriptor() ([]byte, []int) {
+	return file_sai_v1_control_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *GetSensorDataResponse) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *GetSensorDataResponse) GetValues() []float64 {
+	if x != nil {
+		return x.Values
+	}
+	return nil
+}
+
+type GetBehaviorStateRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetBehaviorStateRequest) Reset() {
+	*x = GetBehaviorStateRequest{}
+	mi := &file_sai_v1_control_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetBehaviorStateRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetBehaviorStateRequest) ProtoMessage() {}
+
+func (x *GetBehaviorStateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sai_v1_control_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetBehaviorStateRequest.ProtoReflect.Descriptor instead.
+func (*GetBehaviorStateRequest) Descriptor() ([]byte, []int) {
+	return file_sai_v1_control_proto_rawDescGZIP(), []int{7}
+}
+
+type GetBehaviorStateResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	State         string                 `protobuf:"bytes,1,opt,name=state,proto3" json:"state,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetBehaviorStateResponse) Reset() {
+	*x = GetBehaviorStateResponse{}
+	mi := &file_sai_v1_control_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetBehaviorStateResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetBehaviorStateResponse) ProtoMessage() {}
+
+func (x *GetBehaviorStateResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_sai_v1_control_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetBehaviorStateResponse.ProtoReflect.Descriptor instead.
+func (*GetBehaviorStateResponse) Descriptor() ([]byte, []int) {
+	return file_sai_v1_control_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *GetBehaviorStateResponse) GetState() string {
+	if x != nil {
+		return x.State
+	}
+	return ""
+}
+
+type GetCapabilitiesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetCapabilitiesRequest) Reset() {
+	*x = GetCapabilitiesRequest{}
+	mi := &file_sai_v1_control_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetCapabilitiesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetCapabilitiesRequest) ProtoMessage() {}
+
+func (x *GetCapabilitiesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sai_v1_control_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetCapabilitiesRequest.ProtoReflect.Descriptor instead.
+func (*GetCapabilitiesRequest) Descriptor() ([]byte, []int) {
+	return file_sai_v1_control_proto_rawDescGZIP(), []int{9}
+}
+
+type MotorCapability struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	Id          string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Type        string                 `protobuf:"bytes,2,opt,name=type,proto3" json:"type,omitempty"`
+	MaxSpeed    float64                `protobuf:"fixed64,3,opt,name=max_speed,json=maxSpeed,proto3" json:"max_speed,omitempty"`
+	MinPosition float64                `protobuf:"fixed64,4,opt,name=min_position,json=minPosition,proto3" json:"min_position,omitempty"`
+	MaxPosition float64                `protobuf:"fixed64,5,opt,name=max_position,json=maxPosition,proto3" json:"max_position,omitempty"`
+	Enabled     bool                   `protobuf:"varint,6,opt,name=enabled,proto3" json:"enabled,omitempty"`
+	// trajectory limits of logical motors, 0 is unlimited
+	MaxAcceleration float64 `protobuf:"fixed64,7,opt,name=max_acceleration,json=maxAcceleration,proto3" json:"max_acceleration,omitempty"`
+	MaxJerk         float64 `protobuf:"fixed64,8,opt,name=max_jerk,json=maxJerk,proto3" json:"max_jerk,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *MotorCapability) Reset() {
+	*x = MotorCapability{}
+	mi := &file_sai_v1_control_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MotorCapability) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MotorCapability) ProtoMessage() {}
+
+func (x *MotorCapability) ProtoReflect() protoreflect.Message {
+	mi := &file_sai_v1_control_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MotorCapability.ProtoReflect.Descriptor instead.
+func (*MotorCapability) Descriptor() ([]byte, []int) {
+	return file_sai_v1_control_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *MotorCapability) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *MotorCapability) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *MotorCapability) GetMaxSpeed() float64 {
+	if x != nil {
+		return x.MaxSpeed
+	}
+	return 0
+}
+
+func (x *MotorCapability) GetMinPosition() float64 {
+	if x != nil {
+		return x.MinPosition
+	}
+	return 0
+}
+
+func (x *MotorCapability) GetMaxPosition() float64 {
+	if x != nil {
+		return x.MaxPosition
+	}
+	return 0
+}
+
+func (x *MotorCapability) GetEnabled() bool {
+	if x != nil {
+		return x.Enabled
+	}
+	return false
+}
+
+func (x *MotorCapability) GetMaxAcceleration() float64 {
+	if x != nil {
+		return x.MaxAcceleration
+	}
+	return 0
+}
+
+func (x *MotorCapability) GetMaxJerk() float64 {
+	if x != nil {
+		return x.MaxJerk
+	}
+	return 0
+}
+
+type PatternCapability struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	DurationMs    int64                  `protobuf:"varint,2,opt,name=duration_ms,json=durationMs,proto3" json:"duration_ms,omitempty"`
+	Steps         int32                  `protobuf:"varint,3,opt,name=steps,proto3" json:"steps,omitempty"`
+	Waveforms     int32                  `protobuf:"varint,4,opt,name=waveforms,proto3" json:"waveforms,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PatternCapability) Reset() {
+	*x = PatternCapability{}
+	mi := &file_sai_v1_control_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PatternCapability) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PatternCapability) ProtoMessage() {}
+
+func (x *PatternCapability) ProtoReflect() protoreflect.Message {
+	mi := &file_sai_v1_control_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PatternCapability.ProtoReflect.Descriptor instead.
+func (*PatternCapability) Descriptor() ([]byte, []int) {
+	return file_sai_v1_control_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *PatternCapability) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *PatternCapability) GetDurationMs() int64 {
+	if x != nil {
+		return x.DurationMs
+	}
+	return 0
+}
+
+func (x *PatternCapability) GetSteps() int32 {
+	if x != nil {
+		return x.Steps
+	}
+	return 0
+}
+
+func (x *PatternCapability) GetWaveforms() int32 {
+	if x != nil {
+		return x.Waveforms
+	}
+	return 0
+}
+
+type UnitCapabilities struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Motors        []*MotorCapability     `protobuf:"bytes,2,rep,name=motors,proto3" json:"motors,omitempty"`
+	Sensors       []string               `protobuf:"bytes,3,rep,name=sensors,proto3" json:"sensors,omitempty"`
+	Patterns      []*PatternCapability   `protobuf:"bytes,4,rep,name=patterns,proto3" json:"patterns,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UnitCapabilities) Reset() {
+	*x = UnitCapabilities{}
+	mi := &file_sai_v1_control_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UnitCapabilities) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UnitCapabilities) ProtoMessage() {}
+
+func (x *UnitCapabilities) ProtoReflect() protoreflect.Message {
+	mi := &file_sai_v1_control_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UnitCapabilities.ProtoReflect.Descriptor instead.
+func (*UnitCapabilities) Descriptor() ([]byte, []int) {
+	return file_sai_v1_control_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *UnitCapabilities) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *UnitCapabilities) GetMotors() []*MotorCapability {
+	if x != nil {
+		return x.Motors
+	}
+	return nil
+}
+
+func (x *UnitCapabilities) GetSensors() []string {
+	if x != nil {
+		return x.Sensors
+	}
+	return nil
+}
+
+func (x *UnitCapabilities) GetPatterns() []*PatternCapability {
+	if x != nil {
+		return x.Patterns
+	}
+	return nil
+}
+
+type GetCapabilitiesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Units         []*UnitCapabilities    `protobuf:"bytes,1,rep,name=units,proto3" json:"units,omitempty"`
+	Commands      []string               `protobuf:"bytes,2,rep,name=commands,proto3" json:"commands,omitempty"`
+	Modes         []string               `protobuf:"bytes,3,rep,name=modes,proto3" json:"modes,omitempty"`
+	Simulated     bool                   `protobuf:"varint,4,opt,name=simulated,proto3" json:"simulated,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetCapabilitiesResponse) Reset() {
+	*x = GetCapabilitiesResponse{}
+	mi := &file_sai_v1_control_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetCapabilitiesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetCapabilitiesResponse) ProtoMessage() {}
+
+func (x *GetCapabilitiesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_sai_v1_control_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetCapabilitiesResponse.ProtoReflect.Descriptor instead.
+func (*GetCapabilitiesResponse) Descriptor() ([]byte, []int) {
+	return file_sai_v1_control_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *GetCapabilitiesResponse) GetUnits() []*UnitCapabilities {
+	if x != nil {
+		return x.Units
+	}
+	return nil
+}
+
+func (x *GetCapabilitiesResponse) GetCommands() []string {
+	if x != nil {
+		return x.Commands
+	}
+	return nil
+}
+
+func (x *GetCapabilitiesResponse) GetModes() []string {
+	if x != nil {
+		return x.Modes
+	}
+	return nil
+}
+
+func (x *GetCapabilitiesResponse) GetSimulated() bool {
+	if x != nil {
+		return x.Simulated
+	}
+	return false
+}
+
+var File_sai_v1_control_proto protoreflect.FileDescriptor
+
+const file_sai_v1_control_proto_rawDesc = "" +
+	"\n" +
+	"\x14sai/v1/control.proto\x12\x06sai.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"1\n" +
+	"\x0eCommandRequest\x12\x12\n" +
+	"\x04text\x18\x01 \x01(\tR\x04textJ\x04\b\x02\x10\x03R\x05token\"\x9d\x01\n" +
+	"\x0fCommandResponse\x12\x12\n" +
+	"\x04text\x18\x01 \x01(\tR\x04text\x12\x1c\n" +
+	"\tsentiment\x18\x02 \x01(\x01R\tsentiment\x12\x1e\n" +
+	"\n" +
+	"confidence\x18\x03 \x01(\x01R\n" +
+	"confidence\x128\n" +
+	"\ttimestamp\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp\"\x12\n" +
+	"\x10GetMotorsRequest\"\xf2\x01\n" +
+	"\x05Motor\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04type\x18\x02 \x01(\tR\x04type\x12\x1a\n" +
+	"\bposition\x18\x03 \x01(\x01R\bposition\x12\x14\n" +
+	"\x05speed\x18\x04 \x01(\x01R\x05speed\x12\x1b\n" +
+	"\tmax_speed\x18\x05 \x01(\x01R\bmaxSpeed\x12!\n" +
+	"\fmin_position\x18\x06 \x01(\x01R\vminPosition\x12!\n" +
+	"\fmax_position\x18\a \x01(\x01R\vmaxPosition\x12\x16\n" +
+	"\x06target\x18\b \x01(\x01R\x06target\x12\x18\n" +
+	"\aenabled\x18\t \x01(\bR\aenabled\":\n" +
+	"\x11GetMotorsResponse\x12%\n" +
+	"\x06motors\x18\x01 \x03(\v2\r.sai.v1.MotorR\x06motors\"@\n" +
+	"\x14GetSensorDataRequest\x12\x12\n" +
+	"\x04type\x18\x01 \x01(\tR\x04type\x12\x14\n" +
+	"\x05limit\x18\x02 \x01(\x05R\x05limit\"C\n" +
+	"\x15GetSensorDataResponse\x12\x12\n" +
+	"\x04type\x18\x01 \x01(\tR\x04type\x12\x16\n" +
+	"\x06values\x18\x02 \x03(\x01R\x06values\"\x19\n" +
+	"\x17GetBehaviorStateRequest\"0\n" +
+	"\x18GetBehaviorStateResponse\x12\x14\n" +
+	"\x05state\x18\x01 \x01(\tR\x05state\"\x18\n" +
+	"\x16GetCapabilitiesRequest\"\xf8\x01\n" +
+	"\x0fMotorCapability\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04type\x18\x02 \x01(\tR\x04type\x12\x1b\n" +
+	"\tmax_speed\x18\x03 \x01(\x01R\bmaxSpeed\x12!\n" +
+	"\fmin_position\x18\x04 \x01(\x01R\vminPosition\x12!\n" +
+	"\fmax_position\x18\x05 \x01(\x01R\vmaxPosition\x12\x18\n" +
+	"\aenabled\x18\x06 \x01(\bR\aenabled\x12)\n" +
+	"\x10max_acceleration\x18\a \x01(\x01R\x0fmaxAcceleration\x12\x19\n" +
+	"\bmax_jerk\x18\b \x01(\x01R\amaxJerk\"|\n" +
+	"\x11PatternCapability\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x1f\n" +
+	"\vduration_ms\x18\x02 \x01(\x03R\n" +
+	"durationMs\x12\x14\n" +
+	"\x05steps\x18\x03 \x01(\x05R\x05steps\x12\x1c\n" +
+	"\twaveforms\x18\x04 \x01(\x05R\twaveforms\"\xa4\x01\n" +
+	"\x10UnitCapabilities\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12/\n" +
+	"\x06motors\x18\x02 \x03(\v2\x17.sai.v1.MotorCapabilityR\x06motors\x12\x18\n" +
+	"\asensors\x18\x03 \x03(\tR\asensors\x125\n" +
+	"\bpatterns\x18\x04 \x03(\v2\x19.sai.v1.PatternCapabilityR\bpatterns\"\x99\x01\n" +
+	"\x17GetCapabilitiesResponse\x12.\n" +
+	"\x05units\x18\x01 \x03(\v2\x18.sai.v1.UnitCapabilitiesR\x05units\x12\x1a\n" +
+	"\bcommands\x18\x02 \x03(\tR\bcommands\x12\x14\n" +
+	"\x05modes\x18\x03 \x03(\tR\x05modes\x12\x1c\n" +
+	"\tsimulated\x18\x04 \x01(\bR\tsimulated2\x8e\x03\n" +
+	"\x0eControlService\x12A\n" +
+	"\x0eProcessCommand\x12\x16.sai.v1.CommandRequest\x1a\x17.sai.v1.CommandResponse\x12@\n" +
+	"\tGetMotors\x12\x18.sai.v1.GetMotorsRequest\x1a\x19.sai.v1.GetMotorsResponse\x12L\n" +
+	"\rGetSensorData\x12\x1c.sai.v1.GetSensorDataRequest\x1a\x1d.sai.v1.GetSensorDataResponse\x12U\n" +
+	"\x10GetBehaviorState\x12\x1f.sai.v1.GetBehaviorStateRequest\x1a .sai.v1.GetBehaviorStateResponse\x12R\n" +
+	"\x0fGetCapabilities\x12\x1e.sai.v1.GetCapabilitiesRequest\x1a\x1f.sai.v1.GetCapabilitiesResponseBHZFgithub.com/sashalind/sex-artifical-intelligence/api/proto/sai/v1;saiv1b\x06proto3"
+
+var (
+	file_sai_v1_control_proto_rawDescOnce sync.Once
+	file_sai_v1_control_proto_rawDescData []byte
+)
+
+func file_sai_v1_control_proto_rawDescGZIP() []byte {
+	file_sai_v1_control_proto_rawDescOnce.Do(func() {
+		file_sai_v1_control_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_sai_v1_control_proto_rawDesc), len(file_sai_v1_control_proto_rawDesc)))
+	})
+	return file_sai_v1_control_proto_rawDescData
+}
+
+var file_sai_v1_control_proto_msgTypes = make([]protoimpl.MessageInfo, 14)
+var file_sai_v1_control_proto_goTypes = []any{
+	(*CommandRequest)(nil),           // 0: sai.v1.CommandRequest
+	(*CommandResponse)(nil),          // 1: sai.v1.CommandResponse
+	(*GetMotorsRequest)(nil),         // 2: sai.v1.GetMotorsRequest
+	(*Motor)(nil),                    // 3: sai.v1.Motor
+	(*GetMotorsResponse)(nil),        // 4: sai.v1.GetMotorsResponse
+	(*GetSensorDataRequest)(nil),     // 5: sai.v1.GetSensorDataRequest
+	(*GetSensorDataResponse)(nil),    // 6: sai.v1.GetSensorDataResponse
+	(*GetBehaviorStateRequest)(nil),  // 7: sai.v1.GetBehaviorStateRequest
+	(*GetBehaviorStateResponse)(nil), // 8: sai.v1.GetBehaviorStateResponse
+	(*GetCapabilitiesRequest)(nil),   // 9: sai.v1.GetCapabilitiesRequest
+	(*MotorCapability)(nil),          // 10: sai.v1.MotorCapability
+	(*PatternCapability)(nil),        // 11: sai.v1.PatternCapability
+	(*UnitCapabilities)(nil),         // 12: sai.v1.UnitCapabilities
+	(*GetCapabilitiesResponse)(nil),  // 13: sai.v1.GetCapabilitiesResponse
+	(*timestamppb.Timestamp)(nil),    // 14: google.protobuf.Timestamp
+}
+var file_sai_v1_control_proto_depIdxs = []int32{
+	14, // 0: sai.v1.CommandResponse.timestamp:type_name -> google.protobuf.Timestamp
+	3,  // 1: sai.v1.GetMotorsResponse.motors:type_name -> sai.v1.Motor
+	10, // 2: sai.v1.UnitCapabilities.motors:type_name -> sai.v1.MotorCapability
+	11, // 3: sai.v1.UnitCapabilities.patterns:type_name -> sai.v1.PatternCapability
+	12, // 4: sai.v1.GetCapabilitiesResponse.units:type_name -> sai.v1.UnitCapabilities
+	0,  // 5: sai.v1.ControlService.ProcessCommand:input_type -> sai.v1.CommandRequest
+	2,  // 6: sai.v1.ControlService.GetMotors:input_type -> sai.v1.GetMotorsRequest
+	5,  // 7: sai.v1.ControlService.GetSensorData:input_type -> sai.v1.GetSensorDataRequest
+	7,  // 8: sai.v1.ControlService.GetBehaviorState:input_type -> sai.v1.GetBehaviorStateRequest
+	9,  // 9: sai.v1.ControlService.GetCapabilities:input_type -> sai.v1.GetCapabilitiesRequest
+	1,  // 10: sai.v1.ControlService.ProcessCommand:output_type -> sai.v1.CommandResponse
+	4,  // 11: sai.v1.ControlService.GetMotors:output_type -> sai.v1.GetMotorsResponse
+	6,  // 12: sai.v1.ControlService.GetSensorData:output_type -> sai.v1.GetSensorDataResponse
+	8,  // 13: sai.v1.ControlService.GetBehaviorState:output_type -> sai.v1.GetBehaviorStateResponse
+	13, // 14: sai.v1.ControlService.GetCapabilities:output_type -> sai.v1.GetCapabilitiesResponse
+	10, // [10:15] is the sub-list for method output_type
+	5,  // [5:10] is the sub-list for method input_type
+	5,  // [5:5] is the sub-list for extension type_name
+	5,  // [5:5] is the sub-list for extension extendee
+	0,  // [0:5] is the sub-list for field type_name
+}
+
+func init() { file_sai_v1_control_proto_init() }
+func file_sai_v1_control_proto_init() {
+	if File_sai_v1_control_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_sai_v1_control_proto_rawDesc), len(file_sai_v1_control_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   14,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_sai_v1_control_proto_goTypes,
+		DependencyIndexes: file_sai_v1_control_proto_depIdxs,
+		MessageInfos:      file_sai_v1_control_proto_msgTypes,
+	}.Build()
+	File_sai_v1_control_proto = out.File
+	file_sai_v1_control_proto_goTypes = nil
+	file_sai_v1_control_proto_depIdxs = nil
+}
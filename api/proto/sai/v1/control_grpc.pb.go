@@ -0,0 +1,293 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: sai/v1/control.proto
+
+package saiv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	ControlService_ProcessCommand_FullMethodName   = "/sai.v1.ControlService/ProcessCommand"
+	ControlService_GetMotors_FullMethodName        = "/sai.v1.ControlService/GetMotors"
+	ControlService_GetSensorData_FullMethodName    = "/sai.v1.ControlService/GetSensorData"
+	ControlService_GetBehaviorState_FullMethodName = "/sai.v1.ControlService/GetBehaviorState"
+	ControlService_GetCapabilities_FullMethodName  = "/sai.v1.ControlService/GetCapabilities"
+)
+
+// ControlServiceClient is the client API for ControlService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// ControlService exposes core.System to remote clients (mobile app, web dashboard).
+// pkg/rpc serves it over gRPC, regenerate Go code with go generate ./api/...
+// When system requires access tokens every call sends
+// "authorization: Bearer <token>" metadata.
+type ControlServiceClient interface {
+	// ProcessCommand parses and executes natural language command
+	ProcessCommand(ctx context.Context, in *CommandRequest, opts ...grpc.CallOption) (*CommandResponse, error)
+	// GetMotors returns current state of every motor
+	GetMotors(ctx context.Context, in *GetMotorsRequest, opts ...grpc.CallOption) (*GetMotorsResponse, error)
+	// GetSensorData returns recent readings of one sensor type
+	GetSensorData(ctx context.Context, in *GetSensorDataRequest, opts ...grpc.CallOption) (*GetSensorDataResponse, error)
+	// GetBehaviorState returns current behavior classification
+	GetBehaviorState(ctx context.Context, in *GetBehaviorStateRequest, opts ...grpc.CallOption) (*GetBehaviorStateResponse, error)
+	// GetCapabilities describes motors, sensors, patterns and commands of this build
+	GetCapabilities(ctx context.Context, in *GetCapabilitiesRequest, opts ...grpc.CallOption) (*GetCapabilitiesResponse, error)
+}
+
+type controlServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewControlServiceClient(cc grpc.ClientConnInterface) ControlServiceClient {
+	return &controlServiceClient{cc}
+}
+
+func (c *controlServiceClient) ProcessCommand(ctx context.Context, in *CommandRequest, opts ...grpc.CallOption) (*CommandResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CommandResponse)
+	err := c.cc.Invoke(ctx, ControlService_ProcessCommand_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controlServiceClient) GetMotors(ctx context.Context, in *GetMotorsRequest, opts ...grpc.CallOption) (*GetMotorsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetMotorsResponse)
+	err := c.cc.Invoke(ctx, ControlService_GetMotors_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controlServiceClient) GetSensorData(ctx context.Context, in *GetSensorDataRequest, opts ...grpc.CallOption) (*GetSensorDataResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetSensorDataResponse)
+	err := c.cc.Invoke(ctx, ControlService_GetSensorData_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controlServiceClient) GetBehaviorState(ctx context.Context, in *GetBehaviorStateRequest, opts ...grpc.CallOption) (*GetBehaviorStateResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetBehaviorStateResponse)
+	err := c.cc.Invoke(ctx, ControlService_GetBehaviorState_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controlServiceClient) GetCapabilities(ctx context.Context, in *GetCapabilitiesRequest, opts ...grpc.CallOption) (*GetCapabilitiesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetCapabilitiesResponse)
+	err := c.cc.Invoke(ctx, ControlService_GetCapabilities_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ControlServiceServer is the server API for ControlService service.
+// All implementations must embed UnimplementedControlServiceServer
+// for forward compatibility.
+//
+// ControlService exposes core.System to remote clients (mobile app, web dashboard).
+// pkg/rpc serves it over gRPC, regenerate Go code with go generate ./api/...
+// When system requires access tokens every call sends
+// "authorization: Bearer <token>" metadata.
+type ControlServiceServer interface {
+	// ProcessCommand parses and executes natural language command
+	ProcessCommand(context.Context, *CommandRequest) (*CommandResponse, error)
+	// GetMotors returns current state of every motor
+	GetMotors(context.Context, *GetMotorsRequest) (*GetMotorsResponse, error)
+	// GetSensorData returns recent readings of one sensor type
+	GetSensorData(context.Context, *GetSensorDataRequest) (*GetSensorDataResponse, error)
+	// GetBehaviorState returns current behavior classification
+	GetBehaviorState(context.Context, *GetBehaviorStateRequest) (*GetBehaviorStateResponse, error)
+	// GetCapabilities describes motors, sensors, patterns and commands of this build
+	GetCapabilities(context.Context, *GetCapabilitiesRequest) (*GetCapabilitiesResponse, error)
+	mustEmbedUnimplementedControlServiceServer()
+}
+
+// UnimplementedControlServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedControlServiceServer struct{}
+
+func (UnimplementedControlServiceServer) ProcessCommand(context.Context, *CommandRequest) (*CommandResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ProcessCommand not implemented")
+}
+func (UnimplementedControlServiceServer) GetMotors(context.Context, *GetMotorsRequest) (*GetMotorsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetMotors not implemented")
+}
+func (UnimplementedControlServiceServer) GetSensorData(context.Context, *GetSensorDataRequest) (*GetSensorDataResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetSensorData not implemented")
+}
+func (UnimplementedControlServiceServer) GetBehaviorState(context.Context, *GetBehaviorStateRequest) (*GetBehaviorStateResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetBehaviorState not implemented")
+}
+func (UnimplementedControlServiceServer) GetCapabilities(context.Context, *GetCapabilitiesRequest) (*GetCapabilitiesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetCapabilities not implemented")
+}
+func (UnimplementedControlServiceServer) mustEmbedUnimplementedControlServiceServer() {}
+func (UnimplementedControlServiceServer) testEmbeddedByValue()                        {}
+
+// UnsafeControlServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ControlServiceServer will
+// result in compilation errors.
+type UnsafeControlServiceServer interface {
+	mustEmbedUnimplementedControlServiceServer()
+}
+
+func RegisterControlServiceServer(s grpc.ServiceRegistrar, srv ControlServiceServer) {
+	// If the following call pancis, it indicates UnimplementedControlServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&ControlService_ServiceDesc, srv)
+}
+
+func _ControlService_ProcessCommand_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CommandRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControlServiceServer).ProcessCommand(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ControlService_ProcessCommand_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControlServiceServer).ProcessCommand(ctx, req.(*CommandRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ControlService_GetMotors_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetMotorsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControlServiceServer).GetMotors(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ControlService_GetMotors_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControlServiceServer).GetMotors(ctx, req.(*GetMotorsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ControlService_GetSensorData_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetSensorDataRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControlServiceServer).GetSensorData(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ControlService_GetSensorData_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControlServiceServer).GetSensorData(ctx, req.(*GetSensorDataRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ControlService_GetBehaviorState_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetBehaviorStateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControlServiceServer).GetBehaviorState(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ControlService_GetBehaviorState_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControlServiceServer).GetBehaviorState(ctx, req.(*GetBehaviorStateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ControlService_GetCapabilities_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetCapabilitiesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControlServiceServer).GetCapabilities(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ControlService_GetCapabilities_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControlServiceServer).GetCapabilities(ctx, req.(*GetCapabilitiesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// ControlService_ServiceDesc is the grpc.ServiceDesc for ControlService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var ControlService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "sai.v1.ControlService",
+	HandlerType: (*ControlServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ProcessCommand",
+			Handler:    _ControlService_ProcessCommand_Handler,
+		},
+		{
+			MethodName: "GetMotors",
+			Handler:    _ControlService_GetMotors_Handler,
+		},
+		{
+			MethodName: "GetSensorData",
+			Handler:    _ControlService_GetSensorData_Handler,
+		},
+		{
+			MethodName: "GetBehaviorState",
+			Handler:    _ControlService_GetBehaviorState_Handler,
+		},
+		{
+			MethodName: "GetCapabilities",
+			Handler:    _ControlService_GetCapabilities_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "sai/v1/control.proto",
+}
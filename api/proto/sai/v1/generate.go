@@ -0,0 +1,4 @@
+// Package saiv1 is generated Go code of ControlService, served by pkg/rpc
+package saiv1
+
+//go:generate protoc -I ../.. --go_out=../.. --go_opt=paths=source_relative --go-grpc_out=../.. --go-grpc_opt=paths=source_relative sai/v1/control.proto
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/sashalind/sex-artifical-intelligence/pkg/sim/harness"
+)
+
+// runs end-to-end scripts against simulated hardware, exits non-zero on failure
+func main() {
+	step := flag.Duration("step", harness.DefaultStep, "fake time advanced per iteration")
+	reportPath := flag.String("report", "", "write JSON report with trajectories to file")
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "usage: sai-harness [flags] script.json...")
+		os.Exit(2)
+	}
+
+	var reports []*harness.Report
+	failed := false
+	for _, path := range flag.Args() {
+		script, err := harness.LoadScript(path)
+		if err != nil {
+			log.Fatalf("Failed to load script: %v", err)
+		}
+
+		report, err := harness.Run(script, harness.Options{Step: *step})
+		if err != nil {
+			log.Fatalf("Script %s failed to run: %v", script.Name, err)
+		}
+		reports = append(reports, report)
+
+		if report.Passed() {
+			fmt.Printf("PASS %s\n", script.Name)
+			continue
+		}
+
+		failed = true
+		fmt.Printf("FAIL %s\n", script.Name)
+		for _, f := range report.Failures {
+			fmt.Printf("    %s\n", f)
+		}
+	}
+
+	if *reportPath != "" {
+		data, err := json.MarshalIndent(reports, "", "  ")
+		if err != nil {
+			log.Fatalf("Failed to encode report: %v", err)
+		}
+		if err := os.WriteFile(*reportPath, data, 0644); err != nil {
+			log.Fatalf("Failed to write report: %v", err)
+		}
+	}
+
+	if failed {
+		os.Exit(1)
+	}
+}
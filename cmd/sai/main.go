@@ -1,38 +1,268 @@
 package main
 
 import (
+	"errors"
+	"flag"
+	"fmt"
 	"log"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"syscall"
+	"time"
 
+	"github.com/sashalind/sex-artifical-intelligence/pkg/api"
 	"github.com/sashalind/sex-artifical-intelligence/pkg/core"
 	"github.com/sashalind/sex-artifical-intelligence/pkg/diagnostics"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/rpc"
 	"github.com/sashalind/sex-artifical-intelligence/pkg/safety"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/secure"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/storage"
+)
+
+const (
+	// stateFile keeps system state between runs, inside data directory
+	stateFile = "state.snap"
+	
+	// autosaveInterval bounds state lost on crash
+	autosaveInterval = 30 * time.Second
+	
+	// dataKeyEnv holds hex data key for env key source
+	dataKeyEnv = "SAI_DATA_KEY"
 )
 
 // bozhe moy, main entry point of our glorious system
 // we initialize everything here, da?
 func main() {
+	configPath := flag.String("config", "", "path to JSON or YAML config file")
+	dataDir := flag.String("data", "data", "directory for persistent data")
+	patternDir := flag.String("patterns", "", "pattern library directory, loaded at start and saved on exit")
+	noEncrypt := flag.Bool("no-encrypt", false, "store data without encryption")
+	keySourceName := flag.String("key-source", "", "where data key comes from: file, env ("+dataKeyEnv+") or keyring; env when "+dataKeyEnv+" is set, file otherwise")
+	keyFile := flag.String("key-file", "", "data key file for file key source (default data.key in user config directory)")
+	httpAddr := flag.String("http", "", "serve HTTP API on address, e.g. :8080")
+	rpcAddr := flag.String("rpc", "", "serve control gRPC API on address, e.g. :7070")
+	simulate := flag.Bool("sim", false, "run against simulated hardware instead of real devices")
+	simScenario := flag.String("scenario", "", "scenario file played by simulated sensors (with -sim)")
+	replayPath := flag.String("replay", "", "sensor recording played by simulated sensors (with -sim)")
+	recordPath := flag.String("record-sensors", "", "record raw sensor readings to CSV file until exit")
+	flag.Parse()
+	
 	log.Println("Starting Sex Artificial Intelligence System v0.1.0")
 	
+	cfg := core.DefaultConfig()
+	if *configPath != "" {
+		loaded, err := core.LoadConfig(*configPath)
+		if err != nil {
+			log.Fatalf("Failed to load config: %v", err)
+		}
+		cfg = *loaded
+	}
+	if *simulate {
+		cfg.Simulation.Enabled = true
+	}
+	if *simScenario != "" {
+		cfg.Simulation.Scenario = *simScenario
+	}
+	if *replayPath != "" {
+		cfg.Simulation.Replay = *replayPath
+	}
+	
 	// initialize core systems blyat
-	system, err := core.NewSystem()
+	system, err := core.NewSystemWithConfig(cfg)
 	if err != nil {
 		log.Fatalf("Failed to initialize core system: %v", err)
 	}
 
+	// persistent storage for history, patterns and profiles
+	var src secure.KeySource
+	if !*noEncrypt {
+		if src, err = keySource(*keySourceName, *keyFile, *dataDir); err != nil {
+			log.Fatalf("Failed to pick data key: %v", err)
+		}
+	}
+	store, err := openStore(*dataDir, src)
+	if err != nil {
+		log.Fatalf("Failed to open storage: %v", err)
+	}
+	if err := system.AttachStore(store); err != nil {
+		log.Fatalf("Failed to attach storage: %v", err)
+	}
+	
+	// resume where previous run stopped, planned or not
+	statePath := filepath.Join(*dataDir, stateFile)
+	if err := system.LoadState(statePath); err == nil {
+		log.Println("Previous state restored")
+	} else if !errors.Is(err, os.ErrNotExist) {
+		log.Printf("Failed to restore previous state, starting fresh: %v", err)
+	}
+	system.StartAutosave(statePath, autosaveInterval)
+	
+	// shared pattern files win over stored copies of same name
+	if *patternDir != "" {
+		n, err := system.LoadPatternDir(*patternDir)
+		if err != nil {
+			log.Printf("Some pattern files failed to load: %v", err)
+		}
+		log.Printf("Loaded %d pattern files from %s", n, *patternDir)
+	}
+
+	// every raw reading to disk, hub closes file on shutdown
+	if *recordPath != "" {
+		if err := system.RecordSensors(core.PrimaryUnit, *recordPath); err != nil {
+			log.Fatalf("Failed to start sensor recording: %v", err)
+		}
+		log.Printf("Recording sensor readings to %s", *recordPath)
+	}
+
 	// safety first, tovarisch
 	safety.InitializeSafetyProtocols(system)
 	
+	// no hardware? no problem, simulated E-stop button too
+	if world := system.SimulatedWorld(); world != nil {
+		safety.AttachEStop(world.EStop)
+	}
+	
 	// diagnostic systems for when everything goes to blyat
 	diagnostics.StartMonitoring(system)
 
+	// remote control for apps and dashboards
+	var rpcServer *rpc.Server
+	if *rpcAddr != "" {
+		rpcServer, err = rpc.NewServer(system)
+		if err != nil {
+			log.Fatalf("Failed to create RPC server: %v", err)
+		}
+		go func() {
+			if err := rpcServer.ListenAndServe(*rpcAddr); err != nil {
+				log.Printf("RPC server stopped: %v", err)
+			}
+		}()
+		log.Printf("Control gRPC API listening on %s", *rpcAddr)
+	}
+	
+	// REST for curl lovers and web UIs
+	var apiServer *api.Server
+	if *httpAddr != "" {
+		apiServer, err = api.NewServer(system, *httpAddr)
+		if err != nil {
+			log.Fatalf("Failed to create HTTP API: %v", err)
+		}
+		go func() {
+			if err := apiServer.ListenAndServe(); err != nil {
+				log.Printf("HTTP API stopped: %v", err)
+			}
+		}()
+		log.Printf("HTTP API listening on %s", *httpAddr)
+	}
+	
 	// graceful shutdown, like good vodka
 	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
 	
-	<-sigChan
+	// SIGHUP re-reads config file, anything else shuts down
+	for sig := range sigChan {
+		if sig != syscall.SIGHUP {
+			break
+		}
+		reloadConfig(system, *configPath)
+	}
 	log.Println("Shutting down systems... Do svidaniya!")
+	if rpcServer != nil {
+		rpcServer.Close()
+	}
+	if apiServer != nil {
+		apiServer.Close()
+	}
+	if err := system.SaveState(statePath); err != nil {
+		log.Printf("Failed to save state: %v", err)
+	}
+	if *patternDir != "" {
+		if err := system.SavePatternDir(*patternDir); err != nil {
+			log.Printf("Failed to save pattern library: %v", err)
+		}
+	}
 	system.Shutdown()
-} 
\ No newline at end of file
+	if err := store.Close(); err != nil {
+		log.Printf("Failed to close storage: %v", err)
+	}
+}
+
+// reloadConfig applies config file to running system, keeping old config on error
+func reloadConfig(system *core.System, path string) {
+	if path == "" {
+		log.Println("No config file given, nothing to reload")
+		return
+	}
+	
+	cfg, err := core.LoadConfig(path)
+	if err != nil {
+		log.Printf("Config reload failed: %v", err)
+		return
+	}
+	if err := system.ReloadConfig(*cfg); err != nil {
+		log.Printf("Config reload failed, keeping previous config: %v", err)
+	}
+}
+
+// openStore opens data directory, encrypting it with key from src unless
+// src is nil
+func openStore(dir string, src secure.KeySource) (*storage.Store, error) {
+	if src == nil {
+		return storage.Open(dir, nil)
+	}
+	
+	c, err := secure.NewCipherFromSource(src)
+	if err != nil {
+		return nil, err
+	}
+	return storage.Open(dir, c)
+} 
+
+// keySource returns named data key source, picking env when SAI_DATA_KEY
+// is set and file otherwise when name is empty
+func keySource(name, keyFile, dataDir string) (secure.KeySource, error) {
+	if name == "" {
+		name = "file"
+		if _, ok := os.LookupEnv(dataKeyEnv); ok {
+			name = "env"
+		}
+	}
+	
+	switch name {
+	case "file":
+		if keyFile == "" {
+			path, err := defaultKeyFile(dataDir)
+			if err != nil {
+				return nil, err
+			}
+			keyFile = path
+		}
+		return secure.FileKey{Path: keyFile}, nil
+	case "env":
+		return secure.EnvKey{Name: dataKeyEnv}, nil
+	case "keyring":
+		return secure.KeyringKey{Service: "sai", Account: "data"}, nil
+	}
+	return nil, fmt.Errorf("unknown key source %q, want file, env or keyring", name)
+}
+
+// defaultKeyFile is data.key in user config directory, away from data it
+// protects. Key file earlier versions kept in data directory is used until
+// it is moved.
+func defaultKeyFile(dataDir string) (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("no place for key file, pass -key-file: %w", err)
+	}
+	path := filepath.Join(dir, "sai", "data.key")
+	
+	legacy := filepath.Join(dataDir, "data.key")
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		if _, err := os.Stat(legacy); err == nil {
+			log.Printf("Data key %s is stored with the data it protects, move it to %s", legacy, path)
+			return legacy, nil
+		}
+	}
+	return path, nil
+}
@@ -1,5 +1,17 @@
 module github.com/sashalind/sex-artifical-intelligence
 
-go 1.23.4
+go 1.25.0
 
-<!-- fuck -->
+require (
+	go.etcd.io/bbolt v1.4.3
+	google.golang.org/grpc v1.84.0
+	google.golang.org/protobuf v1.36.11
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require (
+	golang.org/x/net v0.57.0 // indirect
+	golang.org/x/sys v0.47.0 // indirect
+	golang.org/x/text v0.40.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20260706201446-f0a921348800 // indirect
+)
@@ -0,0 +1,1506 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sashalind/sex-artifical-intelligence/pkg/core"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/core/errs"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/diagnostics"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/health"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/motion"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/nlp"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/profile"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/safety"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/script"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/sensor"
+)
+
+// maxBodySize limits request bodies, commands are short
+const maxBodySize = 64 << 10
+
+// defaultTelemetryInterval is frame period of telemetry stream
+const defaultTelemetryInterval = 100 * time.Millisecond
+
+// defaultStatsWindow is window of sensor stats when request names none
+const defaultStatsWindow = time.Minute
+
+// sensorStreamBuffer is readings sensor stream holds for slow client,
+// older ones give way
+const sensorStreamBuffer = 256
+
+// Server exposes core.System as JSON over HTTP
+type Server struct {
+	system *core.System
+	http   *http.Server
+
+	// streams ends open event streams, Shutdown would wait for them
+	streams     context.Context
+	stopStreams context.CancelFunc
+}
+
+// CommandRequest is body of POST /command
+type CommandRequest struct {
+	Text    string `json:"text"`
+	Session string `json:"session,omitempty"`
+}
+
+// SessionRequest is body of POST /sessions. Saved profile of user is
+// loaded, Preferences override it for this session only.
+type SessionRequest struct {
+	Name        string              `json:"name"`
+	Preferences profile.Preferences `json:"preferences"`
+}
+
+// SessionResponse describes open session
+type SessionResponse struct {
+	ID          string              `json:"id"`
+	StartedAt   time.Time           `json:"started_at"`
+	Profile     profile.Profile     `json:"profile"`
+	Preferences profile.Preferences `json:"preferences"`
+}
+
+// StatusResponse is body of GET /status
+type StatusResponse struct {
+	Active        bool     `json:"active"`
+	Mode          string   `json:"mode"`
+	Degraded      bool     `json:"degraded"`
+	Simulated     bool     `json:"simulated"`
+	UptimeSeconds int64    `json:"uptime_seconds"`
+	BehaviorState string   `json:"behavior_state"`
+	SafetyLevel   int      `json:"safety_level"`
+	Warnings      []string `json:"warnings"`
+
+	Subsystems []core.SubsystemStatus `json:"subsystems"`
+	Adaptation core.AdaptationState   `json:"adaptation"`
+	Params     core.MotionParams      `json:"params"`
+}
+
+// ModeRequest is body of POST /mode
+type ModeRequest struct {
+	Mode string `json:"mode"`
+}
+
+// MotorState is motor as returned by GET /motors
+type MotorState struct {
+	ID          string  `json:"id"`
+	Type        string  `json:"type"`
+	Position    float64 `json:"position"`
+	Speed       float64 `json:"speed"`
+	MaxSpeed    float64 `json:"max_speed"`
+	MinPosition float64 `json:"min_position"`
+	MaxPosition float64 `json:"max_position"`
+	Target      float64 `json:"target"`
+	Enabled     bool    `json:"enabled"`
+	Unit        string  `json:"unit"` // of positions, speeds are unit/second
+}
+
+// SensorSample is timestamped reading as returned by
+// GET /sensors/{type}/samples
+type SensorSample struct {
+	Value     float64   `json:"value"`
+	Unit      string    `json:"unit,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+	Source    string    `json:"source,omitempty"`
+	Sensor    string    `json:"sensor,omitempty"`
+}
+
+// SensorReading is event of GET /sensors/stream
+type SensorReading struct {
+	Type string `json:"type"`
+	SensorSample
+}
+
+// MoveGroupRequest is body of POST /motors/group. Sync or named Group
+// times motors to start and arrive together. Wait answers only once every
+// motor arrived, failing after Timeout or when move is interrupted.
+type MoveGroupRequest struct {
+	Unit     string             `json:"unit,omitempty"`
+	Group    string             `json:"group,omitempty"`
+	Sync     bool               `json:"sync,omitempty"`
+	Wait     bool               `json:"wait,omitempty"`
+	Timeout  core.Duration      `json:"timeout,omitempty"`
+	Commands []MotorCommandBody `json:"commands"`
+}
+
+// MotorCommandBody is single motor target in group
+type MotorCommandBody struct {
+	ID       string  `json:"id"`
+	Position float64 `json:"position"`
+	Speed    float64 `json:"speed"`
+}
+
+// UnitState is robot unit as returned by GET /units
+type UnitState struct {
+	ID          string              `json:"id"`
+	Healthy     bool                `json:"healthy"`
+	Motors      []MotorState        `json:"motors"`
+	SensorTypes []sensor.SensorType `json:"sensor_types"`
+}
+
+// errorResponse is body of every non-2xx reply
+type errorResponse struct {
+	Error string    `json:"error"`
+	Code  errs.Code `json:"code,omitempty"`
+}
+
+// statusByCode maps error codes to HTTP statuses
+var statusByCode = map[errs.Code]int{
+	errs.InvalidArgument:    http.StatusBadRequest,
+	errs.NotFound:           http.StatusNotFound,
+	errs.OutOfRange:         http.StatusUnprocessableEntity,
+	errs.FailedPrecondition: http.StatusConflict,
+	errs.Unavailable:        http.StatusServiceUnavailable,
+	errs.Cancelled:          http.StatusConflict,
+	errs.DeadlineExceeded:   http.StatusGatewayTimeout,
+	errs.Unauthenticated:    http.StatusUnauthorized,
+	errs.PermissionDenied:   http.StatusForbidden,
+}
+
+// NewServer creates HTTP server for system listening on addr
+func NewServer(sys *core.System, addr string) (*Server, error) {
+	if sys == nil {
+		return nil, errors.New("system is nil")
+	}
+
+	s := &Server{system: sys}
+	s.streams, s.stopStreams = context.WithCancel(context.Background())
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /command", s.handleCommand)
+	mux.HandleFunc("GET /status", s.handleStatus)
+	mux.HandleFunc("GET /capabilities", s.handleCapabilities)
+	mux.HandleFunc("GET /selftest", s.handleSelfTest)
+	mux.HandleFunc("GET /health", s.handleHealth)
+	mux.HandleFunc("POST /mode", s.require(core.PermConfigure, s.handleMode))
+	mux.HandleFunc("POST /sessions", s.handleStartSession)
+	mux.HandleFunc("DELETE /sessions/{id}", s.handleEndSession)
+	mux.HandleFunc("GET /profiles", s.handleProfiles)
+	mux.HandleFunc("GET /profiles/{name}", s.handleProfile)
+	mux.HandleFunc("PUT /profiles/{name}", s.handlePutProfile)
+	mux.HandleFunc("DELETE /profiles/{name}", s.handleDeleteProfile)
+	mux.HandleFunc("GET /queue", s.handleQueue)
+	mux.HandleFunc("DELETE /queue/{id}", s.require(core.PermConfigure, s.handleCancel))
+	mux.HandleFunc("GET /history", s.require(core.PermConfigure, s.handleHistory))
+	mux.HandleFunc("DELETE /history", s.require(core.PermConfigure, s.handleForgetHistory))
+	mux.HandleFunc("GET /routines", s.handleRoutines)
+	mux.HandleFunc("PUT /routines/{id}", s.require(core.PermConfigure, s.handlePutRoutine))
+	mux.HandleFunc("DELETE /routines/{id}", s.require(core.PermConfigure, s.handleDeleteRoutine))
+	mux.HandleFunc("GET /scripts", s.handleScripts)
+	mux.HandleFunc("PUT /scripts/{name}", s.require(core.PermConfigure, s.handlePutScript))
+	mux.HandleFunc("DELETE /scripts/{name}", s.require(core.PermConfigure, s.handleDeleteScript))
+	mux.HandleFunc("GET /programs", s.handlePrograms)
+	mux.HandleFunc("PUT /programs/{name}", s.require(core.PermConfigure, s.handlePutProgram))
+	mux.HandleFunc("GET /patterns/progress", s.handlePatternProgress)
+	mux.HandleFunc("GET /patterns/running", s.handlePatternRuns)
+	mux.HandleFunc("GET /patterns/{name}/preview", s.handlePatternPreview)
+	mux.HandleFunc("GET /patterns/export", s.handleExportPatterns)
+	mux.HandleFunc("POST /patterns/import", s.require(core.PermConfigure, s.handleImportPatterns))
+	mux.HandleFunc("GET /recording", s.handleRecording)
+	mux.HandleFunc("POST /recording", s.require(core.PermConfigure, s.handleStartRecording))
+	mux.HandleFunc("PUT /recording/{name}", s.require(core.PermConfigure, s.handleStopRecording))
+	mux.HandleFunc("GET /motors", s.handleMotors)
+	mux.HandleFunc("POST /motors", s.require(core.PermConfigure, s.handleAddMotor))
+	mux.HandleFunc("PUT /motors/{id}", s.require(core.PermConfigure, s.handleConfigureMotor))
+	mux.HandleFunc("DELETE /motors/{id}", s.require(core.PermConfigure, s.handleRemoveMotor))
+	mux.HandleFunc("POST /motors/group", s.require(core.PermCommand, s.handleMoveGroup))
+	mux.HandleFunc("GET /motors/groups", s.handleMotorGroups)
+	mux.HandleFunc("GET /motors/calibration", s.handleCalibrations)
+	mux.HandleFunc("GET /motors/telemetry", s.handleTelemetry)
+	mux.HandleFunc("POST /motors/{id}/home", s.require(core.PermCalibrate, s.handleHome))
+	mux.HandleFunc("PUT /motors/{id}/soft-limits", s.require(core.PermCalibrate, s.handleSoftLimits))
+	mux.HandleFunc("POST /motors/{id}/reset", s.require(core.PermFirmware, s.handleResetMotor))
+	mux.HandleFunc("POST /motors/{id}/jog", s.require(core.PermCommand, s.handleJog))
+	mux.HandleFunc("DELETE /motors/{id}/jog", s.require(core.PermCommand, s.handleJogStop))
+	mux.HandleFunc("GET /units", s.handleUnits)
+	mux.HandleFunc("GET /sensors", s.handleSensors)
+	mux.HandleFunc("GET /sensors/{type}/samples", s.handleSensorSamples)
+	mux.HandleFunc("GET /sensors/instances", s.handleSensorInstances)
+	mux.HandleFunc("PUT /sensors/instances/{id}", s.require(core.PermConfigure, s.handlePutSensorInstance))
+	mux.HandleFunc("DELETE /sensors/instances/{id}", s.require(core.PermConfigure, s.handleDeleteSensorInstance))
+	mux.HandleFunc("GET /sensors/instances/{id}/samples", s.handleSensorInstanceSamples)
+	mux.HandleFunc("GET /sensors/instances/{id}/stats", s.handleSensorStats)
+	mux.HandleFunc("GET /sensors/zones", s.handleZoneLevels)
+	mux.HandleFunc("GET /sensors/zones/{zone}/samples", s.handleZoneSamples)
+	mux.HandleFunc("GET /sensors/calibration", s.handleSensorCalibrations)
+	mux.HandleFunc("GET /sensors/health", s.handleSensorHealth)
+	mux.HandleFunc("GET /sensors/channels", s.handleSensorChannels)
+	mux.HandleFunc("GET /sensors/alerts", s.handleSensorAlerts)
+	mux.HandleFunc("GET /sensors/gestures", s.handleSensorGestures)
+	mux.HandleFunc("GET /sensors/temperature/limits", s.handleTempLimits)
+	mux.HandleFunc("PUT /sensors/temperature/limits", s.require(core.PermConfigure, s.handlePutTempLimits))
+	mux.HandleFunc("GET /sensors/alerts/rules", s.handleSensorAlertRules)
+	mux.HandleFunc("PUT /sensors/alerts/rules/{name}", s.require(core.PermConfigure, s.handlePutSensorAlertRule))
+	mux.HandleFunc("DELETE /sensors/alerts/rules/{name}", s.require(core.PermConfigure, s.handleDeleteSensorAlertRule))
+	mux.HandleFunc("POST /sensors/channels", s.require(core.PermConfigure, s.handleAttachSensorChannel))
+	mux.HandleFunc("DELETE /sensors/channels/{id...}", s.require(core.PermConfigure, s.handleDetachSensorChannel))
+	mux.HandleFunc("GET /sensors/stream", s.handleSensorStream)
+	mux.HandleFunc("GET /sensors/recording", s.handleSensorRecording)
+	mux.HandleFunc("POST /sensors/recording", s.require(core.PermConfigure, s.handleStartSensorRecording))
+	mux.HandleFunc("DELETE /sensors/recording", s.require(core.PermConfigure, s.handleStopSensorRecording))
+	mux.HandleFunc("PUT /sensors/instances/{id}/calibration", s.require(core.PermCalibrate, s.handlePutSensorCalibration))
+	mux.HandleFunc("DELETE /sensors/instances/{id}/calibration", s.require(core.PermCalibrate, s.handleDeleteSensorCalibration))
+	mux.HandleFunc("POST /sensors/instances/{id}/zero", s.require(core.PermCalibrate, s.handleSensorZero))
+	mux.HandleFunc("GET /metrics", s.handleMetrics)
+	mux.HandleFunc("GET /metrics/misunderstood", s.require(core.PermConfigure, s.handleMisunderstood))
+
+	s.http = &http.Server{
+		Addr:              addr,
+		Handler:           s.authenticate(mux),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	s.http.RegisterOnShutdown(s.stopStreams)
+	return s, nil
+}
+
+// Handler returns request router, useful for embedding into existing server
+func (s *Server) Handler() http.Handler {
+	return s.http.Handler
+}
+
+// ListenAndServe serves requests until Close is called
+func (s *Server) ListenAndServe() error {
+	err := s.http.ListenAndServe()
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+// Close stops server waiting briefly for in-flight requests
+func (s *Server) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return s.http.Shutdown(ctx)
+}
+
+// authenticate attaches caller identified by bearer token to request
+// context. Open API (no authenticator configured) passes requests as is.
+func (s *Server) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !s.system.AuthRequired() {
+			next.ServeHTTP(w, r)
+			return
+		}
+		token, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		p, err := s.system.Authenticate(r.Context(), strings.TrimSpace(token))
+		if err != nil {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeErr(w, err)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(core.WithPrincipal(r.Context(), p)))
+	})
+}
+
+// require rejects request unless caller holds permission
+func (s *Server) require(perm core.Permission, h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := s.system.Authorize(r.Context(), perm); err != nil {
+			writeErr(w, err)
+			return
+		}
+		h(w, r)
+	}
+}
+
+// handleCommand runs natural language command, POST /command {"text": "..."}
+func (s *Server) handleCommand(w http.ResponseWriter, r *http.Request) {
+	var req CommandRequest
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize))
+	if err := dec.Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return
+	}
+	if req.Text == "" {
+		writeError(w, http.StatusBadRequest, "command text is empty")
+		return
+	}
+
+	// client disconnect or timeout drops command still waiting in queue
+	ctx := r.Context()
+	if req.Session != "" {
+		ctx = core.WithSession(ctx, core.SessionID(req.Session))
+	}
+	if id := r.Header.Get("X-Request-ID"); id != "" {
+		ctx = core.WithTraceID(ctx, id)
+	}
+
+	resp, err := s.system.ProcessCommand(ctx, req.Text)
+	if err != nil {
+		writeErr(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// handleStatus reports overall system health
+func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	status := StatusResponse{
+		Active:        s.system.IsActive(),
+		Mode:          s.system.Mode().String(),
+		Degraded:      s.system.Degraded(),
+		Simulated:     s.system.Simulated(),
+		Subsystems:    s.system.SubsystemStatuses(),
+		Adaptation:    s.system.Adaptation(),
+		Params:        s.system.MotionParams(),
+		UptimeSeconds: int64(s.system.GetUptime().Seconds()),
+		BehaviorState: string(s.system.GetBehaviorState()),
+		Warnings:      []string{},
+	}
+	if monitor := safety.GetMonitor(); monitor != nil {
+		status.SafetyLevel = int(monitor.GetCurrentLevel())
+		status.Warnings = monitor.GetWarnings()
+	}
+	writeJSON(w, http.StatusOK, status)
+}
+
+// handleCapabilities describes hardware and supported commands
+func (s *Server) handleCapabilities(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.system.Capabilities())
+}
+
+// handleSelfTest reports result of startup self-test
+func (s *Server) handleSelfTest(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.system.SelfTest())
+}
+
+// handleHealth returns unified subsystem health, with 503 when any
+// subsystem is down so load balancers and watchdogs can use it as probe
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	h := s.system.Health()
+	code := http.StatusOK
+	if h.Status == health.Down {
+		code = http.StatusServiceUnavailable
+	}
+	writeJSON(w, code, h)
+}
+
+// handleMode switches operating mode, e.g. {"mode": "idle"} to leave safe mode
+func (s *Server) handleMode(w http.ResponseWriter, r *http.Request) {
+	var req ModeRequest
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize))
+	if err := dec.Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return
+	}
+
+	mode, err := core.ParseMode(req.Mode)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := s.system.SetMode(mode); err != nil {
+		writeErr(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, ModeRequest{Mode: s.system.Mode().String()})
+}
+
+// handleStartSession opens session for user named in body
+func (s *Server) handleStartSession(w http.ResponseWriter, r *http.Request) {
+	var req SessionRequest
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize))
+	if err := dec.Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return
+	}
+	if err := req.Preferences.Validate(); err != nil {
+		writeErr(w, err)
+		return
+	}
+
+	sess, err := s.system.StartSession(r.Context(), req.Name)
+	if err != nil {
+		writeErr(w, err)
+		return
+	}
+	if err := sess.SetOverrides(req.Preferences); err != nil {
+		s.system.EndSession(r.Context(), sess.ID)
+		writeErr(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, SessionResponse{
+		ID:          string(sess.ID),
+		StartedAt:   sess.StartedAt,
+		Profile:     sess.Profile(),
+		Preferences: sess.Preferences(),
+	})
+}
+
+// handleEndSession closes session
+func (s *Server) handleEndSession(w http.ResponseWriter, r *http.Request) {
+	if err := s.system.EndSession(r.Context(), core.SessionID(r.PathValue("id"))); err != nil {
+		writeErr(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleProfiles lists saved user profiles
+func (s *Server) handleProfiles(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.system.Profiles())
+}
+
+// handleProfile returns one saved profile
+func (s *Server) handleProfile(w http.ResponseWriter, r *http.Request) {
+	p, err := s.system.Profile(r.PathValue("name"))
+	if err != nil {
+		writeErr(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, p)
+}
+
+// handlePutProfile creates or replaces profile with preferences in body
+func (s *Server) handlePutProfile(w http.ResponseWriter, r *http.Request) {
+	var prefs profile.Preferences
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize))
+	if err := dec.Decode(&prefs); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return
+	}
+
+	p, err := s.system.SaveProfile(r.Context(), profile.Profile{Name: r.PathValue("name"), Preferences: prefs})
+	if err != nil {
+		writeErr(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, p)
+}
+
+// handleDeleteProfile removes saved profile
+func (s *Server) handleDeleteProfile(w http.ResponseWriter, r *http.Request) {
+	if err := s.system.DeleteProfile(r.Context(), r.PathValue("name")); err != nil {
+		writeErr(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleQueue lists commands waiting for execution
+func (s *Server) handleQueue(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.system.QueuedCommands())
+}
+
+// handleCancel drops queued command that has not started yet
+func (s *Server) handleCancel(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid command id")
+		return
+	}
+	if err := s.system.CancelCommand(core.CommandID(id)); err != nil {
+		writeErr(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleHistory lists finished commands, newest first. Query parameters
+// since and until take RFC 3339 times, type and outcome may repeat, e.g.
+// /history?type=move&outcome=failed&limit=20
+func (s *Server) handleHistory(w http.ResponseWriter, r *http.Request) {
+	q, ok := historyQuery(w, r)
+	if !ok {
+		return
+	}
+	entries := s.system.CommandHistory(q)
+	if entries == nil {
+		entries = []core.HistoryEntry{}
+	}
+	writeJSON(w, http.StatusOK, entries)
+}
+
+// handleForgetHistory deletes finished commands matching same parameters
+// as GET /history, e.g. ?session=abc to erase one session
+func (s *Server) handleForgetHistory(w http.ResponseWriter, r *http.Request) {
+	q, ok := historyQuery(w, r)
+	if !ok {
+		return
+	}
+	n, err := s.system.ForgetHistory(q)
+	if err != nil {
+		writeErr(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]int{"deleted": n})
+}
+
+// historyQuery reads history query of request parameters, writing error
+// response when they are invalid
+func historyQuery(w http.ResponseWriter, r *http.Request) (core.HistoryQuery, bool) {
+	params := r.URL.Query()
+	q := core.HistoryQuery{Session: core.SessionID(params.Get("session"))}
+
+	for name, t := range map[string]*time.Time{"since": &q.Since, "until": &q.Until} {
+		if v := params.Get(name); v != "" {
+			parsed, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				writeError(w, http.StatusBadRequest, name+" must be RFC 3339 time")
+				return q, false
+			}
+			*t = parsed
+		}
+	}
+	if v := params.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			writeError(w, http.StatusBadRequest, "limit must be non-negative integer")
+			return q, false
+		}
+		q.Limit = n
+	}
+	for _, t := range params["type"] {
+		q.Types = append(q.Types, nlp.CommandType(t))
+	}
+	for _, o := range params["outcome"] {
+		q.Outcomes = append(q.Outcomes, core.Outcome(o))
+	}
+	return q, true
+}
+
+// handleRoutines lists scheduled routines
+func (s *Server) handleRoutines(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.system.Routines())
+}
+
+// handlePutRoutine creates or replaces routine, e.g.
+// {"trigger": {"kind": "idle", "every": "10m"}, "action": {"mode": "idle"}}
+func (s *Server) handlePutRoutine(w http.ResponseWriter, r *http.Request) {
+	var routine core.Routine
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize))
+	if err := dec.Decode(&routine); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return
+	}
+	routine.ID = core.RoutineID(r.PathValue("id"))
+
+	if err := s.system.AddRoutine(routine); err != nil {
+		writeErr(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, routine)
+}
+
+// handleDeleteRoutine removes routine
+func (s *Server) handleDeleteRoutine(w http.ResponseWriter, r *http.Request) {
+	if err := s.system.RemoveRoutine(core.RoutineID(r.PathValue("id"))); err != nil {
+		writeErr(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleScripts lists running scripts
+func (s *Server) handleScripts(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.system.Scripts())
+}
+
+// handlePutScript loads script source sent as plain text body
+func (s *Server) handlePutScript(w http.ResponseWriter, r *http.Request) {
+	src, err := io.ReadAll(http.MaxBytesReader(w, r.Body, script.MaxSize))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return
+	}
+	if err := s.system.LoadScript(r.PathValue("name"), string(src)); err != nil {
+		writeErr(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleDeleteScript stops script
+func (s *Server) handleDeleteScript(w http.ResponseWriter, r *http.Request) {
+	if err := s.system.UnloadScript(r.PathValue("name")); err != nil {
+		writeErr(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handlePrograms lists pattern programs
+func (s *Server) handlePrograms(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.system.Programs())
+}
+
+// handlePutProgram creates or replaces pattern program, e.g.
+// {"root": {"kind": "loop", "count": 3, "children": [{"kind": "pattern", "pattern": "wave"}]}}
+func (s *Server) handlePutProgram(w http.ResponseWriter, r *http.Request) {
+	var program core.PatternProgram
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize))
+	if err := dec.Decode(&program); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return
+	}
+	program.Name = r.PathValue("name")
+
+	if err := s.system.AddProgram(program); err != nil {
+		writeErr(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, program)
+}
+
+// handlePatternProgress reports running pattern or program, 204 if none
+func (s *Server) handlePatternProgress(w http.ResponseWriter, r *http.Request) {
+	progress, running := s.system.PatternProgress()
+	if !running {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	writeJSON(w, http.StatusOK, progress)
+}
+
+// handlePatternRuns lists playing and queued patterns with motors each
+// drives
+func (s *Server) handlePatternRuns(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.system.PatternRuns())
+}
+
+// handlePatternPreview returns predicted per-motor course of pattern,
+// sampled every 50ms, without moving motors
+func (s *Server) handlePatternPreview(w http.ResponseWriter, r *http.Request) {
+	preview, err := s.system.PreviewPattern(core.UnitID(r.URL.Query().Get("unit")), r.PathValue("name"))
+	if err != nil {
+		writeErr(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, preview)
+}
+
+// handleExportPatterns returns pattern file with patterns and programs
+// given by repeated name parameter, all of them without it
+func (s *Server) handleExportPatterns(w http.ResponseWriter, r *http.Request) {
+	f, err := s.system.ExportPatterns(r.URL.Query()["name"]...)
+	if err != nil {
+		writeErr(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, f)
+}
+
+// handleImportPatterns adds patterns and programs of uploaded pattern file
+func (s *Server) handleImportPatterns(w http.ResponseWriter, r *http.Request) {
+	f, err := core.ReadPatternFile(http.MaxBytesReader(w, r.Body, maxBodySize))
+	if err != nil {
+		writeErr(w, err)
+		return
+	}
+	if err := s.system.ImportPatterns(f); err != nil {
+		writeErr(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleRecording reports whether movement is being recorded
+func (s *Server) handleRecording(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]bool{"recording": s.system.Recording()})
+}
+
+// handleStartRecording starts recording, e.g. {"source": "feedback"}
+func (s *Server) handleStartRecording(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Source motion.RecordSource `json:"source"`
+	}
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize))
+	if err := dec.Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return
+	}
+
+	if err := s.system.StartRecording(req.Source); err != nil {
+		writeErr(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleStopRecording ends recording and saves it as pattern under name
+func (s *Server) handleStopRecording(w http.ResponseWriter, r *http.Request) {
+	pattern, err := s.system.StopRecording(r.PathValue("name"))
+	if err != nil {
+		writeErr(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, pattern)
+}
+
+// handleMotors lists motor states
+func (s *Server) handleMotors(w http.ResponseWriter, r *http.Request) {
+	motors := s.system.GetMotors()
+	states := make([]MotorState, 0, len(motors))
+	for _, m := range motors {
+		states = append(states, motorState(m))
+	}
+	writeJSON(w, http.StatusOK, states)
+}
+
+// handleMoveGroup applies coordinated pose, all motors or none
+func (s *Server) handleMoveGroup(w http.ResponseWriter, r *http.Request) {
+	var req MoveGroupRequest
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize))
+	if err := dec.Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return
+	}
+
+	cmds := make([]motion.MotorCommand, 0, len(req.Commands))
+	for _, c := range req.Commands {
+		cmds = append(cmds, motion.MotorCommand{ID: motion.MotorID(c.ID), Position: c.Position, Speed: c.Speed})
+	}
+	var err error
+	if req.Sync || req.Group != "" {
+		err = s.system.MoveSynced(r.Context(), core.UnitID(req.Unit), req.Group, cmds)
+	} else {
+		err = s.system.MoveGroup(r.Context(), core.UnitID(req.Unit), cmds)
+	}
+	if err == nil && req.Wait {
+		err = s.system.WaitArrival(r.Context(), core.UnitID(req.Unit), cmds, time.Duration(req.Timeout))
+	}
+	if err != nil {
+		writeErr(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleMotorGroups lists configured motor groups of unit
+func (s *Server) handleMotorGroups(w http.ResponseWriter, r *http.Request) {
+	groups, err := s.system.MotorGroups(core.UnitID(r.URL.Query().Get("unit")))
+	if err != nil {
+		writeErr(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, groups)
+}
+
+// handleCalibrations lists motor calibrations of unit
+func (s *Server) handleCalibrations(w http.ResponseWriter, r *http.Request) {
+	cals, err := s.system.Calibrations(core.UnitID(r.URL.Query().Get("unit")))
+	if err != nil {
+		writeErr(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, cals)
+}
+
+// handleHome homes motor and returns its new calibration
+func (s *Server) handleHome(w http.ResponseWriter, r *http.Request) {
+	cal, err := s.system.HomeMotor(r.Context(), core.UnitID(r.URL.Query().Get("unit")), r.PathValue("id"))
+	if err != nil {
+		writeErr(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, cal)
+}
+
+// handleSoftLimits sets calibrated travel of motor, e.g. {"min": 10, "max": 170}
+func (s *Server) handleSoftLimits(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Min float64 `json:"min"`
+		Max float64 `json:"max"`
+	}
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize))
+	if err := dec.Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return
+	}
+
+	if err := s.system.SetSoftLimits(core.UnitID(r.URL.Query().Get("unit")), r.PathValue("id"), req.Min, req.Max); err != nil {
+		writeErr(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleResetMotor reboots driver electronics of motor
+func (s *Server) handleResetMotor(w http.ResponseWriter, r *http.Request) {
+	if err := s.system.ResetMotor(r.Context(), core.UnitID(r.URL.Query().Get("unit")), r.PathValue("id")); err != nil {
+		writeErr(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleJog starts or renews jog of motor, e.g. {"velocity": -20}. Clients
+// repeat it while jog button is held, motor stops once they don't.
+func (s *Server) handleJog(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Velocity float64 `json:"velocity"`
+	}
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize))
+	if err := dec.Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return
+	}
+
+	if err := s.system.Jog(r.Context(), core.UnitID(r.URL.Query().Get("unit")), r.PathValue("id"), req.Velocity); err != nil {
+		writeErr(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleJogStop stops jog of motor on button release
+func (s *Server) handleJogStop(w http.ResponseWriter, r *http.Request) {
+	if err := s.system.JogStop(core.UnitID(r.URL.Query().Get("unit")), r.PathValue("id")); err != nil {
+		writeErr(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleAddMotor declares motor at runtime, body is motor config entry
+func (s *Server) handleAddMotor(w http.ResponseWriter, r *http.Request) {
+	var mc core.MotorConfig
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize))
+	if err := dec.Decode(&mc); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return
+	}
+
+	if err := s.system.AddMotor(core.UnitID(r.URL.Query().Get("unit")), mc); err != nil {
+		writeErr(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleConfigureMotor replaces config of running motor
+func (s *Server) handleConfigureMotor(w http.ResponseWriter, r *http.Request) {
+	var mc core.MotorConfig
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize))
+	if err := dec.Decode(&mc); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return
+	}
+	id := r.PathValue("id")
+	if mc.ID != "" && mc.ID != id {
+		writeError(w, http.StatusBadRequest, "motor id in body does not match path")
+		return
+	}
+	mc.ID = id
+
+	if err := s.system.ConfigureMotor(core.UnitID(r.URL.Query().Get("unit")), mc); err != nil {
+		writeErr(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleRemoveMotor stops motor and removes it
+func (s *Server) handleRemoveMotor(w http.ResponseWriter, r *http.Request) {
+	if err := s.system.RemoveMotor(core.UnitID(r.URL.Query().Get("unit")), r.PathValue("id")); err != nil {
+		writeErr(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleTelemetry streams motor telemetry frames as server-sent events
+// until client goes away, e.g. ?interval=50ms&unit=main
+func (s *Server) handleTelemetry(w http.ResponseWriter, r *http.Request) {
+	interval := defaultTelemetryInterval
+	if v := r.URL.Query().Get("interval"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			writeError(w, http.StatusBadRequest, "invalid interval")
+			return
+		}
+		interval = d
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, "streaming not supported")
+		return
+	}
+
+	t, err := s.system.MotorTelemetry(core.UnitID(r.URL.Query().Get("unit")), interval)
+	if err != nil {
+		writeErr(w, err)
+		return
+	}
+	defer t.Cancel()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+	for {
+		select {
+		case frame, ok := <-t.C:
+			if !ok {
+				return
+			}
+			data, err := json.Marshal(frame)
+			if err != nil {
+				log.Printf("Failed to encode telemetry: %v", err)
+				return
+			}
+			if _, err := fmt.Fprintf(w, "data: %s\n\n", data); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		case <-s.streams.Done():
+			return
+		}
+	}
+}
+
+// handleSensorStream streams readings as server-sent events until client
+// goes away, optionally of one type or sensor, e.g. ?type=touch&unit=main
+func (s *Server) handleSensorStream(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, "streaming not supported")
+		return
+	}
+	params := r.URL.Query()
+	sel := sensor.Selector{Type: sensor.SensorType(params.Get("type")), Sensor: sensor.SensorID(params.Get("sensor"))}
+	sub, err := s.system.SubscribeSensors(core.UnitID(params.Get("unit")), sel, sensorStreamBuffer, sensor.DropOldest)
+	if err != nil {
+		writeErr(w, err)
+		return
+	}
+	defer sub.Cancel()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+	for {
+		select {
+		case d, ok := <-sub.C:
+			if !ok {
+				return
+			}
+			data, err := json.Marshal(SensorReading{
+				Type:         string(d.Type),
+				SensorSample: SensorSample{Value: d.Value, Timestamp: d.Timestamp, Source: d.Source, Sensor: string(d.Sensor)},
+			})
+			if err != nil {
+				log.Printf("Failed to encode sensor reading: %v", err)
+				return
+			}
+			if _, err := fmt.Fprintf(w, "data: %s\n\n", data); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		case <-s.streams.Done():
+			return
+		}
+	}
+}
+
+// handleUnits lists robot units with their motors and health
+func (s *Server) handleUnits(w http.ResponseWriter, r *http.Request) {
+	statuses := s.system.UnitStatuses()
+	units := make([]UnitState, 0, len(statuses))
+	for _, u := range statuses {
+		state := UnitState{
+			ID:          string(u.ID),
+			Healthy:     u.Healthy,
+			Motors:      make([]MotorState, 0, len(u.Motors)),
+			SensorTypes: u.SensorTypes,
+		}
+		for _, m := range u.Motors {
+			state.Motors = append(state.Motors, motorState(m))
+		}
+		units = append(units, state)
+	}
+	writeJSON(w, http.StatusOK, units)
+}
+
+// handleSensors returns readings per sensor type. Optional query
+// parameters: type selects single sensor, limit keeps newest N readings.
+func (s *Server) handleSensors(w http.ResponseWriter, r *http.Request) {
+	limit := 0
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			writeError(w, http.StatusBadRequest, "limit must be non-negative integer")
+			return
+		}
+		limit = n
+	}
+
+	types := s.system.GetSensorTypes()
+	if t := r.URL.Query().Get("type"); t != "" {
+		types = []sensor.SensorType{sensor.SensorType(t)}
+	}
+
+	readings := make(map[sensor.SensorType][]float64, len(types))
+	for _, t := range types {
+		values := s.system.GetSensorData(t)
+		if limit > 0 && limit < len(values) {
+			values = values[len(values)-limit:]
+		}
+		if values == nil {
+			values = []float64{}
+		}
+		readings[t] = values
+	}
+	writeJSON(w, http.StatusOK, readings)
+}
+
+// handleSensorSamples returns timestamped readings of one sensor type in
+// order of arrival. Query parameters since and until take RFC 3339 times,
+// limit keeps newest N, e.g. /sensors/pressure/samples?since=2024-01-01T10:00:00Z
+func (s *Server) handleSensorSamples(w http.ResponseWriter, r *http.Request) {
+	q, ok := sampleQuery(w, r)
+	if !ok {
+		return
+	}
+	data, err := s.system.GetSensorSamples(q.unit, sensor.SensorType(r.PathValue("type")), q.since, q.until)
+	q.write(w, data, err)
+}
+
+// handleSensorInstances lists registered sensors of unit
+func (s *Server) handleSensorInstances(w http.ResponseWriter, r *http.Request) {
+	sensors, err := s.system.Sensors(core.UnitID(r.URL.Query().Get("unit")))
+	if err != nil {
+		writeErr(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, sensors)
+}
+
+// handlePutSensorInstance registers sensor or updates its zone, location
+// and filters, e.g. {"type": "touch", "zone": "tip", "filters": [{"kind": "median"}]}
+func (s *Server) handlePutSensorInstance(w http.ResponseWriter, r *http.Request) {
+	var body core.SensorInstanceConfig
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize))
+	if err := dec.Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return
+	}
+	body.ID = r.PathValue("id")
+	if err := s.system.RegisterSensor(core.UnitID(r.URL.Query().Get("unit")), body); err != nil {
+		writeErr(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, body)
+}
+
+// handleDeleteSensorInstance unregisters sensor
+func (s *Server) handleDeleteSensorInstance(w http.ResponseWriter, r *http.Request) {
+	if err := s.system.UnregisterSensor(core.UnitID(r.URL.Query().Get("unit")), sensor.SensorID(r.PathValue("id"))); err != nil {
+		writeErr(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleSensorInstanceSamples returns readings of one registered sensor,
+// takes same query parameters as /sensors/{type}/samples and as, physical
+// unit to convert readings to, e.g. as=°F or as=F
+func (s *Server) handleSensorInstanceSamples(w http.ResponseWriter, r *http.Request) {
+	q, ok := sampleQuery(w, r)
+	if !ok {
+		return
+	}
+	as, err := sensor.ParseUnit(r.URL.Query().Get("as"))
+	if err != nil {
+		writeErr(w, err)
+		return
+	}
+	data, readUnit, err := s.system.SensorSamplesIn(q.unit, sensor.SensorID(r.PathValue("id")), q.since, q.until, as)
+	q.as = readUnit
+	q.write(w, data, err)
+}
+
+// handleSensorStats returns min, max, mean, deviation and percentiles of
+// readings of one sensor within window, e.g. ?window=30s (default 1m)
+func (s *Server) handleSensorStats(w http.ResponseWriter, r *http.Request) {
+	window := defaultStatsWindow
+	if v := r.URL.Query().Get("window"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			writeError(w, http.StatusBadRequest, "invalid window")
+			return
+		}
+		window = d
+	}
+	stats, err := s.system.SensorStats(core.UnitID(r.URL.Query().Get("unit")), sensor.SensorID(r.PathValue("id")), window)
+	if err != nil {
+		writeErr(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, stats)
+}
+
+// handleZoneSamples returns readings of every sensor in zone in timestamp
+// order, optional type parameter keeps one sensor type
+func (s *Server) handleZoneSamples(w http.ResponseWriter, r *http.Request) {
+	q, ok := sampleQuery(w, r)
+	if !ok {
+		return
+	}
+	sType := sensor.SensorType(r.URL.Query().Get("type"))
+	data, err := s.system.ZoneSamples(q.unit, r.PathValue("zone"), sType, q.since, q.until)
+	q.write(w, data, err)
+}
+
+// handleZoneLevels returns latest level of each zone, of touch unless type
+// parameter names other sensor type
+func (s *Server) handleZoneLevels(w http.ResponseWriter, r *http.Request) {
+	sType := sensor.SensorType(r.URL.Query().Get("type"))
+	if sType == "" {
+		sType = sensor.TypeTouch
+	}
+	levels, err := s.system.ZoneLevels(core.UnitID(r.URL.Query().Get("unit")), sType)
+	if err != nil {
+		writeErr(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, levels)
+}
+
+// handleTempLimits lists temperature limits of zones
+func (s *Server) handleTempLimits(w http.ResponseWriter, r *http.Request) {
+	limits, err := s.system.TempLimits(core.UnitID(r.URL.Query().Get("unit")))
+	if err != nil {
+		writeErr(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, limits)
+}
+
+// handlePutTempLimits replaces temperature limits, e.g.
+// [{"zone": "shaft", "warn": 40, "max": 43, "hysteresis": 1}]
+func (s *Server) handlePutTempLimits(w http.ResponseWriter, r *http.Request) {
+	var body []core.SensorTempLimit
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize))
+	if err := dec.Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return
+	}
+	if err := s.system.SetTempLimits(core.UnitID(r.URL.Query().Get("unit")), body); err != nil {
+		writeErr(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, body)
+}
+
+// handleSensorAlerts lists sensor alerts still in effect
+func (s *Server) handleSensorAlerts(w http.ResponseWriter, r *http.Request) {
+	alerts, err := s.system.ActiveSensorAlerts(core.UnitID(r.URL.Query().Get("unit")))
+	if err != nil {
+		writeErr(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, alerts)
+}
+
+// handleSensorGestures lists touch gestures recently recognized
+func (s *Server) handleSensorGestures(w http.ResponseWriter, r *http.Request) {
+	gestures, err := s.system.SensorGestures(core.UnitID(r.URL.Query().Get("unit")))
+	if err != nil {
+		writeErr(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, gestures)
+}
+
+// handleSensorAlertRules lists sensor alert rules
+func (s *Server) handleSensorAlertRules(w http.ResponseWriter, r *http.Request) {
+	rules, err := s.system.SensorAlertRules(core.UnitID(r.URL.Query().Get("unit")))
+	if err != nil {
+		writeErr(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, rules)
+}
+
+// handlePutSensorAlertRule adds or replaces sensor alert rule, e.g.
+// {"type": "pressure", "kind": "above", "threshold": 0.9, "for": "200ms"}
+func (s *Server) handlePutSensorAlertRule(w http.ResponseWriter, r *http.Request) {
+	var body core.SensorAlertRule
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize))
+	if err := dec.Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return
+	}
+	body.Name = r.PathValue("name")
+	if err := s.system.SetSensorAlertRule(core.UnitID(r.URL.Query().Get("unit")), body); err != nil {
+		writeErr(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, body)
+}
+
+// handleDeleteSensorAlertRule removes sensor alert rule
+func (s *Server) handleDeleteSensorAlertRule(w http.ResponseWriter, r *http.Request) {
+	if err := s.system.RemoveSensorAlertRule(core.UnitID(r.URL.Query().Get("unit")), r.PathValue("name")); err != nil {
+		writeErr(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleSensorRecording reports raw sensor reading recording
+func (s *Server) handleSensorRecording(w http.ResponseWriter, r *http.Request) {
+	rec, err := s.system.SensorRecording(core.UnitID(r.URL.Query().Get("unit")))
+	if err != nil {
+		writeErr(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, rec)
+}
+
+// handleStartSensorRecording starts recording raw sensor readings to file
+// in record directory, e.g. {"name": "session-1"}
+func (s *Server) handleStartSensorRecording(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Name string `json:"name"`
+	}
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize))
+	if err := dec.Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return
+	}
+	rec, err := s.system.StartSensorRecording(core.UnitID(r.URL.Query().Get("unit")), body.Name)
+	if err != nil {
+		writeErr(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, rec)
+}
+
+// handleStopSensorRecording ends sensor recording and returns its totals
+func (s *Server) handleStopSensorRecording(w http.ResponseWriter, r *http.Request) {
+	rec, err := s.system.StopSensorRecording(core.UnitID(r.URL.Query().Get("unit")))
+	if err != nil {
+		writeErr(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, rec)
+}
+
+// handleSensorChannels lists hot-plugged sensor channels
+func (s *Server) handleSensorChannels(w http.ResponseWriter, r *http.Request) {
+	channels, err := s.system.SensorChannels(core.UnitID(r.URL.Query().Get("unit")))
+	if err != nil {
+		writeErr(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, channels)
+}
+
+// handleAttachSensorChannel adds sensor channel, body is channel config
+// entry, e.g. {"type": "temperature", "driver": "tmp102", "bus": "/dev/i2c-1"}
+func (s *Server) handleAttachSensorChannel(w http.ResponseWriter, r *http.Request) {
+	var body core.SensorChannelConfig
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize))
+	if err := dec.Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return
+	}
+	if err := s.system.AttachSensorChannel(core.UnitID(r.URL.Query().Get("unit")), body); err != nil {
+		writeErr(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, body)
+}
+
+// handleDetachSensorChannel removes sensor channel, ID may contain device
+// path slashes
+func (s *Server) handleDetachSensorChannel(w http.ResponseWriter, r *http.Request) {
+	if err := s.system.DetachSensorChannel(core.UnitID(r.URL.Query().Get("unit")), r.PathValue("id")); err != nil {
+		writeErr(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleSensorHealth lists registered sensors with their faults
+func (s *Server) handleSensorHealth(w http.ResponseWriter, r *http.Request) {
+	statuses, err := s.system.SensorHealth(core.UnitID(r.URL.Query().Get("unit")))
+	if err != nil {
+		writeErr(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, statuses)
+}
+
+// handleSensorCalibrations lists calibrated sensors
+func (s *Server) handleSensorCalibrations(w http.ResponseWriter, r *http.Request) {
+	cals, err := s.system.SensorCalibrations(core.UnitID(r.URL.Query().Get("unit")))
+	if err != nil {
+		writeErr(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, cals)
+}
+
+// handlePutSensorCalibration installs calibration of sensor, e.g.
+// {"offset": 0.05, "scale": 1.2, "table": [{"in": 0, "out": 0}, {"in": 1, "out": 0.8}]}
+func (s *Server) handlePutSensorCalibration(w http.ResponseWriter, r *http.Request) {
+	var cal core.SensorCalibration
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize))
+	if err := dec.Decode(&cal); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return
+	}
+	cal.Sensor = r.PathValue("id")
+	if err := s.system.CalibrateSensor(core.UnitID(r.URL.Query().Get("unit")), cal); err != nil {
+		writeErr(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleDeleteSensorCalibration makes sensor report raw readings
+func (s *Server) handleDeleteSensorCalibration(w http.ResponseWriter, r *http.Request) {
+	if err := s.system.ClearSensorCalibration(core.UnitID(r.URL.Query().Get("unit")), r.PathValue("id")); err != nil {
+		writeErr(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleSensorZero takes current readings of sensor at rest as its zero
+// and returns new calibration
+func (s *Server) handleSensorZero(w http.ResponseWriter, r *http.Request) {
+	cal, err := s.system.CaptureSensorZero(core.UnitID(r.URL.Query().Get("unit")), r.PathValue("id"))
+	if err != nil {
+		writeErr(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, cal)
+}
+
+// samplesQuery is unit, time window and limit of sample request
+type samplesQuery struct {
+	unit         core.UnitID
+	since, until time.Time
+	limit        int
+	as           sensor.Unit // physical unit readings are in, when known
+}
+
+// sampleQuery parses sample query parameters, answering bad request itself
+func sampleQuery(w http.ResponseWriter, r *http.Request) (samplesQuery, bool) {
+	params := r.URL.Query()
+	q := samplesQuery{unit: core.UnitID(params.Get("unit"))}
+	for name, t := range map[string]*time.Time{"since": &q.since, "until": &q.until} {
+		if v := params.Get(name); v != "" {
+			parsed, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				writeError(w, http.StatusBadRequest, name+" must be RFC 3339 time")
+				return q, false
+			}
+			*t = parsed
+		}
+	}
+	if v := params.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			writeError(w, http.StatusBadRequest, "limit must be non-negative integer")
+			return q, false
+		}
+		q.limit = n
+	}
+	return q, true
+}
+
+// write answers with newest limit readings of data, or err
+func (q samplesQuery) write(w http.ResponseWriter, data []sensor.SensorData, err error) {
+	if err != nil {
+		writeErr(w, err)
+		return
+	}
+	if q.limit > 0 && q.limit < len(data) {
+		data = data[len(data)-q.limit:]
+	}
+	samples := make([]SensorSample, 0, len(data))
+	for _, d := range data {
+		samples = append(samples, SensorSample{Value: d.Value, Unit: string(q.as), Timestamp: d.Timestamp, Source: d.Source, Sensor: string(d.Sensor)})
+	}
+	writeJSON(w, http.StatusOK, samples)
+}
+
+// handleMetrics returns latest diagnostics sample
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	monitor := diagnostics.GetMonitor()
+	if monitor == nil {
+		writeError(w, http.StatusServiceUnavailable, "diagnostics not running")
+		return
+	}
+	metrics := monitor.GetLatestMetrics()
+	if metrics == nil {
+		writeError(w, http.StatusServiceUnavailable, "no metrics collected yet")
+		return
+	}
+	writeJSON(w, http.StatusOK, metrics)
+}
+
+// handleMisunderstood returns log of misunderstood command text, in intent
+// training file format
+func (s *Server) handleMisunderstood(w http.ResponseWriter, r *http.Request) {
+	monitor := diagnostics.GetMonitor()
+	if monitor == nil {
+		writeError(w, http.StatusServiceUnavailable, "diagnostics not running")
+		return
+	}
+	entries, err := monitor.Misunderstood()
+	if err != nil {
+		writeErr(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, entries)
+}
+
+// motorState converts motion motor to API representation
+func motorState(m motion.Motor) MotorState {
+	return MotorState{
+		ID:          string(m.ID),
+		Type:        m.Type.String(),
+		Position:    m.Position,
+		Speed:       m.Speed,
+		MaxSpeed:    m.MaxSpeed,
+		MinPosition: m.MinPosition,
+		MaxPosition: m.MaxPosition,
+		Target:      m.Target,
+		Enabled:     m.IsEnabled,
+		Unit:        string(m.Frame.PositionUnit()),
+	}
+}
+
+// writeJSON encodes v as response body
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("Failed to write API response: %v", err)
+	}
+}
+
+// writeErr sends system error with status derived from its code
+func writeErr(w http.ResponseWriter, err error) {
+	code := errs.CodeOf(err)
+	status, ok := statusByCode[code]
+	if !ok {
+		status = http.StatusInternalServerError
+	}
+	writeJSON(w, status, errorResponse{Error: err.Error(), Code: code})
+}
+
+// writeError sends error as JSON body
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, errorResponse{Error: msg})
+}
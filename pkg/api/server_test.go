@@ -0,0 +1,121 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sashalind/sex-artifical-intelligence/pkg/clock"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/core"
+)
+
+func accessToken(user string, role core.Role) core.AccessToken {
+	sum := sha256.Sum256([]byte(user + "-token"))
+	return core.AccessToken{User: user, Role: role, SHA256: hex.EncodeToString(sum[:])}
+}
+
+func TestMotorRoutePermissions(t *testing.T) {
+	cfg := core.DefaultConfig()
+	cfg.Clock = clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	cfg.SelfTest.Disabled = true
+	cfg.Access.Tokens = []core.AccessToken{
+		accessToken("guest", core.RoleGuest),
+		accessToken("operator", core.RoleOperator),
+		accessToken("maintainer", core.RoleMaintainer),
+	}
+	sys, err := core.NewSystemWithConfig(cfg)
+	if err != nil {
+		t.Fatalf("NewSystemWithConfig: %v", err)
+	}
+	t.Cleanup(sys.Shutdown)
+	srv, err := NewServer(sys, "")
+	if err != nil {
+		t.Fatalf("NewServer: %v", err)
+	}
+
+	group := `{"commands": [{"id": "servo_1", "position": 10, "speed": 10}]}`
+	tests := []struct {
+		method, path, body, user string
+		want                     int
+	}{
+		{"POST", "/motors/group", group, "", http.StatusUnauthorized},
+		{"POST", "/motors/group", group, "guest", http.StatusNoContent},
+		{"POST", "/motors/servo_1/jog", `{"velocity": 10}`, "", http.StatusUnauthorized},
+		{"POST", "/motors/servo_1/jog", `{"velocity": 10}`, "guest", http.StatusNoContent},
+		{"DELETE", "/motors/servo_1/jog", "", "", http.StatusUnauthorized},
+		{"DELETE", "/motors/servo_1/jog", "", "guest", http.StatusNoContent},
+		{"POST", "/motors/servo_1/reset", "", "operator", http.StatusForbidden},
+		// simulated motor has no driver that could reset it
+		{"POST", "/motors/servo_1/reset", "", "maintainer", http.StatusConflict},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+		if tt.user != "" {
+			req.Header.Set("Authorization", "Bearer "+tt.user+"-token")
+		}
+		rec := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(rec, req)
+		if rec.Code != tt.want {
+			t.Errorf("%s %s as %q = %d %s, want %d", tt.method, tt.path, tt.user, rec.Code, rec.Body, tt.want)
+		}
+	}
+}
+
+func TestSessionAndHistoryPermissions(t *testing.T) {
+	cfg := core.DefaultConfig()
+	cfg.Clock = clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	cfg.SelfTest.Disabled = true
+	cfg.Access.Tokens = []core.AccessToken{
+		accessToken("anna", core.RoleGuest),
+		accessToken("ben", core.RoleGuest),
+		accessToken("operator", core.RoleOperator),
+	}
+	sys, err := core.NewSystemWithConfig(cfg)
+	if err != nil {
+		t.Fatalf("NewSystemWithConfig: %v", err)
+	}
+	t.Cleanup(sys.Shutdown)
+	srv, err := NewServer(sys, "")
+	if err != nil {
+		t.Fatalf("NewServer: %v", err)
+	}
+
+	do := func(method, path, body, user string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+user+"-token")
+		rec := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(rec, req)
+		return rec
+	}
+	startSession := func() string {
+		rec := do("POST", "/sessions", `{"name": "anna"}`, "anna")
+		var resp SessionResponse
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil || rec.Code != http.StatusCreated {
+			t.Fatalf("POST /sessions = %d %v", rec.Code, err)
+		}
+		return resp.ID
+	}
+
+	anna, other := startSession(), startSession()
+	tests := []struct {
+		method, path, user string
+		want               int
+	}{
+		{"DELETE", "/sessions/" + anna, "ben", http.StatusForbidden},
+		{"DELETE", "/sessions/" + anna, "anna", http.StatusNoContent},
+		{"DELETE", "/sessions/" + other, "operator", http.StatusNoContent},
+		{"GET", "/history", "anna", http.StatusForbidden},
+		{"GET", "/history", "operator", http.StatusOK},
+		{"GET", "/metrics/misunderstood", "anna", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		if rec := do(tt.method, tt.path, "", tt.user); rec.Code != tt.want {
+			t.Errorf("%s %s as %s = %d %s, want %d", tt.method, tt.path, tt.user, rec.Code, rec.Body, tt.want)
+		}
+	}
+}
@@ -1,10 +1,15 @@
 package behavior
 
 import (
-	"encoding/json"
+	"log"
 	"math"
 	"sync"
 	"time"
+
+	"github.com/sashalind/sex-artifical-intelligence/pkg/clock"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/core/event"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/health"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/storage"
 )
 
 // BehaviorType represents different types of behaviors
@@ -17,6 +22,9 @@ const (
 	BehaviorErratic    BehaviorType = "erratic"
 )
 
+// TopicStateChanged carries pattern that moved analyzer to new state
+var TopicStateChanged = event.NewTopic[BehaviorPattern]("behavior.state")
+
 // BehaviorPattern represents detected behavior pattern
 type BehaviorPattern struct {
 	Type       BehaviorType     `json:"type"`
@@ -31,6 +39,10 @@ type PatternMetrics struct {
 	Frequency    float64 `json:"frequency"`
 	Duration     float64 `json:"duration"`
 	Consistency  float64 `json:"consistency"`
+	
+	// mood of user commands, see AddInput
+	Sentiment    float64 `json:"sentiment"`
+	Urgency      float64 `json:"urgency"`
 }
 
 // Analyzer processes behavioral patterns
@@ -40,21 +52,51 @@ type Analyzer struct {
 	currentState BehaviorType
 	
 	// Analysis parameters
-	threshold    float64
-	windowSize   time.Duration
+	cfg          Config
+	
+	// Persistent session history, nil when running without storage
+	history      *storage.Table[BehaviorPattern]
+	lastErr      health.LastError
 	
 	// Channels for real-time processing
 	inputChan    chan PatternMetrics
 	done         chan struct{}
+	
+	clock        clock.Clock
+	bus          *event.Bus
+	
+	// mood of latest user command and when it was given
+	mood         InputMood
+	moodAt       time.Time
+}
+
+// InputMood is sentiment (-1 to 1) and urgency (0 to 1) of user command
+type InputMood struct {
+	Sentiment float64
+	Urgency   float64
 }
 
 // NewAnalyzer creates new behavior analysis system
 func NewAnalyzer() (*Analyzer, error) {
+	return NewAnalyzerWithClock(clock.Real)
+}
+
+// NewAnalyzerWithClock creates behavior analysis system driven by given clock
+func NewAnalyzerWithClock(clk clock.Clock) (*Analyzer, error) {
+	return NewAnalyzerWithConfig(clk, DefaultConfig())
+}
+
+// NewAnalyzerWithConfig creates behavior analysis system with given thresholds
+func NewAnalyzerWithConfig(clk clock.Clock, cfg Config) (*Analyzer, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	
 	a := &Analyzer{
+		clock:        clock.OrReal(clk),
 		patterns:     make([]BehaviorPattern, 0),
 		currentState: BehaviorNormal,
-		threshold:    0.75,
-		windowSize:   5 * time.Minute,
+		cfg:          cfg,
 		inputChan:    make(chan PatternMetrics, 100),
 		done:         make(chan struct{}),
 	}
@@ -66,7 +108,7 @@ func NewAnalyzer() (*Analyzer, error) {
 
 // processPatterns analyzes incoming behavioral data
 func (a *Analyzer) processPatterns() {
-	ticker := time.NewTicker(time.Second)
+	ticker := a.clock.NewTicker(time.Second)
 	defer ticker.Stop()
 	
 	var buffer []PatternMetrics
@@ -78,7 +120,7 @@ func (a *Analyzer) processPatterns() {
 			if len(buffer) > 60 { // Keep last minute of data
 				buffer = buffer[1:]
 			}
-		case <-ticker.C:
+		case <-ticker.C():
 			if len(buffer) > 0 {
 				pattern := a.analyzeBuffer(buffer)
 				a.addPattern(pattern)
@@ -95,7 +137,7 @@ func (a *Analyzer) analyzeBuffer(buffer []PatternMetrics) BehaviorPattern {
 		return BehaviorPattern{
 			Type:       BehaviorNormal,
 			Confidence: 1.0,
-			Timestamp:  time.Now(),
+			Timestamp:  a.clock.Now(),
 		}
 	}
 	
@@ -115,35 +157,64 @@ func (a *Analyzer) analyzeBuffer(buffer []PatternMetrics) BehaviorPattern {
 	avgConsistency /= n
 	
 	// Determine behavior type based on metrics
+	mood := a.currentMood()
 	behaviorType := a.classifyBehavior(avgIntensity, avgFrequency)
 	confidence := a.calculateConfidence(avgConsistency)
+	if a.distressed(mood) {
+		// user said so, sensors need not agree
+		behaviorType = BehaviorAggressive
+		confidence = max(confidence, mood.Urgency)
+	}
 	
 	return BehaviorPattern{
 		Type:       behaviorType,
 		Confidence: confidence,
-		Timestamp:  time.Now(),
+		Timestamp:  a.clock.Now(),
 		Metrics: PatternMetrics{
 			Intensity:    avgIntensity,
 			Frequency:    avgFrequency,
 			Duration:     avgDuration,
 			Consistency:  avgConsistency,
+			Sentiment:    mood.Sentiment,
+			Urgency:      mood.Urgency,
 		},
 	}
 }
 
 // classifyBehavior determines behavior type from metrics
 func (a *Analyzer) classifyBehavior(intensity, frequency float64) BehaviorType {
+	// thresholds may be reloaded concurrently
+	cfg := a.Config()
+	
 	// Simple classification based on intensity and frequency
-	if intensity > 0.8 && frequency > 0.8 {
+	if intensity > cfg.AggressiveLevel && frequency > cfg.AggressiveLevel {
 		return BehaviorAggressive
-	} else if intensity < 0.2 && frequency < 0.2 {
+	} else if intensity < cfg.PassiveLevel && frequency < cfg.PassiveLevel {
 		return BehaviorPassive
-	} else if math.Abs(intensity-frequency) > 0.5 {
+	} else if math.Abs(intensity-frequency) > cfg.ErraticSpread {
 		return BehaviorErratic
 	}
 	return BehaviorNormal
 }
 
+// currentMood returns mood of latest user command, neutral once it is
+// older than analysis window
+func (a *Analyzer) currentMood() InputMood {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if a.moodAt.IsZero() || a.clock.Since(a.moodAt) > a.cfg.WindowSize {
+		return InputMood{}
+	}
+	return a.mood
+}
+
+// distressed reports urgent negative input, which makes interaction rough
+// whatever sensors say
+func (a *Analyzer) distressed(mood InputMood) bool {
+	level := a.Config().DistressUrgency
+	return level > 0 && mood.Sentiment < 0 && mood.Urgency >= level
+}
+
 // calculateConfidence determines confidence level
 func (a *Analyzer) calculateConfidence(consistency float64) float64 {
 	// Simple linear confidence based on consistency
@@ -162,14 +233,77 @@ func (a *Analyzer) addPattern(pattern BehaviorPattern) {
 	defer a.mu.Unlock()
 	
 	a.patterns = append(a.patterns, pattern)
-	if len(a.patterns) > 1000 {
+	if len(a.patterns) > a.cfg.HistorySize {
 		a.patterns = a.patterns[1:]
 	}
 	
+	if a.history != nil {
+		if _, err := a.history.Append(pattern); err != nil {
+			a.lastErr.Set(err)
+			log.Printf("Failed to persist behavior pattern: %v", err)
+		} else {
+			a.history.Trim(a.cfg.HistorySize)
+		}
+	}
+	
 	// Update current state if confidence is high enough
-	if pattern.Confidence >= a.threshold {
+	if pattern.Confidence >= a.cfg.Threshold && pattern.Type != a.currentState {
 		a.currentState = pattern.Type
+		event.Publish(a.bus, TopicStateChanged, pattern)
+	}
+}
+
+// AttachStore enables persistent behavior history and restores previous sessions
+func (a *Analyzer) AttachStore(store *storage.Store) error {
+	history, err := storage.OpenTable[BehaviorPattern](store, storage.BucketBehavior)
+	if err != nil {
+		return err
+	}
+	
+	patterns, err := history.All()
+	if err != nil {
+		return err
+	}
+	
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	
+	a.history = history
+	a.patterns = append(patterns, a.patterns...)
+	if len(a.patterns) > a.cfg.HistorySize {
+		a.patterns = a.patterns[len(a.patterns)-a.cfg.HistorySize:]
 	}
+	return nil
+}
+
+// AttachBus starts publishing state changes to event bus
+func (a *Analyzer) AttachBus(bus *event.Bus) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.bus = bus
+}
+
+// Config returns current analysis thresholds
+func (a *Analyzer) Config() Config {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.cfg
+}
+
+// SetConfig replaces analysis thresholds at runtime
+func (a *Analyzer) SetConfig(cfg Config) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	
+	a.cfg = cfg
+	if len(a.patterns) > cfg.HistorySize {
+		a.patterns = a.patterns[len(a.patterns)-cfg.HistorySize:]
+	}
+	return nil
 }
 
 // GetCurrentState returns current behavior state
@@ -190,13 +324,67 @@ func (a *Analyzer) GetPatternHistory() []BehaviorPattern {
 	return patterns
 }
 
-// AddMetrics adds new behavioral metrics for analysis
+// Restore replaces current state and pattern history, used when loading snapshots
+func (a *Analyzer) Restore(state BehaviorType, patterns []BehaviorPattern) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	
+	a.currentState = state
+	a.patterns = make([]BehaviorPattern, len(patterns))
+	copy(a.patterns, patterns)
+	if len(a.patterns) > a.cfg.HistorySize {
+		a.patterns = a.patterns[len(a.patterns)-a.cfg.HistorySize:]
+	}
+}
+
+// AddMetrics adds new behavioral metrics for analysis, dropped once
+// analyzer is shut down
 func (a *Analyzer) AddMetrics(metrics PatternMetrics) {
-	a.inputChan <- metrics
+	select {
+	case a.inputChan <- metrics:
+	case <-a.done:
+	}
+}
+
+// AddInput records mood of user command, analysis takes it into account
+// for rest of window
+func (a *Analyzer) AddInput(mood InputMood) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.mood = mood
+	a.moodAt = a.clock.Now()
+}
+
+// Health reports whether analysis runs, last persistence error and
+// pattern gauges
+func (a *Analyzer) Health() health.Report {
+	status := health.OK
+	select {
+	case <-a.done:
+		status = health.Down
+	default:
+	}
+	
+	a.mu.RLock()
+	patterns := len(a.patterns)
+	confidence := 0.0
+	if patterns > 0 {
+		confidence = a.patterns[patterns-1].Confidence
+	}
+	a.mu.RUnlock()
+	
+	return health.Report{
+		Status:    status,
+		LastError: a.lastErr.String(),
+		Gauges: map[string]float64{
+			"patterns":        float64(patterns),
+			"confidence":      confidence,
+			"pending_metrics": float64(len(a.inputChan)),
+		},
+	}
 }
 
 // Shutdown stops behavior analysis
 func (a *Analyzer) Shutdown() {
 	close(a.done)
-	close(a.inputChan)
 } 
\ No newline at end of file
@@ -0,0 +1,64 @@
+package behavior
+
+import (
+	"errors"
+	"time"
+)
+
+// Config holds behavior classification thresholds
+type Config struct {
+	// Threshold is confidence required before current state changes
+	Threshold float64
+
+	// WindowSize is span of metrics considered for analysis
+	WindowSize time.Duration
+
+	// HistorySize is number of detected patterns kept
+	HistorySize int
+
+	// AggressiveLevel: intensity and frequency both above it mean aggressive
+	AggressiveLevel float64
+
+	// PassiveLevel: intensity and frequency both below it mean passive
+	PassiveLevel float64
+
+	// ErraticSpread: intensity and frequency further apart than it mean erratic
+	ErraticSpread float64
+	
+	// DistressUrgency: negative user input at least this urgent means
+	// aggressive whatever sensors say, zero ignores input mood
+	DistressUrgency float64
+}
+
+// DefaultConfig returns thresholds tuned for reference build
+func DefaultConfig() Config {
+	return Config{
+		Threshold:       0.75,
+		WindowSize:      5 * time.Minute,
+		HistorySize:     1000,
+		AggressiveLevel: 0.8,
+		PassiveLevel:    0.2,
+		ErraticSpread:   0.5,
+		DistressUrgency: 0.7,
+	}
+}
+
+// Validate checks thresholds are consistent
+func (c Config) Validate() error {
+	if c.Threshold < 0 || c.Threshold > 1 {
+		return errors.New("behavior threshold must be within 0..1")
+	}
+	if c.HistorySize <= 0 {
+		return errors.New("behavior history size must be positive")
+	}
+	if c.PassiveLevel >= c.AggressiveLevel {
+		return errors.New("passive level must be below aggressive level")
+	}
+	if c.ErraticSpread <= 0 {
+		return errors.New("erratic spread must be positive")
+	}
+	if c.DistressUrgency < 0 || c.DistressUrgency > 1 {
+		return errors.New("distress urgency must be within 0..1")
+	}
+	return nil
+}
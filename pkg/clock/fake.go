@@ -0,0 +1,171 @@
+package clock
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+// Fake is manually advanced clock. Tickers, timers and sleepers fire only
+// when Advance moves time past their deadline, so time-dependent logic
+// runs deterministically and as fast as the test wants.
+type Fake struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []*waiter
+}
+
+// waiter is pending timer, sleeper or ticker
+type waiter struct {
+	deadline time.Time
+	period   time.Duration // zero for one-shot timers
+	ch       chan time.Time
+	stopped  bool
+}
+
+// NewFake creates fake clock starting at given time
+func NewFake(start time.Time) *Fake {
+	return &Fake{now: start}
+}
+
+// Now returns fake current time
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+// Since returns fake time elapsed since t
+func (f *Fake) Since(t time.Time) time.Duration {
+	return f.Now().Sub(t)
+}
+
+// After fires once when clock is advanced by d
+func (f *Fake) After(d time.Duration) <-chan time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	w := &waiter{deadline: f.now.Add(d), ch: make(chan time.Time, 1)}
+	f.waiters = append(f.waiters, w)
+	return w.ch
+}
+
+// Sleep blocks until clock is advanced by d
+func (f *Fake) Sleep(d time.Duration) {
+	<-f.After(d)
+}
+
+// NewTicker creates ticker firing every d of fake time
+func (f *Fake) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("clock: non-positive interval for NewTicker")
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	w := &waiter{deadline: f.now.Add(d), period: d, ch: make(chan time.Time, 1)}
+	f.waiters = append(f.waiters, w)
+	return &fakeTicker{clock: f, w: w}
+}
+
+// Advance moves time forward, firing every deadline passed on the way in order
+func (f *Fake) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	target := f.now.Add(d)
+	for {
+		next := f.nextWaiter(target)
+		if next == nil {
+			break
+		}
+
+		f.now = next.deadline
+		select {
+		case next.ch <- f.now:
+		default:
+			// slow receiver drops ticks, same as time.Ticker
+		}
+
+		if next.period > 0 {
+			next.deadline = next.deadline.Add(next.period)
+		} else {
+			next.stopped = true
+		}
+	}
+
+	f.now = target
+	f.prune()
+}
+
+// Set jumps clock to t, firing deadlines in between
+func (f *Fake) Set(t time.Time) {
+	f.Advance(t.Sub(f.Now()))
+}
+
+// Waiters returns number of active timers and tickers, handy to wait
+// until goroutine under test has reached its ticker before advancing
+func (f *Fake) Waiters() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.prune()
+	return len(f.waiters)
+}
+
+// Pending returns number of tickers whose last tick was not received yet.
+// Once it is zero every goroutine reading ticker has taken time Advance
+// gave it and finished with tick before.
+func (f *Fake) Pending() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	n := 0
+	for _, w := range f.waiters {
+		if !w.stopped && w.period > 0 && len(w.ch) > 0 {
+			n++
+		}
+	}
+	return n
+}
+
+// nextWaiter returns earliest active waiter due at or before target
+func (f *Fake) nextWaiter(target time.Time) *waiter {
+	// unlike sort.SliceStable, does not allocate, so Advance can pace
+	// allocation-free hot paths in tests
+	slices.SortStableFunc(f.waiters, func(a, b *waiter) int {
+		return a.deadline.Compare(b.deadline)
+	})
+	for _, w := range f.waiters {
+		if w.stopped {
+			continue
+		}
+		if w.deadline.After(target) {
+			return nil
+		}
+		return w
+	}
+	return nil
+}
+
+func (f *Fake) prune() {
+	active := f.waiters[:0]
+	for _, w := range f.waiters {
+		if !w.stopped {
+			active = append(active, w)
+		}
+	}
+	f.waiters = active
+}
+
+type fakeTicker struct {
+	clock *Fake
+	w     *waiter
+}
+
+func (t *fakeTicker) C() <-chan time.Time { return t.w.ch }
+
+func (t *fakeTicker) Stop() {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	t.w.stopped = true
+}
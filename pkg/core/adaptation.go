@@ -0,0 +1,155 @@
+package core
+
+import (
+	"fmt"
+	"log"
+	"sync"
+
+	"github.com/sashalind/sex-artifical-intelligence/pkg/behavior"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/core/event"
+)
+
+// AdaptationPolicy is motion adjustment applied while behavior is in state
+type AdaptationPolicy struct {
+	// SpeedScale multiplies speed of movement commands, 0 means 1
+	SpeedScale float64 `json:"speed_scale"`
+
+	// Pattern is started on primary unit when state is entered, if system
+	// is active
+	Pattern string `json:"pattern,omitempty"`
+}
+
+// AdaptationConfig maps behavior states to motion adjustments
+type AdaptationConfig struct {
+	Enabled bool `json:"enabled"`
+
+	// MinConfidence ignores classifications analyzer is unsure about
+	MinConfidence float64 `json:"min_confidence"`
+
+	Policies map[behavior.BehaviorType]AdaptationPolicy `json:"policies"`
+}
+
+// maxSpeedScale bounds policy speed boost, motor limits still apply after it
+const maxSpeedScale = 2.0
+
+// DefaultAdaptationConfig slows down when interaction gets rough or erratic
+// and speeds up slightly when it is passive
+func DefaultAdaptationConfig() AdaptationConfig {
+	return AdaptationConfig{
+		MinConfidence: 0.6,
+		Policies: map[behavior.BehaviorType]AdaptationPolicy{
+			behavior.BehaviorNormal:     {SpeedScale: 1.0},
+			behavior.BehaviorPassive:    {SpeedScale: 1.2},
+			behavior.BehaviorAggressive: {SpeedScale: 0.5},
+			behavior.BehaviorErratic:    {SpeedScale: 0.7},
+		},
+	}
+}
+
+// Validate checks policy table
+func (c AdaptationConfig) Validate() error {
+	if c.MinConfidence < 0 || c.MinConfidence > 1 {
+		return fmt.Errorf("adaptation min_confidence must be within [0, 1]")
+	}
+	for state, p := range c.Policies {
+		switch state {
+		case behavior.BehaviorNormal, behavior.BehaviorPassive, behavior.BehaviorAggressive, behavior.BehaviorErratic:
+		default:
+			return fmt.Errorf("adaptation policy for unknown behavior %q", state)
+		}
+		if p.SpeedScale < 0 || p.SpeedScale > maxSpeedScale {
+			return fmt.Errorf("adaptation policy %s: speed_scale must be within [0, %g]", state, maxSpeedScale)
+		}
+	}
+	return nil
+}
+
+// AdaptationState is what adaptation loop currently applies
+type AdaptationState struct {
+	Enabled    bool                  `json:"enabled"`
+	Behavior   behavior.BehaviorType `json:"behavior"`
+	SpeedScale float64               `json:"speed_scale"`
+}
+
+// adaptation turns behavior state changes into motion adjustments
+type adaptation struct {
+	mu    sync.RWMutex
+	cfg   AdaptationConfig
+	state behavior.BehaviorType
+}
+
+// setConfig swaps policy table, current state keeps applying under new table
+func (a *adaptation) setConfig(cfg AdaptationConfig) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.cfg = cfg
+}
+
+// speedScale returns factor for movement speed
+func (a *adaptation) speedScale() float64 {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.scaleLocked()
+}
+
+func (a *adaptation) scaleLocked() float64 {
+	if !a.cfg.Enabled {
+		return 1
+	}
+	if p, ok := a.cfg.Policies[a.state]; ok && p.SpeedScale > 0 {
+		return p.SpeedScale
+	}
+	return 1
+}
+
+// enter records new behavior state, returning pattern to start if any
+func (a *adaptation) enter(p behavior.BehaviorPattern) (string, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if !a.cfg.Enabled || p.Confidence < a.cfg.MinConfidence || p.Type == a.state {
+		return "", false
+	}
+	a.state = p.Type
+	return a.cfg.Policies[p.Type].Pattern, true
+}
+
+// runAdaptation applies policy on every behavior state change
+func (s *System) runAdaptation() {
+	states := event.Subscribe(s.bus, behavior.TopicStateChanged, 16)
+	defer states.Cancel()
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case p, ok := <-states.C:
+			if !ok {
+				return
+			}
+			pattern, changed := s.adaptation.enter(p)
+			if !changed {
+				continue
+			}
+			log.Printf("Adapting motion to %s behavior, speed x%.2f", p.Type, s.adaptation.speedScale())
+			if pattern == "" || s.Mode() != ModeActive {
+				continue
+			}
+			if err := s.motionCtrl.ExecutePattern(s.ctx, pattern); err != nil {
+				log.Printf("Adaptation pattern %s failed: %v", pattern, err)
+			}
+		}
+	}
+}
+
+// Adaptation reports current motion adaptation
+func (s *System) Adaptation() AdaptationState {
+	s.adaptation.mu.RLock()
+	defer s.adaptation.mu.RUnlock()
+
+	return AdaptationState{
+		Enabled:    s.adaptation.cfg.Enabled,
+		Behavior:   s.adaptation.state,
+		SpeedScale: s.adaptation.scaleLocked(),
+	}
+}
@@ -0,0 +1,215 @@
+package core
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/sashalind/sex-artifical-intelligence/pkg/core/errs"
+)
+
+var (
+	ErrUnauthenticated  = errs.New(errs.Unauthenticated, "authentication required")
+	ErrPermissionDenied = errs.New(errs.PermissionDenied, "permission denied")
+)
+
+// Role is run level of caller, higher roles include lower ones
+type Role int
+
+const (
+	RoleGuest Role = iota
+	RoleOperator
+	RoleMaintainer
+)
+
+var roleNames = map[Role]string{
+	RoleGuest:      "guest",
+	RoleOperator:   "operator",
+	RoleMaintainer: "maintainer",
+}
+
+// String returns role name
+func (r Role) String() string {
+	if name, ok := roleNames[r]; ok {
+		return name
+	}
+	return fmt.Sprintf("Role(%d)", int(r))
+}
+
+// MarshalText writes role as name
+func (r Role) MarshalText() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+// UnmarshalText reads role name
+func (r *Role) UnmarshalText(text []byte) error {
+	v, err := ParseRole(string(text))
+	if err != nil {
+		return err
+	}
+	*r = v
+	return nil
+}
+
+// ParseRole converts name into role
+func ParseRole(name string) (Role, error) {
+	for r, n := range roleNames {
+		if strings.EqualFold(n, name) {
+			return r, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown role %q", name)
+}
+
+// Permission is operation class checked by Authorize
+type Permission string
+
+const (
+	// PermCommand allows issuing commands and opening sessions
+	PermCommand Permission = "command"
+	// PermConfigure allows changing modes, routines, scripts and profiles
+	PermConfigure Permission = "configure"
+	// PermOverrideLimits allows loosening speed and intensity caps
+	PermOverrideLimits Permission = "override_limits"
+	// PermCalibrate allows changing motor and sensor calibration
+	PermCalibrate Permission = "calibrate"
+	// PermFirmware allows updating and resetting hardware firmware
+	PermFirmware Permission = "firmware"
+)
+
+// minRole is lowest role holding permission
+var minRole = map[Permission]Role{
+	PermCommand:        RoleGuest,
+	PermConfigure:      RoleOperator,
+	PermOverrideLimits: RoleMaintainer,
+	PermCalibrate:      RoleMaintainer,
+	PermFirmware:       RoleMaintainer,
+}
+
+// Can reports whether role holds permission
+func (r Role) Can(p Permission) bool {
+	need, ok := minRole[p]
+	return ok && r >= need
+}
+
+// Principal is authenticated caller
+type Principal struct {
+	User string `json:"user"`
+	Role Role   `json:"role"`
+}
+
+// Authenticator turns credentials presented by remote client, e.g. bearer
+// token, into principal. Implementations return ErrUnauthenticated for
+// unknown credentials.
+type Authenticator interface {
+	Authenticate(ctx context.Context, token string) (Principal, error)
+}
+
+// AccessConfig lists API tokens. Empty list and no custom authenticator
+// leaves API open, as on single-user device without network exposure.
+type AccessConfig struct {
+	Tokens []AccessToken `json:"tokens"`
+}
+
+// AccessToken grants role to holder of token. Only SHA-256 of token is
+// kept in config.
+type AccessToken struct {
+	User   string `json:"user"`
+	Role   Role   `json:"role"`
+	SHA256 string `json:"sha256"`
+}
+
+// Validate checks token entries
+func (c AccessConfig) Validate() error {
+	for _, t := range c.Tokens {
+		if t.User == "" {
+			return fmt.Errorf("access token without user")
+		}
+		if _, ok := roleNames[t.Role]; !ok {
+			return fmt.Errorf("access token of %s: invalid role", t.User)
+		}
+		if b, err := hex.DecodeString(t.SHA256); err != nil || len(b) != sha256.Size {
+			return fmt.Errorf("access token of %s: sha256 must be 64 hex digits", t.User)
+		}
+	}
+	return nil
+}
+
+// tokenAuthenticator checks tokens against AccessConfig hashes
+type tokenAuthenticator struct {
+	tokens []AccessToken
+}
+
+func (a tokenAuthenticator) Authenticate(_ context.Context, token string) (Principal, error) {
+	sum := sha256.Sum256([]byte(token))
+	for _, t := range a.tokens {
+		want, _ := hex.DecodeString(t.SHA256)
+		if subtle.ConstantTimeCompare(sum[:], want) == 1 {
+			return Principal{User: t.User, Role: t.Role}, nil
+		}
+	}
+	return Principal{}, ErrUnauthenticated
+}
+
+// authenticator returns configured authenticator, nil when API is open
+func (c Config) authenticator() Authenticator {
+	if c.Authenticator != nil {
+		return c.Authenticator
+	}
+	if len(c.Access.Tokens) > 0 {
+		return tokenAuthenticator{tokens: c.Access.Tokens}
+	}
+	return nil
+}
+
+// AuthRequired reports whether remote callers must authenticate
+func (s *System) AuthRequired() bool {
+	return s.auth != nil
+}
+
+// Authenticate checks credentials of remote caller. Without authenticator
+// every caller is maintainer.
+func (s *System) Authenticate(ctx context.Context, token string) (Principal, error) {
+	if s.auth == nil {
+		return Principal{Role: RoleMaintainer}, nil
+	}
+	if token == "" {
+		return Principal{}, ErrUnauthenticated
+	}
+	return s.auth.Authenticate(ctx, token)
+}
+
+// callerRole returns role operation in ctx runs with. Principal wins over
+// session; system's own work, e.g. scheduler, runs as maintainer.
+func (s *System) callerRole(ctx context.Context) (Role, bool) {
+	if p, ok := PrincipalFromContext(ctx); ok {
+		return p.Role, true
+	}
+	if sess := s.commandSession(ctx); sess != nil {
+		return sess.Role, true
+	}
+	if isSystemContext(ctx) {
+		return RoleMaintainer, true
+	}
+	return 0, false
+}
+
+// Authorize rejects operation unless caller in ctx holds permission.
+// Caller without principal or session is let through only while API is
+// open.
+func (s *System) Authorize(ctx context.Context, p Permission) error {
+	role, ok := s.callerRole(ctx)
+	if !ok {
+		if s.auth == nil {
+			return nil
+		}
+		return ErrUnauthenticated
+	}
+	if role.Can(p) {
+		return nil
+	}
+	return fmt.Errorf("%w: %s may not %s", ErrPermissionDenied, role, p)
+}
@@ -0,0 +1,201 @@
+package core
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sashalind/sex-artifical-intelligence/pkg/clock"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/motion"
+)
+
+func tokenHash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// newAuthSystem starts system on fake clock, tokens secure its API
+func newAuthSystem(t *testing.T, tokens ...AccessToken) *System {
+	t.Helper()
+	cfg := DefaultConfig()
+	cfg.Clock = clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	cfg.SelfTest.Disabled = true
+	cfg.Access.Tokens = tokens
+	sys, err := NewSystemWithConfig(cfg)
+	if err != nil {
+		t.Fatalf("NewSystemWithConfig: %v", err)
+	}
+	t.Cleanup(sys.Shutdown)
+	return sys
+}
+
+func TestRoleCan(t *testing.T) {
+	tests := []struct {
+		role Role
+		perm Permission
+		want bool
+	}{
+		{RoleGuest, PermCommand, true},
+		{RoleGuest, PermConfigure, false},
+		{RoleOperator, PermConfigure, true},
+		{RoleOperator, PermOverrideLimits, false},
+		{RoleOperator, PermCalibrate, false},
+		{RoleOperator, PermFirmware, false},
+		{RoleMaintainer, PermCalibrate, true},
+		{RoleMaintainer, PermFirmware, true},
+		{RoleMaintainer, Permission("unknown"), false},
+	}
+	for _, tt := range tests {
+		if got := tt.role.Can(tt.perm); got != tt.want {
+			t.Errorf("%s.Can(%s) = %v, want %v", tt.role, tt.perm, got, tt.want)
+		}
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+	open := newAuthSystem(t)
+	secured := newAuthSystem(t, AccessToken{User: "anna", Role: RoleOperator, SHA256: tokenHash("s3cret")})
+
+	guest := WithPrincipal(context.Background(), Principal{User: "guest", Role: RoleGuest})
+	sess, err := secured.StartSession(guest, "guest")
+	if err != nil {
+		t.Fatalf("StartSession: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		sys  *System
+		ctx  context.Context
+		perm Permission
+		want error
+	}{
+		{"open api without caller", open, context.Background(), PermFirmware, nil},
+		{"secured api without caller", secured, context.Background(), PermCommand, ErrUnauthenticated},
+		{"unknown session", secured, WithSession(context.Background(), "nope"), PermCommand, ErrUnauthenticated},
+		{"guest command", secured, guest, PermCommand, nil},
+		{"guest configure", secured, guest, PermConfigure, ErrPermissionDenied},
+		{"operator calibrate", secured, WithPrincipal(context.Background(), Principal{Role: RoleOperator}), PermCalibrate, ErrPermissionDenied},
+		{"maintainer firmware", secured, WithPrincipal(context.Background(), Principal{Role: RoleMaintainer}), PermFirmware, nil},
+		{"session role", secured, WithSession(context.Background(), sess.ID), PermConfigure, ErrPermissionDenied},
+		{"principal wins over session", secured, WithSession(WithPrincipal(context.Background(), Principal{Role: RoleOperator}), sess.ID), PermConfigure, nil},
+		{"system", secured, systemContext(context.Background()), PermFirmware, nil},
+		{"scheduled routine", secured, secured.ctx, PermCommand, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.sys.Authorize(tt.ctx, tt.perm)
+			if tt.want == nil && err != nil || tt.want != nil && !errors.Is(err, tt.want) {
+				t.Errorf("Authorize(%s) = %v, want %v", tt.perm, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	secured := newAuthSystem(t,
+		AccessToken{User: "anna", Role: RoleOperator, SHA256: tokenHash("s3cret")},
+		AccessToken{User: "max", Role: RoleMaintainer, SHA256: tokenHash("t00ls")})
+
+	tests := []struct {
+		name  string
+		sys   *System
+		token string
+		want  Principal
+		err   error
+	}{
+		{"open api", newAuthSystem(t), "", Principal{Role: RoleMaintainer}, nil},
+		{"operator", secured, "s3cret", Principal{User: "anna", Role: RoleOperator}, nil},
+		{"maintainer", secured, "t00ls", Principal{User: "max", Role: RoleMaintainer}, nil},
+		{"missing token", secured, "", Principal{}, ErrUnauthenticated},
+		{"wrong token", secured, "guess", Principal{}, ErrUnauthenticated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.sys.Authenticate(context.Background(), tt.token)
+			if !errors.Is(err, tt.err) || got != tt.want {
+				t.Errorf("Authenticate(%q) = %v, %v, want %v, %v", tt.token, got, err, tt.want, tt.err)
+			}
+		})
+	}
+}
+
+func TestAccessConfigValidate(t *testing.T) {
+	tests := []struct {
+		name  string
+		token AccessToken
+		ok    bool
+	}{
+		{"valid", AccessToken{User: "anna", Role: RoleGuest, SHA256: tokenHash("x")}, true},
+		{"no user", AccessToken{Role: RoleGuest, SHA256: tokenHash("x")}, false},
+		{"bad role", AccessToken{User: "anna", Role: Role(7), SHA256: tokenHash("x")}, false},
+		{"short hash", AccessToken{User: "anna", SHA256: "abcd"}, false},
+		{"not hex", AccessToken{User: "anna", SHA256: "s3cret"}, false},
+	}
+	for _, tt := range tests {
+		err := AccessConfig{Tokens: []AccessToken{tt.token}}.Validate()
+		if (err == nil) != tt.ok {
+			t.Errorf("%s: Validate() = %v, want ok %v", tt.name, err, tt.ok)
+		}
+	}
+}
+
+func TestResetMotorNeedsFirmwarePermission(t *testing.T) {
+	sys := newAuthSystem(t, AccessToken{User: "anna", Role: RoleOperator, SHA256: tokenHash("s3cret")})
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want error
+	}{
+		{"no caller", context.Background(), ErrUnauthenticated},
+		{"operator", WithPrincipal(context.Background(), Principal{Role: RoleOperator}), ErrPermissionDenied},
+		// simulated motors have no driver that could reset them
+		{"maintainer", WithPrincipal(context.Background(), Principal{Role: RoleMaintainer}), motion.ErrNoReset},
+	}
+	for _, tt := range tests {
+		if err := sys.ResetMotor(tt.ctx, "", "servo_1"); !errors.Is(err, tt.want) {
+			t.Errorf("%s: ResetMotor = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestManageSessionsOfOthers(t *testing.T) {
+	sys := newAuthSystem(t, AccessToken{User: "anna", Role: RoleOperator, SHA256: tokenHash("s3cret")})
+	anna := WithPrincipal(context.Background(), Principal{User: "anna", Role: RoleGuest})
+	ben := WithPrincipal(context.Background(), Principal{User: "ben", Role: RoleGuest})
+	max := WithPrincipal(context.Background(), Principal{User: "max", Role: RoleOperator})
+
+	tests := []struct {
+		name string
+		ctx  func(SessionID) context.Context
+		want error
+	}{
+		{"no caller", func(SessionID) context.Context { return context.Background() }, ErrUnauthenticated},
+		{"other guest", func(SessionID) context.Context { return ben }, ErrPermissionDenied},
+		{"owner", func(SessionID) context.Context { return anna }, nil},
+		{"session itself", func(id SessionID) context.Context { return WithSession(context.Background(), id) }, nil},
+		{"operator", func(SessionID) context.Context { return max }, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sess, err := sys.StartSession(anna, "anna")
+			if err != nil {
+				t.Fatalf("StartSession: %v", err)
+			}
+			if err := sys.ActivateSession(tt.ctx(sess.ID), sess.ID); tt.want == nil && err != nil || tt.want != nil && !errors.Is(err, tt.want) {
+				t.Errorf("ActivateSession = %v, want %v", err, tt.want)
+			}
+			err = sys.EndSession(tt.ctx(sess.ID), sess.ID)
+			if tt.want == nil && err != nil || tt.want != nil && !errors.Is(err, tt.want) {
+				t.Fatalf("EndSession = %v, want %v", err, tt.want)
+			}
+			_, err = sys.Session(sess.ID)
+			if ended := errors.Is(err, ErrSessionNotFound); ended != (tt.want == nil) {
+				t.Errorf("session ended = %v, want %v", ended, tt.want == nil)
+			}
+		})
+	}
+}
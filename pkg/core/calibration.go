@@ -0,0 +1,88 @@
+package core
+
+import (
+	"context"
+	"time"
+
+	"github.com/sashalind/sex-artifical-intelligence/pkg/motion"
+)
+
+// MotorCalibration is calibration of one motor as reported by API
+type MotorCalibration struct {
+	Motor   string     `json:"motor"`
+	Offset  float64    `json:"offset"`
+	SoftMin float64    `json:"soft_min,omitempty"`
+	SoftMax float64    `json:"soft_max,omitempty"`
+	HomedAt *time.Time `json:"homed_at,omitempty"`
+}
+
+func motorCalibration(cal motion.Calibration) MotorCalibration {
+	mc := MotorCalibration{
+		Motor:   string(cal.Motor),
+		Offset:  cal.Offset,
+		SoftMin: cal.SoftMin,
+		SoftMax: cal.SoftMax,
+	}
+	if !cal.HomedAt.IsZero() {
+		mc.HomedAt = &cal.HomedAt
+	}
+	return mc
+}
+
+// HomeMotor runs homing of motor on unit and returns new calibration,
+// empty unit means primary one. Blocks until motor found its reference.
+func (s *System) HomeMotor(ctx context.Context, unit UnitID, id string) (MotorCalibration, error) {
+	var cal motion.Calibration
+	err := s.moveUnit(unit, func(u *Unit) error {
+		calibrator, ok := u.motion.(motorCalibrator)
+		if !ok {
+			return ErrNotSupported
+		}
+		var err error
+		cal, err = calibrator.Home(ctx, motion.MotorID(id))
+		return err
+	})
+	if err != nil {
+		return MotorCalibration{}, err
+	}
+	return motorCalibration(cal), nil
+}
+
+// Calibrations lists calibration of every motor of unit
+func (s *System) Calibrations(unit UnitID) ([]MotorCalibration, error) {
+	calibrator, err := s.calibrator(unit)
+	if err != nil {
+		return nil, err
+	}
+	cals := []MotorCalibration{}
+	for _, cal := range calibrator.Calibrations() {
+		cals = append(cals, motorCalibration(cal))
+	}
+	return cals, nil
+}
+
+// SetSoftLimits changes calibrated travel of motor on unit, equal min and
+// max remove soft limits
+func (s *System) SetSoftLimits(unit UnitID, id string, min, max float64) error {
+	calibrator, err := s.calibrator(unit)
+	if err != nil {
+		return err
+	}
+	return calibrator.SetSoftLimits(motion.MotorID(id), min, max)
+}
+
+// calibrator returns calibration feature of unit motion
+func (s *System) calibrator(unit UnitID) (motorCalibrator, error) {
+	if unit == "" {
+		unit = PrimaryUnit
+	}
+	u, err := s.Unit(unit)
+	if err != nil {
+		return nil, err
+	}
+	calibrator, ok := u.motion.(motorCalibrator)
+	if !ok {
+		return nil, ErrNotSupported
+	}
+	return calibrator, nil
+}
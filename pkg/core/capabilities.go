@@ -0,0 +1,95 @@
+package core
+
+import (
+	"sort"
+
+	"github.com/sashalind/sex-artifical-intelligence/pkg/nlp"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/sensor"
+)
+
+// Capabilities describes what this build can do, for clients that adapt
+// their UI to hardware instead of hardcoding it
+type Capabilities struct {
+	Units     []UnitCapabilities `json:"units"`
+	Commands  []nlp.CommandType  `json:"commands"`
+	Modes     []string           `json:"modes"`
+	Simulated bool               `json:"simulated"`
+}
+
+// UnitCapabilities lists hardware of one unit
+type UnitCapabilities struct {
+	ID       UnitID              `json:"id"`
+	Motors   []MotorCapability   `json:"motors"`
+	Sensors  []sensor.SensorType `json:"sensors"`
+	Patterns []PatternCapability `json:"patterns"`
+}
+
+// MotorCapability is static description of motor
+type MotorCapability struct {
+	ID          string  `json:"id"`
+	Type        string  `json:"type"`
+	MaxSpeed    float64 `json:"max_speed"`
+	MinPosition float64 `json:"min_position"`
+	MaxPosition float64 `json:"max_position"`
+	Enabled     bool    `json:"enabled"`
+
+	MaxAcceleration float64 `json:"max_acceleration,omitempty"`
+	MaxJerk         float64 `json:"max_jerk,omitempty"`
+}
+
+// PatternCapability is movement pattern available to commands and scripts
+type PatternCapability struct {
+	Name       string `json:"name"`
+	DurationMs int64  `json:"duration_ms"`
+	Steps      int    `json:"steps"`
+	Waveforms  int    `json:"waveforms,omitempty"`
+}
+
+// Capabilities describes motors, sensors and patterns of every unit and
+// commands system understands
+func (s *System) Capabilities() Capabilities {
+	caps := Capabilities{
+		Units:     make([]UnitCapabilities, 0, len(s.units)),
+		Simulated: s.Simulated(),
+	}
+
+	for _, u := range s.units {
+		uc := UnitCapabilities{
+			ID:      u.ID,
+			Motors:  []MotorCapability{},
+			Sensors: u.sensors.GetSensorTypes(),
+		}
+		for _, m := range u.motion.GetMotors() {
+			uc.Motors = append(uc.Motors, MotorCapability{
+				ID:          string(m.ID),
+				Type:        m.Type.String(),
+				MaxSpeed:    m.MaxSpeed,
+				MinPosition: m.MinPosition,
+				MaxPosition: m.MaxPosition,
+				Enabled:     m.IsEnabled,
+
+				MaxAcceleration: m.MaxAcceleration,
+				MaxJerk:         m.MaxJerk,
+			})
+		}
+		uc.Patterns = []PatternCapability{}
+		for _, p := range u.motion.GetPatterns() {
+			uc.Patterns = append(uc.Patterns, PatternCapability{
+				Name:       p.Name,
+				DurationMs: p.Duration.Milliseconds(),
+				Steps:      len(p.Commands),
+				Waveforms:  len(p.Waveforms),
+			})
+		}
+		caps.Units = append(caps.Units, uc)
+	}
+
+	// status is answered without handler
+	caps.Commands = append(s.HandledCommands(), nlp.CmdStatus)
+	sort.Slice(caps.Commands, func(i, j int) bool { return caps.Commands[i] < caps.Commands[j] })
+
+	for _, m := range []Mode{ModeIdle, ModeActive, ModePaused, ModeSafe, ModeLowPower} {
+		caps.Modes = append(caps.Modes, m.String())
+	}
+	return caps
+}
@@ -0,0 +1,747 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/sashalind/sex-artifical-intelligence/pkg/behavior"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/clock"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/motion"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/nlp"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/profile"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/sensor"
+)
+
+// Duration reads "5m" style strings from config files
+type Duration time.Duration
+
+// UnmarshalJSON parses duration string
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// MarshalJSON writes duration as string
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// Config describes hardware layout and tuning of one deployment
+type Config struct {
+	Motors   []MotorConfig  `json:"motors"`
+	Groups   []GroupConfig  `json:"motor_groups"`
+	Sensors  SensorConfig   `json:"sensors"`
+	NLP      NLPConfig      `json:"nlp"`
+	Behavior BehaviorConfig `json:"behavior"`
+	Plugins  []PluginConfig `json:"plugins"`
+
+	// History keeps finished commands for queries
+	History HistoryConfig `json:"history"`
+
+	// SelfTest exercises hardware before system is declared ready
+	SelfTest SelfTestConfig `json:"self_test"`
+
+	// Idle powers motors down when nobody uses system
+	Idle IdleConfig `json:"idle"`
+
+	// Watchdog stops motors when motion control loop stalls
+	Watchdog WatchdogConfig `json:"watchdog"`
+
+	// Arbitration settles patterns wanting same motors
+	Arbitration ArbitrationConfig `json:"arbitration"`
+
+	// Adaptation maps behavior states to motion adjustments
+	Adaptation AdaptationConfig `json:"adaptation"`
+
+	// Presets are named preference sets "load preset <name>" applies to
+	// session, see presets.go
+	Presets map[string]profile.Preferences `json:"presets"`
+
+	// Scripts are user behavior script files started with system, see
+	// package script for language
+	Scripts []string `json:"scripts"`
+
+	// Access lists API tokens and their roles, see auth.go
+	Access AccessConfig `json:"access"`
+
+	// Units are additional robots driven by this controller, see unit.go
+	Units []UnitConfig `json:"units"`
+
+	// Simulation replaces hardware with simulated motors and sensors
+	Simulation SimulationConfig `json:"simulation"`
+
+	// Clock overrides time source, nil means wall clock
+	Clock clock.Clock `json:"-"`
+
+	// Subsystems overrides built-in implementations, e.g. with test fakes
+	Subsystems Subsystems `json:"-"`
+
+	// Authenticator replaces token check of Access section
+	Authenticator Authenticator `json:"-"`
+}
+
+// MotorConfig describes single motor
+type MotorConfig struct {
+	ID          string  `json:"id"`
+	Type        string  `json:"type"`
+	MaxSpeed    float64 `json:"max_speed"`
+	MinPosition float64 `json:"min_position"`
+	MaxPosition float64 `json:"max_position"`
+	Position    float64 `json:"position"`
+	Disabled    bool    `json:"disabled"`
+
+	// trajectory limits of logical motors, degrees/second² and
+	// degrees/second³; zero is unlimited
+	MaxAcceleration float64 `json:"max_acceleration"`
+	MaxJerk         float64 `json:"max_jerk"`
+
+	// Driver connects motor to hardware, see drivers.go
+	Driver *MotorDriverConfig `json:"driver,omitempty"`
+
+	// Homing calibrates motor zero and soft limits on request
+	Homing *HomingConfig `json:"homing,omitempty"`
+
+	// Protection reacts to stalled or overloaded motor
+	Protection *ProtectionConfig `json:"protection,omitempty"`
+
+	// Thermal limits duty cycle of motor
+	Thermal *ThermalConfig `json:"thermal,omitempty"`
+
+	// Stop shapes emergency and pause stops of motor
+	Stop *StopConfig `json:"stop,omitempty"`
+
+	// Frame sets unit positions, limits and speeds of motor are in
+	Frame *FrameConfig `json:"frame,omitempty"`
+}
+
+// HomingConfig describes how motor finds its reference position: method
+// "endstop" or "stall" drives it in direction (-1 or 1) until switch closes
+// or motor stalls, "current" takes position it sits at. Calibrated soft
+// limits stay margin degrees inside hard ones.
+type HomingConfig struct {
+	Method    string   `json:"method"`
+	Direction int      `json:"direction,omitempty"`
+	Speed     float64  `json:"speed,omitempty"`
+	Reference float64  `json:"reference"`
+	Margin    float64  `json:"margin,omitempty"`
+	Timeout   Duration `json:"timeout,omitempty"`
+}
+
+// ProtectionConfig detects motor making no progress for stall_time or
+// loaded above max_load (fraction of rated, drivers with load feedback) for
+// overload_time. Action "slow" scales speed by slow_factor and disables
+// motor if that does not help, "stop" halts it and "disable" also disables
+// it.
+type ProtectionConfig struct {
+	StallTime    Duration `json:"stall_time,omitempty"`
+	MaxLoad      float64  `json:"max_load,omitempty"`
+	OverloadTime Duration `json:"overload_time,omitempty"`
+	Action       string   `json:"action,omitempty"`
+	SlowFactor   float64  `json:"slow_factor,omitempty"`
+}
+
+// ThermalConfig estimates motor heat: full duty for heat_time reaches
+// limit, idle for cool_time (default heat_time) cools from it. Drivers
+// reading temperature count too, max_temp °C is limit then. Moves slow
+// down above throttle_at (default 0.8) of limit, at limit motor stops until
+// cooled to resume_at (default 0.5).
+type ThermalConfig struct {
+	HeatTime   Duration `json:"heat_time,omitempty"`
+	CoolTime   Duration `json:"cool_time,omitempty"`
+	MaxTemp    float64  `json:"max_temp,omitempty"`
+	Ambient    float64  `json:"ambient,omitempty"`
+	ThrottleAt float64  `json:"throttle_at,omitempty"`
+	ResumeAt   float64  `json:"resume_at,omitempty"`
+}
+
+// StopConfig picks how motor stops: mode "cut" (default) halts output at
+// once, "decel" ramps speed down at decel degrees/second², steeper if
+// needed to stand still within max_time (default 500ms).
+type StopConfig struct {
+	Mode    string   `json:"mode"`
+	Decel   float64  `json:"decel,omitempty"`
+	MaxTime Duration `json:"max_time,omitempty"`
+}
+
+// FrameConfig puts motor in physical units: unit "deg" (default), "rad" or
+// "mm", scale gearbox output degrees per unit of linkage (needed for mm,
+// negative reverses), ratio motor turns per gearbox output turn, invert for
+// mirrored assemblies and zero shaft degrees at position zero. Positions,
+// limits, speeds and accelerations of motor are then in that unit.
+type FrameConfig struct {
+	Unit   string  `json:"unit"`
+	Scale  float64 `json:"scale,omitempty"`
+	Ratio  float64 `json:"ratio,omitempty"`
+	Invert bool    `json:"invert,omitempty"`
+	Zero   float64 `json:"zero,omitempty"`
+}
+
+// frame converts frame section
+func (m MotorConfig) frame() motion.Frame {
+	if m.Frame == nil {
+		return motion.Frame{}
+	}
+	f := m.Frame
+	return motion.Frame{Unit: motion.PositionUnit(f.Unit), Scale: f.Scale, Ratio: f.Ratio, Invert: f.Invert, Zero: f.Zero}
+}
+
+// shaftRange returns position limits and position of motor in shaft
+// degrees its driver works in
+func (m MotorConfig) shaftRange() (lo, hi, pos float64) {
+	f := m.frame()
+	lo, hi = f.ToShaft(m.MinPosition), f.ToShaft(m.MaxPosition)
+	return min(lo, hi), max(lo, hi), f.ToShaft(m.Position)
+}
+
+// GroupConfig names motors moved together in sync
+type GroupConfig struct {
+	Name   string   `json:"name"`
+	Motors []string `json:"motors"`
+}
+
+// SensorConfig lists installed sensor types, individual sensors and
+// hardware channels sampled into them
+type SensorConfig struct {
+	Types       []string               `json:"types"`
+	HistorySize int                    `json:"history_size"`
+	Instances   []SensorInstanceConfig `json:"instances"`
+	Channels    []SensorChannelConfig  `json:"channels"`
+	Alerts      []SensorAlertRule      `json:"alerts"`
+	TempLimits  []SensorTempLimit      `json:"temp_limits"`
+
+	// QueueSize is readings waiting for ingestion before oldest are
+	// dropped, zero is 4096
+	QueueSize int `json:"queue_size"`
+
+	// RecordDir holds recordings started over API, empty is "recordings"
+	RecordDir string `json:"record_dir"`
+
+	// Retention keeps averaged readings past history_size full-rate ones,
+	// finest tier first; empty list keeps full-rate readings only
+	Retention []SensorRetentionTier `json:"retention"`
+
+	// Gestures tunes tap, squeeze and stroke detection on touch and
+	// pressure readings, zero fields take defaults
+	Gestures SensorGestureConfig `json:"gestures"`
+}
+
+// SensorRetentionTier keeps one averaged reading per resolution for horizon
+type SensorRetentionTier struct {
+	Resolution Duration `json:"resolution"`
+	Horizon    Duration `json:"horizon"`
+}
+
+// SensorGestureConfig tunes gesture detection: contact starts at threshold
+// and ends release under it, taps last up to tap_max, squeezes at least
+// squeeze_min, and stroke_min contacts within stroke_window spreading less
+// than regularity of their mean interval are stroke
+type SensorGestureConfig struct {
+	Types        []string `json:"types,omitempty"`
+	Threshold    float64  `json:"threshold,omitempty"`
+	Release      float64  `json:"release,omitempty"`
+	TapMax       Duration `json:"tap_max,omitempty"`
+	SqueezeMin   Duration `json:"squeeze_min,omitempty"`
+	StrokeMin    int      `json:"stroke_min,omitempty"`
+	StrokeWindow Duration `json:"stroke_window,omitempty"`
+	Regularity   float64  `json:"regularity,omitempty"`
+}
+
+// NLPConfig holds language processing options
+type NLPConfig struct {
+	HistorySize int `json:"history_size"`
+
+	// IntentFile is labeled training file of intent classifier, empty
+	// uses built-in examples
+	IntentFile string `json:"intent_file,omitempty"`
+
+	// MinConfidence is classifier confidence below which intent falls
+	// back to keywords, zero is 0.5
+	MinConfidence float64 `json:"min_confidence,omitempty"`
+
+	// ConfirmConfidence is intent confidence below which command must be
+	// confirmed before it runs, default 0.7; zero never asks
+	ConfirmConfidence float64 `json:"confirm_confidence"`
+
+	// DialogTimeout is how long follow-up question about missing
+	// parameter or confirmation waits for answer, zero is 30s
+	DialogTimeout Duration `json:"dialog_timeout,omitempty"`
+
+	// VocabularyFile maps command types to operator phrases, read again
+	// on reload
+	VocabularyFile string `json:"vocabulary_file,omitempty"`
+
+	// Safeword stops everything at once whatever else command says
+	Safeword string `json:"safeword,omitempty"`
+
+	// ExactKeywords turns off spelling correction of command words
+	ExactKeywords bool `json:"exact_keywords,omitempty"`
+
+	// WakeWord is phrase text must name to be taken as command, followed
+	// by more within WakeWindow (zero is 15s); empty takes all text
+	WakeWord   string   `json:"wake_word,omitempty"`
+	WakeWindow Duration `json:"wake_window,omitempty"`
+
+	// Personality selects profile of reply templates, empty is "default";
+	// PersonalityDir adds profile files, read again on reload
+	Personality    string `json:"personality,omitempty"`
+	PersonalityDir string `json:"personality_dir,omitempty"`
+
+	// LLM parses free-form commands local parsing does not understand
+	LLM NLPModelConfig `json:"llm"`
+}
+
+// NLPModelConfig points at OpenAI-compatible chat completions API, e.g.
+// llama.cpp server; empty url disables model
+type NLPModelConfig struct {
+	URL     string   `json:"url,omitempty"`
+	Model   string   `json:"model,omitempty"`
+	APIKey  string   `json:"api_key,omitempty"`
+	Timeout Duration `json:"timeout,omitempty"`
+}
+
+// BehaviorConfig holds behavior classification thresholds
+type BehaviorConfig struct {
+	Threshold       float64  `json:"threshold"`
+	Window          Duration `json:"window"`
+	HistorySize     int      `json:"history_size"`
+	AggressiveLevel float64  `json:"aggressive_level"`
+	PassiveLevel    float64  `json:"passive_level"`
+	ErraticSpread   float64  `json:"erratic_spread"`
+
+	// DistressUrgency is urgency of negative command that makes behavior
+	// aggressive, zero ignores mood of commands
+	DistressUrgency float64 `json:"distress_urgency"`
+}
+
+// UnitConfig describes additional robot unit with its own motors and
+// sensors. Empty sensor section gets reference build sensors.
+type UnitConfig struct {
+	ID      string        `json:"id"`
+	Motors  []MotorConfig `json:"motors"`
+	Groups  []GroupConfig `json:"motor_groups"`
+	Sensors SensorConfig  `json:"sensors"`
+}
+
+// SimulationConfig enables dry-run mode
+type SimulationConfig struct {
+	Enabled bool `json:"enabled"`
+
+	// Scenario is sensor/E-stop script file, empty plays built-in looping session
+	Scenario string `json:"scenario"`
+
+	// Replay is sensor recording played in loop instead of scenario sensors
+	Replay string `json:"replay"`
+}
+
+// DefaultConfig returns configuration of reference hardware build
+func DefaultConfig() Config {
+	var cfg Config
+
+	for _, m := range motion.DefaultConfig().Motors {
+		cfg.Motors = append(cfg.Motors, MotorConfig{
+			ID:          string(m.ID),
+			Type:        m.Type.String(),
+			MaxSpeed:    m.MaxSpeed,
+			MinPosition: m.MinPosition,
+			MaxPosition: m.MaxPosition,
+			Position:    m.Position,
+			Disabled:    !m.IsEnabled,
+
+			MaxAcceleration: m.MaxAcceleration,
+			MaxJerk:         m.MaxJerk,
+		})
+	}
+
+	sc := sensor.DefaultConfig()
+	for _, t := range sc.Types {
+		cfg.Sensors.Types = append(cfg.Sensors.Types, string(t))
+	}
+	cfg.Sensors.HistorySize = sc.HistorySize
+	cfg.Sensors.Retention = sensorRetention(sc.Retention)
+
+	nc := nlp.DefaultConfig()
+	cfg.NLP.HistorySize = nc.HistorySize
+	cfg.NLP.ConfirmConfidence = nc.ConfirmConfidence
+
+	bc := behavior.DefaultConfig()
+	cfg.Behavior = BehaviorConfig{
+		Threshold:       bc.Threshold,
+		Window:          Duration(bc.WindowSize),
+		HistorySize:     bc.HistorySize,
+		AggressiveLevel: bc.AggressiveLevel,
+		PassiveLevel:    bc.PassiveLevel,
+		ErraticSpread:   bc.ErraticSpread,
+		DistressUrgency: bc.DistressUrgency,
+	}
+
+	cfg.History.Size = defaultHistorySize
+	cfg.SelfTest.Sweep = defaultSelfTestSweep
+	cfg.Idle.Timeout = Duration(defaultIdleTimeout)
+	cfg.Watchdog.Timeout = Duration(defaultWatchdogTimeout)
+	cfg.Adaptation = DefaultAdaptationConfig()
+
+	return cfg
+}
+
+// configDecoders turn config file into JSON by file extension, so every
+// format goes through same JSON tags and unmarshalers of Config
+var configDecoders = map[string]func([]byte) ([]byte, error){
+	".json": func(data []byte) ([]byte, error) { return data, nil },
+	".yaml": yamlToJSON,
+	".yml":  yamlToJSON,
+}
+
+// yamlToJSON converts YAML document into equivalent JSON
+func yamlToJSON(data []byte) ([]byte, error) {
+	var doc interface{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	doc, err := jsonValue(doc)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(doc)
+}
+
+// jsonValue returns decoded YAML with string keyed maps only, as JSON
+// objects need
+func jsonValue(v interface{}) (interface{}, error) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, item := range v {
+			item, err := jsonValue(item)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", k, err)
+			}
+			v[k] = item
+		}
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			key, ok := k.(string)
+			if !ok {
+				return nil, fmt.Errorf("key %v is not a string", k)
+			}
+			m[key] = item
+		}
+		return jsonValue(m)
+	case []interface{}:
+		for i, item := range v {
+			item, err := jsonValue(item)
+			if err != nil {
+				return nil, fmt.Errorf("item %d: %w", i, err)
+			}
+			v[i] = item
+		}
+	}
+	return v, nil
+}
+
+// LoadConfig reads JSON (.json) or YAML (.yaml, .yml) config file. Missing
+// sections keep default values, so a deployment only needs to describe
+// what differs from reference build.
+func LoadConfig(path string) (*Config, error) {
+	ext := strings.ToLower(filepath.Ext(path))
+	decode, ok := configDecoders[ext]
+	if !ok {
+		return nil, fmt.Errorf("unsupported config format %q, use .json, .yaml or .yml", ext)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if data, err = decode(data); err != nil {
+		return nil, fmt.Errorf("parse config %s: %w", path, err)
+	}
+
+	cfg := DefaultConfig()
+
+	// motor list replaces default layout instead of merging into it
+	var sections map[string]json.RawMessage
+	if err := json.Unmarshal(data, &sections); err != nil {
+		return nil, fmt.Errorf("parse config %s: %w", path, err)
+	}
+	if _, ok := sections["motors"]; ok {
+		cfg.Motors = nil
+	}
+
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("parse config %s: %w", path, err)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("config %s: %w", path, err)
+	}
+	return &cfg, nil
+}
+
+// Validate checks whole configuration
+func (c Config) Validate() error {
+	if _, err := c.MotionConfig(); err != nil {
+		return err
+	}
+	if err := c.sensorConfig().Validate(); err != nil {
+		return err
+	}
+	if err := c.Sensors.validateChannels(); err != nil {
+		return err
+	}
+	if err := c.nlpConfig().Validate(); err != nil {
+		return err
+	}
+	if err := validatePresets(c.Presets); err != nil {
+		return err
+	}
+	seen := make(map[string]bool, len(c.Plugins))
+	for _, p := range c.Plugins {
+		if _, err := lookupPlugin(p.Name); err != nil {
+			return err
+		}
+		if seen[p.Name] {
+			return fmt.Errorf("plugin %s enabled twice", p.Name)
+		}
+		seen[p.Name] = true
+	}
+	if err := c.validateUnits(); err != nil {
+		return err
+	}
+	if err := c.Idle.validate(c.Motors); err != nil {
+		return err
+	}
+	if err := c.Watchdog.Validate(); err != nil {
+		return err
+	}
+	if err := c.Arbitration.motion().Validate(); err != nil {
+		return err
+	}
+	if err := c.Adaptation.Validate(); err != nil {
+		return err
+	}
+	if err := c.History.Validate(); err != nil {
+		return err
+	}
+	if c.SelfTest.Sweep < 0 {
+		return fmt.Errorf("self-test sweep must not be negative")
+	}
+	if err := c.Access.Validate(); err != nil {
+		return err
+	}
+	return c.behaviorConfig().Validate()
+}
+
+// validateUnits checks unit IDs are unique and hardware of each unit is valid
+func (c Config) validateUnits() error {
+	seen := map[string]bool{string(PrimaryUnit): true}
+	for _, u := range c.Units {
+		if u.ID == "" || strings.ContainsAny(u.ID, " \t\n") {
+			return fmt.Errorf("unit id %q must be single non-empty word", u.ID)
+		}
+		id := strings.ToLower(u.ID)
+		if seen[id] {
+			return fmt.Errorf("unit %s defined twice", u.ID)
+		}
+		seen[id] = true
+
+		if _, err := motorsConfig(u.Motors, u.Groups); err != nil {
+			return fmt.Errorf("unit %s: %w", u.ID, err)
+		}
+		if err := u.sensorConfig().Validate(); err != nil {
+			return fmt.Errorf("unit %s: %w", u.ID, err)
+		}
+		if err := u.Sensors.validateChannels(); err != nil {
+			return fmt.Errorf("unit %s: %w", u.ID, err)
+		}
+	}
+	return nil
+}
+
+// MotionConfig returns motor layout of primary unit as motion controller
+// config, for controllers built outside system and passed in Subsystems
+func (c Config) MotionConfig() (motion.Config, error) {
+	return motorsConfig(c.Motors, c.Groups)
+}
+
+func (u UnitConfig) sensorConfig() sensor.Config {
+	sc := u.Sensors
+	def := sensor.DefaultConfig()
+	if len(sc.Types) == 0 {
+		for _, t := range def.Types {
+			sc.Types = append(sc.Types, string(t))
+		}
+	}
+	if sc.HistorySize == 0 {
+		sc.HistorySize = def.HistorySize
+	}
+	if sc.Retention == nil {
+		sc.Retention = sensorRetention(def.Retention)
+	}
+	return Config{Sensors: sc}.sensorConfig()
+}
+
+// motorsConfig converts motor list and groups into motion controller config
+func motorsConfig(motors []MotorConfig, groups []GroupConfig) (motion.Config, error) {
+	var mc motion.Config
+	for _, m := range motors {
+		motorType, err := motion.ParseMotorType(m.Type)
+		if err != nil {
+			return mc, fmt.Errorf("motor %s: %w", m.ID, err)
+		}
+		if err := m.validateDriver(); err != nil {
+			return mc, err
+		}
+		mc.Motors = append(mc.Motors, motion.Motor{
+			ID:          motion.MotorID(m.ID),
+			Type:        motorType,
+			Position:    m.Position,
+			MaxSpeed:    m.MaxSpeed,
+			MinPosition: m.MinPosition,
+			MaxPosition: m.MaxPosition,
+			IsEnabled:   !m.Disabled,
+
+			MaxAcceleration: m.MaxAcceleration,
+			MaxJerk:         m.MaxJerk,
+		})
+		if h := m.Homing; h != nil {
+			mc.Motors[len(mc.Motors)-1].Homing = motion.Homing{
+				Method:    motion.HomingMethod(h.Method),
+				Direction: h.Direction,
+				Speed:     h.Speed,
+				Reference: h.Reference,
+				Margin:    h.Margin,
+				Timeout:   time.Duration(h.Timeout),
+			}
+		}
+		if p := m.Protection; p != nil {
+			mc.Motors[len(mc.Motors)-1].Protection = motion.Protection{
+				StallTime:    time.Duration(p.StallTime),
+				MaxLoad:      p.MaxLoad,
+				OverloadTime: time.Duration(p.OverloadTime),
+				Action:       motion.ProtectAction(p.Action),
+				SlowFactor:   p.SlowFactor,
+			}
+		}
+		if t := m.Thermal; t != nil {
+			mc.Motors[len(mc.Motors)-1].Thermal = motion.Thermal{
+				HeatTime:   time.Duration(t.HeatTime),
+				CoolTime:   time.Duration(t.CoolTime),
+				MaxTemp:    t.MaxTemp,
+				Ambient:    t.Ambient,
+				ThrottleAt: t.ThrottleAt,
+				ResumeAt:   t.ResumeAt,
+			}
+		}
+		mc.Motors[len(mc.Motors)-1].Frame = m.frame()
+		if st := m.Stop; st != nil {
+			mc.Motors[len(mc.Motors)-1].Stop = motion.StopProfile{
+				Mode:    motion.StopMode(st.Mode),
+				Decel:   st.Decel,
+				MaxTime: time.Duration(st.MaxTime),
+			}
+		}
+	}
+	for _, g := range groups {
+		group := motion.MotorGroup{Name: g.Name}
+		for _, id := range g.Motors {
+			group.Motors = append(group.Motors, motion.MotorID(id))
+		}
+		mc.Groups = append(mc.Groups, group)
+	}
+	return mc, mc.Validate()
+}
+
+func (c Config) sensorConfig() sensor.Config {
+	sc := sensor.Config{HistorySize: c.Sensors.HistorySize, QueueSize: c.Sensors.QueueSize}
+	for _, t := range c.Sensors.Types {
+		sc.Types = append(sc.Types, sensor.SensorType(t))
+	}
+	for _, in := range c.Sensors.Instances {
+		sc.Sensors = append(sc.Sensors, in.sensorInfo())
+	}
+	for _, rule := range c.Sensors.Alerts {
+		sc.Alerts = append(sc.Alerts, rule.alertRule())
+	}
+	for _, l := range c.Sensors.TempLimits {
+		sc.TempLimits = append(sc.TempLimits, l.tempLimit())
+	}
+	for _, t := range c.Sensors.Retention {
+		sc.Retention = append(sc.Retention, sensor.RetentionTier{Resolution: time.Duration(t.Resolution), Horizon: time.Duration(t.Horizon)})
+	}
+	sc.Gestures = c.Sensors.Gestures.gestureConfig()
+	return sc
+}
+
+func (g SensorGestureConfig) gestureConfig() sensor.GestureConfig {
+	gc := sensor.GestureConfig{
+		Threshold:    g.Threshold,
+		Release:      g.Release,
+		TapMax:       time.Duration(g.TapMax),
+		SqueezeMin:   time.Duration(g.SqueezeMin),
+		StrokeMin:    g.StrokeMin,
+		StrokeWindow: time.Duration(g.StrokeWindow),
+		Regularity:   g.Regularity,
+	}
+	for _, t := range g.Types {
+		gc.Types = append(gc.Types, sensor.SensorType(t))
+	}
+	return gc
+}
+
+func sensorRetention(tiers []sensor.RetentionTier) []SensorRetentionTier {
+	out := []SensorRetentionTier{}
+	for _, t := range tiers {
+		out = append(out, SensorRetentionTier{Resolution: Duration(t.Resolution), Horizon: Duration(t.Horizon)})
+	}
+	return out
+}
+
+func (c Config) nlpConfig() nlp.Config {
+	return nlp.Config{
+		HistorySize:       c.NLP.HistorySize,
+		IntentFile:        c.NLP.IntentFile,
+		MinConfidence:     c.NLP.MinConfidence,
+		ConfirmConfidence: c.NLP.ConfirmConfidence,
+		DialogTimeout:     time.Duration(c.NLP.DialogTimeout),
+		VocabularyFile:    c.NLP.VocabularyFile,
+		Safeword:          c.NLP.Safeword,
+		ExactKeywords:     c.NLP.ExactKeywords,
+		WakeWord:          c.NLP.WakeWord,
+		WakeWindow:        time.Duration(c.NLP.WakeWindow),
+		Personality:       c.NLP.Personality,
+		PersonalityDir:    c.NLP.PersonalityDir,
+		Redact:            c.History.Redact,
+		LLM: nlp.LLMConfig{
+			URL:     c.NLP.LLM.URL,
+			Model:   c.NLP.LLM.Model,
+			APIKey:  c.NLP.LLM.APIKey,
+			Timeout: time.Duration(c.NLP.LLM.Timeout),
+		},
+	}
+}
+
+func (c Config) behaviorConfig() behavior.Config {
+	return behavior.Config{
+		Threshold:       c.Behavior.Threshold,
+		WindowSize:      time.Duration(c.Behavior.Window),
+		HistorySize:     c.Behavior.HistorySize,
+		AggressiveLevel: c.Behavior.AggressiveLevel,
+		PassiveLevel:    c.Behavior.PassiveLevel,
+		ErraticSpread:   c.Behavior.ErraticSpread,
+		DistressUrgency: c.Behavior.DistressUrgency,
+	}
+}
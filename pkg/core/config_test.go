@@ -0,0 +1,105 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeConfig(t *testing.T, name, data string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestExampleConfigsMatch(t *testing.T) {
+	fromJSON, err := LoadConfig(filepath.Join("..", "..", "configs", "default.json"))
+	if err != nil {
+		t.Fatalf("LoadConfig json: %v", err)
+	}
+	fromYAML, err := LoadConfig(filepath.Join("..", "..", "configs", "default.yaml"))
+	if err != nil {
+		t.Fatalf("LoadConfig yaml: %v", err)
+	}
+	if !reflect.DeepEqual(fromJSON, fromYAML) {
+		t.Errorf("default.yaml differs from default.json:\n%+v\n%+v", fromYAML, fromJSON)
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		data    string
+		wantErr string
+		check   func(*testing.T, *Config)
+	}{
+		{
+			name: "yaml sections",
+			file: "sai.yaml",
+			data: "behavior:\n  window: 90s\n  threshold: 0.6\nmotors:\n  - {id: arm, type: servo, max_speed: 90, max_position: 90}\n",
+			check: func(t *testing.T, cfg *Config) {
+				if time.Duration(cfg.Behavior.Window) != 90*time.Second || cfg.Behavior.Threshold != 0.6 {
+					t.Errorf("behavior = %+v", cfg.Behavior)
+				}
+				if len(cfg.Motors) != 1 || cfg.Motors[0].ID != "arm" {
+					t.Errorf("motors = %+v, want only arm", cfg.Motors)
+				}
+			},
+		},
+		{
+			name: "yml keeps defaults",
+			file: "sai.yml",
+			data: "nlp:\n  history_size: 10\n",
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.NLP.HistorySize != 10 || len(cfg.Motors) != len(DefaultConfig().Motors) {
+					t.Errorf("nlp %+v, %d motors", cfg.NLP, len(cfg.Motors))
+				}
+			},
+		},
+		{
+			name: "empty yaml",
+			file: "sai.yaml",
+			check: func(t *testing.T, cfg *Config) {
+				if len(cfg.Motors) != len(DefaultConfig().Motors) {
+					t.Errorf("%d motors, want defaults", len(cfg.Motors))
+				}
+			},
+		},
+		{
+			name: "json upper case extension",
+			file: "SAI.JSON",
+			data: `{"nlp": {"history_size": 20}}`,
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.NLP.HistorySize != 20 {
+					t.Errorf("nlp = %+v", cfg.NLP)
+				}
+			},
+		},
+		{name: "unknown extension", file: "sai.toml", data: "x = 1", wantErr: "unsupported config format"},
+		{name: "broken yaml", file: "sai.yaml", data: "motors: [", wantErr: "parse config"},
+		{name: "non-string key", file: "sai.yaml", data: "behavior:\n  1: x\n", wantErr: "not a string"},
+		{name: "invalid value", file: "sai.yaml", data: "behavior:\n  window: soon\n", wantErr: "parse config"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := LoadConfig(writeConfig(t, tt.file, tt.data))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("LoadConfig error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadConfig: %v", err)
+			}
+			tt.check(t, cfg)
+		})
+	}
+}
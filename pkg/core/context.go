@@ -0,0 +1,77 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/sashalind/sex-artifical-intelligence/pkg/core/errs"
+)
+
+// ctxKey keeps context values private to core
+type ctxKey int
+
+const (
+	sessionKey ctxKey = iota
+	traceKey
+	principalKey
+	systemKey
+)
+
+// ErrDeadlineExceeded is returned when command context expires
+var ErrDeadlineExceeded = errs.New(errs.DeadlineExceeded, "command deadline exceeded")
+
+// WithSession attaches session to context, commands submitted with it run
+// on behalf of that session
+func WithSession(ctx context.Context, id SessionID) context.Context {
+	return context.WithValue(ctx, sessionKey, id)
+}
+
+// SessionFromContext returns session attached by WithSession
+func SessionFromContext(ctx context.Context) (SessionID, bool) {
+	id, ok := ctx.Value(sessionKey).(SessionID)
+	return id, ok && id != ""
+}
+
+// WithTraceID attaches trace identifier, e.g. HTTP request ID, carried into
+// queue introspection and logs
+func WithTraceID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, traceKey, id)
+}
+
+// TraceIDFromContext returns trace identifier or empty string
+func TraceIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(traceKey).(string)
+	return id
+}
+
+// WithPrincipal attaches authenticated caller, operations then run with
+// caller's role
+func WithPrincipal(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, principalKey, p)
+}
+
+// PrincipalFromContext returns caller attached by WithPrincipal
+func PrincipalFromContext(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(principalKey).(Principal)
+	return p, ok
+}
+
+// systemContext marks ctx as system's own, e.g. scheduled routines and
+// scripts, which pass Authorize as maintainer
+func systemContext(ctx context.Context) context.Context {
+	return context.WithValue(ctx, systemKey, true)
+}
+
+func isSystemContext(ctx context.Context) bool {
+	system, _ := ctx.Value(systemKey).(bool)
+	return system
+}
+
+// contextError converts context error into coded core error
+func contextError(err error) error {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("%w: %v", ErrDeadlineExceeded, err)
+	}
+	return fmt.Errorf("%w: %v", ErrCommandCancelled, err)
+}
@@ -0,0 +1,98 @@
+package cron
+
+import (
+	"testing"
+	"time"
+)
+
+// at is minute of 2024 in UTC, 1 January 2024 is Monday
+func at(month time.Month, day, hour, min int) time.Time {
+	return time.Date(2024, month, day, hour, min, 0, 0, time.UTC)
+}
+
+func TestNext(t *testing.T) {
+	tests := []struct {
+		name string
+		expr string
+		from time.Time
+		want time.Time
+	}{
+		{"step", "*/15 * * * *", at(1, 1, 10, 7), at(1, 1, 10, 15)},
+		{"step into next hour", "*/15 * * * *", at(1, 1, 10, 45), at(1, 1, 11, 0)},
+		{"step within range", "0-30/10 * * * *", at(1, 1, 10, 25), at(1, 1, 10, 30)},
+		{"step from value", "5/20 * * * *", at(1, 1, 10, 26), at(1, 1, 10, 45)},
+		{"single value", "7 * * * *", at(1, 1, 10, 6), at(1, 1, 10, 7)},
+		{"strictly after", "7 * * * *", at(1, 1, 10, 7), at(1, 1, 11, 7)},
+		{"seconds are dropped", "7 * * * *", at(1, 1, 10, 6).Add(59 * time.Second), at(1, 1, 10, 7)},
+		{"range", "0 8-10 * * *", at(1, 1, 9, 30), at(1, 1, 10, 0)},
+		{"range into next day", "0 8-10 * * *", at(1, 1, 10, 30), at(1, 2, 8, 0)},
+		{"list", "0 8,12,18 * * *", at(1, 1, 12, 0), at(1, 1, 18, 0)},
+		{"day of month only", "0 0 13 * *", at(1, 1, 0, 0), at(1, 13, 0, 0)},
+		{"day of week only", "0 0 * * 5", at(1, 1, 0, 0), at(1, 5, 0, 0)},
+		{"7 is Sunday", "30 9 * * 7", at(1, 1, 0, 0), at(1, 7, 9, 30)},
+		{"0 is Sunday", "30 9 * * 0", at(1, 1, 0, 0), at(1, 7, 9, 30)},
+		{"weekdays", "0 9 * * 1-5", at(1, 5, 10, 0), at(1, 8, 9, 0)},
+		{"day of month or week, week first", "0 0 13 * 5", at(1, 1, 0, 0), at(1, 5, 0, 0)},
+		{"day of month or week, month first", "0 0 13 * 5", at(1, 12, 0, 0), at(1, 13, 0, 0)},
+		{"month boundary", "0 0 1 * *", at(1, 31, 12, 0), at(2, 1, 0, 0)},
+		{"short month skipped", "0 0 31 * *", at(4, 1, 0, 0), at(5, 31, 0, 0)},
+		{"month list", "0 0 1 3,9 *", at(3, 1, 0, 0), at(9, 1, 0, 0)},
+		{"year boundary", "0 0 1 1 *", at(12, 31, 23, 59), time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"last minute of year", "59 23 31 12 *", at(12, 31, 23, 59), time.Date(2025, 12, 31, 23, 59, 0, 0, time.UTC)},
+		{"leap day", "0 0 29 2 *", at(3, 1, 0, 0), time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"never", "0 0 30 2 *", at(1, 1, 0, 0), time.Time{}},
+		{"hourly", "@hourly", at(1, 1, 10, 7), at(1, 1, 11, 0)},
+		{"daily", "@daily", at(1, 1, 10, 7), at(1, 2, 0, 0)},
+		{"weekly", "@weekly", at(1, 1, 10, 7), at(1, 7, 0, 0)},
+		{"monthly", "@monthly", at(1, 1, 10, 7), at(2, 1, 0, 0)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := Parse(tt.expr)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.expr, err)
+			}
+			if got := s.Next(tt.from); !got.Equal(tt.want) {
+				t.Errorf("Next(%s) of %q = %s, want %s", tt.from, tt.expr, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNextKeepsLocation(t *testing.T) {
+	zone := time.FixedZone("UTC+2", 2*60*60)
+	s, err := Parse("0 9 * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := s.Next(time.Date(2024, 1, 1, 10, 0, 0, 0, zone))
+	if want := time.Date(2024, 1, 2, 9, 0, 0, 0, zone); !got.Equal(want) || got.Location() != zone {
+		t.Errorf("Next = %s, want %s", got, want)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"@yearly",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * 32 * *",
+		"* * * 0 *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"*/x * * * *",
+		"5-1 * * * *",
+		"1-x * * * *",
+		"a * * * *",
+		"1,,2 * * * *",
+	} {
+		if _, err := Parse(expr); err == nil {
+			t.Errorf("Parse(%q) succeeded", expr)
+		}
+	}
+}
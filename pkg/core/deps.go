@@ -0,0 +1,352 @@
+package core
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/sashalind/sex-artifical-intelligence/pkg/behavior"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/core/errs"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/core/event"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/health"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/motion"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/neural"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/nlp"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/sensor"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/storage"
+)
+
+// ErrNotSupported is returned when injected subsystem lacks optional
+// feature operation needs
+var ErrNotSupported = errs.New(errs.FailedPrecondition, "not supported by subsystem")
+
+// SensorSource provides readings of one unit. *sensor.Hub is default.
+type SensorSource interface {
+	GetSensorData(sType sensor.SensorType) []float64
+	GetSensorTypes() []sensor.SensorType
+	Shutdown()
+}
+
+// MotionExecutor drives motors of one unit. *motion.Controller is default.
+type MotionExecutor interface {
+	ExecuteCommand(ctx context.Context, cmd motion.MotorCommand) error
+	ExecuteGroup(ctx context.Context, cmds []motion.MotorCommand) error
+	ExecutePattern(ctx context.Context, name string) error
+	GetMotors() []motion.Motor
+	GetPatterns() []motion.MovementPattern
+	StopAll()
+	Shutdown()
+}
+
+// NLPEngine turns text into commands and commands into replies.
+// *nlp.Processor is default.
+type NLPEngine interface {
+	ProcessCommand(text string) (*nlp.Command, error)
+	GenerateResponse(cmd *nlp.Command) (*nlp.Response, error)
+	Shutdown()
+}
+
+// Subsystems replaces built-in implementations of primary unit, nil fields
+// get concrete ones built from config. System owns what it is given and
+// shuts it down on exit.
+//
+// Beyond the interfaces, core uses these methods when implementation has
+// them: AttachBus, AttachStore, OnFailure+Restart (supervision),
+// Hold/Release/Held (pause), PowerDown/PowerUp (idle), SetSpeedLimit
+// (profiles), Config/ApplyConfig and SetConfig (reload), RestoreMotor,
+// AddPattern, AddProgram/GetPrograms/Progress (programs),
+// StartRecording/StopRecording/Recording (recording),
+// ExecuteSynced/MoveGroup/GetGroups (synced group moves),
+// Home/Calibrations/SetSoftLimits (calibration), ResetMotor (motor reset),
+// AddMotor/RemoveMotor/ConfigureMotor (runtime motor changes),
+// Track (move completion), Subscribe (motor telemetry), Jog/JogStop (jog),
+// GetHistory/RestoreHistory (snapshots), SetDriver and
+// AttachSource (simulation), Health (health reports), SetWatchdog
+// (watchdog), SetArbitration/Runs (pattern arbitration), SimulatePattern
+// (pattern preview), GetSamples (timestamped readings),
+// RegisterSensor/UnregisterSensor/Sensors/SensorSamples/ZoneSamples
+// (sensor registry), ZoneLevels (zone levels), Stats (sensor stats),
+// SetCalibration/ClearCalibration/CaptureZero/Calibrations (sensor
+// calibration),
+// Subscribe (sensor streams), SensorHealth (sensor health),
+// AttachChannel/DetachChannel/Channels (sensor hot-plug),
+// SetAlertRule/RemoveAlertRule/AlertRules/ActiveAlerts (sensor alerts),
+// Record/StopRecord/Recording (sensor recording), SetTempLimits/TempLimits
+// (temperature limits), Gestures (touch gestures), ProcessDialog (follow-up
+// questions in sessions), SetPattern (references to playing pattern),
+// Stats/Misunderstood (NLP metrics).
+// Features whose methods are missing are skipped or
+// fail with ErrNotSupported.
+type Subsystems struct {
+	Motion  MotionExecutor
+	Sensors SensorSource
+	NLP     NLPEngine
+}
+
+// optional subsystem features, see Subsystems
+type (
+	busAttacher interface {
+		AttachBus(bus *event.Bus)
+	}
+	storeAttacher interface {
+		AttachStore(store *storage.Store) error
+	}
+	restartable interface {
+		OnFailure(fn func(error))
+		Restart() error
+	}
+	motionHolder interface {
+		Hold()
+		Release()
+		Held() bool
+	}
+	motionPower interface {
+		PowerDown()
+		PowerUp()
+	}
+	speedLimiter interface {
+		SetSpeedLimit(limit float64)
+	}
+	motionConfigurer interface {
+		Config() motion.Config
+		ApplyConfig(cfg motion.Config) error
+	}
+	motionRestorer interface {
+		RestoreMotor(id motion.MotorID, position float64, enabled bool) error
+		AddPattern(pattern motion.MovementPattern)
+	}
+	programRunner interface {
+		AddProgram(program motion.PatternProgram) error
+		GetPrograms() []motion.PatternProgram
+		Progress() (motion.PatternProgress, bool)
+	}
+	patternRecorder interface {
+		StartRecording(source motion.RecordSource) error
+		StopRecording(name string) (motion.MovementPattern, error)
+		Recording() bool
+	}
+	syncMover interface {
+		ExecuteSynced(ctx context.Context, cmds []motion.MotorCommand) error
+		MoveGroup(ctx context.Context, group string, cmds []motion.MotorCommand) error
+		GetGroups() []motion.MotorGroup
+	}
+	motorCalibrator interface {
+		Home(ctx context.Context, id motion.MotorID) (motion.Calibration, error)
+		Calibrations() []motion.Calibration
+		SetSoftLimits(id motion.MotorID, min, max float64) error
+	}
+	motorResetter interface {
+		ResetMotor(id motion.MotorID) error
+	}
+	telemetrySource interface {
+		Subscribe(interval time.Duration, buffer int) *motion.Telemetry
+	}
+	moveTracker interface {
+		Track(id motion.MotorID, target float64, timeout time.Duration) (*motion.Completion, error)
+	}
+	jogger interface {
+		Jog(ctx context.Context, id motion.MotorID, velocity float64) error
+		JogStop(id motion.MotorID) error
+	}
+	motorManager interface {
+		AddMotor(m motion.Motor, backend motion.Driver) error
+		RemoveMotor(id motion.MotorID) error
+		ConfigureMotor(m motion.Motor) error
+	}
+	driverAttacher interface {
+		SetDriver(d motion.Driver)
+	}
+	watchdogSetter interface {
+		SetWatchdog(timeout time.Duration) error
+	}
+	patternArbiter interface {
+		SetArbitration(a motion.Arbitration) error
+		Runs() []motion.PatternProgress
+	}
+	patternPreviewer interface {
+		SimulatePattern(name string) (*motion.PatternPreview, error)
+	}
+	sourceAttacher interface {
+		AttachSource(src sensor.Source, interval time.Duration)
+	}
+	sampleSource interface {
+		GetSamples(sType sensor.SensorType, from, to time.Time) []sensor.SensorData
+	}
+	sensorRegistry interface {
+		RegisterSensor(info sensor.SensorInfo) error
+		UnregisterSensor(id sensor.SensorID) error
+		Sensors() []sensor.SensorInfo
+		SensorSamples(id sensor.SensorID, from, to time.Time) ([]sensor.SensorData, error)
+		ZoneSamples(zone string, sType sensor.SensorType, from, to time.Time) []sensor.SensorData
+	}
+	zoneReader interface {
+		ZoneLevels(sType sensor.SensorType) []sensor.ZoneLevel
+	}
+	sensorStatter interface {
+		Stats(id sensor.SensorID, window time.Duration) (sensor.Stats, error)
+	}
+	channelAttacher interface {
+		AttachChannel(cfg sensor.ChannelConfig) error
+		DetachChannel(id string) error
+		Channels() []sensor.ChannelStatus
+	}
+	sensorAlerter interface {
+		SetAlertRule(rule sensor.AlertRule) error
+		RemoveAlertRule(name string) error
+		AlertRules() []sensor.AlertRule
+		ActiveAlerts() []sensor.SensorAlert
+	}
+	sensorRecorder interface {
+		Record(w io.WriteCloser) error
+		StopRecord() (sensor.RecordStats, error)
+		Recording() (sensor.RecordStats, bool)
+	}
+	tempLimiter interface {
+		SetTempLimits(limits []sensor.TempLimit) error
+		TempLimits() []sensor.TempLimit
+	}
+	gestureReader interface {
+		Gestures() []sensor.Gesture
+	}
+	sensorChecker interface {
+		SensorHealth() []sensor.SensorStatus
+	}
+	readingSubscriber interface {
+		Subscribe(sel sensor.Selector, buffer int, policy sensor.Backpressure) (*sensor.Subscription, error)
+	}
+	sensorCalibrator interface {
+		SetCalibration(cal sensor.Calibration) error
+		ClearCalibration(id sensor.SensorID) error
+		CaptureZero(id sensor.SensorID) (sensor.Calibration, error)
+		Calibrations() []sensor.Calibration
+	}
+	nlpConfigurer interface {
+		SetConfig(cfg nlp.Config) error
+	}
+	dialogProcessor interface {
+		ProcessDialog(d *nlp.Dialog, text string) (*nlp.Command, error)
+	}
+	patternTracker interface {
+		SetPattern(name string)
+	}
+	historyKeeper interface {
+		GetHistory() []nlp.Command
+		RestoreHistory(history []nlp.Command)
+	}
+	nlpStatter interface {
+		Stats() nlp.Stats
+		Misunderstood() []nlp.Misunderstanding
+	}
+	healthReporter interface {
+		Health() health.Report
+	}
+)
+
+// concrete subsystems must keep implementing everything core may use
+var (
+	_ interface {
+		MotionExecutor
+		busAttacher
+		storeAttacher
+		restartable
+		motionHolder
+		motionPower
+		speedLimiter
+		motionConfigurer
+		motionRestorer
+		programRunner
+		patternRecorder
+		syncMover
+		motorCalibrator
+		motorManager
+		moveTracker
+		jogger
+		telemetrySource
+		driverAttacher
+		watchdogSetter
+		patternArbiter
+		patternPreviewer
+		healthReporter
+	} = (*motion.Controller)(nil)
+	_ interface {
+		SensorSource
+		busAttacher
+		restartable
+		sourceAttacher
+		sampleSource
+		storeAttacher
+		sensorRegistry
+		zoneReader
+		sensorStatter
+		sensorCalibrator
+		readingSubscriber
+		sensorChecker
+		channelAttacher
+		sensorAlerter
+		sensorRecorder
+		tempLimiter
+		gestureReader
+		healthReporter
+	} = (*sensor.Hub)(nil)
+	_ interface {
+		NLPEngine
+		storeAttacher
+		nlpConfigurer
+		historyKeeper
+		nlpStatter
+		healthReporter
+	} = (*nlp.Processor)(nil)
+	_ healthReporter = (*neural.Network)(nil)
+	_ healthReporter = (*behavior.Analyzer)(nil)
+)
+
+// configureMotion applies system-wide motion settings to executor, before
+// its hardware is attached so drivers are armed with them
+func (s *System) configureMotion(m MotionExecutor) error {
+	if err := s.setWatchdog(m); err != nil {
+		return err
+	}
+	return s.setArbitration(m)
+}
+
+// superviseMotion tracks health of motion executor under name. Executors
+// that can't report failures are tracked as always healthy.
+func (s *System) superviseMotion(name string, m MotionExecutor) {
+	r, ok := m.(restartable)
+	if !ok {
+		s.supervisor.supervise(name, nil, nil)
+		return
+	}
+	r.OnFailure(func(err error) {
+		// motors may be mid-move with nobody ticking them
+		m.StopAll()
+		s.supervisor.report(name, err)
+	})
+	s.supervisor.supervise(name, nil, r.Restart)
+}
+
+// superviseSensors tracks health of sensor source under name
+func (s *System) superviseSensors(name string, src SensorSource) {
+	r, ok := src.(restartable)
+	if !ok {
+		s.supervisor.supervise(name, nil, nil)
+		return
+	}
+	r.OnFailure(func(err error) { s.supervisor.report(name, err) })
+	s.supervisor.supervise(name, nil, r.Restart)
+}
+
+// attachBus connects subsystem to event bus if it publishes events
+func attachBus(v interface{}, bus *event.Bus) {
+	if b, ok := v.(busAttacher); ok {
+		b.AttachBus(bus)
+	}
+}
+
+// attachStore connects subsystem to storage if it persists anything
+func attachStore(v interface{}, store *storage.Store) error {
+	if a, ok := v.(storeAttacher); ok {
+		return a.AttachStore(store)
+	}
+	return nil
+}
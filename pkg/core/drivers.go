@@ -0,0 +1,282 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/sashalind/sex-artifical-intelligence/pkg/clock"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/motion"
+)
+
+// motor driver kinds
+const (
+	driverPWM     = "pwm"       // Linux PWM channel through sysfs
+	driverPCA9685 = "pca9685"   // channel of PCA9685 board over I2C
+	driverDXL     = "dynamixel" // smart servo on Dynamixel serial bus
+	driverCANopen = "canopen"   // CiA 402 drive on SocketCAN interface
+	driverStepper = "stepper"   // step/dir stepper driver on GPIO lines
+)
+
+// MotorDriverConfig selects hardware backend of motor. Motors without one
+// are logical, as are all motors in simulation.
+type MotorDriverConfig struct {
+	Kind string `json:"kind"`
+
+	// Channel is output of PWM chip or board; MinPulse and MaxPulse are
+	// pulse widths at min and max position (default 1ms and 2ms)
+	Channel  int      `json:"channel"`
+	MinPulse Duration `json:"min_pulse"`
+	MaxPulse Duration `json:"max_pulse"`
+
+	// pwm: chip N of /sys/class/pwm/pwmchipN, frame length (default 20ms)
+	// and optional GPIO line switching servo power
+	Chip      int      `json:"chip"`
+	Period    Duration `json:"period"`
+	PowerGPIO *int     `json:"power_gpio,omitempty"`
+
+	// pca9685: i2c-dev bus like /dev/i2c-1, board address (default 0x40)
+	// and board frequency in Hz (default 50)
+	Bus       string  `json:"bus"`
+	Address   uint16  `json:"address"`
+	Frequency float64 `json:"frequency"`
+
+	// dynamixel: serial port like /dev/ttyUSB0, baud rate (default 57600),
+	// servo ID on bus and servo angle at motor position zero
+	Port    string  `json:"port"`
+	Baud    int     `json:"baud"`
+	ServoID uint8   `json:"servo_id"`
+	Offset  float64 `json:"offset"`
+
+	// canopen: SocketCAN interface like can0, drive node ID and drive
+	// position units per degree
+	Interface       string  `json:"interface"`
+	Node            uint8   `json:"node"`
+	CountsPerDegree float64 `json:"counts_per_degree"`
+
+	// stepper: GPIO lines of step, dir and optional active low enable
+	// input, full steps per turn (default 200), microsteps set on driver
+	// (default 1), cap on microsteps per second (default 1000) and dir
+	// reversal
+	StepGPIO    int     `json:"step_gpio"`
+	DirGPIO     int     `json:"dir_gpio"`
+	EnableGPIO  *int    `json:"enable_gpio,omitempty"`
+	StepsPerRev int     `json:"steps_per_rev"`
+	Microsteps  int     `json:"microsteps"`
+	MaxStepRate float64 `json:"max_step_rate"`
+	Reverse     bool    `json:"reverse"`
+}
+
+// validateDriver checks driver section of motor
+func (m MotorConfig) validateDriver() error {
+	if m.Driver == nil {
+		return nil
+	}
+	switch m.Driver.Kind {
+	case driverPWM:
+		return m.pwmOutput().Validate()
+	case driverPCA9685:
+		return m.pca9685Output().Validate()
+	case driverDXL:
+		return m.dynamixelOutput().Validate()
+	case driverCANopen:
+		return m.canopenOutput().Validate()
+	case driverStepper:
+		return m.stepperOutput().Validate()
+	}
+	return fmt.Errorf("motor %s: unknown driver kind %q", m.ID, m.Driver.Kind)
+}
+
+// pwmOutput converts pwm driver section
+func (m MotorConfig) pwmOutput() motion.PWMOutput {
+	lo, hi, pos := m.shaftRange()
+	return motion.PWMOutput{
+		Motor:       motion.MotorID(m.ID),
+		Chip:        m.Driver.Chip,
+		Channel:     m.Driver.Channel,
+		Period:      time.Duration(m.Driver.Period),
+		MinPulse:    time.Duration(m.Driver.MinPulse),
+		MaxPulse:    time.Duration(m.Driver.MaxPulse),
+		MinPosition: lo,
+		MaxPosition: hi,
+		Position:    pos,
+		PowerGPIO:   m.Driver.PowerGPIO,
+	}
+}
+
+// pca9685Output converts pca9685 driver section
+func (m MotorConfig) pca9685Output() motion.PCA9685Output {
+	lo, hi, pos := m.shaftRange()
+	return motion.PCA9685Output{
+		Motor:       motion.MotorID(m.ID),
+		Bus:         m.Driver.Bus,
+		Address:     m.Driver.Address,
+		Channel:     m.Driver.Channel,
+		Frequency:   m.Driver.Frequency,
+		MinPulse:    time.Duration(m.Driver.MinPulse),
+		MaxPulse:    time.Duration(m.Driver.MaxPulse),
+		MinPosition: lo,
+		MaxPosition: hi,
+		Position:    pos,
+	}
+}
+
+// dynamixelOutput converts dynamixel driver section
+func (m MotorConfig) dynamixelOutput() motion.DynamixelOutput {
+	return motion.DynamixelOutput{
+		Motor:  motion.MotorID(m.ID),
+		Port:   m.Driver.Port,
+		Baud:   m.Driver.Baud,
+		ID:     m.Driver.ServoID,
+		Offset: m.Driver.Offset,
+	}
+}
+
+// canopenOutput converts canopen driver section
+func (m MotorConfig) canopenOutput() motion.CANopenOutput {
+	return motion.CANopenOutput{
+		Motor:           motion.MotorID(m.ID),
+		Interface:       m.Driver.Interface,
+		Node:            m.Driver.Node,
+		CountsPerDegree: m.Driver.CountsPerDegree,
+	}
+}
+
+// stepperOutput converts stepper driver section
+func (m MotorConfig) stepperOutput() motion.StepperOutput {
+	_, _, pos := m.shaftRange()
+	return motion.StepperOutput{
+		Motor:       motion.MotorID(m.ID),
+		StepGPIO:    m.Driver.StepGPIO,
+		DirGPIO:     m.Driver.DirGPIO,
+		EnableGPIO:  m.Driver.EnableGPIO,
+		StepsPerRev: m.Driver.StepsPerRev,
+		Microsteps:  m.Driver.Microsteps,
+		MaxStepRate: m.Driver.MaxStepRate,
+		Reverse:     m.Driver.Reverse,
+		Position:    pos,
+	}
+}
+
+// motorDrivers returns driver sections by motor ID
+func motorDrivers(motors []MotorConfig) map[string]*MotorDriverConfig {
+	drivers := make(map[string]*MotorDriverConfig)
+	for _, m := range motors {
+		if m.Driver != nil {
+			drivers[m.ID] = m.Driver
+		}
+	}
+	return drivers
+}
+
+// hardwareDriver opens backends of motors that configure one and routes
+// motors through them, nil if every motor is logical
+func hardwareDriver(clk clock.Clock, motors []MotorConfig) (motion.Driver, error) {
+	mc, err := motorsConfig(motors, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		pwm motion.PWMConfig
+		pca motion.PCA9685Config
+		dxl motion.DynamixelConfig
+		can motion.CANopenConfig
+		stp motion.StepperConfig
+	)
+	byKind := make(map[string][]motion.MotorID)
+	for _, m := range motors {
+		if m.Driver == nil {
+			continue
+		}
+		switch m.Driver.Kind {
+		case driverPWM:
+			pwm.Outputs = append(pwm.Outputs, m.pwmOutput())
+		case driverPCA9685:
+			pca.Outputs = append(pca.Outputs, m.pca9685Output())
+		case driverDXL:
+			dxl.Outputs = append(dxl.Outputs, m.dynamixelOutput())
+		case driverCANopen:
+			can.Outputs = append(can.Outputs, m.canopenOutput())
+		case driverStepper:
+			stp.Outputs = append(stp.Outputs, m.stepperOutput())
+		}
+		byKind[m.Driver.Kind] = append(byKind[m.Driver.Kind], motion.MotorID(m.ID))
+	}
+	if len(byKind) == 0 {
+		return nil, nil
+	}
+
+	routes := make(map[motion.MotorID]motion.Driver)
+	open := func(kind string, newDriver func() (motion.Driver, error)) error {
+		if len(byKind[kind]) == 0 {
+			return nil
+		}
+		d, err := newDriver()
+		if err != nil {
+			return err
+		}
+		for _, id := range byKind[kind] {
+			routes[id] = d
+		}
+		return nil
+	}
+	err = open(driverPWM, func() (motion.Driver, error) { return motion.NewPWMDriver(clk, pwm) })
+	if err == nil {
+		err = open(driverPCA9685, func() (motion.Driver, error) { return motion.NewPCA9685Driver(clk, pca) })
+	}
+	if err == nil {
+		err = open(driverDXL, func() (motion.Driver, error) { return motion.NewDynamixelDriver(clk, dxl) })
+	}
+	if err == nil {
+		err = open(driverCANopen, func() (motion.Driver, error) { return motion.NewCANopenDriver(clk, can) })
+	}
+	if err == nil {
+		err = open(driverStepper, func() (motion.Driver, error) { return motion.NewStepperDriver(clk, stp) })
+	}
+	mux := motion.NewMux(clk, mc.Motors, routes)
+	if err != nil {
+		mux.Close()
+		return nil, err
+	}
+	return mux, nil
+}
+
+// attachHardware routes motor output of executor to hardware drivers from
+// config. Simulation replaces hardware, so nothing is opened then.
+func (s *System) attachHardware(m MotionExecutor, motors []MotorConfig) error {
+	if s.cfg.Simulation.Enabled {
+		return nil
+	}
+	d, err := hardwareDriver(s.clock, motors)
+	if err != nil || d == nil {
+		return err
+	}
+	a, ok := m.(driverAttacher)
+	if !ok {
+		d.Close()
+		return fmt.Errorf("%w: motion driver", ErrNotSupported)
+	}
+	a.SetDriver(d)
+	return nil
+}
+
+// ResetMotor has driver of motor on unit reboot its electronics, e.g. to
+// clear overload shutdown of smart servo. Empty unit means primary one.
+func (s *System) ResetMotor(ctx context.Context, unit UnitID, id string) error {
+	if err := s.Authorize(ctx, PermFirmware); err != nil {
+		return err
+	}
+	if unit == "" {
+		unit = PrimaryUnit
+	}
+	u, err := s.Unit(unit)
+	if err != nil {
+		return err
+	}
+	r, ok := u.motion.(motorResetter)
+	if !ok {
+		return ErrNotSupported
+	}
+	return r.ResetMotor(motion.MotorID(id))
+}
@@ -0,0 +1,62 @@
+// Package errs gives errors machine readable codes so API layers can map
+// failures to status codes without matching message strings. Subsystems
+// declare sentinel errors with New and wrap them with fmt.Errorf("%w")
+// as usual; CodeOf finds the code anywhere in the chain.
+package errs
+
+import "errors"
+
+// Code classifies error for callers
+type Code string
+
+const (
+	Internal           Code = "internal"
+	InvalidArgument    Code = "invalid_argument"
+	NotFound           Code = "not_found"
+	OutOfRange         Code = "out_of_range"
+	FailedPrecondition Code = "failed_precondition"
+	Unavailable        Code = "unavailable"
+	Cancelled          Code = "cancelled"
+	DeadlineExceeded   Code = "deadline_exceeded"
+	Unauthenticated    Code = "unauthenticated"
+	PermissionDenied   Code = "permission_denied"
+)
+
+// Error is sentinel error with code
+type Error struct {
+	code Code
+	msg  string
+}
+
+// New creates sentinel error with code
+func New(code Code, msg string) *Error {
+	return &Error{code: code, msg: msg}
+}
+
+// Error returns message
+func (e *Error) Error() string {
+	return e.msg
+}
+
+// Code returns error code
+func (e *Error) Code() Code {
+	return e.code
+}
+
+// coder is implemented by every error carrying code
+type coder interface {
+	Code() Code
+}
+
+// CodeOf returns code of first coded error in chain, Internal for errors
+// without code and empty string for nil
+func CodeOf(err error) Code {
+	if err == nil {
+		return ""
+	}
+	var c coder
+	if errors.As(err, &c) {
+		return c.Code()
+	}
+	return Internal
+}
@@ -0,0 +1,143 @@
+package event
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Topic is typed event channel name. Producers declare topics next to their
+// payload types, e.g. sensor.TopicReading carries sensor.SensorData.
+type Topic[T any] struct {
+	name string
+}
+
+// NewTopic declares topic carrying payloads of type T
+func NewTopic[T any](name string) Topic[T] {
+	return Topic[T]{name: name}
+}
+
+// Name returns topic name
+func (t Topic[T]) Name() string {
+	return t.name
+}
+
+// Bus is publish/subscribe hub connecting subsystems. Publishing never
+// blocks: slow subscribers lose events and their drop counter grows.
+type Bus struct {
+	mu   sync.RWMutex
+	subs map[string][]subscriber
+}
+
+// subscriber is type-erased subscription so bus can hold mixed topics
+type subscriber interface {
+	id() uint64
+	close()
+}
+
+var nextID atomic.Uint64
+
+// NewBus creates empty event bus
+func NewBus() *Bus {
+	return &Bus{subs: make(map[string][]subscriber)}
+}
+
+// Subscription receives events of one topic
+type Subscription[T any] struct {
+	C <-chan T
+
+	bus     *Bus
+	topic   string
+	subID   uint64
+	ch      chan T
+	dropped atomic.Uint64
+	once    sync.Once
+}
+
+func (s *Subscription[T]) id() uint64 { return s.subID }
+
+func (s *Subscription[T]) close() {
+	s.once.Do(func() { close(s.ch) })
+}
+
+// Dropped returns number of events lost because subscriber was too slow
+func (s *Subscription[T]) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+// Cancel unsubscribes and closes channel
+func (s *Subscription[T]) Cancel() {
+	s.bus.mu.Lock()
+	subs := s.bus.subs[s.topic]
+	for i, sub := range subs {
+		if sub.id() == s.subID {
+			s.bus.subs[s.topic] = append(subs[:i:i], subs[i+1:]...)
+			break
+		}
+	}
+	s.bus.mu.Unlock()
+
+	s.close()
+}
+
+// Subscribe registers for topic events with given channel buffer size
+func Subscribe[T any](b *Bus, topic Topic[T], buffer int) *Subscription[T] {
+	ch := make(chan T, buffer)
+	sub := &Subscription[T]{
+		C:     ch,
+		bus:   b,
+		topic: topic.name,
+		subID: nextID.Add(1),
+		ch:    ch,
+	}
+
+	b.mu.Lock()
+	b.subs[topic.name] = append(b.subs[topic.name], sub)
+	b.mu.Unlock()
+
+	return sub
+}
+
+// Publish delivers event to every subscriber of topic. Nil bus is no-op,
+// so subsystems can publish unconditionally whether or not bus is attached.
+func Publish[T any](b *Bus, topic Topic[T], ev T) {
+	if b == nil {
+		return
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for _, s := range b.subs[topic.name] {
+		sub := s.(*Subscription[T])
+		select {
+		case sub.ch <- ev:
+		default:
+			sub.dropped.Add(1)
+		}
+	}
+}
+
+// HasSubscribers reports whether anyone listens to topic, letting hot
+// paths skip building events nobody will read
+func HasSubscribers[T any](b *Bus, topic Topic[T]) bool {
+	if b == nil {
+		return false
+	}
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return len(b.subs[topic.name]) > 0
+}
+
+// Close cancels all subscriptions
+func (b *Bus) Close() {
+	b.mu.Lock()
+	subs := b.subs
+	b.subs = make(map[string][]subscriber)
+	b.mu.Unlock()
+
+	for _, list := range subs {
+		for _, s := range list {
+			s.close()
+		}
+	}
+}
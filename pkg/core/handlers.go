@@ -0,0 +1,96 @@
+package core
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sashalind/sex-artifical-intelligence/pkg/core/errs"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/nlp"
+)
+
+// CommandHandler executes parsed command. Session the command runs for, if
+// any, is available through SessionFromContext.
+type CommandHandler func(ctx context.Context, cmd *nlp.Command) error
+
+var (
+	ErrDuplicateHandler = errs.New(errs.FailedPrecondition, "command handler already registered")
+	ErrNoHandler        = errs.New(errs.NotFound, "no handler for command type")
+)
+
+// RegisterHandler makes h execute commands of type t. Plugins call it from
+// Start and UnregisterHandler from Stop. Types without handler, e.g. status,
+// only get response generated.
+func (s *System) RegisterHandler(t nlp.CommandType, h CommandHandler) error {
+	if h == nil {
+		return errs.New(errs.InvalidArgument, "command handler is nil")
+	}
+
+	s.handlersMu.Lock()
+	defer s.handlersMu.Unlock()
+
+	if _, dup := s.handlers[t]; dup {
+		return fmt.Errorf("%w: %s", ErrDuplicateHandler, t)
+	}
+	s.handlers[t] = h
+	return nil
+}
+
+// UnregisterHandler removes handler for command type
+func (s *System) UnregisterHandler(t nlp.CommandType) error {
+	s.handlersMu.Lock()
+	defer s.handlersMu.Unlock()
+
+	if _, ok := s.handlers[t]; !ok {
+		return fmt.Errorf("%w: %s", ErrNoHandler, t)
+	}
+	delete(s.handlers, t)
+	return nil
+}
+
+// HandledCommands lists command types with registered handler
+func (s *System) HandledCommands() []nlp.CommandType {
+	s.handlersMu.RLock()
+	defer s.handlersMu.RUnlock()
+
+	types := make([]nlp.CommandType, 0, len(s.handlers))
+	for t := range s.handlers {
+		types = append(types, t)
+	}
+	return types
+}
+
+// handler returns handler for command type, nil if none
+func (s *System) handler(t nlp.CommandType) CommandHandler {
+	s.handlersMu.RLock()
+	defer s.handlersMu.RUnlock()
+	return s.handlers[t]
+}
+
+// registerBuiltinHandlers installs handlers for commands core understands
+func (s *System) registerBuiltinHandlers() {
+	s.handlers = map[nlp.CommandType]CommandHandler{
+		nlp.CmdMove:     s.handleMovement,
+		nlp.CmdStop:     s.handleStop,
+		nlp.CmdAdjust:   s.handleAdjustment,
+		nlp.CmdPattern:  s.handlePattern,
+		nlp.CmdPause:    s.handlePause,
+		nlp.CmdResume:   s.handleResume,
+		nlp.CmdPreset:   s.handlePreset,
+		nlp.CmdSafeword: s.handleSafeword,
+		nlp.CmdSequence: s.handleSequence,
+	}
+}
+
+// commandSession returns session command runs for, nil for anonymous
+// commands or if session ended while command waited
+func (s *System) commandSession(ctx context.Context) *Session {
+	id, ok := SessionFromContext(ctx)
+	if !ok {
+		return nil
+	}
+	sess, err := s.Session(id)
+	if err != nil {
+		return nil
+	}
+	return sess
+}
@@ -0,0 +1,76 @@
+package core
+
+import (
+	"sort"
+	"time"
+
+	"github.com/sashalind/sex-artifical-intelligence/pkg/health"
+)
+
+// SystemHealth is unified health of every subsystem. Subsystem reports are
+// combined with supervisor state, so subsystem that failed or lost its
+// dependency is never shown healthier than supervisor sees it.
+type SystemHealth struct {
+	Status     health.Status            `json:"status"`
+	CheckedAt  time.Time                `json:"checked_at"`
+	Subsystems map[string]health.Report `json:"subsystems"`
+}
+
+// Down returns names of subsystems that are not working
+func (h SystemHealth) Down() []string {
+	var names []string
+	for name, r := range h.Subsystems {
+		if r.Status == health.Down {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Health collects reports of all subsystems. Injected subsystems without
+// Health method are reported by supervisor state alone.
+func (s *System) Health() SystemHealth {
+	h := SystemHealth{
+		Status:     health.OK,
+		CheckedAt:  s.clock.Now(),
+		Subsystems: make(map[string]health.Report),
+	}
+	add := func(name string, v interface{}) {
+		r := health.Report{Status: health.OK}
+		if hr, ok := v.(healthReporter); ok {
+			r = hr.Health()
+		}
+		h.Subsystems[name] = r
+	}
+
+	add("neural", s.neuralNet)
+	add("behavior", s.behavior)
+	add("nlp", s.nlpProc)
+	for _, u := range s.units {
+		add(motionUnit(u.ID), u.motion)
+		add(sensorUnit(u.ID), u.sensors)
+	}
+
+	for _, st := range s.supervisor.statuses() {
+		r, ok := h.Subsystems[st.Name]
+		if !ok {
+			r.Status = health.OK
+		}
+		switch st.State {
+		case SubsystemFailed:
+			r.Status = health.Down
+		case SubsystemDegraded:
+			r.Status = health.Worse(r.Status, health.Degraded)
+		}
+		if r.LastError == "" {
+			r.LastError = st.LastError
+		}
+		h.Subsystems[st.Name] = r
+	}
+
+	for _, r := range h.Subsystems {
+		h.Status = health.Worse(h.Status, r.Status)
+	}
+	return h
+}
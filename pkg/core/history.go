@@ -0,0 +1,458 @@
+package core
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/sashalind/sex-artifical-intelligence/pkg/clock"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/core/errs"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/nlp"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/storage"
+)
+
+// defaultHistorySize is number of finished commands kept for queries
+const defaultHistorySize = 1000
+
+// historyCompactInterval is how often entries history dropped are at most
+// deleted from disk
+const historyCompactInterval = time.Minute
+
+// HistoryConfig controls command history kept for queries
+type HistoryConfig struct {
+	// Size bounds entries kept in memory and on disk
+	Size int `json:"size"`
+
+	// Persist writes history to data directory so it survives restarts
+	Persist bool `json:"persist"`
+
+	// File appends history to JSON lines file other tools can read, with
+	// or without Persist. It is compacted to retained entries at start and
+	// is plaintext, so encrypted storage refuses it.
+	File string `json:"file,omitempty"`
+
+	// MaxAge drops entries older than it, zero keeps them until Size
+	// pushes them out
+	MaxAge Duration `json:"max_age,omitempty"`
+
+	// Redact keeps no command text, parameters, error or reply text, only
+	// types, sessions, timing, outcomes and error codes. NLP command audit
+	// and misunderstood log keep no text either.
+	Redact bool `json:"redact,omitempty"`
+}
+
+// ErrPlaintextHistory is returned when history file would leak history
+// of encrypted storage
+var ErrPlaintextHistory = errs.New(errs.FailedPrecondition, "history file is not encrypted, remove history.file or store data without encryption")
+
+// Validate checks history options
+func (c HistoryConfig) Validate() error {
+	if c.Size <= 0 {
+		return fmt.Errorf("history size must be positive")
+	}
+	if c.MaxAge < 0 {
+		return fmt.Errorf("history max_age must not be negative")
+	}
+	return nil
+}
+
+// Outcome tells how command ended
+type Outcome string
+
+const (
+	OutcomeOK        Outcome = "ok"
+	OutcomeFailed    Outcome = "failed"
+	OutcomeRejected  Outcome = "rejected"  // refused before queueing, e.g. by mode
+	OutcomeCancelled Outcome = "cancelled" // cancelled, preempted or expired in queue
+)
+
+// HistoryEntry is finished command with its response
+type HistoryEntry struct {
+	ID         CommandID              `json:"id,omitempty"`
+	Text       string                 `json:"text"`
+	Type       nlp.CommandType        `json:"type"`
+	Parameters map[string]interface{} `json:"parameters,omitempty"`
+	Session    SessionID              `json:"session,omitempty"`
+	TraceID    string                 `json:"trace_id,omitempty"`
+	EnqueuedAt time.Time              `json:"enqueued_at"`
+	FinishedAt time.Time              `json:"finished_at"`
+	Outcome    Outcome                `json:"outcome"`
+	Error      string                 `json:"error,omitempty"`
+	Response   *nlp.Response          `json:"response,omitempty"`
+}
+
+// HistoryQuery selects history entries, zero fields match everything
+type HistoryQuery struct {
+	Since    time.Time
+	Until    time.Time
+	Types    []nlp.CommandType
+	Outcomes []Outcome
+	Session  SessionID
+
+	// Limit caps number of entries returned, newest are kept
+	Limit int
+}
+
+// match reports whether entry satisfies query
+func (q HistoryQuery) match(e HistoryEntry) bool {
+	if !q.Since.IsZero() && e.FinishedAt.Before(q.Since) {
+		return false
+	}
+	if !q.Until.IsZero() && !e.FinishedAt.Before(q.Until) {
+		return false
+	}
+	if len(q.Types) > 0 && !slices.Contains(q.Types, e.Type) {
+		return false
+	}
+	if len(q.Outcomes) > 0 && !slices.Contains(q.Outcomes, e.Outcome) {
+		return false
+	}
+	return q.Session == "" || q.Session == e.Session
+}
+
+// outcomeOf classifies command error
+func outcomeOf(err error) Outcome {
+	switch {
+	case err == nil:
+		return OutcomeOK
+	case errors.Is(err, ErrQueueStopped):
+		return OutcomeCancelled
+	}
+	switch errs.CodeOf(err) {
+	case errs.Cancelled, errs.DeadlineExceeded:
+		return OutcomeCancelled
+	}
+	return OutcomeFailed
+}
+
+// commandHistory keeps finished commands, oldest first
+type commandHistory struct {
+	mu      sync.RWMutex
+	cfg     HistoryConfig
+	clock   clock.Clock
+	entries []HistoryEntry
+	table   *storage.Table[HistoryEntry] // nil unless persisting
+	file    *os.File                     // nil without cfg.File
+
+	// entries dropped from memory but still on disk, since compactedAt
+	stale       int
+	compactedAt time.Time
+}
+
+func newCommandHistory(cfg HistoryConfig, clk clock.Clock) *commandHistory {
+	return &commandHistory{cfg: cfg, clock: clk}
+}
+
+// open loads history of JSON lines file, dropping what retention does not
+// keep, and appends new entries to it
+func (h *commandHistory) open() error {
+	if h.cfg.File == "" {
+		return nil
+	}
+	saved, err := readHistoryFile(h.cfg.File)
+	if err != nil {
+		return err
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.entries = append(saved, h.entries...)
+	h.trimLocked()
+	return h.compactLocked()
+}
+
+// close stops writing JSON lines file
+func (h *commandHistory) close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.file != nil {
+		h.file.Close()
+		h.file = nil
+	}
+}
+
+// readHistoryFile reads entries of JSON lines file, missing file has none
+func readHistoryFile(path string) ([]HistoryEntry, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []HistoryEntry
+	sc := bufio.NewScanner(f)
+	sc.Buffer(nil, 1<<20)
+	for line := 1; sc.Scan(); line++ {
+		if len(sc.Bytes()) == 0 {
+			continue
+		}
+		var e HistoryEntry
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			// torn last line of crash, rest is still good
+			log.Printf("Skipping bad history line %d of %s: %v", line, path, err)
+			continue
+		}
+		entries = append(entries, e)
+	}
+	return entries, sc.Err()
+}
+
+// rewriteLocked replaces JSON lines file with entries kept and reopens it
+// for appending
+func (h *commandHistory) rewriteLocked() error {
+	if h.cfg.File == "" {
+		return nil
+	}
+	if h.file != nil {
+		h.file.Close()
+		h.file = nil
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(h.cfg.File), filepath.Base(h.cfg.File)+".tmp*")
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(tmp)
+	enc := json.NewEncoder(w)
+	for _, e := range h.entries {
+		if err := enc.Encode(e); err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), h.cfg.File); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	h.file, err = os.OpenFile(h.cfg.File, os.O_APPEND|os.O_WRONLY, 0o600)
+	return err
+}
+
+// attach loads persisted history and writes new entries to store
+func (h *commandHistory) attach(store *storage.Store) error {
+	if !h.cfg.Persist {
+		return nil
+	}
+	table, err := storage.OpenTable[HistoryEntry](store, storage.BucketHistory)
+	if err != nil {
+		return err
+	}
+	saved, err := table.All()
+	if err != nil {
+		return err
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, e := range h.entries {
+		if _, err := table.Append(e); err != nil {
+			return err
+		}
+	}
+	if err := table.Trim(h.cfg.Size); err != nil {
+		return err
+	}
+	h.entries = append(saved, h.entries...)
+	h.trimLocked()
+	h.table = table
+	return h.compactLocked()
+}
+
+// add appends entry
+func (h *commandHistory) add(e HistoryEntry) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.entries = append(h.entries, e)
+	h.stale += h.trimLocked()
+	if h.table != nil {
+		if _, err := h.table.AppendTrim(e, h.cfg.Size); err != nil {
+			log.Printf("Failed to persist command history: %v", err)
+		}
+	}
+	if h.file != nil {
+		if err := h.appendLocked(e); err != nil {
+			log.Printf("Failed to write command history file: %v", err)
+		}
+	}
+	if h.stale > 0 && (h.stale >= h.cfg.Size || h.clock.Since(h.compactedAt) >= historyCompactInterval) {
+		if err := h.compactLocked(); err != nil {
+			log.Printf("Failed to compact command history: %v", err)
+		}
+	}
+}
+
+// appendLocked writes entry to JSON lines file
+func (h *commandHistory) appendLocked(e HistoryEntry) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	_, err = h.file.Write(append(data, '\n'))
+	return err
+}
+
+// compactLocked deletes entries no longer kept in memory from disk
+func (h *commandHistory) compactLocked() error {
+	h.stale, h.compactedAt = 0, h.clock.Now()
+	if h.table != nil {
+		h.pruneTableLocked()
+	}
+	return h.rewriteLocked()
+}
+
+// trimLocked drops entries past size and older than max age, returning
+// how many it dropped
+func (h *commandHistory) trimLocked() int {
+	n := len(h.entries)
+	if cutoff, ok := h.cutoff(); ok {
+		i := 0
+		for i < len(h.entries) && h.entries[i].FinishedAt.Before(cutoff) {
+			i++
+		}
+		h.entries = h.entries[i:]
+	}
+	if len(h.entries) > h.cfg.Size {
+		h.entries = h.entries[len(h.entries)-h.cfg.Size:]
+	}
+	if len(h.entries) < n {
+		h.entries = append([]HistoryEntry(nil), h.entries...)
+	}
+	return n - len(h.entries)
+}
+
+// cutoff returns time entries finished before are past max age
+func (h *commandHistory) cutoff() (time.Time, bool) {
+	if h.cfg.MaxAge <= 0 {
+		return time.Time{}, false
+	}
+	return h.clock.Now().Add(-time.Duration(h.cfg.MaxAge)), true
+}
+
+// pruneTableLocked deletes persisted entries no longer kept in memory
+func (h *commandHistory) pruneTableLocked() {
+	h.deleteTableLocked(func(e HistoryEntry) bool {
+		return len(h.entries) == 0 || e.FinishedAt.Before(h.entries[0].FinishedAt)
+	})
+}
+
+// deleteTableLocked deletes persisted entries drop selects
+func (h *commandHistory) deleteTableLocked(drop func(HistoryEntry) bool) {
+	if _, err := h.table.DeleteFunc(drop); err != nil {
+		log.Printf("Failed to delete command history entries: %v", err)
+	}
+}
+
+// forget deletes entries matching query from memory, store and file,
+// returning how many were deleted
+func (h *commandHistory) forget(q HistoryQuery) (int, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	kept := h.entries[:0:0]
+	for _, e := range h.entries {
+		if !q.match(e) {
+			kept = append(kept, e)
+		}
+	}
+	n := len(h.entries) - len(kept)
+	h.entries = kept
+	if h.table != nil {
+		h.deleteTableLocked(q.match)
+	}
+	// disk may hold matching entries memory dropped already
+	return n, h.compactLocked()
+}
+
+// query returns matching entries, newest first
+func (h *commandHistory) query(q HistoryQuery) []HistoryEntry {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	// entries past max age wait for next add to be dropped
+	cutoff, expires := h.cutoff()
+	var out []HistoryEntry
+	for i := len(h.entries) - 1; i >= 0; i-- {
+		if expires && h.entries[i].FinishedAt.Before(cutoff) {
+			break
+		}
+		if !q.match(h.entries[i]) {
+			continue
+		}
+		out = append(out, h.entries[i])
+		if q.Limit > 0 && len(out) == q.Limit {
+			break
+		}
+	}
+	return out
+}
+
+// recordCommand adds finished queue entry to history
+func (s *System) recordCommand(e *queueEntry, resp *nlp.Response, err error) {
+	s.history.add(s.historyEntry(e, resp, err))
+}
+
+// historyEntry describes queue entry that ended with resp or err
+func (s *System) historyEntry(e *queueEntry, resp *nlp.Response, err error) HistoryEntry {
+	entry := HistoryEntry{
+		ID:         e.info.ID,
+		Text:       e.info.Text,
+		Type:       e.info.Type,
+		Parameters: e.cmd.Parameters,
+		Session:    e.info.Session,
+		TraceID:    e.info.TraceID,
+		EnqueuedAt: e.info.EnqueuedAt,
+		FinishedAt: s.clock.Now(),
+		Outcome:    outcomeOf(err),
+		Response:   resp,
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	if s.history.cfg.Redact {
+		entry.Text, entry.Parameters = "", nil
+		if err != nil {
+			entry.Error = string(errs.CodeOf(err))
+		}
+		if resp != nil {
+			redacted := *resp
+			redacted.Text = ""
+			entry.Response = &redacted
+		}
+	}
+	return entry
+}
+
+// CommandHistory returns finished commands matching query, newest first
+func (s *System) CommandHistory(q HistoryQuery) []HistoryEntry {
+	return s.history.query(q)
+}
+
+// ForgetHistory deletes finished commands matching query from memory and
+// persisted history, e.g. everything of one session; Limit is ignored. It
+// returns number of commands deleted.
+func (s *System) ForgetHistory(q HistoryQuery) (int, error) {
+	q.Limit = 0
+	return s.history.forget(q)
+}
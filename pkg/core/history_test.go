@@ -0,0 +1,113 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/sashalind/sex-artifical-intelligence/pkg/clock"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/core/errs"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/nlp"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/secure"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/storage"
+)
+
+func newHistorySystem(t *testing.T, history HistoryConfig) *System {
+	t.Helper()
+	cfg := DefaultConfig()
+	cfg.Clock = clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	cfg.SelfTest.Disabled = true
+	cfg.History = history
+	sys, err := NewSystemWithConfig(cfg)
+	if err != nil {
+		t.Fatalf("NewSystemWithConfig: %v", err)
+	}
+	t.Cleanup(sys.Shutdown)
+	return sys
+}
+
+func TestHistoryFileRefusedWithEncryptedStore(t *testing.T) {
+	history := DefaultConfig().History
+	history.File = filepath.Join(t.TempDir(), "history.jsonl")
+	sys := newHistorySystem(t, history)
+
+	c, err := secure.NewCipher(bytes.Repeat([]byte{7}, secure.KeySize))
+	if err != nil {
+		t.Fatal(err)
+	}
+	store, err := storage.Open(t.TempDir(), c)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	if err := sys.AttachStore(store); !errors.Is(err, ErrPlaintextHistory) {
+		t.Fatalf("AttachStore = %v, want ErrPlaintextHistory", err)
+	}
+	if sys.Store() != nil {
+		t.Error("store attached")
+	}
+}
+
+func TestRedactedHistoryEntry(t *testing.T) {
+	history := DefaultConfig().History
+	history.Redact = true
+	sys := newHistorySystem(t, history)
+
+	e := &queueEntry{
+		info: QueuedCommand{ID: 1, Text: "move to 40 percent", Type: nlp.CmdMove},
+		cmd:  &nlp.Command{Type: nlp.CmdMove, Parameters: map[string]interface{}{"position": 40.0}},
+	}
+	failed := fmt.Errorf("motor servo_1 at 40 percent stalled: %w", errs.New(errs.Unavailable, "motor unavailable"))
+	entry := sys.historyEntry(e, &nlp.Response{Text: "Moving to 40 percent"}, failed)
+
+	if entry.Text != "" || entry.Parameters != nil || entry.Response.Text != "" {
+		t.Errorf("entry keeps text: %+v", entry)
+	}
+	if want := string(errs.Unavailable); entry.Error != want {
+		t.Errorf("Error = %q, want %q", entry.Error, want)
+	}
+}
+
+func TestQueueFinishesOutsideLock(t *testing.T) {
+	var q *commandQueue
+	finished := map[CommandID]error{}
+	q = newCommandQueue(func(e *queueEntry, _ *nlp.Response, err error) {
+		if !q.mu.TryLock() {
+			t.Errorf("command %d finished with queue locked", e.info.ID)
+			return
+		}
+		q.mu.Unlock()
+		finished[e.info.ID] = err
+	})
+	push := func(typ nlp.CommandType) CommandID {
+		e := &queueEntry{
+			ctx:    context.Background(),
+			cmd:    &nlp.Command{Type: typ},
+			ticket: &Ticket{done: make(chan struct{})},
+		}
+		if err := q.push(e); err != nil {
+			t.Fatalf("push %s: %v", typ, err)
+		}
+		return e.info.ID
+	}
+
+	moved := push(nlp.CmdMove)
+	push(nlp.CmdStop)
+	status := push(nlp.CmdStatus)
+	if err := q.cancel(status); err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+	close(q.exited)
+	q.stop()
+
+	want := map[CommandID]error{moved: ErrCommandPreempted, status: ErrCommandCancelled, 2: ErrQueueStopped}
+	if !reflect.DeepEqual(finished, want) {
+		t.Errorf("finished = %v, want %v", finished, want)
+	}
+}
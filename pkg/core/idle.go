@@ -0,0 +1,217 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/sashalind/sex-artifical-intelligence/pkg/core/event"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/motion"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/sensor"
+)
+
+// IdleConfig controls automatic power-down
+type IdleConfig struct {
+	// Timeout without commands or sensor activity before powering down,
+	// 0 disables power-down
+	Timeout Duration `json:"timeout"`
+
+	// Park positions of primary unit motors, motors not listed park at
+	// their minimum position
+	Park map[string]float64 `json:"park"`
+}
+
+const (
+	// defaultIdleTimeout powers down forgotten robot
+	defaultIdleTimeout = 30 * time.Minute
+
+	// parkTimeout bounds wait for motors to reach park position
+	parkTimeout = 5 * time.Second
+
+	// parkTolerance is distance counted as parked, degrees
+	parkTolerance = 0.5
+)
+
+// validate checks park positions against primary motor layout
+func (c IdleConfig) validate(motors []MotorConfig) error {
+	if c.Timeout < 0 {
+		return fmt.Errorf("idle timeout must not be negative")
+	}
+	byID := make(map[string]MotorConfig, len(motors))
+	for _, m := range motors {
+		byID[m.ID] = m
+	}
+	for id, pos := range c.Park {
+		m, ok := byID[id]
+		if !ok {
+			return fmt.Errorf("idle park: unknown motor %s", id)
+		}
+		if pos < m.MinPosition || pos > m.MaxPosition {
+			return fmt.Errorf("idle park: motor %s position %g out of range", id, pos)
+		}
+	}
+	return nil
+}
+
+// idleManager tracks last command or sensor activity
+type idleManager struct {
+	mu           sync.Mutex
+	cfg          IdleConfig
+	lastActivity time.Time
+}
+
+func (im *idleManager) setConfig(cfg IdleConfig) {
+	im.mu.Lock()
+	defer im.mu.Unlock()
+	im.cfg = cfg
+}
+
+func (im *idleManager) config() IdleConfig {
+	im.mu.Lock()
+	defer im.mu.Unlock()
+	return im.cfg
+}
+
+func (im *idleManager) touch(now time.Time) {
+	im.mu.Lock()
+	defer im.mu.Unlock()
+	im.lastActivity = now
+}
+
+// expired reports whether timeout passed since last activity
+func (im *idleManager) expired(now time.Time) bool {
+	im.mu.Lock()
+	defer im.mu.Unlock()
+	return im.cfg.Timeout > 0 && now.Sub(im.lastActivity) >= time.Duration(im.cfg.Timeout)
+}
+
+// runIdle powers system down after idle timeout and wakes it on sensor activity
+func (s *System) runIdle() {
+	readings := event.Subscribe(s.bus, sensor.TopicReading, 256)
+	defer readings.Cancel()
+
+	ticker := s.clock.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	s.idle.touch(s.clock.Now())
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case data, ok := <-readings.C:
+			if !ok {
+				return
+			}
+			if isActivity(data) {
+				s.markActivity()
+			}
+		case now := <-ticker.C():
+			if s.Mode() == ModeIdle && s.idle.expired(now) {
+				s.powerDown()
+			}
+		}
+	}
+}
+
+// markActivity resets idle timer, waking system if it is powered down
+func (s *System) markActivity() {
+	s.idle.touch(s.clock.Now())
+	if s.Mode() == ModeLowPower {
+		log.Println("Activity detected, waking up")
+		if err := s.SetMode(ModeIdle); err != nil {
+			log.Printf("Wake up failed: %v", err)
+		}
+	}
+}
+
+// powerDown parks motors and enters low-power mode
+func (s *System) powerDown() {
+	log.Println("Idle timeout reached, parking motors and powering down")
+
+	ctx, cancel := context.WithTimeout(s.ctx, parkTimeout)
+	defer cancel()
+
+	park := s.idle.config().Park
+	for _, u := range s.units {
+		if err := s.park(ctx, u, park); err != nil {
+			log.Printf("Failed to park unit %s: %v", u.ID, err)
+		}
+	}
+
+	// activity during parking cancels power-down
+	if s.Mode() != ModeIdle || !s.idle.expired(s.clock.Now()) {
+		return
+	}
+	if err := s.SetMode(ModeLowPower); err != nil {
+		log.Printf("Power-down failed: %v", err)
+	}
+}
+
+// park moves enabled motors of unit to park positions and waits until they
+// arrive, positions are only configured for primary unit
+func (s *System) park(ctx context.Context, u *Unit, positions map[string]float64) error {
+	var cmds []motion.MotorCommand
+	target := make(map[motion.MotorID]float64)
+	for _, m := range u.motion.GetMotors() {
+		if !m.IsEnabled {
+			continue
+		}
+		pos, ok := positions[string(m.ID)]
+		if !ok || u.ID != PrimaryUnit {
+			pos = m.MinPosition
+		}
+		cmds = append(cmds, motion.MotorCommand{ID: m.ID, Position: pos, Speed: m.MaxSpeed})
+		target[m.ID] = pos
+	}
+	if len(cmds) == 0 {
+		return nil
+	}
+	if err := u.motion.ExecuteGroup(ctx, cmds); err != nil {
+		return err
+	}
+	return s.waitPositions(ctx, u, target, parkTolerance)
+}
+
+// waitPositions polls until motors of unit are within tolerance of target
+// positions or ctx ends
+func (s *System) waitPositions(ctx context.Context, u *Unit, target map[motion.MotorID]float64, tolerance float64) error {
+	for {
+		arrived := true
+		for _, m := range u.motion.GetMotors() {
+			if pos, ok := target[m.ID]; ok && math.Abs(m.Position-pos) > tolerance {
+				arrived = false
+				break
+			}
+		}
+		if arrived {
+			return nil
+		}
+		select {
+		case <-s.clock.After(100 * time.Millisecond):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// applyLowPower cuts and restores motor power, runs as mode change callback
+func (s *System) applyLowPower(c ModeChange) {
+	switch {
+	case c.To == ModeLowPower:
+		for _, u := range s.units {
+			if p, ok := u.motion.(motionPower); ok {
+				p.PowerDown()
+			}
+		}
+	case c.From == ModeLowPower:
+		for _, u := range s.units {
+			if p, ok := u.motion.(motionPower); ok {
+				p.PowerUp()
+			}
+		}
+		s.idle.touch(s.clock.Now())
+	}
+}
@@ -0,0 +1,237 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	"github.com/sashalind/sex-artifical-intelligence/pkg/core/errs"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/motion"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/secure"
+)
+
+// PatternFileFormat identifies pattern files
+const PatternFileFormat = "sai-patterns"
+
+// PatternFileVersion is current pattern file schema version, same
+// compatibility rules as SnapshotVersion
+const PatternFileVersion = 1
+
+var (
+	ErrInvalidPatternFile     = errs.New(errs.InvalidArgument, "not a pattern file")
+	ErrUnsupportedPatternFile = errs.New(errs.FailedPrecondition, "pattern file version is newer than supported")
+)
+
+// PatternFile is set of patterns and programs shared between
+// installations. Patterns use snapshot layout.
+type PatternFile struct {
+	Format   string            `json:"format"`
+	Version  int               `json:"version"`
+	Patterns []PatternSnapshot `json:"patterns"`
+	Programs []PatternProgram  `json:"programs,omitempty"`
+}
+
+// ExportPatterns packs named patterns and programs of primary unit into
+// pattern file, everything when no names are given
+func (s *System) ExportPatterns(names ...string) (*PatternFile, error) {
+	f := &PatternFile{
+		Format:   PatternFileFormat,
+		Version:  PatternFileVersion,
+		Patterns: []PatternSnapshot{},
+	}
+	wanted := make(map[string]bool, len(names))
+	for _, name := range names {
+		wanted[name] = true
+	}
+
+	found := make(map[string]bool)
+	for _, p := range s.motionCtrl.GetPatterns() {
+		if len(names) == 0 || wanted[p.Name] {
+			f.Patterns = append(f.Patterns, patternSnapshot(p))
+			found[p.Name] = true
+		}
+	}
+	for _, p := range s.Programs() {
+		if len(names) == 0 || wanted[p.Name] {
+			f.Programs = append(f.Programs, p)
+			found[p.Name] = true
+		}
+	}
+	for _, name := range names {
+		if !found[name] {
+			return nil, fmt.Errorf("%w: %s", motion.ErrPatternNotFound, name)
+		}
+	}
+	return f, nil
+}
+
+// ImportPatterns adds patterns and programs of file to primary unit,
+// replacing ones with same names. Nothing is added unless whole file is
+// valid.
+func (s *System) ImportPatterns(f *PatternFile) error {
+	if f == nil || f.Format != PatternFileFormat || f.Version < 1 {
+		return ErrInvalidPatternFile
+	}
+	if f.Version > PatternFileVersion {
+		return fmt.Errorf("%w: version %d, supported %d",
+			ErrUnsupportedPatternFile, f.Version, PatternFileVersion)
+	}
+
+	restorer, ok := s.motionCtrl.(motionRestorer)
+	if !ok {
+		return fmt.Errorf("%w: pattern import", ErrNotSupported)
+	}
+	runner, canPrograms := s.motionCtrl.(programRunner)
+	if len(f.Programs) > 0 && !canPrograms {
+		return fmt.Errorf("%w: program import", ErrNotSupported)
+	}
+
+	var problems []error
+	patterns := make([]motion.MovementPattern, 0, len(f.Patterns))
+	for _, ps := range f.Patterns {
+		p := ps.pattern()
+		if err := checkPattern(p); err != nil {
+			problems = append(problems, err)
+		}
+		patterns = append(patterns, p)
+	}
+	programs := make([]motion.PatternProgram, 0, len(f.Programs))
+	for _, pp := range f.Programs {
+		p := motion.PatternProgram{Name: pp.Name, Root: pp.Root.motion()}
+		if err := p.Validate(); err != nil {
+			problems = append(problems, err)
+		}
+		programs = append(programs, p)
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: %w", ErrInvalidPatternFile, errors.Join(problems...))
+	}
+
+	for _, p := range patterns {
+		restorer.AddPattern(p)
+	}
+	for _, p := range programs {
+		if err := runner.AddProgram(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkPattern validates pattern coming from outside
+func checkPattern(p motion.MovementPattern) error {
+	if p.Name == "" {
+		return errors.New("pattern without name")
+	}
+	if p.Duration < 0 {
+		return fmt.Errorf("pattern %s: negative duration", p.Name)
+	}
+	if len(p.Offsets) > 0 && len(p.Offsets) != len(p.Commands) {
+		return fmt.Errorf("pattern %s: %d offsets for %d commands", p.Name, len(p.Offsets), len(p.Commands))
+	}
+	for _, at := range p.Offsets {
+		if at < 0 || at > p.Duration {
+			return fmt.Errorf("pattern %s: offset %v outside duration", p.Name, at)
+		}
+	}
+	for _, w := range p.Waveforms {
+		if err := w.Validate(); err != nil {
+			return fmt.Errorf("pattern %s: %w", p.Name, err)
+		}
+	}
+	return nil
+}
+
+// WritePatternFile encodes pattern file as JSON
+func WritePatternFile(w io.Writer, f *PatternFile) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(f)
+}
+
+// ReadPatternFile decodes pattern file
+func ReadPatternFile(r io.Reader) (*PatternFile, error) {
+	var f PatternFile
+	if err := json.NewDecoder(r).Decode(&f); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPatternFile, err)
+	}
+	if f.Format != PatternFileFormat {
+		return nil, ErrInvalidPatternFile
+	}
+	return &f, nil
+}
+
+// SavePatternDir writes every pattern and program of primary unit to dir,
+// one file each named after it
+func (s *System) SavePatternDir(dir string) error {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	all, err := s.ExportPatterns()
+	if err != nil {
+		return err
+	}
+
+	// programs get suffix so pattern and program of same name don't clash
+	files := make(map[string]*PatternFile)
+	for _, p := range all.Patterns {
+		files[p.Name] = &PatternFile{Format: all.Format, Version: all.Version, Patterns: []PatternSnapshot{p}}
+	}
+	for _, p := range all.Programs {
+		files[p.Name+".program"] = &PatternFile{Format: all.Format, Version: all.Version, Patterns: []PatternSnapshot{}, Programs: []PatternProgram{p}}
+	}
+
+	var problems []error
+	for name, f := range files {
+		var buf bytes.Buffer
+		if err := WritePatternFile(&buf, f); err != nil {
+			problems = append(problems, err)
+			continue
+		}
+		path := filepath.Join(dir, url.PathEscape(name)+".json")
+		if err := secure.WriteFile(path, buf.Bytes(), nil); err != nil {
+			problems = append(problems, err)
+		}
+	}
+	return errors.Join(problems...)
+}
+
+// LoadPatternDir imports every .json pattern file in dir. Broken files are
+// reported without stopping others from loading.
+func (s *System) LoadPatternDir(dir string) (int, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return 0, err
+	}
+
+	loaded := 0
+	var problems []error
+	for _, path := range paths {
+		if err := s.importPatternFile(path); err != nil {
+			problems = append(problems, fmt.Errorf("%s: %w", filepath.Base(path), err))
+			continue
+		}
+		loaded++
+	}
+	return loaded, errors.Join(problems...)
+}
+
+// importPatternFile imports pattern file at path
+func (s *System) importPatternFile(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	f, err := ReadPatternFile(file)
+	if err != nil {
+		return err
+	}
+	return s.ImportPatterns(f)
+}
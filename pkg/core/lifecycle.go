@@ -0,0 +1,169 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+)
+
+var (
+	ErrDuplicateHook     = errors.New("lifecycle hook already registered")
+	ErrUnknownDependency = errors.New("lifecycle dependency not registered")
+	ErrDependencyCycle   = errors.New("lifecycle dependency cycle")
+	ErrAlreadyStarted    = errors.New("lifecycle already started")
+)
+
+// Hook is one unit managed by Lifecycle. Start runs after every dependency
+// has started; Stop runs before any of them stops. Either func may be nil.
+type Hook struct {
+	Name      string
+	DependsOn []string
+	Start     func() error
+	Stop      func()
+}
+
+// Lifecycle starts hooks in dependency order and stops them in reverse.
+// If any Start fails, hooks already started are stopped again, so caller
+// never sees half-initialized system.
+type Lifecycle struct {
+	mu      sync.Mutex
+	hooks   []Hook
+	index   map[string]int
+	started []Hook
+	running bool
+}
+
+// NewLifecycle creates empty lifecycle manager
+func NewLifecycle() *Lifecycle {
+	return &Lifecycle{index: make(map[string]int)}
+}
+
+// Register adds hook. Dependencies may be registered later, they are only
+// resolved by Start.
+func (l *Lifecycle) Register(h Hook) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if h.Name == "" {
+		return errors.New("lifecycle hook name is empty")
+	}
+	if _, exists := l.index[h.Name]; exists {
+		return fmt.Errorf("%w: %s", ErrDuplicateHook, h.Name)
+	}
+	if l.running {
+		return fmt.Errorf("%w: cannot register %s", ErrAlreadyStarted, h.Name)
+	}
+
+	l.index[h.Name] = len(l.hooks)
+	l.hooks = append(l.hooks, h)
+	return nil
+}
+
+// Order returns hook names in start order
+func (l *Lifecycle) Order() ([]string, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	order, err := l.resolve()
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, len(order))
+	for i, h := range order {
+		names[i] = h.Name
+	}
+	return names, nil
+}
+
+// resolve sorts hooks topologically, ties keep registration order.
+// Caller holds mu.
+func (l *Lifecycle) resolve() ([]Hook, error) {
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := make([]int, len(l.hooks))
+	order := make([]Hook, 0, len(l.hooks))
+
+	var visit func(i int) error
+	visit = func(i int) error {
+		switch state[i] {
+		case done:
+			return nil
+		case visiting:
+			return fmt.Errorf("%w at %s", ErrDependencyCycle, l.hooks[i].Name)
+		}
+		state[i] = visiting
+		for _, dep := range l.hooks[i].DependsOn {
+			j, ok := l.index[dep]
+			if !ok {
+				return fmt.Errorf("%w: %s needs %s", ErrUnknownDependency, l.hooks[i].Name, dep)
+			}
+			if err := visit(j); err != nil {
+				return err
+			}
+		}
+		state[i] = done
+		order = append(order, l.hooks[i])
+		return nil
+	}
+
+	for i := range l.hooks {
+		if err := visit(i); err != nil {
+			return nil, err
+		}
+	}
+	return order, nil
+}
+
+// Start runs every Start hook in dependency order. On failure already
+// started hooks are stopped in reverse order and error names failed hook.
+func (l *Lifecycle) Start() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.running {
+		return ErrAlreadyStarted
+	}
+
+	order, err := l.resolve()
+	if err != nil {
+		return err
+	}
+
+	for _, h := range order {
+		if h.Start != nil {
+			if err := h.Start(); err != nil {
+				log.Printf("Subsystem %s failed to start, rolling back: %v", h.Name, err)
+				l.stopStarted()
+				return fmt.Errorf("start %s: %w", h.Name, err)
+			}
+		}
+		l.started = append(l.started, h)
+	}
+
+	l.running = true
+	return nil
+}
+
+// Stop runs Stop hooks of started units in reverse start order. Safe to
+// call more than once.
+func (l *Lifecycle) Stop() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.stopStarted()
+	l.running = false
+}
+
+// stopStarted unwinds started hooks, caller holds mu
+func (l *Lifecycle) stopStarted() {
+	for i := len(l.started) - 1; i >= 0; i-- {
+		if stop := l.started[i].Stop; stop != nil {
+			stop()
+		}
+	}
+	l.started = nil
+}
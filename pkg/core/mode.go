@@ -0,0 +1,189 @@
+package core
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/sashalind/sex-artifical-intelligence/pkg/core/errs"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/core/event"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/nlp"
+)
+
+// Mode is operating mode of whole system
+type Mode int
+
+const (
+	ModeInitializing Mode = iota
+	ModeIdle
+	ModeActive
+	ModePaused
+	ModeSafe
+	ModeShuttingDown
+	ModeLowPower // parked and powered down after idle timeout, see idle.go
+)
+
+var modeNames = map[Mode]string{
+	ModeInitializing: "initializing",
+	ModeIdle:         "idle",
+	ModeActive:       "active",
+	ModePaused:       "paused",
+	ModeSafe:         "safe_mode",
+	ModeShuttingDown: "shutting_down",
+	ModeLowPower:     "low_power",
+}
+
+// String returns mode name as used in configs and API
+func (m Mode) String() string {
+	if name, ok := modeNames[m]; ok {
+		return name
+	}
+	return fmt.Sprintf("mode(%d)", int(m))
+}
+
+// ParseMode converts mode name to Mode
+func ParseMode(name string) (Mode, error) {
+	for m, n := range modeNames {
+		if n == name {
+			return m, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown mode %q", name)
+}
+
+// transitions lists modes reachable from each mode. Safe mode is only left
+// through idle, so operator has to acknowledge whatever caused it.
+var transitions = map[Mode][]Mode{
+	ModeInitializing: {ModeIdle, ModeSafe, ModeShuttingDown},
+	ModeIdle:         {ModeActive, ModePaused, ModeSafe, ModeLowPower, ModeShuttingDown},
+	ModeActive:       {ModeIdle, ModePaused, ModeSafe, ModeShuttingDown},
+	ModePaused:       {ModeActive, ModeIdle, ModeSafe, ModeShuttingDown},
+	ModeSafe:         {ModeIdle, ModeShuttingDown},
+	ModeShuttingDown: nil,
+	ModeLowPower:     {ModeIdle, ModeSafe, ModeShuttingDown},
+}
+
+var (
+	ErrInvalidTransition = errs.New(errs.FailedPrecondition, "invalid mode transition")
+	ErrCommandNotAllowed = errs.New(errs.FailedPrecondition, "command not allowed in current mode")
+)
+
+// ModeChange describes single transition
+type ModeChange struct {
+	From      Mode      `json:"from"`
+	To        Mode      `json:"to"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// TopicModeChanged carries every mode transition
+var TopicModeChanged = event.NewTopic[ModeChange]("system.mode")
+
+// canTransition reports whether to is reachable from from
+func canTransition(from, to Mode) bool {
+	for _, m := range transitions[from] {
+		if m == to {
+			return true
+		}
+	}
+	return false
+}
+
+// Mode returns current operating mode
+func (s *System) Mode() Mode {
+	s.modeMu.RLock()
+	defer s.modeMu.RUnlock()
+	return s.mode
+}
+
+// SetMode moves system to new mode, rejecting transitions not in table.
+// Setting current mode again is no-op.
+func (s *System) SetMode(to Mode) error {
+	return s.changeMode(nil, to)
+}
+
+// switchMode moves system to new mode only if it is still in from, so
+// command finishing does not undo pause or safe mode entered meanwhile
+func (s *System) switchMode(from, to Mode) error {
+	return s.changeMode(&from, to)
+}
+
+// changeMode does transition, checking system is in mode want points to
+// under same lock
+func (s *System) changeMode(want *Mode, to Mode) error {
+	s.modeMu.Lock()
+	from := s.mode
+	if from == to || want != nil && from != *want {
+		s.modeMu.Unlock()
+		return nil
+	}
+	if !canTransition(from, to) {
+		s.modeMu.Unlock()
+		return fmt.Errorf("%w: %s -> %s", ErrInvalidTransition, from, to)
+	}
+	s.mode = to
+	callbacks := s.modeCallbacks
+	s.modeMu.Unlock()
+
+	change := ModeChange{From: from, To: to, Timestamp: s.clock.Now()}
+	for _, cb := range callbacks {
+		cb(change)
+	}
+	event.Publish(s.bus, TopicModeChanged, change)
+	return nil
+}
+
+// OnModeChange registers callback run synchronously after every transition.
+// Callbacks must not call SetMode.
+func (s *System) OnModeChange(cb func(ModeChange)) {
+	s.modeMu.Lock()
+	defer s.modeMu.Unlock()
+	s.modeCallbacks = append(s.modeCallbacks, cb)
+}
+
+// checkCommandAllowed tells whether command type may run in current mode.
+// Stop, safeword, status and wake always work once system is up; motion
+// needs idle or active, paused system accepts it into queue until resumed.
+func (s *System) checkCommandAllowed(cmdType nlp.CommandType) error {
+	mode := s.Mode()
+	switch mode {
+	case ModeInitializing, ModeShuttingDown:
+		return fmt.Errorf("%w: %s", ErrCommandNotAllowed, mode)
+	case ModeSafe:
+		switch cmdType {
+		case nlp.CmdStop, nlp.CmdSafeword, nlp.CmdStatus, nlp.CmdWake:
+		default:
+			return fmt.Errorf("%w: %s in %s", ErrCommandNotAllowed, cmdType, mode)
+		}
+	}
+
+	// degraded motion disables everything that moves
+	if movesMotors(cmdType) && !s.supervisor.healthy("commands") {
+		return fmt.Errorf("%w: motion control", ErrSubsystemUnavailable)
+	}
+	return nil
+}
+
+// movesMotors reports command types setting motors in motion, sequences
+// may
+func movesMotors(cmdType nlp.CommandType) bool {
+	switch cmdType {
+	case nlp.CmdMove, nlp.CmdAdjust, nlp.CmdPattern, nlp.CmdSequence:
+		return true
+	}
+	return false
+}
+
+// setModeAutomated switches mode on behalf of routines and scripts, which
+// may never leave safe mode: that stays operator's decision
+func (s *System) setModeAutomated(name string) error {
+	mode, err := ParseMode(name)
+	if err != nil {
+		return err
+	}
+	switch s.Mode() {
+	case mode:
+		return nil
+	case ModeSafe:
+		return fmt.Errorf("%w: automation cannot leave %s", ErrCommandNotAllowed, ModeSafe)
+	}
+	return s.SetMode(mode)
+}
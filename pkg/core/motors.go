@@ -0,0 +1,141 @@
+package core
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+
+	"github.com/sashalind/sex-artifical-intelligence/pkg/motion"
+)
+
+// AddMotor declares motor on unit at runtime, empty unit means primary
+// one. Driver section opens hardware backend of motor, except in
+// simulation. Running config follows, so reloads and snapshots see motor.
+func (s *System) AddMotor(unit UnitID, mc MotorConfig) error {
+	edit := func(motors []MotorConfig) ([]MotorConfig, error) {
+		if motorIndex(motors, mc.ID) >= 0 {
+			return nil, &motion.MotorError{Motor: motion.MotorID(mc.ID), Err: motion.ErrMotorExists}
+		}
+		return append(motors, mc), nil
+	}
+	return s.changeMotors(unit, edit, func(mgr motorManager) error {
+		m, err := motionMotor(mc)
+		if err != nil {
+			return err
+		}
+		var backend motion.Driver
+		if mc.Driver != nil && !s.cfg.Simulation.Enabled {
+			if backend, err = hardwareDriver(s.clock, []MotorConfig{mc}); err != nil {
+				return err
+			}
+		}
+		if err := mgr.AddMotor(m, backend); err != nil {
+			if backend != nil {
+				backend.Close()
+			}
+			return err
+		}
+		return nil
+	})
+}
+
+// RemoveMotor stops motor of unit and drops it from unit and running
+// config. Motor groups and idle park pose must not refer to it.
+func (s *System) RemoveMotor(unit UnitID, id string) error {
+	edit := func(motors []MotorConfig) ([]MotorConfig, error) {
+		i := motorIndex(motors, id)
+		if i < 0 {
+			return nil, &motion.MotorError{Motor: motion.MotorID(id), Err: motion.ErrMotorNotFound}
+		}
+		return slices.Delete(motors, i, i+1), nil
+	}
+	return s.changeMotors(unit, edit, func(mgr motorManager) error {
+		return mgr.RemoveMotor(motion.MotorID(id))
+	})
+}
+
+// ConfigureMotor replaces config of running motor on unit. Limits, enabled
+// flag and homing apply at once; type and driver need restart.
+func (s *System) ConfigureMotor(unit UnitID, mc MotorConfig) error {
+	edit := func(motors []MotorConfig) ([]MotorConfig, error) {
+		i := motorIndex(motors, mc.ID)
+		if i < 0 {
+			return nil, &motion.MotorError{Motor: motion.MotorID(mc.ID), Err: motion.ErrMotorNotFound}
+		}
+		if !reflect.DeepEqual(motors[i].Driver, mc.Driver) {
+			return nil, fmt.Errorf("%w: motor %s driver", ErrRestartRequired, mc.ID)
+		}
+		motors[i] = mc
+		return motors, nil
+	}
+	return s.changeMotors(unit, edit, func(mgr motorManager) error {
+		m, err := motionMotor(mc)
+		if err != nil {
+			return err
+		}
+		return mgr.ConfigureMotor(m)
+	})
+}
+
+// changeMotors edits motor list of unit in running config, validates
+// resulting config and applies change to unit motion
+func (s *System) changeMotors(unit UnitID, edit func([]MotorConfig) ([]MotorConfig, error), apply func(motorManager) error) error {
+	if unit == "" {
+		unit = PrimaryUnit
+	}
+	u, err := s.Unit(unit)
+	if err != nil {
+		return err
+	}
+	mgr, ok := u.motion.(motorManager)
+	if !ok {
+		return fmt.Errorf("%w: motor changes", ErrNotSupported)
+	}
+
+	s.cfgMu.Lock()
+	defer s.cfgMu.Unlock()
+
+	cfg := s.cfg
+	unitIdx := -1
+	motors := cfg.Motors
+	if unit != PrimaryUnit {
+		unitIdx = slices.IndexFunc(cfg.Units, func(uc UnitConfig) bool { return UnitID(uc.ID) == unit })
+		if unitIdx < 0 {
+			return fmt.Errorf("%w: %s", ErrUnitNotFound, unit)
+		}
+		motors = cfg.Units[unitIdx].Motors
+	}
+	motors, err = edit(slices.Clone(motors))
+	if err != nil {
+		return err
+	}
+	if unitIdx < 0 {
+		cfg.Motors = motors
+	} else {
+		cfg.Units = slices.Clone(cfg.Units)
+		cfg.Units[unitIdx].Motors = motors
+	}
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("%w: %v", motion.ErrInvalidMotor, err)
+	}
+
+	if err := apply(mgr); err != nil {
+		return err
+	}
+	s.cfg = cfg
+	return nil
+}
+
+// motionMotor converts single motor config
+func motionMotor(mc MotorConfig) (motion.Motor, error) {
+	conf, err := motorsConfig([]MotorConfig{mc}, nil)
+	if err != nil {
+		return motion.Motor{}, fmt.Errorf("%w: %v", motion.ErrInvalidMotor, err)
+	}
+	return conf.Motors[0], nil
+}
+
+// motorIndex finds motor in list, -1 if missing
+func motorIndex(motors []MotorConfig, id string) int {
+	return slices.IndexFunc(motors, func(m MotorConfig) bool { return m.ID == id })
+}
@@ -0,0 +1,163 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sashalind/sex-artifical-intelligence/pkg/clock"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/motion"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/nlp"
+)
+
+// recordingMotion keeps commands it is given
+type recordingMotion struct {
+	motors []motion.Motor
+	during func() // runs with every command, nil for nothing
+
+	mu   sync.Mutex
+	cmds []motion.MotorCommand
+}
+
+func (m *recordingMotion) ExecuteCommand(_ context.Context, cmd motion.MotorCommand) error {
+	if m.during != nil {
+		m.during()
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.cmds = append(m.cmds, cmd)
+	return nil
+}
+
+func (m *recordingMotion) ExecuteGroup(ctx context.Context, cmds []motion.MotorCommand) error {
+	for _, cmd := range cmds {
+		m.ExecuteCommand(ctx, cmd)
+	}
+	return nil
+}
+
+func (m *recordingMotion) ExecutePattern(context.Context, string) error { return nil }
+func (m *recordingMotion) GetMotors() []motion.Motor                    { return m.motors }
+func (m *recordingMotion) GetPatterns() []motion.MovementPattern        { return nil }
+func (m *recordingMotion) StopAll()                                     {}
+func (m *recordingMotion) Shutdown()                                    {}
+
+func (m *recordingMotion) last() (motion.MotorCommand, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.cmds) == 0 {
+		return motion.MotorCommand{}, false
+	}
+	return m.cmds[len(m.cmds)-1], true
+}
+
+func TestHandleMovement(t *testing.T) {
+	motors := []motion.Motor{
+		{ID: "off", MaxSpeed: 90, MaxPosition: 180},
+		{ID: "arm", MaxSpeed: 120, MinPosition: 10, MaxPosition: 170, IsEnabled: true},
+		{ID: "slide", MaxSpeed: 50, MaxPosition: 200, Position: 100, IsEnabled: true,
+			SoftMinPosition: 20, SoftMaxPosition: 180, Frame: motion.Frame{Unit: motion.UnitMillimeters, Scale: 45}},
+	}
+	tests := []struct {
+		name   string
+		params map[string]interface{}
+		want   motion.MotorCommand
+		err    error
+	}{
+		{"first enabled motor to middle", map[string]interface{}{"speed": 0.5},
+			motion.MotorCommand{ID: "arm", Position: 90, Speed: 60}, nil},
+		{"direction to end of travel", map[string]interface{}{"direction": "left", "speed": 0.25},
+			motion.MotorCommand{ID: "arm", Position: 10, Speed: 30}, nil},
+		{"current speed", map[string]interface{}{"direction": "up"},
+			motion.MotorCommand{ID: "arm", Position: 170, Speed: 120}, nil},
+		{"named motor", map[string]interface{}{"motor": "SLIDE", "direction": "clockwise", "speed": 1.0},
+			motion.MotorCommand{ID: "slide", Position: 180, Speed: 50}, nil},
+		{"distance of linear motor", map[string]interface{}{"motor": "slide", "direction": "back", "distance": 30.0, "speed": 0.5},
+			motion.MotorCommand{ID: "slide", Position: 70, Speed: 25}, nil},
+		{"distance within soft limits", map[string]interface{}{"motor": "slide", "distance": 300.0, "speed": 0.5},
+			motion.MotorCommand{ID: "slide", Position: 180, Speed: 25}, nil},
+		{"distance of rotary motor", map[string]interface{}{"direction": "right", "distance": 30.0, "speed": 0.5},
+			motion.MotorCommand{ID: "arm", Position: 170, Speed: 60}, nil},
+		{"unknown motor", map[string]interface{}{"motor": "tail"}, motion.MotorCommand{}, motion.ErrMotorNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := &recordingMotion{motors: motors}
+			cfg := DefaultConfig()
+			cfg.Clock = clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+			cfg.SelfTest.Disabled = true
+			cfg.Subsystems.Motion = rec
+			sys, err := NewSystemWithConfig(cfg)
+			if err != nil {
+				t.Fatalf("NewSystemWithConfig: %v", err)
+			}
+			defer sys.Shutdown()
+
+			err = sys.handleMovement(context.Background(), &nlp.Command{Type: nlp.CmdMove, Parameters: tt.params})
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("handleMovement error = %v, want %v", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("handleMovement: %v", err)
+			}
+			if got, ok := rec.last(); !ok || got != tt.want {
+				t.Errorf("motor command = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestCommandModeTransition checks move and stop switch between idle and
+// active, leaving mode entered while command ran as it is
+func TestCommandModeTransition(t *testing.T) {
+	tests := []struct {
+		name   string
+		start  Mode
+		during Mode
+		cmd    nlp.CommandType
+		want   Mode
+	}{
+		{"move wakes idle", ModeIdle, ModeIdle, nlp.CmdMove, ModeActive},
+		{"stop settles active", ModeActive, ModeActive, nlp.CmdStop, ModeIdle},
+		{"pause while moving", ModeIdle, ModePaused, nlp.CmdMove, ModePaused},
+		{"safe mode while moving", ModeIdle, ModeSafe, nlp.CmdMove, ModeSafe},
+		{"stop when paused", ModePaused, ModePaused, nlp.CmdStop, ModePaused},
+		{"move in safe mode", ModeSafe, ModeSafe, nlp.CmdMove, ModeSafe},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := &recordingMotion{motors: []motion.Motor{{ID: "arm", MaxSpeed: 90, MaxPosition: 180, IsEnabled: true}}}
+			cfg := DefaultConfig()
+			cfg.Clock = clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+			cfg.SelfTest.Disabled = true
+			cfg.Subsystems.Motion = rec
+			sys, err := NewSystemWithConfig(cfg)
+			if err != nil {
+				t.Fatalf("NewSystemWithConfig: %v", err)
+			}
+			defer sys.Shutdown()
+			if err := sys.SetMode(tt.start); err != nil {
+				t.Fatal(err)
+			}
+			rec.during = func() { sys.SetMode(tt.during) }
+
+			cmd := &nlp.Command{Type: tt.cmd, Parameters: map[string]interface{}{"speed": 0.5}}
+			if tt.cmd == nlp.CmdMove {
+				err = sys.handleMovement(context.Background(), cmd)
+			} else {
+				err = sys.handleStop(context.Background(), cmd)
+			}
+			if err != nil {
+				t.Fatalf("handle %s: %v", tt.cmd, err)
+			}
+			if got := sys.Mode(); got != tt.want {
+				t.Errorf("mode = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
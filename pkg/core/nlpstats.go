@@ -0,0 +1,28 @@
+package core
+
+import (
+	"fmt"
+
+	"github.com/sashalind/sex-artifical-intelligence/pkg/nlp"
+)
+
+// NLPStats returns command processing counts of NLP engine: intent
+// distribution, unknown command rate and average confidence
+func (s *System) NLPStats() (nlp.Stats, error) {
+	st, ok := s.nlpProc.(nlpStatter)
+	if !ok {
+		return nlp.Stats{}, fmt.Errorf("%w: nlp stats", ErrNotSupported)
+	}
+	return st.Stats(), nil
+}
+
+// Misunderstood returns log of command text NLP engine did not understand
+// or was unsure of, usable as intent training file once intents are
+// corrected
+func (s *System) Misunderstood() ([]nlp.Misunderstanding, error) {
+	st, ok := s.nlpProc.(nlpStatter)
+	if !ok {
+		return nil, fmt.Errorf("%w: misunderstood commands", ErrNotSupported)
+	}
+	return st.Misunderstood(), nil
+}
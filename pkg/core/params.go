@@ -0,0 +1,101 @@
+package core
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/sashalind/sex-artifical-intelligence/pkg/core/errs"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/nlp"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/profile"
+)
+
+// ErrParamAtLimit is returned when relative adjustment cannot move
+// parameter any further, "faster" at full speed
+var ErrParamAtLimit = errs.New(errs.OutOfRange, "parameter at limit")
+
+// MotionParams are current motion parameters, all within [0, 1]. Adjust
+// commands change them; movement without speed of its own runs at Speed.
+type MotionParams struct {
+	Speed       float64 `json:"speed"`
+	Intensity   float64 `json:"intensity"`
+	Sensitivity float64 `json:"sensitivity"`
+}
+
+// defaultMotionParams are parameters before first adjustment
+var defaultMotionParams = MotionParams{Speed: 1, Intensity: 0.5, Sensitivity: 0.5}
+
+// motionParams holds current motion parameters, zero value is unset
+type motionParams struct {
+	mu     sync.Mutex
+	params MotionParams
+	set    bool
+}
+
+// get returns current parameters, speed defaulting to preferred one
+func (m *motionParams) get(prefs profile.Preferences) MotionParams {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.current(prefs)
+}
+
+// current is get with mu held
+func (m *motionParams) current(prefs profile.Preferences) MotionParams {
+	if m.set {
+		return m.params
+	}
+	p := defaultMotionParams
+	if prefs.DefaultSpeed > 0 {
+		p.Speed = prefs.DefaultSpeed
+	}
+	return p
+}
+
+// MotionParams returns current motion parameters, speed default following
+// active session
+func (s *System) MotionParams() MotionParams {
+	prefs := profile.Preferences{}
+	if sess := s.ActiveSession(); sess != nil {
+		prefs = sess.Preferences()
+	}
+	return s.params.get(prefs)
+}
+
+// adjustParams applies absolute values ("speed"), deltas ("speed_change")
+// and multipliers ("speed_factor") of adjust command to current parameters.
+// Results are clamped to [0, 1] and to caps of prefs; relative change
+// that moves nothing fails with ErrParamAtLimit.
+func (s *System) adjustParams(cmd *nlp.Command, prefs profile.Preferences) (MotionParams, error) {
+	s.params.mu.Lock()
+	defer s.params.mu.Unlock()
+
+	p := s.params.current(prefs)
+	fields := []struct {
+		name  string
+		value *float64
+		cap   func(float64) float64
+	}{
+		{"speed", &p.Speed, prefs.CapSpeed},
+		{"intensity", &p.Intensity, prefs.CapIntensity},
+		{"sensitivity", &p.Sensitivity, func(v float64) float64 { return v }},
+	}
+	for _, f := range fields {
+		old := *f.value
+		want, relative := old, false
+		if v, ok := cmd.Parameters[f.name].(float64); ok {
+			want = v
+		}
+		if d, ok := cmd.Parameters[f.name+"_change"].(float64); ok {
+			want, relative = want+d, true
+		}
+		if k, ok := cmd.Parameters[f.name+"_factor"].(float64); ok {
+			want, relative = want*k, true
+		}
+		v := f.cap(max(0, min(1, want)))
+		if relative && v == old && want != old {
+			return MotionParams{}, fmt.Errorf("%w: %s already at %g", ErrParamAtLimit, f.name, old)
+		}
+		*f.value = v
+	}
+	s.params.params, s.params.set = p, true
+	return p, nil
+}
@@ -0,0 +1,48 @@
+package core
+
+import "fmt"
+
+// Pause freezes motors of every unit at current positions, suspends behavior
+// analysis and buffers incoming commands until Resume. Stop and status
+// commands still run, and stop drops buffered motion like it always does.
+// Switching to paused mode through SetMode does the same.
+func (s *System) Pause() error {
+	return s.SetMode(ModePaused)
+}
+
+// Resume releases motors and runs commands buffered during pause in order.
+// System resumes idle, motors don't continue interrupted moves.
+func (s *System) Resume() error {
+	if mode := s.Mode(); mode != ModePaused {
+		return fmt.Errorf("%w: %s is not paused", ErrInvalidTransition, mode)
+	}
+	return s.SetMode(ModeIdle)
+}
+
+// Paused reports whether system is paused
+func (s *System) Paused() bool {
+	return s.Mode() == ModePaused
+}
+
+// applyPause enters and leaves pause, runs as mode change callback
+func (s *System) applyPause(c ModeChange) {
+	switch {
+	case c.To == ModePaused:
+		s.queue.hold(true)
+		for _, u := range s.units {
+			// executors that can't freeze at least stop
+			if h, ok := u.motion.(motionHolder); ok {
+				h.Hold()
+			} else {
+				u.motion.StopAll()
+			}
+		}
+	case c.From == ModePaused:
+		for _, u := range s.units {
+			if h, ok := u.motion.(motionHolder); ok {
+				h.Release()
+			}
+		}
+		s.queue.hold(false)
+	}
+}
@@ -0,0 +1,123 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/sashalind/sex-artifical-intelligence/pkg/core/errs"
+)
+
+// Plugin is third-party subsystem started together with built-in ones, e.g.
+// driver for custom sensor board or alternative behavior engine. Start runs
+// after every built-in subsystem is up, so plugin can use System accessors,
+// Bus, AttachSensorSource and AttachMotionDriver. Stop runs before any
+// built-in subsystem stops.
+type Plugin interface {
+	Start(sys *System) error
+	Stop()
+}
+
+// PluginDependencies is optionally implemented by plugins that must start
+// after other plugins
+type PluginDependencies interface {
+	DependsOn() []string
+}
+
+// PluginFactory builds plugin from options block of its config entry
+type PluginFactory func(options json.RawMessage) (Plugin, error)
+
+// PluginConfig enables registered plugin by name
+type PluginConfig struct {
+	Name    string          `json:"name"`
+	Options json.RawMessage `json:"options,omitempty"`
+}
+
+var (
+	pluginsMu sync.RWMutex
+	plugins   = make(map[string]PluginFactory)
+)
+
+// ErrUnknownPlugin is returned when config enables plugin nobody registered
+var ErrUnknownPlugin = errs.New(errs.NotFound, "plugin not registered")
+
+// RegisterPlugin makes plugin available to configs. Meant to be called from
+// init() of plugin package, so custom build only needs blank import:
+//
+//	import _ "example.com/acme/sai-thermal"
+func RegisterPlugin(name string, factory PluginFactory) {
+	pluginsMu.Lock()
+	defer pluginsMu.Unlock()
+
+	if factory == nil {
+		panic("core: RegisterPlugin factory is nil")
+	}
+	if _, dup := plugins[name]; dup {
+		panic("core: RegisterPlugin called twice for " + name)
+	}
+	plugins[name] = factory
+}
+
+// RegisteredPlugins returns names of all registered plugins, sorted
+func RegisteredPlugins() []string {
+	pluginsMu.RLock()
+	defer pluginsMu.RUnlock()
+
+	names := make([]string, 0, len(plugins))
+	for name := range plugins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// lookupPlugin returns factory for name
+func lookupPlugin(name string) (PluginFactory, error) {
+	pluginsMu.RLock()
+	defer pluginsMu.RUnlock()
+
+	factory, ok := plugins[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownPlugin, name)
+	}
+	return factory, nil
+}
+
+// pluginHookName keeps plugin hooks apart from built-in subsystem names
+func pluginHookName(name string) string {
+	return "plugin:" + name
+}
+
+// registerPlugins builds configured plugins and adds them to lifecycle
+// after built-in subsystems listed in core
+func (s *System) registerPlugins(configs []PluginConfig, core []string) error {
+	for _, pc := range configs {
+		factory, err := lookupPlugin(pc.Name)
+		if err != nil {
+			return err
+		}
+		p, err := factory(pc.Options)
+		if err != nil {
+			return fmt.Errorf("plugin %s: %w", pc.Name, err)
+		}
+
+		deps := append([]string(nil), core...)
+		if pd, ok := p.(PluginDependencies); ok {
+			for _, dep := range pd.DependsOn() {
+				deps = append(deps, pluginHookName(dep))
+			}
+		}
+
+		err = s.lifecycle.Register(Hook{
+			Name:      pluginHookName(pc.Name),
+			DependsOn: deps,
+			Start:     func() error { return p.Start(s) },
+			Stop:      p.Stop,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
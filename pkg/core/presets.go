@@ -0,0 +1,59 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/sashalind/sex-artifical-intelligence/pkg/core/errs"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/nlp"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/profile"
+)
+
+var (
+	ErrPresetNotFound = errs.New(errs.NotFound, "preset not found")
+	ErrNoSession      = errs.New(errs.FailedPrecondition, "no session")
+)
+
+// validatePresets checks preferences of every preset in config
+func validatePresets(presets map[string]profile.Preferences) error {
+	for name, p := range presets {
+		if name == "" {
+			return fmt.Errorf("preset name must not be empty")
+		}
+		if err := p.Validate(); err != nil {
+			return fmt.Errorf("preset %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// Presets lists names of configured presets, sorted
+func (s *System) Presets() []string {
+	presets := s.Config().Presets
+	names := make([]string, 0, len(presets))
+	for name := range presets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// handlePreset loads preferences of named preset as overrides of session
+// command was issued in, or of active one. Presets may not loosen profile
+// limits any more than overrides set through API.
+func (s *System) handlePreset(ctx context.Context, cmd *nlp.Command) error {
+	name, _ := cmd.Parameters["preset"].(string)
+	prefs, ok := s.Config().Presets[name]
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrPresetNotFound, name)
+	}
+	sess := s.commandSession(ctx)
+	if sess == nil {
+		sess = s.ActiveSession()
+	}
+	if sess == nil {
+		return fmt.Errorf("%w: preset %s applies to session", ErrNoSession, name)
+	}
+	return sess.SetOverrides(prefs)
+}
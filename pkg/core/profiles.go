@@ -0,0 +1,100 @@
+package core
+
+import (
+	"context"
+	"strings"
+
+	"github.com/sashalind/sex-artifical-intelligence/pkg/nlp"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/profile"
+)
+
+// SaveProfile creates or replaces user profile. Open sessions of that user
+// pick up new preferences immediately. Loosening caps of existing profile
+// needs PermOverrideLimits.
+func (s *System) SaveProfile(ctx context.Context, p profile.Profile) (profile.Profile, error) {
+	if err := s.Authorize(ctx, PermConfigure); err != nil {
+		return profile.Profile{}, err
+	}
+	if old, err := s.profiles.Get(p.Name); err == nil && p.Preferences.Loosens(old.Preferences) {
+		if err := s.Authorize(ctx, PermOverrideLimits); err != nil {
+			return profile.Profile{}, err
+		}
+	}
+
+	saved, err := s.profiles.Put(p)
+	if err != nil {
+		return profile.Profile{}, err
+	}
+	for _, sess := range s.Sessions() {
+		if strings.EqualFold(sess.Profile().Name, saved.Name) {
+			sess.setProfile(saved)
+		}
+	}
+	s.applyProfile()
+	return saved, nil
+}
+
+// Profile returns saved user profile
+func (s *System) Profile(name string) (profile.Profile, error) {
+	return s.profiles.Get(name)
+}
+
+// Profiles returns all saved profiles sorted by name
+func (s *System) Profiles() []profile.Profile {
+	return s.profiles.List()
+}
+
+// DeleteProfile removes saved profile, open sessions keep their copy.
+// Recreating profile from scratch drops its caps, so this needs
+// PermOverrideLimits.
+func (s *System) DeleteProfile(ctx context.Context, name string) error {
+	if err := s.Authorize(ctx, PermOverrideLimits); err != nil {
+		return err
+	}
+	return s.profiles.Delete(name)
+}
+
+// applyProfile makes motion limits follow preferences of active session,
+// so patterns and group moves respect them too. Without active session
+// motors run up to their configured maximum.
+func (s *System) applyProfile() {
+	var prefs profile.Preferences
+	if sess := s.ActiveSession(); sess != nil {
+		prefs = sess.Preferences()
+	}
+	for _, u := range s.Units() {
+		if l, ok := u.motion.(speedLimiter); ok {
+			l.SetSpeedLimit(prefs.MaxSpeed)
+		}
+	}
+}
+
+// commandPreferences returns preferences of session command was issued
+// in, commands outside any session follow active one
+func (s *System) commandPreferences(ctx context.Context) profile.Preferences {
+	sess := s.commandSession(ctx)
+	if sess == nil {
+		sess = s.ActiveSession()
+	}
+	if sess == nil {
+		return profile.Preferences{}
+	}
+	return sess.Preferences()
+}
+
+// interpretCommand returns copy of command with numeric parameters capped
+// at preference limits. Original is left alone, it is shared with history.
+func interpretCommand(cmd *nlp.Command, prefs profile.Preferences) *nlp.Command {
+	out := *cmd
+	out.Parameters = make(map[string]interface{}, len(cmd.Parameters))
+	for k, v := range cmd.Parameters {
+		out.Parameters[k] = v
+	}
+	if v, ok := out.Parameters["speed"].(float64); ok {
+		out.Parameters["speed"] = prefs.CapSpeed(v)
+	}
+	if v, ok := out.Parameters["intensity"].(float64); ok {
+		out.Parameters["intensity"] = prefs.CapIntensity(v)
+	}
+	return &out
+}
@@ -0,0 +1,197 @@
+package core
+
+import (
+	"time"
+
+	"github.com/sashalind/sex-artifical-intelligence/pkg/motion"
+)
+
+// ProgramNode is JSON form of motion.ProgramNode
+type ProgramNode struct {
+	Kind     motion.ProgramKind `json:"kind"`
+	Pattern  string             `json:"pattern,omitempty"`
+	Children []ProgramNode      `json:"children,omitempty"`
+	Count    int                `json:"count,omitempty"`
+	Fade     Duration           `json:"fade,omitempty"`
+}
+
+// PatternProgram is JSON form of motion.PatternProgram
+type PatternProgram struct {
+	Name string      `json:"name"`
+	Root ProgramNode `json:"root"`
+}
+
+// PatternProgress is JSON form of motion.PatternProgress
+type PatternProgress struct {
+	Name     string   `json:"name"`
+	Elapsed  Duration `json:"elapsed"`
+	Duration Duration `json:"duration,omitempty"`
+	Step     int      `json:"step"`
+	Steps    int      `json:"steps"`
+	Motors   []string `json:"motors"`
+	Queued   bool     `json:"queued,omitempty"`
+}
+
+// PreviewSample is JSON form of motion.PreviewSample
+type PreviewSample struct {
+	At       Duration `json:"at"`
+	Position float64  `json:"position"`
+	Velocity float64  `json:"velocity"`
+}
+
+// PatternPreview is JSON form of motion.PatternPreview
+type PatternPreview struct {
+	Name     string                     `json:"name"`
+	Duration Duration                   `json:"duration"`
+	Endless  bool                       `json:"endless,omitempty"`
+	Motors   map[string][]PreviewSample `json:"motors"`
+	Error    string                     `json:"error,omitempty"`
+}
+
+// ArbitrationConfig settles patterns wanting same motors: policy
+// "preempt" (default) stops pattern already driving them, "reject" fails
+// new one, "queue" lets it wait for them. Patterns on disjoint motors play
+// side by side, up to max_runs (default 4) at once and max_queued
+// (default 8) waiting.
+type ArbitrationConfig struct {
+	Policy    motion.ArbitrationPolicy `json:"policy"`
+	MaxRuns   int                      `json:"max_runs"`
+	MaxQueued int                      `json:"max_queued"`
+}
+
+func (c ArbitrationConfig) motion() motion.Arbitration {
+	return motion.Arbitration{Policy: c.Policy, MaxRuns: c.MaxRuns, MaxQueued: c.MaxQueued}
+}
+
+// setArbitration applies arbitration config to motion executor
+func (s *System) setArbitration(m MotionExecutor) error {
+	a, ok := m.(patternArbiter)
+	if !ok {
+		return nil
+	}
+	return a.SetArbitration(s.cfg.Arbitration.motion())
+}
+
+func patternProgress(p motion.PatternProgress) PatternProgress {
+	motors := make([]string, 0, len(p.Motors))
+	for _, id := range p.Motors {
+		motors = append(motors, string(id))
+	}
+	return PatternProgress{
+		Name:     p.Name,
+		Elapsed:  Duration(p.Elapsed),
+		Duration: Duration(p.Duration),
+		Step:     p.Step,
+		Steps:    p.Steps,
+		Motors:   motors,
+		Queued:   p.Queued,
+	}
+}
+
+func (n ProgramNode) motion() motion.ProgramNode {
+	out := motion.ProgramNode{Kind: n.Kind, Pattern: n.Pattern, Count: n.Count, Fade: time.Duration(n.Fade)}
+	for _, child := range n.Children {
+		out.Children = append(out.Children, child.motion())
+	}
+	return out
+}
+
+func programNode(n motion.ProgramNode) ProgramNode {
+	out := ProgramNode{Kind: n.Kind, Pattern: n.Pattern, Count: n.Count, Fade: Duration(n.Fade)}
+	for _, child := range n.Children {
+		out.Children = append(out.Children, programNode(child))
+	}
+	return out
+}
+
+// AddProgram stores pattern program of primary unit, replacing one with
+// same name. Programs run by name wherever patterns do.
+func (s *System) AddProgram(p PatternProgram) error {
+	runner, ok := s.motionCtrl.(programRunner)
+	if !ok {
+		return ErrNotSupported
+	}
+	return runner.AddProgram(motion.PatternProgram{Name: p.Name, Root: p.Root.motion()})
+}
+
+// Programs returns pattern programs of primary unit
+func (s *System) Programs() []PatternProgram {
+	runner, ok := s.motionCtrl.(programRunner)
+	if !ok {
+		return []PatternProgram{}
+	}
+	programs := make([]PatternProgram, 0)
+	for _, p := range runner.GetPrograms() {
+		programs = append(programs, PatternProgram{Name: p.Name, Root: programNode(p.Root)})
+	}
+	return programs
+}
+
+// PatternProgress reports pattern or program started last on primary
+// unit, false if none
+func (s *System) PatternProgress() (PatternProgress, bool) {
+	runner, ok := s.motionCtrl.(programRunner)
+	if !ok {
+		return PatternProgress{}, false
+	}
+	p, running := runner.Progress()
+	if !running {
+		return PatternProgress{}, false
+	}
+	return patternProgress(p), true
+}
+
+// PreviewPattern predicts per-motor course of pattern or program on unit
+// without moving anything, empty unit means primary one
+func (s *System) PreviewPattern(unit UnitID, name string) (PatternPreview, error) {
+	if unit == "" {
+		unit = PrimaryUnit
+	}
+	u, err := s.Unit(unit)
+	if err != nil {
+		return PatternPreview{}, err
+	}
+	previewer, ok := u.motion.(patternPreviewer)
+	if !ok {
+		return PatternPreview{}, ErrNotSupported
+	}
+	p, err := previewer.SimulatePattern(name)
+	if err != nil {
+		return PatternPreview{}, err
+	}
+
+	out := PatternPreview{
+		Name:     p.Name,
+		Duration: Duration(p.Duration),
+		Endless:  p.Endless,
+		Motors:   make(map[string][]PreviewSample, len(p.Motors)),
+	}
+	for id, samples := range p.Motors {
+		converted := make([]PreviewSample, 0, len(samples))
+		for _, sm := range samples {
+			converted = append(converted, PreviewSample{At: Duration(sm.At), Position: sm.Position, Velocity: sm.Velocity})
+		}
+		out.Motors[string(id)] = converted
+	}
+	if p.Err != nil {
+		out.Error = p.Err.Error()
+	}
+	return out, nil
+}
+
+// PatternRuns lists patterns and programs playing on primary unit with
+// motors each drives, followed by queued ones
+func (s *System) PatternRuns() []PatternProgress {
+	runs := make([]PatternProgress, 0)
+	a, ok := s.motionCtrl.(patternArbiter)
+	if !ok {
+		if p, running := s.PatternProgress(); running {
+			runs = append(runs, p)
+		}
+		return runs
+	}
+	for _, p := range a.Runs() {
+		runs = append(runs, patternProgress(p))
+	}
+	return runs
+}
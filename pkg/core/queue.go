@@ -0,0 +1,434 @@
+package core
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sashalind/sex-artifical-intelligence/pkg/behavior"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/core/errs"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/nlp"
+)
+
+// CommandID identifies command submitted to system queue
+type CommandID uint64
+
+var (
+	ErrCommandCancelled = errs.New(errs.Cancelled, "command cancelled")
+	ErrCommandPreempted = errs.New(errs.Cancelled, "command preempted by stop")
+	ErrCommandNotQueued = errs.New(errs.NotFound, "command not in queue")
+	ErrQueueStopped     = errs.New(errs.Unavailable, "command queue stopped")
+)
+
+// QueuedCommand describes command waiting for execution
+type QueuedCommand struct {
+	ID         CommandID       `json:"id"`
+	Text       string          `json:"text"`
+	Type       nlp.CommandType `json:"type"`
+	Priority   int             `json:"priority"`
+	Session    SessionID       `json:"session,omitempty"`
+	TraceID    string          `json:"trace_id,omitempty"`
+	EnqueuedAt time.Time       `json:"enqueued_at"`
+}
+
+// Ticket tracks submitted command until it finishes
+type Ticket struct {
+	ID CommandID
+
+	done chan struct{}
+	resp *nlp.Response
+	err  error
+}
+
+// Done is closed when command has run, failed or was cancelled
+func (t *Ticket) Done() <-chan struct{} {
+	return t.done
+}
+
+// Wait blocks until command finishes and returns its response
+func (t *Ticket) Wait() (*nlp.Response, error) {
+	<-t.done
+	return t.resp, t.err
+}
+
+// WaitContext is Wait that gives up when ctx is done. Command keeps its
+// place in queue; cancel it explicitly if it is no longer wanted.
+func (t *Ticket) WaitContext(ctx context.Context) (*nlp.Response, error) {
+	select {
+	case <-t.done:
+		return t.resp, t.err
+	case <-ctx.Done():
+		return nil, contextError(ctx.Err())
+	}
+}
+
+// queueEntry is heap element
+type queueEntry struct {
+	ctx    context.Context
+	info   QueuedCommand
+	cmd    *nlp.Command
+	ticket *Ticket
+	seq    uint64
+	index  int
+}
+
+// entryHeap orders by priority, then submission order
+type entryHeap []*queueEntry
+
+func (h entryHeap) Len() int { return len(h) }
+
+func (h entryHeap) Less(i, j int) bool {
+	if h[i].info.Priority != h[j].info.Priority {
+		return h[i].info.Priority > h[j].info.Priority
+	}
+	return h[i].seq < h[j].seq
+}
+
+func (h entryHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *entryHeap) Push(x any) {
+	e := x.(*queueEntry)
+	e.index = len(*h)
+	*h = append(*h, e)
+}
+
+func (h *entryHeap) Pop() any {
+	old := *h
+	n := len(old)
+	e := old[n-1]
+	old[n-1] = nil
+	*h = old[:n-1]
+	e.index = -1
+	return e
+}
+
+// commandQueue schedules parsed commands for single executor goroutine
+type commandQueue struct {
+	mu      sync.Mutex
+	entries entryHeap
+	byID    map[CommandID]*queueEntry
+	seq     uint64
+	stopped bool
+	held    bool // paused: only stop and status commands are handed out
+
+	wake   chan struct{}
+	quit   chan struct{}
+	exited chan struct{}
+
+	// onFinish sees every entry leaving queue, never with mu held
+	onFinish func(e *queueEntry, resp *nlp.Response, err error)
+}
+
+func newCommandQueue(onFinish func(e *queueEntry, resp *nlp.Response, err error)) *commandQueue {
+	return &commandQueue{
+		onFinish: onFinish,
+		byID:     make(map[CommandID]*queueEntry),
+		wake:   make(chan struct{}, 1),
+		quit:   make(chan struct{}),
+		exited: make(chan struct{}),
+	}
+}
+
+// push adds entry; stop and safeword first drop every queued motion command
+func (q *commandQueue) push(e *queueEntry) error {
+	q.mu.Lock()
+	if q.stopped {
+		q.mu.Unlock()
+		return ErrQueueStopped
+	}
+
+	var preempted []*queueEntry
+	if e.cmd.Type == nlp.CmdStop || e.cmd.Type == nlp.CmdSafeword {
+		for _, queued := range append(entryHeap(nil), q.entries...) {
+			if movesMotors(queued.cmd.Type) {
+				q.removeLocked(queued)
+				preempted = append(preempted, queued)
+			}
+		}
+	}
+
+	q.seq++
+	e.seq = q.seq
+	e.info.ID = CommandID(q.seq)
+	e.ticket.ID = e.info.ID
+	heap.Push(&q.entries, e)
+	q.byID[e.info.ID] = e
+	q.mu.Unlock()
+
+	for _, queued := range preempted {
+		q.finish(queued, nil, ErrCommandPreempted)
+	}
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// pop takes highest priority entry or nil when queue is empty. Held queue
+// hands out only stop, safeword, status and resume commands, the rest wait
+// for release.
+func (q *commandQueue) pop() *queueEntry {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if len(q.entries) == 0 {
+		return nil
+	}
+	if !q.held {
+		e := heap.Pop(&q.entries).(*queueEntry)
+		delete(q.byID, e.info.ID)
+		return e
+	}
+
+	var next *queueEntry
+	for _, e := range q.entries {
+		switch e.cmd.Type {
+		case nlp.CmdStop, nlp.CmdSafeword, nlp.CmdStatus, nlp.CmdResume, nlp.CmdWake:
+		default:
+			continue
+		}
+		if next == nil || q.entries.Less(e.index, next.index) {
+			next = e
+		}
+	}
+	if next == nil {
+		return nil
+	}
+	heap.Remove(&q.entries, next.index)
+	delete(q.byID, next.info.ID)
+	return next
+}
+
+// hold stops or resumes handing out motion commands
+func (q *commandQueue) hold(held bool) {
+	q.mu.Lock()
+	q.held = held
+	q.mu.Unlock()
+
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// cancel removes queued entry, running or finished commands can't be cancelled
+func (q *commandQueue) cancel(id CommandID) error {
+	q.mu.Lock()
+	e, ok := q.byID[id]
+	if !ok {
+		q.mu.Unlock()
+		return fmt.Errorf("%w: %d", ErrCommandNotQueued, id)
+	}
+	q.removeLocked(e)
+	q.mu.Unlock()
+
+	q.finish(e, nil, ErrCommandCancelled)
+	return nil
+}
+
+// removeLocked drops entry, caller holds mu and finishes entry after
+// releasing it
+func (q *commandQueue) removeLocked(e *queueEntry) {
+	heap.Remove(&q.entries, e.index)
+	delete(q.byID, e.info.ID)
+}
+
+// snapshot returns queued commands in execution order
+func (q *commandQueue) snapshot() []QueuedCommand {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	sorted := append(entryHeap(nil), q.entries...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted.Less(i, j) })
+
+	infos := make([]QueuedCommand, len(sorted))
+	for i, e := range sorted {
+		infos[i] = e.info
+	}
+	return infos
+}
+
+// stop terminates executor and fails everything still queued
+func (q *commandQueue) stop() {
+	q.mu.Lock()
+	if q.stopped {
+		q.mu.Unlock()
+		return
+	}
+	q.stopped = true
+	close(q.quit)
+	q.mu.Unlock()
+
+	<-q.exited
+
+	q.mu.Lock()
+	var left []*queueEntry
+	for len(q.entries) > 0 {
+		e := q.entries[0]
+		q.removeLocked(e)
+		left = append(left, e)
+	}
+	q.mu.Unlock()
+
+	for _, e := range left {
+		q.finish(e, nil, ErrQueueStopped)
+	}
+}
+
+// finish completes ticket of entry, caller must not hold mu
+func (q *commandQueue) finish(e *queueEntry, resp *nlp.Response, err error) {
+	if q.onFinish != nil {
+		q.onFinish(e, resp, err)
+	}
+	e.ticket.resp = resp
+	e.ticket.err = err
+	close(e.ticket.done)
+}
+
+// runQueue executes queued commands one at a time
+func (s *System) runQueue() {
+	defer close(s.queue.exited)
+
+	for {
+		for e := s.queue.pop(); e != nil; e = s.queue.pop() {
+			// caller may have given up while command waited
+			if err := e.ctx.Err(); err != nil {
+				s.queue.finish(e, nil, contextError(err))
+				continue
+			}
+			resp, err := s.executeCommand(e.ctx, e.cmd)
+			s.queue.finish(e, resp, err)
+		}
+
+		select {
+		case <-s.queue.wake:
+		case <-s.queue.quit:
+			return
+		}
+	}
+}
+
+// SubmitCommand parses command and queues it by priority. Stop commands
+// jump ahead of everything and cancel queued moves and adjustments.
+// Command runs on behalf of session attached with WithSession, if any;
+// when ctx is done before command starts it is skipped.
+func (s *System) SubmitCommand(ctx context.Context, text string) (*Ticket, error) {
+	if err := s.Authorize(ctx, PermCommand); err != nil {
+		return nil, err
+	}
+
+	var sess *Session
+	if id, ok := SessionFromContext(ctx); ok {
+		found, err := s.Session(id)
+		if err != nil {
+			return nil, err
+		}
+		sess = found
+	}
+
+	cmd, err := s.parseCommand(sess, text)
+	if errors.Is(err, nlp.ErrNotAddressed) {
+		// chatter is no activity
+		return nil, err
+	}
+	// any command wakes powered down system
+	s.markActivity()
+	if err != nil {
+		return nil, err
+	}
+	if cmd.Type == nlp.CmdSafeword {
+		// no waiting for queue, command running may be what hurts
+		s.EmergencyStop()
+	}
+	s.behavior.AddInput(behavior.InputMood{Sentiment: cmd.Sentiment, Urgency: cmd.Urgency})
+	if sess != nil {
+		sess.record(*cmd)
+	}
+
+	ticket := &Ticket{done: make(chan struct{})}
+	entry := &queueEntry{
+		info: QueuedCommand{
+			Text:       text,
+			Type:       cmd.Type,
+			Priority:   cmd.Priority,
+			TraceID:    TraceIDFromContext(ctx),
+			EnqueuedAt: s.clock.Now(),
+		},
+		ctx:    ctx,
+		cmd:    cmd,
+		ticket: ticket,
+	}
+	if sess != nil {
+		entry.info.Session = sess.ID
+	}
+
+	if err := s.checkCommandAllowed(pendingType(cmd)); err != nil {
+		rejected := s.historyEntry(entry, nil, err)
+		rejected.Outcome = OutcomeRejected
+		s.history.add(rejected)
+		return nil, err
+	}
+	if err := s.queue.push(entry); err != nil {
+		return nil, err
+	}
+	return ticket, nil
+}
+
+// parseCommand turns text into command. Within session, engine may ask
+// follow-up question about incomplete command and merge answer into it;
+// commands of scripts and routines have nobody to answer and are taken as
+// they are. Either way references like "stop that one" resolve against
+// pattern playing now.
+func (s *System) parseCommand(sess *Session, text string) (*nlp.Command, error) {
+	pattern := ""
+	if p, running := s.PatternProgress(); running {
+		pattern = p.Name
+	}
+
+	// replies name pattern playing, dialog or not
+	if pt, ok := s.nlpProc.(patternTracker); ok {
+		pt.SetPattern(pattern)
+	}
+	dp, ok := s.nlpProc.(dialogProcessor)
+	if sess == nil || !ok {
+		return s.nlpProc.ProcessCommand(text)
+	}
+	sess.dialog.SetPattern(pattern)
+	// profile default speed needs no question
+	if sess.Preferences().DefaultSpeed > 0 {
+		sess.dialog.SetKnown("speed")
+	} else {
+		sess.dialog.SetKnown()
+	}
+	return dp.ProcessDialog(sess.dialog, text)
+}
+
+// pendingType is type of command follow-up question is about, so question
+// is refused when its command would be
+func pendingType(cmd *nlp.Command) nlp.CommandType {
+	if cmd.Type == nlp.CmdClarify {
+		if t, ok := cmd.Parameters["command"].(string); ok {
+			return nlp.CommandType(t)
+		}
+	}
+	return cmd.Type
+}
+
+// CancelCommand removes command that has not started yet
+func (s *System) CancelCommand(id CommandID) error {
+	return s.queue.cancel(id)
+}
+
+// QueuedCommands lists commands waiting for execution, next one first
+func (s *System) QueuedCommands() []QueuedCommand {
+	return s.queue.snapshot()
+}
@@ -0,0 +1,38 @@
+package core
+
+import (
+	"github.com/sashalind/sex-artifical-intelligence/pkg/motion"
+)
+
+// StartRecording starts capturing movement of primary unit, from commands
+// motors accept or from positions they report
+func (s *System) StartRecording(source motion.RecordSource) error {
+	rec, ok := s.motionCtrl.(patternRecorder)
+	if !ok {
+		return ErrNotSupported
+	}
+	return rec.StartRecording(source)
+}
+
+// StopRecording ends recording and saves it as pattern under name
+func (s *System) StopRecording(name string) (PatternCapability, error) {
+	rec, ok := s.motionCtrl.(patternRecorder)
+	if !ok {
+		return PatternCapability{}, ErrNotSupported
+	}
+	p, err := rec.StopRecording(name)
+	if err != nil {
+		return PatternCapability{}, err
+	}
+	return PatternCapability{
+		Name:       p.Name,
+		DurationMs: p.Duration.Milliseconds(),
+		Steps:      len(p.Commands),
+	}, nil
+}
+
+// Recording reports whether primary unit is recording
+func (s *System) Recording() bool {
+	rec, ok := s.motionCtrl.(patternRecorder)
+	return ok && rec.Recording()
+}
@@ -0,0 +1,134 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"reflect"
+	"slices"
+
+	"github.com/sashalind/sex-artifical-intelligence/pkg/core/errs"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/motion"
+)
+
+// ErrRestartRequired is returned when reloaded config changes settings that
+// can only be applied at startup
+var ErrRestartRequired = errs.New(errs.FailedPrecondition, "config change requires restart")
+
+// Config returns configuration system currently runs with
+func (s *System) Config() Config {
+	s.cfgMu.Lock()
+	defer s.cfgMu.Unlock()
+	return s.cfg
+}
+
+// ReloadConfig applies motor limits, behavior thresholds, adaptation policy
+// and NLP settings from cfg without restarting. Config is validated first; if
+// applying any section fails, sections already applied are rolled back and
+// system keeps running with previous config.
+func (s *System) ReloadConfig(cfg Config) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	motionCfg, err := cfg.MotionConfig()
+	if err != nil {
+		return err
+	}
+
+	s.cfgMu.Lock()
+	defer s.cfgMu.Unlock()
+
+	if err := checkStatic(s.cfg, cfg); err != nil {
+		return err
+	}
+
+	// injected subsystems may not take new settings at runtime
+	motionConf, canMotion := s.motionCtrl.(motionConfigurer)
+	if !canMotion && !reflect.DeepEqual(s.cfg.Motors, cfg.Motors) {
+		return fmt.Errorf("%w: motors", ErrNotSupported)
+	}
+	nlpConf, canNLP := s.nlpProc.(nlpConfigurer)
+	if !canNLP && s.cfg.NLP != cfg.NLP {
+		return fmt.Errorf("%w: nlp", ErrNotSupported)
+	}
+
+	var prevMotion motion.Config
+	if canMotion {
+		prevMotion = motionConf.Config()
+		if err := motionConf.ApplyConfig(motionCfg); err != nil {
+			return fmt.Errorf("motors: %w", err)
+		}
+	}
+	rollbackMotion := func() {
+		if canMotion {
+			motionConf.ApplyConfig(prevMotion)
+		}
+	}
+
+	prevBehavior := s.behavior.Config()
+	if err := s.behavior.SetConfig(cfg.behaviorConfig()); err != nil {
+		rollbackMotion()
+		return fmt.Errorf("behavior: %w", err)
+	}
+	if canNLP {
+		if err := nlpConf.SetConfig(cfg.nlpConfig()); err != nil {
+			s.behavior.SetConfig(prevBehavior)
+			rollbackMotion()
+			return fmt.Errorf("nlp: %w", err)
+		}
+	}
+	s.adaptation.setConfig(cfg.Adaptation)
+	s.idle.setConfig(cfg.Idle)
+
+	cfg.Clock = s.cfg.Clock
+	cfg.Subsystems = s.cfg.Subsystems
+	cfg.Authenticator = s.cfg.Authenticator
+	s.cfg = cfg
+	log.Println("Configuration reloaded")
+	return nil
+}
+
+// checkStatic rejects changes to sections applied only at startup
+func checkStatic(old, cfg Config) error {
+	if old.Sensors.HistorySize != cfg.Sensors.HistorySize ||
+		!slices.Equal(old.Sensors.Types, cfg.Sensors.Types) ||
+		!slices.Equal(old.Sensors.Retention, cfg.Sensors.Retention) ||
+		!reflect.DeepEqual(old.Sensors.Gestures, cfg.Sensors.Gestures) {
+		return fmt.Errorf("%w: sensors", ErrRestartRequired)
+	}
+
+	if !reflect.DeepEqual(motorDrivers(old.Motors), motorDrivers(cfg.Motors)) {
+		return fmt.Errorf("%w: motor drivers", ErrRestartRequired)
+	}
+
+	if !slices.Equal(old.Scripts, cfg.Scripts) {
+		return fmt.Errorf("%w: scripts", ErrRestartRequired)
+	}
+
+	if !reflect.DeepEqual(old.Units, cfg.Units) {
+		return fmt.Errorf("%w: units", ErrRestartRequired)
+	}
+
+	if old.History != cfg.History {
+		return fmt.Errorf("%w: history", ErrRestartRequired)
+	}
+
+	if !reflect.DeepEqual(old.Access, cfg.Access) {
+		return fmt.Errorf("%w: access", ErrRestartRequired)
+	}
+
+	if old.Simulation != cfg.Simulation {
+		return fmt.Errorf("%w: simulation", ErrRestartRequired)
+	}
+
+	if len(old.Plugins) != len(cfg.Plugins) {
+		return fmt.Errorf("%w: plugins", ErrRestartRequired)
+	}
+	for i := range cfg.Plugins {
+		if old.Plugins[i].Name != cfg.Plugins[i].Name ||
+			!bytes.Equal(old.Plugins[i].Options, cfg.Plugins[i].Options) {
+			return fmt.Errorf("%w: plugins", ErrRestartRequired)
+		}
+	}
+	return nil
+}
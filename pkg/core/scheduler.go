@@ -0,0 +1,407 @@
+package core
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sashalind/sex-artifical-intelligence/pkg/core/cron"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/core/errs"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/core/event"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/sensor"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/storage"
+)
+
+// RoutineID names scheduled routine
+type RoutineID string
+
+// TriggerKind selects when routine runs
+type TriggerKind string
+
+const (
+	TriggerStartup  TriggerKind = "startup"  // once when saved routines are loaded on start
+	TriggerInterval TriggerKind = "interval" // every Every
+	TriggerCron     TriggerKind = "cron"     // on Cron expression, system clock time zone
+	TriggerIdle     TriggerKind = "idle"     // once after Every without sensor activity
+	TriggerAt       TriggerKind = "at"       // once at At, routine is removed after
+)
+
+// Trigger describes when routine runs
+type Trigger struct {
+	Kind  TriggerKind `json:"kind"`
+	Every Duration    `json:"every,omitempty"`
+	Cron  string      `json:"cron,omitempty"`
+	At    time.Time   `json:"at,omitempty"`
+}
+
+// RoutineAction is what routine does, exactly one field is set
+type RoutineAction struct {
+	Command string `json:"command,omitempty"` // natural language command, queued as usual
+	Pattern string `json:"pattern,omitempty"` // movement pattern of primary unit
+	Mode    string `json:"mode,omitempty"`    // operating mode to switch to
+}
+
+// Routine is timed action registered by user
+type Routine struct {
+	ID       RoutineID     `json:"id"`
+	Trigger  Trigger       `json:"trigger"`
+	Action   RoutineAction `json:"action"`
+	Disabled bool          `json:"disabled,omitempty"`
+	LastRun  time.Time     `json:"last_run,omitempty"`
+	LastErr  string        `json:"last_error,omitempty"`
+}
+
+// idleActivityLevel is smallest touch, pressure or motion reading counted
+// as activity by idle triggers
+const idleActivityLevel = 0.05
+
+var (
+	ErrInvalidRoutine  = errs.New(errs.InvalidArgument, "invalid routine")
+	ErrRoutineNotFound = errs.New(errs.NotFound, "routine not found")
+)
+
+// Validate checks trigger and action
+func (r Routine) Validate() error {
+	if r.ID == "" {
+		return fmt.Errorf("%w: empty id", ErrInvalidRoutine)
+	}
+	if _, err := r.Trigger.schedule(); err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrInvalidRoutine, r.ID, err)
+	}
+
+	a := r.Action
+	set := 0
+	for _, v := range []string{a.Command, a.Pattern, a.Mode} {
+		if v != "" {
+			set++
+		}
+	}
+	if set != 1 {
+		return fmt.Errorf("%w: %s: exactly one action required", ErrInvalidRoutine, r.ID)
+	}
+	if a.Mode != "" {
+		if _, err := ParseMode(a.Mode); err != nil {
+			return fmt.Errorf("%w: %s: %v", ErrInvalidRoutine, r.ID, err)
+		}
+	}
+	return nil
+}
+
+// schedule validates trigger, returning parsed cron expression if any
+func (t Trigger) schedule() (*cron.Schedule, error) {
+	switch t.Kind {
+	case TriggerStartup:
+		return nil, nil
+	case TriggerInterval, TriggerIdle:
+		if t.Every <= 0 {
+			return nil, fmt.Errorf("%s trigger needs positive every", t.Kind)
+		}
+		return nil, nil
+	case TriggerCron:
+		return cron.Parse(t.Cron)
+	case TriggerAt:
+		if t.At.IsZero() {
+			return nil, fmt.Errorf("at trigger needs time")
+		}
+		return nil, nil
+	}
+	return nil, fmt.Errorf("unknown trigger %q", t.Kind)
+}
+
+// scheduled is routine with runtime state
+type scheduled struct {
+	Routine
+	cron *cron.Schedule
+	next time.Time // interval and cron triggers
+	idle bool      // idle trigger already fired in current quiet period
+}
+
+// scheduler runs routines, checking triggers once per second
+type scheduler struct {
+	sys *System
+
+	mu           sync.Mutex
+	routines     map[RoutineID]*scheduled
+	table        *storage.Table[Routine]
+	lastActivity time.Time
+}
+
+func newScheduler(sys *System) *scheduler {
+	return &scheduler{
+		sys:          sys,
+		routines:     make(map[RoutineID]*scheduled),
+		lastActivity: sys.clock.Now(),
+	}
+}
+
+// run checks triggers until system stops
+func (sc *scheduler) run() {
+	readings := event.Subscribe(sc.sys.bus, sensor.TopicReading, 256)
+	defer readings.Cancel()
+
+	ticker := sc.sys.clock.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-sc.sys.ctx.Done():
+			return
+		case data, ok := <-readings.C:
+			if !ok {
+				return
+			}
+			if isActivity(data) {
+				sc.activity(data.Timestamp)
+			}
+		case now := <-ticker.C():
+			for _, r := range sc.due(now) {
+				sc.fire(r)
+			}
+		}
+	}
+}
+
+// isActivity tells whether reading means somebody interacts with robot
+func isActivity(data sensor.SensorData) bool {
+	switch data.Type {
+	case sensor.TypeTouch, sensor.TypePressure, sensor.TypeMotion:
+		return data.Value >= idleActivityLevel
+	}
+	return false
+}
+
+// activity starts new quiet period for idle triggers
+func (sc *scheduler) activity(at time.Time) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	sc.lastActivity = at
+	for _, r := range sc.routines {
+		r.idle = false
+	}
+}
+
+// due returns routines whose trigger fired, advancing their schedule
+func (sc *scheduler) due(now time.Time) []Routine {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	var fired []Routine
+	for _, r := range sc.routines {
+		if r.Disabled {
+			continue
+		}
+		switch r.Trigger.Kind {
+		case TriggerInterval, TriggerCron:
+			if r.next.IsZero() || now.Before(r.next) {
+				continue
+			}
+			r.next = r.nextAfter(now)
+		case TriggerIdle:
+			if r.idle || now.Sub(sc.lastActivity) < time.Duration(r.Trigger.Every) {
+				continue
+			}
+			r.idle = true
+		case TriggerAt:
+			if now.Before(r.Trigger.At) {
+				continue
+			}
+			sc.removeLocked(r.ID)
+		default:
+			continue
+		}
+		fired = append(fired, r.Routine)
+	}
+	sort.Slice(fired, func(i, j int) bool { return fired[i].ID < fired[j].ID })
+	return fired
+}
+
+// nextAfter returns next activation of interval or cron trigger
+func (r *scheduled) nextAfter(t time.Time) time.Time {
+	if r.cron != nil {
+		return r.cron.Next(t)
+	}
+	return t.Add(time.Duration(r.Trigger.Every))
+}
+
+// fire runs routine action and records outcome
+func (sc *scheduler) fire(r Routine) {
+	err := sc.sys.runRoutineAction(r.Action)
+	if err != nil {
+		log.Printf("Routine %s failed: %v", r.ID, err)
+	}
+
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	cur, ok := sc.routines[r.ID]
+	if !ok {
+		return
+	}
+	cur.LastRun = sc.sys.clock.Now()
+	cur.LastErr = ""
+	if err != nil {
+		cur.LastErr = err.Error()
+	}
+	sc.persistLocked(cur.Routine)
+}
+
+// add registers or replaces routine
+func (sc *scheduler) add(r Routine) error {
+	if err := r.Validate(); err != nil {
+		return err
+	}
+	entry := &scheduled{Routine: r}
+	entry.cron, _ = r.Trigger.schedule()
+
+	now := sc.sys.clock.Now()
+	switch r.Trigger.Kind {
+	case TriggerInterval:
+		// resume cadence of saved routine, catching up once if overdue
+		entry.next = now.Add(time.Duration(r.Trigger.Every))
+		if !r.LastRun.IsZero() {
+			if next := r.LastRun.Add(time.Duration(r.Trigger.Every)); next.Before(entry.next) {
+				entry.next = next
+			}
+		}
+	case TriggerCron:
+		entry.next = entry.cron.Next(now)
+	}
+
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	sc.routines[r.ID] = entry
+	sc.persistLocked(r)
+	return nil
+}
+
+// remove drops routine
+func (sc *scheduler) remove(id RoutineID) error {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	if _, ok := sc.routines[id]; !ok {
+		return fmt.Errorf("%w: %s", ErrRoutineNotFound, id)
+	}
+	sc.removeLocked(id)
+	return nil
+}
+
+// removeMatching drops routines whose ID starts with prefix, returning
+// how many
+func (sc *scheduler) removeMatching(prefix string) int {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	n := 0
+	for id := range sc.routines {
+		if strings.HasPrefix(string(id), prefix) {
+			sc.removeLocked(id)
+			n++
+		}
+	}
+	return n
+}
+
+// removeLocked drops routine, caller holds mu
+func (sc *scheduler) removeLocked(id RoutineID) {
+	delete(sc.routines, id)
+	if sc.table != nil {
+		sc.table.Delete(string(id))
+	}
+}
+
+// list returns routines sorted by ID
+func (sc *scheduler) list() []Routine {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	list := make([]Routine, 0, len(sc.routines))
+	for _, r := range sc.routines {
+		list = append(list, r.Routine)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	return list
+}
+
+// persistLocked saves routine if store is attached
+func (sc *scheduler) persistLocked(r Routine) {
+	if sc.table == nil {
+		return
+	}
+	if err := sc.table.Put(string(r.ID), r); err != nil {
+		log.Printf("Failed to save routine %s: %v", r.ID, err)
+	}
+}
+
+// attach loads saved routines and runs startup ones
+func (sc *scheduler) attach(store *storage.Store) error {
+	table, err := storage.OpenTable[Routine](store, storage.BucketRoutines)
+	if err != nil {
+		return err
+	}
+	saved, err := table.All()
+	if err != nil {
+		return err
+	}
+
+	// routines added before store was attached are saved too
+	sc.mu.Lock()
+	sc.table = table
+	for _, r := range sc.routines {
+		sc.persistLocked(r.Routine)
+	}
+	sc.mu.Unlock()
+
+	for _, r := range saved {
+		if r.Trigger.Kind == TriggerAt && r.Trigger.At.Before(sc.sys.clock.Now()) {
+			// moment passed while system was down, running it late could
+			// surprise whoever is there now
+			log.Printf("Dropping overdue routine %s", r.ID)
+			table.Delete(string(r.ID))
+			continue
+		}
+		if err := sc.add(r); err != nil {
+			log.Printf("Skipping saved routine %s: %v", r.ID, err)
+			continue
+		}
+		if r.Trigger.Kind == TriggerStartup && !r.Disabled {
+			go sc.fire(r)
+		}
+	}
+	return nil
+}
+
+// runRoutineAction executes action on behalf of scheduler
+func (s *System) runRoutineAction(a RoutineAction) error {
+	switch {
+	case a.Command != "":
+		// queued like any command but nobody waits for response
+		_, err := s.SubmitCommand(s.ctx, a.Command)
+		return err
+	case a.Pattern != "":
+		return s.motionCtrl.ExecutePattern(s.ctx, a.Pattern)
+	case a.Mode != "":
+		return s.setModeAutomated(a.Mode)
+	}
+	return nil
+}
+
+// AddRoutine registers routine, replacing one with same ID. Routines are
+// saved to attached store and come back on next start.
+func (s *System) AddRoutine(r Routine) error {
+	return s.scheduler.add(r)
+}
+
+// RemoveRoutine deletes routine
+func (s *System) RemoveRoutine(id RoutineID) error {
+	return s.scheduler.remove(id)
+}
+
+// Routines lists registered routines
+func (s *System) Routines() []Routine {
+	return s.scheduler.list()
+}
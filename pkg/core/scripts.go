@@ -0,0 +1,183 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/sashalind/sex-artifical-intelligence/pkg/behavior"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/core/errs"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/core/event"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/script"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/sensor"
+)
+
+var (
+	ErrScriptNotFound = errs.New(errs.NotFound, "script not found")
+	ErrInvalidScript  = errs.New(errs.InvalidArgument, "invalid script")
+)
+
+// scriptRegistry holds running user scripts by name
+type scriptRegistry struct {
+	mu      sync.RWMutex
+	engines map[string]*script.Engine
+}
+
+// scriptHost exposes system to scripts. Everything goes through the same
+// paths as user input: commands are queued and mode checked.
+type scriptHost struct {
+	sys *System
+}
+
+func (h scriptHost) SensorValue(sensorType string) (float64, bool) {
+	values := h.sys.sensorHub.GetSensorData(sensor.SensorType(sensorType))
+	if len(values) == 0 {
+		return 0, false
+	}
+	return values[len(values)-1], true
+}
+
+func (h scriptHost) BehaviorState() string {
+	return string(h.sys.behavior.GetCurrentState())
+}
+
+func (h scriptHost) Command(ctx context.Context, text string) error {
+	_, err := h.sys.ProcessCommand(systemContext(ctx), text)
+	return err
+}
+
+func (h scriptHost) Pattern(ctx context.Context, name string) error {
+	return h.sys.motionCtrl.ExecutePattern(ctx, name)
+}
+
+func (h scriptHost) SetMode(name string) error {
+	return h.sys.setModeAutomated(name)
+}
+
+// startScripts loads script files from config and starts event dispatch
+func (s *System) startScripts(paths []string) error {
+	s.scripts.engines = make(map[string]*script.Engine)
+	for _, path := range paths {
+		src, err := os.ReadFile(path)
+		if err != nil {
+			s.stopScripts()
+			return err
+		}
+		name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		if err := s.LoadScript(name, string(src)); err != nil {
+			s.stopScripts()
+			return err
+		}
+	}
+	go s.dispatchScriptEvents()
+	return nil
+}
+
+// stopScripts stops every script
+func (s *System) stopScripts() {
+	s.scripts.mu.Lock()
+	engines := s.scripts.engines
+	s.scripts.engines = make(map[string]*script.Engine)
+	s.scripts.mu.Unlock()
+
+	for _, e := range engines {
+		e.Stop()
+	}
+}
+
+// dispatchScriptEvents feeds sensor readings and behavior changes to scripts
+func (s *System) dispatchScriptEvents() {
+	readings := event.Subscribe(s.bus, sensor.TopicReading, 1024)
+	defer readings.Cancel()
+	states := event.Subscribe(s.bus, behavior.TopicStateChanged, 16)
+	defer states.Cancel()
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case data, ok := <-readings.C:
+			if !ok {
+				return
+			}
+			for _, e := range s.scriptEngines() {
+				e.OnReading(string(data.Type), data.Value)
+			}
+		case p, ok := <-states.C:
+			if !ok {
+				return
+			}
+			for _, e := range s.scriptEngines() {
+				e.OnState(string(p.Type))
+			}
+		}
+	}
+}
+
+func (s *System) scriptEngines() []*script.Engine {
+	s.scripts.mu.RLock()
+	defer s.scripts.mu.RUnlock()
+
+	engines := make([]*script.Engine, 0, len(s.scripts.engines))
+	for _, e := range s.scripts.engines {
+		engines = append(engines, e)
+	}
+	return engines
+}
+
+// LoadScript compiles and starts script, replacing running one with same
+// name. Syntax errors leave previous version running.
+func (s *System) LoadScript(name, src string) error {
+	if name == "" {
+		return fmt.Errorf("%w: empty name", ErrInvalidScript)
+	}
+	prog, err := script.Parse(name, src)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidScript, err)
+	}
+	engine := script.NewEngine(prog, scriptHost{sys: s}, s.clock)
+
+	s.scripts.mu.Lock()
+	old := s.scripts.engines[name]
+	s.scripts.engines[name] = engine
+	s.scripts.mu.Unlock()
+
+	if old != nil {
+		old.Stop()
+	}
+	engine.Start()
+	log.Printf("Script %s loaded with %d handlers", name, len(prog.Handlers))
+	return nil
+}
+
+// UnloadScript stops script
+func (s *System) UnloadScript(name string) error {
+	s.scripts.mu.Lock()
+	engine, ok := s.scripts.engines[name]
+	delete(s.scripts.engines, name)
+	s.scripts.mu.Unlock()
+
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrScriptNotFound, name)
+	}
+	engine.Stop()
+	return nil
+}
+
+// Scripts lists names of running scripts
+func (s *System) Scripts() []string {
+	s.scripts.mu.RLock()
+	defer s.scripts.mu.RUnlock()
+
+	names := make([]string, 0, len(s.scripts.engines))
+	for name := range s.scripts.engines {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,221 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"github.com/sashalind/sex-artifical-intelligence/pkg/core/errs"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/motion"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/nlp"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/sensor"
+)
+
+// ErrSelfTestFailed is returned by constructor when strict self-test fails
+var ErrSelfTestFailed = errs.New(errs.FailedPrecondition, "self-test failed")
+
+// SelfTestConfig controls startup self-test
+type SelfTestConfig struct {
+	Disabled bool `json:"disabled"`
+
+	// Sweep is how far each motor moves and comes back, degrees
+	Sweep float64 `json:"sweep"`
+
+	// Strict refuses to start on failure, otherwise system starts in safe
+	// mode so operator can look at result
+	Strict bool `json:"strict"`
+}
+
+const (
+	defaultSelfTestSweep = 2.0
+
+	// selfTestMotorTimeout bounds wait for motor to reach sweep position
+	selfTestMotorTimeout = 2 * time.Second
+)
+
+// plausibleRange is span of believable readings per sensor type, types not
+// listed are only checked for NaN and infinity
+var plausibleRange = map[sensor.SensorType][2]float64{
+	sensor.TypeTouch:    {-0.5, 1.5},
+	sensor.TypePressure: {-0.5, 1.5},
+	sensor.TypeMotion:   {-0.5, 1.5},
+	sensor.TypeTemp:     {0, 60},
+}
+
+// CheckStatus is outcome of one self-test check
+type CheckStatus string
+
+const (
+	CheckPass CheckStatus = "pass"
+	CheckFail CheckStatus = "fail"
+	CheckSkip CheckStatus = "skip"
+)
+
+// SelfTestCheck is result of one check
+type SelfTestCheck struct {
+	Name   string      `json:"name"`
+	Status CheckStatus `json:"status"`
+	Detail string      `json:"detail,omitempty"`
+}
+
+// SelfTestResult is structured report of startup self-test
+type SelfTestResult struct {
+	StartedAt  time.Time       `json:"started_at"`
+	FinishedAt time.Time       `json:"finished_at"`
+	Passed     bool            `json:"passed"`
+	Checks     []SelfTestCheck `json:"checks"`
+}
+
+// Failed returns checks that failed
+func (r SelfTestResult) Failed() []SelfTestCheck {
+	var failed []SelfTestCheck
+	for _, c := range r.Checks {
+		if c.Status == CheckFail {
+			failed = append(failed, c)
+		}
+	}
+	return failed
+}
+
+// SelfTest returns result of startup self-test, zero if it was disabled
+func (s *System) SelfTest() SelfTestResult {
+	return s.selfTest
+}
+
+// runSelfTest checks subsystems before system is declared ready
+func (s *System) runSelfTest(cfg SelfTestConfig) SelfTestResult {
+	res := SelfTestResult{StartedAt: s.clock.Now()}
+	add := func(name string, err error) {
+		c := SelfTestCheck{Name: name, Status: CheckPass}
+		if err != nil {
+			c.Status, c.Detail = CheckFail, err.Error()
+		}
+		res.Checks = append(res.Checks, c)
+	}
+	skip := func(name, why string) {
+		res.Checks = append(res.Checks, SelfTestCheck{Name: name, Status: CheckSkip, Detail: why})
+	}
+
+	add("neural", s.checkNeural())
+	add("nlp", s.checkNLP())
+
+	sweep := cfg.Sweep
+	if sweep <= 0 {
+		sweep = defaultSelfTestSweep
+	}
+	for _, u := range s.units {
+		prefix := ""
+		if u.ID != PrimaryUnit {
+			prefix = string(u.ID) + "/"
+		}
+		for _, m := range u.motion.GetMotors() {
+			name := "motor:" + prefix + string(m.ID)
+			if !m.IsEnabled {
+				skip(name, "disabled")
+				continue
+			}
+			add(name, s.checkMotor(u, m, sweep))
+		}
+		// leave motors at rest where they started
+		u.motion.StopAll()
+
+		for _, t := range u.sensors.GetSensorTypes() {
+			name := "sensor:" + prefix + string(t)
+			values := u.sensors.GetSensorData(t)
+			if len(values) == 0 {
+				skip(name, "no readings yet")
+				continue
+			}
+			add(name, checkReading(t, values[len(values)-1]))
+		}
+	}
+
+	res.FinishedAt = s.clock.Now()
+	res.Passed = len(res.Failed()) == 0
+	return res
+}
+
+// checkNeural runs zero input through network
+func (s *System) checkNeural() error {
+	if s.neuralNet == nil {
+		return fmt.Errorf("not initialized")
+	}
+	out, err := s.neuralNet.Process(make([]float64, 64))
+	if err != nil {
+		return err
+	}
+	if len(out) == 0 {
+		return fmt.Errorf("empty output")
+	}
+	for _, v := range out {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return fmt.Errorf("non-finite output")
+		}
+	}
+	return nil
+}
+
+// checkNLP verifies engine is up and parser understands probe command.
+// Engine itself is not fed probe, it would land in command history.
+func (s *System) checkNLP() error {
+	if s.nlpProc == nil {
+		return fmt.Errorf("not initialized")
+	}
+	cmd, err := nlp.Parse("status")
+	if err != nil {
+		return err
+	}
+	if cmd.Type != nlp.CmdStatus {
+		return fmt.Errorf("probe parsed as %s", cmd.Type)
+	}
+	return nil
+}
+
+// checkMotor moves motor by sweep and back, each leg must arrive in time
+func (s *System) checkMotor(u *Unit, m motion.Motor, sweep float64) error {
+	target := m.Position + sweep
+	if target > m.MaxPosition {
+		target = math.Max(m.Position-sweep, m.MinPosition)
+	}
+	if target == m.Position {
+		return nil
+	}
+
+	for _, pos := range []float64{target, m.Position} {
+		ctx, cancel := context.WithTimeout(s.ctx, selfTestMotorTimeout)
+		cmd := motion.MotorCommand{ID: m.ID, Position: pos, Speed: m.MaxSpeed}
+		err := u.motion.ExecuteGroup(ctx, []motion.MotorCommand{cmd})
+		if err == nil {
+			err = s.waitPositions(ctx, u, map[motion.MotorID]float64{m.ID: pos}, parkTolerance)
+		}
+		cancel()
+		if err != nil {
+			return fmt.Errorf("move to %g: %w", pos, err)
+		}
+	}
+	return nil
+}
+
+// checkReading validates latest sensor value
+func checkReading(t sensor.SensorType, v float64) error {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return fmt.Errorf("reading %g", v)
+	}
+	if r, ok := plausibleRange[t]; ok && (v < r[0] || v > r[1]) {
+		return fmt.Errorf("reading %g outside %g..%g", v, r[0], r[1])
+	}
+	return nil
+}
+
+// logSelfTest writes summary of result
+func logSelfTest(res SelfTestResult) {
+	if res.Passed {
+		log.Printf("Self-test passed, %d checks", len(res.Checks))
+		return
+	}
+	for _, c := range res.Failed() {
+		log.Printf("Self-test check %s failed: %s", c.Name, c.Detail)
+	}
+}
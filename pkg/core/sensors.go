@@ -0,0 +1,1035 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/sashalind/sex-artifical-intelligence/pkg/core/errs"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/core/event"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/sensor"
+)
+
+// SensorInstanceConfig registers individual sensor, several may share
+// type. Filters smooth its readings in order. SampleRate caps readings per
+// second taken from it, faster ones are merged as Decimation ("average" or
+// "latest") says.
+type SensorInstanceConfig struct {
+	ID         string               `json:"id"`
+	Type       string               `json:"type"`
+	Zone       string               `json:"zone,omitempty"`
+	Location   string               `json:"location,omitempty"`
+	Unit       string               `json:"unit,omitempty"` // of calibrated readings, e.g. kPa, °C, N
+	Filters    []SensorFilterConfig `json:"filters,omitempty"`
+	SampleRate float64              `json:"sample_rate,omitempty"`
+	Decimation string               `json:"decimation,omitempty"`
+
+	// health checks, see sensor.HealthCheck; zero values turn them off
+	StaleAfter     Duration `json:"stale_after,omitempty"`
+	StuckAfter     Duration `json:"stuck_after,omitempty"`
+	StuckTolerance float64  `json:"stuck_tolerance,omitempty"`
+	Min            float64  `json:"min,omitempty"`
+	Max            float64  `json:"max,omitempty"`
+}
+
+// SensorFilterConfig is filter stage of sensor, see sensor.FilterSpec for
+// kinds and defaults
+type SensorFilterConfig struct {
+	Kind             string  `json:"kind"`
+	Window           int     `json:"window,omitempty"`
+	Cutoff           float64 `json:"cutoff,omitempty"`
+	SampleRate       float64 `json:"sample_rate,omitempty"`
+	ProcessNoise     float64 `json:"process_noise,omitempty"`
+	MeasurementNoise float64 `json:"measurement_noise,omitempty"`
+}
+
+func (c SensorInstanceConfig) sensorInfo() sensor.SensorInfo {
+	info := sensor.SensorInfo{
+		ID:         sensor.SensorID(c.ID),
+		Type:       sensor.SensorType(c.Type),
+		Zone:       c.Zone,
+		Location:   c.Location,
+		Unit:       sensor.Unit(c.Unit),
+		SampleRate: c.SampleRate,
+		Decimation: sensor.Decimation(c.Decimation),
+		Check: sensor.HealthCheck{
+			StaleAfter:     time.Duration(c.StaleAfter),
+			StuckAfter:     time.Duration(c.StuckAfter),
+			StuckTolerance: c.StuckTolerance,
+			Min:            c.Min,
+			Max:            c.Max,
+		},
+	}
+	for _, f := range c.Filters {
+		info.Filters = append(info.Filters, sensor.FilterSpec{
+			Kind:             sensor.FilterKind(f.Kind),
+			Window:           f.Window,
+			Cutoff:           f.Cutoff,
+			SampleRate:       f.SampleRate,
+			ProcessNoise:     f.ProcessNoise,
+			MeasurementNoise: f.MeasurementNoise,
+		})
+	}
+	return info
+}
+
+func sensorInstance(info sensor.SensorInfo) SensorInstanceConfig {
+	c := SensorInstanceConfig{
+		ID:         string(info.ID),
+		Type:       string(info.Type),
+		Zone:       info.Zone,
+		Location:   info.Location,
+		Unit:       string(info.Unit),
+		SampleRate: info.SampleRate,
+		Decimation: string(info.Decimation),
+
+		StaleAfter:     Duration(info.Check.StaleAfter),
+		StuckAfter:     Duration(info.Check.StuckAfter),
+		StuckTolerance: info.Check.StuckTolerance,
+		Min:            info.Check.Min,
+		Max:            info.Check.Max,
+	}
+	for _, f := range info.Filters {
+		c.Filters = append(c.Filters, SensorFilterConfig{
+			Kind:             string(f.Kind),
+			Window:           f.Window,
+			Cutoff:           f.Cutoff,
+			SampleRate:       f.SampleRate,
+			ProcessNoise:     f.ProcessNoise,
+			MeasurementNoise: f.MeasurementNoise,
+		})
+	}
+	return c
+}
+
+// SensorAlertRule is condition on readings hub raises alert for, see
+// sensor.AlertRule for kinds. Severity "emergency" stops motors, "warning"
+// (default) warns and "info" is logged only.
+type SensorAlertRule struct {
+	Name       string   `json:"name"`
+	Type       string   `json:"type,omitempty"`
+	Sensor     string   `json:"sensor,omitempty"`
+	Kind       string   `json:"kind"`
+	Threshold  float64  `json:"threshold"`
+	For        Duration `json:"for,omitempty"`
+	Hysteresis float64  `json:"hysteresis,omitempty"`
+	Severity   string   `json:"severity,omitempty"`
+}
+
+func (r SensorAlertRule) alertRule() sensor.AlertRule {
+	return sensor.AlertRule{
+		Name:       r.Name,
+		Type:       sensor.SensorType(r.Type),
+		Sensor:     sensor.SensorID(r.Sensor),
+		Kind:       sensor.AlertKind(r.Kind),
+		Threshold:  r.Threshold,
+		For:        time.Duration(r.For),
+		Hysteresis: r.Hysteresis,
+		Severity:   sensor.AlertSeverity(r.Severity),
+	}
+}
+
+func sensorAlertRule(r sensor.AlertRule) SensorAlertRule {
+	return SensorAlertRule{
+		Name:       r.Name,
+		Type:       string(r.Type),
+		Sensor:     string(r.Sensor),
+		Kind:       string(r.Kind),
+		Threshold:  r.Threshold,
+		For:        Duration(r.For),
+		Hysteresis: r.Hysteresis,
+		Severity:   string(r.Severity),
+	}
+}
+
+// SensorAlert is alert of sensor rule still in effect as reported by API
+type SensorAlert struct {
+	Rule      string    `json:"rule"`
+	Kind      string    `json:"kind"`
+	Severity  string    `json:"severity"`
+	Type      string    `json:"type"`
+	Sensor    string    `json:"sensor,omitempty"`
+	Value     float64   `json:"value"`
+	Threshold float64   `json:"threshold"`
+	Since     time.Time `json:"since"`
+}
+
+// SensorChannelConfig is physical sensor sampled into hub, see
+// sensor.ChannelConfig for drivers and defaults
+type SensorChannelConfig struct {
+	ID       string   `json:"id,omitempty"` // defaults to driver and device
+	Type     string   `json:"type"`
+	Driver   string   `json:"driver"`
+	Interval Duration `json:"interval"`
+	Sensor   string   `json:"sensor"`         // registered sensor readings come from, optional
+	Unit     string   `json:"unit,omitempty"` // of calibrated readings, defaults to °C for ntc and tmp102, ratio otherwise
+
+	// adc, ntc: IIO device N of /sys/bus/iio/devices/iio:deviceN, input
+	// channel and raw counts reading 0 and 1 (default 0 and 4095)
+	Device  int     `json:"device"`
+	Channel int     `json:"channel"`
+	Min     float64 `json:"min"`
+	Max     float64 `json:"max"`
+
+	// ntc: thermistor Beta (default 3950), its resistance at 25°C and of
+	// divider series resistor in ohms (default 10k)
+	Beta       float64 `json:"beta,omitempty"`
+	Resistance float64 `json:"resistance,omitempty"`
+	Series     float64 `json:"series,omitempty"`
+
+	// tmp102, mpr121: i2c-dev bus like /dev/i2c-1 and address (default
+	// 0x48 and 0x5a)
+	Bus     string `json:"bus"`
+	Address uint16 `json:"address"`
+
+	// mpr121: electrodes grouped into body zones, each zone read as own
+	// sensor, and count drop read as full touch (default 64)
+	Zones []SensorTouchZone `json:"zones,omitempty"`
+	Span  float64           `json:"span,omitempty"`
+
+	// mpu6500: spidev device like /dev/spidev0.0, clock (default 1MHz)
+	// and acceleration in g off rest reading full motion (default 2)
+	SPI     string  `json:"spi"`
+	SpeedHz uint32  `json:"speed_hz"`
+	Range   float64 `json:"range"`
+}
+
+// SensorTempLimit bounds temperature of sensors in zone in °C, empty zone
+// covers zones without own limit. Over warn raises safety warning, at max
+// motors stop.
+type SensorTempLimit struct {
+	Zone       string  `json:"zone,omitempty"`
+	Warn       float64 `json:"warn,omitempty"`
+	Max        float64 `json:"max"`
+	Hysteresis float64 `json:"hysteresis,omitempty"`
+}
+
+func (l SensorTempLimit) tempLimit() sensor.TempLimit {
+	return sensor.TempLimit{Zone: l.Zone, Warn: l.Warn, Max: l.Max, Hysteresis: l.Hysteresis}
+}
+
+// SensorTouchZone maps touch controller electrodes to body zone, sensor
+// defaults to <type>_<zone>
+type SensorTouchZone struct {
+	Zone       string `json:"zone"`
+	Sensor     string `json:"sensor,omitempty"`
+	Electrodes []int  `json:"electrodes"`
+}
+
+// channelConfig converts channel into sensor package form
+func (c SensorChannelConfig) channelConfig() sensor.ChannelConfig {
+	var zones []sensor.TouchZone
+	for _, z := range c.Zones {
+		zones = append(zones, sensor.TouchZone{Zone: z.Zone, Sensor: sensor.SensorID(z.Sensor), Electrodes: z.Electrodes})
+	}
+	return sensor.ChannelConfig{
+		ID:       c.ID,
+		Type:     sensor.SensorType(c.Type),
+		Driver:   sensor.Driver(c.Driver),
+		Interval: time.Duration(c.Interval),
+		Sensor:   sensor.SensorID(c.Sensor),
+		Unit:     sensor.Unit(c.Unit),
+		Device:   c.Device,
+		Channel:  c.Channel,
+		Min:      c.Min,
+		Max:      c.Max,
+		Bus:      c.Bus,
+		Address:  c.Address,
+		SPI:      c.SPI,
+		SpeedHz:  c.SpeedHz,
+		Range:    c.Range,
+		Zones:    zones,
+		Span:     c.Span,
+
+		Beta:       c.Beta,
+		Resistance: c.Resistance,
+		Series:     c.Series,
+	}
+}
+
+func sensorChannel(c sensor.ChannelConfig) SensorChannelConfig {
+	var zones []SensorTouchZone
+	for _, z := range c.Zones {
+		zones = append(zones, SensorTouchZone{Zone: z.Zone, Sensor: string(z.Sensor), Electrodes: z.Electrodes})
+	}
+	return SensorChannelConfig{
+		ID:       c.ID,
+		Type:     string(c.Type),
+		Driver:   string(c.Driver),
+		Interval: Duration(c.Interval),
+		Sensor:   string(c.Sensor),
+		Unit:     string(c.Unit),
+		Device:   c.Device,
+		Channel:  c.Channel,
+		Min:      c.Min,
+		Max:      c.Max,
+		Bus:      c.Bus,
+		Address:  c.Address,
+		SPI:      c.SPI,
+		SpeedHz:  c.SpeedHz,
+		Range:    c.Range,
+		Zones:    zones,
+		Span:     c.Span,
+
+		Beta:       c.Beta,
+		Resistance: c.Resistance,
+		Series:     c.Series,
+	}
+}
+
+// validateChannels checks sensor channels of config and sensors they feed
+func (sc SensorConfig) validateChannels() error {
+	types := make(map[string]string, len(sc.Instances))
+	for _, in := range sc.Instances {
+		types[in.ID] = in.Type
+	}
+	for _, ch := range sc.Channels {
+		cfg := ch.channelConfig()
+		if err := cfg.Validate(); err != nil {
+			return err
+		}
+		for _, fed := range cfg.Sensors() {
+			if t, ok := types[string(fed.ID)]; ok && t != ch.Type {
+				return fmt.Errorf("sensor channel %s: sensor %s is %s", ch.Type, fed.ID, t)
+			}
+		}
+	}
+	return nil
+}
+
+// attachSensors opens sensor channels and feeds them into hub at their
+// sample rates. Hub that hot-plugs channels waits for absent devices,
+// otherwise every device must open. Simulation replaces hardware, so
+// nothing is opened then.
+func (s *System) attachSensors(hub SensorSource, sc SensorConfig) error {
+	if s.cfg.Simulation.Enabled || len(sc.Channels) == 0 {
+		return nil
+	}
+	if a, ok := hub.(channelAttacher); ok {
+		for _, ch := range sc.Channels {
+			if err := a.AttachChannel(ch.channelConfig()); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	a, ok := hub.(sourceAttacher)
+	if !ok {
+		return fmt.Errorf("%w: sensor source", ErrNotSupported)
+	}
+
+	srcs := make([]sensor.Source, 0, len(sc.Channels))
+	for _, ch := range sc.Channels {
+		src, err := sensor.OpenChannel(s.clock, ch.channelConfig())
+		if err != nil {
+			errs := []error{err}
+			for _, src := range srcs {
+				errs = append(errs, src.Close())
+			}
+			return errors.Join(errs...)
+		}
+		srcs = append(srcs, src)
+	}
+	for i, src := range srcs {
+		a.AttachSource(src, sc.Channels[i].channelConfig().SampleInterval())
+	}
+	return nil
+}
+
+// sensorAlerter returns alert rule feature of unit sensors
+func (s *System) sensorAlerter(unit UnitID) (sensorAlerter, error) {
+	if unit == "" {
+		unit = PrimaryUnit
+	}
+	u, err := s.Unit(unit)
+	if err != nil {
+		return nil, err
+	}
+	a, ok := u.sensors.(sensorAlerter)
+	if !ok {
+		return nil, fmt.Errorf("%w: sensor alerts", ErrNotSupported)
+	}
+	return a, nil
+}
+
+// SensorAlertRules lists alert rules of unit sensors
+func (s *System) SensorAlertRules(unit UnitID) ([]SensorAlertRule, error) {
+	a, err := s.sensorAlerter(unit)
+	if err != nil {
+		return nil, err
+	}
+	rules := []SensorAlertRule{}
+	for _, r := range a.AlertRules() {
+		rules = append(rules, sensorAlertRule(r))
+	}
+	return rules, nil
+}
+
+// SetSensorAlertRule adds alert rule to unit or replaces one of same name
+func (s *System) SetSensorAlertRule(unit UnitID, rule SensorAlertRule) error {
+	a, err := s.sensorAlerter(unit)
+	if err != nil {
+		return err
+	}
+	return a.SetAlertRule(rule.alertRule())
+}
+
+// RemoveSensorAlertRule drops alert rule of unit
+func (s *System) RemoveSensorAlertRule(unit UnitID, name string) error {
+	a, err := s.sensorAlerter(unit)
+	if err != nil {
+		return err
+	}
+	return a.RemoveAlertRule(name)
+}
+
+// ActiveSensorAlerts lists alerts of unit sensors not cleared yet
+func (s *System) ActiveSensorAlerts(unit UnitID) ([]SensorAlert, error) {
+	a, err := s.sensorAlerter(unit)
+	if err != nil {
+		return nil, err
+	}
+	alerts := []SensorAlert{}
+	for _, al := range a.ActiveAlerts() {
+		alerts = append(alerts, SensorAlert{
+			Rule:      al.Rule,
+			Kind:      string(al.Kind),
+			Severity:  string(al.Severity),
+			Type:      string(al.Type),
+			Sensor:    string(al.Sensor),
+			Value:     al.Value,
+			Threshold: al.Threshold,
+			Since:     al.Time,
+		})
+	}
+	return alerts, nil
+}
+
+// SensorGesture is touch gesture recognized on unit as reported by API
+type SensorGesture struct {
+	Kind     string    `json:"kind"`
+	Type     string    `json:"type"`
+	Sensor   string    `json:"sensor,omitempty"`
+	Zone     string    `json:"zone,omitempty"`
+	Peak     float64   `json:"peak"`
+	Duration Duration  `json:"duration"`
+	Count    int       `json:"count"`
+	Rate     float64   `json:"rate,omitempty"`
+	Time     time.Time `json:"time"`
+}
+
+// SensorGestures lists gestures recently recognized on unit, oldest first
+func (s *System) SensorGestures(unit UnitID) ([]SensorGesture, error) {
+	if unit == "" {
+		unit = PrimaryUnit
+	}
+	u, err := s.Unit(unit)
+	if err != nil {
+		return nil, err
+	}
+	g, ok := u.sensors.(gestureReader)
+	if !ok {
+		return nil, fmt.Errorf("%w: sensor gestures", ErrNotSupported)
+	}
+	gestures := []SensorGesture{}
+	for _, gs := range g.Gestures() {
+		gestures = append(gestures, SensorGesture{
+			Kind:     string(gs.Kind),
+			Type:     string(gs.Type),
+			Sensor:   string(gs.Sensor),
+			Zone:     gs.Zone,
+			Peak:     gs.Peak,
+			Duration: Duration(gs.Duration),
+			Count:    gs.Count,
+			Rate:     gs.Rate,
+			Time:     gs.Time,
+		})
+	}
+	return gestures, nil
+}
+
+// SensorChannelStatus is hot-plugged sensor channel as reported by API:
+// attached while its device is sampled, waiting while it is absent
+type SensorChannelStatus struct {
+	Channel   SensorChannelConfig `json:"channel"`
+	State     string              `json:"state"`
+	Since     time.Time           `json:"since"`
+	LastError string              `json:"last_error,omitempty"`
+}
+
+// channelAttacher returns hot-plug feature of unit sensors
+func (s *System) channelAttacher(unit UnitID) (channelAttacher, error) {
+	if unit == "" {
+		unit = PrimaryUnit
+	}
+	u, err := s.Unit(unit)
+	if err != nil {
+		return nil, err
+	}
+	a, ok := u.sensors.(channelAttacher)
+	if !ok {
+		return nil, fmt.Errorf("%w: sensor channels", ErrNotSupported)
+	}
+	return a, nil
+}
+
+// SensorChannels lists sensor channels of unit and whether their devices
+// are there
+func (s *System) SensorChannels(unit UnitID) ([]SensorChannelStatus, error) {
+	a, err := s.channelAttacher(unit)
+	if err != nil {
+		return nil, err
+	}
+	out := []SensorChannelStatus{}
+	for _, st := range a.Channels() {
+		out = append(out, SensorChannelStatus{
+			Channel:   sensorChannel(st.Config),
+			State:     string(st.State),
+			Since:     st.Since,
+			LastError: st.LastError,
+		})
+	}
+	return out, nil
+}
+
+// AttachSensorChannel adds sensor channel to unit at runtime, sampled as
+// soon as its device answers. Simulation has no devices to attach.
+func (s *System) AttachSensorChannel(unit UnitID, ch SensorChannelConfig) error {
+	if s.cfg.Simulation.Enabled {
+		return errs.New(errs.FailedPrecondition, "sensor channels can't be attached in simulation")
+	}
+	a, err := s.channelAttacher(unit)
+	if err != nil {
+		return err
+	}
+	return a.AttachChannel(ch.channelConfig())
+}
+
+// DetachSensorChannel stops sampling sensor channel of unit and removes
+// sensor it fed
+func (s *System) DetachSensorChannel(unit UnitID, id string) error {
+	a, err := s.channelAttacher(unit)
+	if err != nil {
+		return err
+	}
+	return a.DetachChannel(id)
+}
+
+// GetSensorSamples returns readings of sensor type on unit with timestamps
+// and sources, stamped within [from, to); zero bound leaves that side open
+func (s *System) GetSensorSamples(unit UnitID, sType sensor.SensorType, from, to time.Time) ([]sensor.SensorData, error) {
+	if unit == "" {
+		unit = PrimaryUnit
+	}
+	u, err := s.Unit(unit)
+	if err != nil {
+		return nil, err
+	}
+	src, ok := u.sensors.(sampleSource)
+	if !ok {
+		return nil, fmt.Errorf("%w: sensor samples", ErrNotSupported)
+	}
+	return src.GetSamples(sType, from, to), nil
+}
+
+// sensorRegistry returns registry of sensors on unit
+func (s *System) sensorRegistry(unit UnitID) (sensorRegistry, error) {
+	if unit == "" {
+		unit = PrimaryUnit
+	}
+	u, err := s.Unit(unit)
+	if err != nil {
+		return nil, err
+	}
+	r, ok := u.sensors.(sensorRegistry)
+	if !ok {
+		return nil, fmt.Errorf("%w: sensor registry", ErrNotSupported)
+	}
+	return r, nil
+}
+
+// Sensors lists sensors registered on unit
+func (s *System) Sensors(unit UnitID) ([]SensorInstanceConfig, error) {
+	r, err := s.sensorRegistry(unit)
+	if err != nil {
+		return nil, err
+	}
+	sensors := []SensorInstanceConfig{}
+	for _, info := range r.Sensors() {
+		sensors = append(sensors, sensorInstance(info))
+	}
+	return sensors, nil
+}
+
+// RegisterSensor adds sensor to unit or updates its zone, location and
+// filters
+func (s *System) RegisterSensor(unit UnitID, sc SensorInstanceConfig) error {
+	r, err := s.sensorRegistry(unit)
+	if err != nil {
+		return err
+	}
+	return r.RegisterSensor(sc.sensorInfo())
+}
+
+// UnregisterSensor removes sensor from unit
+func (s *System) UnregisterSensor(unit UnitID, id sensor.SensorID) error {
+	r, err := s.sensorRegistry(unit)
+	if err != nil {
+		return err
+	}
+	return r.UnregisterSensor(id)
+}
+
+// SensorSamples returns readings of one sensor on unit within [from, to)
+func (s *System) SensorSamples(unit UnitID, id sensor.SensorID, from, to time.Time) ([]sensor.SensorData, error) {
+	r, err := s.sensorRegistry(unit)
+	if err != nil {
+		return nil, err
+	}
+	return r.SensorSamples(id, from, to)
+}
+
+// SensorSamplesIn is SensorSamples converted to physical unit as, empty
+// keeps unit sensor reads in. Unit readings are in is returned with them.
+func (s *System) SensorSamplesIn(unit UnitID, id sensor.SensorID, from, to time.Time, as sensor.Unit) ([]sensor.SensorData, sensor.Unit, error) {
+	r, err := s.sensorRegistry(unit)
+	if err != nil {
+		return nil, "", err
+	}
+	data, err := r.SensorSamples(id, from, to)
+	if err != nil {
+		return nil, "", err
+	}
+	var own sensor.Unit
+	for _, info := range r.Sensors() {
+		if info.ID == id {
+			own = info.Unit
+		}
+	}
+	if as == sensor.UnitNone || as == own {
+		return data, own, nil
+	}
+	if own == sensor.UnitNone {
+		return nil, "", errs.New(errs.FailedPrecondition, fmt.Sprintf("sensor %s declares no unit to convert from", id))
+	}
+	for i := range data {
+		if data[i].Value, err = sensor.Convert(data[i].Value, own, as); err != nil {
+			return nil, "", err
+		}
+	}
+	return data, as, nil
+}
+
+// ZoneSamples returns readings of sensors in zone of unit within [from, to)
+// in timestamp order, only those of sensor type unless it is empty
+func (s *System) ZoneSamples(unit UnitID, zone string, sType sensor.SensorType, from, to time.Time) ([]sensor.SensorData, error) {
+	r, err := s.sensorRegistry(unit)
+	if err != nil {
+		return nil, err
+	}
+	return r.ZoneSamples(zone, sType, from, to), nil
+}
+
+// SensorZoneLevel is latest level of body zone as reported by API, e.g.
+// how strongly it is touched
+type SensorZoneLevel struct {
+	Zone  string    `json:"zone"`
+	Value float64   `json:"value"`
+	Time  time.Time `json:"time"`
+}
+
+// ZoneLevels returns latest level of each zone of unit read by healthy
+// sensors of type, highest sensor of zone wins
+func (s *System) ZoneLevels(unit UnitID, sType sensor.SensorType) ([]SensorZoneLevel, error) {
+	if unit == "" {
+		unit = PrimaryUnit
+	}
+	u, err := s.Unit(unit)
+	if err != nil {
+		return nil, err
+	}
+	z, ok := u.sensors.(zoneReader)
+	if !ok {
+		return nil, fmt.Errorf("%w: zone levels", ErrNotSupported)
+	}
+	levels := []SensorZoneLevel{}
+	for _, l := range z.ZoneLevels(sType) {
+		levels = append(levels, SensorZoneLevel{Zone: l.Zone, Value: l.Value, Time: l.Time})
+	}
+	return levels, nil
+}
+
+// SensorStats is statistics of readings of sensor within window as
+// reported by API
+type SensorStats struct {
+	Sensor string     `json:"sensor"`
+	Window Duration   `json:"window"`
+	Count  int        `json:"count"`
+	Min    float64    `json:"min"`
+	Max    float64    `json:"max"`
+	Mean   float64    `json:"mean"`
+	StdDev float64    `json:"stddev"`
+	P50    float64    `json:"p50"`
+	P90    float64    `json:"p90"`
+	P99    float64    `json:"p99"`
+	From   *time.Time `json:"from,omitempty"`
+	To     *time.Time `json:"to,omitempty"`
+}
+
+// SensorStats returns statistics of readings of sensor on unit within
+// window ending now
+func (s *System) SensorStats(unit UnitID, id sensor.SensorID, window time.Duration) (SensorStats, error) {
+	if unit == "" {
+		unit = PrimaryUnit
+	}
+	u, err := s.Unit(unit)
+	if err != nil {
+		return SensorStats{}, err
+	}
+	st, ok := u.sensors.(sensorStatter)
+	if !ok {
+		return SensorStats{}, fmt.Errorf("%w: sensor stats", ErrNotSupported)
+	}
+	stats, err := st.Stats(id, window)
+	if err != nil {
+		return SensorStats{}, err
+	}
+	out := SensorStats{
+		Sensor: string(stats.Sensor),
+		Window: Duration(stats.Window),
+		Count:  stats.Count,
+		Min:    stats.Min,
+		Max:    stats.Max,
+		Mean:   stats.Mean,
+		StdDev: stats.StdDev,
+		P50:    stats.P50,
+		P90:    stats.P90,
+		P99:    stats.P99,
+	}
+	if stats.Count > 0 {
+		out.From, out.To = &stats.From, &stats.To
+	}
+	return out, nil
+}
+
+// SensorHealth is health of registered sensor as reported by API, fault is
+// empty while it is healthy
+type SensorHealth struct {
+	Sensor      string     `json:"sensor"`
+	Type        string     `json:"type"`
+	Zone        string     `json:"zone,omitempty"`
+	Fault       string     `json:"fault,omitempty"`
+	Since       *time.Time `json:"since,omitempty"`
+	LastReading *time.Time `json:"last_reading,omitempty"`
+}
+
+func sensorHealth(st sensor.SensorStatus) SensorHealth {
+	sh := SensorHealth{Sensor: string(st.Sensor), Type: string(st.Type), Zone: st.Zone, Fault: string(st.Fault)}
+	if !st.Since.IsZero() {
+		sh.Since = &st.Since
+	}
+	if !st.LastReading.IsZero() {
+		sh.LastReading = &st.LastReading
+	}
+	return sh
+}
+
+// SensorHealth lists health of sensors registered on unit: stale, stuck
+// and out of range sensors are excluded from type streams and zones
+func (s *System) SensorHealth(unit UnitID) ([]SensorHealth, error) {
+	if unit == "" {
+		unit = PrimaryUnit
+	}
+	u, err := s.Unit(unit)
+	if err != nil {
+		return nil, err
+	}
+	c, ok := u.sensors.(sensorChecker)
+	if !ok {
+		return nil, fmt.Errorf("%w: sensor health", ErrNotSupported)
+	}
+	out := []SensorHealth{}
+	for _, st := range c.SensorHealth() {
+		out = append(out, sensorHealth(st))
+	}
+	return out, nil
+}
+
+// SensorCalibration is calibration of one sensor as reported by API, see
+// sensor.Calibration
+type SensorCalibration struct {
+	Sensor    string       `json:"sensor"`
+	Offset    float64      `json:"offset"`
+	Scale     float64      `json:"scale,omitempty"`
+	Table     []CurvePoint `json:"table,omitempty"`
+	Poly      []float64    `json:"poly,omitempty"`
+	UpdatedAt *time.Time   `json:"updated_at,omitempty"`
+}
+
+// CurvePoint is point of sensor calibration table
+type CurvePoint struct {
+	In  float64 `json:"in"`
+	Out float64 `json:"out"`
+}
+
+func sensorCalibration(cal sensor.Calibration) SensorCalibration {
+	sc := SensorCalibration{Sensor: string(cal.Sensor), Offset: cal.Offset, Scale: cal.Scale, Poly: cal.Poly}
+	for _, p := range cal.Table {
+		sc.Table = append(sc.Table, CurvePoint{In: p.In, Out: p.Out})
+	}
+	if !cal.UpdatedAt.IsZero() {
+		sc.UpdatedAt = &cal.UpdatedAt
+	}
+	return sc
+}
+
+func (sc SensorCalibration) calibration() sensor.Calibration {
+	cal := sensor.Calibration{Sensor: sensor.SensorID(sc.Sensor), Offset: sc.Offset, Scale: sc.Scale, Poly: sc.Poly}
+	for _, p := range sc.Table {
+		cal.Table = append(cal.Table, sensor.CurvePoint{In: p.In, Out: p.Out})
+	}
+	return cal
+}
+
+// sensorCalibrator returns calibration feature of unit sensors
+func (s *System) sensorCalibrator(unit UnitID) (sensorCalibrator, error) {
+	if unit == "" {
+		unit = PrimaryUnit
+	}
+	u, err := s.Unit(unit)
+	if err != nil {
+		return nil, err
+	}
+	c, ok := u.sensors.(sensorCalibrator)
+	if !ok {
+		return nil, fmt.Errorf("%w: sensor calibration", ErrNotSupported)
+	}
+	return c, nil
+}
+
+// SensorCalibrations lists calibrated sensors of unit
+func (s *System) SensorCalibrations(unit UnitID) ([]SensorCalibration, error) {
+	c, err := s.sensorCalibrator(unit)
+	if err != nil {
+		return nil, err
+	}
+	cals := []SensorCalibration{}
+	for _, cal := range c.Calibrations() {
+		cals = append(cals, sensorCalibration(cal))
+	}
+	return cals, nil
+}
+
+// CalibrateSensor installs calibration of sensor on unit, kept across
+// restarts when storage is attached
+func (s *System) CalibrateSensor(unit UnitID, cal SensorCalibration) error {
+	c, err := s.sensorCalibrator(unit)
+	if err != nil {
+		return err
+	}
+	return c.SetCalibration(cal.calibration())
+}
+
+// ClearSensorCalibration makes sensor on unit report raw readings again
+func (s *System) ClearSensorCalibration(unit UnitID, id string) error {
+	c, err := s.sensorCalibrator(unit)
+	if err != nil {
+		return err
+	}
+	return c.ClearCalibration(sensor.SensorID(id))
+}
+
+// CaptureSensorZero takes latest readings of sensor on unit at rest as its
+// zero and returns new calibration
+func (s *System) CaptureSensorZero(unit UnitID, id string) (SensorCalibration, error) {
+	c, err := s.sensorCalibrator(unit)
+	if err != nil {
+		return SensorCalibration{}, err
+	}
+	cal, err := c.CaptureZero(sensor.SensorID(id))
+	if err != nil {
+		return SensorCalibration{}, err
+	}
+	return sensorCalibration(cal), nil
+}
+
+// SubscribeSensors streams readings of unit selector matches as they
+// arrive, slow reader gets given backpressure. Cancel subscription when
+// done.
+func (s *System) SubscribeSensors(unit UnitID, sel sensor.Selector, buffer int, policy sensor.Backpressure) (*sensor.Subscription, error) {
+	if unit == "" {
+		unit = PrimaryUnit
+	}
+	u, err := s.Unit(unit)
+	if err != nil {
+		return nil, err
+	}
+	sub, ok := u.sensors.(readingSubscriber)
+	if !ok {
+		return nil, fmt.Errorf("%w: sensor subscription", ErrNotSupported)
+	}
+	return sub.Subscribe(sel, buffer, policy)
+}
+
+// sensorReadings streams readings of primary unit to consumer in core,
+// pushed by hub keeping newest ones when reader lags, or from event bus
+// when hub can't do that
+func (s *System) sensorReadings(buffer int) (<-chan sensor.SensorData, func()) {
+	if sub, ok := s.sensorHub.(readingSubscriber); ok {
+		if r, err := sub.Subscribe(sensor.Selector{}, buffer, sensor.DropOldest); err == nil {
+			return r.C, r.Cancel
+		}
+	}
+	r := event.Subscribe(s.bus, sensor.TopicReading, buffer)
+	return r.C, r.Cancel
+}
+
+// defaultRecordDir holds API recordings when config names no directory
+const defaultRecordDir = "recordings"
+
+// SensorRecording is raw reading recording as reported by API
+type SensorRecording struct {
+	Active   bool      `json:"active"`
+	File     string    `json:"file,omitempty"`
+	Started  time.Time `json:"started,omitempty"`
+	Readings uint64    `json:"readings"`
+	Dropped  uint64    `json:"dropped"`
+}
+
+func sensorRecording(st sensor.RecordStats, active bool) SensorRecording {
+	return SensorRecording{Active: active, File: st.File, Started: st.Started, Readings: st.Readings, Dropped: st.Dropped}
+}
+
+// sensorRecorder returns recording feature of unit sensors
+func (s *System) sensorRecorder(unit UnitID) (sensorRecorder, error) {
+	if unit == "" {
+		unit = PrimaryUnit
+	}
+	u, err := s.Unit(unit)
+	if err != nil {
+		return nil, err
+	}
+	r, ok := u.sensors.(sensorRecorder)
+	if !ok {
+		return nil, fmt.Errorf("%w: sensor recording", ErrNotSupported)
+	}
+	return r, nil
+}
+
+// RecordSensors writes raw readings of unit to CSV file at path until
+// StopSensorRecording or shutdown. Replay plays file back.
+func (s *System) RecordSensors(unit UnitID, path string) error {
+	r, err := s.sensorRecorder(unit)
+	if err != nil {
+		return err
+	}
+	if _, active := r.Recording(); active {
+		return sensor.ErrRecording
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return err
+	}
+	if err := r.Record(f); err != nil {
+		f.Close()
+		os.Remove(path)
+		return err
+	}
+	return nil
+}
+
+// StartSensorRecording records raw readings of unit to name.csv in record
+// directory, existing recordings are never overwritten
+func (s *System) StartSensorRecording(unit UnitID, name string) (SensorRecording, error) {
+	if name == "" || name != filepath.Base(name) || strings.HasPrefix(name, ".") {
+		return SensorRecording{}, errs.New(errs.InvalidArgument, fmt.Sprintf("bad recording name %q", name))
+	}
+	dir := s.cfg.Sensors.RecordDir
+	if dir == "" {
+		dir = defaultRecordDir
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return SensorRecording{}, err
+	}
+	err := s.RecordSensors(unit, filepath.Join(dir, name+".csv"))
+	if errors.Is(err, fs.ErrExist) {
+		return SensorRecording{}, errs.New(errs.FailedPrecondition, fmt.Sprintf("recording %s already exists", name))
+	}
+	if err != nil {
+		return SensorRecording{}, err
+	}
+	return s.SensorRecording(unit)
+}
+
+// StopSensorRecording ends recording of unit and returns how it went
+func (s *System) StopSensorRecording(unit UnitID) (SensorRecording, error) {
+	r, err := s.sensorRecorder(unit)
+	if err != nil {
+		return SensorRecording{}, err
+	}
+	st, err := r.StopRecord()
+	if err != nil && st.Started.IsZero() {
+		return SensorRecording{}, err
+	}
+	if err != nil {
+		log.Printf("Sensor recording %s ended with error: %v", st.File, err)
+	}
+	return sensorRecording(st, false), nil
+}
+
+// SensorRecording reports recording of unit, inactive when none runs
+func (s *System) SensorRecording(unit UnitID) (SensorRecording, error) {
+	r, err := s.sensorRecorder(unit)
+	if err != nil {
+		return SensorRecording{}, err
+	}
+	st, active := r.Recording()
+	return sensorRecording(st, active), nil
+}
+
+// tempLimiter returns temperature limit feature of unit sensors
+func (s *System) tempLimiter(unit UnitID) (tempLimiter, error) {
+	if unit == "" {
+		unit = PrimaryUnit
+	}
+	u, err := s.Unit(unit)
+	if err != nil {
+		return nil, err
+	}
+	t, ok := u.sensors.(tempLimiter)
+	if !ok {
+		return nil, fmt.Errorf("%w: temperature limits", ErrNotSupported)
+	}
+	return t, nil
+}
+
+// TempLimits lists temperature limits of unit zones
+func (s *System) TempLimits(unit UnitID) ([]SensorTempLimit, error) {
+	t, err := s.tempLimiter(unit)
+	if err != nil {
+		return nil, err
+	}
+	limits := []SensorTempLimit{}
+	for _, l := range t.TempLimits() {
+		limits = append(limits, SensorTempLimit{Zone: l.Zone, Warn: l.Warn, Max: l.Max, Hysteresis: l.Hysteresis})
+	}
+	return limits, nil
+}
+
+// SetTempLimits replaces temperature limits of unit zones
+func (s *System) SetTempLimits(unit UnitID, limits []SensorTempLimit) error {
+	t, err := s.tempLimiter(unit)
+	if err != nil {
+		return err
+	}
+	var tl []sensor.TempLimit
+	for _, l := range limits {
+		tl = append(tl, l.tempLimit())
+	}
+	return t.SetTempLimits(tl)
+}
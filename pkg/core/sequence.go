@@ -0,0 +1,65 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/sashalind/sex-artifical-intelligence/pkg/nlp"
+)
+
+// sequencePrefix starts IDs of routines running delayed steps of compound
+// commands
+const sequencePrefix = "sequence-"
+
+// handleSequence runs steps of compound command in order. Steps due at
+// once run right away through their handlers; later ones become one-shot
+// routines queueing text of their clause when due, so they pass mode checks
+// of that moment. Stop drops steps still waiting.
+func (s *System) handleSequence(ctx context.Context, cmd *nlp.Command) error {
+	now := s.clock.Now()
+	seq := s.sequences.Add(1)
+
+	var at time.Duration
+	for i := range cmd.Steps {
+		step := &cmd.Steps[i]
+		at += step.Delay
+		if step.Type == nlp.CmdSequence {
+			return fmt.Errorf("%w: nested sequence", ErrCommandNotAllowed)
+		}
+		if at == 0 {
+			if err := s.runStep(ctx, step); err != nil {
+				return fmt.Errorf("step %d %q: %w", i+1, step.Source, err)
+			}
+			continue
+		}
+		r := Routine{
+			ID:      RoutineID(fmt.Sprintf("%s%d-%d", sequencePrefix, seq, i+1)),
+			Trigger: Trigger{Kind: TriggerAt, At: now.Add(at)},
+			Action:  RoutineAction{Command: step.Source},
+		}
+		if err := s.scheduler.add(r); err != nil {
+			return fmt.Errorf("step %d %q: %w", i+1, step.Source, err)
+		}
+	}
+	return nil
+}
+
+// runStep executes step of sequence like queue executor would
+func (s *System) runStep(ctx context.Context, step *nlp.Command) error {
+	if err := s.checkCommandAllowed(step.Type); err != nil {
+		return err
+	}
+	if h := s.handler(step.Type); h != nil {
+		return h(ctx, interpretCommand(step, s.commandPreferences(ctx)))
+	}
+	return nil
+}
+
+// cancelSequences drops delayed steps of compound commands
+func (s *System) cancelSequences() {
+	if n := s.scheduler.removeMatching(sequencePrefix); n > 0 {
+		log.Printf("Cancelled %d pending sequence steps", n)
+	}
+}
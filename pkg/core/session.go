@@ -0,0 +1,312 @@
+package core
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sashalind/sex-artifical-intelligence/pkg/behavior"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/core/errs"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/nlp"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/profile"
+)
+
+// SessionID identifies interaction session
+type SessionID string
+
+// sessionHistorySize bounds per-session command history
+const sessionHistorySize = 100
+
+// baselineWeight is smoothing factor of behavior baseline moving average
+const baselineWeight = 0.1
+
+var ErrSessionNotFound = errs.New(errs.NotFound, "session not found")
+
+// Session isolates command history, NLP context and behavior baseline of
+// one user from everyone else
+type Session struct {
+	ID        SessionID
+	StartedAt time.Time
+
+	// Role is run level of whoever opened session, Owner their user name
+	Role  Role
+	Owner string
+
+	mu          sync.RWMutex
+	profile     profile.Profile
+	overrides   profile.Preferences
+	history     []nlp.Command
+	baseline    behavior.PatternMetrics
+	hasBaseline bool
+
+	// dialog holds command waiting for answer to follow-up question
+	dialog *nlp.Dialog
+
+	// onChange tells system preferences changed, set by StartSession
+	onChange func()
+}
+
+// Profile returns profile of session user
+func (s *Session) Profile() profile.Profile {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.profile
+}
+
+// Preferences returns profile preferences with session overrides applied
+func (s *Session) Preferences() profile.Preferences {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.profile.Preferences.Merge(s.overrides)
+}
+
+// SetOverrides replaces preference overrides for rest of session. Only
+// sessions allowed to override limits may loosen caps of profile.
+func (s *Session) SetOverrides(p profile.Preferences) error {
+	if err := p.Validate(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	if base := s.profile.Preferences; base.Merge(p).Loosens(base) && !s.Role.Can(PermOverrideLimits) {
+		s.mu.Unlock()
+		return fmt.Errorf("%w: %s may not loosen profile limits", ErrPermissionDenied, s.Role)
+	}
+	s.overrides = p
+	s.mu.Unlock()
+
+	if s.onChange != nil {
+		s.onChange()
+	}
+	return nil
+}
+
+// setProfile replaces profile after it was saved again
+func (s *Session) setProfile(p profile.Profile) {
+	s.mu.Lock()
+	s.profile = p
+	s.mu.Unlock()
+}
+
+// History returns commands issued in this session, oldest first
+func (s *Session) History() []nlp.Command {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]nlp.Command(nil), s.history...)
+}
+
+// LastCommand returns most recent command of session, nil if none yet
+func (s *Session) LastCommand() *nlp.Command {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if len(s.history) == 0 {
+		return nil
+	}
+	last := s.history[len(s.history)-1]
+	return &last
+}
+
+// Baseline returns moving average of behavior metrics observed while
+// session was active; ok is false until first observation
+func (s *Session) Baseline() (metrics behavior.PatternMetrics, ok bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.baseline, s.hasBaseline
+}
+
+// record appends command to session history
+func (s *Session) record(cmd nlp.Command) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.history = append(s.history, cmd)
+	if len(s.history) > sessionHistorySize {
+		s.history = s.history[1:]
+	}
+}
+
+// observe folds metrics into behavior baseline
+func (s *Session) observe(m behavior.PatternMetrics) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.hasBaseline {
+		s.baseline = m
+		s.hasBaseline = true
+		return
+	}
+	ewma := func(old, v float64) float64 { return old + baselineWeight*(v-old) }
+	s.baseline.Intensity = ewma(s.baseline.Intensity, m.Intensity)
+	s.baseline.Frequency = ewma(s.baseline.Frequency, m.Frequency)
+	s.baseline.Duration = ewma(s.baseline.Duration, m.Duration)
+	s.baseline.Consistency = ewma(s.baseline.Consistency, m.Consistency)
+}
+
+// sessionRegistry holds open sessions and which one is physically active
+type sessionRegistry struct {
+	mu       sync.RWMutex
+	sessions map[SessionID]*Session
+	active   *Session
+}
+
+// newSessionID returns random hex identifier
+func newSessionID() (SessionID, error) {
+	var b [8]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	return SessionID(hex.EncodeToString(b[:])), nil
+}
+
+// StartSession opens session for user and makes it active one. Saved
+// profile of user is loaded, unknown users get default preferences.
+// Session runs with role of caller in ctx.
+func (s *System) StartSession(ctx context.Context, user string) (*Session, error) {
+	if err := s.Authorize(ctx, PermCommand); err != nil {
+		return nil, err
+	}
+	role, ok := s.callerRole(ctx)
+	if !ok {
+		role = RoleMaintainer
+	}
+	var owner string
+	if p, ok := PrincipalFromContext(ctx); ok {
+		owner = p.User
+	}
+
+	p, err := s.profiles.Get(user)
+	if errors.Is(err, profile.ErrNotFound) {
+		p = profile.Profile{Name: user}
+		err = p.Validate()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := newSessionID()
+	if err != nil {
+		return nil, err
+	}
+
+	sess := &Session{
+		ID:        id,
+		StartedAt: s.clock.Now(),
+		Role:      role,
+		Owner:     owner,
+		profile:   p,
+		dialog:    nlp.NewDialog(),
+		onChange:  s.applyProfile,
+	}
+
+	s.sessions.mu.Lock()
+	if s.sessions.sessions == nil {
+		s.sessions.sessions = make(map[SessionID]*Session)
+	}
+	s.sessions.sessions[id] = sess
+	s.sessions.active = sess
+	s.sessions.mu.Unlock()
+
+	s.applyProfile()
+	return sess, nil
+}
+
+// authorizeSession lets callers manage their own session, sessions of
+// others need configure permission
+func (s *System) authorizeSession(ctx context.Context, sess *Session) error {
+	if err := s.Authorize(ctx, PermCommand); err != nil {
+		return err
+	}
+	if id, ok := SessionFromContext(ctx); ok && id == sess.ID {
+		return nil
+	}
+	if p, ok := PrincipalFromContext(ctx); ok && p.User != "" && p.User == sess.Owner {
+		return nil
+	}
+	return s.Authorize(ctx, PermConfigure)
+}
+
+// EndSession closes session, dropping its context
+func (s *System) EndSession(ctx context.Context, id SessionID) error {
+	sess, err := s.Session(id)
+	if err != nil {
+		return err
+	}
+	if err := s.authorizeSession(ctx, sess); err != nil {
+		return err
+	}
+
+	s.sessions.mu.Lock()
+	if s.sessions.sessions[id] != sess {
+		s.sessions.mu.Unlock()
+		return fmt.Errorf("%w: %s", ErrSessionNotFound, id)
+	}
+	delete(s.sessions.sessions, id)
+	wasActive := s.sessions.active == sess
+	if wasActive {
+		s.sessions.active = nil
+	}
+	s.sessions.mu.Unlock()
+
+	if wasActive {
+		s.applyProfile()
+	}
+	return nil
+}
+
+// Session returns open session by ID
+func (s *System) Session(id SessionID) (*Session, error) {
+	s.sessions.mu.RLock()
+	defer s.sessions.mu.RUnlock()
+
+	sess, ok := s.sessions.sessions[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrSessionNotFound, id)
+	}
+	return sess, nil
+}
+
+// Sessions returns all open sessions, oldest first
+func (s *System) Sessions() []*Session {
+	s.sessions.mu.RLock()
+	defer s.sessions.mu.RUnlock()
+
+	list := make([]*Session, 0, len(s.sessions.sessions))
+	for _, sess := range s.sessions.sessions {
+		list = append(list, sess)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].StartedAt.Before(list[j].StartedAt) })
+	return list
+}
+
+// ActivateSession marks session whose user is currently interacting, sensor
+// derived behavior metrics feed its baseline
+func (s *System) ActivateSession(ctx context.Context, id SessionID) error {
+	sess, err := s.Session(id)
+	if err != nil {
+		return err
+	}
+	if err := s.authorizeSession(ctx, sess); err != nil {
+		return err
+	}
+
+	s.sessions.mu.Lock()
+	if s.sessions.sessions[id] != sess {
+		s.sessions.mu.Unlock()
+		return fmt.Errorf("%w: %s", ErrSessionNotFound, id)
+	}
+	s.sessions.active = sess
+	s.sessions.mu.Unlock()
+
+	s.applyProfile()
+	return nil
+}
+
+// ActiveSession returns session currently interacting, nil if none
+func (s *System) ActiveSession() *Session {
+	s.sessions.mu.RLock()
+	defer s.sessions.mu.RUnlock()
+	return s.sessions.active
+}
@@ -0,0 +1,53 @@
+package core
+
+import (
+	"log"
+
+	"github.com/sashalind/sex-artifical-intelligence/pkg/sensor"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/sim"
+)
+
+// startSimulation replaces hardware with simulated world, sensors feed hub
+// and motor commands go to simulated drivers sharing system clock
+func (s *System) startSimulation(cfg SimulationConfig) error {
+	scenario := sim.DefaultScenario()
+	if cfg.Scenario != "" {
+		loaded, err := sim.LoadScenario(cfg.Scenario)
+		if err != nil {
+			return err
+		}
+		scenario = loaded
+	}
+
+	world := sim.NewWorld(s.clock, s.motionCtrl.GetMotors(), scenario)
+	if err := s.AttachMotionDriver(world.Motors); err != nil {
+		return err
+	}
+	var src sensor.Source = world.Sensors
+	if cfg.Replay != "" {
+		replay, err := sensor.OpenReplay(s.clock, cfg.Replay, sensor.ReplayOptions{Loop: true})
+		if err != nil {
+			return err
+		}
+		src = replay
+		log.Printf("Simulated sensors replay %s", cfg.Replay)
+	}
+	if err := s.AttachSensorSource(src, sim.DefaultSampleInterval); err != nil {
+		return err
+	}
+	s.world = world
+
+	log.Printf("Running in simulation mode, scenario %q", scenario.Name)
+	return nil
+}
+
+// Simulated reports whether system runs against simulated hardware
+func (s *System) Simulated() bool {
+	return s.world != nil
+}
+
+// SimulatedWorld returns simulated hardware, nil when running on real
+// hardware. Safety wires its E-stop, tests press it.
+func (s *System) SimulatedWorld() *sim.World {
+	return s.world
+}
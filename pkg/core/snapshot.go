@@ -0,0 +1,573 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"time"
+
+	"github.com/sashalind/sex-artifical-intelligence/pkg/behavior"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/core/errs"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/motion"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/nlp"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/storage"
+)
+
+// SnapshotFormat identifies snapshot documents
+const SnapshotFormat = "sai-snapshot"
+
+// SnapshotVersion is current snapshot schema version.
+//
+// Compatibility rules:
+//   - adding optional fields does not bump version, readers ignore unknown fields
+//   - renaming, removing or changing meaning of fields bumps version and
+//     requires migration from previous version registered in migrations
+//   - readers refuse snapshots with version newer than they understand
+const SnapshotVersion = 1
+
+var (
+	ErrInvalidSnapshot     = errs.New(errs.InvalidArgument, "not a system snapshot")
+	ErrUnsupportedSnapshot = errs.New(errs.FailedPrecondition, "snapshot version is newer than supported")
+)
+
+// Snapshot is serializable system state. Field layout is decoupled from
+// internal structs so subsystem refactors don't break saved files.
+type Snapshot struct {
+	Format    string    `json:"format"`
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+
+	Motors   []MotorSnapshot   `json:"motors"`
+	Patterns []PatternSnapshot `json:"patterns"`
+	Behavior BehaviorSnapshot  `json:"behavior"`
+
+	// optional fields added within version 1, snapshots without them
+	// leave language context and calibration as they are
+	NLP         NLPSnapshot                `json:"nlp"`
+	Calibration map[string]json.RawMessage `json:"calibration,omitempty"`
+}
+
+// MotorSnapshot holds saved motor state
+type MotorSnapshot struct {
+	ID       string  `json:"id"`
+	Position float64 `json:"position"`
+	Enabled  bool    `json:"enabled"`
+}
+
+// PatternSnapshot holds saved movement pattern
+type PatternSnapshot struct {
+	Name       string            `json:"name"`
+	DurationMs int64             `json:"duration_ms"`
+	Commands   []CommandSnapshot `json:"commands"`
+
+	Waveforms []WaveformSnapshot `json:"waveforms,omitempty"`
+	OffsetsMs []int64            `json:"offsets_ms,omitempty"`
+}
+
+// CommandSnapshot holds single motor command of pattern
+type CommandSnapshot struct {
+	Motor    string  `json:"motor"`
+	Position float64 `json:"position"`
+	Speed    float64 `json:"speed"`
+}
+
+// WaveformSnapshot holds waveform of pattern
+type WaveformSnapshot struct {
+	Motor     string    `json:"motor"`
+	Shape     string    `json:"shape"`
+	Center    float64   `json:"center"`
+	Amplitude float64   `json:"amplitude"`
+	Frequency float64   `json:"frequency"`
+	Phase     float64   `json:"phase"`
+	Points    []float64 `json:"points,omitempty"`
+}
+
+// patternSnapshot converts movement pattern to its saved form
+func patternSnapshot(p motion.MovementPattern) PatternSnapshot {
+	ps := PatternSnapshot{
+		Name:       p.Name,
+		DurationMs: p.Duration.Milliseconds(),
+	}
+	for _, cmd := range p.Commands {
+		ps.Commands = append(ps.Commands, CommandSnapshot{
+			Motor:    string(cmd.ID),
+			Position: cmd.Position,
+			Speed:    cmd.Speed,
+		})
+	}
+	for _, w := range p.Waveforms {
+		ps.Waveforms = append(ps.Waveforms, WaveformSnapshot{
+			Motor:     string(w.Motor),
+			Shape:     string(w.Shape),
+			Center:    w.Center,
+			Amplitude: w.Amplitude,
+			Frequency: w.Frequency,
+			Phase:     w.Phase,
+			Points:    w.Points,
+		})
+	}
+	for _, at := range p.Offsets {
+		ps.OffsetsMs = append(ps.OffsetsMs, at.Milliseconds())
+	}
+	return ps
+}
+
+// pattern converts saved pattern back
+func (p PatternSnapshot) pattern() motion.MovementPattern {
+	pattern := motion.MovementPattern{
+		Name:     p.Name,
+		Duration: time.Duration(p.DurationMs) * time.Millisecond,
+	}
+	for _, cmd := range p.Commands {
+		pattern.Commands = append(pattern.Commands, motion.MotorCommand{
+			ID:       motion.MotorID(cmd.Motor),
+			Position: cmd.Position,
+			Speed:    cmd.Speed,
+		})
+	}
+	for _, w := range p.Waveforms {
+		pattern.Waveforms = append(pattern.Waveforms, motion.Waveform{
+			Motor:     motion.MotorID(w.Motor),
+			Shape:     motion.WaveShape(w.Shape),
+			Center:    w.Center,
+			Amplitude: w.Amplitude,
+			Frequency: w.Frequency,
+			Phase:     w.Phase,
+			Points:    w.Points,
+		})
+	}
+	for _, ms := range p.OffsetsMs {
+		pattern.Offsets = append(pattern.Offsets, time.Duration(ms)*time.Millisecond)
+	}
+	return pattern
+}
+
+// BehaviorSnapshot holds behavior analyzer state
+type BehaviorSnapshot struct {
+	State   string                    `json:"state"`
+	History []BehaviorPatternSnapshot `json:"history,omitempty"`
+}
+
+// BehaviorPatternSnapshot holds detected behavior pattern
+type BehaviorPatternSnapshot struct {
+	Type       string                  `json:"type"`
+	Confidence float64                 `json:"confidence"`
+	Timestamp  time.Time               `json:"timestamp"`
+	Metrics    BehaviorMetricsSnapshot `json:"metrics"`
+}
+
+// BehaviorMetricsSnapshot holds measurements of behavior pattern
+type BehaviorMetricsSnapshot struct {
+	Intensity   float64 `json:"intensity"`
+	Frequency   float64 `json:"frequency"`
+	Duration    float64 `json:"duration"`
+	Consistency float64 `json:"consistency"`
+	Sentiment   float64 `json:"sentiment"`
+	Urgency     float64 `json:"urgency"`
+}
+
+// behaviorSnapshot converts behavior pattern to its saved form
+func behaviorSnapshot(p behavior.BehaviorPattern) BehaviorPatternSnapshot {
+	return BehaviorPatternSnapshot{
+		Type:       string(p.Type),
+		Confidence: p.Confidence,
+		Timestamp:  p.Timestamp,
+		Metrics: BehaviorMetricsSnapshot{
+			Intensity:   p.Metrics.Intensity,
+			Frequency:   p.Metrics.Frequency,
+			Duration:    p.Metrics.Duration,
+			Consistency: p.Metrics.Consistency,
+			Sentiment:   p.Metrics.Sentiment,
+			Urgency:     p.Metrics.Urgency,
+		},
+	}
+}
+
+// pattern converts saved behavior pattern back
+func (p BehaviorPatternSnapshot) pattern() behavior.BehaviorPattern {
+	return behavior.BehaviorPattern{
+		Type:       behavior.BehaviorType(p.Type),
+		Confidence: p.Confidence,
+		Timestamp:  p.Timestamp,
+		Metrics: behavior.PatternMetrics{
+			Intensity:   p.Metrics.Intensity,
+			Frequency:   p.Metrics.Frequency,
+			Duration:    p.Metrics.Duration,
+			Consistency: p.Metrics.Consistency,
+			Sentiment:   p.Metrics.Sentiment,
+			Urgency:     p.Metrics.Urgency,
+		},
+	}
+}
+
+// check validates saved behavior pattern
+func (p BehaviorPatternSnapshot) check() error {
+	if !knownBehavior(p.Type) {
+		return fmt.Errorf("behavior pattern: unknown type %q", p.Type)
+	}
+	if !(p.Confidence >= 0 && p.Confidence <= 1) {
+		return fmt.Errorf("behavior pattern %s: confidence %v outside 0..1", p.Type, p.Confidence)
+	}
+	m := p.Metrics
+	for _, v := range []float64{m.Intensity, m.Frequency, m.Duration, m.Consistency, m.Sentiment, m.Urgency} {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return fmt.Errorf("behavior pattern %s: metrics not finite", p.Type)
+		}
+	}
+	return nil
+}
+
+// knownBehavior reports whether analyzer has state of given name
+func knownBehavior(state string) bool {
+	switch behavior.BehaviorType(state) {
+	case behavior.BehaviorNormal, behavior.BehaviorAggressive,
+		behavior.BehaviorPassive, behavior.BehaviorErratic:
+		return true
+	}
+	return false
+}
+
+// NLPSnapshot holds language context
+type NLPSnapshot struct {
+	History []CommandHistorySnapshot `json:"history,omitempty"`
+}
+
+// CommandHistorySnapshot holds parsed command of language context
+type CommandHistorySnapshot struct {
+	Type       string                 `json:"type"`
+	Parameters map[string]interface{} `json:"parameters"`
+	Priority   int                    `json:"priority"`
+	Confidence float64                `json:"confidence"`
+	Sentiment  float64                `json:"sentiment"`
+	Urgency    float64                `json:"urgency"`
+	Timestamp  time.Time              `json:"timestamp"`
+
+	Steps  []CommandHistorySnapshot `json:"steps,omitempty"`
+	Delay  int64                    `json:"delay,omitempty"` // nanoseconds
+	Source string                   `json:"source,omitempty"`
+}
+
+// commandSnapshot converts parsed command to its saved form
+func commandSnapshot(cmd nlp.Command) CommandHistorySnapshot {
+	cs := CommandHistorySnapshot{
+		Type:       string(cmd.Type),
+		Parameters: cmd.Parameters,
+		Priority:   cmd.Priority,
+		Confidence: cmd.Confidence,
+		Sentiment:  cmd.Sentiment,
+		Urgency:    cmd.Urgency,
+		Timestamp:  cmd.Timestamp,
+		Delay:      int64(cmd.Delay),
+		Source:     cmd.Source,
+	}
+	for _, step := range cmd.Steps {
+		cs.Steps = append(cs.Steps, commandSnapshot(step))
+	}
+	return cs
+}
+
+// command converts saved command back
+func (c CommandHistorySnapshot) command() nlp.Command {
+	cmd := nlp.Command{
+		Type:       nlp.CommandType(c.Type),
+		Parameters: c.Parameters,
+		Priority:   c.Priority,
+		Confidence: c.Confidence,
+		Sentiment:  c.Sentiment,
+		Urgency:    c.Urgency,
+		Timestamp:  c.Timestamp,
+		Delay:      time.Duration(c.Delay),
+		Source:     c.Source,
+	}
+	for _, step := range c.Steps {
+		cmd.Steps = append(cmd.Steps, step.command())
+	}
+	return cmd
+}
+
+// check validates saved command and its steps
+func (c CommandHistorySnapshot) check() error {
+	if c.Type == "" {
+		return errors.New("command without type")
+	}
+	if c.Delay < 0 {
+		return fmt.Errorf("command %s: negative delay", c.Type)
+	}
+	for _, step := range c.Steps {
+		if err := step.check(); err != nil {
+			return fmt.Errorf("command %s: %w", c.Type, err)
+		}
+	}
+	return nil
+}
+
+// migration upgrades raw snapshot document from version N to N+1
+type migration func(doc map[string]json.RawMessage) error
+
+// migrations is keyed by source version. Version 1 is the v0.1 format,
+// later schema changes add entries here instead of breaking old files.
+var migrations = map[int]migration{}
+
+// Snapshot captures current system state
+func (s *System) Snapshot() *Snapshot {
+	snap := &Snapshot{
+		Format:    SnapshotFormat,
+		Version:   SnapshotVersion,
+		CreatedAt: s.clock.Now(),
+		Behavior: BehaviorSnapshot{
+			State: string(s.behavior.GetCurrentState()),
+		},
+	}
+	for _, p := range s.behavior.GetPatternHistory() {
+		snap.Behavior.History = append(snap.Behavior.History, behaviorSnapshot(p))
+	}
+	if h, ok := s.nlpProc.(historyKeeper); ok {
+		for _, cmd := range h.GetHistory() {
+			snap.NLP.History = append(snap.NLP.History, commandSnapshot(cmd))
+		}
+	}
+
+	if calibration, err := s.calibration(); err == nil {
+		snap.Calibration = calibration
+	} else {
+		log.Printf("Snapshot without calibration: %v", err)
+	}
+
+	for _, m := range s.motionCtrl.GetMotors() {
+		snap.Motors = append(snap.Motors, MotorSnapshot{
+			ID:       string(m.ID),
+			Position: m.Position,
+			Enabled:  m.IsEnabled,
+		})
+	}
+
+	for _, p := range s.motionCtrl.GetPatterns() {
+		snap.Patterns = append(snap.Patterns, patternSnapshot(p))
+	}
+
+	return snap
+}
+
+// Restore applies snapshot to running system. Motors missing from current
+// hardware are skipped, so snapshots survive motor layout changes. Nothing
+// is applied unless whole snapshot is valid.
+func (s *System) Restore(snap *Snapshot) error {
+	if snap == nil || snap.Format != SnapshotFormat {
+		return ErrInvalidSnapshot
+	}
+	if snap.Version > SnapshotVersion {
+		return ErrUnsupportedSnapshot
+	}
+
+	restorer, ok := s.motionCtrl.(motionRestorer)
+	if !ok && (len(snap.Motors) > 0 || len(snap.Patterns) > 0) {
+		return fmt.Errorf("%w: motion restore", ErrNotSupported)
+	}
+
+	known := make(map[motion.MotorID]motion.Motor)
+	for _, m := range s.motionCtrl.GetMotors() {
+		known[m.ID] = m
+	}
+
+	var problems []error
+	for _, m := range snap.Motors {
+		motor, ok := known[motion.MotorID(m.ID)]
+		if !ok {
+			continue
+		}
+		if !(m.Position >= motor.MinPosition && m.Position <= motor.MaxPosition) {
+			problems = append(problems, fmt.Errorf("motor %s: position %v outside %v..%v",
+				m.ID, m.Position, motor.MinPosition, motor.MaxPosition))
+		}
+	}
+
+	patterns := make([]motion.MovementPattern, 0, len(snap.Patterns))
+	for _, ps := range snap.Patterns {
+		p := ps.pattern()
+		if err := checkPattern(p); err != nil {
+			problems = append(problems, err)
+		}
+		patterns = append(patterns, p)
+	}
+
+	state := behavior.BehaviorType(snap.Behavior.State)
+	if state == "" {
+		state = behavior.BehaviorNormal
+	} else if !knownBehavior(snap.Behavior.State) {
+		problems = append(problems, fmt.Errorf("unknown behavior state %q", snap.Behavior.State))
+	}
+	history := make([]behavior.BehaviorPattern, 0, len(snap.Behavior.History))
+	for _, p := range snap.Behavior.History {
+		if err := p.check(); err != nil {
+			problems = append(problems, err)
+		}
+		history = append(history, p.pattern())
+	}
+
+	commands := make([]nlp.Command, 0, len(snap.NLP.History))
+	for _, c := range snap.NLP.History {
+		if err := c.check(); err != nil {
+			problems = append(problems, err)
+		}
+		commands = append(commands, c.command())
+	}
+
+	for key, value := range snap.Calibration {
+		if err := checkCalibration(key, value); err != nil {
+			problems = append(problems, err)
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: %w", ErrInvalidSnapshot, errors.Join(problems...))
+	}
+
+	for _, m := range snap.Motors {
+		id := motion.MotorID(m.ID)
+		if _, ok := known[id]; !ok {
+			continue
+		}
+		if err := restorer.RestoreMotor(id, m.Position, m.Enabled); err != nil {
+			return fmt.Errorf("restore motor %s: %w", m.ID, err)
+		}
+	}
+
+	for _, p := range patterns {
+		restorer.AddPattern(p)
+	}
+
+	s.behavior.Restore(state, history)
+
+	if h, ok := s.nlpProc.(historyKeeper); ok && len(commands) > 0 {
+		h.RestoreHistory(commands)
+	}
+
+	return s.restoreCalibration(snap.Calibration)
+}
+
+// checkCalibration validates saved calibration entry
+func checkCalibration(key string, value json.RawMessage) error {
+	if key == "" {
+		return errors.New("calibration without motor")
+	}
+	var cal motion.Calibration
+	if err := json.Unmarshal(value, &cal); err != nil {
+		return fmt.Errorf("calibration %s: %v", key, err)
+	}
+	if cal.SoftMin > cal.SoftMax {
+		return fmt.Errorf("calibration %s: soft limits %v..%v reversed", key, cal.SoftMin, cal.SoftMax)
+	}
+	return nil
+}
+
+// calibration reads calibration bucket, nil without storage
+func (s *System) calibration() (map[string]json.RawMessage, error) {
+	store := s.Store()
+	if store == nil {
+		return nil, nil
+	}
+	bucket, err := store.Bucket(storage.BucketCalibration)
+	if err != nil {
+		return nil, err
+	}
+
+	data := make(map[string]json.RawMessage)
+	err = bucket.ForEach(func(key string, value json.RawMessage) error {
+		data[key] = append(json.RawMessage(nil), value...)
+		return nil
+	})
+	if len(data) == 0 {
+		return nil, err
+	}
+	return data, err
+}
+
+// restoreCalibration writes saved calibration entries back to storage
+func (s *System) restoreCalibration(data map[string]json.RawMessage) error {
+	store := s.Store()
+	if store == nil || len(data) == 0 {
+		return nil
+	}
+	bucket, err := store.Bucket(storage.BucketCalibration)
+	if err != nil {
+		return err
+	}
+	for key, value := range data {
+		if err := bucket.Put(key, value); err != nil {
+			return fmt.Errorf("restore calibration %s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// WriteSnapshot encodes snapshot as JSON
+func WriteSnapshot(w io.Writer, snap *Snapshot) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(snap)
+}
+
+// ReadSnapshot decodes snapshot, migrating older versions to current schema
+func ReadSnapshot(r io.Reader) (*Snapshot, error) {
+	var doc map[string]json.RawMessage
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSnapshot, err)
+	}
+
+	var header struct {
+		Format  string `json:"format"`
+		Version int    `json:"version"`
+	}
+	raw, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(raw, &header); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSnapshot, err)
+	}
+	if header.Format != SnapshotFormat || header.Version < 1 {
+		return nil, ErrInvalidSnapshot
+	}
+	if header.Version > SnapshotVersion {
+		return nil, fmt.Errorf("%w: version %d, supported %d",
+			ErrUnsupportedSnapshot, header.Version, SnapshotVersion)
+	}
+
+	if err := migrateSnapshot(doc, header.Version); err != nil {
+		return nil, err
+	}
+
+	raw, err = json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	var snap Snapshot
+	if err := json.Unmarshal(raw, &snap); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSnapshot, err)
+	}
+	return &snap, nil
+}
+
+// migrateSnapshot walks migration chain from given version to current
+func migrateSnapshot(doc map[string]json.RawMessage, version int) error {
+	for v := version; v < SnapshotVersion; v++ {
+		migrate, ok := migrations[v]
+		if !ok {
+			return fmt.Errorf("no snapshot migration from version %d", v)
+		}
+		if err := migrate(doc); err != nil {
+			return fmt.Errorf("migrate snapshot from version %d: %w", v, err)
+		}
+
+		next, err := json.Marshal(v + 1)
+		if err != nil {
+			return err
+		}
+		doc["version"] = next
+	}
+	return nil
+}
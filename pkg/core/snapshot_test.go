@@ -0,0 +1,230 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sashalind/sex-artifical-intelligence/pkg/behavior"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/clock"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/motion"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/storage"
+)
+
+func newSnapshotSystem(t *testing.T) *System {
+	t.Helper()
+	cfg := DefaultConfig()
+	cfg.Clock = clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	cfg.SelfTest.Disabled = true
+	sys, err := NewSystemWithConfig(cfg)
+	if err != nil {
+		t.Fatalf("NewSystemWithConfig: %v", err)
+	}
+	t.Cleanup(sys.Shutdown)
+	return sys
+}
+
+func motorPosition(t *testing.T, sys *System, id motion.MotorID) float64 {
+	t.Helper()
+	for _, m := range sys.motionCtrl.GetMotors() {
+		if m.ID == id {
+			return m.Position
+		}
+	}
+	t.Fatalf("motor %s not found", id)
+	return 0
+}
+
+func hasPattern(sys *System, name string) bool {
+	for _, p := range sys.motionCtrl.GetPatterns() {
+		if p.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// validSnapshot moves servo_1, adds pattern and changes every other section
+func validSnapshot() *Snapshot {
+	at := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	return &Snapshot{
+		Format:  SnapshotFormat,
+		Version: SnapshotVersion,
+		Motors: []MotorSnapshot{
+			{ID: "servo_1", Position: 45, Enabled: true},
+			{ID: "removed", Position: 1000},
+		},
+		Patterns: []PatternSnapshot{{
+			Name:       "restored",
+			DurationMs: 1000,
+			Commands:   []CommandSnapshot{{Motor: "servo_1", Position: 90, Speed: 30}},
+		}},
+		Behavior: BehaviorSnapshot{
+			State: "passive",
+			History: []BehaviorPatternSnapshot{
+				{Type: "passive", Confidence: 0.8, Timestamp: at, Metrics: BehaviorMetricsSnapshot{Intensity: 0.2}},
+			},
+		},
+		NLP: NLPSnapshot{History: []CommandHistorySnapshot{{
+			Type:  "sequence",
+			Steps: []CommandHistorySnapshot{{Type: "move"}, {Type: "stop", Delay: int64(time.Minute)}},
+		}}},
+	}
+}
+
+func TestRestoreSnapshot(t *testing.T) {
+	sys := newSnapshotSystem(t)
+
+	var buf strings.Builder
+	if err := WriteSnapshot(&buf, validSnapshot()); err != nil {
+		t.Fatal(err)
+	}
+	snap, err := ReadSnapshot(strings.NewReader(buf.String()))
+	if err != nil {
+		t.Fatalf("ReadSnapshot: %v", err)
+	}
+	if err := sys.Restore(snap); err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+
+	if got := motorPosition(t, sys, "servo_1"); got != 45 {
+		t.Errorf("servo_1 at %v, want 45", got)
+	}
+	if !hasPattern(sys, "restored") {
+		t.Error("pattern not restored")
+	}
+	if got := sys.behavior.GetCurrentState(); got != behavior.BehaviorPassive {
+		t.Errorf("behavior state = %s, want passive", got)
+	}
+
+	again := sys.Snapshot()
+	if len(again.Behavior.History) != 1 || again.Behavior.History[0].Confidence != 0.8 {
+		t.Errorf("behavior history = %+v", again.Behavior.History)
+	}
+	if h := again.NLP.History; len(h) != 1 || len(h[0].Steps) != 2 || h[0].Steps[1].Delay != int64(time.Minute) {
+		t.Errorf("nlp history = %+v", h)
+	}
+}
+
+// TestRestoreSnapshotFixture restores files written in version 1 format,
+// with and without fields added within version 1
+func TestRestoreSnapshotFixture(t *testing.T) {
+	tests := []struct {
+		file        string
+		history     int
+		calibration bool
+	}{
+		{"snapshot_v1.json", 1, true},
+		{"snapshot_v1_minimal.json", 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			sys := newSnapshotSystem(t)
+			store, err := storage.Open(t.TempDir(), nil)
+			if err != nil {
+				t.Fatalf("Open: %v", err)
+			}
+			t.Cleanup(func() { store.Close() })
+			if err := sys.AttachStore(store); err != nil {
+				t.Fatalf("AttachStore: %v", err)
+			}
+
+			f, err := os.Open(filepath.Join("testdata", tt.file))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+			snap, err := ReadSnapshot(f)
+			if err != nil {
+				t.Fatalf("ReadSnapshot: %v", err)
+			}
+			if err := sys.Restore(snap); err != nil {
+				t.Fatalf("Restore: %v", err)
+			}
+
+			if got := motorPosition(t, sys, "servo_1"); got != 45 {
+				t.Errorf("servo_1 at %v, want 45", got)
+			}
+			if !hasPattern(sys, "fixture_wave") {
+				t.Error("pattern not restored")
+			}
+			if got := sys.behavior.GetCurrentState(); got != behavior.BehaviorPassive {
+				t.Errorf("behavior state = %s, want passive", got)
+			}
+			history := sys.Snapshot().NLP.History
+			if len(history) != tt.history {
+				t.Fatalf("nlp history = %+v, want %d commands", history, tt.history)
+			}
+			if tt.history > 0 && (len(history[0].Steps) != 2 || history[0].Steps[1].Delay != int64(time.Minute)) {
+				t.Errorf("nlp history = %+v", history)
+			}
+			bucket, err := store.Bucket(storage.BucketCalibration)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var cal motion.Calibration
+			ok, err := bucket.Get("servo_1", &cal)
+			if err != nil || ok != tt.calibration || (ok && cal.SoftMax != 175) {
+				t.Errorf("calibration = %+v, %v, %v", cal, ok, err)
+			}
+		})
+	}
+}
+
+func TestRestoreInvalidSnapshotChangesNothing(t *testing.T) {
+	tests := []struct {
+		name   string
+		mutate func(*Snapshot)
+	}{
+		{"motor out of range", func(s *Snapshot) { s.Motors[0].Position = 200 }},
+		{"motor position not a number", func(s *Snapshot) { s.Motors[0].Position = math.NaN() }},
+		{"pattern without name", func(s *Snapshot) { s.Patterns = append(s.Patterns, PatternSnapshot{}) }},
+		{"negative pattern duration", func(s *Snapshot) { s.Patterns[0].DurationMs = -1 }},
+		{"bad waveform", func(s *Snapshot) {
+			s.Patterns[0].Waveforms = []WaveformSnapshot{{Motor: "servo_1", Shape: "zigzag", Frequency: 1}}
+		}},
+		{"unknown behavior state", func(s *Snapshot) { s.Behavior.State = "grumpy" }},
+		{"unknown behavior type", func(s *Snapshot) { s.Behavior.History[0].Type = "grumpy" }},
+		{"behavior confidence", func(s *Snapshot) { s.Behavior.History[0].Confidence = 2 }},
+		{"behavior metrics", func(s *Snapshot) { s.Behavior.History[0].Metrics.Urgency = math.Inf(1) }},
+		{"command without type", func(s *Snapshot) { s.NLP.History[0].Steps[0].Type = "" }},
+		{"negative delay", func(s *Snapshot) { s.NLP.History[0].Steps[1].Delay = -1 }},
+		{"broken calibration", func(s *Snapshot) {
+			s.Calibration = map[string]json.RawMessage{"servo_1": json.RawMessage(`"zero"`)}
+		}},
+		{"reversed calibration", func(s *Snapshot) {
+			s.Calibration = map[string]json.RawMessage{"servo_1": json.RawMessage(`{"SoftMin": 10, "SoftMax": 5}`)}
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sys := newSnapshotSystem(t)
+			position := motorPosition(t, sys, "servo_1")
+			state := sys.behavior.GetCurrentState()
+
+			snap := validSnapshot()
+			tt.mutate(snap)
+			if err := sys.Restore(snap); !errors.Is(err, ErrInvalidSnapshot) {
+				t.Fatalf("Restore = %v, want ErrInvalidSnapshot", err)
+			}
+
+			if got := motorPosition(t, sys, "servo_1"); got != position {
+				t.Errorf("servo_1 moved to %v", got)
+			}
+			if hasPattern(sys, "restored") {
+				t.Error("pattern added")
+			}
+			if got := sys.behavior.GetCurrentState(); got != state {
+				t.Errorf("behavior state changed to %s", got)
+			}
+			if h := sys.Snapshot().NLP.History; len(h) != 0 {
+				t.Errorf("nlp history restored: %+v", h)
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package core
+
+import (
+	"bytes"
+	"log"
+	"time"
+
+	"github.com/sashalind/sex-artifical-intelligence/pkg/secure"
+)
+
+// SaveState writes snapshot of current state to path atomically. File is
+// encrypted with storage key when storage is attached.
+func (s *System) SaveState(path string) error {
+	var buf bytes.Buffer
+	if err := WriteSnapshot(&buf, s.Snapshot()); err != nil {
+		return err
+	}
+	return secure.WriteFile(path, buf.Bytes(), s.stateCipher())
+}
+
+// LoadState restores state saved by SaveState. Missing file is reported as
+// os.ErrNotExist so callers can treat first start as cold boot.
+func (s *System) LoadState(path string) error {
+	data, err := secure.ReadFile(path, s.stateCipher())
+	if err != nil {
+		return err
+	}
+	snap, err := ReadSnapshot(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	return s.Restore(snap)
+}
+
+// StartAutosave saves state every interval until shutdown, so crash loses
+// at most one interval
+func (s *System) StartAutosave(path string, interval time.Duration) {
+	go func() {
+		ticker := s.clock.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-s.ctx.Done():
+				return
+			case <-ticker.C():
+				if err := s.SaveState(path); err != nil {
+					log.Printf("Autosave failed: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+// stateCipher returns storage cipher, nil without storage
+func (s *System) stateCipher() *secure.Cipher {
+	if store := s.Store(); store != nil {
+		return store.Cipher()
+	}
+	return nil
+}
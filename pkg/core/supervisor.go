@@ -0,0 +1,225 @@
+package core
+
+import (
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sashalind/sex-artifical-intelligence/pkg/core/errs"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/core/event"
+)
+
+// SubsystemState is health of one supervised subsystem
+type SubsystemState string
+
+const (
+	SubsystemOK       SubsystemState = "ok"
+	SubsystemFailed   SubsystemState = "failed"   // subsystem itself died, restart pending
+	SubsystemDegraded SubsystemState = "degraded" // something it depends on failed
+)
+
+// restart backoff doubles from base up to max
+const (
+	restartBaseBackoff = 100 * time.Millisecond
+	restartMaxBackoff  = 30 * time.Second
+)
+
+var ErrSubsystemUnavailable = errs.New(errs.Unavailable, "subsystem unavailable")
+
+// SubsystemStatus is snapshot of supervised subsystem health
+type SubsystemStatus struct {
+	Name      string         `json:"name"`
+	State     SubsystemState `json:"state"`
+	Failures  int            `json:"failures"`
+	LastError string         `json:"last_error,omitempty"`
+	Since     time.Time      `json:"since"`
+}
+
+// TopicSubsystemStatus carries every subsystem health change
+var TopicSubsystemStatus = event.NewTopic[SubsystemStatus]("system.subsystem")
+
+// supervised is one unit watched by supervisor
+type supervised struct {
+	status     SubsystemStatus
+	dependsOn  []string
+	restart    func() error // nil for units that only degrade
+	restarting bool
+}
+
+// supervisor tracks subsystem health, degrades dependents of failed
+// subsystems and restarts failed ones with exponential backoff
+type supervisor struct {
+	sys   *System
+	mu    sync.Mutex
+	units map[string]*supervised
+	order []string
+}
+
+func newSupervisor(sys *System) *supervisor {
+	return &supervisor{sys: sys, units: make(map[string]*supervised)}
+}
+
+// supervise starts tracking subsystem, restart may be nil
+func (sv *supervisor) supervise(name string, dependsOn []string, restart func() error) {
+	sv.mu.Lock()
+	defer sv.mu.Unlock()
+
+	sv.units[name] = &supervised{
+		status: SubsystemStatus{
+			Name:  name,
+			State: SubsystemOK,
+			Since: sv.sys.clock.Now(),
+		},
+		dependsOn: dependsOn,
+		restart:   restart,
+	}
+	sv.order = append(sv.order, name)
+}
+
+// report marks subsystem failed, degrades dependents and schedules restart
+func (sv *supervisor) report(name string, err error) {
+	sv.mu.Lock()
+	u, ok := sv.units[name]
+	if !ok {
+		sv.mu.Unlock()
+		return
+	}
+	u.status.Failures++
+	u.status.LastError = err.Error()
+	changed := sv.setStateLocked(u, SubsystemFailed)
+	changed = append(changed, sv.propagateLocked()...)
+	startRestart := u.restart != nil && !u.restarting
+	if startRestart {
+		u.restarting = true
+	}
+	sv.mu.Unlock()
+
+	log.Printf("Subsystem %s failed: %v", name, err)
+	sv.publish(changed)
+
+	if startRestart {
+		go sv.restartLoop(name, u)
+	}
+}
+
+// restartLoop retries restart with exponential backoff until it succeeds
+// or system shuts down
+func (sv *supervisor) restartLoop(name string, u *supervised) {
+	backoff := restartBaseBackoff
+	for {
+		select {
+		case <-sv.sys.ctx.Done():
+			return
+		case <-sv.sys.clock.After(backoff):
+		}
+
+		err := u.restart()
+		if err == nil {
+			break
+		}
+		log.Printf("Restart of %s failed, next attempt in %v: %v", name, backoff*2, err)
+		if backoff *= 2; backoff > restartMaxBackoff {
+			backoff = restartMaxBackoff
+		}
+	}
+
+	sv.mu.Lock()
+	u.restarting = false
+	changed := sv.setStateLocked(u, SubsystemOK)
+	changed = append(changed, sv.propagateLocked()...)
+	sv.mu.Unlock()
+
+	log.Printf("Subsystem %s restarted", name)
+	sv.publish(changed)
+}
+
+// propagateLocked recomputes degraded state of every unit that didn't fail
+// itself, returns units whose state changed. Caller holds mu.
+func (sv *supervisor) propagateLocked() []SubsystemStatus {
+	var changed []SubsystemStatus
+	for _, name := range sv.order {
+		u := sv.units[name]
+		if u.status.State == SubsystemFailed {
+			continue
+		}
+		state := SubsystemOK
+		if sv.brokenDepLocked(u, make(map[string]bool)) {
+			state = SubsystemDegraded
+		}
+		changed = append(changed, sv.setStateLocked(u, state)...)
+	}
+	return changed
+}
+
+// brokenDepLocked reports whether any transitive dependency failed
+func (sv *supervisor) brokenDepLocked(u *supervised, seen map[string]bool) bool {
+	for _, dep := range u.dependsOn {
+		if seen[dep] {
+			continue
+		}
+		seen[dep] = true
+		d, ok := sv.units[dep]
+		if !ok {
+			continue
+		}
+		if d.status.State == SubsystemFailed || sv.brokenDepLocked(d, seen) {
+			return true
+		}
+	}
+	return false
+}
+
+// setStateLocked updates state, returning new status if it changed
+func (sv *supervisor) setStateLocked(u *supervised, state SubsystemState) []SubsystemStatus {
+	if u.status.State == state {
+		return nil
+	}
+	u.status.State = state
+	u.status.Since = sv.sys.clock.Now()
+	return []SubsystemStatus{u.status}
+}
+
+// publish announces health changes
+func (sv *supervisor) publish(changed []SubsystemStatus) {
+	for _, st := range changed {
+		event.Publish(sv.sys.bus, TopicSubsystemStatus, st)
+	}
+}
+
+// healthy reports whether subsystem is fully available; unknown names are
+// treated as healthy
+func (sv *supervisor) healthy(name string) bool {
+	sv.mu.Lock()
+	defer sv.mu.Unlock()
+	u, ok := sv.units[name]
+	return !ok || u.status.State == SubsystemOK
+}
+
+// statuses returns health of all units sorted by name
+func (sv *supervisor) statuses() []SubsystemStatus {
+	sv.mu.Lock()
+	defer sv.mu.Unlock()
+
+	list := make([]SubsystemStatus, 0, len(sv.units))
+	for _, u := range sv.units {
+		list = append(list, u.status)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// SubsystemStatuses returns health of every supervised subsystem
+func (s *System) SubsystemStatuses() []SubsystemStatus {
+	return s.supervisor.statuses()
+}
+
+// Degraded reports whether any subsystem is failed or degraded
+func (s *System) Degraded() bool {
+	for _, st := range s.supervisor.statuses() {
+		if st.State != SubsystemOK {
+			return true
+		}
+	}
+	return false
+}
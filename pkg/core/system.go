@@ -2,14 +2,23 @@ package core
 
 import (
 	"context"
+	"fmt"
+	"log"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/sashalind/sex-artifical-intelligence/pkg/behavior"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/clock"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/core/event"
 	"github.com/sashalind/sex-artifical-intelligence/pkg/motion"
 	"github.com/sashalind/sex-artifical-intelligence/pkg/neural"
 	"github.com/sashalind/sex-artifical-intelligence/pkg/nlp"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/profile"
 	"github.com/sashalind/sex-artifical-intelligence/pkg/sensor"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/sim"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/storage"
 )
 
 // System represents main control system blyat
@@ -18,91 +27,360 @@ type System struct {
 	cancelFunc context.CancelFunc
 	
 	neuralNet  *neural.Network
-	sensorHub  *sensor.Hub
-	motionCtrl *motion.Controller
+	sensorHub  SensorSource
+	motionCtrl MotionExecutor
 	behavior   *behavior.Analyzer
-	nlpProc    *nlp.Processor
+	nlpProc    NLPEngine
+	
+	// robots driven by this system, primary first; fixed after startup
+	units      []*Unit
+	
+	// saved user preferences, see profiles.go
+	profiles   *profile.Store
+	
+	// publish/subscribe hub connecting subsystems
+	bus        *event.Bus
+	
+	// shared persistence, nil if system runs purely in memory
+	store      *storage.Store
 	
 	// mutex for thread safety, like in soviet russia
 	mu         sync.RWMutex
 	
-	// system states
-	isActive   bool
+	// system states; isActive is atomic because every subsystem loop polls it
+	isActive   atomic.Bool
 	startTime  time.Time
+	
+	// time source shared by every subsystem
+	clock      clock.Clock
+	
+	// ordered startup and shutdown of subsystems
+	lifecycle  *Lifecycle
+	
+	// prioritized commands waiting for execution
+	queue      *commandQueue
+	
+	// finished commands for queries, see history.go
+	history    *commandHistory
+	
+	// per-user interaction sessions
+	sessions   sessionRegistry
+	
+	// checks remote callers, nil leaves API open; see auth.go
+	auth       Authenticator
+	
+	// subsystem health and restarts
+	supervisor *supervisor
+	
+	// behavior driven motion adjustments, see adaptation.go
+	adaptation adaptation
+	
+	// automatic power-down, see idle.go
+	idle       idleManager
+	
+	// timed user routines, see scheduler.go
+	scheduler  *scheduler
+	
+	// current speed, intensity and sensitivity, see params.go
+	params     motionParams
+	
+	// numbers compound commands for routines of their steps, see sequence.go
+	sequences  atomic.Uint64
+	
+	// user scripts, see scripts.go
+	scripts    scriptRegistry
+	
+	// result of startup self-test, see selftest.go
+	selfTest   SelfTestResult
+	
+	// simulated hardware, nil on real hardware
+	world      *sim.World
+	
+	// config system runs with, cfgMu also serializes reloads
+	cfgMu      sync.Mutex
+	cfg        Config
+	
+	// command executors by type, see handlers.go
+	handlersMu sync.RWMutex
+	handlers   map[nlp.CommandType]CommandHandler
+	
+	// operating mode, see mode.go
+	modeMu        sync.RWMutex
+	mode          Mode
+	modeCallbacks []func(ModeChange)
 }
 
 // NewSystem creates new instance of our glorious system
 func NewSystem() (*System, error) {
-	ctx, cancel := context.WithCancel(context.Background())
-	
-	neuralNet, err := neural.NewNetwork()
+	return NewSystemWithClock(clock.Real)
+}
+
+// NewSystemWithClock creates system where all subsystems share given clock,
+// letting tests and simulations control time
+func NewSystemWithClock(clk clock.Clock) (*System, error) {
+	cfg := DefaultConfig()
+	cfg.Clock = clk
+	return NewSystemWithConfig(cfg)
+}
+
+// NewSystemWithConfig creates system for hardware layout described by config
+func NewSystemWithConfig(cfg Config) (*System, error) {
+	motionCfg, err := cfg.MotionConfig()
 	if err != nil {
-		cancel()
 		return nil, err
 	}
 	
-	sensorHub, err := sensor.NewHub()
+	clk := clock.OrReal(cfg.Clock)
+	profiles, err := profile.NewStore(clk)
 	if err != nil {
-		cancel()
 		return nil, err
 	}
+	ctx, cancel := context.WithCancel(systemContext(context.Background()))
 	
-	motionCtrl, err := motion.NewController()
-	if err != nil {
-		cancel()
-		return nil, err
+	sys := &System{
+		ctx:        ctx,
+		cancelFunc: cancel,
+		startTime:  clk.Now(),
+		clock:      clk,
+		lifecycle:  NewLifecycle(),
+		profiles:   profiles,
+		history:    newCommandHistory(cfg.History, clk),
+		auth:       cfg.authenticator(),
+		cfg:        cfg,
 	}
+	sys.supervisor = newSupervisor(sys)
+	sys.scheduler = newScheduler(sys)
+	sys.adaptation.setConfig(cfg.Adaptation)
+	sys.OnModeChange(sys.applyPause)
+	sys.OnModeChange(sys.applyLowPower)
+	sys.idle.setConfig(cfg.Idle)
+	sys.registerBuiltinHandlers()
 	
-	behaviorAnalyzer, err := behavior.NewAnalyzer()
-	if err != nil {
+	hooks := []Hook{
+		{
+			Name:  "bus",
+			Start: func() error { sys.bus = event.NewBus(); return nil },
+			Stop:  func() { sys.bus.Close() },
+		},
+		{
+			Name: "neural",
+			Start: func() (err error) {
+				sys.neuralNet, err = neural.NewNetworkWithClock(clk)
+				return err
+			},
+			Stop: func() { sys.neuralNet.Shutdown() },
+		},
+		{
+			Name:      "sensor",
+			DependsOn: []string{"bus"},
+			Start: func() error {
+				if sys.sensorHub = cfg.Subsystems.Sensors; sys.sensorHub == nil {
+					hub, err := sensor.NewHubWithConfig(clk, cfg.sensorConfig())
+					if err != nil {
+						return err
+					}
+					sys.sensorHub = hub
+				}
+				if err := sys.attachSensors(sys.sensorHub, cfg.Sensors); err != nil {
+					sys.sensorHub.Shutdown()
+					return err
+				}
+				attachBus(sys.sensorHub, sys.bus)
+				sys.superviseSensors("sensor", sys.sensorHub)
+				return nil
+			},
+			Stop: func() { sys.sensorHub.Shutdown() },
+		},
+		{
+			Name:      "motion",
+			DependsOn: []string{"bus"},
+			Start: func() error {
+				if sys.motionCtrl = cfg.Subsystems.Motion; sys.motionCtrl == nil {
+					ctrl, err := motion.NewControllerWithConfig(clk, motionCfg)
+					if err != nil {
+						return err
+					}
+					sys.motionCtrl = ctrl
+				}
+				if err := sys.configureMotion(sys.motionCtrl); err != nil {
+					sys.motionCtrl.Shutdown()
+					return err
+				}
+				if err := sys.attachHardware(sys.motionCtrl, cfg.Motors); err != nil {
+					sys.motionCtrl.Shutdown()
+					return err
+				}
+				attachBus(sys.motionCtrl, sys.bus)
+				sys.superviseMotion("motion", sys.motionCtrl)
+				return nil
+			},
+			Stop: func() { sys.motionCtrl.Shutdown() },
+		},
+		{
+			Name:      "behavior",
+			DependsOn: []string{"bus"},
+			Start: func() (err error) {
+				if sys.behavior, err = behavior.NewAnalyzerWithConfig(clk, cfg.behaviorConfig()); err != nil {
+					return err
+				}
+				sys.behavior.AttachBus(sys.bus)
+				return nil
+			},
+			Stop: func() { sys.behavior.Shutdown() },
+		},
+		{
+			Name: "nlp",
+			Start: func() error {
+				if sys.nlpProc = cfg.Subsystems.NLP; sys.nlpProc == nil {
+					proc, err := nlp.NewProcessorWithConfig(clk, cfg.nlpConfig())
+					if err != nil {
+						return err
+					}
+					sys.nlpProc = proc
+				}
+				return nil
+			},
+			Stop: func() { sys.nlpProc.Shutdown() },
+		},
+		{
+			Name:      "simulation",
+			DependsOn: []string{"motion", "sensor"},
+			Start: func() error {
+				if !cfg.Simulation.Enabled {
+					return nil
+				}
+				return sys.startSimulation(cfg.Simulation)
+			},
+		},
+		{
+			Name:      "units",
+			DependsOn: []string{"motion", "sensor"},
+			Start:     func() error { return sys.startUnits(cfg.Units) },
+			Stop:      func() { sys.stopUnits() },
+		},
+		{
+			Name:  "history",
+			Start: sys.history.open,
+			Stop:  sys.history.close,
+		},
+		{
+			Name:      "queue",
+			DependsOn: []string{"units", "nlp", "history"},
+			Start: func() error {
+				sys.supervisor.supervise("commands", []string{"motion"}, nil)
+				sys.queue = newCommandQueue(sys.recordCommand)
+				go sys.runQueue()
+				return nil
+			},
+			Stop: func() { sys.queue.stop() },
+		},
+		{
+			Name:      "scheduler",
+			DependsOn: []string{"queue"},
+			Start: func() error {
+				go sys.scheduler.run()
+				return nil
+			},
+		},
+		{
+			Name:      "idle",
+			DependsOn: []string{"queue"},
+			Start: func() error {
+				go sys.runIdle()
+				return nil
+			},
+		},
+		{
+			Name:      "adaptation",
+			DependsOn: []string{"behavior", "queue"},
+			Start: func() error {
+				go sys.runAdaptation()
+				return nil
+			},
+		},
+		{
+			Name:      "scripts",
+			DependsOn: []string{"queue", "behavior"},
+			Start:     func() error { return sys.startScripts(cfg.Scripts) },
+			Stop:      func() { sys.stopScripts() },
+		},
+		{
+			// behavior analysis based on sensor data
+			Name:      "analysis",
+			DependsOn: []string{"sensor", "behavior"},
+			Start: func() error {
+				sys.supervisor.supervise("analysis", []string{"sensor"}, nil)
+				go sys.analyzeBehavior()
+				return nil
+			},
+		},
+	}
+	builtin := make([]string, 0, len(hooks))
+	for _, h := range hooks {
+		if err := sys.lifecycle.Register(h); err != nil {
+			cancel()
+			return nil, err
+		}
+		builtin = append(builtin, h.Name)
+	}
+	
+	if err := sys.registerPlugins(cfg.Plugins, builtin); err != nil {
 		cancel()
 		return nil, err
 	}
 	
-	nlpProcessor, err := nlp.NewProcessor()
-	if err != nil {
+	// plugins see system as active while starting, so their loops don't exit early
+	sys.isActive.Store(true)
+	if err := sys.lifecycle.Start(); err != nil {
+		sys.isActive.Store(false)
 		cancel()
 		return nil, err
 	}
 	
-	sys := &System{
-		ctx:        ctx,
-		cancelFunc: cancel,
-		neuralNet:  neuralNet,
-		sensorHub:  sensorHub,
-		motionCtrl: motionCtrl,
-		behavior:   behaviorAnalyzer,
-		nlpProc:    nlpProcessor,
-		isActive:   true,
-		startTime:  time.Now(),
+	ready := ModeIdle
+	if !cfg.SelfTest.Disabled {
+		sys.selfTest = sys.runSelfTest(cfg.SelfTest)
+		logSelfTest(sys.selfTest)
+		if !sys.selfTest.Passed {
+			if cfg.SelfTest.Strict {
+				sys.Shutdown()
+				return nil, fmt.Errorf("%w: %d checks failed", ErrSelfTestFailed, len(sys.selfTest.Failed()))
+			}
+			ready = ModeSafe
+		}
 	}
 	
-	// Start behavior analysis based on sensor data
-	go sys.analyzeBehavior()
+	if err := sys.SetMode(ready); err != nil {
+		sys.Shutdown()
+		return nil, err
+	}
 	
 	return sys, nil
 }
 
-// ProcessCommand handles user command
-func (s *System) ProcessCommand(text string) (*nlp.Response, error) {
-	// Parse command using NLP
-	cmd, err := s.nlpProc.ProcessCommand(text)
+// ProcessCommand handles user command, waiting for its turn in command queue.
+// If ctx ends first, command is dropped from queue.
+func (s *System) ProcessCommand(ctx context.Context, text string) (*nlp.Response, error) {
+	ticket, err := s.SubmitCommand(ctx, text)
 	if err != nil {
 		return nil, err
 	}
+	resp, err := ticket.WaitContext(ctx)
+	if ctx.Err() != nil {
+		s.CancelCommand(ticket.ID)
+	}
+	return resp, err
+}
+
+// executeCommand runs parsed command, called from queue executor only
+func (s *System) executeCommand(ctx context.Context, cmd *nlp.Command) (*nlp.Response, error) {
+	// mode may have changed while command waited in queue
+	if err := s.checkCommandAllowed(cmd.Type); err != nil {
+		return nil, err
+	}
 	
-	// Handle command based on type
-	switch cmd.Type {
-	case nlp.CmdMove:
-		if err := s.handleMovement(cmd); err != nil {
-			return nil, err
-		}
-	case nlp.CmdStop:
-		if err := s.handleStop(cmd); err != nil {
-			return nil, err
-		}
-	case nlp.CmdAdjust:
-		if err := s.handleAdjustment(cmd); err != nil {
+	if h := s.handler(cmd.Type); h != nil {
+		if err := h(ctx, interpretCommand(cmd, s.commandPreferences(ctx))); err != nil {
 			return nil, err
 		}
 	}
@@ -113,62 +391,188 @@ func (s *System) ProcessCommand(text string) (*nlp.Response, error) {
 
 // Command handlers
 
-func (s *System) handleMovement(cmd *nlp.Command) error {
+// directionSigns tell which end of travel direction moves motor to
+var directionSigns = map[string]float64{
+	"right": 1, "up": 1, "forward": 1, "forwards": 1, "outward": 1, "clockwise": 1,
+	"left": -1, "down": -1, "back": -1, "backward": -1, "backwards": -1, "inward": -1, "counterclockwise": -1,
+}
+
+// handleMovement moves motor named by command, or first enabled one, of
+// addressed units. Direction picks end of travel, motors positioned in mm
+// move distance instead when one is said; with neither motor goes to
+// middle of travel. Speed is fraction of maximum speed of motor.
+func (s *System) handleMovement(ctx context.Context, cmd *nlp.Command) error {
+	prefs := s.commandPreferences(ctx)
+	
 	// Extract movement parameters
 	speed, ok := cmd.Parameters["speed"].(float64)
 	if !ok {
-		speed = 1.0 // default speed
+		// as adjusted, preferred speed before any adjustment
+		speed = s.params.get(prefs).Speed
 	}
+	speed = prefs.CapSpeed(speed * s.adaptation.speedScale())
 	
-	// Create motor command
-	motorCmd := motion.MotorCommand{
-		ID:       "servo_1", // TODO: determine appropriate motor
-		Speed:    speed,
-		Position: 90.0, // TODO: calculate from direction
+	units, err := s.commandUnits(cmd)
+	if err != nil {
+		return err
 	}
 	
 	// Send command to motion controller
-	return s.motionCtrl.ExecuteCommand(motorCmd)
+	for _, u := range units {
+		motor, err := commandMotor(u, cmd)
+		if err != nil {
+			return err
+		}
+		motorCmd := motion.MotorCommand{
+			ID:       motor.ID,
+			Speed:    speed * motor.MaxSpeed,
+			Position: movePosition(motor, cmd),
+		}
+		if err := u.motion.ExecuteCommand(ctx, motorCmd); err != nil {
+			return err
+		}
+	}
+	return s.switchMode(ModeIdle, ModeActive)
 }
 
-func (s *System) handleStop(cmd *nlp.Command) error {
-	// Stop all motors
-	for _, motor := range s.motionCtrl.GetMotors() {
-		stopCmd := motion.MotorCommand{
-			ID:       motor.ID,
-			Speed:    0,
-			Position: motor.Position,
+// commandMotor returns motor of unit named by command, first enabled one
+// when command names none
+func commandMotor(u *Unit, cmd *nlp.Command) (motion.Motor, error) {
+	name, named := cmd.Parameters["motor"].(string)
+	for _, m := range u.motion.GetMotors() {
+		if named && strings.EqualFold(string(m.ID), name) || !named && m.IsEnabled {
+			return m, nil
 		}
-		if err := s.motionCtrl.ExecuteCommand(stopCmd); err != nil {
+	}
+	if named {
+		return motion.Motor{}, &motion.MotorError{Motor: motion.MotorID(name), Err: motion.ErrMotorNotFound}
+	}
+	return motion.Motor{}, fmt.Errorf("%w: unit %s has no enabled motor", motion.ErrMotorDisabled, u.ID)
+}
+
+// movePosition returns target of move command within travel of motor
+func movePosition(m motion.Motor, cmd *nlp.Command) float64 {
+	lo, hi := m.Travel()
+	direction, _ := cmd.Parameters["direction"].(string)
+	sign := directionSigns[direction]
+	if d, ok := cmd.Parameters["distance"].(float64); ok && m.Frame.Unit == motion.UnitMillimeters {
+		if sign == 0 {
+			sign = 1
+		}
+		return min(max(m.Position+sign*d, lo), hi)
+	}
+	switch sign {
+	case 1:
+		return hi
+	case -1:
+		return lo
+	}
+	return (lo + hi) / 2
+}
+
+func (s *System) handleStop(ctx context.Context, cmd *nlp.Command) error {
+	units, err := s.commandUnits(cmd)
+	if err != nil {
+		return err
+	}
+	s.cancelSequences()
+	
+	// Stop all motors; keep going on error, other units still need to stop
+	var firstErr error
+	for _, u := range units {
+		if err := s.stopUnit(ctx, u); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	if firstErr != nil {
+		return firstErr
+	}
+	
+	return s.switchMode(ModeActive, ModeIdle)
+}
+
+func (s *System) handleAdjustment(ctx context.Context, cmd *nlp.Command) error {
+	_, err := s.adjustParams(cmd, s.commandPreferences(ctx))
+	return err
+}
+
+// handlePattern starts named pattern or program on addressed units; it
+// keeps playing after command finishes
+func (s *System) handlePattern(ctx context.Context, cmd *nlp.Command) error {
+	name, _ := cmd.Parameters["pattern"].(string)
+	units, err := s.commandUnits(cmd)
+	if err != nil {
+		return err
+	}
+	for _, u := range units {
+		if err := u.motion.ExecutePattern(s.ctx, name); err != nil {
 			return err
 		}
 	}
-	return nil
+	return s.switchMode(ModeIdle, ModeActive)
 }
 
-func (s *System) handleAdjustment(cmd *nlp.Command) error {
-	// TODO: implement parameter adjustment
+func (s *System) handlePause(ctx context.Context, cmd *nlp.Command) error {
+	return s.Pause()
+}
+
+func (s *System) handleResume(ctx context.Context, cmd *nlp.Command) error {
+	return s.Resume()
+}
+
+// handleSafeword stops everything, SubmitCommand already did before
+// command was queued
+func (s *System) handleSafeword(ctx context.Context, cmd *nlp.Command) error {
+	s.EmergencyStop()
 	return nil
 }
 
-// analyzeBehavior processes sensor data for behavioral patterns
+// behaviorWindow is number of recent readings per sensor type used for metrics
+const behaviorWindow = 100
+
+// analyzeBehavior turns sensor readings pushed by hub into behavior metrics
 func (s *System) analyzeBehavior() {
-	ticker := time.NewTicker(time.Second)
+	readings, cancel := s.sensorReadings(1024)
+	defer cancel()
+	
+	ticker := s.clock.NewTicker(time.Second)
 	defer ticker.Stop()
 	
+	windows := map[sensor.SensorType][]float64{
+		sensor.TypeTouch:    make([]float64, 0, behaviorWindow),
+		sensor.TypePressure: make([]float64, 0, behaviorWindow),
+		sensor.TypeMotion:   make([]float64, 0, behaviorWindow),
+	}
+	
 	for {
 		select {
 		case <-s.ctx.Done():
 			return
-		case <-ticker.C:
-			if !s.isActive {
+		case data, ok := <-readings:
+			if !ok {
+				return
+			}
+			window, tracked := windows[data.Type]
+			if !tracked {
+				continue
+			}
+			if len(window) == behaviorWindow {
+				copy(window, window[1:])
+				window = window[:behaviorWindow-1]
+			}
+			windows[data.Type] = append(window, data.Value)
+		case <-ticker.C():
+			if !s.isActive.Load() {
 				return
 			}
+			// nobody interacts during maintenance pause
+			if s.Paused() {
+				continue
+			}
 			
-			// Get latest sensor data
-			touchData := s.sensorHub.GetSensorData(sensor.TypeTouch)
-			pressureData := s.sensorHub.GetSensorData(sensor.TypePressure)
-			motionData := s.sensorHub.GetSensorData(sensor.TypeMotion)
+			touchData := windows[sensor.TypeTouch]
+			pressureData := windows[sensor.TypePressure]
+			motionData := windows[sensor.TypeMotion]
 			
 			if len(touchData) == 0 || len(pressureData) == 0 || len(motionData) == 0 {
 				continue
@@ -184,6 +588,9 @@ func (s *System) analyzeBehavior() {
 			
 			// Send metrics for analysis
 			s.behavior.AddMetrics(metrics)
+			if sess := s.ActiveSession(); sess != nil {
+				sess.observe(metrics)
+			}
 		}
 	}
 }
@@ -221,7 +628,8 @@ func calculateFrequency(motion []float64) float64 {
 
 func calculateConsistency(touch, pressure, motion []float64) float64 {
 	// Simple variance-based consistency measure
-	allData := append(append(touch, pressure...), motion...)
+	allData := make([]float64, 0, len(touch)+len(pressure)+len(motion))
+	allData = append(append(append(allData, touch...), pressure...), motion...)
 	if len(allData) < 2 {
 		return 1.0
 	}
@@ -252,25 +660,125 @@ func (s *System) Shutdown() {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	
-	s.isActive = false
+	s.SetMode(ModeShuttingDown)
+	s.isActive.Store(false)
 	s.cancelFunc()
 	
-	// shutdown all subsystems
-	s.neuralNet.Shutdown()
-	s.sensorHub.Shutdown()
-	s.motionCtrl.Shutdown()
-	s.behavior.Shutdown()
-	s.nlpProc.Shutdown()
+	// shutdown all subsystems, dependents first
+	s.lifecycle.Stop()
 }
 
-// IsActive checks if system is still running
-func (s *System) IsActive() bool {
+// AttachStore connects persistent storage to all subsystems that keep history
+func (s *System) AttachStore(store *storage.Store) error {
+	if s.history.cfg.File != "" && store.Cipher() != nil {
+		return ErrPlaintextHistory
+	}
+	if err := attachStore(s.nlpProc, store); err != nil {
+		return err
+	}
+	if err := s.behavior.AttachStore(store); err != nil {
+		return err
+	}
+	if err := attachStore(s.motionCtrl, store); err != nil {
+		return err
+	}
+	if err := attachStore(s.sensorHub, store); err != nil {
+		return err
+	}
+	if err := s.profiles.AttachStore(store); err != nil {
+		return err
+	}
+	if err := s.history.attach(store); err != nil {
+		return err
+	}
+	if err := s.scheduler.attach(store); err != nil {
+		return err
+	}
+	
+	s.mu.Lock()
+	s.store = store
+	s.mu.Unlock()
+	return nil
+}
+
+// Store returns attached persistent storage or nil
+func (s *System) Store() *storage.Store {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
-	return s.isActive
+	return s.store
+}
+
+// AttachMotionDriver routes motor output to hardware (or simulated) driver
+func (s *System) AttachMotionDriver(d motion.Driver) error {
+	a, ok := s.motionCtrl.(driverAttacher)
+	if !ok {
+		return fmt.Errorf("%w: motion driver", ErrNotSupported)
+	}
+	a.SetDriver(d)
+	return nil
+}
+
+// AttachSensorSource feeds readings from source into sensor hub
+func (s *System) AttachSensorSource(src sensor.Source, interval time.Duration) error {
+	a, ok := s.sensorHub.(sourceAttacher)
+	if !ok {
+		return fmt.Errorf("%w: sensor source", ErrNotSupported)
+	}
+	a.AttachSource(src, interval)
+	return nil
+}
+
+// GetMotors returns current motor states
+func (s *System) GetMotors() []motion.Motor {
+	return s.motionCtrl.GetMotors()
+}
+
+// GetSensorData returns recent readings of sensor type, oldest first
+func (s *System) GetSensorData(sType sensor.SensorType) []float64 {
+	return s.sensorHub.GetSensorData(sType)
+}
+
+// GetSensorTypes returns every known sensor type
+func (s *System) GetSensorTypes() []sensor.SensorType {
+	return s.sensorHub.GetSensorTypes()
+}
+
+// GetBehaviorState returns current behavior analysis state
+func (s *System) GetBehaviorState() behavior.BehaviorType {
+	return s.behavior.GetCurrentState()
+}
+
+// EmergencyStop halts all motors of every unit immediately, drops pending
+// steps of compound commands and enters safe mode
+func (s *System) EmergencyStop() {
+	s.cancelSequences()
+	s.motionCtrl.StopAll()
+	for _, u := range s.units {
+		u.motion.StopAll()
+	}
+	if mode := s.Mode(); mode != ModeSafe && mode != ModeShuttingDown {
+		if err := s.SetMode(ModeSafe); err != nil {
+			log.Printf("Entering safe mode failed: %v", err)
+		}
+	}
+}
+
+// IsActive checks if system is still running
+func (s *System) IsActive() bool {
+	return s.isActive.Load()
 }
 
 // GetUptime returns how long system has been running
 func (s *System) GetUptime() time.Duration {
-	return time.Since(s.startTime)
+	return s.clock.Since(s.startTime)
+}
+
+// Bus returns event bus subsystems publish to
+func (s *System) Bus() *event.Bus {
+	return s.bus
+}
+
+// Clock returns time source used by system
+func (s *System) Clock() clock.Clock {
+	return s.clock
 } 
\ No newline at end of file
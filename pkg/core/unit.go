@@ -0,0 +1,358 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/sashalind/sex-artifical-intelligence/pkg/core/errs"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/motion"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/nlp"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/sensor"
+)
+
+// UnitID identifies robot unit driven by this system
+type UnitID string
+
+// PrimaryUnit is unit built from top-level motors and sensors config. It is
+// the only unit wired to event bus, behavior analysis and simulation.
+const PrimaryUnit UnitID = "main"
+
+var ErrUnitNotFound = errs.New(errs.NotFound, "unit not found")
+
+// telemetryBuffer is frames stream holds for slow reader
+const telemetryBuffer = 16
+
+// Unit is one robot: its own motion controller and sensor hub
+type Unit struct {
+	ID UnitID
+
+	motion  MotionExecutor
+	sensors SensorSource
+}
+
+// Motion returns unit motion controller
+func (u *Unit) Motion() MotionExecutor {
+	return u.motion
+}
+
+// Sensors returns unit sensor hub
+func (u *Unit) Sensors() SensorSource {
+	return u.sensors
+}
+
+// held reports whether unit motion is frozen by pause
+func (u *Unit) held() bool {
+	h, ok := u.motion.(motionHolder)
+	return ok && h.Held()
+}
+
+// UnitStatus is aggregated state of one unit
+type UnitStatus struct {
+	ID          UnitID              `json:"id"`
+	Healthy     bool                `json:"healthy"`
+	Motors      []motion.Motor      `json:"motors"`
+	SensorTypes []sensor.SensorType `json:"sensor_types"`
+}
+
+// motionUnit and sensorUnit name unit subsystems for supervisor, primary
+// unit keeps historical names
+func motionUnit(id UnitID) string {
+	if id == PrimaryUnit {
+		return "motion"
+	}
+	return string(id) + "/motion"
+}
+
+func sensorUnit(id UnitID) string {
+	if id == PrimaryUnit {
+		return "sensor"
+	}
+	return string(id) + "/sensor"
+}
+
+// startUnits registers primary unit and creates additional ones from config
+func (s *System) startUnits(configs []UnitConfig) error {
+	s.units = []*Unit{{ID: PrimaryUnit, motion: s.motionCtrl, sensors: s.sensorHub}}
+
+	for _, uc := range configs {
+		u, err := s.newUnit(uc)
+		if err != nil {
+			s.stopUnits()
+			return fmt.Errorf("unit %s: %w", uc.ID, err)
+		}
+		s.units = append(s.units, u)
+		log.Printf("Unit %s online with %d motors", u.ID, len(uc.Motors))
+	}
+	return nil
+}
+
+// newUnit builds and supervises additional unit
+func (s *System) newUnit(uc UnitConfig) (*Unit, error) {
+	mc, err := motorsConfig(uc.Motors, uc.Groups)
+	if err != nil {
+		return nil, err
+	}
+	ctrl, err := motion.NewControllerWithConfig(s.clock, mc)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.configureMotion(ctrl); err != nil {
+		ctrl.Shutdown()
+		return nil, err
+	}
+	if err := s.attachHardware(ctrl, uc.Motors); err != nil {
+		ctrl.Shutdown()
+		return nil, err
+	}
+	hub, err := sensor.NewHubWithConfig(s.clock, uc.sensorConfig())
+	if err != nil {
+		ctrl.Shutdown()
+		return nil, err
+	}
+	if err := s.attachSensors(hub, uc.Sensors); err != nil {
+		hub.Shutdown()
+		ctrl.Shutdown()
+		return nil, err
+	}
+
+	u := &Unit{ID: UnitID(uc.ID), motion: ctrl, sensors: hub}
+	s.superviseMotion(motionUnit(u.ID), u.motion)
+	s.superviseSensors(sensorUnit(u.ID), u.sensors)
+	return u, nil
+}
+
+// stopUnits shuts down additional units, primary one belongs to lifecycle
+func (s *System) stopUnits() {
+	for _, u := range s.units {
+		if u.ID == PrimaryUnit {
+			continue
+		}
+		u.motion.Shutdown()
+		u.sensors.Shutdown()
+	}
+}
+
+// Unit returns unit by ID, matching case-insensitively as commands arrive
+// lowercased
+func (s *System) Unit(id UnitID) (*Unit, error) {
+	for _, u := range s.units {
+		if strings.EqualFold(string(u.ID), string(id)) {
+			return u, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnitNotFound, id)
+}
+
+// Units returns all units, primary first
+func (s *System) Units() []*Unit {
+	return append([]*Unit(nil), s.units...)
+}
+
+// UnitStatuses reports motors, sensors and health of every unit
+func (s *System) UnitStatuses() []UnitStatus {
+	statuses := make([]UnitStatus, 0, len(s.units))
+	for _, u := range s.units {
+		statuses = append(statuses, UnitStatus{
+			ID:          u.ID,
+			Healthy:     s.supervisor.healthy(motionUnit(u.ID)) && s.supervisor.healthy(sensorUnit(u.ID)),
+			Motors:      u.motion.GetMotors(),
+			SensorTypes: u.sensors.GetSensorTypes(),
+		})
+	}
+	return statuses
+}
+
+// commandUnits returns units command addresses: one named by "unit <id>"
+// or, without address, primary unit. Stop or safeword without address halts
+// all units.
+func (s *System) commandUnits(cmd *nlp.Command) ([]*Unit, error) {
+	if id, ok := cmd.Parameters["unit"].(string); ok {
+		u, err := s.Unit(UnitID(id))
+		if err != nil {
+			return nil, err
+		}
+		return []*Unit{u}, nil
+	}
+	if cmd.Type == nlp.CmdStop || cmd.Type == nlp.CmdSafeword {
+		return s.Units(), nil
+	}
+	return s.units[:1], nil
+}
+
+// stopUnit halts every motor of unit through its control loop, or directly
+// when loop is down or on hold
+func (s *System) stopUnit(ctx context.Context, u *Unit) error {
+	if !s.supervisor.healthy(motionUnit(u.ID)) || u.held() {
+		u.motion.StopAll()
+		return nil
+	}
+	for _, motor := range u.motion.GetMotors() {
+		stopCmd := motion.MotorCommand{
+			ID:       motor.ID,
+			Speed:    0,
+			Position: motor.Position,
+		}
+		if err := u.motion.ExecuteCommand(ctx, stopCmd); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MoveGroup moves several motors of unit as one coordinated pose: either
+// every command is valid and all are applied, or none is. Empty unit means
+// primary one.
+func (s *System) MoveGroup(ctx context.Context, unit UnitID, cmds []motion.MotorCommand) error {
+	return s.moveUnit(unit, func(u *Unit) error {
+		return u.motion.ExecuteGroup(ctx, cmds)
+	})
+}
+
+// MoveSynced moves motors of unit so they start and arrive together,
+// slowing faster ones down. Non-empty group limits commands to motors of
+// that configured group.
+func (s *System) MoveSynced(ctx context.Context, unit UnitID, group string, cmds []motion.MotorCommand) error {
+	return s.moveUnit(unit, func(u *Unit) error {
+		mover, ok := u.motion.(syncMover)
+		if !ok {
+			return ErrNotSupported
+		}
+		if group != "" {
+			return mover.MoveGroup(ctx, group, cmds)
+		}
+		return mover.ExecuteSynced(ctx, cmds)
+	})
+}
+
+// Jog drives motor of unit at velocity (degrees/second, sign is direction)
+// until JogStop. Jog lapses after motion.JogTimeout, so callers repeat it
+// while jog is held.
+func (s *System) Jog(ctx context.Context, unit UnitID, id string, velocity float64) error {
+	return s.moveUnit(unit, func(u *Unit) error {
+		j, ok := u.motion.(jogger)
+		if !ok {
+			return ErrNotSupported
+		}
+		return j.Jog(ctx, motion.MotorID(id), velocity)
+	})
+}
+
+// JogStop ends jog of motor on unit at once. Allowed in every mode, it
+// only ever stops motor.
+func (s *System) JogStop(unit UnitID, id string) error {
+	if unit == "" {
+		unit = PrimaryUnit
+	}
+	u, err := s.Unit(unit)
+	if err != nil {
+		return err
+	}
+	j, ok := u.motion.(jogger)
+	if !ok {
+		return ErrNotSupported
+	}
+	return j.JogStop(motion.MotorID(id))
+}
+
+// WaitArrival blocks until motors of unit reached targets of cmds sent
+// before, e.g. by MoveGroup. Fails once any move is interrupted or not done
+// within timeout; zero timeout waits until ctx ends.
+func (s *System) WaitArrival(ctx context.Context, unit UnitID, cmds []motion.MotorCommand, timeout time.Duration) error {
+	if unit == "" {
+		unit = PrimaryUnit
+	}
+	u, err := s.Unit(unit)
+	if err != nil {
+		return err
+	}
+	tracker, ok := u.motion.(moveTracker)
+	if !ok {
+		return ErrNotSupported
+	}
+
+	moves := make([]*motion.Completion, 0, len(cmds))
+	for _, cmd := range cmds {
+		m, err := tracker.Track(cmd.ID, cmd.Position, timeout)
+		if err != nil {
+			return err
+		}
+		moves = append(moves, m)
+	}
+	for _, m := range moves {
+		select {
+		case <-m.Done():
+			if err := m.Err(); err != nil {
+				return err
+			}
+		case <-ctx.Done():
+			return contextError(ctx.Err())
+		}
+	}
+	return nil
+}
+
+// MotorTelemetry subscribes to live motor state of unit at interval, empty
+// unit means primary one. Caller cancels stream when done.
+func (s *System) MotorTelemetry(unit UnitID, interval time.Duration) (*motion.Telemetry, error) {
+	if unit == "" {
+		unit = PrimaryUnit
+	}
+	u, err := s.Unit(unit)
+	if err != nil {
+		return nil, err
+	}
+	source, ok := u.motion.(telemetrySource)
+	if !ok {
+		return nil, ErrNotSupported
+	}
+	return source.Subscribe(interval, telemetryBuffer), nil
+}
+
+// MotorGroups lists configured motor groups of unit, empty unit means
+// primary one
+func (s *System) MotorGroups(unit UnitID) ([]GroupConfig, error) {
+	if unit == "" {
+		unit = PrimaryUnit
+	}
+	u, err := s.Unit(unit)
+	if err != nil {
+		return nil, err
+	}
+
+	groups := []GroupConfig{}
+	mover, ok := u.motion.(syncMover)
+	if !ok {
+		return groups, nil
+	}
+	for _, g := range mover.GetGroups() {
+		gc := GroupConfig{Name: g.Name}
+		for _, id := range g.Motors {
+			gc.Motors = append(gc.Motors, string(id))
+		}
+		groups = append(groups, gc)
+	}
+	return groups, nil
+}
+
+// moveUnit runs user motion request on unit, waking system from idle
+func (s *System) moveUnit(unit UnitID, move func(u *Unit) error) error {
+	s.markActivity()
+	if err := s.checkCommandAllowed(nlp.CmdMove); err != nil {
+		return err
+	}
+	if unit == "" {
+		unit = PrimaryUnit
+	}
+	u, err := s.Unit(unit)
+	if err != nil {
+		return err
+	}
+
+	if err := move(u); err != nil {
+		return err
+	}
+	return s.switchMode(ModeIdle, ModeActive)
+}
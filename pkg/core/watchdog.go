@@ -0,0 +1,34 @@
+package core
+
+import (
+	"fmt"
+	"time"
+)
+
+// defaultWatchdogTimeout is 20 motion control ticks
+const defaultWatchdogTimeout = 200 * time.Millisecond
+
+// WatchdogConfig controls motion watchdog
+type WatchdogConfig struct {
+	// Timeout without motion control tick before motors are stopped by
+	// controller and by hardware watchdog of drivers that have one, 0
+	// disables both
+	Timeout Duration `json:"timeout"`
+}
+
+// Validate checks watchdog options
+func (c WatchdogConfig) Validate() error {
+	if c.Timeout < 0 {
+		return fmt.Errorf("watchdog timeout must not be negative")
+	}
+	return nil
+}
+
+// setWatchdog applies watchdog config to motion executor
+func (s *System) setWatchdog(m MotionExecutor) error {
+	w, ok := m.(watchdogSetter)
+	if !ok {
+		return nil
+	}
+	return w.SetWatchdog(time.Duration(s.cfg.Watchdog.Timeout))
+}
@@ -7,7 +7,14 @@ import (
 	"sync"
 	"time"
 
+	"github.com/sashalind/sex-artifical-intelligence/pkg/behavior"
 	"github.com/sashalind/sex-artifical-intelligence/pkg/core"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/core/event"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/health"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/nlp"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/safety"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/sensor"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/storage"
 )
 
 // SystemMetrics represents system performance metrics
@@ -17,6 +24,13 @@ type SystemMetrics struct {
 	MemoryUsage   float64   `json:"memory_usage"`
 	Temperature   float64   `json:"temperature"`
 	UptimeSeconds int64     `json:"uptime_seconds"`
+	
+	// overall subsystem health and names of subsystems that are down
+	Health        health.Status `json:"health"`
+	Down          []string      `json:"down,omitempty"`
+	
+	// command processing counts, nil when NLP engine keeps none
+	NLP           *nlp.Stats    `json:"nlp,omitempty"`
 }
 
 // Monitor handles system diagnostics
@@ -27,10 +41,38 @@ type Monitor struct {
 	// diagnostic data
 	metrics  []SystemMetrics
 	logFile  *os.File
+	
+	// persistent metrics table, used instead of log file when storage is attached
+	history  *storage.Table[SystemMetrics]
+}
+
+var current *Monitor
+
+// GetMonitor returns active diagnostics monitor, nil before monitoring starts
+func GetMonitor() *Monitor {
+	return current
 }
 
 // StartMonitoring initializes diagnostic monitoring
 func StartMonitoring(sys *core.System) error {
+	if store := sys.Store(); store != nil {
+		history, err := storage.OpenTable[SystemMetrics](store, storage.BucketMetrics)
+		if err != nil {
+			return err
+		}
+		
+		monitor := &Monitor{
+			system:  sys,
+			metrics: make([]SystemMetrics, 0),
+			history: history,
+		}
+		
+		current = monitor
+		go monitor.collectMetrics()
+		go monitor.logEvents()
+		return nil
+	}
+	
 	logFile, err := os.OpenFile("diagnostics.log",
 		os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
 	if err != nil {
@@ -43,18 +85,87 @@ func StartMonitoring(sys *core.System) error {
 		logFile: logFile,
 	}
 	
+	current = monitor
 	go monitor.collectMetrics()
+	go monitor.logEvents()
 	return nil
 }
 
+// logEvents records safety alerts, behavior changes, sensor faults, alerts,
+// temperature levels and sensors added or removed from event bus
+func (m *Monitor) logEvents() {
+	bus := m.system.Bus()
+	alerts := event.Subscribe(bus, safety.TopicAlert, 64)
+	defer alerts.Cancel()
+	states := event.Subscribe(bus, behavior.TopicStateChanged, 16)
+	defer states.Cancel()
+	faults := event.Subscribe(bus, sensor.TopicSensorFault, 16)
+	defer faults.Cancel()
+	changes := event.Subscribe(bus, sensor.TopicSensorChange, 16)
+	defer changes.Cancel()
+	sensorAlerts := event.Subscribe(bus, sensor.TopicSensorAlert, 64)
+	defer sensorAlerts.Cancel()
+	temps := event.Subscribe(bus, sensor.TopicOverTemp, 16)
+	defer temps.Cancel()
+	
+	for {
+		select {
+		case alert, ok := <-alerts.C:
+			if !ok {
+				return
+			}
+			log.Printf("Safety alert (level %d): %s", alert.Level, alert.Message)
+		case pattern, ok := <-states.C:
+			if !ok {
+				return
+			}
+			log.Printf("Behavior changed to %s (confidence %.2f)", pattern.Type, pattern.Confidence)
+		case f, ok := <-faults.C:
+			if !ok {
+				return
+			}
+			if f.Recovered {
+				log.Printf("Sensor %s recovered from %s", f.Sensor, f.Kind)
+			} else {
+				log.Printf("Sensor %s %s (value %g)", f.Sensor, f.Kind, f.Value)
+			}
+		case c, ok := <-changes.C:
+			if !ok {
+				return
+			}
+			if c.Removed {
+				log.Printf("Sensor %s (%s) removed", c.Sensor.ID, c.Sensor.Type)
+			} else {
+				log.Printf("Sensor %s (%s) added", c.Sensor.ID, c.Sensor.Type)
+			}
+		case a, ok := <-sensorAlerts.C:
+			if !ok {
+				return
+			}
+			if a.Cleared {
+				log.Printf("Sensor alert %s cleared (%s %g)", a.Rule, a.Type, a.Value)
+			} else {
+				log.Printf("Sensor alert %s [%s]: %s %s %g, threshold %g", a.Rule, a.Severity, a.Type, a.Kind, a.Value, a.Threshold)
+			}
+		case t, ok := <-temps.C:
+			if !ok {
+				return
+			}
+			log.Printf("Temperature of zone %q sensor %s %s: %.1f°C, limit %.1f°C", t.Zone, t.Sensor, t.Level, t.Value, t.Limit)
+		}
+	}
+}
+
 // collectMetrics gathers system performance data
 func (m *Monitor) collectMetrics() {
-	ticker := time.NewTicker(5 * time.Second)
+	ticker := m.system.Clock().NewTicker(5 * time.Second)
 	defer ticker.Stop()
 	
-	for range ticker.C {
+	for range ticker.C() {
 		if !m.system.IsActive() {
-			m.logFile.Close()
+			if m.logFile != nil {
+				m.logFile.Close()
+			}
 			return
 		}
 		
@@ -65,15 +176,23 @@ func (m *Monitor) collectMetrics() {
 
 // gatherMetrics collects current system metrics
 func (m *Monitor) gatherMetrics() SystemMetrics {
+	h := m.system.Health()
+	
 	// TODO: implement actual metric collection
 	// For now return dummy data
-	return SystemMetrics{
-		Timestamp:     time.Now(),
+	metrics := SystemMetrics{
+		Health:        h.Status,
+		Down:          h.Down(),
+		Timestamp:     m.system.Clock().Now(),
 		CPUUsage:      45.5,
 		MemoryUsage:   1024.5,
 		Temperature:   37.2,
 		UptimeSeconds: int64(m.system.GetUptime().Seconds()),
 	}
+	if stats, err := m.system.NLPStats(); err == nil {
+		metrics.NLP = &stats
+	}
+	return metrics
 }
 
 // saveMetrics saves metrics to log file
@@ -88,6 +207,16 @@ func (m *Monitor) saveMetrics(metrics SystemMetrics) {
 		m.metrics = m.metrics[1:]
 	}
 	
+	if m.history != nil {
+		if _, err := m.history.Append(metrics); err != nil {
+			log.Printf("Failed to store metrics: %v", err)
+		}
+		if err := m.history.Trim(1000); err != nil {
+			log.Printf("Failed to trim metrics: %v", err)
+		}
+		return
+	}
+	
 	// save to log file
 	data, err := json.Marshal(metrics)
 	if err != nil {
@@ -111,4 +240,10 @@ func (m *Monitor) GetLatestMetrics() *SystemMetrics {
 	
 	latest := m.metrics[len(m.metrics)-1]
 	return &latest
-} 
\ No newline at end of file
+}
+
+// Misunderstood returns log of command text system did not understand, to
+// retrain intent classifier with
+func (m *Monitor) Misunderstood() ([]nlp.Misunderstanding, error) {
+	return m.system.Misunderstood()
+}
@@ -0,0 +1,57 @@
+// Package health is common health model reported by subsystems and
+// aggregated by core
+package health
+
+import "sync"
+
+// Status is overall condition of subsystem
+type Status string
+
+const (
+	OK       Status = "ok"
+	Degraded Status = "degraded" // working with reduced capability
+	Down     Status = "down"     // not working
+)
+
+var severity = map[Status]int{OK: 0, Degraded: 1, Down: 2}
+
+// Worse returns more severe of two statuses
+func Worse(a, b Status) Status {
+	if severity[b] > severity[a] {
+		return b
+	}
+	return a
+}
+
+// Report is health snapshot of one subsystem
+type Report struct {
+	Status    Status `json:"status"`
+	LastError string `json:"last_error,omitempty"`
+
+	// Gauges are current values of key metrics, e.g. queue depth
+	Gauges map[string]float64 `json:"gauges,omitempty"`
+}
+
+// LastError remembers most recent error of subsystem. Zero value is ready
+// to use and safe for concurrent use.
+type LastError struct {
+	mu  sync.Mutex
+	msg string
+}
+
+// Set records err, nil is ignored so callers can pass results through
+func (l *LastError) Set(err error) {
+	if err == nil {
+		return
+	}
+	l.mu.Lock()
+	l.msg = err.Error()
+	l.mu.Unlock()
+}
+
+// String returns message of most recent error, empty if there was none
+func (l *LastError) String() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.msg
+}
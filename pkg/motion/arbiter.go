@@ -0,0 +1,252 @@
+package motion
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+
+	"github.com/sashalind/sex-artifical-intelligence/pkg/core/errs"
+)
+
+var (
+	ErrMotorBusy       = errs.New(errs.FailedPrecondition, "motor driven by another pattern")
+	ErrTooManyPatterns = errs.New(errs.Unavailable, "too many patterns running")
+)
+
+// ArbitrationPolicy decides what pattern wanting motor another pattern
+// drives gets
+type ArbitrationPolicy string
+
+const (
+	ArbitratePreempt ArbitrationPolicy = "preempt" // patterns sharing motors with new one stop, default
+	ArbitrateReject  ArbitrationPolicy = "reject"  // new pattern fails with ErrMotorBusy
+	ArbitrateQueue   ArbitrationPolicy = "queue"   // new pattern waits until its motors are free
+)
+
+// arbitration defaults
+const (
+	defaultMaxRuns   = 4
+	defaultMaxQueued = 8
+)
+
+// Arbitration limits patterns playing at once. Patterns on disjoint motors
+// play side by side, Policy settles conflicts over motors.
+type Arbitration struct {
+	Policy    ArbitrationPolicy
+	MaxRuns   int // patterns playing at once, zero is 4
+	MaxQueued int // patterns waiting under queue policy, zero is 8
+}
+
+// Validate checks arbitration settings
+func (a Arbitration) Validate() error {
+	switch a.Policy {
+	case "", ArbitratePreempt, ArbitrateReject, ArbitrateQueue:
+	default:
+		return fmt.Errorf("unknown arbitration policy %q", a.Policy)
+	}
+	if a.MaxRuns < 0 || a.MaxQueued < 0 {
+		return fmt.Errorf("arbitration limits must not be negative")
+	}
+	return nil
+}
+
+func (a Arbitration) withDefaults() Arbitration {
+	if a.Policy == "" {
+		a.Policy = ArbitratePreempt
+	}
+	if a.MaxRuns == 0 {
+		a.MaxRuns = defaultMaxRuns
+	}
+	if a.MaxQueued == 0 {
+		a.MaxQueued = defaultMaxQueued
+	}
+	return a
+}
+
+// SetArbitration changes how later patterns are arbitrated, patterns
+// already playing or queued are kept
+func (c *Controller) SetArbitration(a Arbitration) error {
+	if err := a.Validate(); err != nil {
+		return err
+	}
+	c.arbMu.Lock()
+	defer c.arbMu.Unlock()
+
+	c.arbitration = a.withDefaults()
+	c.promote()
+	return nil
+}
+
+// Arbitration returns current arbitration settings
+func (c *Controller) Arbitration() Arbitration {
+	c.arbMu.Lock()
+	defer c.arbMu.Unlock()
+	return c.arbitration
+}
+
+// Runs reports patterns playing, oldest first, followed by queued ones
+func (c *Controller) Runs() []PatternProgress {
+	c.arbMu.Lock()
+	defer c.arbMu.Unlock()
+
+	runs := c.playing()
+	out := make([]PatternProgress, 0, len(runs)+len(c.queued))
+	for _, run := range runs {
+		out = append(out, c.progress(run))
+	}
+	for _, run := range c.queued {
+		out = append(out, PatternProgress{Name: run.name, Steps: len(run.commands), Motors: run.motors, Queued: true})
+	}
+	return out
+}
+
+// Owners maps each motor driven by pattern to name of that pattern
+func (c *Controller) Owners() map[MotorID]string {
+	c.arbMu.Lock()
+	defer c.arbMu.Unlock()
+
+	owners := make(map[MotorID]string)
+	for _, run := range c.playing() {
+		for _, id := range run.motors {
+			owners[id] = run.name
+		}
+	}
+	return owners
+}
+
+// admit starts run or queues it as policy says, stopping runs it preempts
+func (c *Controller) admit(run *patternRun) error {
+	c.arbMu.Lock()
+	defer c.arbMu.Unlock()
+
+	a := c.arbitration
+	runs := c.playing()
+	conflicts := conflicting(run, runs)
+	switch {
+	case a.Policy == ArbitratePreempt:
+		for _, old := range conflicts {
+			old.stop()
+		}
+		runs = slices.DeleteFunc(slices.Clone(runs), func(r *patternRun) bool { return slices.Contains(conflicts, r) })
+		c.runs.Store(&runs)
+	case a.Policy == ArbitrateReject && len(conflicts) > 0:
+		return fmt.Errorf("%w: pattern %s drives motor %s", ErrMotorBusy, conflicts[0].name, shared(run, conflicts[0]))
+	case a.Policy == ArbitrateQueue && (len(conflicts) > 0 || len(runs) >= a.MaxRuns || len(conflicting(run, c.queued)) > 0):
+		if len(c.queued) >= a.MaxQueued {
+			return fmt.Errorf("%w: %d patterns already queued", ErrTooManyPatterns, len(c.queued))
+		}
+		c.queued = append(c.queued, run)
+		return nil
+	}
+	if len(runs) >= a.MaxRuns {
+		return fmt.Errorf("%w: limit is %d", ErrTooManyPatterns, a.MaxRuns)
+	}
+	c.begin(run)
+	return nil
+}
+
+// retire drops ended run and starts queued runs it made room for
+func (c *Controller) retire(run *patternRun) {
+	run.stop()
+
+	c.arbMu.Lock()
+	defer c.arbMu.Unlock()
+
+	if i := slices.Index(c.queued, run); i >= 0 {
+		c.queued = slices.Delete(c.queued, i, i+1)
+	}
+	runs := c.playing()
+	if slices.Contains(runs, run) {
+		runs = slices.DeleteFunc(slices.Clone(runs), func(r *patternRun) bool { return r == run })
+		c.runs.Store(&runs)
+	}
+	c.promote()
+}
+
+// promote starts queued runs in order as long as their motors are free,
+// run never overtakes earlier one it shares motor with. Caller holds arbMu.
+func (c *Controller) promote() {
+	var waiting []*patternRun
+	for _, run := range c.queued {
+		if len(c.playing()) < c.arbitration.MaxRuns &&
+			len(conflicting(run, c.playing())) == 0 && len(conflicting(run, waiting)) == 0 {
+			c.begin(run)
+			continue
+		}
+		waiting = append(waiting, run)
+	}
+	c.queued = waiting
+}
+
+// begin starts playing admitted run, caller holds arbMu
+func (c *Controller) begin(run *patternRun) {
+	run.start = c.clock.Now()
+	runs := append(slices.Clone(c.playing()), run)
+	c.runs.Store(&runs)
+	close(run.admitted)
+}
+
+// stopRuns ends every playing and queued run
+func (c *Controller) stopRuns() {
+	c.arbMu.Lock()
+	defer c.arbMu.Unlock()
+
+	for _, run := range c.playing() {
+		run.stop()
+	}
+	for _, run := range c.queued {
+		run.stop()
+	}
+	c.runs.Store(nil)
+	c.queued = nil
+}
+
+// playing returns runs currently playing, oldest first. Slice is shared,
+// never modify it.
+func (c *Controller) playing() []*patternRun {
+	if runs := c.runs.Load(); runs != nil {
+		return *runs
+	}
+	return nil
+}
+
+// conflicting returns runs sharing motor with run
+func conflicting(run *patternRun, runs []*patternRun) []*patternRun {
+	var out []*patternRun
+	for _, other := range runs {
+		if shared(run, other) != "" {
+			out = append(out, other)
+		}
+	}
+	return out
+}
+
+// shared returns motor both runs drive, empty if none
+func shared(a, b *patternRun) MotorID {
+	for _, id := range a.motors {
+		if _, found := slices.BinarySearch(b.motors, id); found {
+			return id
+		}
+	}
+	return ""
+}
+
+// runMotors lists motors timeline drives, sorted
+func runMotors(tl timeline) []MotorID {
+	seen := make(map[MotorID]bool)
+	var ids []MotorID
+	add := func(id MotorID) {
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	for _, tc := range tl.commands {
+		add(tc.cmd.ID)
+	}
+	for _, s := range tl.segments {
+		add(s.wave.Motor)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
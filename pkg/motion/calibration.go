@@ -0,0 +1,361 @@
+package motion
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/sashalind/sex-artifical-intelligence/pkg/core/errs"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/storage"
+)
+
+// HomingMethod is how motor finds its reference position
+type HomingMethod string
+
+const (
+	HomeEndstop HomingMethod = "endstop" // drive until driver reports endstop switch
+	HomeStall   HomingMethod = "stall"   // drive until position stops changing, e.g. against hard stop
+	HomeCurrent HomingMethod = "current" // motor already sits at reference, e.g. placed by hand
+)
+
+var (
+	ErrHoming        = errs.New(errs.FailedPrecondition, "motor is homing")
+	ErrNoHoming      = errs.New(errs.FailedPrecondition, "motor has no homing configured")
+	ErrHomingTimeout = errs.New(errs.DeadlineExceeded, "homing did not find reference")
+	ErrNoEndstop     = errs.New(errs.FailedPrecondition, "driver has no endstop input")
+	ErrNeedsDriver   = errs.New(errs.FailedPrecondition, "calibration needs hardware driver")
+)
+
+// defaultHomingTimeout bounds homing run when config leaves it out
+const defaultHomingTimeout = 30 * time.Second
+
+// stall detection: motor counts as stalled once it moved less than
+// stallTolerance degrees for stallTime
+const (
+	stallTolerance = 0.05
+	stallTime      = 300 * time.Millisecond
+)
+
+// calibrationPrefix keeps motor entries apart from other calibration data
+// sharing the bucket
+const calibrationPrefix = "motor/"
+
+// Homing configures calibration run of motor
+type Homing struct {
+	Method    HomingMethod
+	Direction int           // -1 homes towards MinPosition, 1 towards MaxPosition
+	Speed     float64       // degrees/second, slow enough to stop at switch
+	Reference float64       // calibrated position of motor at reference, degrees
+	Margin    float64       // soft limits stay this far inside hard ones, degrees
+	Timeout   time.Duration // zero is 30s
+}
+
+// validate checks homing config of motor m
+func (h Homing) validate(m Motor) error {
+	switch h.Method {
+	case "":
+		return nil
+	case HomeEndstop, HomeStall:
+		if h.Direction != -1 && h.Direction != 1 {
+			return fmt.Errorf("motor %s: homing direction must be -1 or 1", m.ID)
+		}
+		if h.Speed <= 0 || h.Speed > m.MaxSpeed {
+			return fmt.Errorf("motor %s: homing speed must be positive and within max speed", m.ID)
+		}
+	case HomeCurrent:
+	default:
+		return fmt.Errorf("motor %s: unknown homing method %q", m.ID, h.Method)
+	}
+	if h.Reference < m.MinPosition || h.Reference > m.MaxPosition {
+		return fmt.Errorf("motor %s: homing reference outside position range", m.ID)
+	}
+	if h.Margin < 0 || 2*h.Margin >= m.MaxPosition-m.MinPosition {
+		return fmt.Errorf("motor %s: homing margin must leave some travel", m.ID)
+	}
+	if h.Timeout < 0 {
+		return fmt.Errorf("motor %s: homing timeout must not be negative", m.ID)
+	}
+	return nil
+}
+
+// Travel returns range commands may use: soft limits when calibrated,
+// hard ones otherwise
+func (m Motor) Travel() (float64, float64) {
+	if m.SoftMinPosition < m.SoftMaxPosition {
+		return m.SoftMinPosition, m.SoftMaxPosition
+	}
+	return m.MinPosition, m.MaxPosition
+}
+
+// Calibration is result of homing motor, kept across restarts
+type Calibration struct {
+	Motor   MotorID
+	Offset  float64 // hardware position of calibrated zero, degrees
+	SoftMin float64
+	SoftMax float64
+	HomedAt time.Time
+}
+
+// driveTo commands driver towards calibrated position, caller holds slot lock
+func (c *Controller) driveTo(slot *motorSlot, position, speed float64) error {
+	return c.driver.SetTarget(slot.ID, slot.shaft(position), slot.Frame.Degrees(speed))
+}
+
+// Home runs homing of motor as configured and stores resulting
+// calibration. Motor refuses commands meanwhile; ctx aborts run and stops
+// motor.
+func (c *Controller) Home(ctx context.Context, id MotorID) (Calibration, error) {
+	if !c.running.Load() {
+		return Calibration{}, ErrShutdown
+	}
+	c.mu.RLock()
+	slot, exists := c.motors[id]
+	driver := c.driver
+	c.mu.RUnlock()
+	if !exists {
+		return Calibration{}, &MotorError{Motor: id, Err: ErrMotorNotFound}
+	}
+	if driver == nil {
+		return Calibration{}, &MotorError{Motor: id, Err: ErrNeedsDriver}
+	}
+
+	slot.mu.Lock()
+	switch {
+	case !slot.IsEnabled:
+		slot.mu.Unlock()
+		return Calibration{}, &MotorError{Motor: id, Err: ErrMotorDisabled}
+	case slot.homing:
+		slot.mu.Unlock()
+		return Calibration{}, &MotorError{Motor: id, Err: ErrHoming}
+	case slot.heat.cooling:
+		slot.mu.Unlock()
+		return Calibration{}, &MotorError{Motor: id, Err: ErrMotorOverheated}
+	case slot.Homing.Method == "":
+		slot.mu.Unlock()
+		return Calibration{}, &MotorError{Motor: id, Err: ErrNoHoming}
+	}
+	slot.homing = true
+	m := slot.Motor
+	slot.mu.Unlock()
+
+	raw, err := c.findReference(ctx, driver, m)
+	if err != nil {
+		if serr := driver.Stop(id); serr != nil {
+			log.Printf("Failed to stop motor %s after homing: %v", id, serr)
+		}
+		slot.mu.Lock()
+		slot.homing = false
+		slot.Target = slot.Position
+		slot.mu.Unlock()
+		return Calibration{}, &MotorError{Motor: id, Err: err}
+	}
+
+	cal := Calibration{
+		Motor:   id,
+		Offset:  raw - m.Frame.ToShaft(m.Homing.Reference),
+		HomedAt: c.clock.Now(),
+	}
+	if m.Homing.Margin > 0 {
+		cal.SoftMin = m.MinPosition + m.Homing.Margin
+		cal.SoftMax = m.MaxPosition - m.Homing.Margin
+	}
+
+	slot.mu.Lock()
+	slot.homing = false
+	slot.apply(cal)
+	slot.Position = m.Homing.Reference
+	slot.Target = slot.Position
+	c.publish(slot.Motor)
+	slot.mu.Unlock()
+
+	c.saveCalibration(cal)
+	return cal, nil
+}
+
+// findReference drives motor to its reference and returns hardware
+// position there
+func (c *Controller) findReference(ctx context.Context, driver Driver, m Motor) (float64, error) {
+	raw, _, err := driver.ReadState(m.ID)
+	if err != nil {
+		return 0, err
+	}
+	if m.Homing.Method == HomeCurrent {
+		return raw, nil
+	}
+
+	endstop, hasEndstop := driver.(EndstopDriver)
+	if m.Homing.Method == HomeEndstop && !hasEndstop {
+		return 0, ErrNoEndstop
+	}
+
+	// aim past whole range so only reference can stop motor
+	shaft := m.Shaft()
+	dir := float64(m.Homing.Direction) * math.Copysign(1, m.Frame.scale())
+	far := raw + dir*2*(shaft.MaxPosition-shaft.MinPosition)
+	if err := driver.SetTarget(m.ID, far, m.Frame.Degrees(m.Homing.Speed)); err != nil {
+		return 0, err
+	}
+
+	timeout := m.Homing.Timeout
+	if timeout == 0 {
+		timeout = defaultHomingTimeout
+	}
+	deadline := c.clock.After(timeout)
+	ticker := c.clock.NewTicker(TickInterval)
+	defer ticker.Stop()
+
+	lastPos, lastMove := raw, c.clock.Now()
+	for {
+		select {
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		case <-c.done:
+			return 0, ErrShutdown
+		case <-deadline:
+			return 0, ErrHomingTimeout
+		case <-ticker.C():
+		}
+
+		pos, _, err := driver.ReadState(m.ID)
+		if err != nil {
+			return 0, err
+		}
+		switch m.Homing.Method {
+		case HomeEndstop:
+			hit, err := endstop.AtEndstop(m.ID)
+			if err != nil {
+				return 0, err
+			}
+			if !hit {
+				continue
+			}
+		case HomeStall:
+			if math.Abs(pos-lastPos) > stallTolerance {
+				lastPos, lastMove = pos, c.clock.Now()
+				continue
+			}
+			if c.clock.Since(lastMove) < stallTime {
+				continue
+			}
+		}
+
+		if err := driver.Stop(m.ID); err != nil {
+			return 0, err
+		}
+		raw, _, err := driver.ReadState(m.ID)
+		return raw, err
+	}
+}
+
+// apply installs calibration, caller holds slot lock
+func (slot *motorSlot) apply(cal Calibration) {
+	slot.offset = cal.Offset
+	slot.homedAt = cal.HomedAt
+	slot.SoftMinPosition = cal.SoftMin
+	slot.SoftMaxPosition = cal.SoftMax
+}
+
+// SetSoftLimits changes calibrated travel of motor within its hard limits,
+// equal values remove soft limits
+func (c *Controller) SetSoftLimits(id MotorID, min, max float64) error {
+	c.mu.RLock()
+	slot, exists := c.motors[id]
+	c.mu.RUnlock()
+	if !exists {
+		return &MotorError{Motor: id, Err: ErrMotorNotFound}
+	}
+
+	slot.mu.Lock()
+	if min != max && (min > max || min < slot.MinPosition || max > slot.MaxPosition) {
+		slot.mu.Unlock()
+		return &MotorError{Motor: id, Err: ErrPositionOutOfRange}
+	}
+	cal := slot.calibration()
+	cal.SoftMin, cal.SoftMax = min, max
+	if min == max {
+		cal.SoftMin, cal.SoftMax = 0, 0
+	}
+	slot.apply(cal)
+	c.publish(slot.Motor)
+	slot.mu.Unlock()
+
+	c.saveCalibration(cal)
+	return nil
+}
+
+// calibration returns current calibration of motor, caller holds slot lock
+func (slot *motorSlot) calibration() Calibration {
+	return Calibration{
+		Motor:   slot.ID,
+		Offset:  slot.offset,
+		SoftMin: slot.SoftMinPosition,
+		SoftMax: slot.SoftMaxPosition,
+		HomedAt: slot.homedAt,
+	}
+}
+
+// Calibrations returns calibration of every motor sorted by motor
+func (c *Controller) Calibrations() []Calibration {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	cals := make([]Calibration, 0, len(c.order))
+	for _, slot := range c.order {
+		slot.mu.Lock()
+		cals = append(cals, slot.calibration())
+		slot.mu.Unlock()
+	}
+	return cals
+}
+
+// saveCalibration persists calibration when store is attached
+func (c *Controller) saveCalibration(cal Calibration) {
+	c.mu.RLock()
+	lib := c.calibLib
+	c.mu.RUnlock()
+	if lib == nil {
+		return
+	}
+	if err := lib.Put(calibrationPrefix+string(cal.Motor), cal); err != nil {
+		log.Printf("Failed to persist calibration of motor %s: %v", cal.Motor, err)
+	}
+}
+
+// loadCalibrations applies saved calibrations of known motors
+func (c *Controller) loadCalibrations(bucket *storage.Bucket) error {
+	var problems []error
+	err := bucket.ForEach(func(key string, data json.RawMessage) error {
+		if !strings.HasPrefix(key, calibrationPrefix) {
+			return nil
+		}
+		var cal Calibration
+		if err := json.Unmarshal(data, &cal); err != nil {
+			problems = append(problems, fmt.Errorf("calibration %s: %w", key, err))
+			return nil
+		}
+
+		c.mu.RLock()
+		slot, exists := c.motors[cal.Motor]
+		c.mu.RUnlock()
+		if !exists {
+			return nil
+		}
+		slot.mu.Lock()
+		if cal.SoftMin < cal.SoftMax && (cal.SoftMin < slot.MinPosition || cal.SoftMax > slot.MaxPosition) {
+			// hard limits shrank since, keep offset only
+			cal.SoftMin, cal.SoftMax = 0, 0
+		}
+		slot.apply(cal)
+		slot.mu.Unlock()
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return errors.Join(problems...)
+}
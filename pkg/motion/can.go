@@ -0,0 +1,16 @@
+package motion
+
+// CANFrame is classic CAN frame with 11-bit identifier
+type CANFrame struct {
+	ID   uint32
+	Len  uint8
+	Data [8]byte
+}
+
+// CANBus is raw CAN interface. Receive waits up to bus read timeout and
+// reports false when nothing arrived.
+type CANBus interface {
+	Send(f CANFrame) error
+	Receive() (CANFrame, bool, error)
+	Close() error
+}
@@ -0,0 +1,96 @@
+//go:build linux
+
+package motion
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+// SocketCAN constants missing from syscall
+const (
+	afCAN        = 29
+	canRaw       = 1
+	canFrameSize = 16
+	canFlags     = 0xE0000000 // extended, remote and error frame flags
+	canSFFMask   = 0x7FF
+)
+
+// canReadTimeout bounds Receive so readers notice close
+const canReadTimeout = 100 * time.Millisecond
+
+// rawSockaddrCAN is struct sockaddr_can
+type rawSockaddrCAN struct {
+	Family  uint16
+	_       [2]byte
+	Ifindex int32
+	Addr    [16]byte
+}
+
+// socketCAN is raw CAN socket bound to one interface
+type socketCAN struct {
+	fd int
+}
+
+// OpenCAN opens SocketCAN interface like can0
+func OpenCAN(iface string) (CANBus, error) {
+	ifi, err := net.InterfaceByName(iface)
+	if err != nil {
+		return nil, err
+	}
+	fd, err := syscall.Socket(afCAN, syscall.SOCK_RAW, canRaw)
+	if err != nil {
+		return nil, fmt.Errorf("can socket: %w", err)
+	}
+	tv := syscall.NsecToTimeval(canReadTimeout.Nanoseconds())
+	if err := syscall.SetsockoptTimeval(fd, syscall.SOL_SOCKET, syscall.SO_RCVTIMEO, &tv); err != nil {
+		syscall.Close(fd)
+		return nil, fmt.Errorf("can read timeout: %w", err)
+	}
+	sa := rawSockaddrCAN{Family: afCAN, Ifindex: int32(ifi.Index)}
+	if _, _, errno := syscall.Syscall(syscall.SYS_BIND, uintptr(fd), uintptr(unsafe.Pointer(&sa)), unsafe.Sizeof(sa)); errno != 0 {
+		syscall.Close(fd)
+		return nil, fmt.Errorf("bind %s: %w", iface, errno)
+	}
+	return &socketCAN{fd: fd}, nil
+}
+
+func (s *socketCAN) Send(f CANFrame) error {
+	var buf [canFrameSize]byte
+	binary.NativeEndian.PutUint32(buf[0:], f.ID&canSFFMask)
+	buf[4] = f.Len
+	copy(buf[8:], f.Data[:f.Len])
+	_, err := syscall.Write(s.fd, buf[:])
+	return err
+}
+
+func (s *socketCAN) Receive() (CANFrame, bool, error) {
+	var buf [canFrameSize]byte
+	n, err := syscall.Read(s.fd, buf[:])
+	if errors.Is(err, syscall.EAGAIN) || errors.Is(err, syscall.EINTR) {
+		return CANFrame{}, false, nil
+	}
+	if err != nil {
+		return CANFrame{}, false, err
+	}
+	if n < canFrameSize {
+		return CANFrame{}, false, fmt.Errorf("short can frame of %d bytes", n)
+	}
+	id := binary.NativeEndian.Uint32(buf[0:])
+	if id&canFlags != 0 {
+		// nothing on CANopen bus uses these
+		return CANFrame{}, false, nil
+	}
+	f := CANFrame{ID: id, Len: min(buf[4], 8)}
+	copy(f.Data[:], buf[8:])
+	return f, true, nil
+}
+
+func (s *socketCAN) Close() error {
+	return syscall.Close(s.fd)
+}
@@ -0,0 +1,10 @@
+//go:build !linux
+
+package motion
+
+import "errors"
+
+// OpenCAN opens SocketCAN interface, only available on Linux
+func OpenCAN(iface string) (CANBus, error) {
+	return nil, errors.New("can interfaces are only supported on linux")
+}
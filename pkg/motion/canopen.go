@@ -0,0 +1,579 @@
+package motion
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/sashalind/sex-artifical-intelligence/pkg/clock"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/core/errs"
+)
+
+// CANopen function codes, COB-ID is code plus node ID
+const (
+	cobNMT   = 0x000
+	cobEMCY  = 0x080
+	cobTPDO1 = 0x180
+	cobRPDO1 = 0x200
+	cobTPDO2 = 0x280
+	cobSDOTx = 0x580 // node to driver
+	cobSDORx = 0x600 // driver to node
+)
+
+// NMT commands and SDO reply command bytes
+const (
+	nmtStart  = 0x01
+	nmtStop   = 0x02
+	nmtPreOp  = 0x80
+	sdoResult = 0x60 // download confirmed
+	sdoAbort  = 0x80
+)
+
+// object dictionary entries of CiA 402 drive profile and PDO setup
+const (
+	objControlword     = 0x6040
+	objStatusword      = 0x6041
+	objModeOfOperation = 0x6060
+	objPositionActual  = 0x6064
+	objVelocityActual  = 0x606C
+	objTargetPosition  = 0x607A
+	objProfileVelocity = 0x6081
+	objTargetVelocity  = 0x60FF
+
+	objRPDOComm    = 0x1400 // plus PDO number
+	objRPDOMapping = 0x1600
+	objTPDOComm    = 0x1800
+	objTPDOMapping = 0x1A00
+
+	modeProfilePosition = 1
+	modeProfileVelocity = 3
+)
+
+// CiA 402 controlword commands and statusword bits
+const (
+	cwShutdown          = 0x06
+	cwSwitchOn          = 0x07
+	cwEnableOperation   = 0x0F
+	cwNewSetPoint       = 0x10
+	cwChangeImmediately = 0x20
+	cwFaultReset        = 0x80
+	cwHalt              = 0x100
+
+	swFault             = 0x08
+	swStateMask         = 0x6F
+	swOperationEnabled  = 0x27
+	pdoDisabled         = 0x80000000
+	pdoAsync            = 0xFF
+	canopenEventTimerMs = 10
+)
+
+const (
+	// canopenSDOTimeout bounds wait for SDO confirmation
+	canopenSDOTimeout = 100 * time.Millisecond
+
+	// canopenFeedbackTimeout is age after which TPDO feedback is stale,
+	// drives send it every canopenEventTimerMs
+	canopenFeedbackTimeout = 100 * time.Millisecond
+)
+
+var (
+	// ErrCANopenTimeout is returned when node does not confirm SDO
+	ErrCANopenTimeout = errs.New(errs.Unavailable, "canopen node did not respond")
+
+	// ErrCANopenNoFeedback is returned when drive stopped sending PDOs
+	ErrCANopenNoFeedback = errs.New(errs.Unavailable, "canopen drive feedback is stale")
+)
+
+// CANopenError is SDO abort or fault reported by drive
+type CANopenError struct {
+	Node   uint8
+	Object uint16 // object of aborted SDO, zero for drive fault
+	Code   uint32 // SDO abort code or last emergency error code
+}
+
+func (e *CANopenError) Error() string {
+	if e.Object != 0 {
+		return fmt.Sprintf("canopen node %d: sdo abort 0x%08x on object 0x%04x", e.Node, e.Code, e.Object)
+	}
+	return fmt.Sprintf("canopen node %d: drive fault, error code 0x%04x", e.Node, e.Code)
+}
+
+// CANopenOutput maps motor to CiA 402 drive on CAN bus
+type CANopenOutput struct {
+	Motor MotorID
+
+	// Interface is SocketCAN interface like can0, Node is drive node ID
+	Interface string
+	Node      uint8
+
+	// CountsPerDegree converts drive position units to degrees
+	CountsPerDegree float64
+
+	// Velocity runs drive in profile velocity mode, driven with
+	// SetVelocity instead of positions
+	Velocity bool
+}
+
+// Validate checks interface, node ID and scale
+func (o CANopenOutput) Validate() error {
+	if o.Interface == "" {
+		return fmt.Errorf("motor %s: can interface is empty", o.Motor)
+	}
+	if o.Node < 1 || o.Node > 127 {
+		return fmt.Errorf("motor %s: canopen node must be 1..127", o.Motor)
+	}
+	if o.CountsPerDegree <= 0 {
+		return fmt.Errorf("motor %s: counts per degree must be positive", o.Motor)
+	}
+	return nil
+}
+
+// CANopenConfig lists drives of one driver
+type CANopenConfig struct {
+	Outputs []CANopenOutput
+
+	// Open opens CAN interface, OpenCAN if nil
+	Open func(iface string) (CANBus, error)
+}
+
+// CANopenStatus is drive state from last feedback
+type CANopenStatus struct {
+	Statusword uint16 `json:"statusword"`
+	Enabled    bool   `json:"enabled"`
+	Fault      bool   `json:"fault"`
+	Emergency  uint16 `json:"emergency"` // last emergency error code, 0 after reset
+}
+
+// canBus is one interface with receiver dispatching frames to nodes
+type canBus struct {
+	bus   CANBus
+	clock clock.Clock
+	nodes map[uint8]*canNode
+	done  chan struct{}
+	wg    sync.WaitGroup
+
+	mu  sync.Mutex
+	err error // receive failure, feedback stops after it
+}
+
+// canNode is state of one drive
+type canNode struct {
+	CANopenOutput
+	bus *canBus
+
+	sdoMu sync.Mutex
+	sdo   chan CANFrame
+
+	target  int32  // last target, counts
+	profile uint32 // last written profile velocity, counts/second
+
+	mu         sync.Mutex
+	status     uint16
+	position   float64
+	velocity   float64
+	emergency  uint16
+	feedbackAt time.Time
+}
+
+// CANopenDriver drives CiA 402 motor controllers over SocketCAN. Targets
+// go out as RPDOs, position, velocity and statusword come back as TPDOs
+// the driver maps during setup. Implements Driver and PowerDriver.
+type CANopenDriver struct {
+	mu     sync.Mutex
+	clock  clock.Clock
+	buses  map[string]*canBus
+	nodes  map[MotorID]*canNode
+	closed bool
+}
+
+// NewCANopenDriver opens interfaces, maps PDOs of every drive, sets its
+// operating mode, starts it and enables operation
+func NewCANopenDriver(clk clock.Clock, cfg CANopenConfig) (*CANopenDriver, error) {
+	if cfg.Open == nil {
+		cfg.Open = OpenCAN
+	}
+	d := &CANopenDriver{
+		clock: clock.OrReal(clk),
+		buses: make(map[string]*canBus),
+		nodes: make(map[MotorID]*canNode),
+	}
+	if err := d.open(cfg); err != nil {
+		d.Close()
+		return nil, err
+	}
+	return d, nil
+}
+
+func (d *CANopenDriver) open(cfg CANopenConfig) error {
+	for _, o := range cfg.Outputs {
+		if err := o.Validate(); err != nil {
+			return err
+		}
+		if _, dup := d.nodes[o.Motor]; dup {
+			return fmt.Errorf("motor %s: canopen output configured twice", o.Motor)
+		}
+		bus, ok := d.buses[o.Interface]
+		if !ok {
+			b, err := cfg.Open(o.Interface)
+			if err != nil {
+				return &MotorError{Motor: o.Motor, Err: err}
+			}
+			bus = &canBus{bus: b, clock: d.clock, nodes: make(map[uint8]*canNode), done: make(chan struct{})}
+			d.buses[o.Interface] = bus
+		}
+		if other, ok := bus.nodes[o.Node]; ok {
+			return fmt.Errorf("motor %s: canopen node %d on %s taken by %s", o.Motor, o.Node, o.Interface, other.Motor)
+		}
+		n := &canNode{CANopenOutput: o, bus: bus, sdo: make(chan CANFrame, 1)}
+		bus.nodes[o.Node] = n
+		d.nodes[o.Motor] = n
+	}
+
+	// receivers must run before setup, it waits for SDO confirmations
+	for _, b := range d.buses {
+		b.wg.Add(1)
+		go b.receive()
+	}
+	for _, n := range d.nodes {
+		if err := n.setup(); err != nil {
+			return &MotorError{Motor: n.Motor, Err: err}
+		}
+	}
+	return nil
+}
+
+// setup maps PDOs and brings drive to operation enabled
+func (n *canNode) setup() error {
+	if err := n.nmt(nmtPreOp); err != nil {
+		return err
+	}
+	id := uint32(n.Node)
+	target, mode := uint16(objTargetPosition), byte(modeProfilePosition)
+	if n.Velocity {
+		target, mode = objTargetVelocity, modeProfileVelocity
+	}
+	pdos := []struct {
+		comm, mapping uint16
+		cobID         uint32
+		entries       []uint32
+	}{
+		{objRPDOComm, objRPDOMapping, cobRPDO1 + id, []uint32{pdoEntry(objControlword, 16), pdoEntry(target, 32)}},
+		{objTPDOComm, objTPDOMapping, cobTPDO1 + id, []uint32{pdoEntry(objStatusword, 16), pdoEntry(objPositionActual, 32)}},
+		{objTPDOComm + 1, objTPDOMapping + 1, cobTPDO2 + id, []uint32{pdoEntry(objVelocityActual, 32)}},
+	}
+	for _, p := range pdos {
+		if err := n.mapPDO(p.comm, p.mapping, p.cobID, p.entries); err != nil {
+			return err
+		}
+	}
+	if err := n.download(objModeOfOperation, 0, 1, uint32(mode)); err != nil {
+		return err
+	}
+	if err := n.nmt(nmtStart); err != nil {
+		return err
+	}
+	return n.enable()
+}
+
+// mapPDO replaces mapping of PDO, CiA 301 requires it disabled meanwhile.
+// Transmit PDOs are sent on change and every canopenEventTimerMs.
+func (n *canNode) mapPDO(comm, mapping uint16, cobID uint32, entries []uint32) error {
+	type write struct {
+		index uint16
+		sub   uint8
+		size  int
+		value uint32
+	}
+	writes := []write{{comm, 1, 4, cobID | pdoDisabled}, {mapping, 0, 1, 0}}
+	for i, e := range entries {
+		writes = append(writes, write{mapping, uint8(i + 1), 4, e})
+	}
+	writes = append(writes, write{mapping, 0, 1, uint32(len(entries))}, write{comm, 2, 1, pdoAsync})
+	if comm >= objTPDOComm {
+		writes = append(writes, write{comm, 5, 2, canopenEventTimerMs})
+	}
+	writes = append(writes, write{comm, 1, 4, cobID})
+
+	for _, w := range writes {
+		if err := n.download(w.index, w.sub, w.size, w.value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// enable resets fault and walks state machine to operation enabled
+func (n *canNode) enable() error {
+	for _, cw := range []uint32{cwFaultReset, cwShutdown, cwSwitchOn, cwEnableOperation} {
+		if err := n.download(objControlword, 0, 2, cw); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetTarget moves drive to position with speed as profile velocity
+func (d *CANopenDriver) SetTarget(id MotorID, position, speed float64) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	n, err := d.node(id)
+	if err != nil {
+		return err
+	}
+	if n.Velocity {
+		return fmt.Errorf("motor %s: velocity mode drive takes SetVelocity", id)
+	}
+	// zero profile velocity stalls most drives, keep at least one count
+	profile := uint32(max(math.Round(math.Abs(speed)*n.CountsPerDegree), 1))
+	if profile != n.profile {
+		if err := n.download(objProfileVelocity, 0, 4, profile); err != nil {
+			return err
+		}
+		n.profile = profile
+	}
+	n.target = int32(math.Round(position * n.CountsPerDegree))
+
+	// set point is taken on rising edge of new set point bit
+	cw := uint16(cwEnableOperation | cwChangeImmediately)
+	if err := n.rpdo(cw, n.target); err != nil {
+		return err
+	}
+	return n.rpdo(cw|cwNewSetPoint, n.target)
+}
+
+// SetVelocity spins velocity mode drive at speed in degrees/second,
+// negative turns backwards
+func (d *CANopenDriver) SetVelocity(id MotorID, speed float64) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	n, err := d.node(id)
+	if err != nil {
+		return err
+	}
+	if !n.Velocity {
+		return fmt.Errorf("motor %s: position mode drive takes SetTarget", id)
+	}
+	n.target = int32(math.Round(speed * n.CountsPerDegree))
+	return n.rpdo(cwEnableOperation, n.target)
+}
+
+// ReadState returns position and velocity from latest TPDOs
+func (d *CANopenDriver) ReadState(id MotorID) (float64, float64, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	n, err := d.node(id)
+	if err != nil {
+		return 0, 0, err
+	}
+	if err := n.bus.failure(); err != nil {
+		return 0, 0, err
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.status&swFault != 0 {
+		return 0, 0, &CANopenError{Node: n.Node, Code: uint32(n.emergency)}
+	}
+	if n.feedbackAt.IsZero() || d.clock.Since(n.feedbackAt) > canopenFeedbackTimeout {
+		return 0, 0, ErrCANopenNoFeedback
+	}
+	return n.position, n.velocity, nil
+}
+
+// Stop halts drive with its quick deceleration
+func (d *CANopenDriver) Stop(id MotorID) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	n, err := d.node(id)
+	if err != nil {
+		return err
+	}
+	return n.rpdo(cwEnableOperation|cwHalt, n.target)
+}
+
+// SetPower enables operation or switches power stage off
+func (d *CANopenDriver) SetPower(id MotorID, on bool) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	n, err := d.node(id)
+	if err != nil {
+		return err
+	}
+	if on {
+		return n.enable()
+	}
+	return n.download(objControlword, 0, 2, cwShutdown)
+}
+
+// Status returns statusword and emergency code of drive
+func (d *CANopenDriver) Status(id MotorID) (CANopenStatus, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	n, err := d.node(id)
+	if err != nil {
+		return CANopenStatus{}, err
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return CANopenStatus{
+		Statusword: n.status,
+		Enabled:    n.status&swStateMask == swOperationEnabled,
+		Fault:      n.status&swFault != 0,
+		Emergency:  n.emergency,
+	}, nil
+}
+
+// Close switches drives off, stops their PDOs and closes interfaces
+func (d *CANopenDriver) Close() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.closed {
+		return nil
+	}
+	d.closed = true
+
+	var errs []error
+	for _, n := range d.nodes {
+		errs = append(errs, n.rpdo(cwShutdown, n.target), n.nmt(nmtStop))
+	}
+	for _, b := range d.buses {
+		close(b.done)
+		b.wg.Wait()
+		errs = append(errs, b.bus.Close())
+	}
+	return errors.Join(errs...)
+}
+
+func (d *CANopenDriver) node(id MotorID) (*canNode, error) {
+	if d.closed {
+		return nil, errors.New("canopen driver closed")
+	}
+	n, ok := d.nodes[id]
+	if !ok {
+		return nil, &MotorError{Motor: id, Err: ErrMotorNotFound}
+	}
+	return n, nil
+}
+
+// nmt sends network management command to node
+func (n *canNode) nmt(cmd byte) error {
+	return n.bus.bus.Send(CANFrame{ID: cobNMT, Len: 2, Data: [8]byte{cmd, n.Node}})
+}
+
+// rpdo sends controlword and target position or velocity through receive PDO
+func (n *canNode) rpdo(cw uint16, target int32) error {
+	f := CANFrame{ID: cobRPDO1 + uint32(n.Node), Len: 6}
+	binary.LittleEndian.PutUint16(f.Data[0:], cw)
+	binary.LittleEndian.PutUint32(f.Data[2:], uint32(target))
+	return n.bus.bus.Send(f)
+}
+
+// download writes up to four bytes to object dictionary with expedited SDO
+func (n *canNode) download(index uint16, sub uint8, size int, value uint32) error {
+	n.sdoMu.Lock()
+	defer n.sdoMu.Unlock()
+
+	// drop confirmation of timed out transfer
+	select {
+	case <-n.sdo:
+	default:
+	}
+
+	f := CANFrame{ID: cobSDORx + uint32(n.Node), Len: 8}
+	f.Data[0] = 0x23 | byte(4-size)<<2 // expedited download, size indicated
+	binary.LittleEndian.PutUint16(f.Data[1:], index)
+	f.Data[3] = sub
+	binary.LittleEndian.PutUint32(f.Data[4:], value)
+	if err := n.bus.bus.Send(f); err != nil {
+		return err
+	}
+
+	select {
+	case r := <-n.sdo:
+		if binary.LittleEndian.Uint16(r.Data[1:]) != index || r.Data[3] != sub {
+			return fmt.Errorf("canopen node %d: sdo reply for other object", n.Node)
+		}
+		switch r.Data[0] {
+		case sdoResult:
+			return nil
+		case sdoAbort:
+			return &CANopenError{Node: n.Node, Object: index, Code: binary.LittleEndian.Uint32(r.Data[4:])}
+		}
+		return fmt.Errorf("canopen node %d: unexpected sdo reply 0x%02x", n.Node, r.Data[0])
+	case <-n.bus.clock.After(canopenSDOTimeout):
+		return ErrCANopenTimeout
+	}
+}
+
+// receive dispatches frames of bus until closed
+func (b *canBus) receive() {
+	defer b.wg.Done()
+	for {
+		select {
+		case <-b.done:
+			return
+		default:
+		}
+		f, ok, err := b.bus.Receive()
+		if err != nil {
+			b.mu.Lock()
+			b.err = err
+			b.mu.Unlock()
+			return
+		}
+		if !ok {
+			continue
+		}
+		n, ok := b.nodes[uint8(f.ID&0x7F)]
+		if !ok {
+			continue
+		}
+		n.handle(f, b.clock.Now())
+	}
+}
+
+// handle applies frame sent by node
+func (n *canNode) handle(f CANFrame, now time.Time) {
+	if f.ID&^0x7F == cobSDOTx {
+		select {
+		case n.sdo <- f:
+		default:
+		}
+		return
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	switch f.ID &^ 0x7F {
+	case cobTPDO1:
+		n.status = binary.LittleEndian.Uint16(f.Data[0:])
+		n.position = float64(int32(binary.LittleEndian.Uint32(f.Data[2:]))) / n.CountsPerDegree
+		n.feedbackAt = now
+	case cobTPDO2:
+		n.velocity = float64(int32(binary.LittleEndian.Uint32(f.Data[0:]))) / n.CountsPerDegree
+	case cobEMCY:
+		n.emergency = binary.LittleEndian.Uint16(f.Data[0:])
+	}
+}
+
+// failure returns receive error of bus
+func (b *canBus) failure() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.err
+}
+
+// pdoEntry encodes PDO mapping entry of object subindex zero
+func pdoEntry(index uint16, bits uint32) uint32 {
+	return uint32(index)<<16 | bits
+}
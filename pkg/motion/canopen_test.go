@@ -0,0 +1,69 @@
+package motion
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sashalind/sex-artifical-intelligence/pkg/clock"
+)
+
+// silentBus accepts frames but no node ever answers
+type silentBus struct {
+	once   sync.Once
+	closed chan struct{}
+}
+
+func (b *silentBus) Send(CANFrame) error { return nil }
+
+func (b *silentBus) Receive() (CANFrame, bool, error) {
+	select {
+	case <-b.closed:
+	case <-time.After(time.Millisecond):
+	}
+	return CANFrame{}, false, nil
+}
+
+func (b *silentBus) Close() error {
+	b.once.Do(func() { close(b.closed) })
+	return nil
+}
+
+func TestCANopenSDOTimeoutUsesClock(t *testing.T) {
+	clk := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	cfg := CANopenConfig{
+		Outputs: []CANopenOutput{{Motor: "arm", Interface: "can0", Node: 1, CountsPerDegree: 100}},
+		Open: func(string) (CANBus, error) {
+			return &silentBus{closed: make(chan struct{})}, nil
+		},
+	}
+
+	result := make(chan error, 1)
+	go func() {
+		d, err := NewCANopenDriver(clk, cfg)
+		if err == nil {
+			d.Close()
+		}
+		result <- err
+	}()
+
+	// setup waits for SDO confirmation on fake clock
+	waitFor(t, func() bool { return clk.Waiters() > 0 })
+	clk.Advance(canopenSDOTimeout - time.Millisecond)
+	select {
+	case err := <-result:
+		t.Fatalf("setup finished before SDO timeout: %v", err)
+	default:
+	}
+
+	clk.Advance(time.Millisecond)
+	select {
+	case err := <-result:
+		if !errors.Is(err, ErrCANopenTimeout) {
+			t.Fatalf("NewCANopenDriver = %v, want ErrCANopenTimeout", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("setup did not time out")
+	}
+}
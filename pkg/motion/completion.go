@@ -0,0 +1,147 @@
+package motion
+
+import (
+	"context"
+	"time"
+
+	"github.com/sashalind/sex-artifical-intelligence/pkg/core/errs"
+)
+
+var (
+	ErrMoveInterrupted = errs.New(errs.Cancelled, "move interrupted before reaching target")
+	ErrMoveTimeout     = errs.New(errs.DeadlineExceeded, "motor did not reach target in time")
+)
+
+// tracked move completes once motor is within reachTolerance degrees of
+// target and slower than settleSpeed degrees/second; hardware feedback
+// rarely lands exactly on target
+const (
+	reachTolerance = 0.5
+	settleSpeed    = 1.0
+)
+
+// Completion follows motor towards commanded target until it arrives, is
+// sent elsewhere or runs out of time
+type Completion struct {
+	Motor  MotorID
+	Target float64
+
+	deadline time.Time
+	done     chan struct{}
+	err      error
+}
+
+// Done is closed once outcome of move is known
+func (m *Completion) Done() <-chan struct{} {
+	return m.done
+}
+
+// Err is nil when motor reached target. Otherwise ErrMoveInterrupted if
+// anything retargeted or stopped motor, ErrMoveTimeout or reason motor is
+// gone. Valid after Done.
+func (m *Completion) Err() error {
+	return m.err
+}
+
+// Wait blocks until outcome of move is known or ctx ends
+func (m *Completion) Wait(ctx context.Context) error {
+	select {
+	case <-m.done:
+		return m.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// ExecuteTracked is ExecuteCommand that also follows move, see Track
+func (c *Controller) ExecuteTracked(ctx context.Context, cmd MotorCommand, timeout time.Duration) (*Completion, error) {
+	if err := c.ExecuteCommand(ctx, cmd); err != nil {
+		return nil, err
+	}
+	return c.Track(cmd.ID, cmd.Position, timeout)
+}
+
+// Track follows motor after command sent it to target, e.g. by group or
+// pattern. Command or stop reaching motor meanwhile interrupts move, motor
+// already at target completes at once. Outcome is checked every control
+// tick; zero timeout waits as long as move takes. Timeout only ends
+// waiting, motor keeps going.
+func (c *Controller) Track(id MotorID, target float64, timeout time.Duration) (*Completion, error) {
+	c.mu.RLock()
+	_, exists := c.motors[id]
+	c.mu.RUnlock()
+	if !exists {
+		return nil, &MotorError{Motor: id, Err: ErrMotorNotFound}
+	}
+
+	m := &Completion{Motor: id, Target: target, done: make(chan struct{})}
+	if timeout > 0 {
+		m.deadline = c.clock.Now().Add(timeout)
+	}
+
+	c.trackMu.Lock()
+	defer c.trackMu.Unlock()
+	// checked under trackMu so Shutdown can't miss completion
+	if !c.running.Load() {
+		return nil, ErrShutdown
+	}
+	if c.tracked == nil {
+		c.tracked = make(map[*Completion]struct{})
+	}
+	c.tracked[m] = struct{}{}
+	return m, nil
+}
+
+// checkCompletions resolves tracked moves, caller holds mu
+func (c *Controller) checkCompletions() {
+	c.trackMu.Lock()
+	defer c.trackMu.Unlock()
+	if len(c.tracked) == 0 {
+		return
+	}
+
+	now := c.clock.Now()
+	for m := range c.tracked {
+		slot, exists := c.motors[m.Motor]
+		if !exists {
+			m.finish(&MotorError{Motor: m.Motor, Err: ErrMotorNotFound})
+			delete(c.tracked, m)
+			continue
+		}
+
+		slot.mu.Lock()
+		var err error
+		switch {
+		case slot.Target != m.Target:
+			err = ErrMoveInterrupted
+		case slot.Frame.Degrees(slot.Position-m.Target) <= reachTolerance && slot.Frame.Degrees(slot.Speed) < settleSpeed:
+		case !m.deadline.IsZero() && !now.Before(m.deadline):
+			err = ErrMoveTimeout
+		default:
+			slot.mu.Unlock()
+			continue
+		}
+		slot.mu.Unlock()
+
+		if err != nil {
+			err = &MotorError{Motor: m.Motor, Err: err}
+		}
+		m.finish(err)
+		delete(c.tracked, m)
+	}
+}
+
+// failTracked resolves every tracked move with err
+func (c *Controller) failTracked(err error) {
+	c.trackMu.Lock()
+	defer c.trackMu.Unlock()
+	for m := range c.tracked {
+		m.finish(&MotorError{Motor: m.Motor, Err: err})
+	}
+	c.tracked = nil
+}
+
+func (m *Completion) finish(err error) {
+	m.err = err
+	close(m.done)
+}
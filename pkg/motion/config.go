@@ -0,0 +1,137 @@
+package motion
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Config describes motor layout of particular hardware build
+type Config struct {
+	Motors []Motor
+	Groups []MotorGroup
+}
+
+// DefaultConfig returns two-servo layout of reference build
+func DefaultConfig() Config {
+	return Config{
+		Motors: []Motor{
+			{
+				ID:              "servo_1",
+				Type:            MotorServo,
+				MaxSpeed:        180.0,
+				MinPosition:     0.0,
+				MaxPosition:     180.0,
+				IsEnabled:       true,
+				MaxAcceleration: 720.0,
+				MaxJerk:         14400.0,
+			},
+			{
+				ID:              "servo_2",
+				Type:            MotorServo,
+				MaxSpeed:        180.0,
+				MinPosition:     0.0,
+				MaxPosition:     180.0,
+				IsEnabled:       true,
+				MaxAcceleration: 720.0,
+				MaxJerk:         14400.0,
+			},
+		},
+	}
+}
+
+// Validate checks motor layout for obvious mistakes
+func (c Config) Validate() error {
+	if len(c.Motors) == 0 {
+		return errors.New("no motors configured")
+	}
+
+	seen := make(map[MotorID]bool)
+	for _, m := range c.Motors {
+		if err := ValidateMotor(m); err != nil {
+			return err
+		}
+		if seen[m.ID] {
+			return fmt.Errorf("duplicate motor id %q", m.ID)
+		}
+		seen[m.ID] = true
+	}
+
+	groups := make(map[string]bool)
+	for _, g := range c.Groups {
+		if err := g.validate(seen); err != nil {
+			return err
+		}
+		if groups[g.Name] {
+			return fmt.Errorf("duplicate motor group %q", g.Name)
+		}
+		groups[g.Name] = true
+	}
+	return nil
+}
+
+// ValidateMotor checks single motor definition
+func ValidateMotor(m Motor) error {
+	if m.ID == "" {
+		return errors.New("motor id is empty")
+	}
+	if m.MinPosition >= m.MaxPosition {
+		return fmt.Errorf("motor %s: min position must be below max position", m.ID)
+	}
+	if m.MaxSpeed <= 0 {
+		return fmt.Errorf("motor %s: max speed must be positive", m.ID)
+	}
+	if m.Position < m.MinPosition || m.Position > m.MaxPosition {
+		return fmt.Errorf("motor %s: initial position out of range", m.ID)
+	}
+	if m.MaxAcceleration < 0 || m.MaxJerk < 0 {
+		return fmt.Errorf("motor %s: acceleration and jerk limits must not be negative", m.ID)
+	}
+	if m.MaxJerk > 0 && m.MaxAcceleration == 0 {
+		return fmt.Errorf("motor %s: jerk limit needs acceleration limit", m.ID)
+	}
+	if m.SoftMinPosition < m.SoftMaxPosition && (m.SoftMinPosition < m.MinPosition || m.SoftMaxPosition > m.MaxPosition) {
+		return fmt.Errorf("motor %s: soft limits outside position range", m.ID)
+	}
+	if err := m.Homing.validate(m); err != nil {
+		return err
+	}
+	if err := m.Protection.validate(m); err != nil {
+		return err
+	}
+	if err := m.Thermal.validate(m); err != nil {
+		return err
+	}
+	if err := m.Stop.validate(m); err != nil {
+		return err
+	}
+	if err := m.Frame.validate(m); err != nil {
+		return err
+	}
+	return nil
+}
+
+// ParseMotorType converts config name to motor type
+func ParseMotorType(name string) (MotorType, error) {
+	switch name {
+	case "servo", "":
+		return MotorServo, nil
+	case "stepper":
+		return MotorStepper, nil
+	case "dc":
+		return MotorDC, nil
+	}
+	return 0, fmt.Errorf("unknown motor type %q", name)
+}
+
+// String returns config name of motor type
+func (t MotorType) String() string {
+	switch t {
+	case MotorServo:
+		return "servo"
+	case MotorStepper:
+		return "stepper"
+	case MotorDC:
+		return "dc"
+	}
+	return fmt.Sprintf("motor(%d)", int(t))
+}
@@ -1,10 +1,20 @@
 package motion
 
 import (
+	"context"
 	"errors"
-	"math"
+	"fmt"
+	"log"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
+
+	"github.com/sashalind/sex-artifical-intelligence/pkg/clock"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/core/errs"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/core/event"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/health"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/storage"
 )
 
 // MotorID represents unique identifier for each motor
@@ -23,26 +33,156 @@ const (
 type Motor struct {
 	ID          MotorID
 	Type        MotorType
-	Position    float64  // current position in degrees
+	Position    float64  // current position in degrees, or unit of Frame
 	Speed       float64  // current speed in degrees/second
 	MaxSpeed    float64  // maximum allowed speed
 	MinPosition float64  // minimum allowed position
 	MaxPosition float64  // maximum allowed position
+	Target      float64  // commanded position motor is moving towards
 	IsEnabled   bool
+	
+	// trajectory limits of logical motors in degrees/second² and
+	// degrees/second³, zero is unlimited; hardware drivers run their own
+	// profiles
+	MaxAcceleration float64
+	MaxJerk         float64
+	
+	// soft limits from calibration, commands must stay within them;
+	// equal values mean none and hard limits apply
+	SoftMinPosition float64
+	SoftMaxPosition float64
+	
+	// Homing finds reference position of motor, see Home
+	Homing Homing
+	
+	// Protection reacts to stalls and overloads, see protect
+	Protection Protection
+	
+	// Thermal limits duty cycle of motor, see heatUp
+	Thermal Thermal
+	
+	// Stop shapes how StopAll stops motor
+	Stop StopProfile
+	
+	// Frame maps positions in motor unit to shaft degrees of driver
+	Frame Frame
+}
+
+// TopicMotorState carries motor state whenever it is commanded, stopped,
+// or starts/finishes moving
+var TopicMotorState = event.NewTopic[Motor]("motor.state")
+
+// motorSlot guards single motor so ticks, commands and readers of different
+// motors never wait on each other
+type motorSlot struct {
+	mu sync.Mutex
+	Motor
+	
+	// trajectory state of logical motor: commanded speed and current
+	// acceleration
+	cruise float64
+	accel  float64
+	
+	// calibration: offset is hardware position of calibrated zero, homing
+	// is set while Home drives motor and refuses commands
+	offset  float64
+	homedAt time.Time
+	homing  bool
+	
+	// last failed hardware read, nil once motor reads again
+	readErr error
+	
+	// stall and overload detection
+	guard protection
+	
+	// thermal model; tempAt is last temperature read, control loop only
+	heat   heat
+	tempAt time.Time
+	
+	// manual jog in progress, see Jog
+	jog jog
+	
+	// controlled stop in progress, see beginStop
+	stop stopping
 }
 
 // Controller manages all motion systems
 type Controller struct {
+	// mu guards motor set and driver; per-motor state has its own lock
 	mu      sync.RWMutex
-	motors  map[MotorID]*Motor
-	running bool
+	motors  map[MotorID]*motorSlot
+	order   []*motorSlot // sorted by ID for stable iteration
+	groups  map[string]MotorGroup
+	
+	// persistent motor calibrations, nil without store
+	calibLib *storage.Table[Calibration]
+	running atomic.Bool
+	
+	// Movement patterns, separate lock so pattern edits don't stall ticks
+	patternMu sync.RWMutex
+	patterns   map[string]MovementPattern
+	library    *storage.Table[MovementPattern]
+	programs   map[string]PatternProgram
+	programLib *storage.Table[PatternProgram]
+	
+	// patterns and programs playing, copy on write under arbMu, and those
+	// waiting for motors; waveforms of playing ones are evaluated every
+	// tick, see arbiter.go
+	arbMu       sync.Mutex
+	arbitration Arbitration
+	runs        atomic.Pointer[[]*patternRun]
+	queued      []*patternRun
+	
+	// recording in progress, nil when not recording
+	recMu sync.Mutex
+	rec   *recording
+	
+	// moves awaited through Track, resolved every tick
+	trackMu sync.Mutex
+	tracked map[*Completion]struct{}
+	
+	// live telemetry streams, fed every tick
+	telemMu   sync.Mutex
+	telemetry map[*Telemetry]struct{}
+	
+	// hardware output, nil means motors are purely logical
+	driver Driver
+	
+	clock clock.Clock
+	bus   atomic.Pointer[event.Bus]
+	
+	// supervision: loopRunning is false after control loop died, onFailure
+	// is told why
+	loopRunning    atomic.Bool
+	onFailure      atomic.Pointer[func(error)]
+	driverFailures int // consecutive ticks where every driver read failed
+	
+	// health: most recent error and motors driver failed to read last tick
+	lastErr    health.LastError
+	unreadable atomic.Int64
 	
-	// Movement patterns
-	patterns map[string]MovementPattern
+	// held freezes motors in place, commands are refused until Release
+	held atomic.Bool
+	
+	// speed cap below motor maximums as float64 bits, see SetSpeedLimit
+	speedLimit atomic.Uint64
+	
+	// watchdog: timeout in nanoseconds, zero disabled, and unix nanos of
+	// last tick, see SetWatchdog
+	watchdog atomic.Int64
+	lastTick atomic.Int64
+	
+	// enabled flags saved by PowerDown, nil while powered, guarded by mu
+	poweredDown map[MotorID]bool
 	
 	// Control channels
-	controlChan chan MotorCommand
+	controlChan chan commandRequest
+	groupChan   chan groupRequest
 	done        chan struct{}
+	
+	// manualTick loops tick only when StepTick asks through tickChan
+	manualTick bool
+	tickChan   chan chan error
 }
 
 // MotorCommand represents command for motor
@@ -52,172 +192,706 @@ type MotorCommand struct {
 	Speed    float64
 }
 
-// MovementPattern represents predefined movement sequence
+// MovementPattern represents predefined movement sequence. Commands are
+// spread evenly over Duration unless Offsets times each of them; Waveforms
+// drive their motors continuously for Duration, or until stopped when it
+// is zero.
 type MovementPattern struct {
-	Name     string
-	Commands []MotorCommand
-	Duration time.Duration
+	Name      string
+	Commands  []MotorCommand
+	Duration  time.Duration
+	Waveforms []Waveform
+	
+	// Offsets from pattern start, one per command, e.g. recorded timing
+	Offsets []time.Duration
+}
+
+// TickInterval is period of motor state updates
+const TickInterval = 10 * time.Millisecond
+
+// driverFailureLimit is how many ticks in a row driver may fail every read
+// before control loop gives up and reports failure
+const driverFailureLimit = 100
+
+var (
+	ErrShutdown           = errs.New(errs.Unavailable, "motion controller is shut down")
+	ErrLoopStopped        = errs.New(errs.Unavailable, "motion control loop is not running")
+	ErrMotorNotFound      = errs.New(errs.NotFound, "motor not found")
+	ErrMotorDisabled      = errs.New(errs.FailedPrecondition, "motor is disabled")
+	ErrPositionOutOfRange = errs.New(errs.OutOfRange, "position out of range")
+	ErrPatternNotFound    = errs.New(errs.NotFound, "pattern not found")
+	ErrRestartRequired    = errs.New(errs.FailedPrecondition, "motor layout change requires restart")
+	ErrHeld               = errs.New(errs.FailedPrecondition, "motion is on hold")
+)
+
+// MotorError tells which motor operation failed on, use errors.As to get it
+// and errors.Is to check underlying reason
+type MotorError struct {
+	Motor MotorID
+	Err   error
 }
 
+func (e *MotorError) Error() string { return "motor " + string(e.Motor) + ": " + e.Err.Error() }
+func (e *MotorError) Unwrap() error { return e.Err }
+
 // NewController initializes motion control system
 func NewController() (*Controller, error) {
+	return NewControllerWithClock(clock.Real)
+}
+
+// NewControllerWithClock initializes motion control system driven by given clock
+func NewControllerWithClock(clk clock.Clock) (*Controller, error) {
+	return NewControllerWithConfig(clk, DefaultConfig())
+}
+
+// NewControllerWithConfig initializes motion control system with given motor layout
+func NewControllerWithConfig(clk clock.Clock, cfg Config) (*Controller, error) {
+	return newController(clk, cfg, false)
+}
+
+// newController builds controller and starts its loop, manual one ticks
+// only on request
+func newController(clk clock.Clock, cfg Config, manualTick bool) (*Controller, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	
 	c := &Controller{
-		motors:      make(map[MotorID]*Motor),
+		clock:       clock.OrReal(clk),
+		motors:      make(map[MotorID]*motorSlot),
+		groups:      make(map[string]MotorGroup),
 		patterns:    make(map[string]MovementPattern),
-		controlChan: make(chan MotorCommand, 100),
+		programs:    make(map[string]PatternProgram),
+		controlChan: make(chan commandRequest, 100),
+		groupChan:   make(chan groupRequest),
 		done:        make(chan struct{}),
-		running:     true,
-	}
-	
-	// Initialize default motors
-	defaultMotors := []Motor{
-		{
-			ID:          "servo_1",
-			Type:        MotorServo,
-			MaxSpeed:    180.0,
-			MinPosition: 0.0,
-			MaxPosition: 180.0,
-			IsEnabled:   true,
-		},
-		{
-			ID:          "servo_2",
-			Type:        MotorServo,
-			MaxSpeed:    180.0,
-			MinPosition: 0.0,
-			MaxPosition: 180.0,
-			IsEnabled:   true,
-		},
-		// Add more motors as needed
-	}
-	
-	for _, m := range defaultMotors {
-		motor := m // Create new variable to avoid pointer issues
-		c.motors[motor.ID] = &motor
+		arbitration: Arbitration{}.withDefaults(),
+		manualTick:  manualTick,
+		tickChan:    make(chan chan error),
+	}
+	c.running.Store(true)
+	c.watchdog.Store(int64(defaultWatchdogTimeout))
+	c.lastTick.Store(c.clock.Now().UnixNano())
+	
+	for _, m := range cfg.Motors {
+		c.addSlot(m)
+	}
+	for _, g := range cfg.Groups {
+		c.groups[g.Name] = g
 	}
 	
+	c.loopRunning.Store(true)
 	go c.processCommands()
+	go c.watchTicks()
 	
 	return c, nil
 }
 
-// processCommands handles incoming motor commands
+// addSlot registers motor keeping iteration order sorted, caller holds mu
+func (c *Controller) addSlot(m Motor) {
+	m.Target = m.Position
+	slot := &motorSlot{Motor: m}
+	c.motors[m.ID] = slot
+	c.order = append(c.order, slot)
+	sort.Slice(c.order, func(i, j int) bool { return c.order[i].ID < c.order[j].ID })
+}
+
+// processCommands runs control loop and reports abnormal exit
 func (c *Controller) processCommands() {
-	ticker := time.NewTicker(10 * time.Millisecond)
-	defer ticker.Stop()
+	err := c.controlLoop()
+	c.loopRunning.Store(false)
+	c.drainCommands()
+	if err != nil {
+		c.lastErr.Set(err)
+		log.Printf("Motion control loop failed: %v", err)
+		if fn := c.onFailure.Load(); fn != nil {
+			(*fn)(err)
+		}
+	}
+}
+
+// controlLoop handles incoming motor commands until shutdown or failure
+func (c *Controller) controlLoop() (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("motion loop panic: %v", r)
+		}
+	}()
+	
+	var ticks <-chan time.Time
+	if !c.manualTick {
+		ticker := c.clock.NewTicker(TickInterval)
+		defer ticker.Stop()
+		ticks = ticker.C()
+	}
 	
 	for {
 		select {
-		case cmd := <-c.controlChan:
-			c.executeCommand(cmd)
+		case req := <-c.controlChan:
+			// commands accepted just before Hold must not move frozen motors
+			if c.held.Load() {
+				req.result <- ErrHeld
+				continue
+			}
+			err := c.executeCommand(req.cmd, req.jog)
+			c.lastErr.Set(err)
+			if err == nil && !req.jog {
+				c.record(req.cmd)
+			}
+			req.result <- err
+		case req := <-c.groupChan:
+			if c.held.Load() {
+				req.result <- ErrHeld
+				continue
+			}
+			err := c.executeGroup(req.cmds)
+			c.lastErr.Set(err)
+			if err == nil {
+				c.record(req.cmds...)
+			}
+			req.result <- err
 		case <-c.done:
+			return nil
+		case <-ticks:
+			if err := c.Tick(); err != nil {
+				return err
+			}
+		case result := <-c.tickChan:
+			err := c.Tick()
+			result <- err
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// drainCommands fails commands left in queue by stopped loop, their
+// callers would wait for Restart otherwise
+func (c *Controller) drainCommands() {
+	for {
+		select {
+		case req := <-c.controlChan:
+			req.result <- ErrLoopStopped
+		default:
 			return
-		case <-ticker.C:
-			c.updateMotorStates()
 		}
 	}
 }
 
-// executeCommand processes single motor command
-func (c *Controller) executeCommand(cmd MotorCommand) error {
+// OnFailure sets function called when control loop dies. Controller stays
+// usable for reads and StopAll; Restart brings loop back.
+func (c *Controller) OnFailure(fn func(error)) {
+	c.onFailure.Store(&fn)
+}
+
+// Restart relaunches control loop after failure
+func (c *Controller) Restart() error {
+	if !c.running.Load() {
+		return ErrShutdown
+	}
+	if !c.loopRunning.CompareAndSwap(false, true) {
+		return errors.New("motion control loop already running")
+	}
+	
 	c.mu.Lock()
-	defer c.mu.Unlock()
+	c.driverFailures = 0
+	c.lastTick.Store(c.clock.Now().UnixNano())
+	c.mu.Unlock()
+	
+	go c.processCommands()
+	return nil
+}
+
+// Healthy reports whether control loop is running
+func (c *Controller) Healthy() bool {
+	return c.loopRunning.Load()
+}
+
+// executeCommand processes single motor command or jog, runs in control
+// loop
+func (c *Controller) executeCommand(cmd MotorCommand, jogging bool) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
 	
 	motor, exists := c.motors[cmd.ID]
 	if !exists {
-		return errors.New("motor not found")
+		return &MotorError{Motor: cmd.ID, Err: ErrMotorNotFound}
 	}
 	
-	if !motor.IsEnabled {
-		return errors.New("motor is disabled")
-	}
+	motor.mu.Lock()
+	defer motor.mu.Unlock()
 	
-	// Validate position
-	if cmd.Position < motor.MinPosition || cmd.Position > motor.MaxPosition {
-		return errors.New("position out of range")
+	if jogging {
+		cmd = motor.jogCommand(cmd)
+	}
+	if err := checkCommand(motor, cmd); err != nil {
+		return &MotorError{Motor: cmd.ID, Err: err}
 	}
 	
 	// Validate speed
-	speed := math.Abs(cmd.Speed)
-	if speed > motor.MaxSpeed {
-		speed = motor.MaxSpeed
+	speed := c.motorSpeed(motor, cmd.Speed)
+	motor.jog = jog{}
+	if jogging {
+		motor.jog = jog{until: c.clock.Now().Add(JogTimeout), target: cmd.Position, speed: speed}
+	}
+	
+	if c.driver != nil {
+		if err := c.driveTo(motor, cmd.Position, speed); err != nil {
+			return err
+		}
+		motor.Target = cmd.Position
+		motor.cruise = speed
+		c.publish(motor.Motor)
+		return nil
+	}
+	
+	// Tick moves motor there along trajectory
+	motor.Target = cmd.Position
+	motor.cruise = speed
+	c.publish(motor.Motor)
+	
+	return nil
+}
+
+// publish announces motor state on event bus if one is attached
+func (c *Controller) publish(m Motor) {
+	event.Publish(c.bus.Load(), TopicMotorState, m)
+}
+
+// AttachBus starts publishing motor state changes to event bus
+func (c *Controller) AttachBus(bus *event.Bus) {
+	c.bus.Store(bus)
+}
+
+// Tick advances all motors by one control period. Normally driven by the
+// internal ticker; exported so simulations and benchmarks can step manually.
+// Hot path: iterates preallocated slot slice and does not allocate.
+// Returns error once hardware driver has been unreadable for too long.
+func (c *Controller) Tick() error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	
+	c.petWatchdog()
+	c.tickWaves()
+	defer c.publishTelemetry()
+	defer c.checkCompletions()
+	defer c.sampleRecording()
+	// after motors were read, jogs stop where motors really are
+	defer c.expireJogs()
+	if c.driver != nil {
+		return c.readDriverStates()
+	}
+	
+	for _, motor := range c.order {
+		motor.mu.Lock()
+		wasMoving := motor.Speed != 0
+		if !c.rampDown(motor) {
+			motor.advance()
+		}
+		if (motor.Speed != 0) != wasMoving {
+			c.publish(motor.Motor)
+		}
+		c.heatUp(motor, motor.duty(0, false), 0, false)
+		motor.mu.Unlock()
+	}
+	return nil
+}
+
+// ExecuteCommand runs motor command on next controller cycle and returns
+// its result, e.g. ErrPositionOutOfRange or ErrMotorDisabled wrapped in
+// MotorError. Success means motor set off towards target, not that it
+// arrived. Gives up if ctx ends first; command already queued may still
+// run.
+func (c *Controller) ExecuteCommand(ctx context.Context, cmd MotorCommand) error {
+	if !c.running.Load() {
+		return ErrShutdown
+	}
+	if !c.loopRunning.Load() {
+		return ErrLoopStopped
+	}
+	if c.held.Load() {
+		return ErrHeld
+	}
+	
+	// reject early without waiting for loop, loop checks again under lock
+	c.mu.RLock()
+	err := c.validateCommand(cmd)
+	c.mu.RUnlock()
+	if err != nil {
+		return err
 	}
 	
-	motor.Position = cmd.Position
-	motor.Speed = speed
+	req := commandRequest{cmd: cmd, result: make(chan error, 1)}
+	select {
+	case c.controlChan <- req:
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-c.done:
+		return ErrShutdown
+	}
+	select {
+	case err := <-req.result:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-c.done:
+		return ErrShutdown
+	}
+}
+
+// readDriverStates refreshes motor states from hardware feedback. Only
+// control loop calls it with driver attached, so driverFailures needs no lock.
+func (c *Controller) readDriverStates() error {
+	var lastErr error
+	failed := 0
+	loads, _ := c.driver.(LoadDriver)
+	temps, _ := c.driver.(TemperatureDriver)
+	now := c.clock.Now()
+	for _, motor := range c.order {
+		// hardware read happens outside motor lock, bus latency must not block readers
+		position, speed, err := c.driver.ReadState(motor.ID)
+		if err != nil {
+			lastErr = err
+			failed++
+			motor.mu.Lock()
+			motor.readErr = err
+			motor.mu.Unlock()
+			continue
+		}
+		// config changes hold mu exclusively, Protection and Thermal are
+		// stable here
+		load, hasLoad := 0.0, false
+		if loads != nil && (motor.Protection.MaxLoad > 0 || motor.Thermal.HeatTime > 0) {
+			if load, err = loads.ReadLoad(motor.ID); err == nil {
+				hasLoad = true
+			} else {
+				lastErr = err
+			}
+		}
+		temp, hasTemp := 0.0, false
+		if temps != nil && motor.Thermal.MaxTemp > 0 && now.Sub(motor.tempAt) >= temperatureInterval {
+			motor.tempAt = now
+			if temp, err = temps.ReadTemperature(motor.ID); err == nil {
+				hasTemp = true
+			} else if !errors.Is(err, ErrNoTemperature) {
+				lastErr = err
+			}
+		}
+		motor.mu.Lock()
+		motor.readErr = nil
+		wasMoving := motor.Speed != 0
+		motor.Position = motor.fromShaft(position)
+		motor.Speed = speed / motor.Frame.scale()
+		if (speed != 0) != wasMoving {
+			c.publish(motor.Motor)
+		}
+		c.rampDown(motor)
+		c.protect(motor, load, hasLoad)
+		c.heatUp(motor, motor.duty(load, hasLoad), temp, hasTemp)
+		motor.mu.Unlock()
+	}
+	c.unreadable.Store(int64(failed))
+	c.lastErr.Set(lastErr)
 	
+	if len(c.order) == 0 || failed < len(c.order) {
+		c.driverFailures = 0
+		return nil
+	}
+	c.driverFailures++
+	if c.driverFailures >= driverFailureLimit {
+		return fmt.Errorf("motor driver unreadable for %d ticks: %w", c.driverFailures, lastErr)
+	}
 	return nil
 }
 
-// updateMotorStates updates all motor positions based on current speeds
-func (c *Controller) updateMotorStates() {
+// SetDriver attaches hardware driver, current positions become initial targets
+func (c *Controller) SetDriver(d Driver) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	
+	c.driver = d
+	for _, motor := range c.order {
+		motor.mu.Lock()
+		motor.Target = motor.Position
+		motor.mu.Unlock()
+	}
+	if err := c.armWatchdog(); err != nil {
+		log.Printf("Failed to arm driver watchdog: %v", err)
+	}
+}
+
+// Config returns current motor layout and limits
+func (c *Controller) Config() Config {
+	return Config{Motors: c.GetMotors(), Groups: c.GetGroups()}
+}
+
+// ApplyConfig changes limits and enabled flags of running motors, adds
+// and removes motors and replaces motor groups. Motor types can't change
+// at runtime. Either whole config applies or none of it: any motor whose
+// current position falls outside new limits fails whole call, as does
+// driver refusing new motor. Calibration of kept motors is kept.
+func (c *Controller) ApplyConfig(cfg Config) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	
-	for _, motor := range c.motors {
-		if !motor.IsEnabled {
+	wanted := make(map[MotorID]Motor, len(cfg.Motors))
+	var added []Motor
+	for _, m := range cfg.Motors {
+		wanted[m.ID] = m
+		slot, exists := c.motors[m.ID]
+		if !exists {
+			added = append(added, m)
 			continue
 		}
-		
-		// Update position based on speed
-		// This is simplified; real implementation would be more complex
-		delta := motor.Speed * 0.01 // 10ms tick
-		newPos := motor.Position + delta
-		
-		// Ensure position stays within bounds
-		if newPos < motor.MinPosition {
-			newPos = motor.MinPosition
-			motor.Speed = 0
-		} else if newPos > motor.MaxPosition {
-			newPos = motor.MaxPosition
-			motor.Speed = 0
+		if slot.Type != m.Type {
+			return &MotorError{Motor: m.ID, Err: ErrRestartRequired}
+		}
+	}
+	
+	// control loop and readers are excluded by c.mu, slot locks are still
+	// taken because Motor fields are guarded by them
+	kept := c.order
+	for _, slot := range kept {
+		slot.mu.Lock()
+		defer slot.mu.Unlock()
+	}
+	for _, slot := range kept {
+		m, ok := wanted[slot.ID]
+		if !ok && slot.homing {
+			return &MotorError{Motor: slot.ID, Err: ErrHoming}
+		}
+		if ok {
+			if err := slot.fits(m); err != nil {
+				return &MotorError{Motor: slot.ID, Err: err}
+			}
+		}
+	}
+	
+	for i, m := range added {
+		if err := c.attachMotor(m, nil); err != nil {
+			for _, undo := range added[:i] {
+				c.detachMotor(undo.ID)
+			}
+			return &MotorError{Motor: m.ID, Err: err}
+		}
+	}
+	for _, slot := range kept {
+		if m, ok := wanted[slot.ID]; ok {
+			slot.reconfigure(m)
+			continue
 		}
-		
-		motor.Position = newPos
+		slot.halt()
+		c.detachMotor(slot.ID)
 	}
+	
+	c.groups = make(map[string]MotorGroup, len(cfg.Groups))
+	for _, g := range cfg.Groups {
+		c.groups[g.Name] = g
+	}
+	return nil
 }
 
 // AddPattern adds new movement pattern
 func (c *Controller) AddPattern(pattern MovementPattern) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
+	c.patternMu.Lock()
+	defer c.patternMu.Unlock()
 	c.patterns[pattern.Name] = pattern
+	
+	if c.library != nil {
+		if err := c.library.Put(pattern.Name, pattern); err != nil {
+			log.Printf("Failed to persist pattern %s: %v", pattern.Name, err)
+		}
+	}
 }
 
-// ExecutePattern runs predefined movement pattern
-func (c *Controller) ExecutePattern(name string) error {
-	c.mu.RLock()
-	pattern, exists := c.patterns[name]
-	c.mu.RUnlock()
+// AttachStore enables persistent pattern and program library and motor
+// calibrations and loads saved entries
+func (c *Controller) AttachStore(store *storage.Store) error {
+	library, err := storage.OpenTable[MovementPattern](store, storage.BucketPatterns)
+	if err != nil {
+		return err
+	}
+	programLib, err := storage.OpenTable[PatternProgram](store, storage.BucketPrograms)
+	if err != nil {
+		return err
+	}
+	calibLib, err := storage.OpenTable[Calibration](store, storage.BucketCalibration)
+	if err != nil {
+		return err
+	}
+	if err := c.loadCalibrations(calibLib.Bucket()); err != nil {
+		log.Printf("Some motor calibrations could not be loaded: %v", err)
+	}
+	c.mu.Lock()
+	c.calibLib = calibLib
+	c.mu.Unlock()
 	
-	if !exists {
-		return errors.New("pattern not found")
+	saved, err := library.All()
+	if err != nil {
+		return err
+	}
+	savedPrograms, err := programLib.All()
+	if err != nil {
+		return err
+	}
+	
+	c.patternMu.Lock()
+	defer c.patternMu.Unlock()
+	
+	c.library = library
+	for _, pattern := range saved {
+		if _, exists := c.patterns[pattern.Name]; !exists {
+			c.patterns[pattern.Name] = pattern
+		}
+	}
+	c.programLib = programLib
+	for _, program := range savedPrograms {
+		if _, exists := c.programs[program.Name]; !exists {
+			c.programs[program.Name] = program
+		}
+	}
+	return nil
+}
+
+// ExecutePattern runs predefined movement pattern or pattern program in
+// background. Patterns on other motors keep playing, conflicts over motors
+// are settled by Arbitration: by default pattern sharing motor with new
+// one stops. Cancelling ctx aborts remaining steps, so pass long-lived
+// context for fire-and-forget.
+func (c *Controller) ExecutePattern(ctx context.Context, name string) error {
+	tl, end, err := c.compile(name)
+	if err != nil {
+		return err
 	}
+	return c.startRun(ctx, name, tl, end)
+}
+
+// StopAll halts every motor at its current position, ending running
+// patterns. Motors with decel stop profile ramp down within their stop
+// time, others stop immediately.
+func (c *Controller) StopAll() {
+	c.stopRuns()
+	
+	c.mu.RLock()
+	defer c.mu.RUnlock()
 	
-	go func() {
-		for _, cmd := range pattern.Commands {
-			if !c.running {
-				return
+	for _, motor := range c.order {
+		motor.mu.Lock()
+		cut := c.beginStop(motor)
+		c.publish(motor.Motor)
+		motor.mu.Unlock()
+		if cut && c.driver != nil {
+			if err := c.driver.Stop(motor.ID); err != nil {
+				log.Printf("Failed to stop motor %s: %v", motor.ID, err)
 			}
-			c.controlChan <- cmd
-			time.Sleep(pattern.Duration / time.Duration(len(pattern.Commands)))
 		}
-	}()
+	}
+}
+
+// Hold freezes every motor at its current position and refuses commands,
+// running patterns abort. Motors stay stopped after Release.
+func (c *Controller) Hold() {
+	c.held.Store(true)
+	c.StopAll()
+}
+
+// Release lifts Hold
+func (c *Controller) Release() {
+	c.held.Store(false)
+}
+
+// Held reports whether motion is on hold
+func (c *Controller) Held() bool {
+	return c.held.Load()
+}
+
+// GetMotors returns copy of current motor states
+func (c *Controller) GetMotors() []Motor {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	
+	motors := make([]Motor, 0, len(c.order))
+	for _, motor := range c.order {
+		motor.mu.Lock()
+		motors = append(motors, motor.Motor)
+		motor.mu.Unlock()
+	}
+	return motors
+}
+
+// RestoreMotor sets motor position and enabled flag directly, bypassing
+// command validation except range checks. Used when restoring saved state.
+func (c *Controller) RestoreMotor(id MotorID, position float64, enabled bool) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	
+	motor, exists := c.motors[id]
+	if !exists {
+		return &MotorError{Motor: id, Err: ErrMotorNotFound}
+	}
+	
+	motor.mu.Lock()
+	defer motor.mu.Unlock()
+	if position < motor.MinPosition || position > motor.MaxPosition {
+		return &MotorError{Motor: id, Err: ErrPositionOutOfRange}
+	}
 	
+	motor.IsEnabled = enabled
+	if c.driver != nil {
+		// hardware can't teleport, drive it back to saved position instead
+		motor.Target = position
+		return c.driveTo(motor, position, motor.MaxSpeed)
+	}
+	
+	motor.Position = position
+	motor.halt()
 	return nil
 }
 
+// GetPatterns returns all registered movement patterns sorted by name
+func (c *Controller) GetPatterns() []MovementPattern {
+	c.patternMu.RLock()
+	defer c.patternMu.RUnlock()
+	
+	patterns := make([]MovementPattern, 0, len(c.patterns))
+	for _, pattern := range c.patterns {
+		patterns = append(patterns, pattern)
+	}
+	sort.Slice(patterns, func(i, j int) bool { return patterns[i].Name < patterns[j].Name })
+	return patterns
+}
+
 // Shutdown stops motion control system
 func (c *Controller) Shutdown() {
-	c.mu.Lock()
-	c.running = false
-	c.mu.Unlock()
+	c.running.Store(false)
+	c.stopRuns()
 	
 	close(c.done)
-	close(c.controlChan)
+	c.failTracked(ErrShutdown)
+	c.closeTelemetry()
+	
+	c.mu.RLock()
+	defer c.mu.RUnlock()
 	
 	// Disable all motors
-	for _, motor := range c.motors {
+	for _, motor := range c.order {
+		motor.mu.Lock()
 		motor.IsEnabled = false
-		motor.Speed = 0
+		motor.halt()
+		motor.mu.Unlock()
+		if c.driver != nil {
+			c.driver.Stop(motor.ID)
+		}
+	}
+	
+	if c.driver != nil {
+		if err := c.driver.Close(); err != nil {
+			log.Printf("Failed to close motor driver: %v", err)
+		}
 	}
 } 
\ No newline at end of file
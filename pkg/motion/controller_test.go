@@ -0,0 +1,260 @@
+package motion
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/sashalind/sex-artifical-intelligence/pkg/clock"
+)
+
+// manyMotors is layout of n default servos
+func manyMotors(n int) Config {
+	servo := DefaultConfig().Motors[0]
+	cfg := Config{}
+	for i := range n {
+		m := servo
+		m.ID = MotorID(fmt.Sprintf("servo_%d", i+1))
+		cfg.Motors = append(cfg.Motors, m)
+	}
+	return cfg
+}
+
+// runLoop ticks simulation until stop is closed
+func runLoop(t testing.TB, s *Sim, stop <-chan struct{}) *sync.WaitGroup {
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			if err := s.Step(TickInterval); err != nil {
+				t.Errorf("Step: %v", err)
+				return
+			}
+		}
+	}()
+	return &wg
+}
+
+func newBenchController(b *testing.B) *Controller {
+	b.Helper()
+	c, err := NewManualController(clock.NewFake(simEpoch), DefaultConfig())
+	if err != nil {
+		b.Fatalf("NewManualController: %v", err)
+	}
+	b.Cleanup(c.Shutdown)
+	return c
+}
+
+// playWaves makes every motor of c follow endless sine wave, so motors
+// keep moving as long as clock advances between ticks
+func playWaves(tb testing.TB, c *Controller) {
+	tb.Helper()
+	pattern := MovementPattern{Name: "waves"}
+	for i, m := range c.GetMotors() {
+		pattern.Waveforms = append(pattern.Waveforms, Waveform{
+			Motor:     m.ID,
+			Shape:     WaveSine,
+			Center:    (m.MinPosition + m.MaxPosition) / 2,
+			Amplitude: (m.MaxPosition - m.MinPosition) / 4,
+			Frequency: 0.5,
+			Phase:     float64(i) / 8,
+		})
+	}
+	c.AddPattern(pattern)
+	if err := c.ExecutePattern(context.Background(), pattern.Name); err != nil {
+		tb.Fatalf("ExecutePattern: %v", err)
+	}
+}
+
+// movingTick advances clock by one period and ticks
+func movingTick(c *Controller, clk *clock.Fake) {
+	clk.Advance(TickInterval)
+	c.Tick()
+}
+
+// TestTickDoesNotAllocate guards hot path, see docs/performance.md
+func TestTickDoesNotAllocate(t *testing.T) {
+	c, err := NewManualController(clock.NewFake(simEpoch), DefaultConfig())
+	if err != nil {
+		t.Fatalf("NewManualController: %v", err)
+	}
+	defer c.Shutdown()
+
+	if allocs := testing.AllocsPerRun(1000, func() { c.Tick() }); allocs != 0 {
+		t.Errorf("Tick allocates %v times", allocs)
+	}
+}
+
+// TestMovingTickDoesNotAllocate guards hot path while every motor moves
+func TestMovingTickDoesNotAllocate(t *testing.T) {
+	clk := clock.NewFake(simEpoch)
+	c, err := NewManualController(clk, DefaultConfig())
+	if err != nil {
+		t.Fatalf("NewManualController: %v", err)
+	}
+	defer c.Shutdown()
+	playWaves(t, c)
+	movingTick(c, clk)
+
+	if allocs := testing.AllocsPerRun(1000, func() { movingTick(c, clk) }); allocs != 0 {
+		t.Errorf("Tick of moving motors allocates %v times", allocs)
+	}
+	for _, m := range c.GetMotors() {
+		if m.Speed == 0 {
+			t.Errorf("motor %s stopped", m.ID)
+		}
+	}
+}
+
+// TestControllerConcurrentUse commands, jogs and reads every motor from own
+// goroutine while loop ticks, run with -race. Motors lock separately, so
+// each must still end within its limits.
+func TestControllerConcurrentUse(t *testing.T) {
+	cfg := manyMotors(4)
+	s, err := NewSim(cfg)
+	if err != nil {
+		t.Fatalf("NewSim: %v", err)
+	}
+	defer s.Shutdown()
+
+	stop := make(chan struct{})
+	loop := runLoop(t, s, stop)
+	ctx := context.Background()
+	var wg sync.WaitGroup
+	for _, m := range cfg.Motors {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for i := range 200 {
+				var err error
+				switch i % 4 {
+				case 0, 1:
+					err = s.ExecuteCommand(ctx, MotorCommand{ID: m.ID, Position: float64(i % 180), Speed: 90})
+				case 2:
+					err = s.Jog(ctx, m.ID, 45)
+				case 3:
+					err = s.JogStop(m.ID)
+				}
+				if err != nil {
+					t.Errorf("motor %s op %d: %v", m.ID, i, err)
+					return
+				}
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for range 200 {
+				s.GetMotors()
+				s.Health()
+				s.Runs()
+			}
+		}()
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := range 50 {
+			s.SetSpeedLimit(float64(i%3) / 2)
+			if i%10 == 0 {
+				s.StopAll()
+			}
+		}
+	}()
+	wg.Wait()
+	close(stop)
+	loop.Wait()
+
+	for _, m := range s.GetMotors() {
+		if m.Position < m.MinPosition || m.Position > m.MaxPosition || m.Speed > m.MaxSpeed {
+			t.Errorf("motor %s at %.2f moving %.2f outside limits", m.ID, m.Position, m.Speed)
+		}
+	}
+}
+
+// BenchmarkControllerParallel commands and reads own motor per goroutine
+// while loop ticks, contention is on controller locks only
+func BenchmarkControllerParallel(b *testing.B) {
+	cfg := manyMotors(8)
+	s, err := NewSim(cfg)
+	if err != nil {
+		b.Fatalf("NewSim: %v", err)
+	}
+	b.Cleanup(s.Shutdown)
+	stop := make(chan struct{})
+	loop := runLoop(b, s, stop)
+	b.Cleanup(func() {
+		close(stop)
+		loop.Wait()
+	})
+
+	var next atomic.Int64
+	ctx := context.Background()
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		id := cfg.Motors[int(next.Add(1)-1)%len(cfg.Motors)].ID
+		i := 0
+		for pb.Next() {
+			if i%8 == 0 {
+				if err := s.ExecuteCommand(ctx, MotorCommand{ID: id, Position: float64(i % 180), Speed: 90}); err != nil {
+					b.Errorf("ExecuteCommand: %v", err)
+					return
+				}
+			} else {
+				s.GetMotors()
+			}
+			i++
+		}
+	})
+}
+
+func BenchmarkTick(b *testing.B) {
+	c := newBenchController(b)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		c.Tick()
+	}
+}
+
+// BenchmarkMovingTick ticks while every motor follows waveform, clock
+// advance is included
+func BenchmarkMovingTick(b *testing.B) {
+	clk := clock.NewFake(simEpoch)
+	c, err := NewManualController(clk, DefaultConfig())
+	if err != nil {
+		b.Fatalf("NewManualController: %v", err)
+	}
+	b.Cleanup(c.Shutdown)
+	playWaves(b, c)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		movingTick(c, clk)
+	}
+}
+
+// BenchmarkTickVsRead ticks while API reads motors every tenth op, reads
+// copy and allocate
+func BenchmarkTickVsRead(b *testing.B) {
+	c := newBenchController(b)
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			if i%10 == 0 {
+				c.GetMotors()
+			} else {
+				c.Tick()
+			}
+			i++
+		}
+	})
+}
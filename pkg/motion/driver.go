@@ -0,0 +1,56 @@
+package motion
+
+// Driver moves physical motors. Controller keeps logical motor state and
+// delegates actual output to driver when one is attached. Drivers work in
+// shaft degrees, those taking Motor convert it with Motor.Shaft.
+type Driver interface {
+	// SetTarget commands motor towards position (degrees) at speed (degrees/second)
+	SetTarget(id MotorID, position, speed float64) error
+
+	// ReadState returns actual position and speed reported by hardware
+	ReadState(id MotorID) (position, speed float64, err error)
+
+	// Stop halts motor output immediately
+	Stop(id MotorID) error
+
+	// Close releases hardware resources
+	Close() error
+}
+
+// PowerDriver is implemented by drivers that can cut motor power, e.g.
+// servo supply relay. Used when system powers down while idle.
+type PowerDriver interface {
+	SetPower(id MotorID, on bool) error
+}
+
+// ResetDriver is implemented by drivers that can reboot motor electronics,
+// clearing latched hardware errors. Motor is set up again afterwards.
+type ResetDriver interface {
+	ResetMotor(id MotorID) error
+}
+
+// EndstopDriver is implemented by drivers wired to endstop switches, used
+// by endstop homing
+type EndstopDriver interface {
+	AtEndstop(id MotorID) (bool, error)
+}
+
+// LoadDriver is implemented by drivers reporting motor load, e.g. from
+// current sensing, as fraction of rated load. Used by overload protection.
+type LoadDriver interface {
+	ReadLoad(id MotorID) (float64, error)
+}
+
+// TemperatureDriver is implemented by drivers reading motor temperature in
+// °C, used by thermal model. ErrNoTemperature means motor has no sensor.
+type TemperatureDriver interface {
+	ReadTemperature(id MotorID) (float64, error)
+}
+
+// MotorAttacher is implemented by drivers taking motors added or removed
+// at runtime. Nil backend leaves motor to driver itself, otherwise motor
+// is routed to backend.
+type MotorAttacher interface {
+	AttachMotor(m Motor, backend Driver) error
+	DetachMotor(id MotorID) error
+}
@@ -0,0 +1,608 @@
+package motion
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/sashalind/sex-artifical-intelligence/pkg/clock"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/core/errs"
+)
+
+// Dynamixel protocol 2.0 instructions
+const (
+	dxlPing   = 0x01
+	dxlRead   = 0x02
+	dxlWrite  = 0x03
+	dxlReboot = 0x08
+	dxlStatus = 0x55
+)
+
+// control table of X series servos
+const (
+	dxlOperatingMode   = 11
+	dxlTorqueEnable    = 64
+	dxlBusWatchdog     = 98 // 20ms units, 0 disables
+	dxlGoalVelocity    = 104
+	dxlProfileVelocity = 112 // followed by goal position at 116
+	dxlPresentLoad     = 126 // through present temperature at 146
+	dxlPresentVelocity = 128 // followed by present position at 132
+
+	dxlModeVelocity = 1
+	dxlModePosition = 3
+)
+
+// unit conversions of X series
+const (
+	dxlDegreesPerUnit   = 360.0 / 4096
+	dxlVelocityPerUnit  = 0.229 * 6 // degrees/second, unit is 0.229 rpm
+	dxlLoadPercent      = 0.1
+	dxlVoltsPerUnit     = 0.1
+	dxlWatchdogUnit     = 20 * time.Millisecond
+	dxlWatchdogMax      = 127
+	defaultDynamixelBps = 57600
+
+	// dynamixelPollInterval bounds how often state of one servo is read,
+	// control loop ticks faster than slow buses can answer
+	dynamixelPollInterval = 20 * time.Millisecond
+
+	// dynamixelBootTime is how long rebooted servo is left before setup
+	dynamixelBootTime = 500 * time.Millisecond
+)
+
+// ErrDynamixelTimeout is returned when servo does not answer
+var ErrDynamixelTimeout = errs.New(errs.Unavailable, "dynamixel servo did not respond")
+
+// DynamixelError is error flagged in servo status packet
+type DynamixelError struct {
+	ID    uint8
+	Code  byte // 1 result fail ... 7 access error
+	Alert bool // hardware error, read Hardware Error Status register
+}
+
+func (e *DynamixelError) Error() string {
+	msg := fmt.Sprintf("dynamixel %d: error %d", e.ID, e.Code)
+	if e.Alert {
+		msg += " (hardware alert)"
+	}
+	return msg
+}
+
+// DynamixelOutput maps motor to servo ID on Dynamixel bus
+type DynamixelOutput struct {
+	Motor MotorID
+
+	// Port is serial device like /dev/ttyUSB0, Baud defaults to 57600
+	Port string
+	Baud int
+	ID   uint8
+
+	// Offset is servo angle at motor position zero, degrees
+	Offset float64
+
+	// Velocity runs servo in velocity mode, driven with SetVelocity
+	// instead of positions
+	Velocity bool
+}
+
+// Validate checks port and servo ID
+func (o DynamixelOutput) Validate() error {
+	if o.Port == "" {
+		return fmt.Errorf("motor %s: dynamixel port is empty", o.Motor)
+	}
+	if o.ID > 252 {
+		return fmt.Errorf("motor %s: dynamixel id must be 0..252", o.Motor)
+	}
+	if o.Baud < 0 {
+		return fmt.Errorf("motor %s: baud rate must not be negative", o.Motor)
+	}
+	return nil
+}
+
+// DynamixelConfig lists servos of one driver
+type DynamixelConfig struct {
+	Outputs []DynamixelOutput
+
+	// Open opens serial port, OpenSerial if nil
+	Open func(path string, baud int) (SerialPort, error)
+}
+
+// DynamixelStatus is servo telemetry
+type DynamixelStatus struct {
+	Temperature float64 `json:"temperature"` // °C
+	Load        float64 `json:"load"`        // percent of max torque, signed
+	Voltage     float64 `json:"voltage"`     // volts
+}
+
+// dxlBus is one serial port, transactions are serialized
+type dxlBus struct {
+	mu   sync.Mutex
+	port SerialPort
+	r    *bufio.Reader
+	baud int
+}
+
+// dxlServo is state of one motor
+type dxlServo struct {
+	DynamixelOutput
+	bus *dxlBus
+
+	position float64
+	velocity float64
+	readAt   time.Time
+}
+
+// DynamixelDriver drives smart servos over Dynamixel protocol 2.0 serial
+// buses (X series control table). Servos report real position, so unlike
+// pulse drivers no estimate is kept. Implements Driver, PowerDriver,
+// ResetDriver, TemperatureDriver and WatchdogDriver.
+type DynamixelDriver struct {
+	mu       sync.Mutex
+	clock    clock.Clock
+	buses    map[string]*dxlBus
+	servos   map[MotorID]*dxlServo
+	watchdog byte // armed Bus Watchdog, 20ms units
+	closed   bool
+}
+
+// NewDynamixelDriver opens ports, pings every servo, sets its operating
+// mode and enables torque
+func NewDynamixelDriver(clk clock.Clock, cfg DynamixelConfig) (*DynamixelDriver, error) {
+	if cfg.Open == nil {
+		cfg.Open = OpenSerial
+	}
+	d := &DynamixelDriver{
+		clock:  clock.OrReal(clk),
+		buses:  make(map[string]*dxlBus),
+		servos: make(map[MotorID]*dxlServo),
+	}
+	if err := d.open(cfg); err != nil {
+		d.Close()
+		return nil, err
+	}
+	return d, nil
+}
+
+func (d *DynamixelDriver) open(cfg DynamixelConfig) error {
+	ids := make(map[string]map[uint8]MotorID)
+	for _, o := range cfg.Outputs {
+		if err := o.Validate(); err != nil {
+			return err
+		}
+		if o.Baud == 0 {
+			o.Baud = defaultDynamixelBps
+		}
+		if _, dup := d.servos[o.Motor]; dup {
+			return fmt.Errorf("motor %s: dynamixel output configured twice", o.Motor)
+		}
+		if ids[o.Port] == nil {
+			ids[o.Port] = make(map[uint8]MotorID)
+		}
+		if other, ok := ids[o.Port][o.ID]; ok {
+			return fmt.Errorf("motor %s: dynamixel id %d on %s taken by %s", o.Motor, o.ID, o.Port, other)
+		}
+		ids[o.Port][o.ID] = o.Motor
+
+		bus, ok := d.buses[o.Port]
+		if !ok {
+			port, err := cfg.Open(o.Port, o.Baud)
+			if err != nil {
+				return &MotorError{Motor: o.Motor, Err: err}
+			}
+			bus = &dxlBus{port: port, r: bufio.NewReader(timeoutReader{port}), baud: o.Baud}
+			d.buses[o.Port] = bus
+		} else if bus.baud != o.Baud {
+			return fmt.Errorf("motor %s: baud %d differs from %d of %s", o.Motor, o.Baud, bus.baud, o.Port)
+		}
+
+		s := &dxlServo{DynamixelOutput: o, bus: bus}
+		if err := d.setup(s); err != nil {
+			return &MotorError{Motor: o.Motor, Err: err}
+		}
+		d.servos[o.Motor] = s
+	}
+	return nil
+}
+
+// setup checks servo answers and puts it into configured mode
+func (d *DynamixelDriver) setup(s *dxlServo) error {
+	if _, err := s.bus.transact(s.ID, dxlPing, nil); err != nil {
+		return err
+	}
+	mode := byte(dxlModePosition)
+	if s.Velocity {
+		mode = dxlModeVelocity
+	}
+	// operating mode is writable only with torque off
+	for _, w := range []struct {
+		addr  uint16
+		value byte
+	}{{dxlTorqueEnable, 0}, {dxlOperatingMode, mode}, {dxlTorqueEnable, 1}} {
+		if err := s.bus.write(s.ID, w.addr, []byte{w.value}); err != nil {
+			return err
+		}
+	}
+	return d.read(s)
+}
+
+// SetTarget moves servo to position with speed as profile velocity
+func (d *DynamixelDriver) SetTarget(id MotorID, position, speed float64) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	s, err := d.servo(id)
+	if err != nil {
+		return err
+	}
+	if s.Velocity {
+		return fmt.Errorf("motor %s: velocity mode servo takes SetVelocity", id)
+	}
+	// zero profile velocity means unlimited, keep at least one unit
+	profile := max(math.Round(math.Abs(speed)/dxlVelocityPerUnit), 1)
+	goal := math.Round((position + s.Offset) / dxlDegreesPerUnit)
+
+	data := make([]byte, 8)
+	binary.LittleEndian.PutUint32(data[0:], uint32(profile))
+	binary.LittleEndian.PutUint32(data[4:], uint32(int32(goal)))
+	return s.bus.write(s.ID, dxlProfileVelocity, data)
+}
+
+// SetVelocity spins velocity mode servo at speed in degrees/second,
+// negative turns backwards
+func (d *DynamixelDriver) SetVelocity(id MotorID, speed float64) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	s, err := d.servo(id)
+	if err != nil {
+		return err
+	}
+	if !s.Velocity {
+		return fmt.Errorf("motor %s: position mode servo takes SetTarget", id)
+	}
+	return s.bus.write(s.ID, dxlGoalVelocity, le32(math.Round(speed/dxlVelocityPerUnit)))
+}
+
+// ReadState returns position and velocity reported by servo, reading it
+// at most every dynamixelPollInterval
+func (d *DynamixelDriver) ReadState(id MotorID) (float64, float64, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	s, err := d.servo(id)
+	if err != nil {
+		return 0, 0, err
+	}
+	if d.clock.Since(s.readAt) >= dynamixelPollInterval {
+		if err := d.read(s); err != nil {
+			return 0, 0, err
+		}
+	}
+	return s.position, s.velocity, nil
+}
+
+// read refreshes present velocity and position
+func (d *DynamixelDriver) read(s *dxlServo) error {
+	data, err := s.bus.read(s.ID, dxlPresentVelocity, 8)
+	if err != nil {
+		return err
+	}
+	s.velocity = float64(int32(binary.LittleEndian.Uint32(data[0:]))) * dxlVelocityPerUnit
+	s.position = float64(int32(binary.LittleEndian.Uint32(data[4:])))*dxlDegreesPerUnit - s.Offset
+	s.readAt = d.clock.Now()
+	return nil
+}
+
+// Stop holds servo where it is
+func (d *DynamixelDriver) Stop(id MotorID) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	s, err := d.servo(id)
+	if err != nil {
+		return err
+	}
+	if s.Velocity {
+		return s.bus.write(s.ID, dxlGoalVelocity, le32(0))
+	}
+	if err := d.read(s); err != nil {
+		return err
+	}
+	return s.bus.write(s.ID, dxlProfileVelocity+4, le32(math.Round((s.position+s.Offset)/dxlDegreesPerUnit)))
+}
+
+// SetPower switches servo torque
+func (d *DynamixelDriver) SetPower(id MotorID, on bool) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	s, err := d.servo(id)
+	if err != nil {
+		return err
+	}
+	torque := byte(0)
+	if on {
+		torque = 1
+	}
+	return s.bus.write(s.ID, dxlTorqueEnable, []byte{torque})
+}
+
+// ResetMotor reboots servo, which clears hardware errors like overload
+// shutdown, then sets it up as at start. Bus is released while servo boots.
+func (d *DynamixelDriver) ResetMotor(id MotorID) error {
+	d.mu.Lock()
+	s, err := d.servo(id)
+	if err == nil {
+		_, err = s.bus.transact(s.ID, dxlReboot, nil)
+	}
+	d.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	d.clock.Sleep(dynamixelBootTime)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if s, err = d.servo(id); err != nil {
+		return err
+	}
+	if err := d.setup(s); err != nil {
+		return err
+	}
+	// Bus Watchdog is in RAM, reboot cleared it
+	if d.watchdog == 0 {
+		return nil
+	}
+	return s.bus.write(s.ID, dxlBusWatchdog, []byte{d.watchdog})
+}
+
+// Status reads temperature, load and input voltage of servo
+func (d *DynamixelDriver) Status(id MotorID) (DynamixelStatus, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	s, err := d.servo(id)
+	if err != nil {
+		return DynamixelStatus{}, err
+	}
+	data, err := s.bus.read(s.ID, dxlPresentLoad, 21)
+	if err != nil {
+		return DynamixelStatus{}, err
+	}
+	return DynamixelStatus{
+		Load:        float64(int16(binary.LittleEndian.Uint16(data[0:]))) * dxlLoadPercent,
+		Voltage:     float64(binary.LittleEndian.Uint16(data[18:])) * dxlVoltsPerUnit,
+		Temperature: float64(data[20]),
+	}, nil
+}
+
+// ReadTemperature reads internal temperature of servo for thermal model
+func (d *DynamixelDriver) ReadTemperature(id MotorID) (float64, error) {
+	st, err := d.Status(id)
+	if err != nil {
+		return 0, err
+	}
+	return st.Temperature, nil
+}
+
+// ArmWatchdog sets Bus Watchdog of every servo, servo not addressed for
+// timeout stops by itself. Timeout is rounded up to 20ms and capped at
+// 2.54s. Writing zero first also clears watchdog that already tripped.
+func (d *DynamixelDriver) ArmWatchdog(timeout time.Duration) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	units := byte(min((timeout+dxlWatchdogUnit-1)/dxlWatchdogUnit, dxlWatchdogMax))
+	d.watchdog = units
+	for _, s := range d.servos {
+		if err := s.bus.write(s.ID, dxlBusWatchdog, []byte{0}); err != nil {
+			return err
+		}
+		if units == 0 {
+			continue
+		}
+		if err := s.bus.write(s.ID, dxlBusWatchdog, []byte{units}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Pet does nothing, state reads of control loop address every servo each
+// poll interval and that is what Bus Watchdog waits for
+func (d *DynamixelDriver) Pet() error {
+	return nil
+}
+
+// Close disables torque of every servo and closes ports
+func (d *DynamixelDriver) Close() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.closed {
+		return nil
+	}
+	d.closed = true
+
+	var errs []error
+	for _, s := range d.servos {
+		errs = append(errs, s.bus.write(s.ID, dxlTorqueEnable, []byte{0}))
+	}
+	for _, b := range d.buses {
+		errs = append(errs, b.port.Close())
+	}
+	return errors.Join(errs...)
+}
+
+func (d *DynamixelDriver) servo(id MotorID) (*dxlServo, error) {
+	if d.closed {
+		return nil, errors.New("dynamixel driver closed")
+	}
+	s, ok := d.servos[id]
+	if !ok {
+		return nil, &MotorError{Motor: id, Err: ErrMotorNotFound}
+	}
+	return s, nil
+}
+
+// write stores data in control table at addr
+func (b *dxlBus) write(id uint8, addr uint16, data []byte) error {
+	params := binary.LittleEndian.AppendUint16(nil, addr)
+	_, err := b.transact(id, dxlWrite, append(params, data...))
+	return err
+}
+
+// read fetches n bytes of control table at addr
+func (b *dxlBus) read(id uint8, addr uint16, n int) ([]byte, error) {
+	params := binary.LittleEndian.AppendUint16(nil, addr)
+	params = binary.LittleEndian.AppendUint16(params, uint16(n))
+	data, err := b.transact(id, dxlRead, params)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) != n {
+		return nil, fmt.Errorf("dynamixel %d: read %d bytes, want %d", id, len(data), n)
+	}
+	return data, nil
+}
+
+// transact sends instruction and returns parameters of status reply
+func (b *dxlBus) transact(id uint8, inst byte, params []byte) ([]byte, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// drop leftovers of timed out transaction
+	b.r.Reset(timeoutReader{b.port})
+	if _, err := b.port.Write(dxlPacket(id, inst, params)); err != nil {
+		return nil, err
+	}
+	return b.readStatus(id)
+}
+
+// readStatus reads status packet of servo id
+func (b *dxlBus) readStatus(id uint8) ([]byte, error) {
+	header := []byte{0xFF, 0xFF, 0xFD, 0x00}
+	matched := 0
+	for matched < len(header) {
+		c, err := b.r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case c == header[matched]:
+			matched++
+		case c == 0xFF:
+			// FF FF FF FD: keep last two FF as start of header
+			matched = min(matched, 2)
+		default:
+			matched = 0
+		}
+	}
+
+	var meta [3]byte // id, length
+	if _, err := io.ReadFull(b.r, meta[:]); err != nil {
+		return nil, err
+	}
+	length := int(binary.LittleEndian.Uint16(meta[1:]))
+	if length < 4 {
+		return nil, fmt.Errorf("dynamixel %d: short status packet", id)
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(b.r, body); err != nil {
+		return nil, err
+	}
+
+	packet := append(append(header, meta[:]...), body[:length-2]...)
+	if crc := binary.LittleEndian.Uint16(body[length-2:]); crc != dxlCRC(packet) {
+		return nil, fmt.Errorf("dynamixel %d: status checksum mismatch", id)
+	}
+	if meta[0] != id || body[0] != dxlStatus {
+		return nil, fmt.Errorf("dynamixel %d: unexpected reply from %d", id, meta[0])
+	}
+	if code := body[1]; code != 0 {
+		return nil, &DynamixelError{ID: id, Code: code & 0x7F, Alert: code&0x80 != 0}
+	}
+	return unstuff(body[2 : length-2]), nil
+}
+
+// dxlPacket builds instruction packet with stuffing and checksum
+func dxlPacket(id uint8, inst byte, params []byte) []byte {
+	body := stuff(params)
+	length := len(body) + 3 // instruction and CRC
+	p := []byte{0xFF, 0xFF, 0xFD, 0x00, id, byte(length), byte(length >> 8), inst}
+	p = append(p, body...)
+	return binary.LittleEndian.AppendUint16(p, dxlCRC(p))
+}
+
+// stuff inserts FD after every FF FF FD so payload never looks like header
+func stuff(data []byte) []byte {
+	out := make([]byte, 0, len(data))
+	for _, c := range data {
+		out = append(out, c)
+		if n := len(out); n >= 3 && out[n-3] == 0xFF && out[n-2] == 0xFF && out[n-1] == 0xFD {
+			out = append(out, 0xFD)
+		}
+	}
+	return out
+}
+
+// unstuff reverses stuff
+func unstuff(data []byte) []byte {
+	out := make([]byte, 0, len(data))
+	for i := 0; i < len(data); i++ {
+		out = append(out, data[i])
+		if n := len(out); n >= 3 && out[n-3] == 0xFF && out[n-2] == 0xFF && out[n-1] == 0xFD && i+1 < len(data) && data[i+1] == 0xFD {
+			i++
+		}
+	}
+	return out
+}
+
+// dxlCRCTable is CRC-16/BUYPASS (polynomial 0x8005) lookup table
+var dxlCRCTable = func() (t [256]uint16) {
+	for i := range t {
+		c := uint16(i) << 8
+		for range 8 {
+			if c&0x8000 != 0 {
+				c = c<<1 ^ 0x8005
+			} else {
+				c <<= 1
+			}
+		}
+		t[i] = c
+	}
+	return t
+}()
+
+// dxlCRC returns protocol 2.0 checksum of data
+func dxlCRC(data []byte) uint16 {
+	var crc uint16
+	for _, c := range data {
+		crc = crc<<8 ^ dxlCRCTable[byte(crc>>8)^c]
+	}
+	return crc
+}
+
+// le32 encodes value as little endian 32-bit integer
+func le32(v float64) []byte {
+	return binary.LittleEndian.AppendUint32(nil, uint32(int32(v)))
+}
+
+// timeoutReader turns empty read of timed out serial port into error
+type timeoutReader struct {
+	r io.Reader
+}
+
+func (t timeoutReader) Read(p []byte) (int, error) {
+	n, err := t.r.Read(p)
+	if n == 0 && err == nil {
+		return 0, ErrDynamixelTimeout
+	}
+	return n, err
+}
@@ -0,0 +1,124 @@
+package motion
+
+import (
+	"fmt"
+	"math"
+)
+
+// PositionUnit is unit motor positions are commanded and reported in
+type PositionUnit string
+
+const (
+	UnitDegrees     PositionUnit = "deg"
+	UnitRadians     PositionUnit = "rad"
+	UnitMillimeters PositionUnit = "mm" // linear actuators
+)
+
+// Frame maps motor output position to shaft degrees drivers work in:
+// shaft = ±position*Scale*Ratio + Zero, minus when inverted. Scale is
+// linkage between output and gearbox, Ratio the gearbox itself.
+// Positions, limits, speeds and accelerations of motor are all in its
+// unit, only driver sees degrees. Output geared 3:1 down has Ratio 3, lead
+// screw moving 8mm per turn has unit mm and Scale 45, mirrored twin of
+// joint on other side of robot is inverted.
+type Frame struct {
+	Unit   PositionUnit // empty is degrees
+	Scale  float64      // gearbox output degrees per unit, negative reverses; zero is natural scale of unit
+	Ratio  float64      // shaft turns per gearbox output turn, zero is 1
+	Invert bool         // shaft turns opposite way, for mirrored assemblies
+	Zero   float64      // shaft degrees at position zero
+}
+
+// validate checks frame of motor m
+func (f Frame) validate(m Motor) error {
+	switch f.Unit {
+	case "", UnitDegrees, UnitRadians:
+	case UnitMillimeters:
+		if f.Scale == 0 {
+			return fmt.Errorf("motor %s: unit mm needs scale in degrees per mm", m.ID)
+		}
+	default:
+		return fmt.Errorf("motor %s: unknown position unit %q", m.ID, f.Unit)
+	}
+	if math.IsNaN(f.Scale) || math.IsInf(f.Scale, 0) || math.IsNaN(f.Zero) || math.IsInf(f.Zero, 0) {
+		return fmt.Errorf("motor %s: frame scale and zero must be finite", m.ID)
+	}
+	if !(f.Ratio >= 0) || math.IsInf(f.Ratio, 0) {
+		return fmt.Errorf("motor %s: gear ratio must be finite and not negative, invert reverses", m.ID)
+	}
+	return nil
+}
+
+// PositionUnit returns unit of frame, degrees when unset
+func (f Frame) PositionUnit() PositionUnit {
+	if f.Unit == "" {
+		return UnitDegrees
+	}
+	return f.Unit
+}
+
+// scale returns shaft degrees per unit
+func (f Frame) scale() float64 {
+	s := 1.0
+	switch {
+	case f.Scale != 0:
+		s = f.Scale
+	case f.Unit == UnitRadians:
+		s = 180 / math.Pi
+	}
+	if f.Ratio != 0 {
+		s *= f.Ratio
+	}
+	if f.Invert {
+		s = -s
+	}
+	return s
+}
+
+// ToShaft converts position to shaft degrees
+func (f Frame) ToShaft(position float64) float64 {
+	return position*f.scale() + f.Zero
+}
+
+// FromShaft converts shaft degrees to position
+func (f Frame) FromShaft(degrees float64) float64 {
+	return (degrees - f.Zero) / f.scale()
+}
+
+// Degrees converts distance, speed or acceleration in unit to magnitude in
+// shaft degrees
+func (f Frame) Degrees(v float64) float64 {
+	return math.Abs(v * f.scale())
+}
+
+// Shaft returns m with positions, limits, speed and acceleration in shaft
+// degrees, as drivers taking Motor expect them
+func (m Motor) Shaft() Motor {
+	f := m.Frame
+	lo, hi := f.ToShaft(m.MinPosition), f.ToShaft(m.MaxPosition)
+	m.MinPosition, m.MaxPosition = min(lo, hi), max(lo, hi)
+	if m.SoftMinPosition < m.SoftMaxPosition {
+		lo, hi = f.ToShaft(m.SoftMinPosition), f.ToShaft(m.SoftMaxPosition)
+		m.SoftMinPosition, m.SoftMaxPosition = min(lo, hi), max(lo, hi)
+	}
+	m.Position = f.ToShaft(m.Position)
+	m.Target = f.ToShaft(m.Target)
+	m.Speed *= f.scale()
+	m.MaxSpeed = f.Degrees(m.MaxSpeed)
+	m.MaxAcceleration = f.Degrees(m.MaxAcceleration)
+	m.MaxJerk = f.Degrees(m.MaxJerk)
+	m.Frame = Frame{}
+	return m
+}
+
+// shaft converts position of motor to hardware position of its driver,
+// caller holds slot lock
+func (slot *motorSlot) shaft(position float64) float64 {
+	return slot.Frame.ToShaft(position) + slot.offset
+}
+
+// fromShaft converts hardware position reported by driver, caller holds
+// slot lock
+func (slot *motorSlot) fromShaft(raw float64) float64 {
+	return slot.Frame.FromShaft(raw - slot.offset)
+}
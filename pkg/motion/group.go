@@ -0,0 +1,188 @@
+package motion
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/sashalind/sex-artifical-intelligence/pkg/core/errs"
+)
+
+var (
+	ErrEmptyGroup     = errs.New(errs.InvalidArgument, "motor group is empty")
+	ErrDuplicateMotor = errs.New(errs.InvalidArgument, "motor appears twice in group")
+)
+
+// groupRequest carries group to control loop and its outcome back
+type groupRequest struct {
+	cmds   []MotorCommand
+	result chan error
+}
+
+// commandRequest is single command waiting for control loop
+type commandRequest struct {
+	cmd    MotorCommand
+	jog    bool // cmd.Speed is jog velocity, see Jog
+	result chan error
+}
+
+// ExecuteGroup runs commands as one coordinated move: all of them are
+// validated first and none is applied if any motor is missing, disabled or
+// asked to leave its range. Group is applied in single control loop step.
+// Returned error lists every rejected motor.
+func (c *Controller) ExecuteGroup(ctx context.Context, cmds []MotorCommand) error {
+	if !c.running.Load() {
+		return ErrShutdown
+	}
+	if !c.loopRunning.Load() {
+		return ErrLoopStopped
+	}
+	if c.held.Load() {
+		return ErrHeld
+	}
+
+	// reject early without waiting for loop, loop checks again under lock
+	if err := c.validateGroup(cmds); err != nil {
+		return err
+	}
+
+	req := groupRequest{cmds: cmds, result: make(chan error, 1)}
+	select {
+	case c.groupChan <- req:
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-c.done:
+		return ErrShutdown
+	}
+	// once loop took group it runs to completion, never half-applied
+	return <-req.result
+}
+
+// validateGroup checks every command against current motor state
+func (c *Controller) validateGroup(cmds []MotorCommand) error {
+	if len(cmds) == 0 {
+		return ErrEmptyGroup
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	var problems []error
+	seen := make(map[MotorID]bool, len(cmds))
+	for _, cmd := range cmds {
+		if seen[cmd.ID] {
+			problems = append(problems, &MotorError{Motor: cmd.ID, Err: ErrDuplicateMotor})
+			continue
+		}
+		seen[cmd.ID] = true
+
+		if err := c.validateCommand(cmd); err != nil {
+			problems = append(problems, err)
+		}
+	}
+	return errors.Join(problems...)
+}
+
+// validateCommand checks command against current motor state, caller
+// holds mu
+func (c *Controller) validateCommand(cmd MotorCommand) error {
+	motor, exists := c.motors[cmd.ID]
+	if !exists {
+		return &MotorError{Motor: cmd.ID, Err: ErrMotorNotFound}
+	}
+	motor.mu.Lock()
+	err := checkCommand(motor, cmd)
+	motor.mu.Unlock()
+	if err != nil {
+		return &MotorError{Motor: cmd.ID, Err: err}
+	}
+	return nil
+}
+
+// checkCommand validates single command for motor, caller holds slot lock
+func checkCommand(slot *motorSlot, cmd MotorCommand) error {
+	if !slot.IsEnabled {
+		return ErrMotorDisabled
+	}
+	if slot.homing {
+		return ErrHoming
+	}
+	if slot.heat.cooling {
+		return ErrMotorOverheated
+	}
+	if lo, hi := slot.Travel(); cmd.Position < lo || cmd.Position > hi {
+		return ErrPositionOutOfRange
+	}
+	return nil
+}
+
+// executeGroup applies group, runs in control loop. Motors are locked in
+// controller order so concurrent readers can't see half of group.
+// Duplicates were rejected by ExecuteGroup, state is checked again here as
+// it may have changed while group waited for loop.
+func (c *Controller) executeGroup(cmds []MotorCommand) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	byID := make(map[MotorID]MotorCommand, len(cmds))
+	for _, cmd := range cmds {
+		byID[cmd.ID] = cmd
+	}
+	var slots []*motorSlot
+	for _, slot := range c.order {
+		if _, ok := byID[slot.ID]; ok {
+			slot.mu.Lock()
+			slots = append(slots, slot)
+		}
+	}
+	defer func() {
+		for _, slot := range slots {
+			slot.mu.Unlock()
+		}
+	}()
+
+	var problems []error
+	for _, cmd := range cmds {
+		if _, exists := c.motors[cmd.ID]; !exists {
+			problems = append(problems, &MotorError{Motor: cmd.ID, Err: ErrMotorNotFound})
+		}
+	}
+	for _, slot := range slots {
+		if err := checkCommand(slot, byID[slot.ID]); err != nil {
+			problems = append(problems, &MotorError{Motor: slot.ID, Err: err})
+		}
+	}
+	if len(problems) > 0 {
+		return errors.Join(problems...)
+	}
+
+	if c.driver != nil {
+		// hardware may still refuse, put already moved motors back
+		for i, slot := range slots {
+			cmd := byID[slot.ID]
+			slot.cruise = c.motorSpeed(slot, cmd.Speed)
+			if err := c.driveTo(slot, cmd.Position, slot.cruise); err != nil {
+				for _, done := range slots[:i] {
+					if rerr := c.driveTo(done, done.Target, done.MaxSpeed); rerr != nil {
+						log.Printf("Failed to roll back motor %s: %v", done.ID, rerr)
+					}
+				}
+				return fmt.Errorf("group rolled back: %w", &MotorError{Motor: slot.ID, Err: err})
+			}
+		}
+		for _, slot := range slots {
+			slot.Target = byID[slot.ID].Position
+			c.publish(slot.Motor)
+		}
+		return nil
+	}
+
+	for _, slot := range slots {
+		cmd := byID[slot.ID]
+		slot.Target = cmd.Position
+		slot.cruise = c.motorSpeed(slot, cmd.Speed)
+		c.publish(slot.Motor)
+	}
+	return nil
+}
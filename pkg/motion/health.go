@@ -0,0 +1,49 @@
+package motion
+
+import "github.com/sashalind/sex-artifical-intelligence/pkg/health"
+
+// Health reports control loop state, last motor error and motor gauges.
+// Controller is down when its loop is not running and degraded while driver
+// fails to read some motors.
+func (c *Controller) Health() health.Report {
+	status := health.OK
+	if c.unreadable.Load() > 0 {
+		status = health.Degraded
+	}
+	if !c.running.Load() || !c.loopRunning.Load() {
+		status = health.Down
+	}
+
+	var enabled, moving float64
+	c.mu.RLock()
+	total := len(c.order)
+	for _, m := range c.order {
+		m.mu.Lock()
+		if m.IsEnabled {
+			enabled++
+		}
+		if m.Speed != 0 {
+			moving++
+		}
+		m.mu.Unlock()
+	}
+	c.mu.RUnlock()
+
+	held := 0.0
+	if c.held.Load() {
+		held = 1
+	}
+	return health.Report{
+		Status:    status,
+		LastError: c.lastErr.String(),
+		Gauges: map[string]float64{
+			"motors":            float64(total),
+			"enabled_motors":    enabled,
+			"moving_motors":     moving,
+			"unreadable_motors": float64(c.unreadable.Load()),
+			"queued_commands":   float64(len(c.controlChan)),
+			"speed_limit":       c.SpeedLimit(),
+			"held":              held,
+		},
+	}
+}
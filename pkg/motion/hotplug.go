@@ -0,0 +1,197 @@
+package motion
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"slices"
+
+	"github.com/sashalind/sex-artifical-intelligence/pkg/core/errs"
+)
+
+var (
+	ErrInvalidMotor = errs.New(errs.InvalidArgument, "invalid motor")
+	ErrMotorExists  = errs.New(errs.FailedPrecondition, "motor already exists")
+	ErrMotorInGroup = errs.New(errs.FailedPrecondition, "motor is member of group")
+	ErrLastMotor    = errs.New(errs.FailedPrecondition, "controller needs at least one motor")
+)
+
+// AddMotor registers motor at runtime. Backend is hardware driver of new
+// motor, nil makes it logical; motors with backend are routed through Mux.
+// Attached driver must implement MotorAttacher.
+func (c *Controller) AddMotor(m Motor, backend Driver) error {
+	if !c.running.Load() {
+		return ErrShutdown
+	}
+	if err := ValidateMotor(m); err != nil {
+		return &MotorError{Motor: m.ID, Err: fmt.Errorf("%w: %v", ErrInvalidMotor, err)}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, exists := c.motors[m.ID]; exists {
+		return &MotorError{Motor: m.ID, Err: ErrMotorExists}
+	}
+	if c.driver == nil && backend != nil {
+		c.routeThroughMux()
+	}
+	if err := c.attachMotor(m, backend); err != nil {
+		return &MotorError{Motor: m.ID, Err: err}
+	}
+	c.publish(c.motors[m.ID].Motor)
+	return nil
+}
+
+// RemoveMotor stops motor and drops it from controller and driver. Motors
+// still in group or homing are kept.
+func (c *Controller) RemoveMotor(id MotorID) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	slot, exists := c.motors[id]
+	if !exists {
+		return &MotorError{Motor: id, Err: ErrMotorNotFound}
+	}
+	if len(c.motors) == 1 {
+		return &MotorError{Motor: id, Err: ErrLastMotor}
+	}
+	for _, g := range c.groups {
+		if slices.Contains(g.Motors, id) {
+			return &MotorError{Motor: id, Err: fmt.Errorf("%w %s", ErrMotorInGroup, g.Name)}
+		}
+	}
+
+	slot.mu.Lock()
+	if slot.homing {
+		slot.mu.Unlock()
+		return &MotorError{Motor: id, Err: ErrHoming}
+	}
+	slot.halt()
+	slot.mu.Unlock()
+
+	c.detachMotor(id)
+	return nil
+}
+
+// ConfigureMotor changes limits, enabled flag and homing of running motor,
+// same rules as ApplyConfig. Position of m is ignored, motor stays where it
+// is.
+func (c *Controller) ConfigureMotor(m Motor) error {
+	if err := ValidateMotor(m); err != nil {
+		return &MotorError{Motor: m.ID, Err: fmt.Errorf("%w: %v", ErrInvalidMotor, err)}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	slot, exists := c.motors[m.ID]
+	if !exists {
+		return &MotorError{Motor: m.ID, Err: ErrMotorNotFound}
+	}
+	slot.mu.Lock()
+	defer slot.mu.Unlock()
+
+	if slot.Type != m.Type {
+		return &MotorError{Motor: m.ID, Err: ErrRestartRequired}
+	}
+	if err := slot.fits(m); err != nil {
+		return &MotorError{Motor: m.ID, Err: err}
+	}
+	slot.reconfigure(m)
+	c.publish(slot.Motor)
+	return nil
+}
+
+// routeThroughMux replaces missing driver with Mux emulating current
+// motors, so hardware backend can join them. Caller holds mu.
+func (c *Controller) routeThroughMux() {
+	motors := make([]Motor, 0, len(c.order))
+	for _, slot := range c.order {
+		slot.mu.Lock()
+		m := slot.Motor
+		// calibration moves shaft zero
+		m.Frame.Zero += slot.offset
+		// ramps start at rest, motors in flight stop where they are
+		slot.halt()
+		slot.mu.Unlock()
+		motors = append(motors, m)
+	}
+	c.driver = NewMux(c.clock, motors, map[MotorID]Driver{})
+}
+
+// attachMotor adds motor to driver and motor set, caller holds mu
+func (c *Controller) attachMotor(m Motor, backend Driver) error {
+	if c.driver != nil {
+		a, ok := c.driver.(MotorAttacher)
+		if !ok {
+			return ErrRestartRequired
+		}
+		if err := a.AttachMotor(m, backend); err != nil {
+			return err
+		}
+	} else if backend != nil {
+		return ErrRestartRequired
+	}
+	c.addSlot(m)
+	return nil
+}
+
+// detachMotor stops motor at driver and drops it from motor set, caller
+// holds mu. Slot state is left to caller.
+func (c *Controller) detachMotor(id MotorID) {
+	if c.driver != nil {
+		if err := c.driver.Stop(id); err != nil {
+			log.Printf("Failed to stop removed motor %s: %v", id, err)
+		}
+		if a, ok := c.driver.(MotorAttacher); ok {
+			if err := a.DetachMotor(id); err != nil {
+				log.Printf("Failed to detach motor %s from driver: %v", id, err)
+			}
+		}
+	}
+	delete(c.motors, id)
+	// fresh slice, order may still be read through copies taken under RLock
+	order := make([]*motorSlot, 0, len(c.order))
+	for _, slot := range c.order {
+		if slot.ID != id {
+			order = append(order, slot)
+		}
+	}
+	c.order = order
+}
+
+// fits reports whether motor can take limits of m at its current
+// position, caller holds slot lock
+func (slot *motorSlot) fits(m Motor) error {
+	if slot.Position < m.MinPosition || slot.Position > m.MaxPosition {
+		return fmt.Errorf("%w: current position %.1f outside new limits", ErrPositionOutOfRange, slot.Position)
+	}
+	return nil
+}
+
+// reconfigure installs limits, enabled flag, homing, protection, thermal
+// model and stop profile of m, caller holds slot lock
+func (slot *motorSlot) reconfigure(m Motor) {
+	slot.MaxSpeed = m.MaxSpeed
+	slot.MinPosition = m.MinPosition
+	slot.MaxPosition = m.MaxPosition
+	slot.MaxAcceleration = m.MaxAcceleration
+	slot.MaxJerk = m.MaxJerk
+	slot.Homing = m.Homing
+	slot.cruise = math.Min(slot.cruise, slot.MaxSpeed)
+	if math.Abs(slot.Speed) > slot.MaxSpeed {
+		slot.Speed = math.Copysign(slot.MaxSpeed, slot.Speed)
+	}
+	if !m.IsEnabled && slot.IsEnabled {
+		slot.halt()
+	}
+	slot.IsEnabled = m.IsEnabled
+	slot.Protection = m.Protection
+	slot.guard = protection{}
+	slot.Thermal = m.Thermal
+	slot.Stop = m.Stop
+	if !m.Thermal.enabled() {
+		slot.heat = heat{}
+	}
+}
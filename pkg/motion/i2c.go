@@ -0,0 +1,8 @@
+package motion
+
+// I2CBus is Linux i2c-dev style bus shared by devices at different addresses
+type I2CBus interface {
+	// Write sends data to device at 7-bit address
+	Write(addr uint16, data []byte) error
+	Close() error
+}
@@ -0,0 +1,49 @@
+//go:build linux
+
+package motion
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"syscall"
+)
+
+// i2cSlave is ioctl selecting device address of following transfers
+const i2cSlave = 0x0703
+
+// i2cDev is bus opened through /dev/i2c-N
+type i2cDev struct {
+	mu   sync.Mutex
+	f    *os.File
+	addr int // selected address, -1 before first write
+}
+
+// OpenI2C opens i2c-dev bus like /dev/i2c-1
+func OpenI2C(path string) (I2CBus, error) {
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return nil, err
+	}
+	return &i2cDev{f: f, addr: -1}, nil
+}
+
+func (d *i2cDev) Write(addr uint16, data []byte) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.addr != int(addr) {
+		if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, d.f.Fd(), i2cSlave, uintptr(addr)); errno != 0 {
+			return fmt.Errorf("i2c select 0x%02x: %w", addr, errno)
+		}
+		d.addr = int(addr)
+	}
+	if _, err := d.f.Write(data); err != nil {
+		return fmt.Errorf("i2c write 0x%02x: %w", addr, err)
+	}
+	return nil
+}
+
+func (d *i2cDev) Close() error {
+	return d.f.Close()
+}
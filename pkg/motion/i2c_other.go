@@ -0,0 +1,10 @@
+//go:build !linux
+
+package motion
+
+import "errors"
+
+// OpenI2C opens i2c-dev bus, only available on Linux
+func OpenI2C(path string) (I2CBus, error) {
+	return nil, errors.New("i2c is only supported on linux")
+}
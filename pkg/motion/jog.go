@@ -0,0 +1,123 @@
+package motion
+
+import (
+	"context"
+	"log"
+	"math"
+	"time"
+)
+
+// JogTimeout is how long jog runs without being renewed. UIs repeat Jog
+// while button is held, so lost release or dropped connection stops motor.
+const JogTimeout = 500 * time.Millisecond
+
+// jog is manual velocity command of motor, guarded by slot lock
+type jog struct {
+	until  time.Time // zero when not jogging
+	target float64   // travel limit motor runs towards
+	speed  float64
+}
+
+// Jog drives motor at velocity (degrees/second, sign is direction) towards
+// its travel limit until JogStop, another command, or JogTimeout without
+// Jog being called again. Zero velocity stops jog. Commands recording
+// keeps where jog ended, not jog itself.
+func (c *Controller) Jog(ctx context.Context, id MotorID, velocity float64) error {
+	if velocity == 0 {
+		return c.JogStop(id)
+	}
+	if !c.running.Load() {
+		return ErrShutdown
+	}
+	if !c.loopRunning.Load() {
+		return ErrLoopStopped
+	}
+	if c.held.Load() {
+		return ErrHeld
+	}
+
+	req := commandRequest{cmd: MotorCommand{ID: id, Speed: velocity}, jog: true, result: make(chan error, 1)}
+	select {
+	case c.controlChan <- req:
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-c.done:
+		return ErrShutdown
+	}
+	select {
+	case err := <-req.result:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-c.done:
+		return ErrShutdown
+	}
+}
+
+// JogStop halts jogging motor at once. Motor not jogging is left alone, so
+// late release can't stop move commanded since.
+func (c *Controller) JogStop(id MotorID) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	slot, exists := c.motors[id]
+	if !exists {
+		return &MotorError{Motor: id, Err: ErrMotorNotFound}
+	}
+	slot.mu.Lock()
+	cmd, jogging := c.endJog(slot)
+	slot.mu.Unlock()
+	if jogging {
+		c.record(cmd)
+	}
+	return nil
+}
+
+// jogCommand turns jog request into command towards travel limit, caller
+// holds slot lock
+func (slot *motorSlot) jogCommand(cmd MotorCommand) MotorCommand {
+	lo, hi := slot.Travel()
+	cmd.Position = lo
+	if cmd.Speed > 0 {
+		cmd.Position = hi
+	}
+	cmd.Speed = math.Abs(cmd.Speed)
+	return cmd
+}
+
+// endJog stops motor if it is still jogging and returns command leading
+// to where it stopped, caller holds mu and slot lock
+func (c *Controller) endJog(slot *motorSlot) (MotorCommand, bool) {
+	j := slot.jog
+	slot.jog = jog{}
+	// any other command or stop moved target since
+	if j.until.IsZero() || slot.Target != j.target {
+		return MotorCommand{}, false
+	}
+	slot.halt()
+	c.publish(slot.Motor)
+	if c.driver != nil {
+		if err := c.driver.Stop(slot.ID); err != nil {
+			log.Printf("Failed to stop jogged motor %s: %v", slot.ID, err)
+		}
+	}
+	return MotorCommand{ID: slot.ID, Position: slot.Position, Speed: j.speed}, true
+}
+
+// expireJogs stops jogs not renewed in time, caller holds mu
+func (c *Controller) expireJogs() {
+	now := c.clock.Now()
+	for _, slot := range c.order {
+		slot.mu.Lock()
+		if slot.jog.until.IsZero() || now.Before(slot.jog.until) {
+			slot.mu.Unlock()
+			continue
+		}
+		cmd, jogging := c.endJog(slot)
+		slot.mu.Unlock()
+		if jogging {
+			log.Printf("Jog of motor %s not renewed, stopped", slot.ID)
+			c.record(cmd)
+		}
+	}
+}
@@ -0,0 +1,25 @@
+package motion
+
+import "math"
+
+// SetSpeedLimit caps speed of every motor at fraction of its configured
+// maximum, e.g. 0.5 of user preferences. Zero removes the cap. Applies to
+// commands executed after the call.
+func (c *Controller) SetSpeedLimit(limit float64) {
+	c.speedLimit.Store(math.Float64bits(math.Max(limit, 0)))
+}
+
+// SpeedLimit returns cap set by SetSpeedLimit, zero if none
+func (c *Controller) SpeedLimit() float64 {
+	return math.Float64frombits(c.speedLimit.Load())
+}
+
+// clampSpeed returns magnitude of speed limited by motor maximum and
+// global speed limit
+func (c *Controller) clampSpeed(m Motor, speed float64) float64 {
+	speed = math.Min(math.Abs(speed), m.MaxSpeed)
+	if limit := c.SpeedLimit(); limit > 0 {
+		speed = math.Min(speed, m.MaxSpeed*limit)
+	}
+	return speed
+}
@@ -0,0 +1,245 @@
+package motion
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/sashalind/sex-artifical-intelligence/pkg/clock"
+)
+
+// Mux is driver routing each motor to its own backend, so one controller
+// can mix boards and buses. Motors without backend are emulated as ideal
+// servos moving at commanded speed. Implements MotorAttacher and
+// WatchdogDriver.
+type Mux struct {
+	clock clock.Clock
+
+	mu       sync.Mutex
+	routes   map[MotorID]Driver
+	virtual  map[MotorID]*ramp
+	watchdog time.Duration // armed timeout, zero disarmed
+}
+
+// NewMux creates driver for motors, routes maps motor to its backend
+func NewMux(clk clock.Clock, motors []Motor, routes map[MotorID]Driver) *Mux {
+	clk = clock.OrReal(clk)
+	m := &Mux{
+		clock:   clk,
+		routes:  make(map[MotorID]Driver, len(routes)),
+		virtual: make(map[MotorID]*ramp),
+	}
+	for id, d := range routes {
+		m.routes[id] = d
+	}
+	for _, motor := range motors {
+		if _, ok := routes[motor.ID]; !ok {
+			r := newRamp(motor.Frame.ToShaft(motor.Position), clk.Now())
+			m.virtual[motor.ID] = &r
+		}
+	}
+	return m
+}
+
+// SetTarget forwards to backend of motor
+func (m *Mux) SetTarget(id MotorID, position, speed float64) error {
+	if d, ok := m.backend(id); ok {
+		return d.SetTarget(id, position, speed)
+	}
+	return m.withVirtual(id, func(r *ramp) { r.set(m.clock.Now(), position, speed) })
+}
+
+// ReadState forwards to backend of motor
+func (m *Mux) ReadState(id MotorID) (position, speed float64, err error) {
+	if d, ok := m.backend(id); ok {
+		return d.ReadState(id)
+	}
+	err = m.withVirtual(id, func(r *ramp) {
+		r.advance(m.clock.Now())
+		position, speed = r.position, r.velocity
+	})
+	return position, speed, err
+}
+
+// Stop forwards to backend of motor
+func (m *Mux) Stop(id MotorID) error {
+	if d, ok := m.backend(id); ok {
+		return d.Stop(id)
+	}
+	return m.withVirtual(id, func(r *ramp) { r.stop(m.clock.Now()) })
+}
+
+// SetPower forwards to backend of motor if it can switch power
+func (m *Mux) SetPower(id MotorID, on bool) error {
+	d, _ := m.backend(id)
+	if pd, ok := d.(PowerDriver); ok {
+		return pd.SetPower(id, on)
+	}
+	return nil
+}
+
+// ResetMotor forwards to backend of motor if it can reset it
+func (m *Mux) ResetMotor(id MotorID) error {
+	d, _ := m.backend(id)
+	if rd, ok := d.(ResetDriver); ok {
+		return rd.ResetMotor(id)
+	}
+	return &MotorError{Motor: id, Err: ErrNoReset}
+}
+
+// AtEndstop forwards to backend of motor, ErrNoEndstop if it has no switch
+func (m *Mux) AtEndstop(id MotorID) (bool, error) {
+	d, _ := m.backend(id)
+	if ed, ok := d.(EndstopDriver); ok {
+		return ed.AtEndstop(id)
+	}
+	return false, ErrNoEndstop
+}
+
+// ReadTemperature forwards to backend of motor, ErrNoTemperature if it has
+// no sensor
+func (m *Mux) ReadTemperature(id MotorID) (float64, error) {
+	d, _ := m.backend(id)
+	if td, ok := d.(TemperatureDriver); ok {
+		return td.ReadTemperature(id)
+	}
+	return 0, ErrNoTemperature
+}
+
+// AttachMotor routes new motor to backend, or emulates it when backend is
+// nil
+func (m *Mux) AttachMotor(motor Motor, backend Driver) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, routed := m.routes[motor.ID]
+	_, emulated := m.virtual[motor.ID]
+	if routed || emulated {
+		return &MotorError{Motor: motor.ID, Err: ErrMotorExists}
+	}
+	if backend != nil {
+		// backend new to mux joins armed watchdog
+		if wd, ok := backend.(WatchdogDriver); ok && m.watchdog > 0 && !m.routed(backend) {
+			if err := wd.ArmWatchdog(m.watchdog); err != nil {
+				return &MotorError{Motor: motor.ID, Err: err}
+			}
+		}
+		m.routes[motor.ID] = backend
+		return nil
+	}
+	r := newRamp(motor.Frame.ToShaft(motor.Position), m.clock.Now())
+	m.virtual[motor.ID] = &r
+	return nil
+}
+
+// DetachMotor forgets motor, closing its backend once no other motor uses
+// it
+func (m *Mux) DetachMotor(id MotorID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.virtual, id)
+	d, ok := m.routes[id]
+	if !ok {
+		return nil
+	}
+	delete(m.routes, id)
+	if m.routed(d) {
+		return nil
+	}
+	return d.Close()
+}
+
+// routed reports whether any motor uses backend d, caller holds mu
+func (m *Mux) routed(d Driver) bool {
+	for _, other := range m.routes {
+		if other == d {
+			return true
+		}
+	}
+	return false
+}
+
+// ArmWatchdog arms watchdog of every backend having one, backends attached
+// later are armed too
+func (m *Mux) ArmWatchdog(timeout time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.watchdog = timeout
+	return m.eachWatchdog(func(wd WatchdogDriver) error {
+		return wd.ArmWatchdog(timeout)
+	})
+}
+
+// Pet pets watchdog of every backend having one
+func (m *Mux) Pet() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.eachWatchdog(WatchdogDriver.Pet)
+}
+
+// eachWatchdog runs fn once per backend with watchdog, caller holds mu.
+// Motors sharing backend are few, quadratic dedup keeps tick from
+// allocating.
+func (m *Mux) eachWatchdog(fn func(WatchdogDriver) error) error {
+	var errs []error
+	for id, d := range m.routes {
+		wd, ok := d.(WatchdogDriver)
+		if !ok || m.routedBefore(id, d) {
+			continue
+		}
+		if err := fn(wd); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// routedBefore reports whether motor ordered before id uses backend d
+func (m *Mux) routedBefore(id MotorID, d Driver) bool {
+	for other, od := range m.routes {
+		if od == d && other < id {
+			return true
+		}
+	}
+	return false
+}
+
+// backend returns driver motor is routed to
+func (m *Mux) backend(id MotorID) (Driver, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	d, ok := m.routes[id]
+	return d, ok
+}
+
+// Close closes every backend once
+func (m *Mux) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var errs []error
+	closed := make(map[Driver]bool)
+	for _, d := range m.routes {
+		if closed[d] {
+			continue
+		}
+		closed[d] = true
+		errs = append(errs, d.Close())
+	}
+	return errors.Join(errs...)
+}
+
+func (m *Mux) withVirtual(id MotorID, fn func(r *ramp)) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	r, ok := m.virtual[id]
+	if !ok {
+		return &MotorError{Motor: id, Err: ErrMotorNotFound}
+	}
+	fn(r)
+	return nil
+}
@@ -0,0 +1,363 @@
+package motion
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/sashalind/sex-artifical-intelligence/pkg/clock"
+)
+
+// PCA9685 registers and bits
+const (
+	pcaMode1     = 0x00
+	pcaMode2     = 0x01
+	pcaLED0      = 0x06 // ON_L of channel 0, 4 registers per channel
+	pcaAllLEDOff = 0xFD // ALL_LED_OFF_H
+	pcaPrescale  = 0xFE
+
+	pcaSleep    = 0x10
+	pcaAutoInc  = 0x20
+	pcaRestart  = 0x80
+	pcaOutDrive = 0x04 // totem pole outputs
+	pcaFullOff  = 0x10 // bit 4 of LED_OFF_H
+
+	pcaOscillator = 25e6
+	pcaSteps      = 4096
+	pcaChannels   = 16
+
+	// DefaultPCA9685Address is address of board with no address jumpers
+	DefaultPCA9685Address = 0x40
+	defaultPCA9685Freq    = 50.0
+)
+
+// PCA9685Output maps motor to channel of PCA9685 16-channel PWM board
+type PCA9685Output struct {
+	Motor MotorID
+
+	// Bus is i2c-dev path like /dev/i2c-1, Address is 7-bit board address
+	// (DefaultPCA9685Address if zero)
+	Bus     string
+	Address uint16
+	Channel int
+
+	// Frequency is PWM frequency of board in Hz, 50 if zero. Outputs on one
+	// board must agree.
+	Frequency float64
+
+	// pulse widths at MinPosition and MaxPosition, servo defaults if zero
+	MinPulse time.Duration
+	MaxPulse time.Duration
+
+	MinPosition float64
+	MaxPosition float64
+	Position    float64 // initial position
+}
+
+// withDefaults fills zero address, frequency and timings
+func (o PCA9685Output) withDefaults() PCA9685Output {
+	if o.Address == 0 {
+		o.Address = DefaultPCA9685Address
+	}
+	if o.Frequency == 0 {
+		o.Frequency = defaultPCA9685Freq
+	}
+	if o.MinPulse == 0 {
+		o.MinPulse = defaultServoPulse0
+	}
+	if o.MaxPulse == 0 {
+		o.MaxPulse = defaultServoPulse1
+	}
+	return o
+}
+
+// Validate checks board address, channel and timings
+func (o PCA9685Output) Validate() error {
+	o = o.withDefaults()
+	if o.Bus == "" {
+		return fmt.Errorf("motor %s: pca9685 bus is empty", o.Motor)
+	}
+	if o.Address > 0x7F {
+		return fmt.Errorf("motor %s: pca9685 address 0x%x is not 7-bit", o.Motor, o.Address)
+	}
+	if o.Channel < 0 || o.Channel >= pcaChannels {
+		return fmt.Errorf("motor %s: pca9685 channel must be 0..%d", o.Motor, pcaChannels-1)
+	}
+	// prescale register takes 3..255
+	if o.Frequency < 24 || o.Frequency > 1526 {
+		return fmt.Errorf("motor %s: pca9685 frequency must be 24..1526 Hz", o.Motor)
+	}
+	period := time.Duration(float64(time.Second) / o.Frequency)
+	if o.MinPulse <= 0 || o.MaxPulse <= 0 || o.MinPulse > period || o.MaxPulse > period {
+		return fmt.Errorf("motor %s: pca9685 pulses must be positive and fit in period", o.Motor)
+	}
+	if o.MinPosition >= o.MaxPosition {
+		return fmt.Errorf("motor %s: min position must be below max position", o.Motor)
+	}
+	return nil
+}
+
+// PCA9685Config lists outputs of one driver, boards are set up from them
+type PCA9685Config struct {
+	Outputs []PCA9685Output
+
+	// Open opens I2C bus, OpenI2C if nil
+	Open func(path string) (I2CBus, error)
+}
+
+// pcaBoardKey identifies board
+type pcaBoardKey struct {
+	bus  string
+	addr uint16
+}
+
+// pcaBoard is one set-up board
+type pcaBoard struct {
+	bus    I2CBus
+	addr   uint16
+	period time.Duration // actual period after prescale rounding
+}
+
+// pcaChannel is board channel of one motor
+type pcaChannel struct {
+	PCA9685Output
+	board *pcaBoard
+	ramp  ramp
+	ticks int // last written off count, -1 while off
+}
+
+// PCA9685Driver drives hobby servos from PCA9685 boards over I2C. Like
+// PWMDriver it estimates servo position from commanded speed and steps
+// pulse width along on every read. Implements Driver and PowerDriver.
+type PCA9685Driver struct {
+	mu       sync.Mutex
+	clock    clock.Clock
+	buses    map[string]I2CBus
+	boards   map[pcaBoardKey]*pcaBoard
+	channels map[MotorID]*pcaChannel
+	closed   bool
+}
+
+// NewPCA9685Driver opens buses, sets up boards and drives each servo to
+// its initial position
+func NewPCA9685Driver(clk clock.Clock, cfg PCA9685Config) (*PCA9685Driver, error) {
+	if cfg.Open == nil {
+		cfg.Open = OpenI2C
+	}
+	d := &PCA9685Driver{
+		clock:    clock.OrReal(clk),
+		buses:    make(map[string]I2CBus),
+		boards:   make(map[pcaBoardKey]*pcaBoard),
+		channels: make(map[MotorID]*pcaChannel),
+	}
+	if err := d.open(cfg); err != nil {
+		d.Close()
+		return nil, err
+	}
+	return d, nil
+}
+
+func (d *PCA9685Driver) open(cfg PCA9685Config) error {
+	freqs := make(map[pcaBoardKey]float64)
+	used := make(map[pcaBoardKey]map[int]MotorID)
+	for _, o := range cfg.Outputs {
+		if err := o.Validate(); err != nil {
+			return err
+		}
+		o = o.withDefaults()
+		if _, dup := d.channels[o.Motor]; dup {
+			return fmt.Errorf("motor %s: pca9685 output configured twice", o.Motor)
+		}
+		key := pcaBoardKey{o.Bus, o.Address}
+		if f, ok := freqs[key]; ok && f != o.Frequency {
+			return fmt.Errorf("motor %s: frequency %g Hz differs from %g Hz of board 0x%02x on %s", o.Motor, o.Frequency, f, o.Address, o.Bus)
+		}
+		freqs[key] = o.Frequency
+		if used[key] == nil {
+			used[key] = make(map[int]MotorID)
+		}
+		if other, ok := used[key][o.Channel]; ok {
+			return fmt.Errorf("motor %s: channel %d of board 0x%02x on %s taken by %s", o.Motor, o.Channel, o.Address, o.Bus, other)
+		}
+		used[key][o.Channel] = o.Motor
+
+		board, err := d.board(key, o.Frequency, cfg.Open)
+		if err != nil {
+			return &MotorError{Motor: o.Motor, Err: err}
+		}
+		ch := &pcaChannel{
+			PCA9685Output: o,
+			board:         board,
+			ramp:          newRamp(o.Position, d.clock.Now()),
+			ticks:         -1,
+		}
+		if err := d.writeTicks(ch); err != nil {
+			return &MotorError{Motor: o.Motor, Err: err}
+		}
+		d.channels[o.Motor] = ch
+	}
+	return nil
+}
+
+// board returns set-up board, opening bus and programming frequency on
+// first use
+func (d *PCA9685Driver) board(key pcaBoardKey, freq float64, open func(string) (I2CBus, error)) (*pcaBoard, error) {
+	if b, ok := d.boards[key]; ok {
+		return b, nil
+	}
+	bus, ok := d.buses[key.bus]
+	if !ok {
+		var err error
+		if bus, err = open(key.bus); err != nil {
+			return nil, err
+		}
+		d.buses[key.bus] = bus
+	}
+
+	prescale := math.Round(pcaOscillator/(pcaSteps*freq)) - 1
+	b := &pcaBoard{
+		bus:    bus,
+		addr:   key.addr,
+		period: time.Duration(float64(time.Second) * pcaSteps * (prescale + 1) / pcaOscillator),
+	}
+	// prescale is writable only while oscillator sleeps
+	for _, reg := range [][]byte{
+		{pcaAllLEDOff, pcaFullOff},
+		{pcaMode2, pcaOutDrive},
+		{pcaMode1, pcaSleep | pcaAutoInc},
+		{pcaPrescale, byte(prescale)},
+		{pcaMode1, pcaAutoInc},
+	} {
+		if err := bus.Write(key.addr, reg); err != nil {
+			return nil, err
+		}
+	}
+	// oscillator needs 500us to stabilize before restart
+	d.clock.Sleep(500 * time.Microsecond)
+	if err := bus.Write(key.addr, []byte{pcaMode1, pcaRestart | pcaAutoInc}); err != nil {
+		return nil, err
+	}
+	d.boards[key] = b
+	return b, nil
+}
+
+// SetTarget starts servo moving towards position at speed
+func (d *PCA9685Driver) SetTarget(id MotorID, position, speed float64) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	ch, err := d.channel(id)
+	if err != nil {
+		return err
+	}
+	ch.ramp.set(d.clock.Now(), position, speed)
+	return d.writeTicks(ch)
+}
+
+// ReadState advances estimated position, updating pulse width on the way
+func (d *PCA9685Driver) ReadState(id MotorID) (float64, float64, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	ch, err := d.channel(id)
+	if err != nil {
+		return 0, 0, err
+	}
+	ch.ramp.advance(d.clock.Now())
+	if err := d.writeTicks(ch); err != nil {
+		return 0, 0, err
+	}
+	return ch.ramp.position, ch.ramp.velocity, nil
+}
+
+// Stop holds servo at its current position
+func (d *PCA9685Driver) Stop(id MotorID) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	ch, err := d.channel(id)
+	if err != nil {
+		return err
+	}
+	ch.ramp.stop(d.clock.Now())
+	return d.writeTicks(ch)
+}
+
+// SetPower stops pulses of channel when off so servo goes limp, board has
+// no supply switch
+func (d *PCA9685Driver) SetPower(id MotorID, on bool) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	ch, err := d.channel(id)
+	if err != nil {
+		return err
+	}
+	if on {
+		return d.writeTicks(ch)
+	}
+	if err := d.writeChannel(ch, 0, pcaFullOff<<8); err != nil {
+		return err
+	}
+	ch.ticks = -1
+	return nil
+}
+
+// Close turns every output off, puts boards to sleep and closes buses
+func (d *PCA9685Driver) Close() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.closed {
+		return nil
+	}
+	d.closed = true
+
+	var errs []error
+	for _, b := range d.boards {
+		errs = append(errs,
+			b.bus.Write(b.addr, []byte{pcaAllLEDOff, pcaFullOff}),
+			b.bus.Write(b.addr, []byte{pcaMode1, pcaSleep | pcaAutoInc}))
+	}
+	for _, bus := range d.buses {
+		errs = append(errs, bus.Close())
+	}
+	return errors.Join(errs...)
+}
+
+func (d *PCA9685Driver) channel(id MotorID) (*pcaChannel, error) {
+	if d.closed {
+		return nil, errors.New("pca9685 driver closed")
+	}
+	ch, ok := d.channels[id]
+	if !ok {
+		return nil, &MotorError{Motor: id, Err: ErrMotorNotFound}
+	}
+	return ch, nil
+}
+
+// writeTicks writes pulse for estimated position if it changed
+func (d *PCA9685Driver) writeTicks(ch *pcaChannel) error {
+	pulse := servoPulse(ch.ramp.position, ch.MinPosition, ch.MaxPosition, ch.MinPulse, ch.MaxPulse)
+	ticks := int(math.Round(float64(pulse) / float64(ch.board.period) * pcaSteps))
+	ticks = min(ticks, pcaSteps-1)
+	if ticks == ch.ticks {
+		return nil
+	}
+	if err := d.writeChannel(ch, 0, ticks); err != nil {
+		return err
+	}
+	ch.ticks = ticks
+	return nil
+}
+
+// writeChannel sets ON and OFF counts of channel, 12-bit counts with full
+// on/off flag in bit 12
+func (d *PCA9685Driver) writeChannel(ch *pcaChannel, on, off int) error {
+	reg := byte(pcaLED0 + 4*ch.Channel)
+	return ch.board.bus.Write(ch.board.addr, []byte{
+		reg, byte(on), byte(on >> 8), byte(off), byte(off >> 8),
+	})
+}
@@ -0,0 +1,63 @@
+package motion
+
+import "log"
+
+// PowerDown disables every motor and cuts its power if driver supports it.
+// Motors keep position; PowerUp restores enabled flags as they were.
+func (c *Controller) PowerDown() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.poweredDown != nil {
+		return
+	}
+	c.poweredDown = make(map[MotorID]bool, len(c.order))
+
+	pd, _ := c.driver.(PowerDriver)
+	for _, motor := range c.order {
+		motor.mu.Lock()
+		c.poweredDown[motor.ID] = motor.IsEnabled
+		motor.IsEnabled = false
+		motor.halt()
+		c.publish(motor.Motor)
+		motor.mu.Unlock()
+
+		if pd != nil {
+			if err := pd.SetPower(motor.ID, false); err != nil {
+				log.Printf("Failed to power down motor %s: %v", motor.ID, err)
+			}
+		}
+	}
+}
+
+// PowerUp reverses PowerDown
+func (c *Controller) PowerUp() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.poweredDown == nil {
+		return
+	}
+
+	pd, _ := c.driver.(PowerDriver)
+	for _, motor := range c.order {
+		if pd != nil {
+			if err := pd.SetPower(motor.ID, true); err != nil {
+				log.Printf("Failed to power up motor %s: %v", motor.ID, err)
+			}
+		}
+
+		motor.mu.Lock()
+		motor.IsEnabled = c.poweredDown[motor.ID]
+		c.publish(motor.Motor)
+		motor.mu.Unlock()
+	}
+	c.poweredDown = nil
+}
+
+// PoweredDown reports whether motors are powered down
+func (c *Controller) PoweredDown() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.poweredDown != nil
+}
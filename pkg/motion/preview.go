@@ -0,0 +1,138 @@
+package motion
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/sashalind/sex-artifical-intelligence/pkg/clock"
+)
+
+const (
+	// PreviewInterval is time between samples of pattern preview
+	PreviewInterval = 50 * time.Millisecond
+
+	// previewSettle is how long preview follows motors after last command
+	// while they still move
+	previewSettle = 5 * time.Second
+
+	// previewEndless is span previewed of pattern running until stopped
+	previewEndless = 10 * time.Second
+)
+
+// PreviewSample is predicted state of motor
+type PreviewSample struct {
+	At       time.Duration // since pattern start
+	Position float64
+	Velocity float64
+}
+
+// PatternPreview is predicted course of pattern or program
+type PatternPreview struct {
+	Name     string
+	Duration time.Duration // span covered by samples
+	Endless  bool          // pattern runs until stopped, only its start is previewed
+	Motors   map[MotorID][]PreviewSample
+
+	// Err is command that would abort pattern, samples end there; nil if
+	// pattern plays through
+	Err error
+}
+
+// SimulatePattern plays pattern or program on copy of motors as they are
+// now, through same trajectory planner and limits as real run, and returns
+// where each motor it drives would be every PreviewInterval. Nothing moves,
+// patterns already playing are not taken into account.
+func (c *Controller) SimulatePattern(name string) (*PatternPreview, error) {
+	tl, end, err := c.compile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	motors := c.GetMotors()
+	for i := range motors {
+		motors[i].Speed = 0
+	}
+	clk := clock.NewFake(simEpoch)
+	p, err := newController(clk, Config{Motors: motors, Groups: c.GetGroups()}, true)
+	if err != nil {
+		return nil, err
+	}
+	defer p.Shutdown()
+	p.watchdog.Store(0)
+	p.speedLimit.Store(c.speedLimit.Load())
+
+	run := newRun(name, tl, end)
+	preview := &PatternPreview{Name: name, Motors: make(map[MotorID][]PreviewSample, len(run.motors))}
+	horizon := end
+	if end == forever {
+		preview.Endless = true
+		horizon = previewEndless
+	}
+	p.arbMu.Lock()
+	p.begin(run)
+	p.arbMu.Unlock()
+
+	next := 0
+	for at := time.Duration(0); ; at += TickInterval {
+		for next < len(run.commands) && run.commands[next].at <= at {
+			tc := run.commands[next]
+			if err := p.executeCommand(tc.cmd, false); err != nil {
+				preview.Err = fmt.Errorf("at %v: %w", tc.at, err)
+				return preview, nil
+			}
+			next++
+		}
+		if at%PreviewInterval == 0 {
+			p.sample(preview, run.motors, at)
+		}
+		if at >= horizon && (preview.Endless || at >= horizon+previewSettle || p.settled(run.motors)) {
+			if preview.Duration != at {
+				p.sample(preview, run.motors, at)
+			}
+			return preview, nil
+		}
+
+		clk.Advance(TickInterval)
+		if err := p.Tick(); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// sample records state of motors at offset at of preview
+func (c *Controller) sample(preview *PatternPreview, ids []MotorID, at time.Duration) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for _, id := range ids {
+		slot, exists := c.motors[id]
+		if !exists {
+			continue
+		}
+		slot.mu.Lock()
+		preview.Motors[id] = append(preview.Motors[id], PreviewSample{At: at, Position: slot.Position, Velocity: slot.Speed})
+		slot.mu.Unlock()
+	}
+	preview.Duration = at
+}
+
+// settled reports whether motors came to rest at their targets
+func (c *Controller) settled(ids []MotorID) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for _, id := range ids {
+		slot, exists := c.motors[id]
+		if !exists {
+			continue
+		}
+		slot.mu.Lock()
+		moving := slot.Speed != 0 || math.Abs(slot.Target-slot.Position) > 1e-9
+		slot.mu.Unlock()
+		if moving {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,487 @@
+package motion
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sashalind/sex-artifical-intelligence/pkg/core/errs"
+)
+
+// ProgramKind is type of program node
+type ProgramKind string
+
+const (
+	ProgramPattern   ProgramKind = "pattern"   // stored pattern or program by name
+	ProgramSequence  ProgramKind = "sequence"  // children one after another
+	ProgramLoop      ProgramKind = "loop"      // only child Count times
+	ProgramParallel  ProgramKind = "parallel"  // children at once, on disjoint motors
+	ProgramCrossfade ProgramKind = "crossfade" // sequence, each child overlapping previous by Fade
+)
+
+var ErrInvalidProgram = errs.New(errs.InvalidArgument, "invalid pattern program")
+
+// maxProgramSteps bounds commands and waveform segments of one run, loops
+// multiply quickly
+const maxProgramSteps = 10000
+
+// forever is end of run that lasts until stopped
+const forever = time.Duration(math.MaxInt64)
+
+// ProgramNode is step of pattern program
+type ProgramNode struct {
+	Kind     ProgramKind
+	Pattern  string
+	Children []ProgramNode
+	Count    int
+	Fade     time.Duration
+}
+
+// PatternProgram composes movement patterns into one run. ExecutePattern
+// runs it by name like pattern; patterns win when names clash.
+type PatternProgram struct {
+	Name string
+	Root ProgramNode
+}
+
+// Validate checks program structure, referenced patterns are resolved
+// when program runs
+func (p PatternProgram) Validate() error {
+	if p.Name == "" {
+		return fmt.Errorf("%w: empty name", ErrInvalidProgram)
+	}
+	if err := p.Root.validate(); err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrInvalidProgram, p.Name, err)
+	}
+	return nil
+}
+
+func (n ProgramNode) validate() error {
+	switch n.Kind {
+	case ProgramPattern:
+		if n.Pattern == "" {
+			return errors.New("pattern node needs pattern name")
+		}
+		if len(n.Children) > 0 {
+			return errors.New("pattern node can't have children")
+		}
+	case ProgramSequence, ProgramParallel:
+		if len(n.Children) == 0 {
+			return fmt.Errorf("%s needs children", n.Kind)
+		}
+	case ProgramLoop:
+		if len(n.Children) != 1 {
+			return errors.New("loop needs exactly one child")
+		}
+		if n.Count < 1 {
+			return errors.New("loop count must be positive")
+		}
+	case ProgramCrossfade:
+		if len(n.Children) < 2 {
+			return errors.New("crossfade needs at least two children")
+		}
+		if n.Fade <= 0 {
+			return errors.New("crossfade needs positive fade")
+		}
+	default:
+		return fmt.Errorf("unknown node kind %q", n.Kind)
+	}
+	for _, child := range n.Children {
+		if err := child.validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AddProgram validates and registers pattern program
+func (c *Controller) AddProgram(program PatternProgram) error {
+	if err := program.Validate(); err != nil {
+		return err
+	}
+
+	c.patternMu.Lock()
+	defer c.patternMu.Unlock()
+	c.programs[program.Name] = program
+
+	if c.programLib != nil {
+		if err := c.programLib.Put(program.Name, program); err != nil {
+			log.Printf("Failed to persist program %s: %v", program.Name, err)
+		}
+	}
+	return nil
+}
+
+// GetPrograms returns registered pattern programs sorted by name
+func (c *Controller) GetPrograms() []PatternProgram {
+	c.patternMu.RLock()
+	defer c.patternMu.RUnlock()
+
+	programs := make([]PatternProgram, 0, len(c.programs))
+	for _, p := range c.programs {
+		programs = append(programs, p)
+	}
+	sort.Slice(programs, func(i, j int) bool { return programs[i].Name < programs[j].Name })
+	return programs
+}
+
+// timedCommand is pattern command sent at offset from run start
+type timedCommand struct {
+	at  time.Duration
+	cmd MotorCommand
+}
+
+// timeline is flattened pattern or program
+type timeline struct {
+	commands []timedCommand
+	segments []waveSegment
+}
+
+// compiler flattens program tree into timeline, caller holds patternMu
+type compiler struct {
+	c        *Controller
+	tl       timeline
+	placed   int // patterns placed, counts towards maxProgramSteps
+	visiting map[string]bool
+	problems []error
+}
+
+// compile resolves pattern or program name into timeline and its end
+func (c *Controller) compile(name string) (timeline, time.Duration, error) {
+	c.patternMu.RLock()
+	defer c.patternMu.RUnlock()
+
+	_, isPattern := c.patterns[name]
+	_, isProgram := c.programs[name]
+	if !isPattern && !isProgram {
+		return timeline{}, 0, ErrPatternNotFound
+	}
+
+	k := &compiler{c: c, visiting: make(map[string]bool)}
+	end, err := k.node(ProgramNode{Kind: ProgramPattern, Pattern: name}, 0)
+	if err == nil {
+		err = errors.Join(k.problems...)
+	}
+	if err != nil {
+		return timeline{}, 0, err
+	}
+	sort.SliceStable(k.tl.commands, func(i, j int) bool { return k.tl.commands[i].at < k.tl.commands[j].at })
+	return k.tl, end, nil
+}
+
+// node places n at start and returns where it ends
+func (k *compiler) node(n ProgramNode, start time.Duration) (time.Duration, error) {
+	switch n.Kind {
+	case ProgramPattern:
+		return k.named(n.Pattern, start)
+
+	case ProgramSequence, ProgramLoop:
+		count, children := 1, n.Children
+		if n.Kind == ProgramLoop {
+			count = n.Count
+		}
+		t := start
+		for i := 0; i < count*len(children); i++ {
+			child := children[i%len(children)]
+			if t == forever {
+				return 0, fmt.Errorf("%w: pattern running until stopped must come last", ErrInvalidProgram)
+			}
+			end, err := k.node(child, t)
+			if err != nil {
+				return 0, err
+			}
+			t = end
+		}
+		return t, nil
+
+	case ProgramParallel:
+		end := start
+		owner := make(map[MotorID]int)
+		for i, child := range n.Children {
+			commands, segments := len(k.tl.commands), len(k.tl.segments)
+			childEnd, err := k.node(child, start)
+			if err != nil {
+				return 0, err
+			}
+			for _, id := range k.motorsSince(commands, segments) {
+				if j, taken := owner[id]; taken && j != i {
+					return 0, fmt.Errorf("%w: motor %s in more than one parallel branch", ErrInvalidProgram, id)
+				}
+				owner[id] = i
+			}
+			end = max(end, childEnd)
+		}
+		return end, nil
+
+	case ProgramCrossfade:
+		t := start
+		prevStart, prevSegments := start, -1
+		for i, child := range n.Children {
+			childStart := t
+			if i > 0 {
+				if t == forever {
+					return 0, fmt.Errorf("%w: pattern running until stopped must come last", ErrInvalidProgram)
+				}
+				if childStart = t - n.Fade; childStart < prevStart {
+					return 0, fmt.Errorf("%w: fade longer than crossfaded pattern", ErrInvalidProgram)
+				}
+			}
+			segments := len(k.tl.segments)
+			end, err := k.node(child, childStart)
+			if err != nil {
+				return 0, err
+			}
+			if end-childStart < n.Fade {
+				return 0, fmt.Errorf("%w: fade longer than crossfaded pattern", ErrInvalidProgram)
+			}
+			if i > 0 {
+				for j := prevSegments; j < segments; j++ {
+					if s := &k.tl.segments[j]; s.to == t {
+						s.fadeOut = n.Fade
+					}
+				}
+				for j := segments; j < len(k.tl.segments); j++ {
+					if s := &k.tl.segments[j]; s.from == childStart {
+						s.fadeIn = n.Fade
+					}
+				}
+			}
+			prevStart, prevSegments, t = childStart, segments, end
+		}
+		return t, nil
+	}
+	return 0, fmt.Errorf("%w: unknown node kind %q", ErrInvalidProgram, n.Kind)
+}
+
+// named places stored pattern, or program expanded in place, at start
+func (k *compiler) named(name string, start time.Duration) (time.Duration, error) {
+	if pattern, ok := k.c.patterns[name]; ok {
+		return k.pattern(pattern, start)
+	}
+	program, ok := k.c.programs[name]
+	if !ok {
+		return 0, fmt.Errorf("%w: %s", ErrPatternNotFound, name)
+	}
+	if k.visiting[name] {
+		return 0, fmt.Errorf("%w: %s includes itself", ErrInvalidProgram, name)
+	}
+	k.visiting[name] = true
+	defer delete(k.visiting, name)
+	return k.node(program.Root, start)
+}
+
+// pattern places commands and waveforms of pattern at start
+func (k *compiler) pattern(p MovementPattern, start time.Duration) (time.Duration, error) {
+	k.placed++
+	if k.placed+len(k.tl.commands)+len(p.Commands)+len(k.tl.segments)+len(p.Waveforms) > maxProgramSteps {
+		return 0, fmt.Errorf("%w: more than %d steps", ErrInvalidProgram, maxProgramSteps)
+	}
+
+	end := start + p.Duration
+	if len(p.Waveforms) > 0 && p.Duration == 0 {
+		end = forever
+	}
+	if len(p.Offsets) > 0 && len(p.Offsets) == len(p.Commands) {
+		for i, cmd := range p.Commands {
+			k.tl.commands = append(k.tl.commands, timedCommand{at: start + p.Offsets[i], cmd: cmd})
+		}
+	} else if len(p.Commands) > 0 {
+		step := p.Duration / time.Duration(len(p.Commands))
+		for i, cmd := range p.Commands {
+			k.tl.commands = append(k.tl.commands, timedCommand{at: start + time.Duration(i)*step, cmd: cmd})
+		}
+	}
+	for _, w := range p.Waveforms {
+		if err := k.c.checkWaveform(w); err != nil {
+			k.problems = append(k.problems, err)
+			continue
+		}
+		k.tl.segments = append(k.tl.segments, waveSegment{wave: w, from: start, to: end})
+	}
+	return end, nil
+}
+
+// motorsSince lists motors of timeline entries added after given lengths
+func (k *compiler) motorsSince(commands, segments int) []MotorID {
+	var ids []MotorID
+	for _, tc := range k.tl.commands[commands:] {
+		ids = append(ids, tc.cmd.ID)
+	}
+	for _, s := range k.tl.segments[segments:] {
+		ids = append(ids, s.wave.Motor)
+	}
+	return ids
+}
+
+// checkWaveform validates waveform against motor limits
+func (c *Controller) checkWaveform(w Waveform) error {
+	if err := w.Validate(); err != nil {
+		return err
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	slot, exists := c.motors[w.Motor]
+	if !exists {
+		return &MotorError{Motor: w.Motor, Err: ErrMotorNotFound}
+	}
+	slot.mu.Lock()
+	defer slot.mu.Unlock()
+	if w.Center-w.Amplitude < slot.MinPosition || w.Center+w.Amplitude > slot.MaxPosition {
+		return &MotorError{Motor: w.Motor, Err: ErrPositionOutOfRange}
+	}
+	return nil
+}
+
+// patternRun is pattern or program being played or waiting for its
+// motors
+type patternRun struct {
+	name     string
+	start    time.Time // set when admitted
+	end      time.Duration
+	commands []timedCommand
+	waves    [][]waveSegment // by motor, for tickWaves
+	motors   []MotorID       // sorted, for arbitration
+	sent     atomic.Int64
+
+	admitted chan struct{}
+	once     sync.Once
+	stopped  chan struct{}
+}
+
+func (r *patternRun) stop() {
+	r.once.Do(func() { close(r.stopped) })
+}
+
+// PatternProgress describes running pattern or program
+type PatternProgress struct {
+	Name     string
+	Elapsed  time.Duration
+	Duration time.Duration // zero when it runs until stopped
+	Step     int           // commands sent so far
+	Steps    int
+	Motors   []MotorID // motors pattern drives
+	Queued   bool      // waiting for motors, see Arbitration
+}
+
+// Progress reports pattern or program started last of those playing,
+// false if none. Runs lists all of them.
+func (c *Controller) Progress() (PatternProgress, bool) {
+	c.arbMu.Lock()
+	defer c.arbMu.Unlock()
+
+	runs := c.playing()
+	if len(runs) == 0 {
+		return PatternProgress{}, false
+	}
+	return c.progress(runs[len(runs)-1]), true
+}
+
+// progress describes playing run, caller holds arbMu
+func (c *Controller) progress(run *patternRun) PatternProgress {
+	p := PatternProgress{
+		Name:    run.name,
+		Elapsed: c.clock.Since(run.start),
+		Step:    int(run.sent.Load()),
+		Steps:   len(run.commands),
+		Motors:  run.motors,
+	}
+	if run.end != forever {
+		p.Duration = run.end
+		p.Elapsed = min(p.Elapsed, run.end)
+	}
+	return p
+}
+
+// startRun plays timeline in background once arbitration admits it
+func (c *Controller) startRun(ctx context.Context, name string, tl timeline, end time.Duration) error {
+	run := newRun(name, tl, end)
+	if err := c.admit(run); err != nil {
+		return err
+	}
+	go c.play(ctx, run)
+	return nil
+}
+
+// newRun prepares timeline for playing
+func newRun(name string, tl timeline, end time.Duration) *patternRun {
+	run := &patternRun{
+		name:     name,
+		end:      end,
+		commands: tl.commands,
+		motors:   runMotors(tl),
+		admitted: make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}
+	byMotor := make(map[MotorID]int)
+	for _, s := range tl.segments {
+		i, ok := byMotor[s.wave.Motor]
+		if !ok {
+			i = len(run.waves)
+			byMotor[s.wave.Motor] = i
+			run.waves = append(run.waves, nil)
+		}
+		run.waves[i] = append(run.waves[i], s)
+	}
+	return run
+}
+
+// play waits until run is admitted, sends its commands on time and
+// retires it at its end
+func (c *Controller) play(ctx context.Context, run *patternRun) {
+	defer c.retire(run)
+
+	select {
+	case <-run.admitted:
+	case <-ctx.Done():
+		log.Printf("Queued pattern %s cancelled", run.name)
+		return
+	case <-run.stopped:
+		return
+	case <-c.done:
+		return
+	}
+	for _, tc := range run.commands {
+		if !c.running.Load() {
+			return
+		}
+		if !c.waitRun(ctx, run, tc.at) {
+			return
+		}
+		if err := c.ExecuteCommand(ctx, tc.cmd); err != nil {
+			log.Printf("Pattern %s aborted: %v", run.name, err)
+			return
+		}
+		run.sent.Add(1)
+	}
+	c.waitRun(ctx, run, run.end)
+}
+
+// waitRun waits until offset at of run, false if run ended meanwhile
+func (c *Controller) waitRun(ctx context.Context, run *patternRun, at time.Duration) bool {
+	var due <-chan time.Time
+	if at != forever {
+		wait := at - c.clock.Since(run.start)
+		if wait <= 0 {
+			return true
+		}
+		due = c.clock.After(wait)
+	}
+	select {
+	case <-due:
+		return true
+	case <-ctx.Done():
+		log.Printf("Pattern %s cancelled", run.name)
+	case <-run.stopped:
+	case <-c.done:
+	}
+	return false
+}
+
+
@@ -0,0 +1,182 @@
+package motion
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"github.com/sashalind/sex-artifical-intelligence/pkg/core/errs"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/core/event"
+)
+
+// ProtectAction is what controller does with stalled or overloaded motor
+type ProtectAction string
+
+const (
+	ProtectSlow    ProtectAction = "slow"    // scale speed down, disable if it happens again
+	ProtectStop    ProtectAction = "stop"    // halt motor where it is
+	ProtectDisable ProtectAction = "disable" // halt and disable motor until reconfigured
+)
+
+// FaultKind tells what protection detected
+type FaultKind string
+
+const (
+	FaultStall    FaultKind = "stall"    // motor makes no progress towards target
+	FaultOverload FaultKind = "overload" // driver reports load above limit
+	FaultOverheat FaultKind = "overheat" // thermal model or sensor reached limit
+)
+
+var ErrMotorFault = errs.New(errs.Unavailable, "motor fault")
+
+// defaultSlowFactor scales speed of motor protected by slowing down
+const defaultSlowFactor = 0.5
+
+// Protection detects stalled and overloaded motors. Stall detection needs
+// position feedback, overload detection a driver implementing LoadDriver.
+// Zero StallTime or MaxLoad turns that check off.
+type Protection struct {
+	StallTime    time.Duration // no progress towards target this long is stall
+	MaxLoad      float64       // fraction of rated load, e.g. 1.2
+	OverloadTime time.Duration // load above MaxLoad this long is overload
+	Action       ProtectAction // empty is stop
+	SlowFactor   float64       // speed scale of slow action, zero is 0.5
+}
+
+// validate checks protection config of motor m
+func (p Protection) validate(m Motor) error {
+	if p.StallTime < 0 || p.OverloadTime < 0 || p.MaxLoad < 0 {
+		return fmt.Errorf("motor %s: protection limits must not be negative", m.ID)
+	}
+	switch p.Action {
+	case "", ProtectSlow, ProtectStop, ProtectDisable:
+	default:
+		return fmt.Errorf("motor %s: unknown protection action %q", m.ID, p.Action)
+	}
+	if p.SlowFactor < 0 || p.SlowFactor >= 1 {
+		return fmt.Errorf("motor %s: protection slow factor must be within 0..1", m.ID)
+	}
+	return nil
+}
+
+// MotorFault is published when protection acts on motor
+type MotorFault struct {
+	Motor    MotorID
+	Kind     FaultKind
+	Action   ProtectAction
+	Position float64
+	Target   float64
+	Load     float64
+	Time     time.Time
+}
+
+// TopicMotorFault carries stall and overload faults
+var TopicMotorFault = event.NewTopic[MotorFault]("motor.fault")
+
+// protection is detection state of motor, guarded by slot lock
+type protection struct {
+	progressAt time.Time // last time motor got closer to target
+	bestDist   float64   // distance to target then
+	overAt     time.Time // load above limit since, zero when below
+	slowed     float64   // speed scale after slow action, zero is none
+}
+
+// motorSpeed is clampSpeed of motor slowed down by protection and heat,
+// caller holds slot lock
+func (c *Controller) motorSpeed(slot *motorSlot, speed float64) float64 {
+	speed = c.clampSpeed(slot.Motor, speed) * slot.throttle()
+	if slot.guard.slowed > 0 {
+		speed *= slot.guard.slowed
+	}
+	return speed
+}
+
+// protect checks feedback of motor read this tick and acts on fault,
+// caller holds mu and slot lock
+func (c *Controller) protect(slot *motorSlot, load float64, hasLoad bool) {
+	p := slot.Protection
+	if p.StallTime == 0 && (p.MaxLoad == 0 || !hasLoad) {
+		return
+	}
+	now := c.clock.Now()
+	g := &slot.guard
+
+	var kind FaultKind
+	if p.StallTime > 0 {
+		dist := math.Abs(slot.Target - slot.Position)
+		switch {
+		case !slot.IsEnabled || slot.homing || slot.Frame.Degrees(dist) <= reachTolerance:
+			g.progressAt = time.Time{}
+		case g.progressAt.IsZero() || slot.Frame.Degrees(g.bestDist-dist) > stallTolerance:
+			g.progressAt, g.bestDist = now, dist
+		case now.Sub(g.progressAt) >= p.StallTime:
+			kind = FaultStall
+		}
+	}
+	if hasLoad && p.MaxLoad > 0 && kind == "" {
+		switch {
+		case load <= p.MaxLoad:
+			g.overAt = time.Time{}
+		case g.overAt.IsZero():
+			g.overAt = now
+		case now.Sub(g.overAt) >= p.OverloadTime:
+			kind = FaultOverload
+		}
+	}
+	if kind == "" {
+		return
+	}
+
+	action := p.Action
+	if action == "" {
+		action = ProtectStop
+	}
+	if action == ProtectSlow && g.slowed > 0 {
+		// slowing down did not help
+		action = ProtectDisable
+	}
+	fault := MotorFault{
+		Motor:    slot.ID,
+		Kind:     kind,
+		Action:   action,
+		Position: slot.Position,
+		Target:   slot.Target,
+		Load:     load,
+		Time:     now,
+	}
+	c.applyProtection(slot, action)
+	*g = protection{slowed: g.slowed}
+	if action == ProtectSlow {
+		g.slowed = p.SlowFactor
+		if g.slowed == 0 {
+			g.slowed = defaultSlowFactor
+		}
+		// carry on to same target at lower speed
+		if err := c.driveTo(slot, slot.Target, c.motorSpeed(slot, slot.cruise)); err != nil {
+			c.lastErr.Set(&MotorError{Motor: slot.ID, Err: err})
+		}
+	}
+
+	log.Printf("Motor %s %s at %.1f (target %.1f), protection: %s", slot.ID, kind, fault.Position, fault.Target, action)
+	c.lastErr.Set(&MotorError{Motor: slot.ID, Err: fmt.Errorf("%w: %s", ErrMotorFault, kind)})
+	event.Publish(c.bus.Load(), TopicMotorFault, fault)
+	c.publish(slot.Motor)
+}
+
+// applyProtection stops motor as action requires, caller holds slot lock
+func (c *Controller) applyProtection(slot *motorSlot, action ProtectAction) {
+	if action == ProtectSlow {
+		return
+	}
+	slot.halt()
+	if action == ProtectDisable {
+		slot.IsEnabled = false
+	}
+	if c.driver == nil {
+		return
+	}
+	if err := c.driver.Stop(slot.ID); err != nil {
+		c.lastErr.Set(&MotorError{Motor: slot.ID, Err: err})
+	}
+}
@@ -0,0 +1,328 @@
+package motion
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/sashalind/sex-artifical-intelligence/pkg/clock"
+)
+
+// DefaultSysfsRoot is where Linux exposes PWM and GPIO class devices
+const DefaultSysfsRoot = "/sys/class"
+
+// hobby servo defaults: 50 Hz frame, 1-2 ms pulse over full travel
+const (
+	defaultPWMPeriod   = 20 * time.Millisecond
+	defaultServoPulse0 = time.Millisecond
+	defaultServoPulse1 = 2 * time.Millisecond
+)
+
+// PWMOutput maps motor to Linux PWM channel driving hobby servo
+type PWMOutput struct {
+	Motor   MotorID
+	Chip    int // N of /sys/class/pwm/pwmchipN
+	Channel int
+
+	// Period is PWM frame length, MinPulse and MaxPulse are pulse widths
+	// at MinPosition and MaxPosition. Zero values take servo defaults.
+	Period   time.Duration
+	MinPulse time.Duration
+	MaxPulse time.Duration
+
+	MinPosition float64
+	MaxPosition float64
+	Position    float64 // initial position, servo is driven there on start
+
+	// PowerGPIO is GPIO line switching servo supply, nil if there is none
+	PowerGPIO *int
+}
+
+// withDefaults fills zero timings
+func (o PWMOutput) withDefaults() PWMOutput {
+	if o.Period == 0 {
+		o.Period = defaultPWMPeriod
+	}
+	if o.MinPulse == 0 {
+		o.MinPulse = defaultServoPulse0
+	}
+	if o.MaxPulse == 0 {
+		o.MaxPulse = defaultServoPulse1
+	}
+	return o
+}
+
+// Validate checks channel and timings
+func (o PWMOutput) Validate() error {
+	o = o.withDefaults()
+	if o.Chip < 0 || o.Channel < 0 {
+		return fmt.Errorf("motor %s: pwm chip and channel must not be negative", o.Motor)
+	}
+	if o.MinPulse <= 0 || o.MaxPulse <= 0 || o.MinPulse > o.Period || o.MaxPulse > o.Period {
+		return fmt.Errorf("motor %s: pwm pulses must be positive and fit in period", o.Motor)
+	}
+	if o.MinPosition >= o.MaxPosition {
+		return fmt.Errorf("motor %s: min position must be below max position", o.Motor)
+	}
+	if o.PowerGPIO != nil && *o.PowerGPIO < 0 {
+		return fmt.Errorf("motor %s: power gpio must not be negative", o.Motor)
+	}
+	return nil
+}
+
+// pulse returns pulse width commanding position
+func (o PWMOutput) pulse(position float64) time.Duration {
+	return servoPulse(position, o.MinPosition, o.MaxPosition, o.MinPulse, o.MaxPulse)
+}
+
+// servoPulse maps position within min..max linearly to pulse width
+func servoPulse(position, minPos, maxPos float64, minPulse, maxPulse time.Duration) time.Duration {
+	frac := (position - minPos) / (maxPos - minPos)
+	frac = math.Max(0, math.Min(1, frac))
+	return minPulse + time.Duration(frac*float64(maxPulse-minPulse))
+}
+
+// PWMConfig lists PWM outputs of one driver
+type PWMConfig struct {
+	// Root is sysfs class directory, DefaultSysfsRoot if empty
+	Root    string
+	Outputs []PWMOutput
+}
+
+// pwmChannel is exported PWM channel of one motor
+type pwmChannel struct {
+	PWMOutput
+	dir  string
+	ramp ramp
+	duty time.Duration // last written, -1 before first write
+}
+
+// PWMDriver drives hobby servos from Linux PWM channels through sysfs.
+// Servos give no feedback, so position is estimated from commanded speed
+// and pulse width is stepped along that estimate on every read, which
+// control loop does each tick. Implements Driver and PowerDriver.
+type PWMDriver struct {
+	mu       sync.Mutex
+	clock    clock.Clock
+	root     string
+	channels map[MotorID]*pwmChannel
+	closed   bool
+}
+
+// NewPWMDriver exports and enables configured channels, driving each servo
+// to its initial position and switching its power on
+func NewPWMDriver(clk clock.Clock, cfg PWMConfig) (*PWMDriver, error) {
+	clk = clock.OrReal(clk)
+	if cfg.Root == "" {
+		cfg.Root = DefaultSysfsRoot
+	}
+
+	d := &PWMDriver{
+		clock:    clk,
+		root:     cfg.Root,
+		channels: make(map[MotorID]*pwmChannel),
+	}
+	for _, o := range cfg.Outputs {
+		if err := o.Validate(); err != nil {
+			d.Close()
+			return nil, err
+		}
+		if _, dup := d.channels[o.Motor]; dup {
+			d.Close()
+			return nil, fmt.Errorf("motor %s: pwm output configured twice", o.Motor)
+		}
+		ch, err := d.open(o.withDefaults())
+		if err != nil {
+			d.Close()
+			return nil, &MotorError{Motor: o.Motor, Err: err}
+		}
+		d.channels[o.Motor] = ch
+	}
+	return d, nil
+}
+
+// open exports channel and starts output at initial position
+func (d *PWMDriver) open(o PWMOutput) (*pwmChannel, error) {
+	chip := filepath.Join(d.root, "pwm", fmt.Sprintf("pwmchip%d", o.Chip))
+	ch := &pwmChannel{
+		PWMOutput: o,
+		dir:       filepath.Join(chip, fmt.Sprintf("pwm%d", o.Channel)),
+		ramp:      newRamp(o.Position, d.clock.Now()),
+		duty:      -1,
+	}
+
+	if _, err := os.Stat(ch.dir); errors.Is(err, os.ErrNotExist) {
+		if err := writeSysfs(filepath.Join(chip, "export"), strconv.Itoa(o.Channel)); err != nil {
+			return nil, err
+		}
+	}
+	// duty must not exceed period while period changes, so clear it first
+	if err := writeSysfs(filepath.Join(ch.dir, "duty_cycle"), "0"); err != nil {
+		return nil, err
+	}
+	if err := writeSysfs(filepath.Join(ch.dir, "period"), nanos(o.Period)); err != nil {
+		return nil, err
+	}
+	if err := d.writeDuty(ch); err != nil {
+		return nil, err
+	}
+	if err := writeSysfs(filepath.Join(ch.dir, "enable"), "1"); err != nil {
+		return nil, err
+	}
+	if o.PowerGPIO != nil {
+		if err := d.setGPIO(*o.PowerGPIO, true); err != nil {
+			return nil, err
+		}
+	}
+	return ch, nil
+}
+
+// SetTarget starts servo moving towards position at speed
+func (d *PWMDriver) SetTarget(id MotorID, position, speed float64) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	ch, err := d.channel(id)
+	if err != nil {
+		return err
+	}
+	ch.ramp.set(d.clock.Now(), position, speed)
+	return d.writeDuty(ch)
+}
+
+// ReadState advances estimated position, updating pulse width on the way
+func (d *PWMDriver) ReadState(id MotorID) (float64, float64, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	ch, err := d.channel(id)
+	if err != nil {
+		return 0, 0, err
+	}
+	ch.ramp.advance(d.clock.Now())
+	if err := d.writeDuty(ch); err != nil {
+		return 0, 0, err
+	}
+	return ch.ramp.position, ch.ramp.velocity, nil
+}
+
+// Stop holds servo at its current position
+func (d *PWMDriver) Stop(id MotorID) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	ch, err := d.channel(id)
+	if err != nil {
+		return err
+	}
+	ch.ramp.stop(d.clock.Now())
+	return d.writeDuty(ch)
+}
+
+// SetPower switches servo supply and pulse output, unpowered servo goes limp
+func (d *PWMDriver) SetPower(id MotorID, on bool) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	ch, err := d.channel(id)
+	if err != nil {
+		return err
+	}
+	enable := "0"
+	if on {
+		enable = "1"
+	}
+	if err := writeSysfs(filepath.Join(ch.dir, "enable"), enable); err != nil {
+		return err
+	}
+	if ch.PowerGPIO != nil {
+		return d.setGPIO(*ch.PowerGPIO, on)
+	}
+	return nil
+}
+
+// Close disables outputs, switches power off and unexports channels
+func (d *PWMDriver) Close() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.closed {
+		return nil
+	}
+	d.closed = true
+
+	var errs []error
+	for _, ch := range d.channels {
+		errs = append(errs, writeSysfs(filepath.Join(ch.dir, "enable"), "0"))
+		if ch.PowerGPIO != nil {
+			errs = append(errs, d.setGPIO(*ch.PowerGPIO, false))
+		}
+		chip := filepath.Dir(ch.dir)
+		errs = append(errs, writeSysfs(filepath.Join(chip, "unexport"), strconv.Itoa(ch.Channel)))
+	}
+	return errors.Join(errs...)
+}
+
+func (d *PWMDriver) channel(id MotorID) (*pwmChannel, error) {
+	if d.closed {
+		return nil, errors.New("pwm driver closed")
+	}
+	ch, ok := d.channels[id]
+	if !ok {
+		return nil, &MotorError{Motor: id, Err: ErrMotorNotFound}
+	}
+	return ch, nil
+}
+
+// writeDuty writes pulse for estimated position if it changed
+func (d *PWMDriver) writeDuty(ch *pwmChannel) error {
+	duty := ch.pulse(ch.ramp.position)
+	if duty == ch.duty {
+		return nil
+	}
+	if err := writeSysfs(filepath.Join(ch.dir, "duty_cycle"), nanos(duty)); err != nil {
+		return err
+	}
+	ch.duty = duty
+	return nil
+}
+
+// setGPIO drives output line, exporting it on first use
+func (d *PWMDriver) setGPIO(line int, on bool) error {
+	dir := filepath.Join(d.root, "gpio", fmt.Sprintf("gpio%d", line))
+	if _, err := os.Stat(dir); errors.Is(err, os.ErrNotExist) {
+		if err := writeSysfs(filepath.Join(d.root, "gpio", "export"), strconv.Itoa(line)); err != nil {
+			return err
+		}
+		if err := writeSysfs(filepath.Join(dir, "direction"), "out"); err != nil {
+			return err
+		}
+	}
+	value := "0"
+	if on {
+		value = "1"
+	}
+	return writeSysfs(filepath.Join(dir, "value"), value)
+}
+
+// writeSysfs writes attribute file, sysfs attributes must not be created
+func writeSysfs(path, value string) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_TRUNC, 0)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(value); err != nil {
+		f.Close()
+		return fmt.Errorf("write %s: %w", path, err)
+	}
+	return f.Close()
+}
+
+func nanos(d time.Duration) string {
+	return strconv.FormatInt(d.Nanoseconds(), 10)
+}
@@ -0,0 +1,53 @@
+package motion
+
+import (
+	"math"
+	"time"
+)
+
+// ramp estimates position of open-loop motor moving towards target at
+// commanded speed, for hardware that gives no position feedback
+type ramp struct {
+	position float64
+	velocity float64
+	target   float64
+	speed    float64
+	last     time.Time
+}
+
+func newRamp(position float64, now time.Time) ramp {
+	return ramp{position: position, target: position, last: now}
+}
+
+// advance moves estimate up to now
+func (r *ramp) advance(now time.Time) {
+	dt := now.Sub(r.last).Seconds()
+	r.last = now
+
+	distance := r.target - r.position
+	if distance == 0 || r.speed == 0 {
+		r.velocity = 0
+		return
+	}
+	if step := r.speed * dt; math.Abs(distance) <= step {
+		r.position = r.target
+		r.velocity = 0
+		return
+	}
+	r.velocity = math.Copysign(r.speed, distance)
+	r.position += r.velocity * dt
+}
+
+// set starts move towards target
+func (r *ramp) set(now time.Time, target, speed float64) {
+	r.advance(now)
+	r.target = target
+	r.speed = math.Abs(speed)
+}
+
+// stop holds current position
+func (r *ramp) stop(now time.Time) {
+	r.advance(now)
+	r.target = r.position
+	r.velocity = 0
+}
@@ -0,0 +1,203 @@
+package motion
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"github.com/sashalind/sex-artifical-intelligence/pkg/core/errs"
+)
+
+// RecordSource is what recording captures
+type RecordSource string
+
+const (
+	RecordCommands RecordSource = "commands" // commands motors accept, e.g. manual jog
+	RecordFeedback RecordSource = "feedback" // positions motors report, e.g. moved by hand
+)
+
+var (
+	ErrRecording      = errs.New(errs.FailedPrecondition, "already recording")
+	ErrNotRecording   = errs.New(errs.FailedPrecondition, "not recording")
+	ErrEmptyRecording = errs.New(errs.FailedPrecondition, "recording captured no movement")
+)
+
+// recordThreshold is how far motor must move, degrees, before feedback
+// recording captures new point
+const recordThreshold = 0.5
+
+// recording collects timed commands until StopRecording turns them into
+// pattern, guarded by recMu
+type recording struct {
+	source   RecordSource
+	start    time.Time
+	origin   map[MotorID]MotorCommand // where motors were when recording started
+	tracks   map[MotorID]*recordTrack
+	commands []MotorCommand
+	offsets  []time.Duration
+	full     bool
+}
+
+// recordTrack follows one motor during feedback recording
+type recordTrack struct {
+	pos   float64       // last captured position
+	at    time.Duration // when it was captured
+	prev  float64       // position at previous tick
+	still time.Duration // last tick motor was not moving
+}
+
+// StartRecording starts capturing motor movement into pattern. Commands
+// source captures every command motors accept, including pattern steps;
+// feedback source samples reported positions each tick.
+func (c *Controller) StartRecording(source RecordSource) error {
+	if source != RecordCommands && source != RecordFeedback {
+		return errs.New(errs.InvalidArgument, fmt.Sprintf("unknown record source %q", source))
+	}
+
+	rec := &recording{
+		source: source,
+		start:  c.clock.Now(),
+		origin: make(map[MotorID]MotorCommand),
+		tracks: make(map[MotorID]*recordTrack),
+	}
+	c.mu.RLock()
+	for _, slot := range c.order {
+		slot.mu.Lock()
+		rec.origin[slot.ID] = MotorCommand{ID: slot.ID, Position: slot.Position, Speed: slot.MaxSpeed}
+		rec.tracks[slot.ID] = &recordTrack{pos: slot.Position, prev: slot.Position}
+		slot.mu.Unlock()
+	}
+	c.mu.RUnlock()
+
+	c.recMu.Lock()
+	defer c.recMu.Unlock()
+	if c.rec != nil {
+		return ErrRecording
+	}
+	c.rec = rec
+	return nil
+}
+
+// Recording reports whether recording is in progress
+func (c *Controller) Recording() bool {
+	c.recMu.Lock()
+	defer c.recMu.Unlock()
+	return c.rec != nil
+}
+
+// StopRecording ends recording and saves captured movement as pattern
+// under name. Replay starts by moving recorded motors back to where they
+// were when recording started, then repeats captured steps on their
+// original timing.
+func (c *Controller) StopRecording(name string) (MovementPattern, error) {
+	c.recMu.Lock()
+	rec := c.rec
+	c.rec = nil
+	c.recMu.Unlock()
+
+	if rec == nil {
+		return MovementPattern{}, ErrNotRecording
+	}
+	if name == "" {
+		return MovementPattern{}, errs.New(errs.InvalidArgument, "recording needs pattern name")
+	}
+	if len(rec.commands) == 0 {
+		return MovementPattern{}, ErrEmptyRecording
+	}
+
+	pattern := MovementPattern{
+		Name:     name,
+		Duration: c.clock.Since(rec.start),
+	}
+	seen := make(map[MotorID]bool)
+	for _, cmd := range rec.commands {
+		if origin, ok := rec.origin[cmd.ID]; ok && !seen[cmd.ID] {
+			pattern.Commands = append(pattern.Commands, origin)
+			pattern.Offsets = append(pattern.Offsets, 0)
+		}
+		seen[cmd.ID] = true
+	}
+	pattern.Commands = append(pattern.Commands, rec.commands...)
+	pattern.Offsets = append(pattern.Offsets, rec.offsets...)
+
+	c.AddPattern(pattern)
+	return pattern, nil
+}
+
+// record captures commands accepted by control loop
+func (c *Controller) record(cmds ...MotorCommand) {
+	c.recMu.Lock()
+	defer c.recMu.Unlock()
+	if c.rec == nil || c.rec.source != RecordCommands {
+		return
+	}
+	at := c.clock.Since(c.rec.start)
+	for _, cmd := range cmds {
+		c.rec.add(cmd, at)
+	}
+}
+
+// sampleRecording captures motors that moved since last captured point,
+// caller holds mu
+func (c *Controller) sampleRecording() {
+	c.recMu.Lock()
+	rec := c.rec
+	c.recMu.Unlock()
+	if rec == nil || rec.source != RecordFeedback {
+		return
+	}
+
+	// hardware may report slightly past limits, replay would refuse that
+	positions := make(map[MotorID]float64, len(c.order))
+	maxSpeed := make(map[MotorID]float64, len(c.order))
+	for _, slot := range c.order {
+		slot.mu.Lock()
+		lo, hi := slot.Travel()
+		positions[slot.ID] = math.Max(lo, math.Min(slot.Position, hi))
+		maxSpeed[slot.ID] = slot.MaxSpeed
+		slot.mu.Unlock()
+	}
+
+	c.recMu.Lock()
+	defer c.recMu.Unlock()
+	if c.rec != rec {
+		return
+	}
+	t := c.clock.Since(rec.start)
+	for _, slot := range c.order {
+		tr, ok := rec.tracks[slot.ID]
+		if !ok {
+			continue
+		}
+		pos := positions[slot.ID]
+		if moved := math.Abs(pos - tr.pos); moved >= recordThreshold {
+			// speed over time motor actually moved, not time it sat still
+			dt := t - max(tr.at, tr.still)
+			if dt <= 0 {
+				dt = TickInterval
+			}
+			speed := math.Min(moved/dt.Seconds(), maxSpeed[slot.ID])
+			rec.add(MotorCommand{ID: slot.ID, Position: pos, Speed: speed}, t)
+			tr.pos, tr.at = pos, t
+		}
+		if math.Abs(pos-tr.prev) < arriveTolerance {
+			tr.still = t
+		}
+		tr.prev = pos
+	}
+}
+
+// add appends step unless recording is full, caller holds recMu
+func (r *recording) add(cmd MotorCommand, at time.Duration) {
+	// origins are prepended and pattern counts as step of its own
+	if len(r.commands)+len(r.origin)+1 >= maxProgramSteps {
+		if !r.full {
+			log.Printf("Recording full after %d steps, further movement is dropped", len(r.commands))
+			r.full = true
+		}
+		return
+	}
+	r.commands = append(r.commands, cmd)
+	r.offsets = append(r.offsets, at)
+}
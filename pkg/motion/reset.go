@@ -0,0 +1,30 @@
+package motion
+
+import "github.com/sashalind/sex-artifical-intelligence/pkg/core/errs"
+
+// ErrNoReset is returned when driver of motor can't reset it
+var ErrNoReset = errs.New(errs.FailedPrecondition, "driver can't reset motor")
+
+// ResetMotor stops motor and has driver reboot its electronics, e.g. to
+// clear overload shutdown of smart servo. Control loop keeps running,
+// reads of motor may fail until it is back.
+func (c *Controller) ResetMotor(id MotorID) error {
+	c.mu.RLock()
+	slot, exists := c.motors[id]
+	rd, ok := c.driver.(ResetDriver)
+	if exists {
+		slot.mu.Lock()
+		slot.halt()
+		c.publish(slot.Motor)
+		slot.mu.Unlock()
+	}
+	c.mu.RUnlock()
+
+	if !exists {
+		return &MotorError{Motor: id, Err: ErrMotorNotFound}
+	}
+	if !ok {
+		return &MotorError{Motor: id, Err: ErrNoReset}
+	}
+	return rd.ResetMotor(id)
+}
@@ -0,0 +1,7 @@
+package motion
+
+import "io"
+
+// SerialPort is opened serial line. Reads return what arrived within port
+// read timeout, zero bytes when nothing did.
+type SerialPort = io.ReadWriteCloser
@@ -0,0 +1,49 @@
+//go:build linux
+
+package motion
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// baudRates maps supported rates to termios speed flags
+var baudRates = map[int]uint32{
+	9600:    syscall.B9600,
+	57600:   syscall.B57600,
+	115200:  syscall.B115200,
+	1000000: syscall.B1000000,
+	2000000: syscall.B2000000,
+	3000000: syscall.B3000000,
+	4000000: syscall.B4000000,
+}
+
+// serialReadTimeout is how long read waits for first byte, deciseconds
+const serialReadTimeout = 1
+
+// OpenSerial opens tty in raw 8N1 mode at baud
+func OpenSerial(path string, baud int) (SerialPort, error) {
+	speed, ok := baudRates[baud]
+	if !ok {
+		return nil, fmt.Errorf("unsupported baud rate %d", baud)
+	}
+	f, err := os.OpenFile(path, os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	t := syscall.Termios{
+		Cflag:  syscall.CS8 | syscall.CREAD | syscall.CLOCAL | speed,
+		Ispeed: speed,
+		Ospeed: speed,
+	}
+	t.Cc[syscall.VMIN] = 0
+	t.Cc[syscall.VTIME] = serialReadTimeout
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), syscall.TCSETS, uintptr(unsafe.Pointer(&t))); errno != 0 {
+		f.Close()
+		return nil, fmt.Errorf("configure %s: %w", path, errno)
+	}
+	return f, nil
+}
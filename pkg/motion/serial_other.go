@@ -0,0 +1,10 @@
+//go:build !linux
+
+package motion
+
+import "errors"
+
+// OpenSerial opens tty, only available on Linux
+func OpenSerial(path string, baud int) (SerialPort, error) {
+	return nil, errors.New("serial ports are only supported on linux")
+}
@@ -0,0 +1,361 @@
+package motion
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/sashalind/sex-artifical-intelligence/pkg/clock"
+)
+
+// simEpoch is where fake clock of every simulation starts, so runs repeat
+// to the nanosecond
+var simEpoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// SimDriver is deterministic driver for tests. Motors run straight to
+// target at commanded speed, no inertia, between hard stops at their
+// position limits, with time taken from fake clock only. Faults are
+// injected per motor: failing reads, jammed shaft, load and temperature.
+// Hardware watchdog is emulated too. Implements Driver, EndstopDriver,
+// LoadDriver, TemperatureDriver, WatchdogDriver and MotorAttacher.
+type SimDriver struct {
+	mu     sync.Mutex
+	clock  *clock.Fake
+	motors map[MotorID]*simMotor
+	closed bool
+
+	// watchdog stops every motor once not petted for timeout and refuses
+	// targets until armed again, like servo firmware does
+	watchdog time.Duration
+	petted   time.Time
+	tripped  bool
+}
+
+// simMotor is one motor of SimDriver
+type simMotor struct {
+	ramp
+	min, max float64 // hard stops
+	jammed   bool
+	fail     error
+	load     float64
+	temp     float64 // °C, zero is no sensor
+}
+
+// NewSimDriver creates simulated driver for motors resting at their
+// current positions, on fake clock starting at fixed epoch
+func NewSimDriver(motors []Motor) *SimDriver {
+	d := &SimDriver{
+		clock:  clock.NewFake(simEpoch),
+		motors: make(map[MotorID]*simMotor),
+	}
+	for _, m := range motors {
+		d.motors[m.ID] = d.newMotor(m)
+	}
+	return d
+}
+
+func (d *SimDriver) newMotor(m Motor) *simMotor {
+	m = m.Shaft()
+	return &simMotor{
+		ramp: newRamp(m.Position, d.clock.Now()),
+		min:  m.MinPosition,
+		max:  m.MaxPosition,
+	}
+}
+
+// Clock returns fake clock driving simulation
+func (d *SimDriver) Clock() *clock.Fake {
+	return d.clock
+}
+
+// SetTarget starts move towards position at speed
+func (d *SimDriver) SetTarget(id MotorID, position, speed float64) error {
+	return d.with(id, func(m *simMotor, now time.Time) error {
+		if m.fail != nil {
+			return m.fail
+		}
+		if d.tripped {
+			return ErrWatchdog
+		}
+		m.set(now, position, speed)
+		return nil
+	})
+}
+
+// ReadState returns position and speed motor has at fake now
+func (d *SimDriver) ReadState(id MotorID) (position, speed float64, err error) {
+	err = d.with(id, func(m *simMotor, now time.Time) error {
+		position, speed = m.position, m.velocity
+		return m.fail
+	})
+	return position, speed, err
+}
+
+// Stop holds motor where it is
+func (d *SimDriver) Stop(id MotorID) error {
+	return d.with(id, func(m *simMotor, now time.Time) error {
+		m.stop(now)
+		return nil
+	})
+}
+
+// AtEndstop reports whether motor sits on either hard stop
+func (d *SimDriver) AtEndstop(id MotorID) (bool, error) {
+	var hit bool
+	err := d.with(id, func(m *simMotor, now time.Time) error {
+		hit = m.position <= m.min || m.position >= m.max
+		return m.fail
+	})
+	return hit, err
+}
+
+// ReadLoad returns load set by SetLoad
+func (d *SimDriver) ReadLoad(id MotorID) (float64, error) {
+	var load float64
+	err := d.with(id, func(m *simMotor, now time.Time) error {
+		load = m.load
+		return m.fail
+	})
+	return load, err
+}
+
+// ReadTemperature returns temperature set by SetTemperature,
+// ErrNoTemperature before that
+func (d *SimDriver) ReadTemperature(id MotorID) (float64, error) {
+	var temp float64
+	err := d.with(id, func(m *simMotor, now time.Time) error {
+		if m.fail != nil {
+			return m.fail
+		}
+		if m.temp == 0 {
+			return ErrNoTemperature
+		}
+		temp = m.temp
+		return nil
+	})
+	return temp, err
+}
+
+// Fail makes every call for motor return err, nil heals it
+func (d *SimDriver) Fail(id MotorID, err error) error {
+	return d.with(id, func(m *simMotor, now time.Time) error {
+		m.fail = err
+		return nil
+	})
+}
+
+// Jam freezes motor shaft, it keeps its target but does not move
+func (d *SimDriver) Jam(id MotorID, jammed bool) error {
+	return d.with(id, func(m *simMotor, now time.Time) error {
+		m.jammed = jammed
+		return nil
+	})
+}
+
+// SetLoad sets load motor reports, fraction of rated
+func (d *SimDriver) SetLoad(id MotorID, load float64) error {
+	return d.with(id, func(m *simMotor, now time.Time) error {
+		m.load = load
+		return nil
+	})
+}
+
+// SetTemperature sets temperature motor reports in °C
+func (d *SimDriver) SetTemperature(id MotorID, temp float64) error {
+	return d.with(id, func(m *simMotor, now time.Time) error {
+		m.temp = temp
+		return nil
+	})
+}
+
+// ArmWatchdog arms emulated watchdog, zero disarms it. Also clears watchdog
+// that tripped.
+func (d *SimDriver) ArmWatchdog(timeout time.Duration) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.watchdog = timeout
+	d.petted = d.clock.Now()
+	d.tripped = false
+	return nil
+}
+
+// Pet restarts watchdog timeout
+func (d *SimDriver) Pet() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.expireWatchdog()
+	d.petted = d.clock.Now()
+	return nil
+}
+
+// WatchdogTripped reports whether emulated watchdog stopped motors
+func (d *SimDriver) WatchdogTripped() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.expireWatchdog()
+	return d.tripped
+}
+
+// expireWatchdog stops motors where they were when watchdog ran out,
+// caller holds mu
+func (d *SimDriver) expireWatchdog() {
+	if d.watchdog == 0 || d.tripped {
+		return
+	}
+	expiry := d.petted.Add(d.watchdog)
+	if !d.clock.Now().After(expiry) {
+		return
+	}
+	d.tripped = true
+	for _, m := range d.motors {
+		m.advance(expiry)
+		m.stop(expiry)
+	}
+}
+
+// AttachMotor adds simulated motor, simulation has no backends
+func (d *SimDriver) AttachMotor(m Motor, backend Driver) error {
+	if backend != nil {
+		return errors.New("sim driver can't route hardware backend")
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.motors[m.ID] = d.newMotor(m)
+	return nil
+}
+
+// DetachMotor drops simulated motor
+func (d *SimDriver) DetachMotor(id MotorID) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	delete(d.motors, id)
+	return nil
+}
+
+// Close stops simulation, later calls fail
+func (d *SimDriver) Close() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.closed = true
+	return nil
+}
+
+// with runs fn on motor brought up to fake now
+func (d *SimDriver) with(id MotorID, fn func(m *simMotor, now time.Time) error) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.closed {
+		return errors.New("sim driver closed")
+	}
+	m, ok := d.motors[id]
+	if !ok {
+		return ErrMotorNotFound
+	}
+	d.expireWatchdog()
+	now := d.clock.Now()
+	m.advance(now)
+	return fn(m, now)
+}
+
+// advance moves motor up to now, jammed one stays put and hard stops
+// hold it at limits
+func (m *simMotor) advance(now time.Time) {
+	if m.jammed {
+		m.last = now
+		m.velocity = 0
+		return
+	}
+	m.ramp.advance(now)
+	if m.position < m.min || m.position > m.max {
+		m.position = max(m.min, min(m.position, m.max))
+		m.velocity = 0
+	}
+}
+
+// Sim is controller running on SimDriver. Its control loop does not tick
+// by itself: Step moves fake time and ticks loop once per control period,
+// so tests of patterns, trajectories and interlocks run deterministically
+// and as fast as CPU allows. Commands are processed by loop as usual.
+type Sim struct {
+	*Controller
+	Driver *SimDriver
+	Clock  *clock.Fake
+}
+
+// NewSim creates controller with motor layout on simulated driver
+func NewSim(cfg Config) (*Sim, error) {
+	driver := NewSimDriver(cfg.Motors)
+	c, err := newController(driver.Clock(), cfg, true)
+	if err != nil {
+		return nil, err
+	}
+	c.SetDriver(driver)
+	return &Sim{Controller: c, Driver: driver, Clock: driver.Clock()}, nil
+}
+
+// Step advances simulation by d in control ticks, d is rounded up to
+// whole ticks. Fails with error that stopped control loop, e.g. driver
+// unreadable for too long.
+func (s *Sim) Step(d time.Duration) error {
+	for elapsed := time.Duration(0); elapsed < d; elapsed += TickInterval {
+		if err := s.tick(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// StepUntil steps simulation until cond holds, at most limit of fake time
+func (s *Sim) StepUntil(cond func() bool, limit time.Duration) error {
+	for elapsed := time.Duration(0); !cond(); elapsed += TickInterval {
+		if elapsed >= limit {
+			return ErrMoveTimeout
+		}
+		if err := s.tick(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// tick moves fake time by one control period and waits for loop to tick
+func (s *Sim) tick() error {
+	if !s.running.Load() {
+		return ErrShutdown
+	}
+	s.Clock.Advance(TickInterval)
+	return s.StepTick()
+}
+
+// NewManualController creates controller whose loop never ticks by itself,
+// only on StepTick. Caller moves clk, so simulations sharing fake clock
+// with rest of system tick controller in step with it.
+func NewManualController(clk clock.Clock, cfg Config) (*Controller, error) {
+	return newController(clk, cfg, true)
+}
+
+// StepTick makes control loop tick once at current clock time and waits
+// until tick is done. Controllers of NewManualController tick only this
+// way.
+func (c *Controller) StepTick() error {
+	if !c.running.Load() {
+		return ErrShutdown
+	}
+	if !c.loopRunning.Load() {
+		return ErrLoopStopped
+	}
+	result := make(chan error, 1)
+	select {
+	case c.tickChan <- result:
+	case <-c.done:
+		return ErrShutdown
+	}
+	return <-result
+}
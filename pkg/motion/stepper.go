@@ -0,0 +1,416 @@
+package motion
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/sashalind/sex-artifical-intelligence/pkg/clock"
+)
+
+// step/dir driver defaults: 1.8° motor in full step mode. Steps are bit
+// banged through sysfs, which keeps up with a few thousand per second.
+const (
+	defaultStepsPerRev = 200
+	defaultStepRate    = 1000 // microsteps/second
+	maxMicrosteps      = 256
+)
+
+// StepperOutput maps motor to step/dir stepper driver (A4988, DRV8825,
+// TMC2208 and alike) wired to GPIO lines
+type StepperOutput struct {
+	Motor      MotorID
+	StepGPIO   int
+	DirGPIO    int
+	EnableGPIO *int // active low enable, nil if driver is always enabled
+
+	// StepsPerRev is full steps per shaft turn, Microsteps what driver's
+	// mode pins select. Zero values are 200 and 1.
+	StepsPerRev int
+	Microsteps  int
+
+	// MaxStepRate caps microsteps per second whatever speed is commanded,
+	// zero is 1000
+	MaxStepRate float64
+
+	Reverse  bool    // dir line high turns backwards
+	Position float64 // initial position, degrees
+}
+
+// withDefaults fills zero values
+func (o StepperOutput) withDefaults() StepperOutput {
+	if o.StepsPerRev == 0 {
+		o.StepsPerRev = defaultStepsPerRev
+	}
+	if o.Microsteps == 0 {
+		o.Microsteps = 1
+	}
+	if o.MaxStepRate == 0 {
+		o.MaxStepRate = defaultStepRate
+	}
+	return o
+}
+
+// Validate checks lines and step settings
+func (o StepperOutput) Validate() error {
+	o = o.withDefaults()
+	if o.StepGPIO < 0 || o.DirGPIO < 0 || o.StepGPIO == o.DirGPIO {
+		return fmt.Errorf("motor %s: step and dir gpio must be distinct and not negative", o.Motor)
+	}
+	if e := o.EnableGPIO; e != nil && (*e < 0 || *e == o.StepGPIO || *e == o.DirGPIO) {
+		return fmt.Errorf("motor %s: enable gpio must be distinct and not negative", o.Motor)
+	}
+	if o.StepsPerRev < 0 {
+		return fmt.Errorf("motor %s: steps per revolution must be positive", o.Motor)
+	}
+	if m := o.Microsteps; m < 0 || m > maxMicrosteps || m&(m-1) != 0 {
+		return fmt.Errorf("motor %s: microsteps must be power of two up to %d", o.Motor, maxMicrosteps)
+	}
+	if !(o.MaxStepRate > 0) || math.IsInf(o.MaxStepRate, 0) {
+		return fmt.Errorf("motor %s: max step rate must be positive", o.Motor)
+	}
+	return nil
+}
+
+// degreesPerStep returns shaft degrees of one microstep
+func (o StepperOutput) degreesPerStep() float64 {
+	return 360 / float64(o.StepsPerRev*o.Microsteps)
+}
+
+// StepperConfig lists stepper outputs of one driver
+type StepperConfig struct {
+	// Root is sysfs class directory, DefaultSysfsRoot if empty
+	Root    string
+	Outputs []StepperOutput
+}
+
+// stepper is one motor with its pulse generator
+type stepper struct {
+	StepperOutput
+	step, dir, enable *gpioLine
+	wake              chan struct{}
+
+	mu      sync.Mutex
+	steps   int64   // position in microsteps
+	target  int64   // microsteps
+	rate    float64 // microsteps/second of current move
+	forward bool    // dir line state
+	moving  bool
+	err     error // GPIO failure that stopped generator, reported by ReadState
+}
+
+// StepperDriver drives stepper motors through step/dir driver boards on
+// Linux GPIO lines via sysfs. Every motor has its own pulse generator
+// stepping towards target at commanded speed, capped at MaxStepRate.
+// Position is counted in microsteps, stepper gives no feedback so missed
+// steps go unnoticed. Implements Driver and PowerDriver.
+type StepperDriver struct {
+	mu       sync.Mutex
+	clock    clock.Clock
+	root     string
+	steppers map[MotorID]*stepper
+	done     chan struct{}
+	wg       sync.WaitGroup
+	closed   bool
+}
+
+// NewStepperDriver exports GPIO lines of configured motors, enables their
+// drivers and starts pulse generators with motors at initial positions
+func NewStepperDriver(clk clock.Clock, cfg StepperConfig) (*StepperDriver, error) {
+	if cfg.Root == "" {
+		cfg.Root = DefaultSysfsRoot
+	}
+	d := &StepperDriver{
+		clock:    clock.OrReal(clk),
+		root:     cfg.Root,
+		steppers: make(map[MotorID]*stepper),
+		done:     make(chan struct{}),
+	}
+	for _, o := range cfg.Outputs {
+		if err := o.Validate(); err != nil {
+			d.Close()
+			return nil, err
+		}
+		if _, dup := d.steppers[o.Motor]; dup {
+			d.Close()
+			return nil, fmt.Errorf("motor %s: stepper output configured twice", o.Motor)
+		}
+		s, err := d.open(o.withDefaults())
+		if err != nil {
+			d.Close()
+			return nil, &MotorError{Motor: o.Motor, Err: err}
+		}
+		d.steppers[o.Motor] = s
+		d.wg.Add(1)
+		go d.pulse(s)
+	}
+	return d, nil
+}
+
+// open exports lines of motor and enables its driver
+func (d *StepperDriver) open(o StepperOutput) (*stepper, error) {
+	s := &stepper{StepperOutput: o, wake: make(chan struct{}, 1)}
+	s.steps = int64(math.Round(o.Position / o.degreesPerStep()))
+	s.target = s.steps
+
+	var err error
+	if s.step, err = openGPIO(d.root, o.StepGPIO); err != nil {
+		return nil, err
+	}
+	if s.dir, err = openGPIO(d.root, o.DirGPIO); err != nil {
+		s.close(d.root)
+		return nil, err
+	}
+	s.forward = true
+	if err := s.dir.set(!o.Reverse); err != nil {
+		s.close(d.root)
+		return nil, err
+	}
+	if o.EnableGPIO != nil {
+		if s.enable, err = openGPIO(d.root, *o.EnableGPIO); err != nil {
+			s.close(d.root)
+			return nil, err
+		}
+		if err := s.enable.set(false); err != nil {
+			s.close(d.root)
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// SetTarget starts stepping towards position at speed
+func (d *StepperDriver) SetTarget(id MotorID, position, speed float64) error {
+	s, err := d.stepper(id)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.target = int64(math.Round(position / s.degreesPerStep()))
+	s.rate = math.Min(math.Abs(speed)/s.degreesPerStep(), s.MaxStepRate)
+	s.mu.Unlock()
+	s.poke()
+	return nil
+}
+
+// ReadState returns counted position and speed of current move
+func (d *StepperDriver) ReadState(id MotorID) (float64, float64, error) {
+	s, err := d.stepper(id)
+	if err != nil {
+		return 0, 0, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.err != nil {
+		return 0, 0, s.err
+	}
+	position := float64(s.steps) * s.degreesPerStep()
+	if !s.moving || s.steps == s.target {
+		return position, 0, nil
+	}
+	speed := s.rate * s.degreesPerStep()
+	if !s.forward {
+		speed = -speed
+	}
+	return position, speed, nil
+}
+
+// Stop holds motor at step it is on
+func (d *StepperDriver) Stop(id MotorID) error {
+	s, err := d.stepper(id)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.target = s.steps
+	s.mu.Unlock()
+	s.poke()
+	return nil
+}
+
+// SetPower switches driver through its enable line, disabled motor loses
+// holding torque. Motor without enable line ignores power off.
+func (d *StepperDriver) SetPower(id MotorID, on bool) error {
+	s, err := d.stepper(id)
+	if err != nil {
+		return err
+	}
+	if s.enable == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.enable.set(!on)
+}
+
+// Close stops pulse generators, disables drivers and unexports lines
+func (d *StepperDriver) Close() error {
+	d.mu.Lock()
+	if d.closed {
+		d.mu.Unlock()
+		return nil
+	}
+	d.closed = true
+	close(d.done)
+	d.mu.Unlock()
+	d.wg.Wait()
+
+	var errs []error
+	for _, s := range d.steppers {
+		if s.enable != nil {
+			errs = append(errs, s.enable.set(true))
+		}
+		errs = append(errs, s.close(d.root))
+	}
+	return errors.Join(errs...)
+}
+
+func (d *StepperDriver) stepper(id MotorID) (*stepper, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.closed {
+		return nil, errors.New("stepper driver closed")
+	}
+	s, ok := d.steppers[id]
+	if !ok {
+		return nil, &MotorError{Motor: id, Err: ErrMotorNotFound}
+	}
+	return s, nil
+}
+
+// pulse is pulse generator of motor: one step per period of commanded
+// rate while motor is off target, sleeping until poked otherwise. New
+// target or rate applies from last step, so targets renewed every tick
+// don't speed motor up.
+func (d *StepperDriver) pulse(s *stepper) {
+	defer d.wg.Done()
+
+	var last time.Time
+	for {
+		s.mu.Lock()
+		if s.steps == s.target || s.rate == 0 || s.err != nil {
+			s.moving = false
+			s.mu.Unlock()
+			select {
+			case <-s.wake:
+				continue
+			case <-d.done:
+				return
+			}
+		}
+		period := time.Duration(float64(time.Second) / s.rate)
+		now := d.clock.Now()
+		if wait := last.Add(period).Sub(now); wait > 0 {
+			s.mu.Unlock()
+			select {
+			case <-d.clock.After(wait):
+			case <-s.wake:
+			case <-d.done:
+				return
+			}
+			continue
+		}
+		if err := s.stepOnce(); err != nil {
+			s.err = fmt.Errorf("motor %s: %w", s.Motor, err)
+		}
+		s.moving = true
+		s.mu.Unlock()
+
+		// fixed schedule keeps rate steady, generator fallen behind
+		// restarts it instead of bursting
+		if last = last.Add(period); now.Sub(last) > period {
+			last = now
+		}
+	}
+}
+
+// poke wakes pulse generator for changed target
+func (s *stepper) poke() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// stepOnce moves one microstep towards target, setting direction first,
+// caller holds s.mu
+func (s *stepper) stepOnce() error {
+	forward := s.target > s.steps
+	if forward != s.forward {
+		if err := s.dir.set(forward != s.Reverse); err != nil {
+			return err
+		}
+		s.forward = forward
+	}
+	// sysfs write latency is way over minimum pulse width of drivers
+	if err := s.step.set(true); err != nil {
+		return err
+	}
+	if err := s.step.set(false); err != nil {
+		return err
+	}
+	if forward {
+		s.steps++
+	} else {
+		s.steps--
+	}
+	return nil
+}
+
+// close releases lines of motor
+func (s *stepper) close(root string) error {
+	var errs []error
+	for _, l := range []*gpioLine{s.step, s.dir, s.enable} {
+		if l != nil {
+			errs = append(errs, l.close(root))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// gpioLine is exported output line with value file kept open, steps are
+// too frequent to reopen it every time
+type gpioLine struct {
+	line  int
+	value *os.File
+}
+
+// openGPIO exports line as output
+func openGPIO(root string, line int) (*gpioLine, error) {
+	dir := filepath.Join(root, "gpio", fmt.Sprintf("gpio%d", line))
+	if _, err := os.Stat(dir); errors.Is(err, os.ErrNotExist) {
+		if err := writeSysfs(filepath.Join(root, "gpio", "export"), strconv.Itoa(line)); err != nil {
+			return nil, err
+		}
+	}
+	if err := writeSysfs(filepath.Join(dir, "direction"), "out"); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(filepath.Join(dir, "value"), os.O_WRONLY, 0)
+	if err != nil {
+		return nil, err
+	}
+	return &gpioLine{line: line, value: f}, nil
+}
+
+// set drives line high or low
+func (l *gpioLine) set(high bool) error {
+	v := []byte("0")
+	if high {
+		v[0] = '1'
+	}
+	_, err := l.value.WriteAt(v, 0)
+	return err
+}
+
+// close closes value file and unexports line
+func (l *gpioLine) close(root string) error {
+	return errors.Join(l.value.Close(), writeSysfs(filepath.Join(root, "gpio", "unexport"), strconv.Itoa(l.line)))
+}
@@ -0,0 +1,143 @@
+package motion
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"time"
+)
+
+// StopMode is how motor stops on StopAll
+type StopMode string
+
+const (
+	StopCut   StopMode = "cut"   // halt output at once, default
+	StopDecel StopMode = "decel" // ramp speed down, for heavy actuators
+)
+
+// defaultStopTime bounds controlled stop when profile sets no MaxTime
+const defaultStopTime = 500 * time.Millisecond
+
+// StopProfile shapes stop of motor. Decel ramps speed down at Decel
+// degrees/second², steeper if needed to stand still within MaxTime, so
+// emergency stop timing holds whatever speed motor had.
+type StopProfile struct {
+	Mode    StopMode
+	Decel   float64       // degrees/second², decel mode only
+	MaxTime time.Duration // zero is 500ms, decel mode only
+}
+
+// validate checks stop profile of motor m
+func (p StopProfile) validate(m Motor) error {
+	switch p.Mode {
+	case "", StopCut:
+		return nil
+	case StopDecel:
+	default:
+		return fmt.Errorf("motor %s: unknown stop mode %q", m.ID, p.Mode)
+	}
+	if p.Decel <= 0 {
+		return fmt.Errorf("motor %s: decel stop needs positive deceleration", m.ID)
+	}
+	if p.MaxTime < 0 {
+		return fmt.Errorf("motor %s: stop time must not be negative", m.ID)
+	}
+	return nil
+}
+
+func (p StopProfile) maxTime() time.Duration {
+	if p.MaxTime == 0 {
+		return defaultStopTime
+	}
+	return p.MaxTime
+}
+
+// stopping is controlled stop in progress, guarded by slot lock
+type stopping struct {
+	at       time.Time // zero when not stopping
+	last     time.Time
+	velocity float64 // signed speed stop started at
+	decel    float64
+	target   float64 // where motor comes to rest
+	deadline time.Time
+}
+
+// beginStop stops motor as its profile says, caller holds slot lock.
+// Returns false when motor ramps down over following ticks instead of
+// being cut.
+func (c *Controller) beginStop(slot *motorSlot) bool {
+	p := slot.Stop
+	v := slot.Speed
+	if p.Mode != StopDecel || v == 0 {
+		slot.stop = stopping{}
+		slot.halt()
+		return true
+	}
+
+	limit := p.maxTime()
+	decel := math.Max(p.Decel, math.Abs(v)/limit.Seconds())
+	lo, hi := slot.Travel()
+	target := slot.Position + math.Copysign(v*v/(2*decel), v)
+	target = math.Max(lo, math.Min(target, hi))
+	now := c.clock.Now()
+	slot.stop = stopping{at: now, last: now, velocity: v, decel: decel, target: target, deadline: now.Add(limit)}
+	slot.Target = target
+	slot.cruise = math.Abs(v)
+	slot.accel = 0
+	if c.driver != nil {
+		if err := c.driveTo(slot, target, math.Abs(v)); err != nil {
+			// can't steer motor down, cut it
+			log.Printf("Failed to ramp motor %s down, cutting: %v", slot.ID, err)
+			slot.stop = stopping{}
+			slot.halt()
+			return true
+		}
+	}
+	return false
+}
+
+// rampDown advances controlled stop of motor by one tick, caller holds mu
+// and slot lock. Reports whether motor is stopping, logical motors then
+// skip their trajectory.
+func (c *Controller) rampDown(slot *motorSlot) bool {
+	s := &slot.stop
+	if s.at.IsZero() {
+		return false
+	}
+	// motor got commanded since, stop is over
+	if slot.Target != s.target {
+		*s = stopping{}
+		return false
+	}
+
+	now := c.clock.Now()
+	speed := math.Abs(s.velocity) - s.decel*now.Sub(s.at).Seconds()
+	if speed > 0 && now.Before(s.deadline) && c.driver == nil {
+		step := speed * now.Sub(s.last).Seconds()
+		if dist := s.target - slot.Position; math.Abs(dist) > step {
+			slot.Position += math.Copysign(step, dist)
+			slot.Speed = math.Copysign(speed, s.velocity)
+			s.last = now
+			return true
+		}
+		slot.Position = s.target
+		speed = 0
+	}
+	if speed <= 0 || !now.Before(s.deadline) {
+		*s = stopping{}
+		slot.halt()
+		if c.driver != nil {
+			if err := c.driver.Stop(slot.ID); err != nil {
+				log.Printf("Failed to stop motor %s: %v", slot.ID, err)
+			}
+		}
+		c.publish(slot.Motor)
+		return true
+	}
+
+	s.last = now
+	if err := c.driveTo(slot, s.target, speed); err != nil {
+		c.lastErr.Set(&MotorError{Motor: slot.ID, Err: err})
+	}
+	return true
+}
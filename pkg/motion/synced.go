@@ -0,0 +1,156 @@
+package motion
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/sashalind/sex-artifical-intelligence/pkg/core/errs"
+)
+
+var (
+	ErrGroupNotFound = errs.New(errs.NotFound, "motor group not found")
+	ErrNotInGroup    = errs.New(errs.InvalidArgument, "motor is not in group")
+)
+
+// MotorGroup names motors that move together, e.g. both sides of joint
+type MotorGroup struct {
+	Name   string
+	Motors []MotorID
+}
+
+// validate checks group against configured motors
+func (g MotorGroup) validate(known map[MotorID]bool) error {
+	if g.Name == "" {
+		return errors.New("motor group name is empty")
+	}
+	if len(g.Motors) == 0 {
+		return fmt.Errorf("motor group %s: %w", g.Name, ErrEmptyGroup)
+	}
+	seen := make(map[MotorID]bool, len(g.Motors))
+	for _, id := range g.Motors {
+		if !known[id] {
+			return fmt.Errorf("motor group %s: unknown motor %s", g.Name, id)
+		}
+		if seen[id] {
+			return fmt.Errorf("motor group %s: motor %s listed twice", g.Name, id)
+		}
+		seen[id] = true
+	}
+	return nil
+}
+
+// GetGroups returns configured motor groups sorted by name
+func (c *Controller) GetGroups() []MotorGroup {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	groups := make([]MotorGroup, 0, len(c.groups))
+	for _, g := range c.groups {
+		groups = append(groups, g)
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].Name < groups[j].Name })
+	return groups
+}
+
+// MoveGroup moves motors of named group in sync, see ExecuteSynced.
+// Commands may cover part of group, other members stay where they are.
+func (c *Controller) MoveGroup(ctx context.Context, name string, cmds []MotorCommand) error {
+	c.mu.RLock()
+	group, exists := c.groups[name]
+	c.mu.RUnlock()
+	if !exists {
+		return fmt.Errorf("%w: %s", ErrGroupNotFound, name)
+	}
+
+	member := make(map[MotorID]bool, len(group.Motors))
+	for _, id := range group.Motors {
+		member[id] = true
+	}
+	var problems []error
+	for _, cmd := range cmds {
+		if !member[cmd.ID] {
+			problems = append(problems, &MotorError{Motor: cmd.ID, Err: ErrNotInGroup})
+		}
+	}
+	if len(problems) > 0 {
+		return errors.Join(problems...)
+	}
+	return c.ExecuteSynced(ctx, cmds)
+}
+
+// ExecuteSynced runs commands as one group move timed so every motor
+// starts and arrives together. Slowest motor at its commanded speed sets
+// move duration, others are slowed down to match. Timing assumes motors
+// start from rest and respects acceleration limits; jerk limits stretch
+// moves slightly.
+func (c *Controller) ExecuteSynced(ctx context.Context, cmds []MotorCommand) error {
+	if err := c.validateGroup(cmds); err != nil {
+		return err
+	}
+	return c.ExecuteGroup(ctx, c.syncSpeeds(cmds))
+}
+
+// syncSpeeds rescales command speeds so moves take equally long
+func (c *Controller) syncSpeeds(cmds []MotorCommand) []MotorCommand {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	type move struct {
+		distance, speed, accel float64
+	}
+	moves := make([]move, len(cmds))
+	longest := 0.0
+	for i, cmd := range cmds {
+		slot, exists := c.motors[cmd.ID]
+		if !exists {
+			continue
+		}
+		slot.mu.Lock()
+		m := move{
+			distance: math.Abs(cmd.Position - slot.Position),
+			speed:    c.motorSpeed(slot, cmd.Speed),
+			accel:    slot.MaxAcceleration,
+		}
+		slot.mu.Unlock()
+		moves[i] = m
+		// motor told not to move can't hold others back
+		if m.distance > 0 && m.speed > 0 {
+			longest = math.Max(longest, travelTime(m.distance, m.speed, m.accel))
+		}
+	}
+
+	synced := make([]MotorCommand, len(cmds))
+	for i, cmd := range cmds {
+		synced[i] = cmd
+		if m := moves[i]; longest > 0 && m.distance > 0 && m.speed > 0 {
+			synced[i].Speed = cruiseFor(m.distance, longest, m.accel)
+		}
+	}
+	return synced
+}
+
+// travelTime is seconds move of distance takes from rest to rest at
+// cruise speed, trapezoidal when accel limits it, zero accel is unlimited
+func travelTime(distance, speed, accel float64) float64 {
+	if accel == 0 {
+		return distance / speed
+	}
+	if distance >= speed*speed/accel {
+		return distance/speed + speed/accel
+	}
+	// never reaches cruise speed, triangular profile
+	return 2 * math.Sqrt(distance/accel)
+}
+
+// cruiseFor is cruise speed that makes move of distance take t seconds,
+// inverse of travelTime. t is never shorter than fastest possible move.
+func cruiseFor(distance, t, accel float64) float64 {
+	if accel == 0 {
+		return distance / t
+	}
+	disc := accel*accel*t*t - 4*accel*distance
+	return (accel*t - math.Sqrt(math.Max(disc, 0))) / 2
+}
@@ -0,0 +1,139 @@
+package motion
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// MotorState is live sample of one motor in telemetry frame
+type MotorState struct {
+	ID       MotorID `json:"id"`
+	Position float64 `json:"position"`
+	Speed    float64 `json:"speed"`
+	Target   float64 `json:"target"`
+	Enabled  bool    `json:"enabled"`
+	Homing   bool    `json:"homing,omitempty"`
+	Heat     float64 `json:"heat,omitempty"` // thermal model estimate, 1 is limit
+	Cooling  bool    `json:"cooling,omitempty"`
+	Error    string  `json:"error,omitempty"` // last hardware read failure
+}
+
+// TelemetryFrame is state of every motor at one control tick. Frames are
+// shared between subscribers and must not be modified.
+type TelemetryFrame struct {
+	Time   time.Time    `json:"time"`
+	Motors []MotorState `json:"motors"`
+}
+
+// Telemetry receives frames at subscribed rate. Like event bus it never
+// blocks control loop: frames a slow reader has no room for are dropped.
+type Telemetry struct {
+	C <-chan TelemetryFrame
+
+	ch       chan TelemetryFrame
+	interval time.Duration
+	last     time.Time
+	dropped  atomic.Uint64
+	ctrl     *Controller
+	once     sync.Once
+}
+
+// Dropped returns number of frames lost because subscriber was too slow
+func (t *Telemetry) Dropped() uint64 {
+	return t.dropped.Load()
+}
+
+// Cancel unsubscribes and closes channel
+func (t *Telemetry) Cancel() {
+	t.ctrl.telemMu.Lock()
+	delete(t.ctrl.telemetry, t)
+	t.ctrl.telemMu.Unlock()
+	t.close()
+}
+
+func (t *Telemetry) close() {
+	t.once.Do(func() { close(t.ch) })
+}
+
+// Subscribe starts telemetry stream with frame every interval, rounded up
+// to control tick. Frames come from control loop, so readers never touch
+// motor locks. Shutdown closes stream.
+func (c *Controller) Subscribe(interval time.Duration, buffer int) *Telemetry {
+	ch := make(chan TelemetryFrame, buffer)
+	t := &Telemetry{C: ch, ch: ch, interval: max(interval, TickInterval), ctrl: c}
+
+	c.telemMu.Lock()
+	defer c.telemMu.Unlock()
+	if !c.running.Load() {
+		t.close()
+		return t
+	}
+	if c.telemetry == nil {
+		c.telemetry = make(map[*Telemetry]struct{})
+	}
+	c.telemetry[t] = struct{}{}
+	return t
+}
+
+// publishTelemetry sends frame to subscribers whose interval elapsed,
+// caller holds mu
+func (c *Controller) publishTelemetry() {
+	c.telemMu.Lock()
+	defer c.telemMu.Unlock()
+	if len(c.telemetry) == 0 {
+		return
+	}
+
+	now := c.clock.Now()
+	var frame *TelemetryFrame
+	for t := range c.telemetry {
+		// half a tick of slack keeps ticker jitter from skipping frames
+		if now.Sub(t.last) < t.interval-TickInterval/2 {
+			continue
+		}
+		if frame == nil {
+			frame = c.telemetryFrame(now)
+		}
+		t.last = now
+		select {
+		case t.ch <- *frame:
+		default:
+			t.dropped.Add(1)
+		}
+	}
+}
+
+// telemetryFrame samples every motor, caller holds mu
+func (c *Controller) telemetryFrame(now time.Time) *TelemetryFrame {
+	frame := &TelemetryFrame{Time: now, Motors: make([]MotorState, 0, len(c.order))}
+	for _, slot := range c.order {
+		slot.mu.Lock()
+		state := MotorState{
+			ID:       slot.ID,
+			Position: slot.Position,
+			Speed:    slot.Speed,
+			Target:   slot.Target,
+			Enabled:  slot.IsEnabled,
+			Homing:   slot.homing,
+			Heat:     slot.heat.level,
+			Cooling:  slot.heat.cooling,
+		}
+		if slot.readErr != nil {
+			state.Error = slot.readErr.Error()
+		}
+		slot.mu.Unlock()
+		frame.Motors = append(frame.Motors, state)
+	}
+	return frame
+}
+
+// closeTelemetry ends every stream
+func (c *Controller) closeTelemetry() {
+	c.telemMu.Lock()
+	defer c.telemMu.Unlock()
+	for t := range c.telemetry {
+		t.close()
+	}
+	c.telemetry = nil
+}
@@ -0,0 +1,173 @@
+package motion
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"github.com/sashalind/sex-artifical-intelligence/pkg/core/errs"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/core/event"
+)
+
+var (
+	ErrMotorOverheated = errs.New(errs.Unavailable, "motor is cooling down")
+	ErrNoTemperature   = errs.New(errs.FailedPrecondition, "driver has no temperature sensor")
+)
+
+const (
+	defaultAmbient    = 25.0 // °C
+	defaultThrottleAt = 0.8
+	defaultResumeAt   = 0.5
+
+	// minThermalScale is speed scale of motor at heat limit
+	minThermalScale = 0.25
+
+	// temperatureInterval spaces temperature reads, sensors are slow and
+	// motors heat up in minutes
+	temperatureInterval = time.Second
+)
+
+// Thermal estimates heat of motor from its duty cycle and, with driver
+// implementing TemperatureDriver, measured temperature. Heat 1 is limit:
+// motor run at full duty for HeatTime from cold gets there, idle motor
+// cools from there in CoolTime, so duty cycle it can keep up forever is
+// HeatTime/(HeatTime+CoolTime). Above ThrottleAt new moves slow down, at
+// limit motor stops and refuses commands until it cooled to ResumeAt.
+// Zero HeatTime and MaxTemp turn thermal model off.
+type Thermal struct {
+	HeatTime   time.Duration // full duty this long reaches limit
+	CoolTime   time.Duration // idle this long cools from limit, zero is HeatTime
+	MaxTemp    float64       // °C at limit, zero ignores temperature sensor
+	Ambient    float64       // °C of cold motor, zero is 25
+	ThrottleAt float64       // heat speed scaling starts at, zero is 0.8
+	ResumeAt   float64       // heat cooled motor resumes at, zero is 0.5
+}
+
+// validate checks thermal config of motor m
+func (t Thermal) validate(m Motor) error {
+	if t.HeatTime < 0 || t.CoolTime < 0 || t.MaxTemp < 0 {
+		return fmt.Errorf("motor %s: thermal limits must not be negative", m.ID)
+	}
+	if t.MaxTemp > 0 && t.MaxTemp <= t.ambient() {
+		return fmt.Errorf("motor %s: max temperature must be above ambient", m.ID)
+	}
+	if t.ThrottleAt < 0 || t.ThrottleAt >= 1 || t.ResumeAt < 0 || t.ResumeAt >= 1 {
+		return fmt.Errorf("motor %s: thermal throttle and resume levels must be within 0..1", m.ID)
+	}
+	return nil
+}
+
+// enabled reports whether motor heat is tracked at all
+func (t Thermal) enabled() bool {
+	return t.HeatTime > 0 || t.MaxTemp > 0
+}
+
+func (t Thermal) ambient() float64 {
+	if t.Ambient == 0 {
+		return defaultAmbient
+	}
+	return t.Ambient
+}
+
+func (t Thermal) coolTime() time.Duration {
+	if t.CoolTime == 0 {
+		return t.HeatTime
+	}
+	return t.CoolTime
+}
+
+func (t Thermal) throttleAt() float64 {
+	if t.ThrottleAt == 0 {
+		return defaultThrottleAt
+	}
+	return t.ThrottleAt
+}
+
+func (t Thermal) resumeAt() float64 {
+	if t.ResumeAt == 0 {
+		return defaultResumeAt
+	}
+	return t.ResumeAt
+}
+
+// heat is thermal state of motor, guarded by slot lock. Kept across
+// reconfiguration, motor does not cool down by being reconfigured.
+type heat struct {
+	level   float64   // 1 is limit
+	at      time.Time // last model update
+	cooling bool      // stopped until level drops to resumeAt
+}
+
+// throttle is speed scale of motor at its current heat, caller holds slot
+// lock
+func (slot *motorSlot) throttle() float64 {
+	at := slot.Thermal.throttleAt()
+	if slot.heat.level <= at {
+		return 1
+	}
+	over := math.Min((slot.heat.level-at)/(1-at), 1)
+	return 1 - over*(1-minThermalScale)
+}
+
+// duty is fraction of full power motor runs at: its speed, or load when
+// driver reports more
+func (slot *motorSlot) duty(load float64, hasLoad bool) float64 {
+	duty := 0.0
+	if slot.MaxSpeed > 0 {
+		duty = math.Min(math.Abs(slot.Speed)/slot.MaxSpeed, 1)
+	}
+	if hasLoad {
+		duty = math.Max(duty, math.Abs(load))
+	}
+	return duty
+}
+
+// heatUp advances thermal model of motor to now and starts or ends
+// cool-down, caller holds mu and slot lock. Temperature read this tick
+// raises heat to what sensor shows.
+func (c *Controller) heatUp(slot *motorSlot, duty, temp float64, hasTemp bool) {
+	t := slot.Thermal
+	if !t.enabled() {
+		return
+	}
+	now := c.clock.Now()
+	h := &slot.heat
+
+	if t.HeatTime > 0 && !h.at.IsZero() {
+		dt := now.Sub(h.at).Seconds()
+		h.level += dt * (duty/t.HeatTime.Seconds() - math.Max(1-duty, 0)/t.coolTime().Seconds())
+		h.level = math.Max(h.level, 0)
+	}
+	h.at = now
+	if hasTemp && t.MaxTemp > 0 {
+		sensed := (temp - t.ambient()) / (t.MaxTemp - t.ambient())
+		if t.HeatTime == 0 {
+			h.level = math.Max(sensed, 0)
+		} else {
+			h.level = math.Max(h.level, sensed)
+		}
+	}
+
+	switch {
+	case !h.cooling && h.level >= 1:
+		h.cooling = true
+		fault := MotorFault{
+			Motor:    slot.ID,
+			Kind:     FaultOverheat,
+			Action:   ProtectStop,
+			Position: slot.Position,
+			Target:   slot.Target,
+			Time:     now,
+		}
+		c.applyProtection(slot, ProtectStop)
+		log.Printf("Motor %s overheated at heat %.2f, cooling down", slot.ID, h.level)
+		c.lastErr.Set(&MotorError{Motor: slot.ID, Err: ErrMotorOverheated})
+		event.Publish(c.bus.Load(), TopicMotorFault, fault)
+		c.publish(slot.Motor)
+	case h.cooling && h.level <= t.resumeAt():
+		h.cooling = false
+		log.Printf("Motor %s cooled down", slot.ID)
+		c.publish(slot.Motor)
+	}
+}
@@ -0,0 +1,161 @@
+package motion
+
+import "math"
+
+// arriveTolerance is distance at which logical motor counts as arrived, degrees
+const arriveTolerance = 1e-3
+
+// advance moves logical motor one tick along its trajectory towards Target,
+// caller holds slot lock. Each tick motor speeds up towards commanded speed
+// unless that would leave it unable to brake before target, then it brakes.
+// Speed changes within MaxAcceleration: trapezoidal profile, or S-curve
+// when MaxJerk also limits how fast acceleration changes.
+func (motor *motorSlot) advance() {
+	if !motor.IsEnabled {
+		return
+	}
+	if motor.Speed == 0 && motor.Position == motor.Target {
+		return
+	}
+
+	distance := motor.Target - motor.Position
+	if math.Abs(distance) < arriveTolerance {
+		motor.arrive()
+		return
+	}
+
+	// work along direction of travel, positive towards target
+	dir := math.Copysign(1, distance)
+	lim := motor.limits()
+	now := kinematics{vel: motor.Speed * dir, acc: motor.accel * dir}
+	next := now
+	next.step(lim, motor.cruise)
+	if next.stopDistance(lim) > math.Abs(distance) {
+		if now.vel <= arriveTolerance && now.acc <= 0 {
+			// at rest and even smallest move overshoots, finish here
+			motor.arrive()
+			return
+		}
+		next = now
+		next.step(lim, 0)
+	}
+
+	if next.pos >= math.Abs(distance) {
+		motor.arrive()
+		return
+	}
+	pos := motor.Position + next.pos*dir
+	if pos < motor.MinPosition || pos > motor.MaxPosition {
+		motor.Position = math.Max(motor.MinPosition, math.Min(pos, motor.MaxPosition))
+		motor.halt()
+		return
+	}
+	motor.Position = pos
+	motor.Speed = next.vel * dir
+	motor.accel = next.acc * dir
+}
+
+// arrive finishes move at target
+func (motor *motorSlot) arrive() {
+	motor.Position = motor.Target
+	motor.Speed = 0
+	motor.accel = 0
+}
+
+// halt stops logical motor where it is, dropping its trajectory
+func (motor *motorSlot) halt() {
+	motor.Target = motor.Position
+	motor.Speed = 0
+	motor.accel = 0
+}
+
+// limits returns trajectory limits of motor with zero meaning unlimited
+func (motor *motorSlot) limits() profileLimits {
+	lim := profileLimits{accel: motor.MaxAcceleration, jerk: motor.MaxJerk}
+	if lim.accel == 0 {
+		lim.accel = math.Inf(1)
+	}
+	if lim.jerk == 0 {
+		lim.jerk = math.Inf(1)
+	}
+	return lim
+}
+
+// profileLimits bounds acceleration and jerk of trajectory
+type profileLimits struct {
+	accel, jerk float64
+}
+
+// kinematics is motion state relative to start of tick
+type kinematics struct {
+	pos, vel, acc float64
+}
+
+// step advances state one tick changing velocity towards vel
+func (k *kinematics) step(lim profileLimits, vel float64) {
+	dt := TickInterval.Seconds()
+	gap := vel - k.vel
+	// highest acceleration that still ramps down to zero as velocity
+	// reaches vel: tick at a and ramp down over following ticks gain
+	// a²/2j + a·dt/2
+	want := math.Abs(gap) / dt
+	if j := lim.jerk; !math.IsInf(j, 1) {
+		want = math.Min(want, (math.Sqrt(j*j*dt*dt+8*j*math.Abs(gap))-j*dt)/2)
+	}
+	want = clampAbs(math.Copysign(want, gap), lim.accel)
+	k.acc += clampAbs(want-k.acc, lim.jerk*dt)
+	k.vel += k.acc * dt
+	if (vel-k.vel)*gap < 0 {
+		// tick overshot, settle on vel
+		k.vel, k.acc = vel, 0
+	}
+	k.pos += k.vel * dt
+}
+
+// stopDistance is where motor ends up braking from state, in closed form:
+// v²/2a for trapezoidal profile, with jerk terms for S-curve. Continuous
+// motion brakes a little sooner than ticks do, so motor never overshoots.
+func (k kinematics) stopDistance(lim profileLimits) float64 {
+	v, a := k.vel, k.acc
+	if v <= arriveTolerance && a <= 0 {
+		return k.pos
+	}
+	if math.IsInf(lim.jerk, 1) {
+		return k.pos + brakeFrom(v, lim)
+	}
+	j := lim.jerk
+	if a >= 0 {
+		// ramp acceleration down first, motor still speeds up meanwhile
+		t := a / j
+		return k.pos + v*t + a*t*t/2 - j*t*t*t/6 + brakeFrom(v+a*a/(2*j), lim)
+	}
+	if a*a > 2*v*j {
+		// braking so hard motor stops before deceleration eases off
+		t := (-a - math.Sqrt(a*a-2*v*j)) / j
+		return k.pos + v*t + a*t*t/2 + j*t*t*t/6
+	}
+	// already braking: continue braking that started at rest acceleration
+	// from higher speed, less distance it covered until now
+	t := -a / j
+	start := v + a*a/(2*j)
+	return k.pos + brakeFrom(start, lim) - (start*t - j*t*t*t/6)
+}
+
+// brakeFrom is distance braking from speed v at zero acceleration takes.
+// Deceleration ramps up and down with jerk, holding maximum in between
+// when there is time; profile is symmetric, so motor covers v/2 times
+// braking time.
+func brakeFrom(v float64, lim profileLimits) float64 {
+	if v <= 0 {
+		return 0
+	}
+	if v >= lim.accel*lim.accel/lim.jerk {
+		return v / 2 * (v/lim.accel + lim.accel/lim.jerk)
+	}
+	return v * math.Sqrt(v/lim.jerk)
+}
+
+// clampAbs limits magnitude of v to limit
+func clampAbs(v, limit float64) float64 {
+	return math.Max(-limit, math.Min(v, limit))
+}
@@ -0,0 +1,103 @@
+package motion
+
+import (
+	"math"
+	"testing"
+)
+
+// TestTrajectoryArrives drives logical motor across its range and checks
+// profile stays within limits and stops at target without overshoot
+func TestTrajectoryArrives(t *testing.T) {
+	tests := []struct {
+		name        string
+		accel, jerk float64
+		speed       float64
+	}{
+		{"unlimited", 0, 0, 90},
+		{"trapezoidal", 200, 0, 90},
+		{"trapezoidal, no time to cruise", 50, 0, 180},
+		{"s-curve", 400, 2000, 90},
+		{"s-curve, acceleration never peaks", 400, 500, 30},
+	}
+	dt := TickInterval.Seconds()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &motorSlot{Motor: Motor{
+				ID: "servo_1", MaxSpeed: 180, MaxPosition: 180, Target: 150, IsEnabled: true,
+				MaxAcceleration: tt.accel, MaxJerk: tt.jerk,
+			}}
+			m.cruise = tt.speed
+
+			lim := m.limits()
+			ticks, prevAcc := 0, 0.0
+			for m.Position != m.Target || m.Speed != 0 {
+				if ticks++; ticks > 10000 {
+					t.Fatalf("not arrived after %d ticks, at %.3f moving %.3f", ticks, m.Position, m.Speed)
+				}
+				prevSpeed, prevPos := m.Speed, m.Position
+				m.advance()
+				if m.Position > m.Target || m.Position < prevPos {
+					t.Fatalf("tick %d: moved from %.4f to %.4f, target %.0f", ticks, prevPos, m.Position, m.Target)
+				}
+				if m.Speed > tt.speed+1e-9 {
+					t.Fatalf("tick %d: speed %.3f over %.0f", ticks, m.Speed, tt.speed)
+				}
+				if m.Position == m.Target {
+					// last tick snaps onto target from crawl
+					if prevSpeed > 2*lim.accel*dt && !math.IsInf(lim.accel, 1) {
+						t.Errorf("arrived at %.3f deg/s", prevSpeed)
+					}
+					continue
+				}
+				acc := (m.Speed - prevSpeed) / dt
+				if math.Abs(acc) > lim.accel*(1+1e-9) {
+					t.Fatalf("tick %d: acceleration %.1f over %.0f", ticks, acc, lim.accel)
+				}
+				// settling onto commanded speed drops what acceleration is left
+				settled := m.Speed == tt.speed && m.accel == 0
+				if jerk := math.Abs(m.accel-prevAcc) / dt; jerk > lim.jerk*(1+1e-9) && !settled {
+					t.Fatalf("tick %d: jerk %.1f over %.0f", ticks, jerk, lim.jerk)
+				}
+				prevAcc = m.accel
+			}
+		})
+	}
+}
+
+// TestStopDistance compares closed form with braking tick by tick: it
+// must not fall short, or motor overshoots, and stay within few ticks of
+// travel
+func TestStopDistance(t *testing.T) {
+	tests := []struct {
+		name     string
+		lim      profileLimits
+		vel, acc float64
+	}{
+		{"trapezoidal", profileLimits{accel: 200, jerk: math.Inf(1)}, 90, 0},
+		{"trapezoidal, slow", profileLimits{accel: 200, jerk: math.Inf(1)}, 3, 0},
+		{"s-curve, cruising", profileLimits{accel: 400, jerk: 2000}, 90, 0},
+		{"s-curve, speeding up", profileLimits{accel: 400, jerk: 2000}, 60, 300},
+		{"s-curve, braking", profileLimits{accel: 400, jerk: 2000}, 60, -300},
+		{"s-curve, braking hard", profileLimits{accel: 400, jerk: 2000}, 10, -400},
+		{"s-curve, acceleration never peaks", profileLimits{accel: 400, jerk: 500}, 30, 0},
+		{"at rest", profileLimits{accel: 400, jerk: 2000}, 0, 0},
+	}
+	dt := TickInterval.Seconds()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k := kinematics{vel: tt.vel, acc: tt.acc}
+			got := k.stopDistance(tt.lim)
+
+			ticked := k
+			for i := 0; ticked.vel > arriveTolerance || ticked.acc > 0; i++ {
+				if i > 10000 {
+					t.Fatal("braking does not end")
+				}
+				ticked.step(tt.lim, 0)
+			}
+			if got < ticked.pos-1e-9 || got > ticked.pos+3*tt.vel*dt+1e-9 {
+				t.Errorf("stop distance = %.4f, braking tick by tick takes %.4f", got, ticked.pos)
+			}
+		})
+	}
+}
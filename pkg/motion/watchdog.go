@@ -0,0 +1,126 @@
+package motion
+
+import (
+	"log"
+	"time"
+
+	"github.com/sashalind/sex-artifical-intelligence/pkg/core/errs"
+)
+
+// ErrWatchdog is reported when control loop stopped ticking in time
+var ErrWatchdog = errs.New(errs.DeadlineExceeded, "motion watchdog tripped, control loop fell behind")
+
+// defaultWatchdogTimeout is 20 control ticks
+const defaultWatchdogTimeout = 200 * time.Millisecond
+
+// WatchdogDriver is implemented by drivers with hardware watchdog that
+// stops outputs once controller stops petting it, e.g. because process
+// hangs or died. Zero timeout disarms it. Controller pets every tick.
+type WatchdogDriver interface {
+	ArmWatchdog(timeout time.Duration) error
+	Pet() error
+}
+
+// SetWatchdog sets how long control loop may go without tick. Past that
+// software watchdog stops every motor and hardware watchdog of driver
+// cuts outputs by itself. Zero disables both.
+func (c *Controller) SetWatchdog(timeout time.Duration) error {
+	if timeout < 0 {
+		return errs.New(errs.InvalidArgument, "watchdog timeout must not be negative")
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.watchdog.Store(int64(timeout))
+	return c.armWatchdog()
+}
+
+// armWatchdog arms hardware watchdog of driver if it has one, caller
+// holds mu
+func (c *Controller) armWatchdog() error {
+	wd, ok := c.driver.(WatchdogDriver)
+	if !ok {
+		return nil
+	}
+	return wd.ArmWatchdog(time.Duration(c.watchdog.Load()))
+}
+
+// petWatchdog marks tick done and pets hardware watchdog, caller holds mu
+func (c *Controller) petWatchdog() {
+	c.lastTick.Store(c.clock.Now().UnixNano())
+	if c.watchdog.Load() == 0 {
+		return
+	}
+	if wd, ok := c.driver.(WatchdogDriver); ok {
+		if err := wd.Pet(); err != nil {
+			c.lastErr.Set(err)
+		}
+	}
+}
+
+// watchTicks is software watchdog: it cuts motors once ticks fall behind
+// and rearms itself and hardware watchdog when they come back. Both run in
+// own goroutine, watchdog never waits for controller lock.
+func (c *Controller) watchTicks() {
+	ticker := c.clock.NewTicker(TickInterval)
+	defer ticker.Stop()
+
+	tripped := false
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C():
+		}
+		timeout := time.Duration(c.watchdog.Load())
+		// dead loop is supervisor's business
+		if timeout == 0 || !c.loopRunning.Load() {
+			tripped = false
+			continue
+		}
+		lag := c.clock.Now().Sub(time.Unix(0, c.lastTick.Load()))
+		switch {
+		case lag > timeout && !tripped:
+			tripped = true
+			log.Printf("Motion watchdog: no control tick for %v, stopping motors", lag)
+			c.lastErr.Set(ErrWatchdog)
+			go c.cutAll()
+		case lag <= timeout && tripped:
+			tripped = false
+			log.Printf("Motion watchdog: control loop caught up")
+			go c.rearmWatchdog()
+		}
+	}
+}
+
+// cutAll zeroes speed of every motor at once, stop profiles are skipped
+// since stalled loop would not ramp them down
+func (c *Controller) cutAll() {
+	c.stopRuns()
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for _, motor := range c.order {
+		motor.mu.Lock()
+		motor.stop = stopping{}
+		motor.halt()
+		c.publish(motor.Motor)
+		motor.mu.Unlock()
+		if c.driver != nil {
+			if err := c.driver.Stop(motor.ID); err != nil {
+				log.Printf("Failed to stop motor %s: %v", motor.ID, err)
+			}
+		}
+	}
+}
+
+// rearmWatchdog clears hardware watchdog that tripped while loop stalled
+func (c *Controller) rearmWatchdog() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.armWatchdog(); err != nil {
+		log.Printf("Failed to rearm driver watchdog: %v", err)
+	}
+}
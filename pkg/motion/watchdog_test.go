@@ -0,0 +1,243 @@
+package motion
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/sashalind/sex-artifical-intelligence/pkg/clock"
+)
+
+// plainDriver hides optional interfaces of driver, here hardware watchdog
+type plainDriver struct{ Driver }
+
+// countingDriver counts watchdog calls reaching simulated backend
+type countingDriver struct {
+	*SimDriver
+	armed, pets int
+	timeout     time.Duration
+}
+
+func (d *countingDriver) ArmWatchdog(timeout time.Duration) error {
+	d.armed++
+	d.timeout = timeout
+	return d.SimDriver.ArmWatchdog(timeout)
+}
+
+func (d *countingDriver) Pet() error {
+	d.pets++
+	return d.SimDriver.Pet()
+}
+
+// newWatchdogSim creates manually ticked controller on simulated driver,
+// hardware watchdog hidden unless hardware is set
+func newWatchdogSim(t *testing.T, hardware bool) (*Controller, *SimDriver) {
+	t.Helper()
+	cfg := DefaultConfig()
+	d := NewSimDriver(cfg.Motors)
+	c, err := NewManualController(d.Clock(), cfg)
+	if err != nil {
+		t.Fatalf("NewManualController: %v", err)
+	}
+	t.Cleanup(c.Shutdown)
+	if hardware {
+		c.SetDriver(d)
+	} else {
+		c.SetDriver(plainDriver{d})
+	}
+	return c, d
+}
+
+// stall moves fake time by d without control ticks. Once ticker of
+// watchdog is drained after next tick, watchdog handled previous one.
+func stall(t *testing.T, clk *clock.Fake, d time.Duration) {
+	t.Helper()
+	for elapsed := time.Duration(0); elapsed < d; elapsed += TickInterval {
+		clk.Advance(TickInterval)
+		waitFor(t, func() bool { return clk.Pending() == 0 })
+	}
+}
+
+// waitFor yields to watchdog goroutines until cond holds
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not reached")
+		}
+		runtime.Gosched()
+	}
+}
+
+func moving(c *Controller, id MotorID) bool {
+	for _, m := range c.GetMotors() {
+		if m.ID == id {
+			return m.Speed != 0
+		}
+	}
+	return false
+}
+
+func TestSoftwareWatchdog(t *testing.T) {
+	tests := []struct {
+		name     string
+		timeout  time.Duration
+		hardware bool
+		stall    time.Duration
+		tripped  bool
+	}{
+		{name: "short stall", timeout: 100 * time.Millisecond, stall: 50 * time.Millisecond},
+		{name: "stall past timeout", timeout: 100 * time.Millisecond, stall: 150 * time.Millisecond, tripped: true},
+		{name: "default timeout", timeout: defaultWatchdogTimeout, stall: 250 * time.Millisecond, tripped: true},
+		{name: "disabled", timeout: 0, stall: time.Second},
+		{name: "with hardware watchdog", timeout: 100 * time.Millisecond, hardware: true, stall: 150 * time.Millisecond, tripped: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, d := newWatchdogSim(t, tt.hardware)
+			if err := c.SetWatchdog(tt.timeout); err != nil {
+				t.Fatalf("SetWatchdog: %v", err)
+			}
+			if err := c.ExecuteCommand(context.Background(), MotorCommand{ID: "servo_1", Position: 180, Speed: 30}); err != nil {
+				t.Fatalf("ExecuteCommand: %v", err)
+			}
+			for range 5 {
+				d.Clock().Advance(TickInterval)
+				if err := c.StepTick(); err != nil {
+					t.Fatalf("StepTick: %v", err)
+				}
+			}
+
+			stall(t, d.Clock(), tt.stall)
+			if tt.tripped {
+				waitFor(t, func() bool { return !moving(c, "servo_1") })
+				if got := c.Health().LastError; got != ErrWatchdog.Error() {
+					t.Errorf("last error = %q, want %q", got, ErrWatchdog)
+				}
+			} else if !moving(c, "servo_1") {
+				t.Fatal("motor stopped without watchdog trip")
+			}
+			if got := d.WatchdogTripped(); got != (tt.tripped && tt.hardware) {
+				t.Errorf("hardware watchdog tripped = %v, want %v", got, tt.tripped && tt.hardware)
+			}
+
+			// ticks coming back rearm hardware watchdog, motors take
+			// commands again
+			d.Clock().Advance(TickInterval)
+			if err := c.StepTick(); err != nil {
+				t.Fatalf("StepTick: %v", err)
+			}
+			stall(t, d.Clock(), 2*TickInterval)
+			waitFor(t, func() bool { return !d.WatchdogTripped() })
+			if err := d.SetTarget("servo_1", 10, 30); err != nil {
+				t.Errorf("SetTarget after recovery: %v", err)
+			}
+		})
+	}
+}
+
+func TestSetWatchdogNegative(t *testing.T) {
+	c, _ := newWatchdogSim(t, true)
+	if err := c.SetWatchdog(-time.Second); err == nil {
+		t.Fatal("negative timeout accepted")
+	}
+}
+
+func TestSimDriverWatchdog(t *testing.T) {
+	tests := []struct {
+		name    string
+		timeout time.Duration
+		pets    []time.Duration // fake time of each pet
+		at      time.Duration
+		tripped bool
+	}{
+		{name: "disarmed", at: time.Minute},
+		{name: "within timeout", timeout: 100 * time.Millisecond, at: 100 * time.Millisecond},
+		{name: "not petted", timeout: 100 * time.Millisecond, at: 101 * time.Millisecond, tripped: true},
+		{name: "petted in time", timeout: 100 * time.Millisecond, pets: []time.Duration{90 * time.Millisecond, 180 * time.Millisecond}, at: 250 * time.Millisecond},
+		{name: "pet too late", timeout: 100 * time.Millisecond, pets: []time.Duration{150 * time.Millisecond}, at: 160 * time.Millisecond, tripped: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := NewSimDriver(DefaultConfig().Motors)
+			if err := d.ArmWatchdog(tt.timeout); err != nil {
+				t.Fatal(err)
+			}
+			if err := d.SetTarget("servo_1", 180, 100); err != nil {
+				t.Fatal(err)
+			}
+			var now time.Duration
+			for _, at := range tt.pets {
+				d.Clock().Advance(at - now)
+				now = at
+				if err := d.Pet(); err != nil {
+					t.Fatal(err)
+				}
+			}
+			d.Clock().Advance(tt.at - now)
+
+			if got := d.WatchdogTripped(); got != tt.tripped {
+				t.Fatalf("tripped = %v, want %v", got, tt.tripped)
+			}
+			err := d.SetTarget("servo_1", 0, 100)
+			if tt.tripped != errors.Is(err, ErrWatchdog) {
+				t.Fatalf("SetTarget = %v, tripped %v", err, tt.tripped)
+			}
+			if !tt.tripped {
+				return
+			}
+			// motor stopped where watchdog ran out and stays there
+			pos, speed, _ := d.ReadState("servo_1")
+			if speed != 0 || pos > 100*tt.timeout.Seconds()+1e-9 {
+				t.Errorf("motor at %.2f moving %.2f after trip", pos, speed)
+			}
+			if err := d.ArmWatchdog(tt.timeout); err != nil || d.WatchdogTripped() {
+				t.Errorf("rearm left watchdog tripped: %v", err)
+			}
+		})
+	}
+}
+
+func TestMuxWatchdog(t *testing.T) {
+	motors := []Motor{{ID: "a", MaxPosition: 180}, {ID: "b", MaxPosition: 180}, {ID: "c", MaxPosition: 180}, {ID: "d", MaxPosition: 180}}
+	shared := &countingDriver{SimDriver: NewSimDriver(motors[:2])}
+	own := &countingDriver{SimDriver: NewSimDriver(motors[2:3])}
+	mux := NewMux(shared.Clock(), motors[:3], map[MotorID]Driver{"a": shared, "b": shared})
+
+	if err := mux.ArmWatchdog(time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if err := mux.DetachMotor("c"); err != nil {
+		t.Fatal(err)
+	}
+	// backend attached while armed joins watchdog
+	if err := mux.AttachMotor(motors[2], own); err != nil {
+		t.Fatal(err)
+	}
+	// virtual motor has no watchdog
+	if err := mux.AttachMotor(motors[3], nil); err != nil {
+		t.Fatal(err)
+	}
+	for range 3 {
+		if err := mux.Pet(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name        string
+		d           *countingDriver
+		armed, pets int
+	}{
+		{"shared backend", shared, 1, 3},
+		{"attached backend", own, 1, 3},
+	}
+	for _, tt := range tests {
+		if tt.d.armed != tt.armed || tt.d.pets != tt.pets || tt.d.timeout != time.Second {
+			t.Errorf("%s: armed %d times with %v, petted %d times, want %d and %d", tt.name, tt.d.armed, tt.d.timeout, tt.d.pets, tt.armed, tt.pets)
+		}
+	}
+}
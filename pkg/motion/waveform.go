@@ -0,0 +1,183 @@
+package motion
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/sashalind/sex-artifical-intelligence/pkg/core/errs"
+)
+
+// WaveShape is curve motor follows over one period of waveform
+type WaveShape string
+
+const (
+	WaveSine     WaveShape = "sine"
+	WaveTriangle WaveShape = "triangle"
+	WaveRamp     WaveShape = "ramp"   // rises over period, then jumps back
+	WaveSpline   WaveShape = "spline" // smooth curve through Points
+)
+
+var ErrInvalidWaveform = errs.New(errs.InvalidArgument, "invalid waveform")
+
+// Waveform drives one motor continuously while its pattern runs. Position
+// is Center + Amplitude*shape(Frequency*t + Phase), shapes swing -1..1.
+type Waveform struct {
+	Motor     MotorID
+	Shape     WaveShape
+	Center    float64 // degrees
+	Amplitude float64 // degrees
+	Frequency float64 // periods per second
+	Phase     float64 // fraction of period, 0..1
+
+	// Points are spline values -1..1 spaced evenly over one period,
+	// curve wraps from last point back to first
+	Points []float64
+}
+
+// Validate checks waveform parameters
+func (w Waveform) Validate() error {
+	if w.Frequency <= 0 {
+		return fmt.Errorf("%w: motor %s: frequency must be positive", ErrInvalidWaveform, w.Motor)
+	}
+	if w.Amplitude < 0 {
+		return fmt.Errorf("%w: motor %s: amplitude must not be negative", ErrInvalidWaveform, w.Motor)
+	}
+	switch w.Shape {
+	case WaveSine, WaveTriangle, WaveRamp:
+		return nil
+	case WaveSpline:
+		if len(w.Points) < 2 {
+			return fmt.Errorf("%w: motor %s: spline needs at least 2 points", ErrInvalidWaveform, w.Motor)
+		}
+		for _, p := range w.Points {
+			if p < -1 || p > 1 {
+				return fmt.Errorf("%w: motor %s: spline points must be within -1..1", ErrInvalidWaveform, w.Motor)
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("%w: motor %s: unknown shape %q", ErrInvalidWaveform, w.Motor, w.Shape)
+}
+
+// At returns motor position t into pattern
+func (w Waveform) At(t time.Duration) float64 {
+	_, x := math.Modf(w.Frequency*t.Seconds() + w.Phase)
+	if x < 0 {
+		x++
+	}
+	return w.Center + w.Amplitude*w.shape(x)
+}
+
+// shape evaluates unit waveform at fraction x of period
+func (w Waveform) shape(x float64) float64 {
+	switch w.Shape {
+	case WaveSine:
+		return math.Sin(2 * math.Pi * x)
+	case WaveTriangle:
+		// in phase with sine: zero, peak at quarter, trough at three quarters
+		switch {
+		case x < 0.25:
+			return 4 * x
+		case x < 0.75:
+			return 2 - 4*x
+		}
+		return 4*x - 4
+	case WaveRamp:
+		return 2*x - 1
+	case WaveSpline:
+		return catmullRom(w.Points, x)
+	}
+	return 0
+}
+
+// catmullRom interpolates closed Catmull-Rom spline through points at x
+func catmullRom(points []float64, x float64) float64 {
+	n := len(points)
+	u := x * float64(n)
+	i := int(u)
+	f := u - float64(i)
+	p0 := points[(i-1+n)%n]
+	p1 := points[i%n]
+	p2 := points[(i+1)%n]
+	p3 := points[(i+2)%n]
+	return 0.5 * (2*p1 + (p2-p0)*f + (2*p0-5*p1+4*p2-p3)*f*f + (3*p1-p0-3*p2+p3)*f*f*f)
+}
+
+// waveSegment is waveform active over part of pattern run, fading in
+// and out when crossfaded with neighbours
+type waveSegment struct {
+	wave            Waveform
+	from, to        time.Duration
+	fadeIn, fadeOut time.Duration
+}
+
+// weight is share of segment in motor position at t, zero when inactive
+func (s waveSegment) weight(t time.Duration) float64 {
+	if t < s.from || t >= s.to {
+		return 0
+	}
+	w := 1.0
+	if s.fadeIn > 0 && t-s.from < s.fadeIn {
+		w = float64(t-s.from) / float64(s.fadeIn)
+	}
+	if s.fadeOut > 0 && s.to-t < s.fadeOut {
+		w = math.Min(w, float64(s.to-t)/float64(s.fadeOut))
+	}
+	return w
+}
+
+// tickWaves moves targets of motors driven by waveforms of playing
+// patterns, caller holds mu
+func (c *Controller) tickWaves() {
+	runs := c.runs.Load()
+	if runs == nil {
+		return
+	}
+	for _, run := range *runs {
+		c.tickRunWaves(run)
+	}
+}
+
+// tickRunWaves moves targets of motors driven by waveforms of run, caller
+// holds mu. Overlapping segments of motor blend by weight. Motors chase
+// moving target at full allowed speed, logical ones within their
+// trajectory limits.
+func (c *Controller) tickRunWaves(run *patternRun) {
+	t := c.clock.Since(run.start)
+	for _, segments := range run.waves {
+		var sum, total float64
+		for _, s := range segments {
+			if w := s.weight(t); w > 0 {
+				sum += w * s.wave.At(t-s.from)
+				total += w
+			}
+		}
+		if total == 0 {
+			continue
+		}
+		id := segments[0].wave.Motor
+		slot, exists := c.motors[id]
+		if !exists {
+			continue
+		}
+		slot.mu.Lock()
+		if !slot.IsEnabled || slot.homing || slot.heat.cooling {
+			slot.mu.Unlock()
+			continue
+		}
+		lo, hi := slot.Travel()
+		pos := math.Max(lo, math.Min(sum/total, hi))
+		speed := c.motorSpeed(slot, slot.MaxSpeed)
+		slot.Target = pos
+		slot.cruise = speed
+		raw, rawSpeed := slot.shaft(pos), slot.Frame.Degrees(speed)
+		slot.mu.Unlock()
+
+		if c.driver != nil {
+			if err := c.driver.SetTarget(id, raw, rawSpeed); err != nil {
+				c.lastErr.Set(&MotorError{Motor: id, Err: err})
+			}
+		}
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"sync"
 	"time"
 
+	"github.com/sashalind/sex-artifical-intelligence/pkg/clock"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/health"
 	"github.com/sashalind/sex-artifical-intelligence/pkg/utils"
 )
 
@@ -19,6 +21,8 @@ type Network struct {
 	// network state
 	isTraining bool
 	lastUpdate time.Time
+	
+	clock      clock.Clock
 }
 
 // Layer represents single neural network layer
@@ -34,11 +38,18 @@ type ActivationFunc func(float64) float64
 
 // NewNetwork initializes new neural network with default parameters
 func NewNetwork() (*Network, error) {
+	return NewNetworkWithClock(clock.Real)
+}
+
+// NewNetworkWithClock initializes neural network using given clock
+func NewNetworkWithClock(clk clock.Clock) (*Network, error) {
+	clk = clock.OrReal(clk)
 	network := &Network{
+		clock:      clk,
 		weights:    make(map[string]float64),
 		biases:     make(map[string]float64),
 		isTraining: false,
-		lastUpdate: time.Now(),
+		lastUpdate: clk.Now(),
 	}
 	
 	// initialize default layers
@@ -85,12 +96,31 @@ func (n *Network) Train(dataset [][]float64) error {
 	
 	n.isTraining = true
 	// TODO: implement actual training
-	time.Sleep(time.Second) // simulate training
+	n.clock.Sleep(time.Second) // simulate training
 	n.isTraining = false
 	
 	return nil
 }
 
+// Health reports whether network is usable and its gauges. Network is down
+// after Shutdown released its weights.
+func (n *Network) Health() health.Report {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	
+	status := health.OK
+	if n.weights == nil {
+		status = health.Down
+	}
+	return health.Report{
+		Status: status,
+		Gauges: map[string]float64{
+			"layers":           float64(len(n.layers)),
+			"since_update_sec": n.clock.Since(n.lastUpdate).Seconds(),
+		},
+	}
+}
+
 // Shutdown gracefully stops neural network
 func (n *Network) Shutdown() {
 	n.mu.Lock()
@@ -0,0 +1,153 @@
+package nlp
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// MaxSequenceDelay bounds how far ahead step of compound command may run
+const MaxSequenceDelay = 24 * time.Hour
+
+// clause separators of compound commands, "slow down and then stop";
+// plain "and" separates only commands on both sides, see splitClauses
+var (
+	thenSeparator = regexp.MustCompile(`(?i)\s*(?:;|,?\s+and\s+then\s+|,?\s+then\s+|,\s*and\s+)\s*`)
+	andSeparator  = regexp.MustCompile(`(?i)\s+and\s+`)
+)
+
+// timeUnits convert delay said in words
+var timeUnits = map[string]time.Duration{
+	"s": time.Second, "sec": time.Second, "secs": time.Second, "second": time.Second, "seconds": time.Second,
+	"min": time.Minute, "mins": time.Minute, "minute": time.Minute, "minutes": time.Minute,
+	"h": time.Hour, "hr": time.Hour, "hrs": time.Hour, "hour": time.Hour, "hours": time.Hour,
+	"day": 24 * time.Hour, "days": 24 * time.Hour,
+}
+
+// delay words: "after"/"in"/"wait" put time before clause runs, "for" how
+// long it lasts before next one
+var (
+	delayWords = map[string]bool{"after": true, "in": true, "wait": true}
+	holdWords  = map[string]bool{"for": true}
+)
+
+// splitClauses splits text into clauses in order said. Plain "and" splits
+// only where known reports commands on both sides, "move left and right"
+// stays one.
+func splitClauses(text string, known func(string) bool) []string {
+	var clauses []string
+	for _, part := range thenSeparator.Split(strings.TrimSpace(text), -1) {
+		if part = strings.TrimSpace(part); part == "" {
+			continue
+		}
+		pieces := andSeparator.Split(part, -1)
+		cur := pieces[0]
+		for _, next := range pieces[1:] {
+			if known(cur) && known(next) {
+				clauses = append(clauses, cur)
+				cur = next
+			} else {
+				cur += " and " + next
+			}
+		}
+		clauses = append(clauses, cur)
+	}
+	return clauses
+}
+
+// clauseTiming takes timing phrases out of clause: delay before it ("stop
+// after one minute", "in 30 seconds") and how long it holds before next
+// clause ("move left for 10 seconds"). Rest is clause without them, empty
+// for bare "wait 5 seconds".
+func clauseTiming(clause string) (rest string, delay, hold time.Duration, err error) {
+	words := strings.Fields(clause)
+	kept := words[:0:0]
+	for i := 0; i < len(words); i++ {
+		w := strings.ToLower(trimWord(words[i]))
+		if !delayWords[w] && !holdWords[w] {
+			kept = append(kept, words[i])
+			continue
+		}
+		d, n, ok := parseDuration(words[i+1:])
+		if !ok {
+			kept = append(kept, words[i])
+			continue
+		}
+		if d > MaxSequenceDelay {
+			return "", 0, 0, fmt.Errorf("%w: %s %v, at most %v", ErrParamOutOfRange, w, d, MaxSequenceDelay)
+		}
+		if holdWords[w] {
+			hold += d
+		} else {
+			delay += d
+		}
+		i += n
+	}
+	return strings.Join(kept, " "), delay, hold, nil
+}
+
+// parseDuration reads "one minute", "30 seconds", "half a minute" or "a
+// minute" at start of words, returning words it took
+func parseDuration(words []string) (time.Duration, int, bool) {
+	lower := make([]string, len(words))
+	for i, w := range words {
+		lower[i] = strings.ToLower(trimWord(w))
+	}
+	v, n, ok := parseNumber(lower)
+	if !ok {
+		if len(lower) == 0 || (lower[0] != "a" && lower[0] != "an") {
+			return 0, 0, false
+		}
+		v, n = 1, 1
+	}
+	// "half a minute"
+	for n < len(lower) && fillers[lower[n]] {
+		n++
+	}
+	if n >= len(lower) {
+		return 0, 0, false
+	}
+	unit, ok := timeUnits[lower[n]]
+	if !ok || v < 0 {
+		return 0, 0, false
+	}
+	return time.Duration(v * float64(unit)), n + 1, true
+}
+
+// unclearStep asks for clause of timed compound command again, none of
+// its steps run until all are understood
+func unclearStep(clause string, now time.Time) *Command {
+	return &Command{
+		Type:       CmdClarify,
+		Parameters: map[string]interface{}{"question": fmt.Sprintf("I did not understand %q, please say the whole command again", clause)},
+		Priority:   1,
+		Confidence: 1,
+		Timestamp:  now,
+	}
+}
+
+// plan describes sequence steps for replies, "slow down, then stop after
+// 1m"
+func plan(steps []Command) string {
+	parts := make([]string, len(steps))
+	for i, step := range steps {
+		parts[i] = step.Source
+		if step.Delay > 0 {
+			parts[i] += " after " + shortDuration(step.Delay)
+		}
+	}
+	return strings.Join(parts, ", then ")
+}
+
+// shortDuration formats d without zero units, "1m" rather than "1m0s"
+func shortDuration(d time.Duration) string {
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
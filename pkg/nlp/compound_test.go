@@ -0,0 +1,175 @@
+package nlp
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sashalind/sex-artifical-intelligence/pkg/storage"
+)
+
+func TestSequence(t *testing.T) {
+	type step struct {
+		typ   CommandType
+		delay time.Duration
+	}
+	tests := []struct {
+		text  string
+		want  CommandType
+		steps []step
+		err   error
+	}{
+		{
+			text:  "move left slowly for 10 seconds then move right slowly",
+			want:  CmdSequence,
+			steps: []step{{CmdMove, 0}, {CmdMove, 10 * time.Second}},
+		},
+		{
+			text:  "move left and then status",
+			want:  CmdSequence,
+			steps: []step{{CmdMove, 0}, {CmdStatus, 0}},
+		},
+		{
+			text:  "slow down and then stop after one minute",
+			want:  CmdSequence,
+			steps: []step{{CmdAdjust, 0}, {CmdStop, time.Minute}},
+		},
+		{
+			text:  "stop after 30 seconds",
+			want:  CmdSequence,
+			steps: []step{{CmdStop, 30 * time.Second}},
+		},
+		{
+			text:  "in 5 seconds move left",
+			want:  CmdSequence,
+			steps: []step{{CmdMove, 5 * time.Second}},
+		},
+		{
+			text:  "wait half a minute then stop",
+			want:  CmdSequence,
+			steps: []step{{CmdStop, 30 * time.Second}},
+		},
+		{text: "move left and right", want: CmdMove},
+		{text: "move left and stop after two days", err: ErrParamOutOfRange},
+
+		// nothing said for later may run now when part of text is not
+		// understood
+		{text: "dance wildly and then stop after one minute", want: CmdClarify},
+		{text: "stop after a minute then juggle", want: CmdClarify},
+		{text: "blah then stop", want: CmdStop},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			p, _ := newTestProcessor(t, DefaultConfig())
+			cmd, err := p.ProcessCommand(tt.text)
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("ProcessCommand(%q) error = %v, want %v", tt.text, err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ProcessCommand(%q): %v", tt.text, err)
+			}
+			if cmd.Type != tt.want {
+				t.Fatalf("ProcessCommand(%q) = %s %v, want %s", tt.text, cmd.Type, cmd.Parameters, tt.want)
+			}
+			if len(cmd.Steps) != len(tt.steps) {
+				t.Fatalf("ProcessCommand(%q) has %d steps, want %d", tt.text, len(cmd.Steps), len(tt.steps))
+			}
+			for i, s := range tt.steps {
+				if got := cmd.Steps[i]; got.Type != s.typ || got.Delay != s.delay {
+					t.Errorf("step %d = %s after %v, want %s after %v", i, got.Type, got.Delay, s.typ, s.delay)
+				}
+			}
+		})
+	}
+}
+
+func TestUnclearStepLogged(t *testing.T) {
+	p, _ := newTestProcessor(t, DefaultConfig())
+	cmd, err := p.ProcessCommand("dance wildly and then stop after one minute")
+	if err != nil {
+		t.Fatalf("ProcessCommand: %v", err)
+	}
+	if q, _ := cmd.Parameters["question"].(string); !strings.Contains(q, "dance wildly") {
+		t.Errorf("question %q does not name unclear clause", q)
+	}
+	logged := p.Misunderstood()
+	if len(logged) != 1 || logged[0].Text != "dance wildly" || logged[0].Reason != ReasonUnknown {
+		t.Errorf("misunderstood = %+v, want unknown %q", logged, "dance wildly")
+	}
+}
+
+func TestRedactedAudit(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Redact = true
+	p, _ := newTestProcessor(t, cfg)
+	store, err := storage.Open(t.TempDir(), nil)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	if err := p.AttachStore(store); err != nil {
+		t.Fatalf("AttachStore: %v", err)
+	}
+
+	if _, err := p.ProcessCommand("dance wildly and then stop after one minute"); err != nil {
+		t.Fatalf("ProcessCommand: %v", err)
+	}
+	if logged := p.Misunderstood(); len(logged) != 0 {
+		t.Errorf("misunderstood = %+v, want nothing logged", logged)
+	}
+	if n := p.Stats().Misunderstood; n != 1 {
+		t.Errorf("misunderstood count = %d, want 1", n)
+	}
+
+	audit, err := storage.OpenTable[Command](store, storage.BucketCommands)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored, err := audit.All()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stored) != 1 {
+		t.Fatalf("audit has %d commands, want 1", len(stored))
+	}
+	cmds := append([]Command{stored[0]}, stored[0].Steps...)
+	for _, cmd := range cmds {
+		if cmd.Parameters != nil || cmd.Source != "" {
+			t.Errorf("audited %s keeps %q and %v", cmd.Type, cmd.Source, cmd.Parameters)
+		}
+	}
+	mis, err := storage.OpenTable[Misunderstanding](store, storage.BucketMisunderstood)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if logged, err := mis.All(); err != nil || len(logged) != 0 {
+		t.Errorf("misunderstood log = %+v, %v, want empty", logged, err)
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		text string
+		want time.Duration
+		n    int
+		ok   bool
+	}{
+		{"one minute", time.Minute, 2, true},
+		{"30 seconds later", 30 * time.Second, 2, true},
+		{"half a minute", 30 * time.Second, 3, true},
+		{"a minute", time.Minute, 2, true},
+		{"2 days", 48 * time.Hour, 2, true},
+		{"lunch", 0, 0, false},
+		{"5", 0, 0, false},
+	}
+	for _, tt := range tests {
+		d, n, ok := parseDuration(strings.Fields(tt.text))
+		if d != tt.want || n != tt.n || ok != tt.ok {
+			t.Errorf("parseDuration(%q) = %v, %d, %v, want %v, %d, %v", tt.text, d, n, ok, tt.want, tt.n, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package nlp
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Config holds NLP processor options
+type Config struct {
+	// HistorySize limits commands and responses kept in memory and on disk
+	HistorySize int
+	
+	// IntentFile is labeled training file of intent classifier, empty uses
+	// built-in examples
+	IntentFile string
+	
+	// MinConfidence is classifier confidence below which intent falls back
+	// to keywords, zero is DefaultMinConfidence
+	MinConfidence float64
+	
+	// ConfirmConfidence is intent confidence below which command is asked
+	// to be confirmed before it runs, zero never asks
+	ConfirmConfidence float64
+	
+	// DialogTimeout is how long question about missing parameter or
+	// confirmation waits for answer, zero is DefaultDialogTimeout
+	DialogTimeout time.Duration
+	
+	// VocabularyFile lists operator phrases per command type, see
+	// LoadVocabulary; it is read again on every SetConfig
+	VocabularyFile string
+	
+	// Safeword is phrase that stops everything at once whatever else
+	// command says, empty for none
+	Safeword string
+	
+	// ExactKeywords turns off spelling correction of command words
+	ExactKeywords bool
+	
+	// WakeWord is phrase text must name to be taken as command, "robot";
+	// WakeWindow after it no wake word is needed, zero is
+	// DefaultWakeWindow. Empty takes all text as commands.
+	WakeWord   string
+	WakeWindow time.Duration
+	
+	// Personality names profile of reply templates, empty is
+	// DefaultPersonality
+	Personality string
+	
+	// PersonalityDir holds personality profile files besides built-in
+	// ones, see LoadPersonalities; it is read again on every SetConfig
+	PersonalityDir string
+	
+	// LLM parses free-form commands local parsing does not understand,
+	// disabled when its URL is empty
+	LLM LLMConfig
+	
+	// Redact keeps command parameters and text out of persisted audit and
+	// counts misunderstood text without logging it
+	Redact bool
+}
+
+// DefaultConfig returns default NLP options
+func DefaultConfig() Config {
+	return Config{
+		HistorySize:       1000,
+		ConfirmConfidence: DefaultConfirmConfidence,
+	}
+}
+
+// Validate checks NLP options
+func (c Config) Validate() error {
+	if c.HistorySize <= 0 {
+		return errors.New("nlp history size must be positive")
+	}
+	if c.MinConfidence < 0 || c.MinConfidence > 1 {
+		return errors.New("nlp min confidence must be within 0 and 1")
+	}
+	if c.ConfirmConfidence < 0 || c.ConfirmConfidence > 1 {
+		return errors.New("nlp confirm confidence must be within 0 and 1")
+	}
+	if c.DialogTimeout < 0 {
+		return errors.New("nlp dialog timeout must not be negative")
+	}
+	if c.WakeWindow < 0 {
+		return errors.New("nlp wake window must not be negative")
+	}
+	if c.WakeWord != "" && normalizePhrase(c.WakeWord) == "" {
+		return errors.New("nlp wake word has no words")
+	}
+	if err := c.LLM.Validate(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// minConfidence returns MinConfidence with default applied
+func (c Config) minConfidence() float64 {
+	if c.MinConfidence == 0 {
+		return DefaultMinConfidence
+	}
+	return c.MinConfidence
+}
+
+// dialogTimeout returns DialogTimeout with default applied
+func (c Config) dialogTimeout() time.Duration {
+	if c.DialogTimeout == 0 {
+		return DefaultDialogTimeout
+	}
+	return c.DialogTimeout
+}
+
+// wakeWindow returns WakeWindow with default applied
+func (c Config) wakeWindow() time.Duration {
+	if c.WakeWindow == 0 {
+		return DefaultWakeWindow
+	}
+	return c.WakeWindow
+}
+
+// vocabulary reads VocabularyFile, none when it is empty
+func (c Config) vocabulary() (Vocabulary, error) {
+	if c.VocabularyFile == "" {
+		return nil, nil
+	}
+	return LoadVocabulary(c.VocabularyFile)
+}
+
+// classifier trains intent classifier of IntentFile, built-in one when
+// there is none
+func (c Config) classifier() (*Classifier, error) {
+	if c.IntentFile == "" {
+		return DefaultClassifier(), nil
+	}
+	examples, err := LoadTrainingFile(c.IntentFile)
+	if err != nil {
+		return nil, err
+	}
+	return TrainClassifier(examples)
+}
+
+// personality loads profile Personality selects
+func (c Config) personality() (*Personality, error) {
+	set, err := LoadPersonalities(c.PersonalityDir)
+	if err != nil {
+		return nil, err
+	}
+	name := c.Personality
+	if name == "" {
+		name = DefaultPersonality
+	}
+	p, ok := set[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: no personality %q, have %s", ErrInvalidPersonality, name, strings.Join(set.Names(), ", "))
+	}
+	return p, nil
+}
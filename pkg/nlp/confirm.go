@@ -0,0 +1,112 @@
+package nlp
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DefaultConfirmConfidence is intent confidence below which command waits
+// for user to confirm it
+const DefaultConfirmConfidence = 0.7
+
+// answers to confirmation question; rest of words may only be fillers
+var (
+	yesWords = map[string]bool{
+		"yes": true, "yeah": true, "yep": true, "sure": true, "confirm": true,
+		"confirmed": true, "correct": true, "ok": true, "okay": true, "right": true, "go": true, "ahead": true,
+	}
+	noWords = map[string]bool{
+		"no": true, "nope": true, "cancel": true, "wrong": true, "nevermind": true, "never": true, "mind": true,
+	}
+	answerFillers = map[string]bool{"please": true, "do": true, "it": true, "that": true, "i": true, "did": true, "meant": true, "mean": true}
+)
+
+// cancelQuestion answers declined confirmation
+const cancelQuestion = "Okay, what should I do instead?"
+
+// needsConfirm reports whether command is too uncertain to run unasked.
+// Stop and safeword never wait, nor does what moves nothing.
+func needsConfirm(cmd *Command, threshold float64) bool {
+	switch cmd.Type {
+	case CmdStop, CmdSafeword, CmdStatus, CmdClarify, CmdUnknown:
+		return false
+	}
+	return cmd.Confidence < threshold
+}
+
+// askConfirm holds cmd of text until user confirms it, returning question
+// to ask
+func (c *Conversation) askConfirm(cmd *Command, text string, now time.Time, timeout time.Duration) *Command {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.unconfirmed, c.confirmBy = cloneCommand(cmd), now.Add(timeout)
+	c.unconfirmed.Source = text
+	return &Command{
+		Type: CmdClarify,
+		Parameters: map[string]interface{}{
+			"question": fmt.Sprintf("Did you mean %s?", describe(cmd)),
+			"command":  string(cmd.Type),
+			"confirm":  true,
+		},
+		Priority:   cmd.Priority,
+		Confidence: cmd.Confidence,
+		Sentiment:  cmd.Sentiment,
+		Urgency:    cmd.Urgency,
+	}
+}
+
+// answerConfirm reads text as answer to confirmation question: yes returns
+// held command, now certain; no returns question what to do instead and
+// declined command, its Source is text it was made of. Any other text
+// drops held command and reports false, it is command of its own.
+func (c *Conversation) answerConfirm(text string, now time.Time) (cmd, declined *Command, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	held := c.unconfirmed
+	c.unconfirmed = nil
+	if held == nil || !now.Before(c.confirmBy) {
+		return nil, nil, false
+	}
+
+	yes, no := false, false
+	for _, w := range strings.Fields(strings.ToLower(text)) {
+		w = trimWord(w)
+		switch {
+		case yesWords[w]:
+			yes = true
+		case noWords[w]:
+			no = true
+		case !answerFillers[w]:
+			return nil, nil, false
+		}
+	}
+	switch {
+	case no:
+		return &Command{
+			Type:       CmdClarify,
+			Parameters: map[string]interface{}{"question": cancelQuestion},
+			Priority:   1,
+			Confidence: 1,
+		}, held, true
+	case yes:
+		held.Confidence = 1
+		held.Source = ""
+		return held, nil, true
+	}
+	return nil, nil, false
+}
+
+// describe names command for confirmation question, "move left"
+func describe(cmd *Command) string {
+	if cmd.Type == CmdSequence {
+		return plan(cmd.Steps)
+	}
+	parts := []string{string(cmd.Type)}
+	for _, name := range []string{"direction", "pattern", "preset"} {
+		if v, ok := cmd.Parameters[name].(string); ok {
+			parts = append(parts, v)
+		}
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,118 @@
+package nlp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sashalind/sex-artifical-intelligence/pkg/clock"
+)
+
+func newTestProcessor(t *testing.T, cfg Config) (*Processor, *clock.Fake) {
+	t.Helper()
+	clk := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	p, err := NewProcessorWithConfig(clk, cfg)
+	if err != nil {
+		t.Fatalf("NewProcessorWithConfig: %v", err)
+	}
+	t.Cleanup(p.Shutdown)
+	return p, clk
+}
+
+func TestKeywordCommandsRunUnconfirmed(t *testing.T) {
+	tests := []struct {
+		text string
+		want CommandType
+	}{
+		{"move", CmdMove},
+		{"move left", CmdMove},
+		{"rotate", CmdMove},
+		{"speed 0.5", CmdAdjust},
+		{"set speed 0.5", CmdAdjust},
+		{"intensity 0.3", CmdAdjust},
+		{"status", CmdStatus},
+		{"stop", CmdStop},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			p, _ := newTestProcessor(t, DefaultConfig())
+			cmd, err := p.ProcessCommand(tt.text)
+			if err != nil {
+				t.Fatalf("ProcessCommand(%q): %v", tt.text, err)
+			}
+			if cmd.Type != tt.want {
+				t.Fatalf("ProcessCommand(%q) = %s (confidence %.2f), want %s", tt.text, cmd.Type, cmd.Confidence, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfirmation(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		answer  string
+		after   time.Duration
+		want    CommandType
+		decline bool
+	}{
+		{name: "yes runs held command", text: "mve left", answer: "yes", want: CmdMove},
+		{name: "filler around yes", text: "mve left", answer: "yes please do it", want: CmdMove},
+		{name: "no declines", text: "mve left", answer: "no", want: CmdClarify, decline: true},
+		{name: "other text is new command", text: "mve left", answer: "status", want: CmdStatus},
+		{name: "late yes is not answer", text: "mve left", answer: "yes", after: time.Hour, want: CmdUnknown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, clk := newTestProcessor(t, DefaultConfig())
+			q, err := p.ProcessCommand(tt.text)
+			if err != nil {
+				t.Fatalf("ProcessCommand(%q): %v", tt.text, err)
+			}
+			if q.Type != CmdClarify || q.Parameters["confirm"] != true {
+				t.Fatalf("ProcessCommand(%q) = %s %v, want confirmation question", tt.text, q.Type, q.Parameters)
+			}
+
+			clk.Advance(tt.after)
+			cmd, err := p.ProcessCommand(tt.answer)
+			if err != nil {
+				t.Fatalf("ProcessCommand(%q): %v", tt.answer, err)
+			}
+			if cmd.Type != tt.want {
+				t.Fatalf("answer %q gave %s, want %s", tt.answer, cmd.Type, tt.want)
+			}
+			if cmd.Type == CmdMove && (cmd.Confidence != 1 || cmd.Parameters["direction"] != "left") {
+				t.Errorf("confirmed command = %+v, want certain move left", cmd)
+			}
+
+			declined := false
+			for _, m := range p.Misunderstood() {
+				declined = declined || m.Reason == ReasonDeclined
+			}
+			if declined != tt.decline {
+				t.Errorf("declined logged = %v, want %v", declined, tt.decline)
+			}
+		})
+	}
+}
+
+func TestNeedsConfirm(t *testing.T) {
+	tests := []struct {
+		typ        CommandType
+		confidence float64
+		want       bool
+	}{
+		{CmdMove, 0.5, true},
+		{CmdMove, DefaultConfirmConfidence, false},
+		{CmdAdjust, 0.65, true},
+		{CmdStop, 0.1, false},
+		{CmdSafeword, 0.1, false},
+		{CmdStatus, 0.1, false},
+		{CmdUnknown, 0, false},
+	}
+	for _, tt := range tests {
+		cmd := &Command{Type: tt.typ, Confidence: tt.confidence}
+		if got := needsConfirm(cmd, DefaultConfirmConfidence); got != tt.want {
+			t.Errorf("needsConfirm(%s, %.2f) = %v, want %v", tt.typ, tt.confidence, got, tt.want)
+		}
+	}
+}
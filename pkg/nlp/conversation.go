@@ -0,0 +1,136 @@
+package nlp
+
+import (
+	"maps"
+	"strings"
+	"sync"
+	"time"
+)
+
+// repeatWords make up command repeating previous one, "do that again";
+// one of them must be in repeatTriggers
+var (
+	repeatWords = map[string]bool{
+		"do": true, "that": true, "it": true, "this": true, "the": true, "same": true, "thing": true,
+		"again": true, "repeat": true, "once": true, "more": true, "one": true, "time": true,
+		"please": true, "now": true, "encore": true,
+	}
+	repeatTriggers = []string{"again", "repeat", "encore", "more"}
+)
+
+// words referring to what is going on, "stop that one"
+var referenceWords = map[string]bool{"that": true, "it": true, "this": true, "one": true}
+
+// Conversation remembers what references in later commands point to:
+// previous command and pattern playing, and command waiting for
+// confirmation. Zero value remembers nothing.
+type Conversation struct {
+	mu      sync.Mutex
+	last    *Command
+	pattern string
+
+	// uncertain command waiting for confirmation until confirmBy
+	unconfirmed *Command
+	confirmBy   time.Time
+
+	// text needs no wake word until then, see wake.go
+	awakeUntil time.Time
+}
+
+// SetPattern names pattern playing now, empty when none
+func (c *Conversation) SetPattern(name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.pattern = name
+}
+
+// Pattern returns name of pattern playing, empty when none
+func (c *Conversation) Pattern() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.pattern
+}
+
+// Last returns previous command, nil if none
+func (c *Conversation) Last() *Command {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.last == nil {
+		return nil
+	}
+	return cloneCommand(c.last)
+}
+
+// remember makes cmd previous command. Questions and status are not
+// something to repeat, they are skipped.
+func (c *Conversation) remember(cmd *Command) {
+	if cmd.Type == CmdClarify || cmd.Type == CmdStatus || cmd.Type == CmdUnknown {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.last = cloneCommand(cmd)
+}
+
+// resolve turns command referring to previous one or to playing pattern
+// into command it means: "do that again" repeats previous command and
+// "stop that one" names pattern it stops. "Faster" refers to nothing, it
+// is relative adjustment of its own. It reports false for commands
+// referring to nothing.
+func (c *Conversation) resolve(text string) (*Command, bool) {
+	words := strings.Fields(strings.ToLower(text))
+	for i := range words {
+		words[i] = trimWord(words[i])
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, w := range words {
+		if containsWord(stopKeywords, w) {
+			if c.pattern == "" || !refers(words) {
+				return nil, false
+			}
+			return &Command{
+				Type:       CmdStop,
+				Parameters: map[string]interface{}{"pattern": c.pattern},
+				Priority:   10,
+				Confidence: 1,
+			}, true
+		}
+	}
+
+	if c.last != nil && repeats(words) {
+		return cloneCommand(c.last), true
+	}
+	return nil, false
+}
+
+// repeats reports whether words only ask to repeat, "move left again" is
+// command of its own
+func repeats(words []string) bool {
+	trigger := false
+	for _, w := range words {
+		if !repeatWords[w] {
+			return false
+		}
+		trigger = trigger || containsWord(repeatTriggers, w)
+	}
+	return trigger
+}
+
+// refers reports whether words point at something, "that one", "it"
+func refers(words []string) bool {
+	for _, w := range words {
+		if referenceWords[w] {
+			return true
+		}
+	}
+	return false
+}
+
+func cloneCommand(cmd *Command) *Command {
+	out := *cmd
+	out.Parameters = maps.Clone(cmd.Parameters)
+	return &out
+}
@@ -0,0 +1,154 @@
+package nlp
+
+import (
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultDialogTimeout is how long follow-up question waits for answer
+const DefaultDialogTimeout = 30 * time.Second
+
+// requiredSlots are parameters command type needs before it runs, asked
+// for in order
+var requiredSlots = map[CommandType][]string{
+	CmdMove: {"speed", "direction"},
+}
+
+// slotQuestions ask user for missing parameter
+var slotQuestions = map[string]string{
+	"speed":     "How fast should I move?",
+	"direction": "Which direction should I move?",
+}
+
+// Dialog tracks command waiting for parameters user was asked for and
+// conversation references resolve against. Each conversation keeps its
+// own, zero value is idle dialog.
+type Dialog struct {
+	Conversation
+	
+	mu       sync.Mutex
+	pending  *Command
+	slot     string
+	deadline time.Time
+	known    []string
+}
+
+// NewDialog returns idle dialog
+func NewDialog() *Dialog {
+	return &Dialog{}
+}
+
+// Pending returns command waiting for answer and parameter asked for, nil
+// when dialog is idle or answer is overdue
+func (d *Dialog) Pending(now time.Time) (*Command, string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.pending == nil || !now.Before(d.deadline) {
+		return nil, ""
+	}
+	return cloneCommand(d.pending), d.slot
+}
+
+// Reset drops pending command
+func (d *Dialog) Reset() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.pending, d.slot = nil, ""
+}
+
+// SetKnown replaces parameters known from elsewhere, e.g. default speed of
+// user profile; they are not asked for
+func (d *Dialog) SetKnown(slots ...string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.known = slices.Clone(slots)
+}
+
+// step advances dialog by text parsed as cmd, returning command to run,
+// question to ask as CmdClarify command, or error of answer
+func (d *Dialog) step(text string, cmd *Command, now time.Time, timeout time.Duration) (*Command, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.pending != nil && !now.Before(d.deadline) {
+		d.pending, d.slot = nil, ""
+	}
+
+	if d.pending != nil && (cmd == nil || !cmd.Type.halts()) {
+		v, ok, err := fillSlot(d.slot, text)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			d.pending.Parameters[d.slot] = v
+			cmd = d.pending
+			d.pending, d.slot = nil, ""
+		} else if cmd != nil && cmd.Type != CmdUnknown {
+			// user moved on to another command
+			d.pending, d.slot = nil, ""
+		} else {
+			d.deadline = now.Add(timeout)
+			return d.question(), nil
+		}
+	} else {
+		d.pending, d.slot = nil, ""
+	}
+	if cmd == nil {
+		return nil, nil
+	}
+
+	for _, slot := range requiredSlots[cmd.Type] {
+		if _, ok := cmd.Parameters[slot]; ok || slices.Contains(d.known, slot) {
+			continue
+		}
+		d.pending, d.slot, d.deadline = cmd, slot, now.Add(timeout)
+		return d.question(), nil
+	}
+	return cmd, nil
+}
+
+// question is clarify command asking for pending slot
+func (d *Dialog) question() *Command {
+	return &Command{
+		Type: CmdClarify,
+		Parameters: map[string]interface{}{
+			"slot":     d.slot,
+			"question": slotQuestions[d.slot],
+			"command":  string(d.pending.Type),
+		},
+		Priority:   d.pending.Priority,
+		Confidence: d.pending.Confidence,
+	}
+}
+
+// fillSlot reads answer to question about slot, on its own ("left",
+// "slowly", "half") or named ("speed 0.5")
+func fillSlot(slot, text string) (interface{}, bool, error) {
+	words := strings.Fields(strings.ToLower(text))
+	for i, w := range words {
+		if trimWord(w) == slot {
+			words = words[i+1:]
+			break
+		}
+	}
+	switch slot {
+	case "speed":
+		for _, w := range words {
+			if v, ok := speedWords[trimWord(w)]; ok {
+				return v, true, nil
+			}
+		}
+		if v, ok, err := parseParam(slot, words, kindFraction); ok || err != nil {
+			return v, ok, err
+		}
+	case "direction":
+		for _, w := range words {
+			if w = trimWord(w); directionWords[w] {
+				return w, true, nil
+			}
+		}
+	}
+	return nil, false, nil
+}
@@ -0,0 +1,208 @@
+package nlp
+
+import (
+	"maps"
+	"strings"
+	"sync"
+)
+
+// fuzzyConfidence scales confidence of command per word spelling
+// correction changed: below DefaultConfirmConfidence, so guessed commands
+// are confirmed before they run
+const fuzzyConfidence = 0.65
+
+// minFuzzyLength is length of shortest word corrected; shorter ones are
+// too close to each other, "go" and "no"
+const minFuzzyLength = 3
+
+// commonWords are never corrected though command words do not use them
+var commonWords = map[string]bool{
+	"and": true, "then": true, "you": true, "me": true, "let": true, "lets": true,
+	"let's": true, "can": true, "could": true, "would": true, "will": true, "now": true,
+	"want": true, "need": true, "just": true, "with": true, "from": true, "this": true,
+	"what": true, "how": true, "why": true, "who": true, "are": true, "was": true,
+	"get": true, "got": true, "put": true, "but": true, "all": true, "any": true,
+	"your": true, "our": true, "his": true, "her": true, "its": true, "them": true,
+	"stay": true,
+}
+
+// fuzzyTargets are words misspelled ones are corrected to: command
+// keywords, parameter names, directions and speed and change words
+var fuzzyTargets = sync.OnceValue(func() map[string]bool {
+	t := make(map[string]bool)
+	for _, list := range [][]string{
+		moveKeywords, stopKeywords, adjustKeywords, statusKeywords, pauseKeywords,
+		resumeKeywords, patternKeywords, presetKeywords, adjustableParams,
+	} {
+		for _, w := range list {
+			t[w] = true
+		}
+	}
+	for _, m := range []map[string]bool{paramNames, directionWords} {
+		maps.Copy(t, m)
+	}
+	for w := range speedWords {
+		t[w] = true
+	}
+	for _, m := range []map[string]float64{speedChanges, changeVerbs, changeDirections} {
+		for w := range m {
+			t[w] = true
+		}
+	}
+	return t
+})
+
+// knownWords are words left as they are besides fuzzyTargets: fillers,
+// numbers, units, step sizes, and mood and answer words
+var knownWords = sync.OnceValue(func() map[string]bool {
+	k := maps.Clone(commonWords)
+	for _, m := range []map[string]bool{
+		fillers, nameFillers, percentWords, negators, delayWords, holdWords,
+		repeatWords, referenceWords, yesWords, noWords, answerFillers,
+	} {
+		maps.Copy(k, m)
+	}
+	for _, m := range []map[string]float64{
+		numberWords, fractionWords, lengthUnits, sentimentLexicon, urgencyWords, intensifiers, stepWords,
+	} {
+		for w := range m {
+			k[w] = true
+		}
+	}
+	for w := range timeUnits {
+		k[w] = true
+	}
+	return k
+})
+
+// correctSpelling replaces misspelled command words with words they are
+// closest to, returning corrected words and number of corrections. Words
+// classifier or vocabulary know, names following pattern and preset
+// keywords and anything not made of letters stay as they are.
+func correctSpelling(words []string, opts ParseOptions) ([]string, int) {
+	targets, known := fuzzyTargets(), knownWords()
+	vocab := opts.Vocabulary.words()
+	var trained, trainedTargets map[string]bool
+	if opts.Classifier != nil {
+		trained, trainedTargets = opts.Classifier.words, opts.Classifier.targets
+	}
+
+	out := make([]string, len(words))
+	copy(out, words)
+	n := 0
+	for i, raw := range words {
+		w := trimWord(raw)
+		if containsWord(patternKeywords, w) || containsWord(presetKeywords, w) {
+			break
+		}
+		if len(w) < minFuzzyLength || !letters(w) || targets[w] || known[w] || vocab[w] || trained[w] {
+			continue
+		}
+		best, ok := closestWord(w, targets, vocab, trainedTargets)
+		if !ok {
+			continue
+		}
+		out[i] = strings.Replace(raw, w, best, 1)
+		n++
+		if containsWord(patternKeywords, best) || containsWord(presetKeywords, best) {
+			break
+		}
+	}
+	return out, n
+}
+
+// closestWord finds target word w is likely misspelling of: within edit
+// distance of one for short words and two for longer ones, or one more
+// when it sounds alike. Ties go to word sounding alike, then to first in
+// alphabet, so result never depends on map order.
+func closestWord(w string, sets ...map[string]bool) (string, bool) {
+	limit := 1
+	if len(w) >= 5 {
+		limit = 2
+	}
+	key := skeleton(w)
+	best, bestDist, bestAlike := "", limit+2, false
+	for _, set := range sets {
+		for t := range set {
+			if len(t) < minFuzzyLength {
+				continue
+			}
+			alike := skeleton(t) == key
+			d := editDistance(w, t)
+			if d > limit && !(alike && d == limit+1) {
+				continue
+			}
+			if d < bestDist || (d == bestDist && (alike && !bestAlike || alike == bestAlike && t < best)) {
+				best, bestDist, bestAlike = t, d, alike
+			}
+		}
+	}
+	return best, best != ""
+}
+
+// words returns single words of operator phrases
+func (v Vocabulary) words() map[string]bool {
+	set := make(map[string]bool)
+	for _, phrases := range v {
+		for _, p := range phrases {
+			for _, w := range strings.Fields(normalizePhrase(p)) {
+				set[w] = true
+			}
+		}
+	}
+	return set
+}
+
+// skeleton is rough phonetic key of word: first letter and following
+// consonants without repeats, "speed" and "spede" both give "spd"
+func skeleton(w string) string {
+	var b strings.Builder
+	var last rune
+	for i, r := range w {
+		if i > 0 && strings.ContainsRune("aeiouy", r) {
+			continue
+		}
+		if r != last {
+			b.WriteRune(r)
+		}
+		last = r
+	}
+	return b.String()
+}
+
+// editDistance counts insertions, deletions, substitutions and swaps of
+// adjacent letters turning a into b
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	// rows i-2, i-1 and i of distance table
+	prev2 := make([]int, len(rb)+1)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				cur[j] = min(cur[j], prev2[j-2]+1)
+			}
+		}
+		prev2, prev, cur = prev, cur, prev2
+	}
+	return prev[len(rb)]
+}
+
+func letters(w string) bool {
+	for _, r := range w {
+		if r < 'a' || r > 'z' {
+			return false
+		}
+	}
+	return true
+}
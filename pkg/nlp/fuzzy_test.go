@@ -0,0 +1,146 @@
+package nlp
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"stop", "stop", 0},
+		{"stp", "stop", 1},
+		{"stopp", "stop", 1},
+		{"sotp", "stop", 1},
+		{"spede", "speed", 1},
+		{"mvoe", "move", 1},
+		{"hault", "halt", 1},
+		{"intnsty", "intensity", 2},
+		{"", "move", 4},
+		{"abc", "", 3},
+		{"ca", "abc", 3},
+		{"ü", "u", 1},
+	}
+	for _, tt := range tests {
+		if got := editDistance(tt.a, tt.b); got != tt.want {
+			t.Errorf("editDistance(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+		if got := editDistance(tt.b, tt.a); got != tt.want {
+			t.Errorf("editDistance(%q, %q) = %d, want %d", tt.b, tt.a, got, tt.want)
+		}
+	}
+}
+
+func TestSkeleton(t *testing.T) {
+	for w, want := range map[string]string{
+		"speed": "spd", "spede": "spd", "stop": "stp", "stoop": "stp",
+		"intensity": "intnst", "up": "up", "": "",
+	} {
+		if got := skeleton(w); got != want {
+			t.Errorf("skeleton(%q) = %q, want %q", w, got, want)
+		}
+	}
+}
+
+func TestCorrectSpelling(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+		n    int
+	}{
+		{"stp", "stop", 1},
+		{"spede 0.2", "speed 0.2", 1},
+		{"mve lft", "move left", 2},
+		{"adjst intensty 0.3", "adjust intensity 0.3", 2},
+		{"stpo!", "stop!", 1},
+		{"paus", "pause", 1},
+		{"resme", "resume", 1},
+		// phonetic match allows one edit more, "stooop" is three away
+		{"stooop", "stop", 1},
+		// too short, not letters or too far from any command word
+		{"go", "go", 0},
+		{"mv", "mv", 0},
+		{"st0p", "st0p", 0},
+		{"xylophone", "xylophone", 0},
+		// pattern and preset names stay as said
+		{"play patern wavee", "play patern wavee", 0},
+		{"lod preset gentle", "lod preset gentle", 0},
+		{"paly wavee", "play wavee", 1},
+		// real words are not misspelled command words
+		{"how are things going", "how are things going", 0},
+		{"please hold still", "please hold still", 0},
+		{"make it stronger", "make it stronger", 0},
+		{"stay right there", "stay right there", 0},
+		{"thank you so much", "thank you so much", 0},
+		{"two and a half", "two and a half", 0},
+	}
+	opts := ParseOptions{Classifier: DefaultClassifier()}
+	for _, tt := range tests {
+		words, n := correctSpelling(strings.Fields(tt.text), opts)
+		if got := strings.Join(words, " "); got != tt.want || n != tt.n {
+			t.Errorf("correctSpelling(%q) = %q, %d, want %q, %d", tt.text, got, n, tt.want, tt.n)
+		}
+	}
+}
+
+// TestTypoConfidence checks misspelled commands resolve to intent of
+// corrected text, each correction scaling confidence by fuzzyConfidence
+// so commands that move ask for confirmation
+func TestTypoConfidence(t *testing.T) {
+	tests := []struct {
+		text       string
+		want       CommandType
+		confidence float64
+		confirm    bool
+	}{
+		{"stp", CmdStop, fuzzyConfidence, false},
+		{"sotp", CmdStop, fuzzyConfidence, false},
+		{"hault", CmdStop, fuzzyConfidence, false},
+		{"staus", CmdStatus, fuzzyConfidence, false},
+		{"paus", CmdPause, fuzzyConfidence, true},
+		{"mvoe left", CmdMove, fuzzyConfidence, true},
+		{"mve lft", CmdMove, fuzzyConfidence * fuzzyConfidence, true},
+		{"adjst intensty 0.3", CmdAdjust, fuzzyConfidence * fuzzyConfidence, true},
+		{"move left", CmdMove, 1, false},
+	}
+	for _, tt := range tests {
+		cmd, err := Parse(tt.text)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", tt.text, err)
+		}
+		if cmd.Type != tt.want || math.Abs(cmd.Confidence-tt.confidence) > 1e-9 {
+			t.Errorf("Parse(%q) = %s %.3f, want %s %.3f", tt.text, cmd.Type, cmd.Confidence, tt.want, tt.confidence)
+		}
+		if got := needsConfirm(cmd, DefaultConfirmConfidence); got != tt.confirm {
+			t.Errorf("Parse(%q) at %.3f needs confirmation %v, want %v", tt.text, cmd.Confidence, got, tt.confirm)
+		}
+
+		exact, err := ParseWith(tt.text, ParseOptions{Classifier: DefaultClassifier(), MinConfidence: DefaultMinConfidence, Exact: true})
+		if err != nil {
+			t.Fatalf("ParseWith(%q): %v", tt.text, err)
+		}
+		if tt.confidence < 1 && exact.Type == tt.want && exact.Confidence >= cmd.Confidence {
+			t.Errorf("ParseWith(%q, exact) = %s %.3f, want typo not corrected", tt.text, exact.Type, exact.Confidence)
+		}
+	}
+}
+
+// TestTypoParameters checks corrected parameter names keep their values
+// and range checks
+func TestTypoParameters(t *testing.T) {
+	cmd, err := Parse("intensty 0.5")
+	if err != nil || cmd.Type != CmdAdjust || cmd.Parameters["intensity"] != 0.5 {
+		t.Errorf("Parse(intensty 0.5) = %+v, %v", cmd, err)
+	}
+	cmd, err = Parse("move sped 0.5")
+	if err != nil || cmd.Type != CmdMove || cmd.Parameters["speed"] != 0.5 || cmd.Confidence != fuzzyConfidence {
+		t.Errorf("Parse(move sped 0.5) = %+v, %v", cmd, err)
+	}
+	if _, err := Parse("spede 2"); !errors.Is(err, ErrParamOutOfRange) {
+		t.Errorf("Parse(spede 2) = %v, want ErrParamOutOfRange", err)
+	}
+}
@@ -0,0 +1,230 @@
+package nlp
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/sashalind/sex-artifical-intelligence/pkg/core/errs"
+)
+
+// ErrInvalidTraining is returned for training examples classifier cannot
+// learn from
+var ErrInvalidTraining = errs.New(errs.InvalidArgument, "invalid intent training data")
+
+// intentsJSON is labeled training file of built-in classifier
+//
+//go:embed intents.json
+var intentsJSON []byte
+
+// TrainingExample is command text labeled with its intent
+type TrainingExample struct {
+	Text   string      `json:"text"`
+	Intent CommandType `json:"intent"`
+}
+
+// training settings, fixed so same examples always give same model
+const (
+	trainEpochs = 300
+	trainRate   = 0.5
+	trainL2     = 1e-3
+)
+
+// Classifier is bag-of-words logistic regression over command intents.
+// Words and word pairs of text are features, softmax of per-intent scores
+// is confidence. Classifier is read-only once trained.
+type Classifier struct {
+	intents []CommandType
+	vocab   map[string]int
+	words   map[string]bool // single words of vocab, never corrected
+	targets map[string]bool // words of command examples, misspellings correct to them
+	weights [][]float64     // per intent, bias last
+}
+
+// LoadTrainingFile reads JSON list of training examples
+func LoadTrainingFile(path string) ([]TrainingExample, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return decodeTraining(data)
+}
+
+func decodeTraining(data []byte) ([]TrainingExample, error) {
+	var examples []TrainingExample
+	if err := json.Unmarshal(data, &examples); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidTraining, err)
+	}
+	return examples, nil
+}
+
+// TrainClassifier fits classifier to examples. Intents are known command
+// types, at least two of them must have examples.
+func TrainClassifier(examples []TrainingExample) (*Classifier, error) {
+	c := &Classifier{vocab: make(map[string]int), words: make(map[string]bool), targets: make(map[string]bool)}
+	samples := make([][]int, len(examples))
+	labels := make([]int, len(examples))
+	for i, ex := range examples {
+		if !knownIntent(ex.Intent) {
+			return nil, fmt.Errorf("%w: example %d has intent %q", ErrInvalidTraining, i, ex.Intent)
+		}
+		feats := features(strings.Fields(strings.ToLower(ex.Text)))
+		if len(feats) == 0 {
+			return nil, fmt.Errorf("%w: example %d has no words", ErrInvalidTraining, i)
+		}
+		if !slices.Contains(c.intents, ex.Intent) {
+			c.intents = append(c.intents, ex.Intent)
+		}
+		for _, f := range feats {
+			if _, ok := c.vocab[f]; !ok {
+				c.vocab[f] = len(c.vocab)
+			}
+			// words of unknown examples mean no command, "thanks" is
+			// no misspelled "things"
+			if !strings.ContainsAny(f, " <") {
+				c.words[f] = true
+				if ex.Intent != CmdUnknown {
+					c.targets[f] = true
+				}
+			}
+			samples[i] = append(samples[i], c.vocab[f])
+		}
+		labels[i] = slices.Index(c.intents, ex.Intent)
+	}
+	if len(c.intents) < 2 {
+		return nil, fmt.Errorf("%w: need examples of at least 2 intents", ErrInvalidTraining)
+	}
+
+	// full-batch gradient descent on cross-entropy, deterministic for
+	// given example order
+	bias := len(c.vocab)
+	c.weights = make([][]float64, len(c.intents))
+	for k := range c.weights {
+		c.weights[k] = make([]float64, bias+1)
+	}
+	grad := make([][]float64, len(c.intents))
+	for k := range grad {
+		grad[k] = make([]float64, bias+1)
+	}
+	probs := make([]float64, len(c.intents))
+	n := float64(len(samples))
+	for epoch := 0; epoch < trainEpochs; epoch++ {
+		for k := range grad {
+			clear(grad[k])
+		}
+		for i, feats := range samples {
+			c.softmax(feats, probs)
+			for k, p := range probs {
+				if k == labels[i] {
+					p--
+				}
+				for _, f := range feats {
+					grad[k][f] += p
+				}
+				grad[k][bias] += p
+			}
+		}
+		for k, w := range c.weights {
+			for f := range w {
+				g := grad[k][f] / n
+				if f != bias {
+					g += trainL2 * w[f]
+				}
+				w[f] -= trainRate * g
+			}
+		}
+	}
+	return c, nil
+}
+
+// Classify returns most likely intent of words and its probability.
+// Words classifier never saw give CmdUnknown with zero confidence.
+func (c *Classifier) Classify(words []string) (CommandType, float64) {
+	var feats []int
+	for _, f := range features(words) {
+		if i, ok := c.vocab[f]; ok {
+			feats = append(feats, i)
+		}
+	}
+	if len(feats) == 0 {
+		return CmdUnknown, 0
+	}
+	probs := make([]float64, len(c.intents))
+	c.softmax(feats, probs)
+	best := 0
+	for k, p := range probs {
+		if p > probs[best] {
+			best = k
+		}
+	}
+	return c.intents[best], probs[best]
+}
+
+// softmax fills probs with intent probabilities of features
+func (c *Classifier) softmax(feats []int, probs []float64) {
+	bias := len(c.vocab)
+	top := math.Inf(-1)
+	for k, w := range c.weights {
+		s := w[bias]
+		for _, f := range feats {
+			s += w[f]
+		}
+		probs[k] = s
+		top = max(top, s)
+	}
+	var sum float64
+	for k := range probs {
+		probs[k] = math.Exp(probs[k] - top)
+		sum += probs[k]
+	}
+	for k := range probs {
+		probs[k] /= sum
+	}
+}
+
+// features turns lowercase words into words and adjacent word pairs,
+// numbers all become one feature
+func features(words []string) []string {
+	toks := make([]string, 0, len(words))
+	for _, w := range words {
+		w = trimWord(w)
+		if w == "" {
+			continue
+		}
+		if _, ok := parseFloat(strings.TrimSuffix(w, "%")); ok {
+			w = "<num>"
+		}
+		toks = append(toks, w)
+	}
+	feats := slices.Clone(toks)
+	for i := 1; i < len(toks); i++ {
+		feats = append(feats, toks[i-1]+" "+toks[i])
+	}
+	return feats
+}
+
+func knownIntent(t CommandType) bool {
+	switch t {
+	case CmdMove, CmdStop, CmdAdjust, CmdStatus, CmdUnknown:
+		return true
+	}
+	return false
+}
+
+// DefaultClassifier returns classifier trained on built-in examples
+var DefaultClassifier = sync.OnceValue(func() *Classifier {
+	examples, err := decodeTraining(intentsJSON)
+	if err != nil {
+		panic(err)
+	}
+	c, err := TrainClassifier(examples)
+	if err != nil {
+		panic(err)
+	}
+	return c
+})
@@ -0,0 +1,125 @@
+package nlp
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/sashalind/sex-artifical-intelligence/pkg/core/errs"
+)
+
+func TestTrainClassifierRejects(t *testing.T) {
+	tests := []struct {
+		name     string
+		examples []TrainingExample
+	}{
+		{"unknown intent", []TrainingExample{{"go", CmdMove}, {"dance", "dance"}}},
+		{"structural intent", []TrainingExample{{"go", CmdMove}, {"play wave", CmdPattern}}},
+		{"no words", []TrainingExample{{"go", CmdMove}, {" ,. ", CmdStop}}},
+		{"one intent", []TrainingExample{{"go", CmdMove}, {"move", CmdMove}}},
+		{"no examples", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := TrainClassifier(tt.examples)
+			if !errors.Is(err, ErrInvalidTraining) || errs.CodeOf(err) != errs.InvalidArgument || c != nil {
+				t.Errorf("TrainClassifier = %v, %v, want ErrInvalidTraining", c, err)
+			}
+		})
+	}
+
+	if _, err := decodeTraining([]byte(`[{"text": 1}]`)); !errors.Is(err, ErrInvalidTraining) {
+		t.Errorf("decodeTraining = %v, want ErrInvalidTraining", err)
+	}
+}
+
+func TestTrainClassifierDeterministic(t *testing.T) {
+	examples, err := decodeTraining(intentsJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, err := TrainClassifier(examples)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := TrainClassifier(examples)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(a, b) {
+		t.Error("same examples trained different classifiers")
+	}
+}
+
+// TestClassify uses phrasings not in intents.json, classifier generalises
+// from words and word pairs it learned
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		text string
+		want CommandType
+		min  float64 // confidence at least
+	}{
+		{"how are you doing", CmdStatus, 0.9},
+		{"shift a little to the left", CmdAdjust, 0.5},
+		{"cease all motion", CmdStop, 0.5},
+		{"spin around", CmdMove, 0.5},
+		{"set intensity higher", CmdAdjust, 0.8},
+		{"turn it down", CmdAdjust, 0.8},
+		{"tell me a joke", CmdUnknown, 0.8},
+		{"report", CmdStatus, 0.4},
+		{"move 0.5", CmdMove, 0.5},
+		{"xyzzy plugh", CmdUnknown, 0},
+		{"", CmdUnknown, 0},
+	}
+	c := DefaultClassifier()
+	for _, tt := range tests {
+		got, p := c.Classify(strings.Fields(tt.text))
+		if got != tt.want || p < tt.min || p > 1 {
+			t.Errorf("Classify(%q) = %s %.3f, want %s at least %.2f", tt.text, got, p, tt.want, tt.min)
+		}
+	}
+	if got, p := c.Classify([]string{"xyzzy"}); got != CmdUnknown || p != 0 {
+		t.Errorf("Classify(unseen word) = %s %v, want unknown 0", got, p)
+	}
+}
+
+// TestIntentFallback checks how classifier and keywords decide with
+// spelling correction off
+func TestIntentFallback(t *testing.T) {
+	tests := []struct {
+		text       string
+		min        float64
+		want       CommandType
+		confidence float64 // zero checks type only
+	}{
+		// classifier confident enough
+		{"cease all motion", 0.5, CmdStop, 0},
+		{"keep going", 0.5, CmdMove, 0},
+		// below threshold nothing is guessed
+		{"cease all motion", 0.9, CmdUnknown, 0},
+		{"report", 0.5, CmdUnknown, 0},
+		{"report", 0.4, CmdStatus, 0},
+		// keyword agreeing with classifier, or classifier unsure, is certain
+		{"rotate slowly", 0.5, CmdMove, 1},
+		{"whats your state", 0.9, CmdStatus, 1},
+		{"halt", 0.99, CmdStop, 1},
+		// classifier sure of other intent overrides keyword
+		{"turn it down", 0.5, CmdAdjust, 0},
+	}
+	for _, tt := range tests {
+		cmd, err := ParseWith(tt.text, ParseOptions{Classifier: DefaultClassifier(), MinConfidence: tt.min, Exact: true})
+		if err != nil {
+			t.Fatalf("ParseWith(%q): %v", tt.text, err)
+		}
+		if cmd.Type != tt.want || (tt.confidence != 0 && cmd.Confidence != tt.confidence) {
+			t.Errorf("ParseWith(%q, min %v) = %s %.3f, want %s", tt.text, tt.min, cmd.Type, cmd.Confidence, tt.want)
+		}
+		if cmd.Type == CmdUnknown && cmd.Confidence >= tt.min {
+			t.Errorf("ParseWith(%q, min %v) unknown with confidence %.3f", tt.text, tt.min, cmd.Confidence)
+		}
+		if cmd.Type != CmdUnknown && tt.confidence == 0 && (cmd.Confidence < tt.min || cmd.Confidence >= 1) {
+			t.Errorf("ParseWith(%q, min %v) confidence %.3f, want classifier confidence", tt.text, tt.min, cmd.Confidence)
+		}
+	}
+}
@@ -0,0 +1,246 @@
+package nlp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/sashalind/sex-artifical-intelligence/pkg/core/errs"
+)
+
+var (
+	ErrLLMUnavailable = errs.New(errs.Unavailable, "language model unavailable")
+	ErrLLMRejected    = errs.New(errs.InvalidArgument, "language model reply rejected")
+)
+
+// defaultLLMTimeout bounds one model request when config sets none
+const defaultLLMTimeout = 5 * time.Second
+
+// llmConfidence is confidence of model commands, model gives none of its own
+const llmConfidence = 0.6
+
+// maxLLMReply bounds model reply read, command JSON is far smaller
+const maxLLMReply = 64 << 10
+
+// LLMConfig points parser at OpenAI-compatible chat completions API, which
+// llama.cpp server provides too. Empty URL disables model.
+type LLMConfig struct {
+	URL     string        // base URL, e.g. http://localhost:8080/v1
+	Model   string        // model name sent with request, may be empty for llama.cpp
+	APIKey  string        // bearer token, empty sends none
+	Timeout time.Duration // of one request, zero is 5s
+}
+
+// Validate checks model settings
+func (c LLMConfig) Validate() error {
+	if c.URL != "" && !strings.HasPrefix(c.URL, "http://") && !strings.HasPrefix(c.URL, "https://") {
+		return fmt.Errorf("llm url %q must be http or https", c.URL)
+	}
+	if c.Timeout < 0 {
+		return errors.New("llm timeout must not be negative")
+	}
+	return nil
+}
+
+// llmPrompt tells model schema it must answer in
+const llmPrompt = `You control a robot. Turn the user's text into one JSON object and nothing else:
+{"type": "move"|"stop"|"adjust"|"status"|"pause"|"resume"|"unknown", "parameters": {...}}
+move parameters, all optional: "speed" number 0 to 1, "direction" one of left, right, up, down, forward, back, inward, outward, clockwise, counterclockwise, "distance" millimetres, number 0 or more.
+adjust parameters, all optional: "speed", "intensity" and "sensitivity", numbers 0 to 1.
+stop, status, pause and resume take no parameters. Use "unknown" when the text asks for nothing of these.`
+
+// LLMParser turns free-form text into command with language model. Its
+// replies are untrusted: they must match command schema exactly and pass
+// safety filter before they become commands.
+type LLMParser struct {
+	cfg    LLMConfig
+	client *http.Client
+}
+
+// NewLLMParser creates parser of cfg, nil when cfg disables model
+func NewLLMParser(cfg LLMConfig) (*LLMParser, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	if cfg.URL == "" {
+		return nil, nil
+	}
+	if cfg.Timeout == 0 {
+		cfg.Timeout = defaultLLMTimeout
+	}
+	return &LLMParser{cfg: cfg, client: &http.Client{Timeout: cfg.Timeout}}, nil
+}
+
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type chatRequest struct {
+	Model          string            `json:"model,omitempty"`
+	Messages       []chatMessage     `json:"messages"`
+	Temperature    float64           `json:"temperature"`
+	ResponseFormat map[string]string `json:"response_format"`
+}
+
+type chatResponse struct {
+	Choices []struct {
+		Message chatMessage `json:"message"`
+	} `json:"choices"`
+}
+
+// Parse asks model for command text means
+func (l *LLMParser) Parse(ctx context.Context, text string) (*Command, error) {
+	body, err := json.Marshal(chatRequest{
+		Model: l.cfg.Model,
+		Messages: []chatMessage{
+			{Role: "system", Content: llmPrompt},
+			{Role: "user", Content: text},
+		},
+		ResponseFormat: map[string]string{"type": "json_object"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(l.cfg.URL, "/")+"/chat/completions", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if l.cfg.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+l.cfg.APIKey)
+	}
+
+	resp, err := l.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrLLMUnavailable, err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxLLMReply))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrLLMUnavailable, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: status %d", ErrLLMUnavailable, resp.StatusCode)
+	}
+
+	var chat chatResponse
+	if err := json.Unmarshal(data, &chat); err != nil || len(chat.Choices) == 0 {
+		return nil, fmt.Errorf("%w: malformed completion", ErrLLMRejected)
+	}
+	return decodeLLMCommand(chat.Choices[0].Message.Content)
+}
+
+// llmCommand is command schema model answers in
+type llmCommand struct {
+	Type       CommandType                `json:"type"`
+	Parameters map[string]json.RawMessage `json:"parameters"`
+}
+
+// llmParams are parameters model may set per command type and their kind
+var llmParams = map[CommandType]map[string]paramKind{
+	CmdMove:   {"speed": kindFraction, "distance": kindLength, "direction": kindDirection},
+	CmdAdjust: {"speed": kindFraction, "intensity": kindFraction, "sensitivity": kindFraction},
+	CmdStop:   {},
+	CmdStatus: {},
+	CmdPause:  {},
+	CmdResume: {},
+}
+
+// decodeLLMCommand validates model reply strictly, unknown fields, types
+// and parameters are rejected rather than dropped
+func decodeLLMCommand(content string) (*Command, error) {
+	dec := json.NewDecoder(strings.NewReader(strings.TrimSpace(content)))
+	dec.DisallowUnknownFields()
+	var reply llmCommand
+	if err := dec.Decode(&reply); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrLLMRejected, err)
+	}
+	if dec.More() {
+		return nil, fmt.Errorf("%w: trailing data", ErrLLMRejected)
+	}
+
+	cmd := &Command{
+		Type:       reply.Type,
+		Parameters: make(map[string]interface{}),
+		Priority:   1,
+		Confidence: llmConfidence,
+	}
+	if reply.Type == CmdUnknown {
+		return cmd, nil
+	}
+	allowed, ok := llmParams[reply.Type]
+	if !ok {
+		return nil, fmt.Errorf("%w: command type %q", ErrLLMRejected, reply.Type)
+	}
+	switch reply.Type {
+	case CmdStop:
+		cmd.Priority = 10
+	case CmdPause:
+		cmd.Priority = 9
+	}
+	for name, raw := range reply.Parameters {
+		kind, ok := allowed[name]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s takes no %q", ErrLLMRejected, reply.Type, name)
+		}
+		v, err := llmParam(name, raw, kind)
+		if err != nil {
+			return nil, err
+		}
+		cmd.Parameters[name] = v
+	}
+	return cmd, nil
+}
+
+// llmParam checks one parameter model set against what command text could
+// have said
+func llmParam(name string, raw json.RawMessage, kind paramKind) (interface{}, error) {
+	if kind == kindDirection {
+		var dir string
+		if err := json.Unmarshal(raw, &dir); err != nil || !directionWords[dir] {
+			return nil, fmt.Errorf("%w: direction %s", ErrLLMRejected, raw)
+		}
+		return dir, nil
+	}
+	var v float64
+	if err := json.Unmarshal(raw, &v); err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+		return nil, fmt.Errorf("%w: %s %s is no number", ErrLLMRejected, name, raw)
+	}
+	switch {
+	case kind == kindFraction && (v < 0 || v > 1):
+		return nil, fmt.Errorf("%w: %s %g", ErrParamOutOfRange, name, v)
+	case kind == kindLength && v < 0:
+		return nil, fmt.Errorf("%w: %s %g", ErrParamOutOfRange, name, v)
+	}
+	return v, nil
+}
+
+// distressWords in text mean user may want things to end, model may not
+// read them as anything but stop
+var distressWords = map[string]bool{
+	"ouch": true, "hurt": true, "hurts": true, "pain": true, "painful": true,
+	"no": true, "don't": true, "dont": true, "enough": true, "help": true,
+}
+
+// llmSafe is safety filter of model commands: in text with distress
+// words, model may only stop, pause or report status
+func llmSafe(text string, cmd *Command) bool {
+	switch cmd.Type {
+	case CmdStop, CmdPause, CmdStatus, CmdUnknown:
+		return true
+	}
+	for _, w := range strings.Fields(strings.ToLower(text)) {
+		if distressWords[trimWord(w)] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,143 @@
+package nlp
+
+import (
+	"time"
+
+	"github.com/sashalind/sex-artifical-intelligence/pkg/storage"
+)
+
+// reasons text counts as misunderstood
+const (
+	ReasonUnknown   = "unknown"   // no intent found
+	ReasonUncertain = "uncertain" // intent had to be confirmed
+	ReasonDeclined  = "declined"  // user said no to confirmation
+	ReasonInvalid   = "invalid"   // text could not be parsed
+)
+
+// Misunderstanding is command text processor did not get right, with
+// intent it guessed. Log of them reads as intent training file: correct
+// intents and pass it as IntentFile to retrain.
+type Misunderstanding struct {
+	TrainingExample
+	Confidence float64   `json:"confidence"`
+	Reason     string    `json:"reason"`
+	Timestamp  time.Time `json:"timestamp"`
+}
+
+// Stats are processing counts since start: intent distribution, share of
+// unknown commands and average intent confidence. Clarification questions
+// are not counted as commands.
+type Stats struct {
+	Commands      int                 `json:"commands"`
+	Intents       map[CommandType]int `json:"intents"`
+	UnknownRate   float64             `json:"unknown_rate"`
+	AvgConfidence float64             `json:"avg_confidence"`
+	Errors        int                 `json:"errors"`
+	Misunderstood int                 `json:"misunderstood"`
+}
+
+// metrics accumulate Stats, guarded by processor mutex
+type metrics struct {
+	intents       map[CommandType]int
+	commands      int
+	confidence    float64
+	errors        int
+	misunderstood int
+}
+
+// count adds recorded command to metrics, p.mu held
+func (p *Processor) count(cmd *Command) {
+	if cmd.Type == CmdClarify {
+		return
+	}
+	if p.metrics.intents == nil {
+		p.metrics.intents = make(map[CommandType]int)
+	}
+	p.metrics.intents[cmd.Type]++
+	p.metrics.commands++
+	p.metrics.confidence += cmd.Confidence
+}
+
+// misunderstood logs text processor did not get right, guess is command
+// it made of text, nil when none
+func (p *Processor) misunderstood(text string, guess *Command, reason string) {
+	m := Misunderstanding{
+		TrainingExample: TrainingExample{Text: text, Intent: CmdUnknown},
+		Reason:          reason,
+		Timestamp:       p.clock.Now(),
+	}
+	if guess != nil {
+		m.Intent, m.Confidence = guess.Type, guess.Confidence
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.metrics.misunderstood++
+	if reason == ReasonInvalid {
+		p.metrics.errors++
+	}
+	if p.cfg.Redact {
+		return
+	}
+	p.misunderstandings = append(p.misunderstandings, m)
+	if len(p.misunderstandings) > p.cfg.HistorySize {
+		p.misunderstandings = p.misunderstandings[1:]
+	}
+	if p.misLog != nil {
+		if _, err := p.misLog.Append(m); err != nil {
+			p.lastErr.Set(err)
+			return
+		}
+		if err := p.misLog.Trim(p.cfg.HistorySize); err != nil {
+			p.lastErr.Set(err)
+		}
+	}
+}
+
+// attachMisunderstood restores misunderstanding log from store, p.mu held
+func (p *Processor) attachMisunderstood(store *storage.Store) error {
+	misLog, err := storage.OpenTable[Misunderstanding](store, storage.BucketMisunderstood)
+	if err != nil {
+		return err
+	}
+	logged, err := misLog.All()
+	if err != nil {
+		return err
+	}
+	p.misLog = misLog
+	p.misunderstandings = append(logged, p.misunderstandings...)
+	if len(p.misunderstandings) > p.cfg.HistorySize {
+		p.misunderstandings = p.misunderstandings[len(p.misunderstandings)-p.cfg.HistorySize:]
+	}
+	return nil
+}
+
+// Stats returns processing counts since start
+func (p *Processor) Stats() Stats {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	m := p.metrics
+	s := Stats{
+		Commands:      m.commands,
+		Intents:       make(map[CommandType]int, len(m.intents)),
+		Errors:        m.errors,
+		Misunderstood: m.misunderstood,
+	}
+	for t, n := range m.intents {
+		s.Intents[t] = n
+	}
+	if m.commands > 0 {
+		s.UnknownRate = float64(m.intents[CmdUnknown]) / float64(m.commands)
+		s.AvgConfidence = m.confidence / float64(m.commands)
+	}
+	return s
+}
+
+// Misunderstood returns log of misunderstood command text, oldest first
+func (p *Processor) Misunderstood() []Misunderstanding {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return append([]Misunderstanding(nil), p.misunderstandings...)
+}
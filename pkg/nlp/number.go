@@ -0,0 +1,200 @@
+package nlp
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/sashalind/sex-artifical-intelligence/pkg/core/errs"
+)
+
+// ErrParamOutOfRange is returned when command names value its parameter
+// cannot take, e.g. "speed 5"
+var ErrParamOutOfRange = errs.New(errs.OutOfRange, "parameter out of range")
+
+// numberWords are spelled numbers, tens combine with following unit word
+// as in "twenty five" or "twenty-five"
+var numberWords = map[string]float64{
+	"zero": 0, "one": 1, "two": 2, "three": 3, "four": 4, "five": 5,
+	"six": 6, "seven": 7, "eight": 8, "nine": 9, "ten": 10,
+	"eleven": 11, "twelve": 12, "thirteen": 13, "fourteen": 14, "fifteen": 15,
+	"sixteen": 16, "seventeen": 17, "eighteen": 18, "nineteen": 19,
+	"twenty": 20, "thirty": 30, "forty": 40, "fifty": 50,
+	"sixty": 60, "seventy": 70, "eighty": 80, "ninety": 90,
+	"hundred": 100,
+}
+
+// fractionWords stand for whole value on their own, "half" or "a quarter"
+var fractionWords = map[string]float64{
+	"half": 0.5, "quarter": 0.25, "third": 1.0 / 3, "full": 1,
+	"halfway": 0.5, "max": 1, "maximum": 1, "min": 0, "minimum": 0,
+}
+
+// fillers may stand between parameter and its value, "speed to 0.5"
+var fillers = map[string]bool{"to": true, "at": true, "of": true, "by": true, "a": true, "an": true, "is": true, "=": true}
+
+// percentWords mark value as percentage, "50 percent" or "50%"
+var percentWords = map[string]bool{"percent": true, "per-cent": true, "pct": true, "%": true}
+
+// lengthUnits convert distance hint to millimetres
+var lengthUnits = map[string]float64{
+	"mm": 1, "millimeter": 1, "millimeters": 1, "millimetre": 1, "millimetres": 1,
+	"cm": 10, "centimeter": 10, "centimeters": 10, "centimetre": 10, "centimetres": 10,
+	"m": 1000, "meter": 1000, "meters": 1000, "metre": 1000, "metres": 1000,
+	"in": 25.4, "inch": 25.4, "inches": 25.4,
+}
+
+// paramKind is value range and unit hints parameter accepts
+type paramKind int
+
+const (
+	// fraction of full scale in [0, 1], percentages are scaled down
+	kindFraction paramKind = iota
+	// distance in millimetres, length units convert
+	kindLength
+	// one of directionWords
+	kindDirection
+)
+
+// parseParam reads value of named parameter from words following it,
+// skipping fillers. It reports false when no number follows and
+// ErrParamOutOfRange when one does but parameter cannot take it.
+func parseParam(name string, words []string, kind paramKind) (float64, bool, error) {
+	for len(words) > 0 && fillers[trimWord(words[0])] {
+		words = words[1:]
+	}
+	v, n, ok := parseNumber(words)
+	if !ok {
+		return 0, false, nil
+	}
+	percent := strings.HasSuffix(trimWord(words[0]), "%")
+	words = words[n:]
+
+	hint := ""
+	if len(words) > 0 {
+		hint = trimWord(words[0])
+	}
+	switch kind {
+	case kindFraction:
+		if percent || percentWords[hint] {
+			v /= 100
+		}
+		if v < 0 || v > 1 {
+			return 0, false, fmt.Errorf("%w: %s %g, use 0 to 1 or percent", ErrParamOutOfRange, name, v)
+		}
+	case kindLength:
+		if scale, ok := lengthUnits[hint]; ok {
+			v *= scale
+		}
+		if v < 0 {
+			return 0, false, fmt.Errorf("%w: %s %g must not be negative", ErrParamOutOfRange, name, v)
+		}
+	}
+	return v, true, nil
+}
+
+// parseNumber reads number at start of words, as digits ("0.5", "50%"),
+// spelled ("twenty five"), fraction word ("half") or whole number and
+// fraction ("two and a half"), returning words it took
+func parseNumber(words []string) (float64, int, bool) {
+	v, n, ok := parseSimpleNumber(words)
+	if !ok || v != math.Trunc(v) || strings.HasSuffix(trimWord(words[0]), "%") {
+		return v, n, ok
+	}
+	// "one and a half", "2 and three quarters"
+	rest := words[n:]
+	if len(rest) < 2 || trimWord(rest[0]) != "and" {
+		return v, n, true
+	}
+	skip := 1
+	if w := trimWord(rest[1]); w == "a" || w == "an" {
+		skip = 2
+	}
+	rest = rest[skip:]
+	if len(rest) == 0 {
+		return v, n, true
+	}
+	if _, digits := parseFloat(trimWord(rest[0])); digits {
+		return v, n, true
+	}
+	f, m, ok := parseSimpleNumber(rest)
+	if !ok || f <= 0 || f >= 1 {
+		return v, n, true
+	}
+	return v + f, n + skip + m, true
+}
+
+// parseSimpleNumber reads one number without mixed fraction
+func parseSimpleNumber(words []string) (float64, int, bool) {
+	if len(words) == 0 {
+		return 0, 0, false
+	}
+	w := trimWord(words[0])
+	if v, ok := parseFloat(strings.TrimSuffix(w, "%")); ok {
+		return v, 1, true
+	}
+	if v, ok := fractionWords[w]; ok {
+		return v, 1, true
+	}
+
+	v, ok := spelledNumber(w)
+	if !ok {
+		return 0, 0, false
+	}
+	n := 1
+	// "twenty five", "one hundred", "three quarters"
+	if len(words) > 1 {
+		next := trimWord(words[1])
+		switch {
+		case next == "hundred" && v >= 1 && v < 10:
+			v, n = v*100, 2
+		case next == "quarters" || next == "quarter":
+			v, n = v*0.25, 2
+		case next == "thirds" || next == "third":
+			v, n = v/3, 2
+		case next == "halves" || next == "half":
+			v, n = v*0.5, 2
+		case isTens(v):
+			if u, ok := numberWords[next]; ok && u >= 1 && u < 10 {
+				v, n = v+u, 2
+			}
+		}
+	}
+	return v, n, true
+}
+
+// spelledNumber reads one number word, hyphenated tens included
+func spelledNumber(w string) (float64, bool) {
+	if v, ok := numberWords[w]; ok {
+		return v, true
+	}
+	tens, unit, ok := strings.Cut(w, "-")
+	if !ok {
+		return 0, false
+	}
+	t, ok := numberWords[tens]
+	u, uok := numberWords[unit]
+	if !ok || !uok || !isTens(t) || u < 1 || u >= 10 {
+		return 0, false
+	}
+	return t + u, true
+}
+
+func isTens(v float64) bool {
+	return v >= 20 && v < 100 && math.Mod(v, 10) == 0
+}
+
+// trimWord drops punctuation command text may leave on word
+func trimWord(w string) string {
+	return strings.Trim(w, ",.;:!?\"'()")
+}
+
+func parseFloat(s string) (float64, bool) {
+	v, err := strconv.ParseFloat(s, 64)
+	// strconv takes "nan" and "inf" too, neither is a usable value
+	if err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+		return 0, false
+	}
+	return v, true
+}
@@ -0,0 +1,115 @@
+package nlp
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/sashalind/sex-artifical-intelligence/pkg/core/errs"
+)
+
+func TestParseNumber(t *testing.T) {
+	tests := []struct {
+		text string
+		want float64
+		n    int
+		ok   bool
+	}{
+		{"0.5", 0.5, 1, true},
+		{"50%", 50, 1, true},
+		{"-3,", -3, 1, true},
+		{"1e2", 100, 1, true},
+		{"half", 0.5, 1, true},
+		{"a", 0, 0, false},
+		{"seven", 7, 1, true},
+		{"twenty five", 25, 2, true},
+		{"twenty-five", 25, 1, true},
+		{"twenty", 20, 1, true},
+		{"twenty ten", 20, 1, true},
+		{"five-twenty", 0, 0, false},
+		{"one hundred", 100, 2, true},
+		{"three quarters", 0.75, 2, true},
+		{"two thirds", 2.0 / 3, 2, true},
+		{"one half", 0.5, 2, true},
+		{"one and a half", 1.5, 4, true},
+		{"2 and a quarter cm", 2.25, 4, true},
+		{"two and three quarters", 2.75, 4, true},
+		{"zero and a half", 0.5, 4, true},
+		{"one and two", 1, 1, true},
+		{"one and 0.5", 1, 1, true},
+		{"one and a", 1, 1, true},
+		{"one and a full", 1, 1, true},
+		{"1.5 and a half", 1.5, 1, true},
+		{"50% and a half", 50, 1, true},
+		{"nan", 0, 0, false},
+		{"inf", 0, 0, false},
+		{"", 0, 0, false},
+	}
+	for _, tt := range tests {
+		v, n, ok := parseNumber(strings.Fields(tt.text))
+		if v != tt.want || n != tt.n || ok != tt.ok {
+			t.Errorf("parseNumber(%q) = %v, %d, %v, want %v, %d, %v", tt.text, v, n, ok, tt.want, tt.n, tt.ok)
+		}
+	}
+}
+
+func TestParseParam(t *testing.T) {
+	tests := []struct {
+		text string
+		kind paramKind
+		want float64
+		ok   bool
+		err  bool
+	}{
+		{"0.5", kindFraction, 0.5, true, false},
+		{"to 0.3", kindFraction, 0.3, true, false},
+		{"at 50 percent", kindFraction, 0.5, true, false},
+		{"75%", kindFraction, 0.75, true, false},
+		{"of a quarter", kindFraction, 0.25, true, false},
+		{"max", kindFraction, 1, true, false},
+		{"1", kindFraction, 1, true, false},
+		{"0", kindFraction, 0, true, false},
+		{"5", kindFraction, 0, false, true},
+		{"150%", kindFraction, 0, false, true},
+		{"-0.1", kindFraction, 0, false, true},
+		{"one and a half", kindFraction, 0, false, true},
+		{"fast", kindFraction, 0, false, false},
+		{"", kindFraction, 0, false, false},
+		{"3 cm", kindLength, 30, true, false},
+		{"two and a half cm", kindLength, 25, true, false},
+		{"1 inch", kindLength, 25.4, true, false},
+		{"12", kindLength, 12, true, false},
+		{"5 furlongs", kindLength, 5, true, false},
+		{"-2 mm", kindLength, 0, false, true},
+	}
+	for _, tt := range tests {
+		v, ok, err := parseParam("p", strings.Fields(tt.text), tt.kind)
+		if tt.err {
+			if !errors.Is(err, ErrParamOutOfRange) || errs.CodeOf(err) != errs.OutOfRange {
+				t.Errorf("parseParam(%q) error = %v, want ErrParamOutOfRange", tt.text, err)
+			}
+		} else if err != nil {
+			t.Errorf("parseParam(%q): %v", tt.text, err)
+		}
+		if ok != tt.ok || (ok && (v-tt.want > 1e-9 || tt.want-v > 1e-9)) {
+			t.Errorf("parseParam(%q) = %v, %v, want %v, %v", tt.text, v, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+// TestParseOutOfRange checks command naming impossible value fails
+// instead of dropping or clamping it
+func TestParseOutOfRange(t *testing.T) {
+	for _, text := range []string{
+		"move speed 5",
+		"move speed one and a half",
+		"move speed 150%",
+		"adjust intensity 2",
+		"move left distance -3 cm",
+	} {
+		cmd, err := Parse(text)
+		if !errors.Is(err, ErrParamOutOfRange) || cmd != nil {
+			t.Errorf("Parse(%q) = %+v, %v, want ErrParamOutOfRange", text, cmd, err)
+		}
+	}
+}
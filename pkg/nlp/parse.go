@@ -0,0 +1,339 @@
+package nlp
+
+import (
+	"strings"
+
+	"github.com/sashalind/sex-artifical-intelligence/pkg/core/errs"
+)
+
+// MaxCommandLength bounds input size, commands may arrive from network
+const MaxCommandLength = 4096
+
+var (
+	ErrEmptyCommand   = errs.New(errs.InvalidArgument, "empty command")
+	ErrCommandTooLong = errs.New(errs.InvalidArgument, "command too long")
+)
+
+// DefaultMinConfidence is classifier confidence below which intent falls
+// back to keywords
+const DefaultMinConfidence = 0.5
+
+// Parse converts text into command using built-in intent classifier. It is
+// pure and deterministic: no processor state is read or written and
+// Timestamp is left zero, so same input always gives same output. Parse
+// never panics on arbitrary input.
+func Parse(text string) (*Command, error) {
+	return ParseWith(text, ParseOptions{Classifier: DefaultClassifier(), MinConfidence: DefaultMinConfidence})
+}
+
+// ParseOptions tune how intent of command is found
+type ParseOptions struct {
+	Classifier    *Classifier
+	MinConfidence float64    // classifier confidence needed, keywords decide below it
+	Vocabulary    Vocabulary // operator phrases, taking precedence over classifier
+	Safeword      string     // phrase stopping everything at once, empty for none
+	Exact         bool       // no spelling correction of command words
+}
+
+// ParseWith converts text into command. Safeword stops everything whatever
+// else text says; otherwise intent comes from operator vocabulary, then
+// pattern, preset, pause and resume keywords, then classifier when it is
+// sure enough, then keywords. Misspelled command words are corrected
+// first unless opts are exact, "stp" stops; every correction lowers
+// confidence.
+func ParseWith(text string, opts ParseOptions) (*Command, error) {
+	if len(text) > MaxCommandLength {
+		return nil, ErrCommandTooLong
+	}
+	
+	// Basic command parsing
+	words := strings.Fields(strings.ToLower(text))
+	if len(words) == 0 {
+		return nil, ErrEmptyCommand
+	}
+	
+	cmd := &Command{
+		Parameters: make(map[string]interface{}),
+		Priority:   1,
+	}
+	if safeword(words, opts.Safeword) {
+		// nothing else is read from it, safeword must never fail to parse
+		cmd.Type, cmd.Confidence, cmd.Priority = CmdSafeword, 1, 11
+		cmd.Sentiment, cmd.Urgency = ScoreSentiment(text)
+		return cmd, nil
+	}
+	corrected := 0
+	if !opts.Exact {
+		words, corrected = correctSpelling(words, opts)
+	}
+	cmd.Type, cmd.Confidence = determineCommandType(words, opts)
+	for range corrected {
+		cmd.Confidence *= fuzzyConfidence
+	}
+	cmd.Sentiment, cmd.Urgency = ScoreSentiment(text)
+	
+	// any command may address one unit of multi-robot install
+	if unit, ok := parseUnit(words); ok {
+		cmd.Parameters["unit"] = unit
+	}
+	
+	// Parse parameters based on command type
+	var err error
+	switch cmd.Type {
+	case CmdMove:
+		err = parseMovementParams(words, cmd)
+	case CmdAdjust:
+		err = parseAdjustmentParams(words, cmd)
+	case CmdPattern:
+		cmd.Parameters["pattern"], _ = namedArg(text, words, patternKeywords)
+	case CmdPreset:
+		cmd.Parameters["preset"], _ = namedArg(text, words, presetKeywords)
+	case CmdStatus, CmdResume:
+		// No parameters needed
+	case CmdPause:
+		cmd.Priority = 9 // freezes motion ahead of queued commands
+	case CmdStop:
+		cmd.Priority = 10 // High priority for stop command
+	}
+	if err != nil {
+		return nil, err
+	}
+	
+	return cmd, nil
+}
+
+// keyword lists of fallback intent matching
+var (
+	moveKeywords   = []string{"move", "go", "rotate", "turn"}
+	stopKeywords   = []string{"stop", "halt", "freeze"}
+	adjustKeywords = []string{"adjust", "change", "modify", "set"}
+	statusKeywords = []string{"status", "state", "condition"}
+	pauseKeywords  = []string{"pause"}
+	resumeKeywords = []string{"resume", "unpause", "continue"}
+	
+	// words followed by name of what command is about, "play pattern
+	// wave", "play wave", "load preset gentle"
+	patternKeywords = []string{"pattern", "program", "play"}
+	presetKeywords  = []string{"preset"}
+)
+
+// paramNames are parameters and addresses, never names: "adjust pattern
+// intensity 0.5" names no pattern
+var paramNames = map[string]bool{"unit": true, "motor": true, "speed": true, "direction": true, "distance": true, "intensity": true, "sensitivity": true}
+
+// nameFillers may stand between keyword and name, "play the wave"
+var nameFillers = map[string]bool{"the": true, "a": true, "my": true, "pattern": true, "program": true, "called": true, "named": true, "to": true}
+
+// determineCommandType identifies command type from words and confidence
+// in it. Stop keywords and phrases always win so stop is never talked out
+// of; then operator phrases, then commands naming pattern or preset,
+// relative adjustments, pause and resume, then first keyword found unless
+// classifier is sure of another intent, then classifier when sure enough.
+func determineCommandType(words []string, opts ParseOptions) (CommandType, float64) {
+	if len(words) == 0 {
+		return CmdUnknown, 0
+	}
+	for _, word := range words {
+		if containsWord(stopKeywords, trimWord(word)) {
+			return CmdStop, 1
+		}
+	}
+	if t, ok := opts.Vocabulary.match(words); ok {
+		return t, 1
+	}
+	// commands naming pattern or preset, and pause and resume, are
+	// structural: classifier knows none of them
+	if _, ok := namedArg("", words, presetKeywords); ok {
+		return CmdPreset, 1
+	}
+	if _, ok := namedArg("", words, patternKeywords); ok {
+		return CmdPattern, 1
+	}
+	if _, ok, err := parseRelative(words); ok || err != nil {
+		return CmdAdjust, 1
+	}
+	for _, word := range words {
+		word = trimWord(word)
+		if containsWord(pauseKeywords, word) {
+			return CmdPause, 1
+		}
+		if containsWord(resumeKeywords, word) {
+			return CmdResume, 1
+		}
+	}
+	
+	// keyword said outright is certain unless classifier is sure of other
+	// intent, "move" and "speed 0.5" need no confirmation
+	keyword := keywordType(words)
+	intent, confidence := opts.Classifier.Classify(words)
+	if keyword != CmdUnknown && (intent == keyword || confidence < DefaultConfirmConfidence) {
+		return keyword, 1
+	}
+	if intent != CmdUnknown && confidence >= opts.MinConfidence {
+		return intent, confidence
+	}
+	
+	if intent == CmdUnknown {
+		return CmdUnknown, confidence
+	}
+	return CmdUnknown, 0
+}
+
+// keywordType returns type of first move, adjust or status keyword of
+// words, parameter names count as adjust, CmdUnknown when there is none
+func keywordType(words []string) CommandType {
+	for _, word := range words {
+		word = trimWord(word)
+		if containsWord(moveKeywords, word) {
+			return CmdMove
+		}
+		if containsWord(adjustKeywords, word) || containsWord(adjustableParams, word) {
+			return CmdAdjust
+		}
+		if containsWord(statusKeywords, word) {
+			return CmdStatus
+		}
+	}
+	return CmdUnknown
+}
+
+// speedWords are speeds said in words, fractions of full speed
+var speedWords = map[string]float64{
+	"slowly": 0.25, "slow": 0.25, "gently": 0.25,
+	"normal": 0.5, "medium": 0.5, "steadily": 0.5,
+	"fast": 0.75, "quickly": 0.75, "quick": 0.75,
+}
+
+// directionWords are directions movement takes
+var directionWords = map[string]bool{
+	"left": true, "right": true, "up": true, "down": true,
+	"forward": true, "forwards": true, "back": true, "backward": true, "backwards": true,
+	"inward": true, "outward": true, "clockwise": true, "counterclockwise": true,
+}
+
+// parseMovementParams extracts movement parameters: motor, speed as
+// fraction of full speed, distance in millimetres. Speed and direction may
+// also be said on their own, "move slowly left".
+func parseMovementParams(words []string, cmd *Command) error {
+	for i := 0; i < len(words)-1; i++ {
+		switch words[i] {
+		case "speed":
+			if err := setParam(cmd, "speed", words[i+1:], kindFraction); err != nil {
+				return err
+			}
+		case "direction":
+			cmd.Parameters["direction"] = words[i+1]
+		case "motor":
+			cmd.Parameters["motor"] = trimWord(words[i+1])
+		case "distance":
+			if err := setParam(cmd, "distance", words[i+1:], kindLength); err != nil {
+				return err
+			}
+		}
+	}
+	for _, w := range words {
+		w = trimWord(w)
+		if v, ok := speedWords[w]; ok {
+			if _, set := cmd.Parameters["speed"]; !set {
+				cmd.Parameters["speed"] = v
+			}
+		}
+		if directionWords[w] {
+			if _, set := cmd.Parameters["direction"]; !set {
+				cmd.Parameters["direction"] = w
+			}
+		}
+	}
+	return nil
+}
+
+// parseAdjustmentParams extracts adjustment parameters, absolute values as
+// fractions of full scale or relative changes, see parseRelative
+func parseAdjustmentParams(words []string, cmd *Command) error {
+	rel, ok, err := parseRelative(words)
+	if err != nil {
+		return err
+	}
+	if ok {
+		for name, v := range rel {
+			cmd.Parameters[name] = v
+		}
+		return nil
+	}
+	for i := 0; i < len(words)-1; i++ {
+		switch words[i] {
+		case "speed", "intensity", "sensitivity":
+			if err := setParam(cmd, words[i], words[i+1:], kindFraction); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// setParam stores value following parameter name, leaving parameter unset
+// when no number follows
+func setParam(cmd *Command, name string, words []string, kind paramKind) error {
+	v, ok, err := parseParam(name, words, kind)
+	if ok {
+		cmd.Parameters[name] = v
+	}
+	return err
+}
+
+// namedArg finds name following one of keywords, as written in text so
+// names keep their case. Empty text gives name lowercased.
+func namedArg(text string, words, keywords []string) (string, bool) {
+	orig := strings.Fields(text)
+	if len(orig) != len(words) {
+		orig = words
+	}
+	for i, w := range words {
+		if !containsWord(keywords, trimWord(w)) {
+			continue
+		}
+		for j := i + 1; j < len(words); j++ {
+			name := trimWord(words[j])
+			if nameFillers[name] {
+				continue
+			}
+			if name == "" || paramNames[name] {
+				break
+			}
+			return trimWord(orig[j]), true
+		}
+	}
+	return "", false
+}
+
+// safeword reports whether words say safeword phrase, as whole words
+// ignoring case and punctuation
+func safeword(words []string, phrase string) bool {
+	phrase = normalizePhrase(phrase)
+	if phrase == "" {
+		return false
+	}
+	text := " " + normalizePhrase(strings.Join(words, " ")) + " "
+	return strings.Contains(text, " "+phrase+" ")
+}
+
+// parseUnit finds "unit <id>" address
+func parseUnit(words []string) (string, bool) {
+	for i := 0; i < len(words)-1; i++ {
+		if words[i] == "unit" {
+			return words[i+1], true
+		}
+	}
+	return "", false
+}
+
+// Helper functions
+
+func containsWord(words []string, target string) bool {
+	for _, word := range words {
+		if word == target {
+			return true
+		}
+	}
+	return false
+}
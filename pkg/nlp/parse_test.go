@@ -0,0 +1,122 @@
+package nlp
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+// parsedTypes are command types Parse may return
+var parsedTypes = map[CommandType]bool{
+	CmdMove: true, CmdStop: true, CmdAdjust: true, CmdStatus: true, CmdPattern: true,
+	CmdPause: true, CmdResume: true, CmdPreset: true, CmdUnknown: true,
+}
+
+func FuzzParse(f *testing.F) {
+	examples, err := decodeTraining(intentsJSON)
+	if err != nil {
+		f.Fatal(err)
+	}
+	for _, ex := range examples {
+		f.Add(ex.Text)
+	}
+	for _, list := range [][]string{
+		moveKeywords, stopKeywords, adjustKeywords, statusKeywords, pauseKeywords,
+		resumeKeywords, patternKeywords, presetKeywords, adjustableParams,
+	} {
+		for _, w := range list {
+			f.Add(w)
+			f.Add(w + " 0.5")
+		}
+	}
+	for _, s := range []string{
+		"", "   ", "move speed", "move speed -1", "speed 150%", "distance 3 cm",
+		"play", "load preset", "unit 2 stop", "increase speed by 200 percent",
+		"STOP!!!", "mve lft", "not not good", "move\x00left", "ａｄｊｕｓｔ",
+	} {
+		f.Add(s)
+	}
+
+	f.Fuzz(func(t *testing.T, text string) {
+		cmd, err := Parse(text)
+		if err != nil {
+			if cmd != nil {
+				t.Fatalf("Parse(%q) returned command %+v with error %v", text, cmd, err)
+			}
+			return
+		}
+		if !parsedTypes[cmd.Type] {
+			t.Fatalf("Parse(%q) type %q is not a command type", text, cmd.Type)
+		}
+		if math.IsNaN(cmd.Confidence) || cmd.Confidence < 0 || cmd.Confidence > 1 {
+			t.Fatalf("Parse(%q) confidence %v outside [0, 1]", text, cmd.Confidence)
+		}
+		if cmd.Urgency < 0 || cmd.Urgency > 1 || cmd.Sentiment < -1 || cmd.Sentiment > 1 {
+			t.Fatalf("Parse(%q) mood %v, %v out of range", text, cmd.Sentiment, cmd.Urgency)
+		}
+		again, err := Parse(text)
+		if err != nil || !reflect.DeepEqual(cmd, again) {
+			t.Fatalf("Parse(%q) not deterministic: %+v then %+v, %v", text, cmd, again, err)
+		}
+	})
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		text   string
+		want   CommandType
+		params map[string]interface{}
+	}{
+		{"move left slowly", CmdMove, map[string]interface{}{"direction": "left", "speed": 0.25}},
+		{"move speed 50 percent distance 3 cm", CmdMove, map[string]interface{}{"speed": 0.5, "distance": 30.0}},
+		{"move motor servo_2 left", CmdMove, map[string]interface{}{"motor": "servo_2", "direction": "left"}},
+		{"adjust intensity 0.3", CmdAdjust, map[string]interface{}{"intensity": 0.3}},
+		{"stop", CmdStop, map[string]interface{}{}},
+		{"please don't stop moving", CmdStop, map[string]interface{}{}},
+		{"play pattern wave", CmdPattern, map[string]interface{}{"pattern": "wave"}},
+		{"load preset gentle", CmdPreset, map[string]interface{}{"preset": "gentle"}},
+		{"pause", CmdPause, map[string]interface{}{}},
+		{"what is your status", CmdStatus, map[string]interface{}{}},
+		{"banana", CmdUnknown, map[string]interface{}{}},
+		{"slow down", CmdAdjust, map[string]interface{}{"speed_change": -0.25}},
+		{"speed up a bit", CmdAdjust, map[string]interface{}{"speed_change": 0.1}},
+		{"much faster", CmdAdjust, map[string]interface{}{"speed_change": 0.4}},
+		{"slow down by 10%", CmdAdjust, map[string]interface{}{"speed_factor": 0.9}},
+		{"intensity down a lot", CmdAdjust, map[string]interface{}{"intensity_change": -0.4}},
+	}
+	for _, tt := range tests {
+		cmd, err := Parse(tt.text)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.text, err)
+			continue
+		}
+		if cmd.Type != tt.want || !reflect.DeepEqual(cmd.Parameters, tt.params) {
+			t.Errorf("Parse(%q) = %s %v, want %s %v", tt.text, cmd.Type, cmd.Parameters, tt.want, tt.params)
+		}
+	}
+}
+
+func TestSpeedChangeAfterMove(t *testing.T) {
+	tests := []struct {
+		text string
+		want float64
+	}{
+		{"slow down", -0.25},
+		{"a bit faster", 0.1},
+		{"much slower", -0.4},
+	}
+	for _, tt := range tests {
+		p, _ := newTestProcessor(t, DefaultConfig())
+		if _, err := p.ProcessCommand("move left slowly"); err != nil {
+			t.Fatalf("ProcessCommand: %v", err)
+		}
+		// relative to current speed applied by core, not new move
+		cmd, err := p.ProcessCommand(tt.text)
+		if err != nil {
+			t.Fatalf("ProcessCommand(%q): %v", tt.text, err)
+		}
+		if cmd.Type != CmdAdjust || cmd.Parameters["speed_change"] != tt.want {
+			t.Errorf("ProcessCommand(%q) = %s %v, want adjust by %g", tt.text, cmd.Type, cmd.Parameters, tt.want)
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package nlp
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"text/template"
+
+	"github.com/sashalind/sex-artifical-intelligence/pkg/core/errs"
+)
+
+// ErrInvalidPersonality is returned for personality profiles responses
+// cannot be made of
+var ErrInvalidPersonality = errs.New(errs.InvalidArgument, "invalid personality profile")
+
+// DefaultPersonality is personality of responses when config selects none
+const DefaultPersonality = "default"
+
+// personalitiesJSON holds built-in personality profiles
+//
+//go:embed personalities.json
+var personalitiesJSON []byte
+
+// Personality is set of reply templates in one manner of speaking. Replies
+// are keyed by command type, "move", or by command type and tone,
+// "stop.brief"; each key has one or more phrasings used in turn. Templates
+// are text/template over ReplyData, e.g. "Moving {{.Direction}} at
+// {{percent .Speed}}".
+type Personality struct {
+	Name    string              `json:"name"`
+	Replies map[string][]string `json:"replies"`
+	
+	templates map[string][]*template.Template
+}
+
+// ReplyData are variables reply templates may use
+type ReplyData struct {
+	Speed     float64 // of command, else last one given; 0 when unknown
+	Direction string  // of move command
+	Pattern   string  // command refers to, else one playing
+	Intensity float64 // of adjust command
+	Preset    string  // of preset command
+	Plan      string  // steps of sequence command, "slow down, then stop after 1m"
+	Question  string  // of clarify command
+}
+
+// replyFuncs are functions reply templates may call
+var replyFuncs = template.FuncMap{
+	"percent": func(v float64) string { return fmt.Sprintf("%.0f%%", v*100) },
+}
+
+// compile parses reply templates of personality and checks their keys
+func (p *Personality) compile() error {
+	if p.Name == "" {
+		return fmt.Errorf("%w: name missing", ErrInvalidPersonality)
+	}
+	p.templates = make(map[string][]*template.Template, len(p.Replies))
+	for key, phrasings := range p.Replies {
+		t, tone, _ := strings.Cut(key, ".")
+		switch CommandType(t) {
+		case CmdMove, CmdStop, CmdAdjust, CmdStatus, CmdPattern, CmdPause, CmdResume, CmdPreset, CmdSafeword, CmdSequence, CmdClarify, CmdWake, CmdUnknown:
+		default:
+			return fmt.Errorf("%w: %s: command type %q", ErrInvalidPersonality, p.Name, t)
+		}
+		switch Tone(tone) {
+		case "", ToneNeutral, ToneSoothing, ToneBrief:
+		default:
+			return fmt.Errorf("%w: %s: tone %q", ErrInvalidPersonality, p.Name, tone)
+		}
+		if len(phrasings) == 0 {
+			return fmt.Errorf("%w: %s: no phrasings of %s", ErrInvalidPersonality, p.Name, key)
+		}
+		for i, text := range phrasings {
+			tmpl, err := template.New(key).Funcs(replyFuncs).Parse(text)
+			if err != nil {
+				return fmt.Errorf("%w: %s: %s %d: %v", ErrInvalidPersonality, p.Name, key, i+1, err)
+			}
+			p.templates[key] = append(p.templates[key], tmpl)
+		}
+	}
+	return nil
+}
+
+// Personalities are personality profiles by name
+type Personalities map[string]*Personality
+
+// builtinPersonalities parses embedded profiles, they are known valid
+func builtinPersonalities() Personalities {
+	set, err := decodePersonalities(personalitiesJSON)
+	if err != nil {
+		panic(err)
+	}
+	return set
+}
+
+// defaultPersonality is built-in default profile, replies other profiles
+// lack come from it
+var defaultPersonality = sync.OnceValue(func() *Personality {
+	return builtinPersonalities()[DefaultPersonality]
+})
+
+// LoadPersonalities reads built-in profiles and JSON profile files of dir,
+// one profile per *.json file. Profile of file replaces built-in one of
+// same name.
+func LoadPersonalities(dir string) (Personalities, error) {
+	set := builtinPersonalities()
+	if dir == "" {
+		return set, nil
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range files {
+		data, err := os.ReadFile(f)
+		if err != nil {
+			return nil, err
+		}
+		var p Personality
+		if err := json.Unmarshal(data, &p); err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrInvalidPersonality, filepath.Base(f), err)
+		}
+		if err := p.compile(); err != nil {
+			return nil, err
+		}
+		set[p.Name] = &p
+	}
+	return set, nil
+}
+
+func decodePersonalities(data []byte) (Personalities, error) {
+	var list []*Personality
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPersonality, err)
+	}
+	set := make(Personalities, len(list))
+	for _, p := range list {
+		if err := p.compile(); err != nil {
+			return nil, err
+		}
+		set[p.Name] = p
+	}
+	return set, nil
+}
+
+// Names returns profile names, sorted
+func (s Personalities) Names() []string {
+	names := make([]string, 0, len(s))
+	for name := range s {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// phrasings returns templates of reply to command type in tone: tone
+// variant first, plain one otherwise
+func (p *Personality) phrasings(t CommandType, tone Tone) (string, []*template.Template) {
+	if tone != ToneNeutral {
+		key := string(t) + "." + string(tone)
+		if tmpls, ok := p.templates[key]; ok {
+			return key, tmpls
+		}
+	}
+	return string(t), p.templates[string(t)]
+}
+
+// render executes template, reply data are plain values so only broken
+// templates fail
+func render(tmpl *template.Template, data ReplyData) (string, error) {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return strings.Join(strings.Fields(buf.String()), " "), nil
+}
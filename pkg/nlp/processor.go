@@ -2,10 +2,14 @@ package nlp
 
 import (
 	"context"
-	"errors"
+	"log"
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/sashalind/sex-artifical-intelligence/pkg/clock"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/health"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/storage"
 )
 
 // CommandType represents different types of commands
@@ -16,23 +20,47 @@ const (
 	CmdStop     CommandType = "stop"
 	CmdAdjust   CommandType = "adjust"
 	CmdStatus   CommandType = "status"
+	CmdPattern  CommandType = "pattern" // run pattern or program named by "pattern"
+	CmdPause    CommandType = "pause"
+	CmdResume   CommandType = "resume"
+	CmdPreset   CommandType = "preset"   // load preferences named by "preset"
+	CmdSafeword CommandType = "safeword" // emergency stop
+	CmdSequence CommandType = "sequence" // Steps in order
+	CmdClarify  CommandType = "clarify"  // question about missing parameter
+	CmdWake     CommandType = "wake"     // wake word alone, device listens
 	CmdUnknown  CommandType = "unknown"
 )
 
+// halts reports command types stopping motion, which nothing talks user
+// out of
+func (t CommandType) halts() bool {
+	return t == CmdStop || t == CmdSafeword
+}
+
 // Command represents parsed user command
 type Command struct {
-	Type       CommandType
-	Parameters map[string]interface{}
-	Priority   int
-	Timestamp  time.Time
+	Type       CommandType            `json:"type"`
+	Parameters map[string]interface{} `json:"parameters"`
+	Priority   int                    `json:"priority"`
+	Confidence float64                `json:"confidence"` // of Type, 0 to 1
+	Sentiment  float64                `json:"sentiment"`  // of input text, -1.0 to 1.0
+	Urgency    float64                `json:"urgency"`    // of input text, 0 to 1
+	Timestamp  time.Time              `json:"timestamp"`
+	
+	// steps of CmdSequence; each waits Delay after one before it and
+	// keeps Source, text of its clause
+	Steps  []Command     `json:"steps,omitempty"`
+	Delay  time.Duration `json:"delay,omitempty"`
+	Source string        `json:"source,omitempty"`
 }
 
 // Response represents system's reply
 type Response struct {
-	Text       string
-	Sentiment  float64  // -1.0 to 1.0
-	Confidence float64
-	Timestamp  time.Time
+	Text       string    `json:"text"`
+	Sentiment  float64   `json:"sentiment"` // -1.0 to 1.0
+	Tone       Tone      `json:"tone"`
+	Confidence float64   `json:"confidence"`
+	Timestamp  time.Time `json:"timestamp"`
 }
 
 // Processor handles natural language processing
@@ -47,6 +75,30 @@ type Processor struct {
 	responseHistory []Response
 	lastResponse    *Response
 	
+	// Persistent command audit, nil when running without storage
+	audit   *storage.Table[Command]
+	lastErr health.LastError
+	
+	// processing counts and log of misunderstood text, see metrics.go
+	metrics           metrics
+	misunderstandings []Misunderstanding
+	misLog            *storage.Table[Misunderstanding]
+	
+	cfg        Config
+	classifier *Classifier
+	vocabulary Vocabulary
+	llm        *LLMParser // nil without model
+	
+	// references of commands processed outside dialogs
+	convo Conversation
+	
+	// reply templates and turn of next phrasing per reply
+	personality *Personality
+	replyTurns  map[string]int
+	speed       float64 // last move speed given, for replies
+
+	clock      clock.Clock
+	
 	// Context management
 	ctx        context.Context
 	cancelFunc context.CancelFunc
@@ -54,9 +106,46 @@ type Processor struct {
 
 // NewProcessor creates new NLP processor
 func NewProcessor() (*Processor, error) {
+	return NewProcessorWithClock(clock.Real)
+}
+
+// NewProcessorWithClock creates NLP processor stamping commands with given clock
+func NewProcessorWithClock(clk clock.Clock) (*Processor, error) {
+	return NewProcessorWithConfig(clk, DefaultConfig())
+}
+
+// NewProcessorWithConfig creates NLP processor with given options
+func NewProcessorWithConfig(clk clock.Clock, cfg Config) (*Processor, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	clf, err := cfg.classifier()
+	if err != nil {
+		return nil, err
+	}
+	vocab, err := cfg.vocabulary()
+	if err != nil {
+		return nil, err
+	}
+	llm, err := NewLLMParser(cfg.LLM)
+	if err != nil {
+		return nil, err
+	}
+	pers, err := cfg.personality()
+	if err != nil {
+		return nil, err
+	}
+	
 	ctx, cancel := context.WithCancel(context.Background())
 	
 	return &Processor{
+		cfg:             cfg,
+		classifier:      clf,
+		vocabulary:      vocab,
+		llm:             llm,
+		personality:     pers,
+		replyTurns:      make(map[string]int),
+		clock:           clock.OrReal(clk),
 		commandHistory:  make([]Command, 0),
 		responseHistory: make([]Response, 0),
 		ctx:            ctx,
@@ -64,144 +153,395 @@ func NewProcessor() (*Processor, error) {
 	}, nil
 }
 
-// ProcessCommand handles incoming command text
+// ProcessCommand handles incoming command text. Commands referring to
+// previous one or to pattern playing, like "faster" or "do that again",
+// are resolved against them. Command of uncertain intent comes back as
+// CmdClarify question "Did you mean move?" and runs once user says yes.
+// With wake word configured, text not addressed to device fails with
+// ErrNotAddressed.
 func (p *Processor) ProcessCommand(text string) (*Command, error) {
-	p.mu.Lock()
-	defer p.mu.Unlock()
+	now := p.clock.Now()
+	text, woke, err := p.gate(&p.convo, text, now)
+	if err != nil || woke != nil {
+		return woke, err
+	}
+	cmd, declined, answered := p.convo.answerConfirm(text, now)
+	if answered {
+		cmd.Timestamp = now
+		withMood(cmd, text)
+		if declined != nil {
+			p.misunderstood(declined.Source, declined, ReasonDeclined)
+		}
+	} else {
+		if cmd, err = p.resolve(&p.convo, text); err != nil {
+			p.misunderstood(text, nil, ReasonInvalid)
+			return nil, err
+		}
+		cmd = p.confirm(&p.convo, cmd, text, now)
+	}
+	p.convo.remember(cmd)
+	return p.record(cmd)
+}
+
+// confirm returns cmd, or question confirming it when intent is too
+// uncertain to act on; command waits in conversation for answer. Unknown
+// and uncertain text is logged as misunderstood.
+func (p *Processor) confirm(c *Conversation, cmd *Command, text string, now time.Time) *Command {
+	p.mu.RLock()
+	threshold, timeout := p.cfg.ConfirmConfidence, p.cfg.dialogTimeout()
+	p.mu.RUnlock()
 	
-	// Basic command parsing
-	words := strings.Fields(strings.ToLower(text))
-	if len(words) == 0 {
-		return nil, errors.New("empty command")
+	if cmd.Type == CmdUnknown {
+		p.misunderstood(text, cmd, ReasonUnknown)
+	}
+	if !needsConfirm(cmd, threshold) {
+		return cmd
+	}
+	p.misunderstood(text, cmd, ReasonUncertain)
+	q := c.askConfirm(cmd, text, now, timeout)
+	q.Timestamp = now
+	return q
+}
+
+// SetPattern names pattern playing now for commands processed outside
+// dialogs to refer to, empty when none
+func (p *Processor) SetPattern(name string) {
+	p.convo.SetPattern(name)
+}
+
+// ProcessDialog handles command text said within dialog. Move command
+// missing speed or direction gets back CmdClarify command whose
+// "question" parameter asks for it; answer given within dialog timeout is
+// merged into pending command, which is returned once complete. Stop or
+// another command abandons pending one. References are resolved against
+// conversation of dialog, uncertain commands are confirmed and text is
+// gated by wake word as in ProcessCommand.
+func (p *Processor) ProcessDialog(d *Dialog, text string) (*Command, error) {
+	now := p.clock.Now()
+	text, woke, err := p.gate(&d.Conversation, text, now)
+	if err != nil || woke != nil {
+		return woke, err
+	}
+	if cmd, declined, ok := d.answerConfirm(text, now); ok {
+		cmd.Timestamp = now
+		withMood(cmd, text)
+		if declined != nil {
+			p.misunderstood(declined.Source, declined, ReasonDeclined)
+		}
+		d.remember(cmd)
+		return p.record(cmd)
+	}
+	cmd, err := p.resolve(&d.Conversation, text)
+	if err != nil {
+		p.misunderstood(text, nil, ReasonInvalid)
+		if pending, _ := d.Pending(now); pending == nil {
+			return nil, err
+		}
+		cmd = nil
 	}
 	
-	cmd := &Command{
-		Type:       p.determineCommandType(words),
+	p.mu.RLock()
+	timeout := p.cfg.dialogTimeout()
+	p.mu.RUnlock()
+	
+	next, stepErr := d.step(text, cmd, now, timeout)
+	if stepErr != nil {
+		return nil, stepErr
+	}
+	if next == nil {
+		return nil, err
+	}
+	next.Timestamp = now
+	withMood(next, text)
+	next = p.confirm(&d.Conversation, next, text, now)
+	d.remember(next)
+	return p.record(next)
+}
+
+// resolve turns text into command, taking what it refers to from
+// conversation. Text of several clauses or delayed one becomes CmdSequence.
+// Operator phrases and safeword mean what operator says, they are not
+// references; safeword is never delayed.
+func (p *Processor) resolve(c *Conversation, text string) (*Command, error) {
+	if len(text) > MaxCommandLength {
+		return p.parse(text)
+	}
+	p.mu.RLock()
+	word := p.cfg.Safeword
+	p.mu.RUnlock()
+	if safeword(strings.Fields(text), word) {
+		return p.parse(text)
+	}
+	if seq, ok, err := p.sequence(c, text); ok || err != nil {
+		return seq, err
+	}
+	return p.resolveClause(c, text)
+}
+
+// resolveClause resolves text of one command
+func (p *Processor) resolveClause(c *Conversation, text string) (*Command, error) {
+	p.mu.RLock()
+	vocab := p.vocabulary
+	p.mu.RUnlock()
+	
+	if _, ok := vocab.match(strings.Fields(text)); ok {
+		return p.parse(text)
+	}
+	if cmd, ok := c.resolve(text); ok {
+		cmd.Timestamp = p.clock.Now()
+		return withMood(cmd, text), nil
+	}
+	return p.parse(text)
+}
+
+// sequence parses compound text, "slow down and then stop after one
+// minute", into CmdSequence of its clauses with delay of each after one
+// before. It reports false for text of one undelayed command and for
+// untimed text with clause it does not understand, which is then taken as
+// a whole. Timed text with such clause gets CmdClarify question instead,
+// nothing said for later may run now.
+func (p *Processor) sequence(c *Conversation, text string) (*Command, bool, error) {
+	p.mu.RLock()
+	opts := ParseOptions{Classifier: p.classifier, MinConfidence: p.cfg.minConfidence(), Vocabulary: p.vocabulary}
+	p.mu.RUnlock()
+	
+	known := func(clause string) bool {
+		rest, _, _, err := clauseTiming(clause)
+		words := strings.Fields(strings.ToLower(rest))
+		if err != nil || len(words) == 0 {
+			return err == nil
+		}
+		for i := range words {
+			words[i] = trimWord(words[i])
+		}
+		if _, ok := speedChange(words); ok || repeats(words) {
+			return true
+		}
+		cmd, err := ParseWith(rest, opts)
+		return err == nil && cmd.Type != CmdUnknown
+	}
+	clauses := splitClauses(text, known)
+	
+	seq := &Command{
+		Type:       CmdSequence,
 		Parameters: make(map[string]interface{}),
 		Priority:   1,
-		Timestamp:  time.Now(),
+		Confidence: 1,
 	}
+	timed, timing := false, false
+	for _, clause := range clauses {
+		_, delay, hold, err := clauseTiming(clause)
+		if err != nil {
+			return nil, false, err
+		}
+		timing = timing || delay > 0 || hold > 0
+	}
+	var wait time.Duration
+	for _, clause := range clauses {
+		rest, delay, hold, _ := clauseTiming(clause)
+		timed = timed || delay > 0
+		if rest == "" {
+			wait += delay // bare "wait 5 seconds"
+			continue
+		}
+		step, err := p.resolveClause(c, rest)
+		if err != nil {
+			return nil, false, err
+		}
+		if step.Type == CmdUnknown {
+			if !timing {
+				return nil, false, nil
+			}
+			p.misunderstood(rest, step, ReasonUnknown)
+			return unclearStep(rest, p.clock.Now()), true, nil
+		}
+		step.Delay, step.Source = wait+delay, rest
+		wait = hold
+		seq.Steps = append(seq.Steps, *step)
+		seq.Priority = max(seq.Priority, step.Priority)
+		seq.Confidence = min(seq.Confidence, step.Confidence)
+	}
+	if len(seq.Steps) == 0 || (len(seq.Steps) == 1 && !timed) {
+		return nil, false, nil
+	}
+	seq.Timestamp = p.clock.Now()
+	return withMood(seq, text), true, nil
+}
+
+// resolved command gets mood of text referring to it
+func withMood(cmd *Command, text string) *Command {
+	cmd.Sentiment, cmd.Urgency = ScoreSentiment(text)
+	return cmd
+}
+
+// parse parses text with current intent classifier, asking language model
+// when there is one and classifier and keywords do not understand text.
+// Model failures and replies failing validation or safety filter leave
+// command unknown.
+func (p *Processor) parse(text string) (*Command, error) {
+	p.mu.RLock()
+	opts := ParseOptions{
+		Classifier:    p.classifier,
+		MinConfidence: p.cfg.minConfidence(),
+		Vocabulary:    p.vocabulary,
+		Safeword:      p.cfg.Safeword,
+		Exact:         p.cfg.ExactKeywords,
+	}
+	llm := p.llm
+	p.mu.RUnlock()
 	
-	// Parse parameters based on command type
-	switch cmd.Type {
-	case CmdMove:
-		p.parseMovementParams(words, cmd)
-	case CmdAdjust:
-		p.parseAdjustmentParams(words, cmd)
-	case CmdStatus:
-		// No parameters needed
-	case CmdStop:
-		cmd.Priority = 10 // High priority for stop command
+	cmd, err := ParseWith(text, opts)
+	if err != nil {
+		return nil, err
+	}
+	if cmd.Type == CmdUnknown && llm != nil {
+		if model, err := llm.Parse(p.ctx, text); err != nil {
+			p.lastErr.Set(err)
+			log.Printf("Language model failed on command: %v", err)
+		} else if !llmSafe(text, model) {
+			log.Printf("Language model read distressed command as %s, ignored", model.Type)
+		} else {
+			if unit, ok := cmd.Parameters["unit"]; ok {
+				model.Parameters["unit"] = unit
+			}
+			model.Sentiment, model.Urgency = cmd.Sentiment, cmd.Urgency
+			cmd = model
+		}
 	}
+	cmd.Timestamp = p.clock.Now()
+	return cmd, nil
+}
+
+// record stores command in history and audit
+func (p *Processor) record(cmd *Command) (*Command, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
 	
 	// Store command in history
 	p.commandHistory = append(p.commandHistory, *cmd)
-	if len(p.commandHistory) > 1000 {
+	if len(p.commandHistory) > p.cfg.HistorySize {
 		p.commandHistory = p.commandHistory[1:]
 	}
 	p.lastCommand = cmd
-	
-	return cmd, nil
-}
-
-// determineCommandType identifies command type from words
-func (p *Processor) determineCommandType(words []string) CommandType {
-	if len(words) == 0 {
-		return CmdUnknown
+	p.count(cmd)
+	if v, ok := cmd.Parameters["speed"].(float64); ok && cmd.Type == CmdMove {
+		p.speed = v
 	}
 	
-	// Simple keyword matching
-	moveKeywords := []string{"move", "go", "rotate", "turn"}
-	stopKeywords := []string{"stop", "halt", "freeze"}
-	adjustKeywords := []string{"adjust", "change", "modify"}
-	statusKeywords := []string{"status", "state", "condition"}
-	
-	for _, word := range words {
-		if containsWord(moveKeywords, word) {
-			return CmdMove
-		}
-		if containsWord(stopKeywords, word) {
-			return CmdStop
+	if p.audit != nil {
+		audited := *cmd
+		if p.cfg.Redact {
+			audited = redacted(audited)
 		}
-		if containsWord(adjustKeywords, word) {
-			return CmdAdjust
+		if _, err := p.audit.Append(audited); err != nil {
+			p.lastErr.Set(err)
+			return nil, err
 		}
-		if containsWord(statusKeywords, word) {
-			return CmdStatus
+		if err := p.audit.Trim(p.cfg.HistorySize); err != nil {
+			p.lastErr.Set(err)
 		}
 	}
 	
-	return CmdUnknown
+	return cmd, nil
 }
 
-// parseMovementParams extracts movement parameters
-func (p *Processor) parseMovementParams(words []string, cmd *Command) {
-	for i := 0; i < len(words)-1; i++ {
-		switch words[i] {
-		case "speed":
-			if speed, ok := parseFloat(words[i+1]); ok {
-				cmd.Parameters["speed"] = speed
-			}
-		case "direction":
-			cmd.Parameters["direction"] = words[i+1]
-		case "distance":
-			if dist, ok := parseFloat(words[i+1]); ok {
-				cmd.Parameters["distance"] = dist
-			}
+// redacted returns cmd without parameters and clause text
+func redacted(cmd Command) Command {
+	cmd.Parameters, cmd.Source = nil, ""
+	if len(cmd.Steps) > 0 {
+		steps := make([]Command, len(cmd.Steps))
+		for i, step := range cmd.Steps {
+			steps[i] = redacted(step)
 		}
+		cmd.Steps = steps
 	}
+	return cmd
 }
 
-// parseAdjustmentParams extracts adjustment parameters
-func (p *Processor) parseAdjustmentParams(words []string, cmd *Command) {
-	for i := 0; i < len(words)-1; i++ {
-		switch words[i] {
-		case "intensity":
-			if intensity, ok := parseFloat(words[i+1]); ok {
-				cmd.Parameters["intensity"] = intensity
-			}
-		case "sensitivity":
-			if sensitivity, ok := parseFloat(words[i+1]); ok {
-				cmd.Parameters["sensitivity"] = sensitivity
-			}
-		}
+// AttachStore enables persistent command audit and restores recent history
+func (p *Processor) AttachStore(store *storage.Store) error {
+	audit, err := storage.OpenTable[Command](store, storage.BucketCommands)
+	if err != nil {
+		return err
+	}
+	
+	history, err := audit.All()
+	if err != nil {
+		return err
 	}
+	if len(history) > p.cfg.HistorySize {
+		history = history[len(history)-p.cfg.HistorySize:]
+	}
+	
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	
+	p.audit = audit
+	p.commandHistory = append(history, p.commandHistory...)
+	if len(p.commandHistory) > 0 && p.lastCommand == nil {
+		last := p.commandHistory[len(p.commandHistory)-1]
+		p.lastCommand = &last
+	}
+	return p.attachMisunderstood(store)
+}
+
+// replySentiment is sentiment of neutral reply to command type
+var replySentiment = map[CommandType]float64{
+	CmdMove:     0.5,
+	CmdStop:     -0.3,
+	CmdAdjust:   0.2,
+	CmdStatus:   0.8,
+	CmdPattern:  0.5,
+	CmdPause:    0.1,
+	CmdResume:   0.4,
+	CmdPreset:   0.3,
+	CmdSafeword: -0.5,
+	CmdSequence: 0.4,
+	CmdClarify:  0.1,
+	CmdWake:     0.3,
+	CmdUnknown:  -0.1,
 }
 
-// GenerateResponse creates appropriate response
+// GenerateResponse creates appropriate response from reply templates of
+// selected personality, in tone fitting mood of command. Phrasings of reply
+// are used in turn.
 func (p *Processor) GenerateResponse(cmd *Command) (*Response, error) {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 	
+	t := cmd.Type
+	if _, ok := replySentiment[t]; !ok {
+		t = CmdUnknown
+	}
 	response := &Response{
 		Confidence: 0.8,
-		Timestamp:  time.Now(),
+		Sentiment:  replySentiment[t],
+		Tone:       toneFor(cmd),
+		Timestamp:  p.clock.Now(),
 	}
-	
-	// Generate response based on command type
-	switch cmd.Type {
-	case CmdMove:
-		response.Text = "Moving as requested, tovarisch"
-		response.Sentiment = 0.5
-	case CmdStop:
-		response.Text = "Emergency stop initiated! Bozhe moy!"
-		response.Sentiment = -0.3
+	switch t {
+	case CmdStop, CmdSafeword:
 		response.Confidence = 1.0
-	case CmdAdjust:
-		response.Text = "Adjusting parameters, one moment please"
-		response.Sentiment = 0.2
-	case CmdStatus:
-		response.Text = "All systems operational, running like Kalashnikov"
-		response.Sentiment = 0.8
-	default:
-		response.Text = "Command not understood, try again comrade"
-		response.Sentiment = -0.1
+	case CmdUnknown:
 		response.Confidence = 0.4
 	}
+	// reply is no surer than intent it answers
+	if t != CmdUnknown && cmd.Confidence > 0 {
+		response.Confidence = min(response.Confidence, cmd.Confidence)
+	}
+	
+	text, toned := p.reply(t, response.Tone, p.replyData(cmd))
+	response.Text = text
+	// answer in tone fitting mood of input
+	if toned {
+		response.Sentiment = toneSentiment[response.Tone]
+	} else {
+		response.Sentiment = max(-1, min(1, response.Sentiment+0.2*cmd.Sentiment))
+	}
 	
 	// Store response in history
 	p.responseHistory = append(p.responseHistory, *response)
-	if len(p.responseHistory) > 1000 {
+	if len(p.responseHistory) > p.cfg.HistorySize {
 		p.responseHistory = p.responseHistory[1:]
 	}
 	p.lastResponse = response
@@ -209,6 +549,133 @@ func (p *Processor) GenerateResponse(cmd *Command) (*Response, error) {
 	return response, nil
 }
 
+// reply renders next phrasing of reply to command type in tone, reporting
+// whether phrasing is one of tone. Replies selected personality lacks or
+// fails to render come from built-in default one.
+func (p *Processor) reply(t CommandType, tone Tone, data ReplyData) (string, bool) {
+	for _, pers := range []*Personality{p.personality, defaultPersonality()} {
+		key, tmpls := pers.phrasings(t, tone)
+		if len(tmpls) == 0 {
+			continue
+		}
+		turn := pers.Name + "/" + key
+		tmpl := tmpls[p.replyTurns[turn]%len(tmpls)]
+		p.replyTurns[turn]++
+		text, err := render(tmpl, data)
+		if err != nil {
+			p.lastErr.Set(err)
+			log.Printf("Reply template %s of %s failed: %v", key, pers.Name, err)
+			continue
+		}
+		return text, key != string(t)
+	}
+	return "", false
+}
+
+// replyData fills reply variables from command, speed and pattern it does
+// not name are last speed given and pattern playing
+func (p *Processor) replyData(cmd *Command) ReplyData {
+	data := ReplyData{Speed: p.speed, Pattern: p.convo.Pattern()}
+	if v, ok := cmd.Parameters["speed"].(float64); ok {
+		data.Speed = v
+	}
+	if v, ok := cmd.Parameters["direction"].(string); ok {
+		data.Direction = v
+	}
+	if v, ok := cmd.Parameters["pattern"].(string); ok {
+		data.Pattern = v
+	}
+	if v, ok := cmd.Parameters["intensity"].(float64); ok {
+		data.Intensity = v
+	}
+	if v, ok := cmd.Parameters["question"].(string); ok {
+		data.Question = v
+	}
+	if v, ok := cmd.Parameters["preset"].(string); ok {
+		data.Preset = v
+	}
+	data.Plan = plan(cmd.Steps)
+	return data
+}
+
+// Config returns current processor options
+func (p *Processor) Config() Config {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.cfg
+}
+
+// SetConfig replaces processor options at runtime, trimming history if it
+// shrank, retraining intent classifier if its training file changed and
+// reading vocabulary and personality files again
+func (p *Processor) SetConfig(cfg Config) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	
+	p.mu.RLock()
+	clf := p.classifier
+	retrain := cfg.IntentFile != p.cfg.IntentFile
+	p.mu.RUnlock()
+	if retrain {
+		var err error
+		if clf, err = cfg.classifier(); err != nil {
+			return err
+		}
+	}
+	vocab, err := cfg.vocabulary()
+	if err != nil {
+		return err
+	}
+	llm, err := NewLLMParser(cfg.LLM)
+	if err != nil {
+		return err
+	}
+	pers, err := cfg.personality()
+	if err != nil {
+		return err
+	}
+	
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	
+	p.cfg = cfg
+	p.classifier = clf
+	p.vocabulary = vocab
+	p.llm = llm
+	p.personality = pers
+	if n := len(p.commandHistory); n > cfg.HistorySize {
+		p.commandHistory = p.commandHistory[n-cfg.HistorySize:]
+	}
+	if n := len(p.responseHistory); n > cfg.HistorySize {
+		p.responseHistory = p.responseHistory[n-cfg.HistorySize:]
+	}
+	return nil
+}
+
+// GetHistory returns recent commands, oldest first
+func (p *Processor) GetHistory() []Command {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return append([]Command(nil), p.commandHistory...)
+}
+
+// RestoreHistory replaces command history, used when loading saved state
+func (p *Processor) RestoreHistory(history []Command) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	
+	if len(history) > p.cfg.HistorySize {
+		history = history[len(history)-p.cfg.HistorySize:]
+	}
+	p.commandHistory = append([]Command(nil), history...)
+	p.lastCommand = nil
+	if len(p.commandHistory) > 0 {
+		last := p.commandHistory[len(p.commandHistory)-1]
+		p.lastCommand = &last
+	}
+}
+
 // GetLastCommand returns most recent command
 func (p *Processor) GetLastCommand() *Command {
 	p.mu.RLock()
@@ -223,23 +690,34 @@ func (p *Processor) GetLastResponse() *Response {
 	return p.lastResponse
 }
 
+// Health reports whether processor runs, last audit error and history
+// gauges
+func (p *Processor) Health() health.Report {
+	status := health.OK
+	if p.ctx.Err() != nil {
+		status = health.Down
+	}
+	
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	
+	persisted := 0.0
+	if p.audit != nil {
+		persisted = 1
+	}
+	return health.Report{
+		Status:    status,
+		LastError: p.lastErr.String(),
+		Gauges: map[string]float64{
+			"commands":      float64(len(p.commandHistory)),
+			"responses":     float64(len(p.responseHistory)),
+			"persisted":     persisted,
+			"misunderstood": float64(p.metrics.misunderstood),
+		},
+	}
+}
+
 // Shutdown stops NLP processor
 func (p *Processor) Shutdown() {
 	p.cancelFunc()
 }
-
-// Helper functions
-
-func containsWord(words []string, target string) bool {
-	for _, word := range words {
-		if word == target {
-			return true
-		}
-	}
-	return false
-}
-
-func parseFloat(s string) (float64, bool) {
-	// TODO: implement proper float parsing
-	return 0.0, false
-} 
\ No newline at end of file
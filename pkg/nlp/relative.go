@@ -0,0 +1,155 @@
+package nlp
+
+import (
+	"fmt"
+	"strings"
+)
+
+// speed steps of relative commands, "a bit faster" takes small one
+const (
+	speedStep      = 0.25
+	smallSpeedStep = 0.1
+	largeSpeedStep = 0.4
+)
+
+// stepWords size relative change said without amount, "a bit faster"
+var stepWords = map[string]float64{
+	"bit": smallSpeedStep, "little": smallSpeedStep, "slightly": smallSpeedStep,
+	"much": largeSpeedStep, "lot": largeSpeedStep,
+}
+
+// relative speed words, sign is direction of change
+var speedChanges = map[string]float64{
+	"faster": 1, "quicker": 1, "speedier": 1,
+	"slower": -1, "slowlier": -1,
+}
+
+// adjustable parameters, relative changes without one named apply to speed
+var adjustableParams = []string{"speed", "intensity", "sensitivity"}
+
+// change words of relative adjustments. Verbs make adjustment on their
+// own, "increase by 10%", as do "faster" and "slower" for speed;
+// directions only next to parameter name, "intensity up", as "move up" is
+// movement.
+var (
+	changeVerbs = map[string]float64{
+		"increase": 1, "raise": 1, "boost": 1,
+		"decrease": -1, "lower": -1, "reduce": -1, "drop": -1,
+	}
+	changeDirections = map[string]float64{
+		"up": 1, "more": 1, "higher": 1,
+		"down": -1, "less": -1,
+	}
+)
+
+// maxChangeFactor bounds percent increase, "+1000%" is no sane request
+const maxChangeFactor = 10
+
+// parseRelative reads relative adjustment: "+10%", "increase intensity by
+// 0.1", "speed down a bit", "slow down". Percentages scale current value
+// and set "<param>_factor"; plain amounts and steps add to it and set
+// "<param>_change". It reports false for text with no relative adjustment.
+func parseRelative(words []string) (map[string]interface{}, bool, error) {
+	trimmed := make([]string, len(words))
+	name := ""
+	for i, w := range words {
+		trimmed[i] = trimWord(w)
+		if name == "" && containsWord(adjustableParams, trimmed[i]) {
+			name = trimmed[i]
+		}
+	}
+
+	// "faster" and "slow down" can only mean speed
+	sign, amount, percent, found := 0.0, 0.0, false, false
+	if name == "" || name == "speed" {
+		sign, _ = speedChange(trimmed)
+	}
+	for i, raw := range words {
+		w := trimWord(raw)
+		if s, ok := changeVerbs[w]; ok && sign == 0 {
+			sign = s
+			continue
+		}
+		if s, ok := changeDirections[w]; ok && sign == 0 && name != "" {
+			sign = s
+			continue
+		}
+		// "+10%", "-0.1"
+		if len(w) > 1 && (w[0] == '+' || w[0] == '-') {
+			v, ok := parseFloat(strings.TrimSuffix(w[1:], "%"))
+			if !ok {
+				continue
+			}
+			sign, amount, found = 1, v, true
+			if w[0] == '-' {
+				sign = -1
+			}
+			percent = strings.HasSuffix(w, "%") || (i+1 < len(words) && percentWords[trimWord(words[i+1])])
+			break
+		}
+		// "by 10 percent"
+		if w == "by" && sign != 0 {
+			rest := words[i+1:]
+			v, n, ok := parseNumber(rest)
+			if !ok {
+				continue
+			}
+			amount, found = v, true
+			percent = strings.HasSuffix(trimWord(rest[0]), "%") || (n < len(rest) && percentWords[trimWord(rest[n])])
+			break
+		}
+	}
+	if sign == 0 {
+		return nil, false, nil
+	}
+	if name == "" {
+		name = "speed"
+	}
+	if !found {
+		// no amount said, step as in "faster"
+		amount = speedStep
+		for _, w := range trimmed {
+			if step, ok := stepWords[w]; ok {
+				amount = step
+				break
+			}
+		}
+	}
+
+	if amount < 0 {
+		// "lower by -10%" is ambiguous, direction goes in words
+		return nil, false, fmt.Errorf("%w: %s change by %g, use increase or decrease", ErrParamOutOfRange, name, amount)
+	}
+
+	params := make(map[string]interface{})
+	switch {
+	case percent:
+		factor := 1 + sign*amount/100
+		if factor < 0 || factor > maxChangeFactor {
+			return nil, false, fmt.Errorf("%w: %s change %+g%%", ErrParamOutOfRange, name, sign*amount)
+		}
+		params[name+"_factor"] = factor
+	default:
+		if amount < 0 || amount > 1 {
+			return nil, false, fmt.Errorf("%w: %s change %+g, use 0 to 1 or percent", ErrParamOutOfRange, name, sign*amount)
+		}
+		params[name+"_change"] = sign * amount
+	}
+	return params, true, nil
+}
+
+// speedChange finds relative speed word and direction of change
+func speedChange(words []string) (float64, bool) {
+	for i, w := range words {
+		if sign, ok := speedChanges[w]; ok {
+			return sign, true
+		}
+		if i+1 < len(words) && (w == "speed" || w == "slow") && (words[i+1] == "up" || words[i+1] == "down") {
+			if words[i+1] == "up" {
+				return 1, true
+			}
+			return -1, true
+		}
+	}
+	return 0, false
+}
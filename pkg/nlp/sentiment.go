@@ -0,0 +1,98 @@
+package nlp
+
+import (
+	"math"
+	"strings"
+	"unicode"
+)
+
+// sentimentLexicon scores words from -1 (very negative) to 1 (very
+// positive)
+var sentimentLexicon = map[string]float64{
+	"good": 0.5, "great": 0.7, "nice": 0.5, "love": 0.8, "lovely": 0.7,
+	"perfect": 0.9, "wonderful": 0.8, "amazing": 0.8, "excellent": 0.8,
+	"yes": 0.3, "more": 0.2, "thanks": 0.5, "thank": 0.5, "please": 0.1,
+	"happy": 0.6, "like": 0.4, "enjoy": 0.6, "relaxing": 0.5, "better": 0.4,
+	"gentle": 0.3, "gently": 0.3, "comfortable": 0.5, "fine": 0.2, "ok": 0.1, "okay": 0.1,
+
+	"bad": -0.5, "terrible": -0.8, "awful": -0.8, "hate": -0.8, "worse": -0.5,
+	"no": -0.3, "ouch": -0.8, "hurt": -0.8, "hurts": -0.8, "pain": -0.8,
+	"painful": -0.8, "uncomfortable": -0.6, "wrong": -0.5, "annoying": -0.6,
+	"scared": -0.7, "afraid": -0.7, "angry": -0.7, "rough": -0.4, "harsh": -0.5,
+	"enough": -0.3, "tired": -0.3, "sore": -0.5, "stupid": -0.6,
+}
+
+// urgencyWords signal user needs something at once, scored 0 to 1. Stop
+// words stay below urgentLevel, plain "stop" is not urgent but "stop!" is.
+var urgencyWords = map[string]float64{
+	"now": 0.5, "immediately": 0.8, "hurry": 0.6, "quick": 0.3, "quickly": 0.3,
+	"asap": 0.7, "urgent": 0.8, "emergency": 1, "help": 0.8, "stop": 0.5,
+	"halt": 0.5, "freeze": 0.5, "ouch": 0.7, "hurts": 0.6, "pain": 0.6, "right": 0.1,
+}
+
+// negators flip sentiment of next scored word, "not good"
+var negators = map[string]bool{"not": true, "don't": true, "dont": true, "never": true, "no": true, "isn't": true, "doesn't": true}
+
+// intensifiers scale next scored word, "very good", "a bit rough"
+var intensifiers = map[string]float64{
+	"very": 1.5, "really": 1.5, "so": 1.3, "extremely": 1.8, "too": 1.3,
+	"slightly": 0.5, "bit": 0.6, "little": 0.6, "somewhat": 0.6,
+}
+
+// ScoreSentiment rates mood of command text: sentiment from -1 (negative)
+// to 1 (positive) and urgency from 0 to 1. It uses word lexicons with
+// negation and intensifiers; exclamation marks and shouting add urgency.
+func ScoreSentiment(text string) (sentiment, urgency float64) {
+	var sum, weight float64
+	negate, scale := false, 1.0
+	for _, raw := range strings.Fields(text) {
+		w := trimWord(strings.ToLower(raw))
+		if u, ok := urgencyWords[w]; ok {
+			urgency = max(urgency, u)
+		}
+		if s, ok := sentimentLexicon[w]; ok && !negators[w] {
+			if negate {
+				s = -s * 0.5 // "not good" is milder than "bad"
+			}
+			sum += max(-1, min(1, s*scale))
+			weight++
+			negate, scale = false, 1
+			continue
+		}
+		if negators[w] {
+			negate = true
+			// lone "no" is negative too
+			sum += sentimentLexicon[w]
+			if _, scored := sentimentLexicon[w]; scored {
+				weight++
+			}
+			continue
+		}
+		if f, ok := intensifiers[w]; ok {
+			scale *= f
+		}
+	}
+	if weight > 0 {
+		sentiment = max(-1, min(1, sum/weight))
+	}
+
+	urgency += 0.15 * float64(min(strings.Count(text, "!"), 3))
+	if shouting(text) {
+		urgency += 0.3
+	}
+	return sentiment, math.Min(urgency, 1)
+}
+
+// shouting reports text written in capitals, "STOP NOW"
+func shouting(text string) bool {
+	letters, upper := 0, 0
+	for _, r := range text {
+		if unicode.IsLetter(r) {
+			letters++
+			if unicode.IsUpper(r) {
+				upper++
+			}
+		}
+	}
+	return letters >= 3 && upper == letters
+}
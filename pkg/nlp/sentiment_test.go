@@ -0,0 +1,61 @@
+package nlp
+
+import (
+	"math"
+	"testing"
+)
+
+func TestScoreSentiment(t *testing.T) {
+	tests := []struct {
+		text      string
+		sentiment float64
+		urgency   float64
+	}{
+		{"move left", 0, 0},
+		{"that is good", 0.5, 0},
+		{"not good", -0.25, 0},
+		{"very good", 0.75, 0},
+		{"ouch that hurts", -0.8, 0.7},
+		{"stop", 0, 0.5},
+		{"stop!", 0, 0.65},
+		{"STOP NOW", 0, 0.8},
+		{"help!!!!", 0, 1},
+	}
+	for _, tt := range tests {
+		s, u := ScoreSentiment(tt.text)
+		if math.Abs(s-tt.sentiment) > 1e-9 || math.Abs(u-tt.urgency) > 1e-9 {
+			t.Errorf("ScoreSentiment(%q) = %.2f, %.2f, want %.2f, %.2f", tt.text, s, u, tt.sentiment, tt.urgency)
+		}
+	}
+}
+
+func TestReplyTone(t *testing.T) {
+	tests := []struct {
+		text string
+		want Tone
+	}{
+		{"stop", ToneNeutral},
+		{"halt", ToneNeutral},
+		{"stop!", ToneBrief},
+		{"STOP NOW", ToneBrief},
+		{"hurry up and move", ToneBrief},
+		{"ouch stop it hurts", ToneSoothing},
+		{"move left please", ToneNeutral},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			p, _ := newTestProcessor(t, DefaultConfig())
+			cmd, err := p.ProcessCommand(tt.text)
+			if err != nil {
+				t.Fatalf("ProcessCommand(%q): %v", tt.text, err)
+			}
+			resp, err := p.GenerateResponse(cmd)
+			if err != nil {
+				t.Fatalf("GenerateResponse: %v", err)
+			}
+			if resp.Tone != tt.want {
+				t.Errorf("tone of %q = %s, want %s", tt.text, resp.Tone, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,35 @@
+package nlp
+
+// Tone is manner of reply chosen from mood of command it answers
+type Tone string
+
+const (
+	ToneNeutral  Tone = "neutral"
+	ToneSoothing Tone = "soothing" // input was negative, reply calms
+	ToneBrief    Tone = "brief"    // input was urgent, reply gets to point
+)
+
+// mood levels switching tone
+const (
+	urgentLevel   = 0.6
+	negativeLevel = -0.3
+)
+
+// toneSentiment is sentiment of replies in tone, personalities phrase
+// them as "stop.brief" or "move.soothing"
+var toneSentiment = map[Tone]float64{
+	ToneBrief:    0,
+	ToneSoothing: 0.3,
+}
+
+// toneFor picks tone of reply to cmd, upset users are soothed even when
+// urgent
+func toneFor(cmd *Command) Tone {
+	switch {
+	case cmd.Sentiment <= negativeLevel:
+		return ToneSoothing
+	case cmd.Urgency >= urgentLevel:
+		return ToneBrief
+	}
+	return ToneNeutral
+}
@@ -0,0 +1,97 @@
+package nlp
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/sashalind/sex-artifical-intelligence/pkg/core/errs"
+)
+
+// ErrInvalidVocabulary is returned for vocabulary files parser cannot use
+var ErrInvalidVocabulary = errs.New(errs.InvalidArgument, "invalid command vocabulary")
+
+// Vocabulary maps operator phrases to command types they trigger, single
+// words and multi-word phrases alike: "knock it off" to stop, "get going"
+// to move. Phrases match whole words anywhere in command, ignoring case and
+// punctuation.
+type Vocabulary map[CommandType][]string
+
+// LoadVocabulary reads JSON object of command types and their phrases,
+// e.g. {"stop": ["knock it off"], "adjust": ["slow down"]}
+func LoadVocabulary(path string) (Vocabulary, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var v Vocabulary
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidVocabulary, err)
+	}
+	if err := v.Validate(); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// Validate checks every phrase has words and triggers one command type
+func (v Vocabulary) Validate() error {
+	seen := make(map[string]CommandType)
+	for t, phrases := range v {
+		switch t {
+		case CmdMove, CmdStop, CmdAdjust, CmdStatus, CmdPause, CmdResume:
+		default:
+			return fmt.Errorf("%w: command type %q", ErrInvalidVocabulary, t)
+		}
+		for _, p := range phrases {
+			norm := normalizePhrase(p)
+			if norm == "" {
+				return fmt.Errorf("%w: empty %s phrase", ErrInvalidVocabulary, t)
+			}
+			if other, ok := seen[norm]; ok && other != t {
+				return fmt.Errorf("%w: %q triggers both %s and %s", ErrInvalidVocabulary, p, other, t)
+			}
+			seen[norm] = t
+		}
+	}
+	return nil
+}
+
+// match finds command type of phrase in words. Stop phrases win, among
+// others longest phrase does.
+func (v Vocabulary) match(words []string) (CommandType, bool) {
+	if len(v) == 0 {
+		return "", false
+	}
+	text := " " + normalizePhrase(strings.Join(words, " ")) + " "
+	best, bestLen := CommandType(""), 0
+	for t, phrases := range v {
+		for _, p := range phrases {
+			p = normalizePhrase(p)
+			if p == "" || !strings.Contains(text, " "+p+" ") {
+				continue
+			}
+			if t == CmdStop {
+				return CmdStop, true
+			}
+			// type name breaks ties so map order does not matter
+			if len(p) > bestLen || (len(p) == bestLen && t < best) {
+				best, bestLen = t, len(p)
+			}
+		}
+	}
+	return best, bestLen > 0
+}
+
+// normalizePhrase lowercases phrase and strips punctuation of its words
+func normalizePhrase(p string) string {
+	words := strings.Fields(strings.ToLower(p))
+	out := words[:0]
+	for _, w := range words {
+		if w = trimWord(w); w != "" {
+			out = append(out, w)
+		}
+	}
+	return strings.Join(out, " ")
+}
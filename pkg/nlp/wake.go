@@ -0,0 +1,98 @@
+package nlp
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sashalind/sex-artifical-intelligence/pkg/core/errs"
+)
+
+// ErrNotAddressed is returned for text not addressed to device while wake
+// word gates commands, chatter of always-listening input
+var ErrNotAddressed = errs.New(errs.FailedPrecondition, "command not addressed to device")
+
+// DefaultWakeWindow is how long after being addressed text needs no wake
+// word
+const DefaultWakeWindow = 15 * time.Second
+
+// wakeGreetings may stand before wake word, "hey robot"
+var wakeGreetings = map[string]bool{"hey": true, "hi": true, "hello": true, "ok": true, "okay": true, "yo": true}
+
+// gate lets through text addressed to device: text naming wake word, with
+// wake word and greeting before it removed, or any text within window
+// after it. Wake word alone gives CmdWake. Stop and safeword are never
+// gated. Without wake word everything passes.
+func (p *Processor) gate(c *Conversation, text string, now time.Time) (string, *Command, error) {
+	p.mu.RLock()
+	wake, window := p.cfg.WakeWord, p.cfg.wakeWindow()
+	word, vocab := p.cfg.Safeword, p.vocabulary
+	p.mu.RUnlock()
+
+	phrase := strings.Fields(normalizePhrase(wake))
+	if len(phrase) == 0 {
+		return text, nil, nil
+	}
+	rest, named := stripWakeWord(text, phrase)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	switch {
+	case named:
+		c.awakeUntil = now.Add(window)
+		if rest == "" {
+			return "", &Command{Type: CmdWake, Parameters: map[string]interface{}{}, Priority: 1, Confidence: 1, Timestamp: now}, nil
+		}
+		return rest, nil, nil
+	case now.Before(c.awakeUntil):
+		c.awakeUntil = now.Add(window)
+		return text, nil, nil
+	case halting(strings.Fields(strings.ToLower(text)), word, vocab):
+		return text, nil, nil
+	}
+	return "", nil, fmt.Errorf("%w: say %q first", ErrNotAddressed, wake)
+}
+
+// stripWakeWord removes first wake phrase of text and greetings right
+// before it, reporting whether text named it
+func stripWakeWord(text string, phrase []string) (string, bool) {
+	fields := strings.Fields(text)
+	norm := make([]string, len(fields))
+	for i, f := range fields {
+		norm[i] = trimWord(strings.ToLower(f))
+	}
+	for i := 0; i+len(phrase) <= len(norm); i++ {
+		match := true
+		for j, w := range phrase {
+			if norm[i+j] != w {
+				match = false
+				break
+			}
+		}
+		if !match {
+			continue
+		}
+		start := i
+		for start > 0 && wakeGreetings[norm[start-1]] {
+			start--
+		}
+		rest := append(append([]string(nil), fields[:start]...), fields[i+len(phrase):]...)
+		return strings.TrimLeft(strings.Join(rest, " "), ",.;:!? "), true
+	}
+	return text, false
+}
+
+// halting reports text saying stop, in keywords or operator phrases, or
+// safeword
+func halting(words []string, safewordPhrase string, vocab Vocabulary) bool {
+	if safeword(words, safewordPhrase) {
+		return true
+	}
+	for _, w := range words {
+		if containsWord(stopKeywords, trimWord(w)) {
+			return true
+		}
+	}
+	t, ok := vocab.match(words)
+	return ok && t == CmdStop
+}
@@ -0,0 +1,229 @@
+// Package profile keeps per-user preferences. Profiles live in memory and
+// are persisted to local store once one is attached.
+package profile
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sashalind/sex-artifical-intelligence/pkg/clock"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/core/errs"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/storage"
+)
+
+var (
+	ErrNotFound       = errs.New(errs.NotFound, "profile not found")
+	ErrInvalidProfile = errs.New(errs.InvalidArgument, "invalid profile")
+)
+
+// Preferences tune system for one user. Zero fields mean "use default".
+type Preferences struct {
+	DefaultSpeed float64 `json:"default_speed,omitempty"`
+	MaxSpeed     float64 `json:"max_speed,omitempty"`
+	Intensity    float64 `json:"intensity,omitempty"`
+	MaxIntensity float64 `json:"max_intensity,omitempty"`
+
+	// FavoritePatterns are motion pattern names, most liked first
+	FavoritePatterns []string `json:"favorite_patterns,omitempty"`
+
+	// Language is BCP 47 tag like "en" or "ru"
+	Language string `json:"language,omitempty"`
+}
+
+// Merge returns p with non-zero fields of o applied on top
+func (p Preferences) Merge(o Preferences) Preferences {
+	if o.DefaultSpeed != 0 {
+		p.DefaultSpeed = o.DefaultSpeed
+	}
+	if o.MaxSpeed != 0 {
+		p.MaxSpeed = o.MaxSpeed
+	}
+	if o.Intensity != 0 {
+		p.Intensity = o.Intensity
+	}
+	if o.MaxIntensity != 0 {
+		p.MaxIntensity = o.MaxIntensity
+	}
+	if len(o.FavoritePatterns) > 0 {
+		p.FavoritePatterns = o.FavoritePatterns
+	}
+	if o.Language != "" {
+		p.Language = o.Language
+	}
+	return p
+}
+
+// Validate checks preference values
+func (p Preferences) Validate() error {
+	for name, v := range map[string]float64{
+		"default speed": p.DefaultSpeed,
+		"max speed":     p.MaxSpeed,
+		"intensity":     p.Intensity,
+		"max intensity": p.MaxIntensity,
+	} {
+		if v < 0 {
+			return fmt.Errorf("%w: %s must not be negative", ErrInvalidProfile, name)
+		}
+	}
+	if p.MaxSpeed > 0 && p.DefaultSpeed > p.MaxSpeed {
+		return fmt.Errorf("%w: default speed above max speed", ErrInvalidProfile)
+	}
+	if p.MaxIntensity > 0 && p.Intensity > p.MaxIntensity {
+		return fmt.Errorf("%w: intensity above max intensity", ErrInvalidProfile)
+	}
+	if strings.ContainsAny(p.Language, " \t\n") {
+		return fmt.Errorf("%w: language %q", ErrInvalidProfile, p.Language)
+	}
+	return nil
+}
+
+// CapSpeed limits speed to MaxSpeed when one is set
+func (p Preferences) CapSpeed(speed float64) float64 {
+	if p.MaxSpeed > 0 && speed > p.MaxSpeed {
+		return p.MaxSpeed
+	}
+	return speed
+}
+
+// CapIntensity limits intensity to MaxIntensity when one is set
+func (p Preferences) CapIntensity(intensity float64) float64 {
+	if p.MaxIntensity > 0 && intensity > p.MaxIntensity {
+		return p.MaxIntensity
+	}
+	return intensity
+}
+
+// Loosens reports whether p allows more than o does: speed or intensity
+// cap of o is raised or removed
+func (p Preferences) Loosens(o Preferences) bool {
+	looser := func(v, limit float64) bool { return limit > 0 && (v == 0 || v > limit) }
+	return looser(p.MaxSpeed, o.MaxSpeed) || looser(p.MaxIntensity, o.MaxIntensity)
+}
+
+// Profile is who is interacting with system
+type Profile struct {
+	Name        string      `json:"name"`
+	Preferences Preferences `json:"preferences"`
+	UpdatedAt   time.Time   `json:"updated_at"`
+}
+
+// Validate checks profile is storable
+func (p Profile) Validate() error {
+	if strings.TrimSpace(p.Name) == "" {
+		return fmt.Errorf("%w: empty name", ErrInvalidProfile)
+	}
+	return p.Preferences.Validate()
+}
+
+// key is case-insensitive storage key of profile name
+func key(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// Store holds saved profiles
+type Store struct {
+	mu       sync.RWMutex
+	profiles map[string]Profile
+	table    *storage.Table[Profile] // nil while running without storage
+	clock    clock.Clock
+}
+
+// NewStore creates empty in-memory profile store
+func NewStore(clk clock.Clock) (*Store, error) {
+	return &Store{
+		profiles: make(map[string]Profile),
+		clock:    clock.OrReal(clk),
+	}, nil
+}
+
+// AttachStore persists profiles to local store and loads saved ones.
+// Profiles saved before attaching are written out too.
+func (s *Store) AttachStore(store *storage.Store) error {
+	table, err := storage.OpenTable[Profile](store, storage.BucketProfiles)
+	if err != nil {
+		return err
+	}
+	saved, err := table.All()
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, p := range s.profiles {
+		if err := table.Put(key(p.Name), p); err != nil {
+			return err
+		}
+	}
+	for _, p := range saved {
+		if _, ok := s.profiles[key(p.Name)]; !ok {
+			s.profiles[key(p.Name)] = p
+		}
+	}
+	s.table = table
+	return nil
+}
+
+// Get returns profile by name, names are case-insensitive
+func (s *Store) Get(name string) (Profile, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	p, ok := s.profiles[key(name)]
+	if !ok {
+		return Profile{}, fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	return p, nil
+}
+
+// Put creates or replaces profile and returns stored version
+func (s *Store) Put(p Profile) (Profile, error) {
+	if err := p.Validate(); err != nil {
+		return Profile{}, err
+	}
+	p.Name = strings.TrimSpace(p.Name)
+	p.UpdatedAt = s.clock.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.table != nil {
+		if err := s.table.Put(key(p.Name), p); err != nil {
+			return Profile{}, err
+		}
+	}
+	s.profiles[key(p.Name)] = p
+	return p, nil
+}
+
+// Delete removes profile
+func (s *Store) Delete(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.profiles[key(name)]; !ok {
+		return fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	delete(s.profiles, key(name))
+	if s.table != nil {
+		return s.table.Delete(key(name))
+	}
+	return nil
+}
+
+// List returns all profiles sorted by name
+func (s *Store) List() []Profile {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	list := make([]Profile, 0, len(s.profiles))
+	for _, p := range s.profiles {
+		list = append(list, p)
+	}
+	sort.Slice(list, func(i, j int) bool { return key(list[i].Name) < key(list[j].Name) })
+	return list
+}
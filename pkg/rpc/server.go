@@ -0,0 +1,85 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	saiv1 "github.com/sashalind/sex-artifical-intelligence/api/proto/sai/v1"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/core"
+)
+
+// Server serves ControlService of api/proto/sai/v1 over gRPC
+type Server struct {
+	grpc   *grpc.Server
+	mu     sync.Mutex
+	closed bool
+}
+
+// NewServer creates gRPC server for system
+func NewServer(sys *core.System) (*Server, error) {
+	srv := grpc.NewServer(grpc.UnaryInterceptor(authenticate(sys)))
+	saiv1.RegisterControlServiceServer(srv, NewControlService(sys))
+	return &Server{grpc: srv}, nil
+}
+
+// authenticate checks "authorization: Bearer <token>" metadata of every
+// call and attaches caller to its context
+func authenticate(sys *core.System) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if !sys.AuthRequired() {
+			return handler(ctx, req)
+		}
+		var token string
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if values := md.Get("authorization"); len(values) > 0 {
+				token, _ = strings.CutPrefix(values[0], "Bearer ")
+			}
+		}
+		p, err := sys.Authenticate(ctx, strings.TrimSpace(token))
+		if err != nil {
+			return nil, statusError(err)
+		}
+		return handler(core.WithPrincipal(ctx, p), req)
+	}
+}
+
+// ListenAndServe accepts clients on addr until Close is called
+func (s *Server) ListenAndServe(addr string) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(ln)
+}
+
+// Serve accepts clients on ln until Close is called
+func (s *Server) Serve(ln net.Listener) error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		ln.Close()
+		return errors.New("rpc server closed")
+	}
+	s.mu.Unlock()
+
+	if err := s.grpc.Serve(ln); err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+		return err
+	}
+	return nil
+}
+
+// Close stops accepting clients and drops open connections
+func (s *Server) Close() error {
+	s.mu.Lock()
+	s.closed = true
+	s.mu.Unlock()
+
+	s.grpc.Stop()
+	return nil
+}
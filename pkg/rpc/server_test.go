@@ -0,0 +1,169 @@
+package rpc
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	saiv1 "github.com/sashalind/sex-artifical-intelligence/api/proto/sai/v1"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/clock"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/core"
+)
+
+const testToken = "s3cret"
+
+// newClient serves system over in-memory connection
+func newClient(t *testing.T, access core.AccessConfig) saiv1.ControlServiceClient {
+	t.Helper()
+	cfg := core.DefaultConfig()
+	cfg.Clock = clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	cfg.SelfTest.Disabled = true
+	cfg.Access = access
+	sys, err := core.NewSystemWithConfig(cfg)
+	if err != nil {
+		t.Fatalf("NewSystemWithConfig: %v", err)
+	}
+	t.Cleanup(sys.Shutdown)
+
+	srv, err := NewServer(sys)
+	if err != nil {
+		t.Fatalf("NewServer: %v", err)
+	}
+	ln := bufconn.Listen(1 << 20)
+	go srv.Serve(ln)
+	t.Cleanup(func() { srv.Close() })
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return ln.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return saiv1.NewControlServiceClient(conn)
+}
+
+func TestControlService(t *testing.T) {
+	client := newClient(t, core.AccessConfig{})
+	ctx := context.Background()
+
+	motors, err := client.GetMotors(ctx, &saiv1.GetMotorsRequest{})
+	if err != nil {
+		t.Fatalf("GetMotors: %v", err)
+	}
+	if len(motors.GetMotors()) != 2 || motors.GetMotors()[0].GetId() != "servo_1" || motors.GetMotors()[0].GetMaxSpeed() != 180 {
+		t.Errorf("GetMotors = %v, want servo_1 and servo_2", motors.GetMotors())
+	}
+
+	caps, err := client.GetCapabilities(ctx, &saiv1.GetCapabilitiesRequest{})
+	if err != nil {
+		t.Fatalf("GetCapabilities: %v", err)
+	}
+	if len(caps.GetUnits()) == 0 || len(caps.GetCommands()) == 0 {
+		t.Errorf("GetCapabilities = %v, want units and commands", caps)
+	}
+
+	reply, err := client.ProcessCommand(ctx, &saiv1.CommandRequest{Text: "status"})
+	if err != nil {
+		t.Fatalf("ProcessCommand: %v", err)
+	}
+	if reply.GetText() == "" || reply.GetTimestamp().AsTime().IsZero() {
+		t.Errorf("ProcessCommand = %v, want reply with timestamp", reply)
+	}
+}
+
+func TestControlServiceErrors(t *testing.T) {
+	sum := sha256.Sum256([]byte(testToken))
+	secured := core.AccessConfig{Tokens: []core.AccessToken{{User: "app", Role: core.RoleOperator, SHA256: hex.EncodeToString(sum[:])}}}
+
+	tests := []struct {
+		name   string
+		access core.AccessConfig
+		token  string
+		call   func(context.Context, saiv1.ControlServiceClient) error
+		want   codes.Code
+	}{
+		{"empty command", core.AccessConfig{}, "", func(ctx context.Context, c saiv1.ControlServiceClient) error {
+			_, err := c.ProcessCommand(ctx, &saiv1.CommandRequest{})
+			return err
+		}, codes.InvalidArgument},
+		{"empty sensor type", core.AccessConfig{}, "", func(ctx context.Context, c saiv1.ControlServiceClient) error {
+			_, err := c.GetSensorData(ctx, &saiv1.GetSensorDataRequest{})
+			return err
+		}, codes.InvalidArgument},
+		{"missing token", secured, "", func(ctx context.Context, c saiv1.ControlServiceClient) error {
+			_, err := c.ProcessCommand(ctx, &saiv1.CommandRequest{Text: "status"})
+			return err
+		}, codes.Unauthenticated},
+		{"wrong token", secured, "guess", func(ctx context.Context, c saiv1.ControlServiceClient) error {
+			_, err := c.ProcessCommand(ctx, &saiv1.CommandRequest{Text: "status"})
+			return err
+		}, codes.Unauthenticated},
+		{"valid token", secured, testToken, func(ctx context.Context, c saiv1.ControlServiceClient) error {
+			_, err := c.ProcessCommand(ctx, &saiv1.CommandRequest{Text: "status"})
+			return err
+		}, codes.OK},
+		{"motors without token", secured, "", func(ctx context.Context, c saiv1.ControlServiceClient) error {
+			_, err := c.GetMotors(ctx, &saiv1.GetMotorsRequest{})
+			return err
+		}, codes.Unauthenticated},
+		{"sensor data without token", secured, "", func(ctx context.Context, c saiv1.ControlServiceClient) error {
+			_, err := c.GetSensorData(ctx, &saiv1.GetSensorDataRequest{Type: "touch"})
+			return err
+		}, codes.Unauthenticated},
+		{"behavior state without token", secured, "", func(ctx context.Context, c saiv1.ControlServiceClient) error {
+			_, err := c.GetBehaviorState(ctx, &saiv1.GetBehaviorStateRequest{})
+			return err
+		}, codes.Unauthenticated},
+		{"capabilities without token", secured, "", func(ctx context.Context, c saiv1.ControlServiceClient) error {
+			_, err := c.GetCapabilities(ctx, &saiv1.GetCapabilitiesRequest{})
+			return err
+		}, codes.Unauthenticated},
+		{"sensor data with token", secured, testToken, func(ctx context.Context, c saiv1.ControlServiceClient) error {
+			_, err := c.GetSensorData(ctx, &saiv1.GetSensorDataRequest{Type: "touch"})
+			return err
+		}, codes.OK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.token != "" {
+				ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+tt.token)
+			}
+			err := tt.call(ctx, newClient(t, tt.access))
+			if got := status.Code(err); got != tt.want {
+				t.Errorf("code = %v (%v), want %v", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestStatusError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want codes.Code
+	}{
+		{nil, codes.OK},
+		{core.ErrPermissionDenied, codes.PermissionDenied},
+		{fmt.Errorf("wrapped: %w", core.ErrUnauthenticated), codes.Unauthenticated},
+		{context.DeadlineExceeded, codes.DeadlineExceeded},
+		{errors.New("plain"), codes.Internal},
+	}
+	for _, tt := range tests {
+		if got := status.Code(statusError(tt.err)); got != tt.want {
+			t.Errorf("statusError(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	saiv1 "github.com/sashalind/sex-artifical-intelligence/api/proto/sai/v1"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/core"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/core/errs"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/sensor"
+)
+
+// codeByErr maps error codes of core to gRPC status codes
+var codeByErr = map[errs.Code]codes.Code{
+	errs.InvalidArgument:    codes.InvalidArgument,
+	errs.NotFound:           codes.NotFound,
+	errs.OutOfRange:         codes.OutOfRange,
+	errs.FailedPrecondition: codes.FailedPrecondition,
+	errs.Unavailable:        codes.Unavailable,
+	errs.Cancelled:          codes.Canceled,
+	errs.DeadlineExceeded:   codes.DeadlineExceeded,
+	errs.Unauthenticated:    codes.Unauthenticated,
+	errs.PermissionDenied:   codes.PermissionDenied,
+}
+
+// statusError converts error of core to gRPC status error
+func statusError(err error) error {
+	if err == nil {
+		return nil
+	}
+	switch {
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	}
+	code, ok := codeByErr[errs.CodeOf(err)]
+	if !ok {
+		code = codes.Internal
+	}
+	return status.Error(code, err.Error())
+}
+
+// ControlService implements saiv1.ControlServiceServer on top of core.System
+type ControlService struct {
+	saiv1.UnimplementedControlServiceServer
+	system *core.System
+}
+
+// NewControlService creates service backed by system
+func NewControlService(sys *core.System) *ControlService {
+	return &ControlService{system: sys}
+}
+
+// ProcessCommand parses and executes natural language command
+func (s *ControlService) ProcessCommand(ctx context.Context, req *saiv1.CommandRequest) (*saiv1.CommandResponse, error) {
+	if req.GetText() == "" {
+		return nil, status.Error(codes.InvalidArgument, "command text is empty")
+	}
+
+	reply, err := s.system.ProcessCommand(ctx, req.GetText())
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	return &saiv1.CommandResponse{
+		Text:       reply.Text,
+		Sentiment:  reply.Sentiment,
+		Confidence: reply.Confidence,
+		Timestamp:  timestamppb.New(reply.Timestamp),
+	}, nil
+}
+
+// GetMotors returns current state of every motor
+func (s *ControlService) GetMotors(ctx context.Context, req *saiv1.GetMotorsRequest) (*saiv1.GetMotorsResponse, error) {
+	resp := &saiv1.GetMotorsResponse{}
+	for _, m := range s.system.GetMotors() {
+		resp.Motors = append(resp.Motors, &saiv1.Motor{
+			Id:          string(m.ID),
+			Type:        m.Type.String(),
+			Position:    m.Position,
+			Speed:       m.Speed,
+			MaxSpeed:    m.MaxSpeed,
+			MinPosition: m.MinPosition,
+			MaxPosition: m.MaxPosition,
+			Target:      m.Target,
+			Enabled:     m.IsEnabled,
+		})
+	}
+	return resp, nil
+}
+
+// GetSensorData returns recent readings of one sensor type
+func (s *ControlService) GetSensorData(ctx context.Context, req *saiv1.GetSensorDataRequest) (*saiv1.GetSensorDataResponse, error) {
+	if req.GetType() == "" {
+		return nil, status.Error(codes.InvalidArgument, "sensor type is empty")
+	}
+
+	values := s.system.GetSensorData(sensor.SensorType(req.GetType()))
+	if limit := int(req.GetLimit()); limit > 0 && limit < len(values) {
+		values = values[len(values)-limit:]
+	}
+	return &saiv1.GetSensorDataResponse{Type: req.GetType(), Values: values}, nil
+}
+
+// GetBehaviorState returns current behavior classification
+func (s *ControlService) GetBehaviorState(ctx context.Context, req *saiv1.GetBehaviorStateRequest) (*saiv1.GetBehaviorStateResponse, error) {
+	return &saiv1.GetBehaviorStateResponse{State: string(s.system.GetBehaviorState())}, nil
+}
+
+// GetCapabilities describes motors, sensors, patterns and commands of this build
+func (s *ControlService) GetCapabilities(ctx context.Context, req *saiv1.GetCapabilitiesRequest) (*saiv1.GetCapabilitiesResponse, error) {
+	caps := s.system.Capabilities()
+
+	resp := &saiv1.GetCapabilitiesResponse{Modes: caps.Modes, Simulated: caps.Simulated}
+	for _, c := range caps.Commands {
+		resp.Commands = append(resp.Commands, string(c))
+	}
+	for _, u := range caps.Units {
+		unit := &saiv1.UnitCapabilities{Id: string(u.ID)}
+		for _, m := range u.Motors {
+			unit.Motors = append(unit.Motors, &saiv1.MotorCapability{
+				Id:              m.ID,
+				Type:            m.Type,
+				MaxSpeed:        m.MaxSpeed,
+				MinPosition:     m.MinPosition,
+				MaxPosition:     m.MaxPosition,
+				Enabled:         m.Enabled,
+				MaxAcceleration: m.MaxAcceleration,
+				MaxJerk:         m.MaxJerk,
+			})
+		}
+		for _, t := range u.Sensors {
+			unit.Sensors = append(unit.Sensors, string(t))
+		}
+		for _, p := range u.Patterns {
+			unit.Patterns = append(unit.Patterns, &saiv1.PatternCapability{
+				Name:       p.Name,
+				DurationMs: p.DurationMs,
+				Steps:      int32(p.Steps),
+				Waveforms:  int32(p.Waveforms),
+			})
+		}
+		resp.Units = append(resp.Units, unit)
+	}
+	return resp, nil
+}
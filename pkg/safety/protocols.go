@@ -1,11 +1,17 @@
 package safety
 
 import (
+	"fmt"
 	"log"
 	"sync"
 	"time"
 
+	"github.com/sashalind/sex-artifical-intelligence/pkg/behavior"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/clock"
 	"github.com/sashalind/sex-artifical-intelligence/pkg/core"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/core/event"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/motion"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/sensor"
 )
 
 // SafetyLevel represents system safety status
@@ -18,6 +24,16 @@ const (
 	SafetyEmergency
 )
 
+// Alert is safety event published on system event bus
+type Alert struct {
+	Level     SafetyLevel `json:"level"`
+	Message   string      `json:"message"`
+	Timestamp time.Time   `json:"timestamp"`
+}
+
+// TopicAlert carries every safety warning and emergency
+var TopicAlert = event.NewTopic[Alert]("safety.alert")
+
 // SafetyMonitor handles system safety
 type SafetyMonitor struct {
 	system     *core.System
@@ -27,6 +43,9 @@ type SafetyMonitor struct {
 	currentLevel SafetyLevel
 	lastCheck    time.Time
 	warnings     []string
+	
+	// subsystems seen down by last check, warned about once
+	down         map[string]bool
 }
 
 var monitor *SafetyMonitor
@@ -36,19 +55,141 @@ func InitializeSafetyProtocols(sys *core.System) {
 	monitor = &SafetyMonitor{
 		system:      sys,
 		currentLevel: SafetyNormal,
-		lastCheck:    time.Now(),
+		lastCheck:    sys.Clock().Now(),
 		warnings:     make([]string, 0),
 	}
 	
-	go monitor.runSafetyChecks()
+	// tickers start before goroutines do, so fake clocks advanced right
+	// after initialization do not run ahead of checks
+	go monitor.runSafetyChecks(sys.Clock().NewTicker(time.Second))
+	go monitor.watchBehavior()
+	go monitor.watchMotors()
+	go monitor.watchSensors()
+}
+
+// watchBehavior reacts to behavior state changes published on event bus
+func (s *SafetyMonitor) watchBehavior() {
+	changes := event.Subscribe(s.system.Bus(), behavior.TopicStateChanged, 16)
+	defer changes.Cancel()
+	
+	for pattern := range changes.C {
+		switch pattern.Type {
+		case behavior.BehaviorAggressive, behavior.BehaviorErratic:
+			s.AddWarning("behavior changed to " + string(pattern.Type))
+		}
+	}
+}
+
+// watchMotors turns motor faults (stall, overload, overheat) into warnings
+func (s *SafetyMonitor) watchMotors() {
+	faults := event.Subscribe(s.system.Bus(), motion.TopicMotorFault, 16)
+	defer faults.Cancel()
+	
+	for f := range faults.C {
+		s.AddWarning(fmt.Sprintf("motor %s %s, protection: %s", f.Motor, f.Kind, f.Action))
+	}
+}
+
+// watchSensors turns sensor faults (stale, stuck, out of range), sensors
+// unplugged at runtime, sensor alerts and zones running hot into warnings,
+// readings of faulty sensor are already left out. Emergency alerts and
+// zones over their temperature limit stop motors.
+func (s *SafetyMonitor) watchSensors() {
+	bus := s.system.Bus()
+	faults := event.Subscribe(bus, sensor.TopicSensorFault, 16)
+	defer faults.Cancel()
+	changes := event.Subscribe(bus, sensor.TopicSensorChange, 16)
+	defer changes.Cancel()
+	alerts := event.Subscribe(bus, sensor.TopicSensorAlert, 64)
+	defer alerts.Cancel()
+	temps := event.Subscribe(bus, sensor.TopicOverTemp, 16)
+	defer temps.Cancel()
+	
+	for {
+		select {
+		case f, ok := <-faults.C:
+			if !ok {
+				return
+			}
+			if !f.Recovered {
+				s.AddWarning(fmt.Sprintf("sensor %s %s", f.Sensor, f.Kind))
+			}
+		case c, ok := <-changes.C:
+			if !ok {
+				return
+			}
+			if c.Removed {
+				s.AddWarning(fmt.Sprintf("sensor %s removed", c.Sensor.ID))
+			}
+		case a, ok := <-alerts.C:
+			if !ok {
+				return
+			}
+			if !a.Cleared {
+				s.sensorAlert(a)
+			}
+		case t, ok := <-temps.C:
+			if !ok {
+				return
+			}
+			s.overTemp(t)
+		}
+	}
+}
+
+// sensorAlert acts on alert as its severity says
+func (s *SafetyMonitor) sensorAlert(a sensor.SensorAlert) {
+	message := fmt.Sprintf("sensor alert %s: %s %s %g (threshold %g)", a.Rule, a.Type, a.Kind, a.Value, a.Threshold)
+	switch a.Severity {
+	case sensor.SeverityWarning:
+		s.AddWarning(message)
+	case sensor.SeverityEmergency:
+		s.emergency(message)
+	}
+}
+
+// overTemp warns about zone running hot and stops motors once it is over
+// its limit, cooling down needs no action
+func (s *SafetyMonitor) overTemp(t sensor.OverTemp) {
+	message := fmt.Sprintf("zone %q temperature %.1f°C over %.1f°C (sensor %s)", t.Zone, t.Value, t.Limit, t.Sensor)
+	switch t.Level {
+	case sensor.TempWarning:
+		s.AddWarning(message)
+	case sensor.TempCritical:
+		s.emergency(message)
+	}
+}
+
+// emergency stops every motor and raises emergency level
+func (s *SafetyMonitor) emergency(message string) {
+	s.system.EmergencyStop()
+	s.mu.Lock()
+	s.currentLevel = SafetyEmergency
+	s.warnings = append(s.warnings, message)
+	s.publish(message)
+	s.mu.Unlock()
+	log.Printf("EMERGENCY: %s, all motors halted", message)
+}
+
+// publish sends alert to event bus, caller holds s.mu
+func (s *SafetyMonitor) publish(message string) {
+	event.Publish(s.system.Bus(), TopicAlert, Alert{
+		Level:     s.currentLevel,
+		Message:   message,
+		Timestamp: s.system.Clock().Now(),
+	})
+}
+
+// GetMonitor returns active safety monitor, nil before initialization
+func GetMonitor() *SafetyMonitor {
+	return monitor
 }
 
 // runSafetyChecks performs periodic system safety verification
-func (s *SafetyMonitor) runSafetyChecks() {
-	ticker := time.NewTicker(time.Second)
+func (s *SafetyMonitor) runSafetyChecks(ticker clock.Ticker) {
 	defer ticker.Stop()
 	
-	for range ticker.C {
+	for range ticker.C() {
 		if !s.system.IsActive() {
 			return
 		}
@@ -62,10 +203,17 @@ func (s *SafetyMonitor) performSafetyCheck() {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	
-	s.lastCheck = time.Now()
+	s.lastCheck = s.system.Clock().Now()
+	
+	down := make(map[string]bool)
+	for _, name := range s.system.Health().Down() {
+		down[name] = true
+		if !s.down[name] {
+			s.addWarningLocked("subsystem " + name + " is down")
+		}
+	}
+	s.down = down
 	
-	// TODO: implement actual safety checks
-	// For now just log that we're checking
 	log.Printf("Safety check performed at %v - Status: %v\n", 
 		s.lastCheck.Format(time.RFC3339),
 		s.currentLevel)
@@ -75,7 +223,11 @@ func (s *SafetyMonitor) performSafetyCheck() {
 func (s *SafetyMonitor) AddWarning(warning string) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	
+	s.addWarningLocked(warning)
+}
+
+// addWarningLocked records warning and raises level, caller holds s.mu
+func (s *SafetyMonitor) addWarningLocked(warning string) {
 	s.warnings = append(s.warnings, warning)
 	
 	if len(s.warnings) > 10 {
@@ -85,6 +237,8 @@ func (s *SafetyMonitor) AddWarning(warning string) {
 	if len(s.warnings) > 20 {
 		s.currentLevel = SafetyCritical
 	}
+	
+	s.publish(warning)
 }
 
 // GetCurrentLevel returns current safety level
@@ -98,4 +252,53 @@ func (s *SafetyMonitor) GetCurrentLevel() SafetyLevel {
 func (s *SafetyMonitor) GetWarnings() []string {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
-	return append([]string{}, s.warnings...) 
\ No newline at end of file
+	return append([]string{}, s.warnings...)
+}
+
+// EStopInput is physical emergency stop button or equivalent signal
+type EStopInput interface {
+	Engaged() bool
+}
+
+// estopPollInterval is how often E-stop input is sampled
+const estopPollInterval = 10 * time.Millisecond
+
+// AttachEStop starts watching emergency stop input, halting all motors
+// and raising emergency level while it is engaged
+func AttachEStop(input EStopInput) {
+	if monitor == nil {
+		log.Println("Safety protocols not initialized, E-stop ignored")
+		return
+	}
+	
+	go monitor.watchEStop(input, monitor.system.Clock().NewTicker(estopPollInterval))
+}
+
+// watchEStop polls E-stop input independently of slower safety checks
+func (s *SafetyMonitor) watchEStop(input EStopInput, ticker clock.Ticker) {
+	defer ticker.Stop()
+	
+	engaged := false
+	for range ticker.C() {
+		if !s.system.IsActive() {
+			return
+		}
+		
+		if input.Engaged() {
+			// keep stopping while engaged so nothing can restart motors
+			s.system.EmergencyStop()
+			if !engaged {
+				engaged = true
+				s.mu.Lock()
+				s.currentLevel = SafetyEmergency
+				s.warnings = append(s.warnings, "emergency stop engaged")
+				s.publish("emergency stop engaged")
+				s.mu.Unlock()
+				log.Println("EMERGENCY STOP engaged, all motors halted")
+			}
+		} else if engaged {
+			engaged = false
+			log.Println("Emergency stop released, system stays in safe mode until reset")
+		}
+	}
+}
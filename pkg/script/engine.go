@@ -0,0 +1,251 @@
+package script
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+
+	"github.com/sashalind/sex-artifical-intelligence/pkg/clock"
+)
+
+// Host is what scripts can see and do
+type Host interface {
+	SensorValue(sensorType string) (float64, bool)
+	BehaviorState() string
+	Command(ctx context.Context, text string) error
+	Pattern(ctx context.Context, name string) error
+	SetMode(name string) error
+}
+
+// queueSize bounds handler runs waiting behind slow one
+const queueSize = 32
+
+// Engine runs one script against host. Handler runs are serialized, so
+// script never races with itself.
+type Engine struct {
+	script *Script
+	host   Host
+	clock  clock.Clock
+
+	jobs   chan *Handler
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu    sync.Mutex
+	armed map[*Handler]bool // sensor handlers whose condition held last time
+}
+
+// NewEngine prepares script for running, nothing runs until Start
+func NewEngine(s *Script, host Host, clk clock.Clock) *Engine {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Engine{
+		script: s,
+		host:   host,
+		clock:  clock.OrReal(clk),
+		jobs:   make(chan *Handler, queueSize),
+		ctx:    ctx,
+		cancel: cancel,
+		armed:  make(map[*Handler]bool),
+	}
+}
+
+// Script returns program engine runs
+func (e *Engine) Script() *Script {
+	return e.script
+}
+
+// Start runs "on start" handlers and starts timers
+func (e *Engine) Start() {
+	e.wg.Add(1)
+	go e.loop()
+
+	for _, h := range e.script.Handlers {
+		switch h.Kind {
+		case OnStart:
+			e.enqueue(h)
+		case OnEvery:
+			e.wg.Add(1)
+			go e.every(h)
+		}
+	}
+}
+
+// Stop cancels running handler and timers and waits for them
+func (e *Engine) Stop() {
+	e.cancel()
+	e.wg.Wait()
+}
+
+// OnReading feeds sensor reading, firing handlers whose condition just
+// became true
+func (e *Engine) OnReading(sensorType string, value float64) {
+	for _, h := range e.script.Handlers {
+		if h.Kind != OnSensor || h.Cond.Any[0][0].Left.Name != sensorType {
+			continue
+		}
+		cmp := h.Cond.Any[0][0]
+		holds := compareNum(value, cmp.Op, cmp.Right.Num)
+
+		e.mu.Lock()
+		fire := holds && !e.armed[h]
+		e.armed[h] = holds
+		e.mu.Unlock()
+
+		if fire {
+			e.enqueue(h)
+		}
+	}
+}
+
+// OnState feeds behavior state change
+func (e *Engine) OnState(state string) {
+	for _, h := range e.script.Handlers {
+		if h.Kind == OnState && h.State == state {
+			e.enqueue(h)
+		}
+	}
+}
+
+// enqueue schedules handler run, dropping it if script is too far behind
+func (e *Engine) enqueue(h *Handler) {
+	select {
+	case e.jobs <- h:
+	default:
+		log.Printf("Script %s: handler at line %d dropped, script is busy", e.script.Name, h.Line)
+	}
+}
+
+func (e *Engine) loop() {
+	defer e.wg.Done()
+	for {
+		select {
+		case <-e.ctx.Done():
+			return
+		case h := <-e.jobs:
+			if err := e.run(h.Body); err != nil && e.ctx.Err() == nil {
+				log.Printf("Script %s: %v", e.script.Name, err)
+			}
+		}
+	}
+}
+
+func (e *Engine) every(h *Handler) {
+	defer e.wg.Done()
+	ticker := e.clock.NewTicker(h.Every)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-e.ctx.Done():
+			return
+		case <-ticker.C():
+			e.enqueue(h)
+		}
+	}
+}
+
+// run executes statements, stopping at first error
+func (e *Engine) run(body []Stmt) error {
+	for _, stmt := range body {
+		if err := e.exec(stmt); err != nil {
+			return fmt.Errorf("line %d: %w", stmt.line(), err)
+		}
+	}
+	return nil
+}
+
+func (e *Engine) exec(stmt Stmt) error {
+	switch s := stmt.(type) {
+	case *CommandStmt:
+		return e.host.Command(e.ctx, s.Text)
+	case *PatternStmt:
+		return e.host.Pattern(e.ctx, s.Name)
+	case *ModeStmt:
+		return e.host.SetMode(s.Mode)
+	case *LogStmt:
+		log.Printf("Script %s: %s", e.script.Name, s.Text)
+	case *WaitStmt:
+		select {
+		case <-e.clock.After(s.Duration):
+		case <-e.ctx.Done():
+			return e.ctx.Err()
+		}
+	case *IfStmt:
+		if e.eval(s.Cond) {
+			return e.run(s.Then)
+		}
+		return e.run(s.Else)
+	}
+	return nil
+}
+
+// eval evaluates condition against current host state
+func (e *Engine) eval(c *Cond) bool {
+	for _, group := range c.Any {
+		all := true
+		for _, cmp := range group {
+			if !e.compare(cmp) {
+				all = false
+				break
+			}
+		}
+		if all {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *Engine) compare(c Compare) bool {
+	if c.Left.Kind == State || c.Left.Kind == Word {
+		l, r := e.text(c.Left), e.text(c.Right)
+		if c.Op == "==" {
+			return l == r
+		}
+		return l != r
+	}
+
+	// sensor without readings yet makes comparison false
+	l, ok := e.number(c.Left)
+	if !ok {
+		return false
+	}
+	r, ok := e.number(c.Right)
+	if !ok {
+		return false
+	}
+	return compareNum(l, c.Op, r)
+}
+
+func (e *Engine) text(o Operand) string {
+	if o.Kind == State {
+		return e.host.BehaviorState()
+	}
+	return o.Name
+}
+
+func (e *Engine) number(o Operand) (float64, bool) {
+	if o.Kind == Number {
+		return o.Num, true
+	}
+	return e.host.SensorValue(o.Name)
+}
+
+func compareNum(l float64, op string, r float64) bool {
+	switch op {
+	case "<":
+		return l < r
+	case "<=":
+		return l <= r
+	case ">":
+		return l > r
+	case ">=":
+		return l >= r
+	case "==":
+		return l == r
+	case "!=":
+		return l != r
+	}
+	return false
+}
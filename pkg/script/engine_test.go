@@ -0,0 +1,200 @@
+package script
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sashalind/sex-artifical-intelligence/pkg/clock"
+)
+
+// fakeHost records what script did
+type fakeHost struct {
+	mu      sync.Mutex
+	state   string
+	sensors map[string]float64
+	calls   []string
+}
+
+func (h *fakeHost) SensorValue(sensorType string) (float64, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	v, ok := h.sensors[sensorType]
+	return v, ok
+}
+
+func (h *fakeHost) BehaviorState() string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.state
+}
+
+func (h *fakeHost) record(call string) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.calls = append(h.calls, call)
+	return nil
+}
+
+func (h *fakeHost) Command(_ context.Context, text string) error {
+	return h.record("command " + text)
+}
+
+func (h *fakeHost) Pattern(_ context.Context, name string) error {
+	return h.record("pattern " + name)
+}
+
+func (h *fakeHost) SetMode(name string) error {
+	return h.record("mode " + name)
+}
+
+// waitCalls waits until host saw n calls and returns them
+func (h *fakeHost) waitCalls(t *testing.T, n int) []string {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		h.mu.Lock()
+		calls := slices.Clone(h.calls)
+		h.mu.Unlock()
+		if len(calls) >= n {
+			return calls
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("host saw %v, want %d calls", calls, n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// waitWaiters waits until n timers are pending on clk
+func waitWaiters(t *testing.T, clk *clock.Fake, n int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for clk.Waiters() < n {
+		if time.Now().After(deadline) {
+			t.Fatalf("%d timers pending, want %d", clk.Waiters(), n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func startEngine(t *testing.T, src string, host *fakeHost) (*Engine, *clock.Fake) {
+	t.Helper()
+	s, err := Parse("test", src)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	clk := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	e := NewEngine(s, host, clk)
+	e.Start()
+	t.Cleanup(e.Stop)
+	return e, clk
+}
+
+func TestEngineEvaluatesConditions(t *testing.T) {
+	src := `on state aggressive
+  if state == aggressive and sensor.touch > 0.5 or sensor.motion >= 2
+    command "stop"
+  else
+    pattern gentle_wave
+  end
+end
+`
+	tests := []struct {
+		name    string
+		sensors map[string]float64
+		want    string
+	}{
+		{"first group holds", map[string]float64{"touch": 0.6}, "command stop"},
+		{"second group holds", map[string]float64{"touch": 0.1, "motion": 2}, "command stop"},
+		{"neither holds", map[string]float64{"touch": 0.5, "motion": 1}, "pattern gentle_wave"},
+		{"no readings yet", nil, "pattern gentle_wave"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			host := &fakeHost{state: "aggressive", sensors: tt.sensors}
+			e, _ := startEngine(t, src, host)
+			e.OnState("aggressive")
+			if calls := host.waitCalls(t, 1); calls[0] != tt.want {
+				t.Errorf("script did %v, want %q", calls, tt.want)
+			}
+		})
+	}
+}
+
+// TestSensorHandlerFiresOnEdge checks handler runs when condition becomes
+// true, not on every reading it holds for
+func TestSensorHandlerFiresOnEdge(t *testing.T) {
+	host := &fakeHost{}
+	e, _ := startEngine(t, "on sensor pressure > 0.8\n  mode paused\nend\n", host)
+
+	for _, v := range []float64{0.5, 0.9, 0.95, 0.7, 0.85} {
+		e.OnReading("pressure", v)
+		e.OnReading("touch", v)
+	}
+	host.waitCalls(t, 2)
+	e.Stop()
+	if calls := host.waitCalls(t, 2); len(calls) != 2 {
+		t.Errorf("script did %v, want mode paused twice", calls)
+	}
+}
+
+// TestEveryRepeats checks timer handler runs again each period, the only
+// way script repeats itself
+func TestEveryRepeats(t *testing.T) {
+	host := &fakeHost{}
+	_, clk := startEngine(t, "every 10s\n  command \"status\"\nend\n", host)
+	waitWaiters(t, clk, 1)
+
+	for i := range 3 {
+		clk.Advance(10 * time.Second)
+		host.waitCalls(t, i+1)
+	}
+	if calls := host.waitCalls(t, 3); len(calls) != 3 {
+		t.Errorf("script did %v, want status three times", calls)
+	}
+}
+
+// TestStopCancelsWait checks Stop ends handler in the middle of wait, so
+// script never runs on
+func TestStopCancelsWait(t *testing.T) {
+	host := &fakeHost{}
+	e, clk := startEngine(t, "on start\n  wait 1h\n  command \"move\"\nend\n", host)
+	waitWaiters(t, clk, 1)
+
+	done := make(chan struct{})
+	go func() {
+		e.Stop()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Stop waits for script")
+	}
+	clk.Advance(time.Hour)
+	host.mu.Lock()
+	defer host.mu.Unlock()
+	if len(host.calls) != 0 {
+		t.Errorf("script did %v after stop", host.calls)
+	}
+}
+
+// TestBusyScriptDropsRuns checks runs queued behind slow handler are
+// bounded
+func TestBusyScriptDropsRuns(t *testing.T) {
+	host := &fakeHost{}
+	e, clk := startEngine(t, "on state aggressive\n  wait 1m\nend\n", host)
+
+	e.OnState("aggressive")
+	// first run is waiting, following ones queue up to queueSize
+	waitWaiters(t, clk, 1)
+	for range queueSize + 10 {
+		e.OnState("aggressive")
+	}
+	if n := len(e.jobs); n != queueSize {
+		t.Errorf("%d runs queued, want %d", n, queueSize)
+	}
+}
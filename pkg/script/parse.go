@@ -0,0 +1,425 @@
+// Package script is small line-oriented language for user-defined
+// behaviors. Scripts react to events and drive system through Host:
+//
+//	# calm down when things get rough
+//	on state aggressive
+//	  pattern gentle_wave
+//	  log "calming down"
+//	end
+//
+//	on sensor pressure > 0.8
+//	  if state != passive and sensor.touch > 0.5
+//	    command "stop"
+//	  else
+//	    mode paused
+//	  end
+//	end
+//
+//	every 30s
+//	  log "still here"
+//	end
+//
+// Handlers are "on start", "on state <behavior>", "on sensor <type> <op>
+// <number>" (fires when condition becomes true) and "every <duration>".
+// Statements are command "<text>", pattern <name>, mode <name>,
+// wait <duration>, log "<text>" and if/else/end with conditions joined by
+// and/or. There are no loops, so every handler run terminates.
+package script
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MaxSize bounds script source, scripts may arrive over network
+const MaxSize = 64 << 10
+
+// HandlerKind is event handler reacts to
+type HandlerKind int
+
+const (
+	OnStart HandlerKind = iota
+	OnState
+	OnSensor
+	OnEvery
+)
+
+// Script is parsed program
+type Script struct {
+	Name     string
+	Handlers []*Handler
+}
+
+// Handler is block run on event
+type Handler struct {
+	Kind  HandlerKind
+	Line  int
+	State string        // OnState
+	Cond  *Cond         // OnSensor
+	Every time.Duration // OnEvery
+	Body  []Stmt
+}
+
+// Stmt is executable statement
+type Stmt interface {
+	line() int
+}
+
+type (
+	CommandStmt struct {
+		Line int
+		Text string
+	}
+	PatternStmt struct {
+		Line int
+		Name string
+	}
+	ModeStmt struct {
+		Line int
+		Mode string
+	}
+	WaitStmt struct {
+		Line     int
+		Duration time.Duration
+	}
+	LogStmt struct {
+		Line int
+		Text string
+	}
+	IfStmt struct {
+		Line int
+		Cond *Cond
+		Then []Stmt
+		Else []Stmt
+	}
+)
+
+func (s *CommandStmt) line() int { return s.Line }
+func (s *PatternStmt) line() int { return s.Line }
+func (s *ModeStmt) line() int    { return s.Line }
+func (s *WaitStmt) line() int    { return s.Line }
+func (s *LogStmt) line() int     { return s.Line }
+func (s *IfStmt) line() int      { return s.Line }
+
+// Cond is OR of AND groups of comparisons
+type Cond struct {
+	Any [][]Compare
+}
+
+// Compare is binary comparison
+type Compare struct {
+	Left  Operand
+	Op    string
+	Right Operand
+}
+
+// OperandKind is type of comparison operand
+type OperandKind int
+
+const (
+	Number OperandKind = iota
+	Sensor             // sensor.<type>, latest reading
+	State              // current behavior state
+	Word               // bare word, compared with state
+)
+
+// Operand is value in comparison
+type Operand struct {
+	Kind OperandKind
+	Num  float64
+	Name string
+}
+
+var comparisonOps = map[string]bool{"<": true, "<=": true, ">": true, ">=": true, "==": true, "!=": true}
+
+// SyntaxError points at offending line
+type SyntaxError struct {
+	Script string
+	Line   int
+	Msg    string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("%s:%d: %s", e.Script, e.Line, e.Msg)
+}
+
+// line is tokenized source line
+type line struct {
+	num    int
+	tokens []string
+}
+
+// parser walks tokenized lines
+type parser struct {
+	name  string
+	lines []line
+	pos   int
+}
+
+// Parse compiles script source
+func Parse(name, src string) (*Script, error) {
+	if len(src) > MaxSize {
+		return nil, &SyntaxError{Script: name, Msg: "script too large"}
+	}
+
+	p := &parser{name: name}
+	for i, text := range strings.Split(src, "\n") {
+		tokens, err := tokenize(text)
+		if err != nil {
+			return nil, p.errAt(i+1, "%v", err)
+		}
+		if len(tokens) > 0 {
+			p.lines = append(p.lines, line{num: i + 1, tokens: tokens})
+		}
+	}
+
+	s := &Script{Name: name}
+	for p.pos < len(p.lines) {
+		h, err := p.handler()
+		if err != nil {
+			return nil, err
+		}
+		s.Handlers = append(s.Handlers, h)
+	}
+	return s, nil
+}
+
+// tokenize splits line on spaces keeping quoted strings whole, quotes are
+// kept so parser can tell strings from words
+func tokenize(text string) ([]string, error) {
+	var tokens []string
+	for i := 0; i < len(text); {
+		switch c := text[i]; {
+		case c == '#':
+			return tokens, nil
+		case c == ' ' || c == '\t' || c == '\r':
+			i++
+		case c == '"':
+			end := strings.IndexByte(text[i+1:], '"')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated string")
+			}
+			tokens = append(tokens, text[i:i+end+2])
+			i += end + 2
+		default:
+			j := i
+			for j < len(text) && !strings.ContainsRune(" \t\r#\"", rune(text[j])) {
+				j++
+			}
+			tokens = append(tokens, text[i:j])
+			i = j
+		}
+	}
+	return tokens, nil
+}
+
+func (p *parser) errAt(line int, format string, args ...interface{}) error {
+	return &SyntaxError{Script: p.name, Line: line, Msg: fmt.Sprintf(format, args...)}
+}
+
+// handler parses top-level block
+func (p *parser) handler() (*Handler, error) {
+	l := p.lines[p.pos]
+	p.pos++
+	t := l.tokens
+	h := &Handler{Line: l.num}
+
+	switch {
+	case t[0] == "every" && len(t) == 2:
+		d, err := time.ParseDuration(t[1])
+		if err != nil || d < time.Second {
+			return nil, p.errAt(l.num, "every needs duration of at least 1s")
+		}
+		h.Kind, h.Every = OnEvery, d
+	case t[0] == "on" && len(t) == 2 && t[1] == "start":
+		h.Kind = OnStart
+	case t[0] == "on" && len(t) == 3 && t[1] == "state":
+		h.Kind, h.State = OnState, t[2]
+	case t[0] == "on" && len(t) == 5 && t[1] == "sensor":
+		n, err := strconv.ParseFloat(t[4], 64)
+		if err != nil || !comparisonOps[t[3]] {
+			return nil, p.errAt(l.num, "expected: on sensor <type> <op> <number>")
+		}
+		h.Kind = OnSensor
+		h.Cond = &Cond{Any: [][]Compare{{{
+			Left:  Operand{Kind: Sensor, Name: t[2]},
+			Op:    t[3],
+			Right: Operand{Kind: Number, Num: n},
+		}}}}
+	default:
+		return nil, p.errAt(l.num, "expected handler: on start, on state, on sensor or every")
+	}
+
+	body, term, err := p.block()
+	if err != nil {
+		return nil, err
+	}
+	if term != "end" {
+		return nil, p.errAt(l.num, "else without if")
+	}
+	h.Body = body
+	return h, nil
+}
+
+// block parses statements up to "end" or "else", returning terminator
+func (p *parser) block() ([]Stmt, string, error) {
+	start := p.lines[p.pos-1].num
+	var body []Stmt
+	for p.pos < len(p.lines) {
+		l := p.lines[p.pos]
+		p.pos++
+		t := l.tokens
+
+		switch t[0] {
+		case "end", "else":
+			if len(t) != 1 {
+				return nil, "", p.errAt(l.num, "unexpected tokens after %s", t[0])
+			}
+			return body, t[0], nil
+		case "if":
+			stmt, err := p.ifStmt(l)
+			if err != nil {
+				return nil, "", err
+			}
+			body = append(body, stmt)
+		default:
+			stmt, err := p.simple(l)
+			if err != nil {
+				return nil, "", err
+			}
+			body = append(body, stmt)
+		}
+	}
+	return nil, "", p.errAt(start, "block is missing end")
+}
+
+// ifStmt parses if with optional else
+func (p *parser) ifStmt(l line) (Stmt, error) {
+	cond, err := p.cond(l, l.tokens[1:])
+	if err != nil {
+		return nil, err
+	}
+	stmt := &IfStmt{Line: l.num, Cond: cond}
+
+	body, term, err := p.block()
+	if err != nil {
+		return nil, err
+	}
+	stmt.Then = body
+	if term == "else" {
+		body, term, err = p.block()
+		if err != nil {
+			return nil, err
+		}
+		if term != "end" {
+			return nil, p.errAt(l.num, "if has two else branches")
+		}
+		stmt.Else = body
+	}
+	return stmt, nil
+}
+
+// simple parses single-line statement
+func (p *parser) simple(l line) (Stmt, error) {
+	t := l.tokens
+	if len(t) != 2 {
+		return nil, p.errAt(l.num, "%s takes exactly one argument", t[0])
+	}
+
+	switch t[0] {
+	case "command", "log":
+		text, ok := unquote(t[1])
+		if !ok {
+			return nil, p.errAt(l.num, "%s needs quoted text", t[0])
+		}
+		if t[0] == "log" {
+			return &LogStmt{Line: l.num, Text: text}, nil
+		}
+		return &CommandStmt{Line: l.num, Text: text}, nil
+	case "pattern":
+		return &PatternStmt{Line: l.num, Name: t[1]}, nil
+	case "mode":
+		return &ModeStmt{Line: l.num, Mode: t[1]}, nil
+	case "wait":
+		d, err := time.ParseDuration(t[1])
+		if err != nil || d <= 0 {
+			return nil, p.errAt(l.num, "wait needs positive duration")
+		}
+		return &WaitStmt{Line: l.num, Duration: d}, nil
+	}
+	return nil, p.errAt(l.num, "unknown statement %q", t[0])
+}
+
+// cond parses "a op b [and|or a op b]...", and binds tighter than or
+func (p *parser) cond(l line, t []string) (*Cond, error) {
+	cond := &Cond{}
+	group := []Compare{}
+	for {
+		if len(t) < 3 {
+			return nil, p.errAt(l.num, "expected comparison: <operand> <op> <operand>")
+		}
+		if !comparisonOps[t[1]] {
+			return nil, p.errAt(l.num, "unknown operator %q", t[1])
+		}
+		cmp := Compare{Left: operand(t[0]), Op: t[1], Right: operand(t[2])}
+		if err := checkCompare(cmp); err != nil {
+			return nil, p.errAt(l.num, "%v", err)
+		}
+		group = append(group, cmp)
+		t = t[3:]
+
+		if len(t) == 0 {
+			cond.Any = append(cond.Any, group)
+			return cond, nil
+		}
+		switch t[0] {
+		case "and":
+		case "or":
+			cond.Any = append(cond.Any, group)
+			group = []Compare{}
+		default:
+			return nil, p.errAt(l.num, "expected and/or, got %q", t[0])
+		}
+		t = t[1:]
+	}
+}
+
+// operand classifies comparison token
+func operand(tok string) Operand {
+	if n, err := strconv.ParseFloat(tok, 64); err == nil {
+		return Operand{Kind: Number, Num: n}
+	}
+	if name, ok := strings.CutPrefix(tok, "sensor."); ok {
+		return Operand{Kind: Sensor, Name: name}
+	}
+	if tok == "state" {
+		return Operand{Kind: State}
+	}
+	return Operand{Kind: Word, Name: tok}
+}
+
+// checkCompare rejects comparisons that can never be evaluated
+func checkCompare(c Compare) error {
+	textual := func(o Operand) bool { return o.Kind == State || o.Kind == Word }
+	if textual(c.Left) != textual(c.Right) {
+		return fmt.Errorf("cannot compare state with number")
+	}
+	if textual(c.Left) {
+		if c.Op != "==" && c.Op != "!=" {
+			return fmt.Errorf("state supports only == and !=")
+		}
+		if c.Left.Kind == Word && c.Right.Kind == Word {
+			return fmt.Errorf("unknown operand %q", c.Left.Name)
+		}
+	}
+	return nil
+}
+
+func unquote(tok string) (string, bool) {
+	if len(tok) < 2 || tok[0] != '"' || tok[len(tok)-1] != '"' {
+		return "", false
+	}
+	return tok[1 : len(tok)-1], true
+}
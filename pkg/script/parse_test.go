@@ -0,0 +1,132 @@
+package script
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	src := `# calm down when things get rough
+on state aggressive
+  pattern gentle_wave
+  log "calming down"
+end
+
+on sensor pressure > 0.8
+  if state != passive and sensor.touch > 0.5 or sensor.motion >= 2
+    command "stop"
+  else
+    mode paused
+  end
+end
+
+every 30s
+  wait 5s
+end
+`
+	s, err := Parse("calm", src)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if len(s.Handlers) != 3 {
+		t.Fatalf("got %d handlers, want 3", len(s.Handlers))
+	}
+
+	state, sensor, every := s.Handlers[0], s.Handlers[1], s.Handlers[2]
+	if state.Kind != OnState || state.State != "aggressive" || state.Line != 2 || len(state.Body) != 2 {
+		t.Errorf("state handler = %+v", state)
+	}
+	if cmp := sensor.Cond.Any[0][0]; sensor.Kind != OnSensor || cmp.Left.Name != "pressure" || cmp.Op != ">" || cmp.Right.Num != 0.8 {
+		t.Errorf("sensor handler = %+v", sensor)
+	}
+	ifStmt, ok := sensor.Body[0].(*IfStmt)
+	if !ok {
+		t.Fatalf("sensor body = %+v, want if", sensor.Body)
+	}
+	// and binds tighter than or
+	if any := ifStmt.Cond.Any; len(any) != 2 || len(any[0]) != 2 || len(any[1]) != 1 {
+		t.Errorf("condition groups = %+v", any)
+	}
+	if len(ifStmt.Then) != 1 || len(ifStmt.Else) != 1 || ifStmt.Line != 8 {
+		t.Errorf("if = %+v", ifStmt)
+	}
+	if every.Kind != OnEvery || every.Every != 30*time.Second {
+		t.Errorf("every handler = %+v", every)
+	}
+	if wait, ok := every.Body[0].(*WaitStmt); !ok || wait.Duration != 5*time.Second || wait.Line != 16 {
+		t.Errorf("every body = %+v", every.Body)
+	}
+}
+
+func TestSyntaxErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		line int
+		msg  string
+	}{
+		{"statement outside handler", "\n\n  wait 5s\n", 3, "expected handler"},
+		{"missing end", "on start\n  log \"hi\"\n", 1, "block is missing end"},
+		{"else without if", "on start\nelse\n", 1, "else without if"},
+		{"two else branches", "on start\n  if state == passive\n  else\n  else\n  end\nend\n", 2, "two else"},
+		{"unquoted command", "on start\n  command stop\nend\n", 2, "needs quoted text"},
+		{"unterminated string", "on start\n  log \"hi\nend\n", 2, "unterminated string"},
+		{"extra argument", "on start\n  pattern a b\nend\n", 2, "exactly one argument"},
+		{"unknown statement", "on start\n  dance now\nend\n", 2, "unknown statement"},
+		{"tokens after end", "on start\nend now\n", 2, "unexpected tokens"},
+		{"bad sensor handler", "on sensor pressure >> 0.8\nend\n", 1, "on sensor <type> <op> <number>"},
+		{"dangling and", "on start\n  if state == passive and\n  end\nend\n", 2, "expected comparison"},
+		{"unknown operator", "on start\n  if sensor.touch ~ 1\n  end\nend\n", 2, "unknown operator"},
+		{"state ordered", "on start\n  if state > passive\n  end\nend\n", 2, "only == and !="},
+		{"state against number", "on start\n  if sensor.touch == passive\n  end\nend\n", 2, "cannot compare"},
+		{"two words", "on start\n  if passive == aggressive\n  end\nend\n", 2, "unknown operand"},
+		{"joined by neither and nor or", "on start\n  if state == passive xor state == normal\n  end\nend\n", 2, "expected and/or"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse("test", tt.src)
+			var syntax *SyntaxError
+			if !errors.As(err, &syntax) {
+				t.Fatalf("Parse = %v, want syntax error", err)
+			}
+			if syntax.Line != tt.line || !strings.Contains(syntax.Msg, tt.msg) {
+				t.Errorf("error at line %d: %q, want line %d: %q", syntax.Line, syntax.Msg, tt.line, tt.msg)
+			}
+			if want := "test:"; !strings.HasPrefix(err.Error(), want) {
+				t.Errorf("Error() = %q, want prefix %q", err, want)
+			}
+		})
+	}
+}
+
+// TestSafetyLimits checks parser refuses what could keep engine busy
+// forever: there are no loops, timers fire at most every second, waits
+// are positive and sources are bounded
+func TestSafetyLimits(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+	}{
+		{"while loop", "on start\n  while state == passive\n    wait 1s\n  end\nend\n"},
+		{"repeat loop", "on start\n  repeat 10\n  end\nend\n"},
+		{"goto", "on start\n  goto start\nend\n"},
+		{"timer too fast", "every 500ms\n  command \"status\"\nend\n"},
+		{"timer without unit", "every 30\nend\n"},
+		{"zero wait", "on start\n  wait 0s\nend\n"},
+		{"negative wait", "on start\n  wait -1s\nend\n"},
+		{"too large", "on start\n" + strings.Repeat("  log \"x\"\n", MaxSize/10) + "end\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Parse("test", tt.src); err == nil {
+				t.Error("Parse succeeded")
+			}
+		})
+	}
+
+	if _, err := Parse("test", "every 1s\n  wait 1ms\nend\n"); err != nil {
+		t.Errorf("shortest timer and wait: %v", err)
+	}
+}
@@ -0,0 +1,147 @@
+package secure
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// KeySize is the AES-256 key length in bytes
+const KeySize = 32
+
+// magic prefixes every sealed file so plaintext and ciphertext are never confused
+var magic = []byte("SAIENC1\x00")
+
+var (
+	ErrInvalidKey    = errors.New("encryption key must be 32 bytes")
+	ErrNotEncrypted  = errors.New("data is not encrypted")
+	ErrDecryptFailed = errors.New("decryption failed: wrong key or corrupted data")
+)
+
+// Cipher encrypts and decrypts data at rest using AES-GCM
+type Cipher struct {
+	aead cipher.AEAD
+}
+
+// NewCipher creates cipher from raw 32-byte key
+func NewCipher(key []byte) (*Cipher, error) {
+	if len(key) != KeySize {
+		return nil, ErrInvalidKey
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Cipher{aead: aead}, nil
+}
+
+// NewCipherFromSource loads key from given source and creates cipher
+func NewCipherFromSource(src KeySource) (*Cipher, error) {
+	key, err := src.Key()
+	if err != nil {
+		return nil, err
+	}
+	return NewCipher(key)
+}
+
+// Seal encrypts plaintext, output is magic + nonce + ciphertext
+func (c *Cipher) Seal(plaintext []byte) ([]byte, error) {
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	out := make([]byte, 0, len(magic)+len(nonce)+len(plaintext)+c.aead.Overhead())
+	out = append(out, magic...)
+	out = append(out, nonce...)
+	return c.aead.Seal(out, nonce, plaintext, magic), nil
+}
+
+// Open decrypts data produced by Seal
+func (c *Cipher) Open(data []byte) ([]byte, error) {
+	if !IsEncrypted(data) {
+		return nil, ErrNotEncrypted
+	}
+
+	data = data[len(magic):]
+	nonceSize := c.aead.NonceSize()
+	if len(data) < nonceSize {
+		return nil, ErrDecryptFailed
+	}
+
+	plaintext, err := c.aead.Open(nil, data[:nonceSize], data[nonceSize:], magic)
+	if err != nil {
+		return nil, ErrDecryptFailed
+	}
+	return plaintext, nil
+}
+
+// IsEncrypted reports whether data carries sealed file header
+func IsEncrypted(data []byte) bool {
+	return bytes.HasPrefix(data, magic)
+}
+
+// WriteFile encrypts data and writes it atomically with owner-only permissions.
+// Nil cipher writes plaintext, so callers can make encryption optional.
+func WriteFile(path string, data []byte, c *Cipher) error {
+	if c != nil {
+		sealed, err := c.Seal(data)
+		if err != nil {
+			return err
+		}
+		data = sealed
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := tmp.Chmod(0600); err != nil {
+		tmp.Close()
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// ReadFile reads file written by WriteFile. Plaintext files written before
+// encryption was enabled are still readable so existing data isn't lost.
+func ReadFile(path string, c *Cipher) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if !IsEncrypted(data) {
+		return data, nil
+	}
+	if c == nil {
+		return nil, errors.New("file is encrypted but no key configured")
+	}
+	return c.Open(data)
+}
@@ -0,0 +1,134 @@
+package secure
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// KeySource provides encryption key from somewhere safe
+type KeySource interface {
+	Key() ([]byte, error)
+}
+
+// FileKey loads hex-encoded key from file, generating new one on first use
+type FileKey struct {
+	Path string
+}
+
+// Key returns key stored in file, creating it with 0600 permissions if missing
+func (f FileKey) Key() ([]byte, error) {
+	data, err := os.ReadFile(f.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return f.generate()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := os.Stat(f.Path)
+	if err != nil {
+		return nil, err
+	}
+	if info.Mode().Perm()&0077 != 0 {
+		return nil, fmt.Errorf("key file %s is accessible by other users", f.Path)
+	}
+
+	return decodeKey(data)
+}
+
+func (f FileKey) generate() ([]byte, error) {
+	key, err := GenerateKey()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(f.Path), 0700); err != nil {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(f.Path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	if _, err := file.WriteString(hex.EncodeToString(key) + "\n"); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// EnvKey reads hex-encoded key from environment variable
+type EnvKey struct {
+	Name string
+}
+
+// Key returns key from environment
+func (e EnvKey) Key() ([]byte, error) {
+	value, ok := os.LookupEnv(e.Name)
+	if !ok {
+		return nil, fmt.Errorf("environment variable %s not set", e.Name)
+	}
+	return decodeKey([]byte(value))
+}
+
+// KeyringKey stores key in OS keyring through libsecret's secret-tool,
+// so key never touches the filesystem in plaintext
+type KeyringKey struct {
+	Service string
+	Account string
+}
+
+// Key looks up key in keyring, storing freshly generated one if absent
+func (k KeyringKey) Key() ([]byte, error) {
+	if _, err := exec.LookPath("secret-tool"); err != nil {
+		return nil, errors.New("OS keyring not available: secret-tool not found")
+	}
+
+	out, err := exec.Command("secret-tool", "lookup",
+		"service", k.Service, "account", k.Account).Output()
+	if err == nil && len(bytes.TrimSpace(out)) > 0 {
+		return decodeKey(out)
+	}
+
+	key, err := GenerateKey()
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := exec.Command("secret-tool", "store", "--label", k.Service+" data key",
+		"service", k.Service, "account", k.Account)
+	cmd.Stdin = strings.NewReader(hex.EncodeToString(key))
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("failed to store key in keyring: %w", err)
+	}
+	return key, nil
+}
+
+// GenerateKey creates random AES-256 key
+func GenerateKey() ([]byte, error) {
+	key := make([]byte, KeySize)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+func decodeKey(data []byte) ([]byte, error) {
+	key, err := hex.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil {
+		return nil, fmt.Errorf("invalid key encoding: %w", err)
+	}
+	if len(key) != KeySize {
+		return nil, ErrInvalidKey
+	}
+	return key, nil
+}
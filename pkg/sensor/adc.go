@@ -0,0 +1,84 @@
+package sensor
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/sashalind/sex-artifical-intelligence/pkg/clock"
+)
+
+// kelvin0 is 0°C in kelvin, ntc25 is 25°C thermistors are rated at
+const (
+	kelvin0 = 273.15
+	ntc25   = kelvin0 + 25
+)
+
+// adcSource reads analog sensor through Linux IIO sysfs, raw counts are
+// scaled linearly so Min reads 0 and Max reads 1, or converted to °C of
+// thermistor
+type adcSource struct {
+	clock    clock.Clock
+	typ      SensorType
+	id       string
+	sensor   SensorID
+	raw      *os.File
+	min, max float64
+	ntc      *ntc
+	buf      [32]byte
+}
+
+// ntc is thermistor divider of adc input
+type ntc struct {
+	beta, r25, series float64
+}
+
+// celsius converts input at fraction v of full scale to °C by Beta
+// equation. Rail readings mean open or shorted thermistor.
+func (t *ntc) celsius(v float64) (float64, error) {
+	if v <= 0 || v >= 1 {
+		return 0, fmt.Errorf("thermistor open or shorted, input at %.0f%% of full scale", v*100)
+	}
+	r := t.series * v / (1 - v)
+	return 1/(1/ntc25+math.Log(r/t.r25)/t.beta) - kelvin0, nil
+}
+
+// openADC opens in_voltageN_raw of IIO device, file is kept open and reread
+// from start every sample
+func openADC(clk clock.Clock, cfg ChannelConfig) (*adcSource, error) {
+	path := filepath.Join(cfg.Root, fmt.Sprintf("iio:device%d", cfg.Device), fmt.Sprintf("in_voltage%d_raw", cfg.Channel))
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	s := &adcSource{clock: clk, typ: cfg.Type, id: cfg.ID, sensor: cfg.Sensor, raw: f, min: cfg.Min, max: cfg.Max}
+	if cfg.Driver == DriverNTC {
+		s.min, s.ntc = 0, &ntc{beta: cfg.Beta, r25: cfg.Resistance, series: cfg.Series}
+	}
+	return s, nil
+}
+
+func (s *adcSource) Read(dst []SensorData) ([]SensorData, error) {
+	n, err := s.raw.ReadAt(s.buf[:], 0)
+	if n == 0 && err != nil {
+		return dst, fmt.Errorf("adc %s: %w", s.raw.Name(), err)
+	}
+	raw, err := strconv.ParseFloat(strings.TrimSpace(string(s.buf[:n])), 64)
+	if err != nil {
+		return dst, fmt.Errorf("adc %s: %w", s.raw.Name(), err)
+	}
+	value := clamp01((raw - s.min) / (s.max - s.min))
+	if s.ntc != nil {
+		if value, err = s.ntc.celsius(raw / s.max); err != nil {
+			return dst, fmt.Errorf("adc %s: %w", s.raw.Name(), err)
+		}
+	}
+	return append(dst, SensorData{Type: s.typ, Value: value, Timestamp: s.clock.Now(), Source: s.id, Sensor: s.sensor}), nil
+}
+
+func (s *adcSource) Close() error {
+	return s.raw.Close()
+}
@@ -0,0 +1,296 @@
+package sensor
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sashalind/sex-artifical-intelligence/pkg/core/errs"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/core/event"
+)
+
+// ErrUnknownAlertRule is returned for alert rule nobody set
+var ErrUnknownAlertRule = errs.New(errs.NotFound, "unknown alert rule")
+
+// AlertKind selects condition alert rule watches for
+type AlertKind string
+
+const (
+	AlertAbove   AlertKind = "above"   // value above Threshold for For
+	AlertBelow   AlertKind = "below"   // value below Threshold for For
+	AlertRising  AlertKind = "rising"  // value crosses Threshold upwards
+	AlertFalling AlertKind = "falling" // value crosses Threshold downwards
+	AlertRate    AlertKind = "rate"    // value changes faster than Threshold per second for For
+)
+
+// AlertSeverity tells consumers how seriously to take alert
+type AlertSeverity string
+
+const (
+	SeverityInfo      AlertSeverity = "info"      // logged only
+	SeverityWarning   AlertSeverity = "warning"   // safety warning, default
+	SeverityEmergency AlertSeverity = "emergency" // safety stops every motor
+)
+
+// AlertRule is condition on readings hub evaluates as it ingests them.
+// Readings of each sensor, and unaddressed readings of type, are watched
+// separately. Level rules (above, below, rate) fire once condition held
+// For and clear once value is Hysteresis back past threshold; edge rules
+// fire on crossing and re-arm once value is Hysteresis back.
+type AlertRule struct {
+	Name      string
+	Type      SensorType // readings of type, empty matches any
+	Sensor    SensorID   // readings of sensor, empty matches any
+	Kind      AlertKind
+	Threshold float64 // value, or change per second of rate rule
+
+	For        time.Duration
+	Hysteresis float64
+	Severity   AlertSeverity // empty is warning
+}
+
+// Validate checks alert rule
+func (r AlertRule) Validate() error {
+	if r.Name == "" {
+		return errs.New(errs.InvalidArgument, "alert rule name is empty")
+	}
+	if r.Type == "" && r.Sensor == "" {
+		return errs.New(errs.InvalidArgument, fmt.Sprintf("alert rule %s: sensor type or sensor required", r.Name))
+	}
+	switch r.Kind {
+	case AlertAbove, AlertBelow, AlertRising, AlertFalling:
+	case AlertRate:
+		if !(r.Threshold > 0) {
+			return errs.New(errs.InvalidArgument, fmt.Sprintf("alert rule %s: rate threshold must be positive", r.Name))
+		}
+	default:
+		return errs.New(errs.InvalidArgument, fmt.Sprintf("alert rule %s: unknown kind %q", r.Name, r.Kind))
+	}
+	if math.IsNaN(r.Threshold) || math.IsInf(r.Threshold, 0) {
+		return errs.New(errs.InvalidArgument, fmt.Sprintf("alert rule %s: threshold must be finite", r.Name))
+	}
+	if r.For < 0 || !(r.Hysteresis >= 0) || math.IsInf(r.Hysteresis, 0) {
+		return errs.New(errs.InvalidArgument, fmt.Sprintf("alert rule %s: duration and hysteresis must not be negative", r.Name))
+	}
+	switch r.Severity {
+	case "", SeverityInfo, SeverityWarning, SeverityEmergency:
+	default:
+		return errs.New(errs.InvalidArgument, fmt.Sprintf("alert rule %s: unknown severity %q", r.Name, r.Severity))
+	}
+	return nil
+}
+
+func (r AlertRule) matches(data SensorData) bool {
+	return (r.Type == "" || r.Type == data.Type) && (r.Sensor == "" || r.Sensor == data.Sensor)
+}
+
+// SensorAlert is published when alert rule fires and, for level rules,
+// again with Cleared set once condition is gone. Value is offending
+// reading, or its change per second for rate rule.
+type SensorAlert struct {
+	Rule      string
+	Kind      AlertKind
+	Severity  AlertSeverity
+	Type      SensorType
+	Sensor    SensorID
+	Value     float64
+	Threshold float64
+	Cleared   bool
+	Time      time.Time
+}
+
+// TopicSensorAlert carries alerts of hub alert rules
+var TopicSensorAlert = event.NewTopic[SensorAlert]("sensor.alert")
+
+// alertRule is rule with its state per watched sensor
+type alertRule struct {
+	AlertRule
+
+	mu     sync.Mutex
+	states map[SensorID]*alertState
+}
+
+// alertState is evaluation state of rule for one sensor
+type alertState struct {
+	since  time.Time // level condition holds since, zero when it doesn't
+	active bool      // level rule fired and not cleared, edge rule not re-armed
+	fired  SensorAlert
+	seen   bool
+	prev   float64
+	prevAt time.Time
+}
+
+func newAlertRule(r AlertRule) *alertRule {
+	if r.Severity == "" {
+		r.Severity = SeverityWarning
+	}
+	return &alertRule{AlertRule: r, states: make(map[SensorID]*alertState)}
+}
+
+// eval judges reading and returns alert it raises or clears
+func (r *alertRule) eval(data SensorData) (SensorAlert, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	st, ok := r.states[data.Sensor]
+	if !ok {
+		st = &alertState{}
+		r.states[data.Sensor] = st
+	}
+	v, t, h := data.Value, r.Threshold, r.Hysteresis
+	first := !st.seen
+	prev, prevAt := st.prev, st.prevAt
+	st.seen, st.prev, st.prevAt = true, v, data.Timestamp
+
+	switch r.Kind {
+	case AlertAbove:
+		return r.level(st, data, v, v > t, v <= t-h)
+	case AlertBelow:
+		return r.level(st, data, v, v < t, v >= t+h)
+	case AlertRate:
+		dt := data.Timestamp.Sub(prevAt).Seconds()
+		if first || dt <= 0 {
+			return SensorAlert{}, false
+		}
+		rate := math.Abs(v-prev) / dt
+		return r.level(st, data, rate, rate > t, rate <= t-h)
+	case AlertRising:
+		return r.edge(st, data, first, v > t, v <= t-h)
+	case AlertFalling:
+		return r.edge(st, data, first, v < t, v >= t+h)
+	}
+	return SensorAlert{}, false
+}
+
+// level fires once cond held For and clears once clear holds
+func (r *alertRule) level(st *alertState, data SensorData, v float64, cond, clear bool) (SensorAlert, bool) {
+	if st.active {
+		if !clear {
+			return SensorAlert{}, false
+		}
+		st.active, st.since = false, time.Time{}
+		a := r.alert(data, v)
+		a.Cleared = true
+		return a, true
+	}
+	if !cond {
+		st.since = time.Time{}
+		return SensorAlert{}, false
+	}
+	if st.since.IsZero() {
+		st.since = data.Timestamp
+	}
+	if data.Timestamp.Sub(st.since) < r.For {
+		return SensorAlert{}, false
+	}
+	st.active, st.fired = true, r.alert(data, v)
+	return st.fired, true
+}
+
+// edge fires when cond starts to hold and re-arms once rearm holds. Value
+// past threshold at first reading is no crossing.
+func (r *alertRule) edge(st *alertState, data SensorData, first, cond, rearm bool) (SensorAlert, bool) {
+	switch {
+	case first:
+		st.active = cond
+	case st.active:
+		st.active = !rearm
+	case cond:
+		st.active = true
+		return r.alert(data, data.Value), true
+	}
+	return SensorAlert{}, false
+}
+
+func (r *alertRule) alert(data SensorData, v float64) SensorAlert {
+	return SensorAlert{
+		Rule:      r.Name,
+		Kind:      r.Kind,
+		Severity:  r.Severity,
+		Type:      data.Type,
+		Sensor:    data.Sensor,
+		Value:     v,
+		Threshold: r.Threshold,
+		Time:      data.Timestamp,
+	}
+}
+
+// SetAlertRule adds rule or replaces rule of same name, which starts over
+func (h *Hub) SetAlertRule(rule AlertRule) error {
+	if err := rule.Validate(); err != nil {
+		return err
+	}
+	h.alertMu.Lock()
+	defer h.alertMu.Unlock()
+
+	var rules []*alertRule
+	if cur := h.alertRules.Load(); cur != nil {
+		rules = slices.DeleteFunc(slices.Clone(*cur), func(r *alertRule) bool { return r.Name == rule.Name })
+	}
+	rules = append(rules, newAlertRule(rule))
+	h.alertRules.Store(&rules)
+	return nil
+}
+
+// RemoveAlertRule drops rule, its active alerts end without clearing
+func (h *Hub) RemoveAlertRule(name string) error {
+	h.alertMu.Lock()
+	defer h.alertMu.Unlock()
+
+	cur := h.alertRules.Load()
+	if cur == nil || !slices.ContainsFunc(*cur, func(r *alertRule) bool { return r.Name == name }) {
+		return fmt.Errorf("%w: %s", ErrUnknownAlertRule, name)
+	}
+	rules := slices.DeleteFunc(slices.Clone(*cur), func(r *alertRule) bool { return r.Name == name })
+	h.alertRules.Store(&rules)
+	return nil
+}
+
+// AlertRules lists alert rules sorted by name
+func (h *Hub) AlertRules() []AlertRule {
+	var out []AlertRule
+	if cur := h.alertRules.Load(); cur != nil {
+		for _, r := range *cur {
+			out = append(out, r.AlertRule)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// ActiveAlerts lists level alerts fired and not cleared yet, oldest first
+func (h *Hub) ActiveAlerts() []SensorAlert {
+	var out []SensorAlert
+	if cur := h.alertRules.Load(); cur != nil {
+		for _, r := range *cur {
+			r.mu.Lock()
+			for _, st := range r.states {
+				if st.active && r.Kind != AlertRising && r.Kind != AlertFalling {
+					out = append(out, st.fired)
+				}
+			}
+			r.mu.Unlock()
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Time.Before(out[j].Time) })
+	return out
+}
+
+// evaluateAlerts runs reading through matching rules and publishes alerts
+func (h *Hub) evaluateAlerts(data SensorData) {
+	rules := h.alertRules.Load()
+	if rules == nil {
+		return
+	}
+	for _, r := range *rules {
+		if !r.matches(data) {
+			continue
+		}
+		if a, ok := r.eval(data); ok {
+			event.Publish(h.bus.Load(), TopicSensorAlert, a)
+		}
+	}
+}
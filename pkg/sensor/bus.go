@@ -0,0 +1,16 @@
+package sensor
+
+// I2CBus is Linux i2c-dev style bus shared by devices at different addresses
+type I2CBus interface {
+	// Tx writes w to device at 7-bit address, then reads len(r) bytes
+	// into r; either may be empty
+	Tx(addr uint16, w, r []byte) error
+	Close() error
+}
+
+// SPIDevice is one chip select of SPI bus
+type SPIDevice interface {
+	// Tx clocks w out and reads as many bytes into r, full duplex
+	Tx(w, r []byte) error
+	Close() error
+}
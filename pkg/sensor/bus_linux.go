@@ -0,0 +1,126 @@
+//go:build linux
+
+package sensor
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+// ioctls of i2c-dev and spidev
+const (
+	i2cSlave       = 0x0703
+	spiIOCWrMode   = 0x40016b01
+	spiIOCWrSpeed  = 0x40046b04
+	spiIOCMessage1 = 0x40206b00
+)
+
+// i2cDev is bus opened through /dev/i2c-N
+type i2cDev struct {
+	mu   sync.Mutex
+	f    *os.File
+	addr int // selected address, -1 before first transfer
+}
+
+// OpenI2C opens i2c-dev bus like /dev/i2c-1
+func OpenI2C(path string) (I2CBus, error) {
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return nil, err
+	}
+	return &i2cDev{f: f, addr: -1}, nil
+}
+
+func (d *i2cDev) Tx(addr uint16, w, r []byte) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.addr != int(addr) {
+		if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, d.f.Fd(), i2cSlave, uintptr(addr)); errno != 0 {
+			return fmt.Errorf("i2c select 0x%02x: %w", addr, errno)
+		}
+		d.addr = int(addr)
+	}
+	if len(w) > 0 {
+		if _, err := d.f.Write(w); err != nil {
+			return fmt.Errorf("i2c write 0x%02x: %w", addr, err)
+		}
+	}
+	if len(r) > 0 {
+		if _, err := d.f.Read(r); err != nil {
+			return fmt.Errorf("i2c read 0x%02x: %w", addr, err)
+		}
+	}
+	return nil
+}
+
+func (d *i2cDev) Close() error {
+	return d.f.Close()
+}
+
+// spiTransfer is struct spi_ioc_transfer
+type spiTransfer struct {
+	tx, rx     uint64
+	len, speed uint32
+	delay      uint16
+	bits       uint8
+	csChange   uint8
+	txNbits    uint8
+	rxNbits    uint8
+	wordDelay  uint8
+	_          uint8
+}
+
+// spiDev is chip select opened through /dev/spidevB.C
+type spiDev struct {
+	mu    sync.Mutex
+	f     *os.File
+	speed uint32
+}
+
+// OpenSPI opens spidev device like /dev/spidev0.0 in mode 0-3 at speed Hz
+func OpenSPI(path string, mode uint8, speed uint32) (SPIDevice, error) {
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return nil, err
+	}
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), spiIOCWrMode, uintptr(unsafe.Pointer(&mode))); errno != 0 {
+		f.Close()
+		return nil, fmt.Errorf("spi mode %s: %w", path, errno)
+	}
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), spiIOCWrSpeed, uintptr(unsafe.Pointer(&speed))); errno != 0 {
+		f.Close()
+		return nil, fmt.Errorf("spi speed %s: %w", path, errno)
+	}
+	return &spiDev{f: f, speed: speed}, nil
+}
+
+func (d *spiDev) Tx(w, r []byte) error {
+	if len(w) != len(r) {
+		return fmt.Errorf("spi transfer needs equal buffers, got %d and %d", len(w), len(r))
+	}
+	if len(w) == 0 {
+		return nil
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	t := spiTransfer{
+		tx:    uint64(uintptr(unsafe.Pointer(&w[0]))),
+		rx:    uint64(uintptr(unsafe.Pointer(&r[0]))),
+		len:   uint32(len(w)),
+		speed: d.speed,
+		bits:  8,
+	}
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, d.f.Fd(), spiIOCMessage1, uintptr(unsafe.Pointer(&t))); errno != 0 {
+		return fmt.Errorf("spi transfer: %w", errno)
+	}
+	return nil
+}
+
+func (d *spiDev) Close() error {
+	return d.f.Close()
+}
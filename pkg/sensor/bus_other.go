@@ -0,0 +1,15 @@
+//go:build !linux
+
+package sensor
+
+import "errors"
+
+// OpenI2C opens i2c-dev bus, only available on Linux
+func OpenI2C(path string) (I2CBus, error) {
+	return nil, errors.New("i2c is only supported on linux")
+}
+
+// OpenSPI opens spidev device, only available on Linux
+func OpenSPI(path string, mode uint8, speed uint32) (SPIDevice, error) {
+	return nil, errors.New("spi is only supported on linux")
+}
@@ -0,0 +1,291 @@
+package sensor
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sashalind/sex-artifical-intelligence/pkg/core/errs"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/storage"
+)
+
+var (
+	ErrNoCalibration = errs.New(errs.NotFound, "sensor has no calibration")
+	ErrNoReadings    = errs.New(errs.FailedPrecondition, "sensor has no readings to capture zero from")
+)
+
+// calibrationPrefix keeps sensor entries apart from motor calibrations
+// sharing the bucket
+const calibrationPrefix = "sensor/"
+
+// zeroWindow is how many latest raw readings zero capture averages
+const zeroWindow = 50
+
+// CurvePoint maps raw value to calibrated one in lookup table
+type CurvePoint struct {
+	In  float64
+	Out float64
+}
+
+// Calibration corrects readings of one sensor before they are stored or
+// published: offset is subtracted, result multiplied by scale and then
+// passed through curve. Curve is either lookup table, linearly interpolated
+// and held at its ends, or polynomial with coefficients lowest power first.
+type Calibration struct {
+	Sensor    SensorID
+	Offset    float64
+	Scale     float64 // zero is 1
+	Table     []CurvePoint
+	Poly      []float64
+	UpdatedAt time.Time
+}
+
+// Validate checks calibration
+func (c Calibration) Validate() error {
+	if c.Sensor == "" {
+		return errs.New(errs.InvalidArgument, "calibration has no sensor")
+	}
+	for _, v := range append([]float64{c.Offset, c.Scale}, c.Poly...) {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return errs.New(errs.InvalidArgument, fmt.Sprintf("sensor %s: calibration values must be finite", c.Sensor))
+		}
+	}
+	if len(c.Table) > 0 && len(c.Poly) > 0 {
+		return errs.New(errs.InvalidArgument, fmt.Sprintf("sensor %s: calibration curve is either table or polynomial", c.Sensor))
+	}
+	if len(c.Table) == 1 {
+		return errs.New(errs.InvalidArgument, fmt.Sprintf("sensor %s: calibration table needs at least 2 points", c.Sensor))
+	}
+	for i, p := range c.Table {
+		if math.IsNaN(p.In) || math.IsInf(p.In, 0) || math.IsNaN(p.Out) || math.IsInf(p.Out, 0) {
+			return errs.New(errs.InvalidArgument, fmt.Sprintf("sensor %s: calibration table values must be finite", c.Sensor))
+		}
+		if i > 0 && !(p.In > c.Table[i-1].In) {
+			return errs.New(errs.InvalidArgument, fmt.Sprintf("sensor %s: calibration table must be sorted by input", c.Sensor))
+		}
+	}
+	return nil
+}
+
+// Apply returns calibrated value of raw reading
+func (c Calibration) Apply(raw float64) float64 {
+	v := raw - c.Offset
+	if c.Scale != 0 {
+		v *= c.Scale
+	}
+	switch {
+	case len(c.Table) > 0:
+		return lookup(c.Table, v)
+	case len(c.Poly) > 0:
+		// Horner's scheme
+		out := 0.0
+		for i := len(c.Poly) - 1; i >= 0; i-- {
+			out = out*v + c.Poly[i]
+		}
+		return out
+	}
+	return v
+}
+
+// lookup interpolates table at v
+func lookup(table []CurvePoint, v float64) float64 {
+	i := sort.Search(len(table), func(i int) bool { return table[i].In >= v })
+	switch {
+	case i == 0:
+		return table[0].Out
+	case i == len(table):
+		return table[len(table)-1].Out
+	}
+	a, b := table[i-1], table[i]
+	return a.Out + (v-a.In)/(b.In-a.In)*(b.Out-a.Out)
+}
+
+// SetCalibration installs calibration of sensor, readings arriving from now
+// on are corrected. Sensor need not be registered yet.
+func (h *Hub) SetCalibration(cal Calibration) error {
+	if err := cal.Validate(); err != nil {
+		return err
+	}
+	if err := h.checkCalibrationUnit(cal); err != nil {
+		return err
+	}
+	cal.UpdatedAt = h.clock.Now()
+	h.installCalibration(cal)
+	h.saveCalibration(cal)
+	return nil
+}
+
+// ClearCalibration removes calibration of sensor, its readings pass raw
+func (h *Hub) ClearCalibration(id SensorID) error {
+	h.mu.Lock()
+	if _, ok := h.calibrations[id]; !ok {
+		h.mu.Unlock()
+		return fmt.Errorf("%w: %s", ErrNoCalibration, id)
+	}
+	delete(h.calibrations, id)
+	if in := h.instances[id]; in != nil {
+		in.mu.Lock()
+		in.cal = nil
+		in.mu.Unlock()
+	}
+	lib := h.calibLib
+	h.mu.Unlock()
+
+	if lib != nil {
+		return lib.Delete(calibrationPrefix + string(id))
+	}
+	return nil
+}
+
+// CaptureZero sets offset of sensor to average of its latest raw readings,
+// so sensor at rest reads zero. Scale and curve are kept.
+func (h *Hub) CaptureZero(id SensorID) (Calibration, error) {
+	h.mu.RLock()
+	in, ok := h.instances[id]
+	cal, calibrated := h.calibrations[id]
+	h.mu.RUnlock()
+	if !ok {
+		return Calibration{}, fmt.Errorf("%w: %s", ErrUnknownSensor, id)
+	}
+
+	in.mu.RLock()
+	n := min(in.rawCount, zeroWindow)
+	sum := 0.0
+	for _, v := range in.raw[:n] {
+		sum += v
+	}
+	in.mu.RUnlock()
+	if n == 0 {
+		return Calibration{}, fmt.Errorf("%w: %s", ErrNoReadings, id)
+	}
+
+	if !calibrated {
+		cal = Calibration{Sensor: id}
+	}
+	cal.Offset = sum / float64(n)
+	if err := cal.Validate(); err != nil {
+		return Calibration{}, err
+	}
+	cal.UpdatedAt = h.clock.Now()
+	h.installCalibration(cal)
+	h.saveCalibration(cal)
+	return cal, nil
+}
+
+// Calibrations returns calibration of every calibrated sensor sorted by
+// sensor
+func (h *Hub) Calibrations() []Calibration {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	cals := make([]Calibration, 0, len(h.calibrations))
+	for _, cal := range h.calibrations {
+		cals = append(cals, cal)
+	}
+	sort.Slice(cals, func(i, j int) bool { return cals[i].Sensor < cals[j].Sensor })
+	return cals
+}
+
+// AttachStore persists sensor calibrations and loads saved ones
+func (h *Hub) AttachStore(store *storage.Store) error {
+	lib, err := storage.OpenTable[Calibration](store, storage.BucketCalibration)
+	if err != nil {
+		return err
+	}
+	var problems []error
+	err = lib.Bucket().ForEach(func(key string, data json.RawMessage) error {
+		if !strings.HasPrefix(key, calibrationPrefix) {
+			return nil
+		}
+		var cal Calibration
+		if err := json.Unmarshal(data, &cal); err != nil {
+			problems = append(problems, fmt.Errorf("calibration %s: %w", key, err))
+			return nil
+		}
+		if err := cal.Validate(); err != nil {
+			problems = append(problems, fmt.Errorf("calibration %s: %w", key, err))
+			return nil
+		}
+		h.installCalibration(cal)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if err := errors.Join(problems...); err != nil {
+		log.Printf("Some sensor calibrations could not be loaded: %v", err)
+	}
+
+	h.mu.Lock()
+	h.calibLib = lib
+	h.mu.Unlock()
+	return nil
+}
+
+// installCalibration makes calibration current for sensor
+func (h *Hub) installCalibration(cal Calibration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.calibrations[cal.Sensor] = cal
+	if in := h.instances[cal.Sensor]; in != nil {
+		in.mu.Lock()
+		in.cal = &cal
+		in.mu.Unlock()
+	}
+}
+
+// checkCalibrationUnit rejects calibration of registered sensor whose
+// table or latest raw reading comes out physically impossible in its unit
+func (h *Hub) checkCalibrationUnit(cal Calibration) error {
+	h.mu.RLock()
+	in := h.instances[cal.Sensor]
+	h.mu.RUnlock()
+	if in == nil {
+		return nil
+	}
+	in.mu.Lock()
+	unit, n := in.info.Unit, in.rawCount
+	last := in.raw[(n+zeroWindow-1)%zeroWindow]
+	in.mu.Unlock()
+
+	lo, hi := unit.Bounds()
+	for _, p := range cal.Table {
+		if !unit.Contains(p.Out) {
+			return errs.New(errs.InvalidArgument, fmt.Sprintf("sensor %s: calibration table output %g outside %g..%g %s", cal.Sensor, p.Out, lo, hi, unit))
+		}
+	}
+	if v := cal.Apply(last); n > 0 && !unit.Contains(v) {
+		return errs.New(errs.InvalidArgument, fmt.Sprintf("sensor %s: latest reading calibrates to %g, outside %g..%g %s", cal.Sensor, v, lo, hi, unit))
+	}
+	return nil
+}
+
+// saveCalibration persists calibration when store is attached
+func (h *Hub) saveCalibration(cal Calibration) {
+	h.mu.RLock()
+	lib := h.calibLib
+	h.mu.RUnlock()
+	if lib == nil {
+		return
+	}
+	if err := lib.Put(calibrationPrefix+string(cal.Sensor), cal); err != nil {
+		log.Printf("Failed to persist calibration of sensor %s: %v", cal.Sensor, err)
+	}
+}
+
+// calibrate records raw reading of sensor and returns it corrected,
+// caller holds in.mu
+func (in *instance) calibrate(raw float64) float64 {
+	in.raw[in.rawCount%zeroWindow] = raw
+	in.rawCount++
+	if in.cal == nil {
+		return raw
+	}
+	return in.cal.Apply(raw)
+}
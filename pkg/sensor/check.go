@@ -0,0 +1,191 @@
+package sensor
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/sashalind/sex-artifical-intelligence/pkg/core/errs"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/core/event"
+)
+
+// SensorFaultKind tells what sensor check detected
+type SensorFaultKind string
+
+const (
+	FaultStale      SensorFaultKind = "stale"        // no readings for StaleAfter
+	FaultStuck      SensorFaultKind = "stuck"        // constant readings for StuckAfter
+	FaultOutOfRange SensorFaultKind = "out_of_range" // reading outside physical range
+)
+
+// staleCheckInterval is how often hub looks for sensors gone quiet
+const staleCheckInterval = 250 * time.Millisecond
+
+// HealthCheck configures detection of broken sensor. Zero durations turn
+// their check off, as does zero range.
+type HealthCheck struct {
+	StaleAfter     time.Duration // no reading this long is dead sensor
+	StuckAfter     time.Duration // readings within StuckTolerance this long is stuck sensor
+	StuckTolerance float64       // change smaller than this counts as constant
+
+	// physical range of calibrated readings, anything outside is fault
+	Min, Max float64
+}
+
+// Validate checks health check settings
+func (c HealthCheck) Validate() error {
+	if c.StaleAfter < 0 || c.StuckAfter < 0 || c.StuckTolerance < 0 || math.IsNaN(c.StuckTolerance) {
+		return errs.New(errs.InvalidArgument, "sensor check times and tolerance must not be negative")
+	}
+	if (c.Min != 0 || c.Max != 0) && !(c.Min < c.Max) {
+		return errs.New(errs.InvalidArgument, "sensor range min must be below max")
+	}
+	return nil
+}
+
+func (c HealthCheck) hasRange() bool {
+	return c.Min != 0 || c.Max != 0
+}
+
+// SensorFault is published when sensor turns unhealthy and again, with
+// Recovered set, when it is healthy again
+type SensorFault struct {
+	Sensor    SensorID
+	Type      SensorType
+	Zone      string
+	Kind      SensorFaultKind
+	Value     float64 // offending reading, zero for stale sensor
+	Recovered bool
+	Time      time.Time
+}
+
+// TopicSensorFault carries sensor faults and recoveries
+var TopicSensorFault = event.NewTopic[SensorFault]("sensor.fault")
+
+// SensorStatus is health of registered sensor
+type SensorStatus struct {
+	Sensor      SensorID
+	Type        SensorType
+	Zone        string
+	Fault       SensorFaultKind // empty while healthy
+	Since       time.Time       // fault detected at
+	LastReading time.Time       // zero if sensor never reported
+}
+
+// checkState is fault detection state of sensor, guarded by stream lock
+type checkState struct {
+	fault      SensorFaultKind
+	since      time.Time
+	lastAt     time.Time // last reading, or registration until first one
+	read       bool      // lastAt is reading
+	stuckValue float64
+	stuckSince time.Time
+}
+
+// check judges calibrated reading of sensor and returns fault it has now
+// and whether that changed, caller holds in.mu
+func (in *instance) check(v float64, at time.Time) (SensorFaultKind, bool) {
+	c, st := in.info.Check, &in.health
+	st.lastAt, st.read = at, true
+
+	var fault SensorFaultKind
+	if c.hasRange() && (v < c.Min || v > c.Max || math.IsNaN(v)) {
+		fault = FaultOutOfRange
+	}
+	if c.StuckAfter > 0 {
+		if st.stuckSince.IsZero() || math.Abs(v-st.stuckValue) > c.StuckTolerance {
+			st.stuckValue, st.stuckSince = v, at
+		} else if fault == "" && at.Sub(st.stuckSince) >= c.StuckAfter {
+			fault = FaultStuck
+		}
+	}
+	return fault, st.setFault(fault, at)
+}
+
+// setFault records fault, returning whether it changed
+func (st *checkState) setFault(fault SensorFaultKind, at time.Time) bool {
+	if fault == st.fault {
+		return false
+	}
+	st.fault, st.since = fault, at
+	return true
+}
+
+// healthy tells whether readings of sensor may be used, caller holds in.mu
+func (in *instance) healthy() bool {
+	return in.health.fault == ""
+}
+
+// reportFault publishes fault change of sensor
+func (h *Hub) reportFault(info SensorInfo, prev, fault SensorFaultKind, value float64, at time.Time) {
+	f := SensorFault{Sensor: info.ID, Type: info.Type, Zone: info.Zone, Kind: fault, Value: value, Time: at}
+	if fault == "" {
+		f.Kind, f.Recovered = prev, true
+	} else {
+		h.lastErr.Set(fmt.Errorf("sensor %s %s", info.ID, fault))
+	}
+	event.Publish(h.bus.Load(), TopicSensorFault, f)
+}
+
+// watchStale marks sensors gone quiet as stale until hub shuts down
+func (h *Hub) watchStale() {
+	ticker := h.clock.NewTicker(staleCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-h.done:
+			return
+		case <-ticker.C():
+			h.checkStale()
+		}
+	}
+}
+
+// checkStale marks every sensor without reading for its StaleAfter
+func (h *Hub) checkStale() {
+	h.mu.RLock()
+	all := make([]*instance, 0, len(h.instances))
+	for _, in := range h.instances {
+		all = append(all, in)
+	}
+	h.mu.RUnlock()
+
+	now := h.clock.Now()
+	for _, in := range all {
+		in.mu.Lock()
+		after := in.info.Check.StaleAfter
+		if after == 0 || in.health.fault == FaultStale || now.Sub(in.health.lastAt) < after {
+			in.mu.Unlock()
+			continue
+		}
+		prev := in.health.fault
+		in.health.setFault(FaultStale, now)
+		info := in.info
+		in.mu.Unlock()
+		h.reportFault(info, prev, FaultStale, 0, now)
+	}
+}
+
+// SensorHealth lists health of registered sensors sorted by ID
+func (h *Hub) SensorHealth() []SensorStatus {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	out := make([]SensorStatus, 0, len(h.instances))
+	for _, in := range h.instances {
+		in.mu.RLock()
+		st := SensorStatus{Sensor: in.info.ID, Type: in.info.Type, Zone: in.info.Zone, Fault: in.health.fault}
+		if st.Fault != "" {
+			st.Since = in.health.since
+		}
+		if in.health.read {
+			st.LastReading = in.health.lastAt
+		}
+		in.mu.RUnlock()
+		out = append(out, st)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Sensor < out[j].Sensor })
+	return out
+}
@@ -0,0 +1,99 @@
+package sensor
+
+import (
+	"errors"
+	"fmt"
+)
+
+// DefaultHistorySize is number of readings kept per sensor stream
+const DefaultHistorySize = 1000
+
+// Config describes sensors present in particular hardware build
+type Config struct {
+	Types       []SensorType
+	HistorySize int
+	Sensors     []SensorInfo // registered at start, more may register later
+	Alerts      []AlertRule  // evaluated on every reading, may change later
+	TempLimits  []TempLimit  // per zone, may change later
+
+	// QueueSize is readings waiting for ingestion before oldest are
+	// dropped, zero is DefaultQueueSize
+	QueueSize int
+
+	// Retention keeps averaged readings past HistorySize full-rate ones,
+	// finest tier first; none keeps full-rate readings only
+	Retention []RetentionTier
+
+	// Gestures tunes tap, squeeze and stroke detection
+	Gestures GestureConfig
+}
+
+// DefaultConfig returns sensor set of reference build
+func DefaultConfig() Config {
+	return Config{
+		Types:       []SensorType{TypeTouch, TypePressure, TypeMotion, TypeTemp},
+		HistorySize: DefaultHistorySize,
+		Retention:   DefaultRetention(),
+	}
+}
+
+// Validate checks sensor configuration
+func (c Config) Validate() error {
+	if c.HistorySize <= 0 {
+		return errors.New("sensor history size must be positive")
+	}
+	if c.QueueSize < 0 {
+		return errors.New("sensor queue size must not be negative")
+	}
+	if err := validateRetention(c.Retention); err != nil {
+		return err
+	}
+	if err := c.Gestures.Validate(); err != nil {
+		return err
+	}
+
+	seen := make(map[SensorType]bool)
+	for _, t := range c.Types {
+		if t == "" {
+			return errors.New("sensor type is empty")
+		}
+		if seen[t] {
+			return fmt.Errorf("duplicate sensor type %q", t)
+		}
+		seen[t] = true
+	}
+
+	ids := make(map[SensorID]bool)
+	for _, info := range c.Sensors {
+		if err := info.Validate(); err != nil {
+			return err
+		}
+		if ids[info.ID] {
+			return fmt.Errorf("duplicate sensor %q", info.ID)
+		}
+		ids[info.ID] = true
+	}
+
+	rules := make(map[string]bool)
+	for _, rule := range c.Alerts {
+		if err := rule.Validate(); err != nil {
+			return err
+		}
+		if rules[rule.Name] {
+			return fmt.Errorf("duplicate alert rule %q", rule.Name)
+		}
+		rules[rule.Name] = true
+	}
+
+	zones := make(map[string]bool)
+	for _, l := range c.TempLimits {
+		if err := l.Validate(); err != nil {
+			return err
+		}
+		if zones[l.Zone] {
+			return fmt.Errorf("duplicate temperature limit of zone %q", l.Zone)
+		}
+		zones[l.Zone] = true
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package sensor
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/sashalind/sex-artifical-intelligence/pkg/core/errs"
+)
+
+// Decimation decides how readings over sample rate of sensor are merged
+type Decimation string
+
+const (
+	DecimateAverage Decimation = "average" // mean of readings in period, default
+	DecimateLatest  Decimation = "latest"  // last reading of period, rest dropped
+)
+
+// validateRate checks sample rate settings of sensor
+func (i SensorInfo) validateRate() error {
+	if i.SampleRate < 0 || math.IsNaN(i.SampleRate) || math.IsInf(i.SampleRate, 0) {
+		return errs.New(errs.InvalidArgument, fmt.Sprintf("sensor %s: sample rate must not be negative", i.ID))
+	}
+	switch i.Decimation {
+	case "", DecimateAverage, DecimateLatest:
+	default:
+		return errs.New(errs.InvalidArgument, fmt.Sprintf("sensor %s: unknown decimation %q", i.ID, i.Decimation))
+	}
+	return nil
+}
+
+// decimator merges readings of one sensor down to its sample rate. First
+// reading passes at once, then one reading per period comes out stamped
+// with time of last one merged into it.
+type decimator struct {
+	period time.Duration
+	mode   Decimation
+	next   time.Time // when next reading comes out
+	sum    float64
+	n      int
+}
+
+// newDecimator returns decimator of sensor, nil if it keeps every reading
+func newDecimator(info SensorInfo) *decimator {
+	if info.SampleRate == 0 {
+		return nil
+	}
+	return &decimator{period: time.Duration(float64(time.Second) / info.SampleRate), mode: info.Decimation}
+}
+
+// add merges reading, returning reading to pass on once period is over
+func (d *decimator) add(data SensorData) (SensorData, bool) {
+	d.sum += data.Value
+	d.n++
+	if data.Timestamp.Before(d.next) {
+		return SensorData{}, false
+	}
+	if d.mode != DecimateLatest {
+		data.Value = d.sum / float64(d.n)
+	}
+	d.sum, d.n = 0, 0
+
+	// fixed schedule keeps rate steady, sensor gone quiet restarts it
+	if d.next = d.next.Add(d.period); data.Timestamp.Sub(d.next) >= 0 {
+		d.next = data.Timestamp.Add(d.period)
+	}
+	return data, true
+}
+
+// decimate stamps and records reading, then merges it down to sample rate
+// of its sensor. Runs before ingestion channel, so fast sensors don't
+// flood it.
+func (h *Hub) decimate(data SensorData) (SensorData, bool) {
+	if data.Timestamp.IsZero() {
+		data.Timestamp = h.clock.Now()
+	}
+	h.record(data)
+	if data.Sensor == "" {
+		return data, true
+	}
+	h.mu.RLock()
+	in, ok := h.instances[data.Sensor]
+	h.mu.RUnlock()
+	if !ok {
+		return data, true
+	}
+
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	if in.decimator == nil {
+		return data, true
+	}
+	out, ok := in.decimator.add(data)
+	if !ok {
+		h.decimated.Add(1)
+	}
+	return out, ok
+}
@@ -0,0 +1,305 @@
+package sensor
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/sashalind/sex-artifical-intelligence/pkg/clock"
+)
+
+// Driver names sensor part channel reads
+type Driver string
+
+const (
+	DriverADC     Driver = "adc"     // analog sensor on IIO ADC channel, pressure or touch pads
+	DriverNTC     Driver = "ntc"     // NTC thermistor divider on IIO ADC channel, reads °C
+	DriverTMP102  Driver = "tmp102"  // I2C temperature sensor, LM75 family reads alike
+	DriverMPU6500 Driver = "mpu6500" // SPI IMU, accelerometer gives motion
+	DriverMPR121  Driver = "mpr121"  // I2C capacitive touch controller, electrodes grouped into zones
+)
+
+// channel defaults
+const (
+	DefaultSampleInterval = 20 * time.Millisecond
+	defaultIIORoot        = "/sys/bus/iio/devices"
+	defaultADCMax         = 4095 // 12 bit converter
+	defaultTMP102Address  = 0x48
+	defaultMPR121Address  = 0x5a
+	defaultTouchSpan      = 64 // counts
+	defaultNTCBeta        = 3950
+	defaultNTCResistance  = 10000 // ohm, of thermistor at 25°C and of series resistor
+	defaultSPISpeed       = 1000000
+	defaultMotionRange    = 2 // g
+)
+
+// ChannelConfig is one physical sensor feeding readings of Type into hub.
+// Fields past Interval are used only by drivers named next to them.
+type ChannelConfig struct {
+	Type     SensorType
+	Driver   Driver
+	Interval time.Duration // between samples, zero is DefaultSampleInterval
+
+	// ID is stamped on readings as their Source, empty names driver and
+	// device, e.g. tmp102:/dev/i2c-1@0x48
+	ID string
+
+	// Sensor is registered sensor readings come from, empty leaves them
+	// unaddressed
+	Sensor SensorID
+
+	// Unit is unit of calibrated readings of Sensor, empty is unit driver
+	// reads in: °C for ntc and tmp102, ratio 0..1 for the rest
+	Unit Unit
+
+	// adc, ntc: IIO device number and input channel, Root overrides sysfs
+	// directory. Raw counts Min..Max map to 0..1, zero Max is 4095; ntc
+	// takes Max as full scale only.
+	Root    string
+	Device  int
+	Channel int
+	Min     float64
+	Max     float64
+
+	// ntc: thermistor between input and ground, Series resistor between
+	// input and reference. Beta and Resistance at 25°C come from part
+	// datasheet; zero is 3950, Resistance and Series zero are 10kΩ.
+	Beta       float64
+	Resistance float64
+	Series     float64
+
+	// tmp102, mpr121: bus device like /dev/i2c-1, Address zero is 0x48
+	// for tmp102 and 0x5a for mpr121
+	Bus     string
+	Address uint16
+
+	// mpr121: Zones group electrodes, each zone feeding its own sensor
+	// instead of Sensor. Span is drop of electrode counts below baseline
+	// read as full touch, zero is 64.
+	Zones []TouchZone
+	Span  float64
+
+	// mpu6500: spidev device like /dev/spidev0.0 and speed, zero is 1MHz.
+	// Range is acceleration away from 1g read as full motion, zero is 2g.
+	SPI     string
+	SpeedHz uint32
+	Range   float64
+}
+
+// withDefaults fills zero values
+func (c ChannelConfig) withDefaults() ChannelConfig {
+	if c.Interval == 0 {
+		c.Interval = DefaultSampleInterval
+	}
+	if c.Root == "" {
+		c.Root = defaultIIORoot
+	}
+	if c.Max == 0 {
+		c.Max = defaultADCMax
+	}
+	if c.Address == 0 {
+		c.Address = defaultTMP102Address
+		if c.Driver == DriverMPR121 {
+			c.Address = defaultMPR121Address
+		}
+	}
+	if c.Beta == 0 {
+		c.Beta = defaultNTCBeta
+	}
+	if c.Resistance == 0 {
+		c.Resistance = defaultNTCResistance
+	}
+	if c.Series == 0 {
+		c.Series = defaultNTCResistance
+	}
+	if c.Span == 0 {
+		c.Span = defaultTouchSpan
+	}
+	if c.Unit == UnitNone {
+		c.Unit = c.Driver.Unit()
+	}
+	if c.Driver == DriverMPR121 {
+		c.Zones = slices.Clone(c.Zones)
+		for i := range c.Zones {
+			if c.Zones[i].Sensor == "" {
+				c.Zones[i].Sensor = SensorID(string(c.Type) + "_" + c.Zones[i].Zone)
+			}
+		}
+	}
+	if c.SpeedHz == 0 {
+		c.SpeedHz = defaultSPISpeed
+	}
+	if c.Range == 0 {
+		c.Range = defaultMotionRange
+	}
+	if c.ID == "" {
+		switch c.Driver {
+		case DriverADC, DriverNTC:
+			c.ID = fmt.Sprintf("%s:iio:device%d/in_voltage%d", c.Driver, c.Device, c.Channel)
+		case DriverTMP102:
+			c.ID = fmt.Sprintf("tmp102:%s@0x%02x", c.Bus, c.Address)
+		case DriverMPU6500:
+			c.ID = "mpu6500:" + c.SPI
+		case DriverMPR121:
+			c.ID = fmt.Sprintf("mpr121:%s@0x%02x", c.Bus, c.Address)
+		}
+	}
+	return c
+}
+
+// Validate checks channel settings for its driver
+func (c ChannelConfig) Validate() error {
+	if c.Type == "" {
+		return fmt.Errorf("sensor channel has no type")
+	}
+	if c.Interval < 0 {
+		return fmt.Errorf("sensor channel %s: sample interval must not be negative", c.Type)
+	}
+	if err := c.Unit.Validate(); err != nil {
+		return fmt.Errorf("sensor channel %s: %w", c.Type, err)
+	}
+	c = c.withDefaults()
+	switch c.Driver {
+	case DriverADC:
+		if c.Device < 0 || c.Channel < 0 {
+			return fmt.Errorf("sensor channel %s: adc device and channel must not be negative", c.Type)
+		}
+		if !(c.Max > c.Min) || math.IsInf(c.Max-c.Min, 0) {
+			return fmt.Errorf("sensor channel %s: adc max must be above min", c.Type)
+		}
+	case DriverNTC:
+		if c.Device < 0 || c.Channel < 0 {
+			return fmt.Errorf("sensor channel %s: adc device and channel must not be negative", c.Type)
+		}
+		if !(c.Max > 0) || math.IsInf(c.Max, 0) {
+			return fmt.Errorf("sensor channel %s: adc max must be positive", c.Type)
+		}
+		for _, v := range []float64{c.Beta, c.Resistance, c.Series} {
+			if !(v > 0) || math.IsInf(v, 0) {
+				return fmt.Errorf("sensor channel %s: thermistor beta and resistances must be positive", c.Type)
+			}
+		}
+	case DriverTMP102:
+		if c.Bus == "" {
+			return fmt.Errorf("sensor channel %s: i2c bus required", c.Type)
+		}
+		if c.Address > 0x7f {
+			return fmt.Errorf("sensor channel %s: i2c address 0x%x out of 7 bit range", c.Type, c.Address)
+		}
+	case DriverMPU6500:
+		if c.SPI == "" {
+			return fmt.Errorf("sensor channel %s: spi device required", c.Type)
+		}
+		if !(c.Range > 0) || math.IsInf(c.Range, 0) {
+			return fmt.Errorf("sensor channel %s: motion range must be positive", c.Type)
+		}
+	case DriverMPR121:
+		if c.Bus == "" {
+			return fmt.Errorf("sensor channel %s: i2c bus required", c.Type)
+		}
+		if c.Address > 0x7f {
+			return fmt.Errorf("sensor channel %s: i2c address 0x%x out of 7 bit range", c.Type, c.Address)
+		}
+		if !(c.Span > 0) || math.IsInf(c.Span, 0) {
+			return fmt.Errorf("sensor channel %s: touch span must be positive", c.Type)
+		}
+		if c.Sensor != "" {
+			return fmt.Errorf("sensor channel %s: touch zones name their sensors, sensor must be empty", c.Type)
+		}
+		return validateZones(c.Type, c.Zones)
+	default:
+		return fmt.Errorf("sensor channel %s: unknown driver %q", c.Type, c.Driver)
+	}
+	return nil
+}
+
+// Sensors lists sensors channel feeds, registered while its device is there
+func (c ChannelConfig) Sensors() []SensorInfo {
+	c = c.withDefaults()
+	if c.Driver == DriverMPR121 {
+		out := make([]SensorInfo, 0, len(c.Zones))
+		for _, z := range c.Zones {
+			out = append(out, SensorInfo{ID: z.Sensor, Type: c.Type, Zone: z.Zone, Unit: c.Unit})
+		}
+		return out
+	}
+	if c.Sensor == "" {
+		return nil
+	}
+	return []SensorInfo{{ID: c.Sensor, Type: c.Type, Unit: c.Unit}}
+}
+
+// Unit returns unit readings of driver come in before calibration
+func (d Driver) Unit() Unit {
+	switch d {
+	case DriverNTC, DriverTMP102:
+		return UnitCelsius
+	case DriverADC, DriverMPU6500, DriverMPR121:
+		return UnitRatio
+	}
+	return UnitNone
+}
+
+// SampleInterval returns how often channel is sampled
+func (c ChannelConfig) SampleInterval() time.Duration {
+	return c.withDefaults().Interval
+}
+
+// OpenChannel opens device of channel as Source giving one reading of
+// channel's type per Read, one per zone for touch controllers, stamped
+// with clk
+func OpenChannel(clk clock.Clock, cfg ChannelConfig) (Source, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	cfg = cfg.withDefaults()
+	clk = clock.OrReal(clk)
+
+	var (
+		src Source
+		err error
+	)
+	switch cfg.Driver {
+	case DriverADC, DriverNTC:
+		src, err = openADC(clk, cfg)
+	case DriverTMP102:
+		var bus I2CBus
+		if bus, err = OpenI2C(cfg.Bus); err == nil {
+			t := NewTMP102(clk, cfg.Type, bus, cfg.Address)
+			t.ID, t.Sensor = cfg.ID, cfg.Sensor
+			src = t
+		}
+	case DriverMPR121:
+		var bus I2CBus
+		if bus, err = OpenI2C(cfg.Bus); err == nil {
+			var m *MPR121
+			if m, err = NewMPR121(clk, cfg.Type, bus, cfg.Address, cfg.Zones, cfg.Span); err != nil {
+				bus.Close()
+			} else {
+				m.ID = cfg.ID
+				src = m
+			}
+		}
+	case DriverMPU6500:
+		var dev SPIDevice
+		if dev, err = OpenSPI(cfg.SPI, 3, cfg.SpeedHz); err == nil {
+			var m *MPU6500
+			if m, err = NewMPU6500(clk, cfg.Type, dev, cfg.Range); err != nil {
+				dev.Close()
+			} else {
+				m.ID, m.Sensor = cfg.ID, cfg.Sensor
+				src = m
+			}
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("sensor channel %s: %w", cfg.Type, err)
+	}
+	return src, nil
+}
+
+// clamp01 limits normalized reading to 0..1
+func clamp01(v float64) float64 {
+	return math.Max(0, math.Min(1, v))
+}
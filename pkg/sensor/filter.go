@@ -0,0 +1,205 @@
+package sensor
+
+import (
+	"fmt"
+	"math"
+	"slices"
+
+	"github.com/sashalind/sex-artifical-intelligence/pkg/core/errs"
+)
+
+// FilterKind selects smoothing filter
+type FilterKind string
+
+const (
+	FilterMovingAverage FilterKind = "moving_average" // mean of last Window readings
+	FilterMedian        FilterKind = "median"         // median of last Window readings, drops spikes
+	FilterLowPass       FilterKind = "lowpass"        // 2nd order Butterworth at Cutoff Hz
+	FilterKalman        FilterKind = "kalman"         // 1D Kalman tracking slowly changing value
+)
+
+// filter defaults
+const (
+	defaultFilterWindow     = 5
+	defaultSampleRate       = 50 // Hz, DefaultSampleInterval
+	defaultProcessNoise     = 1e-4
+	defaultMeasurementNoise = 1e-2
+	maxFilterWindow         = 1000
+)
+
+// FilterSpec configures one filter stage of sensor. Fields not used by
+// kind are ignored.
+type FilterSpec struct {
+	Kind FilterKind
+
+	// moving_average, median: readings filtered over, zero is 5
+	Window int
+
+	// lowpass: cutoff frequency and rate readings arrive at in Hz, zero
+	// rate is 50
+	Cutoff     float64
+	SampleRate float64
+
+	// kalman: variance of value change per reading and of sensor noise,
+	// zero values are 1e-4 and 1e-2
+	ProcessNoise     float64
+	MeasurementNoise float64
+}
+
+func (f FilterSpec) withDefaults() FilterSpec {
+	if f.Window == 0 {
+		f.Window = defaultFilterWindow
+	}
+	if f.SampleRate == 0 {
+		f.SampleRate = defaultSampleRate
+	}
+	if f.ProcessNoise == 0 {
+		f.ProcessNoise = defaultProcessNoise
+	}
+	if f.MeasurementNoise == 0 {
+		f.MeasurementNoise = defaultMeasurementNoise
+	}
+	return f
+}
+
+// Validate checks filter settings
+func (f FilterSpec) Validate() error {
+	f = f.withDefaults()
+	switch f.Kind {
+	case FilterMovingAverage, FilterMedian:
+		if f.Window < 1 || f.Window > maxFilterWindow {
+			return errs.New(errs.InvalidArgument, fmt.Sprintf("%s filter window must be 1..%d", f.Kind, maxFilterWindow))
+		}
+	case FilterLowPass:
+		if !(f.SampleRate > 0) || math.IsInf(f.SampleRate, 0) {
+			return errs.New(errs.InvalidArgument, "lowpass filter sample rate must be positive")
+		}
+		if !(f.Cutoff > 0) || f.Cutoff >= f.SampleRate/2 {
+			return errs.New(errs.InvalidArgument, "lowpass filter cutoff must be between 0 and half of sample rate")
+		}
+	case FilterKalman:
+		if !(f.ProcessNoise > 0) || !(f.MeasurementNoise > 0) || math.IsInf(f.ProcessNoise, 0) || math.IsInf(f.MeasurementNoise, 0) {
+			return errs.New(errs.InvalidArgument, "kalman filter noise must be positive")
+		}
+	default:
+		return errs.New(errs.InvalidArgument, fmt.Sprintf("unknown filter %q", f.Kind))
+	}
+	return nil
+}
+
+// Filter smooths stream of readings one at a time
+type Filter interface {
+	Filter(v float64) float64
+}
+
+// NewFilter creates filter of valid spec with empty history
+func NewFilter(f FilterSpec) Filter {
+	f = f.withDefaults()
+	switch f.Kind {
+	case FilterMovingAverage:
+		return &movingAverage{window: make([]float64, f.Window)}
+	case FilterMedian:
+		return &median{window: make([]float64, f.Window), sorted: make([]float64, 0, f.Window)}
+	case FilterLowPass:
+		return newButterworth(f.Cutoff, f.SampleRate)
+	case FilterKalman:
+		return &kalman{q: f.ProcessNoise, r: f.MeasurementNoise}
+	}
+	return nil
+}
+
+// movingAverage keeps running sum over circular window
+type movingAverage struct {
+	window []float64
+	next   int
+	n      int
+	sum    float64
+}
+
+func (m *movingAverage) Filter(v float64) float64 {
+	if m.n == len(m.window) {
+		m.sum -= m.window[m.next]
+	} else {
+		m.n++
+	}
+	m.window[m.next] = v
+	m.next = (m.next + 1) % len(m.window)
+	m.sum += v
+	return m.sum / float64(m.n)
+}
+
+// median sorts copy of window every reading, windows are short
+type median struct {
+	window []float64
+	sorted []float64
+	next   int
+	n      int
+}
+
+func (m *median) Filter(v float64) float64 {
+	if m.n < len(m.window) {
+		m.n++
+	}
+	m.window[m.next] = v
+	m.next = (m.next + 1) % len(m.window)
+
+	m.sorted = append(m.sorted[:0], m.window[:m.n]...)
+	slices.Sort(m.sorted)
+	if m.n%2 == 1 {
+		return m.sorted[m.n/2]
+	}
+	return (m.sorted[m.n/2-1] + m.sorted[m.n/2]) / 2
+}
+
+// butterworth is 2nd order low-pass biquad, direct form I, coefficients
+// by bilinear transform
+type butterworth struct {
+	b0, b1, b2, a1, a2 float64
+	x1, x2, y1, y2     float64
+	primed             bool
+}
+
+func newButterworth(cutoff, rate float64) *butterworth {
+	k := math.Tan(math.Pi * cutoff / rate)
+	norm := 1 / (1 + math.Sqrt2*k + k*k)
+	b0 := k * k * norm
+	return &butterworth{
+		b0: b0,
+		b1: 2 * b0,
+		b2: b0,
+		a1: 2 * (k*k - 1) * norm,
+		a2: (1 - math.Sqrt2*k + k*k) * norm,
+	}
+}
+
+func (b *butterworth) Filter(v float64) float64 {
+	if !b.primed {
+		// start settled at first reading instead of ramping up from zero
+		b.x1, b.x2, b.y1, b.y2 = v, v, v, v
+		b.primed = true
+	}
+	y := b.b0*v + b.b1*b.x1 + b.b2*b.x2 - b.a1*b.y1 - b.a2*b.y2
+	b.x2, b.x1 = b.x1, v
+	b.y2, b.y1 = b.y1, y
+	return y
+}
+
+// kalman estimates value assumed to drift randomly
+type kalman struct {
+	q, r   float64
+	x, p   float64
+	primed bool
+}
+
+func (k *kalman) Filter(v float64) float64 {
+	if !k.primed {
+		k.x, k.p = v, k.r
+		k.primed = true
+		return v
+	}
+	k.p += k.q
+	gain := k.p / (k.p + k.r)
+	k.x += gain * (v - k.x)
+	k.p *= 1 - gain
+	return k.x
+}
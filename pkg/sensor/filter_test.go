@@ -0,0 +1,196 @@
+package sensor
+
+import (
+	"math"
+	"testing"
+
+	"github.com/sashalind/sex-artifical-intelligence/pkg/core/errs"
+)
+
+func near(got, want, tol float64) bool {
+	return math.Abs(got-want) <= tol
+}
+
+func TestFilterValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		spec FilterSpec
+		ok   bool
+	}{
+		{"average default window", FilterSpec{Kind: FilterMovingAverage}, true},
+		{"median window too big", FilterSpec{Kind: FilterMedian, Window: maxFilterWindow + 1}, false},
+		{"average negative window", FilterSpec{Kind: FilterMovingAverage, Window: -1}, false},
+		{"lowpass", FilterSpec{Kind: FilterLowPass, Cutoff: 10}, true},
+		{"lowpass without cutoff", FilterSpec{Kind: FilterLowPass}, false},
+		{"lowpass cutoff at nyquist", FilterSpec{Kind: FilterLowPass, Cutoff: 25}, false},
+		{"lowpass infinite rate", FilterSpec{Kind: FilterLowPass, Cutoff: 1, SampleRate: math.Inf(1)}, false},
+		{"kalman default noise", FilterSpec{Kind: FilterKalman}, true},
+		{"kalman negative noise", FilterSpec{Kind: FilterKalman, ProcessNoise: -1}, false},
+		{"kalman noise not a number", FilterSpec{Kind: FilterKalman, MeasurementNoise: math.NaN()}, false},
+		{"unknown", FilterSpec{Kind: "fir"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.spec.Validate()
+			if tt.ok != (err == nil) {
+				t.Fatalf("Validate = %v, want ok %v", err, tt.ok)
+			}
+			if err != nil && errs.CodeOf(err) != errs.InvalidArgument {
+				t.Errorf("Validate code = %s, want InvalidArgument", errs.CodeOf(err))
+			}
+		})
+	}
+}
+
+func TestWindowFilters(t *testing.T) {
+	in := []float64{1, 2, 9, 4, 5}
+	tests := []struct {
+		kind FilterKind
+		want []float64
+	}{
+		{FilterMovingAverage, []float64{1, 1.5, 4, 5, 6}},
+		{FilterMedian, []float64{1, 1.5, 2, 4, 5}},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.kind), func(t *testing.T) {
+			f := NewFilter(FilterSpec{Kind: tt.kind, Window: 3})
+			for i, v := range in {
+				if got := f.Filter(v); !near(got, tt.want[i], 1e-12) {
+					t.Errorf("reading %d: got %v, want %v", i, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+// TestButterworthCoefficients compares with scipy.signal.butter(2, 0.4)
+func TestButterworthCoefficients(t *testing.T) {
+	b := newButterworth(10, 50)
+	got := []float64{b.b0, b.b1, b.b2, b.a1, b.a2}
+	want := []float64{0.20657208, 0.41314417, 0.20657208, -0.36952738, 0.19581571}
+	for i := range want {
+		if !near(got[i], want[i], 1e-8) {
+			t.Errorf("coefficients = %v, want %v", got, want)
+			break
+		}
+	}
+}
+
+func TestButterworthStepResponse(t *testing.T) {
+	tests := []struct {
+		cutoff, rate float64
+		peak         float64 // overshoot grows as cutoff nears Nyquist
+		settle       int     // readings to within 1% of step
+	}{
+		{10, 50, 1.075, 10},
+		{2, 50, 1.044, 40},
+		{1, 100, 1.043, 200},
+	}
+	for _, tt := range tests {
+		f := NewFilter(FilterSpec{Kind: FilterLowPass, Cutoff: tt.cutoff, SampleRate: tt.rate})
+		// first reading primes filter, no ramp from zero
+		if got := f.Filter(0); got != 0 {
+			t.Errorf("%v Hz: first reading filtered to %v", tt.cutoff, got)
+		}
+		peak, settled := 0.0, -1
+		for i := range 1000 {
+			y := f.Filter(1)
+			peak = max(peak, y)
+			if math.Abs(y-1) > 0.01 {
+				settled = -1
+			} else if settled < 0 {
+				settled = i
+			}
+		}
+		// analog Butterworth overshoots 4.3%
+		if !near(peak, tt.peak, 0.001) {
+			t.Errorf("%v Hz: step peaks at %v, want %v", tt.cutoff, peak, tt.peak)
+		}
+		if settled < 0 || settled > tt.settle {
+			t.Errorf("%v Hz: settles after %d readings, want at most %d", tt.cutoff, settled, tt.settle)
+		}
+		if y := f.Filter(1); !near(y, 1, 1e-12) {
+			t.Errorf("%v Hz: steady state %v, want 1", tt.cutoff, y)
+		}
+	}
+}
+
+// TestButterworthAttenuation checks sine amplitude after filter settles
+// against 1/sqrt(1+(f/fc)^4) at frequencies warped by bilinear transform,
+// so cutoff stays at -3 dB
+func TestButterworthAttenuation(t *testing.T) {
+	const cutoff, rate = 5.0, 100.0
+	for _, freq := range []float64{0.5, 2, 5, 10, 20, 40} {
+		ratio := math.Tan(math.Pi*freq/rate) / math.Tan(math.Pi*cutoff/rate)
+		want := 1 / math.Sqrt(1+math.Pow(ratio, 4))
+
+		// correlate whole cycles with sine and cosine, sample peaks miss
+		// crest at few samples per cycle
+		f := NewFilter(FilterSpec{Kind: FilterLowPass, Cutoff: cutoff, SampleRate: rate})
+		var re, im float64
+		const settle, n = 2000, 2000
+		for i := range settle + n {
+			phase := 2 * math.Pi * freq * float64(i) / rate
+			y := f.Filter(math.Sin(phase))
+			if i >= settle {
+				re += y * math.Sin(phase)
+				im += y * math.Cos(phase)
+			}
+		}
+		amp := 2 * math.Hypot(re, im) / n
+		if !near(amp, want, 1e-3) {
+			t.Errorf("%v Hz through %v Hz lowpass: amplitude %v, want %v", freq, cutoff, amp, want)
+		}
+	}
+}
+
+func TestKalmanStepResponse(t *testing.T) {
+	const q, r = 1e-4, 1e-2
+	f := NewFilter(FilterSpec{Kind: FilterKalman})
+	for range 500 {
+		if got := f.Filter(0); got != 0 {
+			t.Fatalf("constant input filtered to %v", got)
+		}
+	}
+
+	// steady state prior variance m solves m² - qm - qr = 0
+	m := (q + math.Sqrt(q*q+4*q*r)) / 2
+	gain := m / (m + r)
+	prev := 0.0
+	for i := range 200 {
+		y := f.Filter(1)
+		if y <= prev || y > 1 {
+			t.Fatalf("reading %d: %v after %v, want monotonic approach to 1", i, y, prev)
+		}
+		if want := prev + gain*(1-prev); !near(y, want, 1e-9) {
+			t.Fatalf("reading %d: %v, want %v from steady state gain %v", i, y, want, gain)
+		}
+		prev = y
+	}
+	if !near(prev, 1, 1e-6) {
+		t.Errorf("settles at %v, want 1", prev)
+	}
+}
+
+// TestKalmanReducesNoise checks noise variance drops by about
+// gain/(2-gain) around constant value
+func TestKalmanReducesNoise(t *testing.T) {
+	f := NewFilter(FilterSpec{Kind: FilterKalman, ProcessNoise: 1e-6, MeasurementNoise: 1e-2})
+	// deterministic alternating noise of variance 1e-2
+	var sum, sq float64
+	const n = 4000
+	for i := range n {
+		v := 5 + 0.1*float64(1-2*(i%2))
+		y := f.Filter(v)
+		if i >= n/2 {
+			sum += y
+			sq += (y - 5) * (y - 5)
+		}
+	}
+	if mean := sum / (n / 2); !near(mean, 5, 1e-3) {
+		t.Errorf("mean %v, want 5", mean)
+	}
+	if variance := sq / (n / 2); variance > 1e-4 {
+		t.Errorf("variance %v, want below 1e-4", variance)
+	}
+}
@@ -0,0 +1,240 @@
+package sensor
+
+import (
+	"errors"
+	"math"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/sashalind/sex-artifical-intelligence/pkg/core/event"
+)
+
+// GestureKind is short event recognized in touch and pressure readings
+type GestureKind string
+
+const (
+	GestureTap     GestureKind = "tap"     // short contact
+	GestureSqueeze GestureKind = "squeeze" // contact held, reported once it lasted SqueezeMin
+	GestureStroke  GestureKind = "stroke"  // contacts repeating at steady rhythm
+)
+
+// gestureHistory is gestures hub keeps for queries
+const gestureHistory = 100
+
+// GestureConfig tunes gesture detection, zero fields take defaults. Contact
+// starts when reading reaches Threshold and ends once it falls Release
+// under it.
+type GestureConfig struct {
+	Types     []SensorType // watched sensor types, empty is touch and pressure
+	Threshold float64      // zero is 0.3
+	Release   float64      // zero is 0.05
+
+	TapMax     time.Duration // longest tap, zero is 300ms
+	SqueezeMin time.Duration // shortest squeeze, zero is 1.5s
+
+	// StrokeMin contacts starting within StrokeWindow are stroke when their
+	// intervals spread less than Regularity of their mean; zero is 3
+	// contacts in 4s spreading 0.35
+	StrokeMin    int
+	StrokeWindow time.Duration
+	Regularity   float64
+}
+
+func (c GestureConfig) withDefaults() GestureConfig {
+	if len(c.Types) == 0 {
+		c.Types = []SensorType{TypeTouch, TypePressure}
+	}
+	if c.Threshold == 0 {
+		c.Threshold = 0.3
+	}
+	if c.Release == 0 {
+		c.Release = 0.05
+	}
+	if c.TapMax == 0 {
+		c.TapMax = 300 * time.Millisecond
+	}
+	if c.SqueezeMin == 0 {
+		c.SqueezeMin = 1500 * time.Millisecond
+	}
+	if c.StrokeMin == 0 {
+		c.StrokeMin = 3
+	}
+	if c.StrokeWindow == 0 {
+		c.StrokeWindow = 4 * time.Second
+	}
+	if c.Regularity == 0 {
+		c.Regularity = 0.35
+	}
+	return c
+}
+
+// Validate checks gesture settings
+func (c GestureConfig) Validate() error {
+	if c.Threshold < 0 || c.Release < 0 || c.Regularity < 0 || c.StrokeMin < 0 ||
+		c.TapMax < 0 || c.SqueezeMin < 0 || c.StrokeWindow < 0 {
+		return errors.New("gesture settings must not be negative")
+	}
+	c = c.withDefaults()
+	if c.Release >= c.Threshold {
+		return errors.New("gesture release must be below threshold")
+	}
+	if c.TapMax >= c.SqueezeMin {
+		return errors.New("longest tap must be shorter than squeeze")
+	}
+	if c.StrokeMin < 2 {
+		return errors.New("stroke needs at least 2 contacts")
+	}
+	return nil
+}
+
+// Gesture is published when gesture is recognized on sensor, or on type
+// stream for readings of no sensor
+type Gesture struct {
+	Kind     GestureKind
+	Sensor   SensorID
+	Type     SensorType
+	Zone     string
+	Peak     float64       // highest reading of contact, of latest one for stroke
+	Duration time.Duration // of contact, of squeeze so far, of strokes so far
+	Count    int           // contacts of stroke, 1 otherwise
+	Rate     float64       // contacts per second of stroke
+	Time     time.Time
+}
+
+// TopicGesture carries recognized gestures, behavior analysis and NLP
+// take them as context
+var TopicGesture = event.NewTopic[Gesture]("sensor.gesture")
+
+// gestures tracks contacts of every watched sensor
+type gestures struct {
+	mu     sync.Mutex
+	cfg    GestureConfig
+	types  map[SensorType]bool
+	states map[gestureKey]*gestureState
+	recent []Gesture // oldest first, gestureHistory at most
+}
+
+type gestureKey struct {
+	typ    SensorType
+	sensor SensorID
+}
+
+// gestureState is contact in progress and recent contact starts of sensor
+type gestureState struct {
+	contact  bool
+	start    time.Time
+	peak     float64
+	squeezed bool
+	starts   []time.Time
+	stroking bool
+}
+
+func (g *gestures) init(cfg GestureConfig) {
+	g.cfg = cfg.withDefaults()
+	g.types = make(map[SensorType]bool, len(g.cfg.Types))
+	for _, t := range g.cfg.Types {
+		g.types[t] = true
+	}
+	g.states = make(map[gestureKey]*gestureState)
+}
+
+// detectGestures feeds healthy reading to gesture detection of its sensor
+// and publishes what it recognized
+func (h *Hub) detectGestures(data SensorData, zone string) {
+	g := &h.gestures
+	g.mu.Lock()
+	if !g.types[data.Type] {
+		g.mu.Unlock()
+		return
+	}
+	key := gestureKey{data.Type, data.Sensor}
+	st := g.states[key]
+	if st == nil {
+		st = &gestureState{}
+		g.states[key] = st
+	}
+	found, ok := st.step(g.cfg, data.Value, data.Timestamp)
+	if ok {
+		found.Sensor, found.Type, found.Zone, found.Time = data.Sensor, data.Type, zone, data.Timestamp
+		if len(g.recent) == gestureHistory {
+			g.recent = slices.Delete(g.recent, 0, 1)
+		}
+		g.recent = append(g.recent, found)
+	}
+	g.mu.Unlock()
+
+	if ok {
+		event.Publish(h.bus.Load(), TopicGesture, found)
+	}
+}
+
+// step advances contact of sensor by reading, returning gesture it
+// completes if any
+func (st *gestureState) step(cfg GestureConfig, v float64, t time.Time) (Gesture, bool) {
+	if !st.contact {
+		if v < cfg.Threshold {
+			return Gesture{}, false
+		}
+		st.contact, st.start, st.peak, st.squeezed = true, t, v, false
+
+		cutoff := t.Add(-cfg.StrokeWindow)
+		st.starts = slices.DeleteFunc(st.starts, func(s time.Time) bool { return !s.After(cutoff) })
+		st.starts = append(st.starts, t)
+		rate, ok := rhythm(st.starts, cfg)
+		if !ok {
+			st.stroking = false
+			return Gesture{}, false
+		}
+		if st.stroking {
+			return Gesture{}, false
+		}
+		st.stroking = true
+		return Gesture{Kind: GestureStroke, Peak: v, Duration: t.Sub(st.starts[0]), Count: len(st.starts), Rate: rate}, true
+	}
+
+	st.peak = max(st.peak, v)
+	held := t.Sub(st.start)
+	if v < cfg.Threshold-cfg.Release {
+		st.contact = false
+		if held <= cfg.TapMax && !st.stroking {
+			return Gesture{Kind: GestureTap, Peak: st.peak, Duration: held, Count: 1}, true
+		}
+		return Gesture{}, false
+	}
+	if !st.squeezed && held >= cfg.SqueezeMin {
+		// held contact breaks rhythm
+		st.squeezed, st.stroking, st.starts = true, false, st.starts[:0]
+		return Gesture{Kind: GestureSqueeze, Peak: st.peak, Duration: held, Count: 1}, true
+	}
+	return Gesture{}, false
+}
+
+// rhythm returns contacts per second when contact starts are enough and
+// evenly spaced to be stroke
+func rhythm(starts []time.Time, cfg GestureConfig) (float64, bool) {
+	n := len(starts) - 1
+	if n+1 < cfg.StrokeMin {
+		return 0, false
+	}
+	mean := starts[n].Sub(starts[0]).Seconds() / float64(n)
+	if mean <= 0 {
+		return 0, false
+	}
+	var sq float64
+	for i := 1; i <= n; i++ {
+		d := starts[i].Sub(starts[i-1]).Seconds() - mean
+		sq += d * d
+	}
+	if math.Sqrt(sq/float64(n))/mean > cfg.Regularity {
+		return 0, false
+	}
+	return 1 / mean, true
+}
+
+// Gestures lists recently recognized gestures, oldest first
+func (h *Hub) Gestures() []Gesture {
+	h.gestures.mu.Lock()
+	defer h.gestures.mu.Unlock()
+	return slices.Clone(h.gestures.recent)
+}
@@ -0,0 +1,256 @@
+package sensor
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/sashalind/sex-artifical-intelligence/pkg/core/errs"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/core/event"
+)
+
+// ErrUnknownChannel is returned for channel ID nobody attached
+var ErrUnknownChannel = errs.New(errs.NotFound, "unknown sensor channel")
+
+// hotplugInterval is how often channels waiting for their device are probed
+const hotplugInterval = 2 * time.Second
+
+// SensorChange is published when sensor is registered, by hand, by its
+// channel plugging in or by its first reading, and when it goes away
+type SensorChange struct {
+	Sensor  SensorInfo
+	Removed bool
+	Time    time.Time
+}
+
+// TopicSensorChange carries sensors added and removed at runtime
+var TopicSensorChange = event.NewTopic[SensorChange]("sensor.change")
+
+// ChannelState tells whether device of channel is there
+type ChannelState string
+
+const (
+	ChannelAttached ChannelState = "attached" // device is being sampled
+	ChannelWaiting  ChannelState = "waiting"  // device absent or broken, probed until it shows up
+)
+
+// ChannelStatus is state of hot-plugged channel
+type ChannelStatus struct {
+	Config    ChannelConfig
+	State     ChannelState
+	Since     time.Time
+	LastError string // why channel is waiting
+}
+
+// channel is physical sensor hub plugs in and out as its device comes and
+// goes, guarded by hub mu
+type channel struct {
+	cfg     ChannelConfig
+	poller  *poller      // nil while waiting
+	saved   []SensorInfo // registrations of sensors kept while unplugged
+	since   time.Time
+	lastErr string
+}
+
+// AttachChannel adds channel to hub. Its device is sampled once it opens
+// and gives reading, until then and after it fails it is probed every
+// few seconds. Sensors channel feeds are registered while device is there.
+func (h *Hub) AttachChannel(cfg ChannelConfig) error {
+	if err := cfg.Validate(); err != nil {
+		return errs.New(errs.InvalidArgument, err.Error())
+	}
+	cfg = cfg.withDefaults()
+
+	h.mu.Lock()
+	if _, ok := h.channels[cfg.ID]; ok {
+		h.mu.Unlock()
+		return errs.New(errs.FailedPrecondition, fmt.Sprintf("sensor channel %s already attached", cfg.ID))
+	}
+	h.channels[cfg.ID] = &channel{cfg: cfg, since: h.clock.Now()}
+	h.mu.Unlock()
+
+	h.plug(cfg.ID)
+	return nil
+}
+
+// DetachChannel stops sampling channel and forgets it, removing sensors it
+// fed unless another channel feeds them too
+func (h *Hub) DetachChannel(id string) error {
+	h.mu.Lock()
+	ch, ok := h.channels[id]
+	if !ok {
+		h.mu.Unlock()
+		return fmt.Errorf("%w: %s", ErrUnknownChannel, id)
+	}
+	delete(h.channels, id)
+	if ch.poller != nil {
+		h.dropPoller(ch.poller)
+		close(ch.poller.stop)
+	}
+	h.releaseSensors(ch)
+	h.mu.Unlock()
+	return nil
+}
+
+// Channels lists hot-plugged channels sorted by ID
+func (h *Hub) Channels() []ChannelStatus {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	out := make([]ChannelStatus, 0, len(h.channels))
+	for _, ch := range h.channels {
+		st := ChannelStatus{Config: ch.cfg, State: ChannelWaiting, Since: ch.since, LastError: ch.lastErr}
+		if ch.poller != nil {
+			st.State = ChannelAttached
+		}
+		out = append(out, st)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Config.ID < out[j].Config.ID })
+	return out
+}
+
+// plug opens device of waiting channel and starts sampling it if it gives
+// reading. Opening may be slow, so it happens outside mu.
+func (h *Hub) plug(id string) {
+	h.mu.RLock()
+	ch, ok := h.channels[id]
+	var cfg ChannelConfig
+	if ok {
+		cfg = ch.cfg
+	}
+	h.mu.RUnlock()
+	if !ok || h.stopped.Load() {
+		return
+	}
+
+	src, err := OpenChannel(h.clock, cfg)
+	if err == nil {
+		// bus devices open fine with nothing wired to them
+		if _, err = src.Read(nil); err != nil {
+			src.Close()
+		}
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if ch, ok = h.channels[id]; !ok || ch.poller != nil || h.stopped.Load() {
+		if err == nil {
+			src.Close()
+		}
+		return
+	}
+	if err != nil {
+		ch.lastErr = err.Error()
+		return
+	}
+
+	for _, info := range cfg.Sensors() {
+		if _, registered := h.instances[info.ID]; registered {
+			continue
+		}
+		if i := slices.IndexFunc(ch.saved, func(s SensorInfo) bool { return s.ID == info.ID }); i >= 0 {
+			info = ch.saved[i]
+		}
+		if err := h.register(info); err != nil {
+			src.Close()
+			ch.lastErr = err.Error()
+			return
+		}
+	}
+	ch.saved, ch.lastErr, ch.since = nil, "", h.clock.Now()
+	ch.poller = &poller{src: src, interval: cfg.Interval, channel: id, stop: make(chan struct{})}
+	h.pollers = append(h.pollers, ch.poller)
+	ch.poller.running.Store(true)
+	go h.pollSource(ch.poller)
+	log.Printf("Sensor channel %s attached", id)
+}
+
+// unplug puts channel whose source failed back to waiting for its device
+func (h *Hub) unplug(p *poller, cause error) {
+	p.src.Close()
+	h.lastErr.Set(cause)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.dropPoller(p)
+	ch, ok := h.channels[p.channel]
+	if !ok || ch.poller != p {
+		return
+	}
+	ch.poller, ch.lastErr, ch.since = nil, cause.Error(), h.clock.Now()
+	h.releaseSensors(ch)
+	log.Printf("Sensor channel %s detached: %v", p.channel, cause)
+}
+
+// releaseSensors removes sensors channel fed, keeping their registrations
+// for when channel comes back, unless other attached channel still feeds
+// them. Caller holds mu.
+func (h *Hub) releaseSensors(ch *channel) {
+	ch.saved = nil
+	for _, fed := range ch.cfg.Sensors() {
+		if h.fedElsewhere(ch, fed.ID) {
+			continue
+		}
+		in, ok := h.instances[fed.ID]
+		if !ok {
+			continue
+		}
+		in.mu.RLock()
+		info := in.info
+		info.Filters = slices.Clone(info.Filters)
+		in.mu.RUnlock()
+		ch.saved = append(ch.saved, info)
+		h.unregister(fed.ID)
+	}
+}
+
+// fedElsewhere reports whether attached channel other than ch feeds
+// sensor, caller holds mu
+func (h *Hub) fedElsewhere(ch *channel, id SensorID) bool {
+	for _, other := range h.channels {
+		if other == ch || other.poller == nil {
+			continue
+		}
+		if slices.ContainsFunc(other.cfg.Sensors(), func(s SensorInfo) bool { return s.ID == id }) {
+			return true
+		}
+	}
+	return false
+}
+
+// dropPoller forgets poller of channel, caller holds mu
+func (h *Hub) dropPoller(p *poller) {
+	h.pollers = slices.DeleteFunc(h.pollers, func(o *poller) bool { return o == p })
+}
+
+// watchChannels probes waiting channels until hub shuts down
+func (h *Hub) watchChannels() {
+	ticker := h.clock.NewTicker(hotplugInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-h.done:
+			return
+		case <-ticker.C():
+			h.mu.RLock()
+			var waiting []string
+			for id, ch := range h.channels {
+				if ch.poller == nil {
+					waiting = append(waiting, id)
+				}
+			}
+			h.mu.RUnlock()
+			for _, id := range waiting {
+				h.plug(id)
+			}
+		}
+	}
+}
+
+// announce publishes sensor added or removed
+func (h *Hub) announce(info SensorInfo, removed bool) {
+	event.Publish(h.bus.Load(), TopicSensorChange, SensorChange{Sensor: info, Removed: removed, Time: h.clock.Now()})
+}
@@ -1,10 +1,23 @@
 package sensor
 
 import (
+	"fmt"
+	"log"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
+
+	"github.com/sashalind/sex-artifical-intelligence/pkg/clock"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/core/errs"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/core/event"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/health"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/storage"
 )
 
+// ErrShutdown is returned by operations on stopped hub
+var ErrShutdown = errs.New(errs.Unavailable, "sensor hub is shut down")
+
 // SensorType represents different types of sensors
 type SensorType string
 
@@ -16,78 +29,433 @@ const (
 	TypeTemp     SensorType = "temperature"
 )
 
+// TopicReading carries every reading ingested by hub
+var TopicReading = event.NewTopic[SensorData]("sensor.reading")
+
 // SensorData represents data from single sensor
 type SensorData struct {
 	Type      SensorType
 	Value     float64
 	Timestamp time.Time
+	Source    string   // ID of source that produced reading, empty if injected
+	Sensor    SensorID // registered sensor reading comes from, empty if unaddressed
+}
+
+// stream holds readings of one sensor type behind its own lock, so ingestion
+// of one type never blocks readers of another. Tiers keep downsampled
+// readings older than values.
+type stream struct {
+	mu     sync.RWMutex
+	values ring
+	tiers  []*tier
 }
 
 // Hub manages all sensor systems
 type Hub struct {
-	sensors map[SensorType][]float64
+	// mu guards sensors map only, readings are guarded per stream
+	sensors map[SensorType]*stream
 	mu      sync.RWMutex
 	
-	// channels for sensor data
-	dataChan chan SensorData
-	done     chan struct{}
+	// readings waiting for ingestion loop
+	queue *queue
+	done  chan struct{}
+	
+	// registered sensors, guarded by mu
+	instances map[SensorID]*instance
+	
+	// calibrations by sensor and their persistent copy, guarded by mu
+	calibrations map[SensorID]Calibration
+	calibLib     *storage.Table[Calibration]
+	
+	// alert rules, copy on write like subscriptions
+	alertRules atomic.Pointer[[]*alertRule]
+	alertMu    sync.Mutex
+	
+	// temperature limits per zone and level of each sensor
+	thermal thermal
+	
+	// contacts of touch and pressure sensors and gestures they made
+	gestures gestures
+	
+	// raw reading recording, nil when not recording; recMu keeps readings
+	// off channel of recording being stopped
+	recording atomic.Pointer[recorder]
+	recMu     sync.RWMutex
+	
+	// readings merged away by sample rate of their sensor
+	decimated atomic.Uint64
+	
+	// reading subscriptions, copy on write so ingestion takes no lock;
+	// subMu serializes writers
+	subs  atomic.Pointer[[]*Subscription]
+	subMu sync.Mutex
+	
+	// readings kept per stream at full rate and downsampled
+	historySize int
+	retention   []RetentionTier
+	
+	// event bus readings are published to, nil until attached
+	bus atomic.Pointer[event.Bus]
+	
+	// attached sources and hot-plugged channels, guarded by mu
+	pollers  []*poller
+	channels map[string]*channel
+	
+	// supervision: processing is false after ingestion loop died
+	processing atomic.Bool
+	stopped    atomic.Bool
+	onFailure  atomic.Pointer[func(error)]
+	lastErr    health.LastError
+	
+	clock clock.Clock
 }
 
 // NewHub creates new sensor management system
 func NewHub() (*Hub, error) {
+	return NewHubWithClock(clock.Real)
+}
+
+// NewHubWithClock creates sensor hub driven by given clock
+func NewHubWithClock(clk clock.Clock) (*Hub, error) {
+	return NewHubWithConfig(clk, DefaultConfig())
+}
+
+// NewHubWithConfig creates sensor hub with given sensor set
+func NewHubWithConfig(clk clock.Clock, cfg Config) (*Hub, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	
 	hub := &Hub{
-		sensors:  make(map[SensorType][]float64),
-		dataChan: make(chan SensorData, 100),
+		historySize: cfg.HistorySize,
+		retention: cfg.Retention,
+		clock:    clock.OrReal(clk),
+		sensors:  make(map[SensorType]*stream),
+		instances: make(map[SensorID]*instance),
+		calibrations: make(map[SensorID]Calibration),
+		channels: make(map[string]*channel),
+		queue:    newQueue(cfg.QueueSize),
 		done:     make(chan struct{}),
 	}
 	
 	// initialize sensor types
-	hub.sensors[TypeTouch] = make([]float64, 0)
-	hub.sensors[TypePressure] = make([]float64, 0)
-	hub.sensors[TypeMotion] = make([]float64, 0)
-	hub.sensors[TypeTemp] = make([]float64, 0)
+	for _, t := range cfg.Types {
+		hub.sensors[t] = hub.newStream()
+	}
+	for _, info := range cfg.Sensors {
+		if err := hub.register(info); err != nil {
+			return nil, err
+		}
+	}
+	for _, rule := range cfg.Alerts {
+		if err := hub.SetAlertRule(rule); err != nil {
+			return nil, err
+		}
+	}
+	hub.gestures.init(cfg.Gestures)
+	if err := hub.SetTempLimits(cfg.TempLimits); err != nil {
+		return nil, err
+	}
 	
+	hub.processing.Store(true)
 	go hub.processData()
+	go hub.watchStale()
+	go hub.watchChannels()
 	
 	return hub, nil
 }
 
-// processData handles incoming sensor data
+// processData runs ingestion loop and reports abnormal exit
 func (h *Hub) processData() {
+	err := h.ingestLoop()
+	h.processing.Store(false)
+	if err != nil {
+		h.fail(err)
+	}
+}
+
+// ingestLoop handles incoming sensor data until shutdown or panic
+func (h *Hub) ingestLoop() (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("sensor ingestion panic: %v", r)
+		}
+	}()
+	
+	var batch []SensorData
 	for {
 		select {
-		case data := <-h.dataChan:
-			h.mu.Lock()
-			h.sensors[data.Type] = append(h.sensors[data.Type], data.Value)
-			// keep only last 1000 readings
-			if len(h.sensors[data.Type]) > 1000 {
-				h.sensors[data.Type] = h.sensors[data.Type][1:]
+		case <-h.queue.ready:
+			batch = h.queue.drain(batch[:0])
+			for _, data := range batch {
+				h.ingest(data)
 			}
-			h.mu.Unlock()
 		case <-h.done:
-			return
+			return nil
+		}
+	}
+}
+
+// fail logs failure and tells supervisor
+func (h *Hub) fail(err error) {
+	h.lastErr.Set(err)
+	log.Printf("Sensor hub failed: %v", err)
+	if fn := h.onFailure.Load(); fn != nil {
+		(*fn)(err)
+	}
+}
+
+// OnFailure sets function called when ingestion loop or source poller dies
+func (h *Hub) OnFailure(fn func(error)) {
+	h.onFailure.Store(&fn)
+}
+
+// Healthy reports whether ingestion and every source poller are running
+func (h *Hub) Healthy() bool {
+	if !h.processing.Load() {
+		return false
+	}
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for _, p := range h.pollers {
+		if !p.running.Load() {
+			return false
+		}
+	}
+	return true
+}
+
+// Health reports ingestion and source state, last error and reading
+// gauges. Hub is down when ingestion stopped and degraded while any source
+// is failing or stopped, channel waits for its device or any sensor is
+// unhealthy or overheated.
+func (h *Hub) Health() health.Report {
+	status := health.OK
+	h.mu.RLock()
+	types := len(h.sensors)
+	sensors := len(h.instances)
+	sources := len(h.pollers)
+	running := 0
+	for _, p := range h.pollers {
+		if p.running.Load() {
+			running++
+		}
+		if !p.running.Load() || p.failing.Load() {
+			status = health.Degraded
+		}
+	}
+	unhealthy := 0
+	for _, in := range h.instances {
+		in.mu.RLock()
+		if !in.healthy() {
+			unhealthy++
+		}
+		in.mu.RUnlock()
+	}
+	waiting := 0
+	for _, ch := range h.channels {
+		if ch.poller == nil {
+			waiting++
+		}
+	}
+	h.mu.RUnlock()
+	overheated := h.overheated()
+	if unhealthy > 0 || waiting > 0 || overheated > 0 {
+		status = health.Degraded
+	}
+	
+	if h.stopped.Load() || !h.processing.Load() {
+		status = health.Down
+	}
+	return health.Report{
+		Status:    status,
+		LastError: h.lastErr.String(),
+		Gauges: map[string]float64{
+			"sensor_types":       float64(types),
+			"sensors":            float64(sensors),
+			"unhealthy_sensors":  float64(unhealthy),
+			"sources":            float64(sources),
+			"running_sources":    float64(running),
+			"waiting_channels":   float64(waiting),
+			"pending_readings":   float64(h.queue.len()),
+			"dropped_readings":   float64(h.queue.dropped.Load()),
+			"decimated_readings": float64(h.decimated.Load()),
+			"overheated_sensors": float64(overheated),
+		},
+	}
+}
+
+// Restart relaunches ingestion loop and source pollers that died
+func (h *Hub) Restart() error {
+	if h.stopped.Load() {
+		return ErrShutdown
+	}
+	
+	if h.processing.CompareAndSwap(false, true) {
+		go h.processData()
+	}
+	
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for _, p := range h.pollers {
+		// channels come back by themselves once device does
+		if p.channel == "" && p.running.CompareAndSwap(false, true) {
+			go h.pollSource(p)
+		}
+	}
+	return nil
+}
+
+// Ingest stores reading synchronously, bypassing ingestion channel.
+// Reading without timestamp is stamped with time of arrival, reading of
+// registered sensor is decimated, calibrated and filtered first.
+func (h *Hub) Ingest(data SensorData) {
+	if data, ok := h.decimate(data); ok {
+		h.ingest(data)
+	}
+}
+
+// ingest stores decimated reading.
+// Hot path: no allocations once stream for the type exists.
+func (h *Hub) ingest(data SensorData) {
+	if data.Timestamp.IsZero() {
+		data.Timestamp = h.clock.Now()
+	}
+	healthy, zone := true, ""
+	if data.Sensor != "" {
+		// registered type wins, readings of sensor stay in one stream
+		in := h.instance(data)
+		in.mu.Lock()
+		data.Type = in.info.Type
+		value := in.calibrate(data.Value)
+		prev := in.health.fault
+		fault, changed := in.check(value, data.Timestamp)
+		if fault == FaultOutOfRange {
+			// kept for inspection, but would throw filters off
+			data.Value = value
+		} else {
+			data.Value = in.filter(value)
+		}
+		in.push(data)
+		for _, st := range in.stats {
+			st.add(data.Value, data.Timestamp)
+			st.expire(data.Timestamp)
 		}
+		info := in.info
+		in.mu.Unlock()
+		zone = info.Zone
+		
+		if changed {
+			h.reportFault(info, prev, fault, value, data.Timestamp)
+		}
+		healthy = fault == ""
+	}
+	if healthy {
+		s := h.stream(data.Type)
+		s.mu.Lock()
+		s.push(data)
+		s.mu.Unlock()
+		
+		event.Publish(h.bus.Load(), TopicReading, data)
+		h.evaluateAlerts(data)
+		h.checkTemperature(data, zone)
+		h.detectGestures(data, zone)
+	}
+	h.publish(data, healthy)
+}
+
+// AttachBus starts publishing readings to event bus
+func (h *Hub) AttachBus(bus *event.Bus) {
+	h.bus.Store(bus)
+}
+
+// newStream creates stream keeping readings as hub is configured to
+func (h *Hub) newStream() *stream {
+	s := &stream{}
+	s.init(h.historySize, h.retention)
+	return s
+}
+
+// stream returns stream for sensor type, creating it on first reading
+func (h *Hub) stream(sType SensorType) *stream {
+	h.mu.RLock()
+	s, ok := h.sensors[sType]
+	h.mu.RUnlock()
+	if ok {
+		return s
 	}
+	
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if s, ok = h.sensors[sType]; !ok {
+		s = h.newStream()
+		h.sensors[sType] = s
+	}
+	return s
 }
 
-// AddSensorData adds new sensor reading
+// AddSensorData queues reading for ingestion without waiting. When
+// ingestion falls behind oldest waiting reading is dropped, after shutdown
+// reading is; both count in dropped_readings health gauge.
 func (h *Hub) AddSensorData(data SensorData) {
-	h.dataChan <- data
+	if data, ok := h.decimate(data); ok {
+		h.queue.push(data)
+	}
 }
 
 // GetSensorData returns latest sensor readings
 func (h *Hub) GetSensorData(sType SensorType) []float64 {
+	h.mu.RLock()
+	s, ok := h.sensors[sType]
+	h.mu.RUnlock()
+	if !ok {
+		return nil
+	}
+	
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	
+	// return copy, ingestion keeps overwriting the ring
+	return s.values.appendValues(make([]float64, 0, s.values.len()))
+}
+
+// GetSamples returns readings of sensor type with timestamps and sources,
+// in order of arrival, stamped within [from, to). Zero from or to leaves
+// that side of window open.
+func (h *Hub) GetSamples(sType SensorType, from, to time.Time) []SensorData {
+	h.mu.RLock()
+	s, ok := h.sensors[sType]
+	h.mu.RUnlock()
+	if !ok {
+		return nil
+	}
+	
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	
+	return s.window(nil, from, to)
+}
+
+// GetSensorTypes returns every sensor type hub has readings for, sorted
+func (h *Hub) GetSensorTypes() []SensorType {
 	h.mu.RLock()
 	defer h.mu.RUnlock()
 	
-	if data, ok := h.sensors[sType]; ok {
-		return data
+	types := make([]SensorType, 0, len(h.sensors))
+	for t := range h.sensors {
+		types = append(types, t)
 	}
-	return nil
+	sort.Slice(types, func(i, j int) bool { return types[i] < types[j] })
+	return types
 }
 
-// Shutdown stops sensor processing
+// Shutdown stops sensor processing, more calls do nothing
 func (h *Hub) Shutdown() {
+	if !h.stopped.CompareAndSwap(false, true) {
+		return
+	}
+	h.StopRecord()
+	h.queue.close()
 	close(h.done)
-	close(h.dataChan)
+	h.closeSubscriptions()
 } 
\ No newline at end of file
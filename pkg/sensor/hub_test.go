@@ -0,0 +1,273 @@
+package sensor
+
+import (
+	"math"
+	"math/rand"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sashalind/sex-artifical-intelligence/pkg/clock"
+)
+
+func newBenchHub(b *testing.B) *Hub {
+	b.Helper()
+	hub, err := NewHub()
+	if err != nil {
+		b.Fatalf("NewHub: %v", err)
+	}
+	b.Cleanup(hub.Shutdown)
+	return hub
+}
+
+// TestIngestDoesNotAllocate guards hot path, see docs/performance.md
+func TestIngestDoesNotAllocate(t *testing.T) {
+	hub, err := NewHub()
+	if err != nil {
+		t.Fatalf("NewHub: %v", err)
+	}
+	defer hub.Shutdown()
+
+	data := SensorData{Type: TypePressure, Value: 0.5, Timestamp: time.Now()}
+	// fill ring first, steady state overwrites oldest reading
+	for range 2 * DefaultHistorySize {
+		hub.Ingest(data)
+	}
+	if allocs := testing.AllocsPerRun(1000, func() { hub.Ingest(data) }); allocs != 0 {
+		t.Errorf("Ingest allocates %v times per reading", allocs)
+	}
+}
+
+// newStatsHub returns hub with registered sensor whose stats windows have
+// been queried, so each reading updates them
+func newStatsHub(tb testing.TB) (*Hub, *clock.Fake) {
+	tb.Helper()
+	clk := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	hub, err := NewHubWithClock(clk)
+	if err != nil {
+		tb.Fatalf("NewHubWithClock: %v", err)
+	}
+	tb.Cleanup(hub.Shutdown)
+	if err := hub.RegisterSensor(SensorInfo{ID: "tip", Type: TypePressure}); err != nil {
+		tb.Fatalf("RegisterSensor: %v", err)
+	}
+	for _, window := range []time.Duration{time.Second, time.Minute} {
+		if _, err := hub.Stats("tip", window); err != nil {
+			tb.Fatalf("Stats: %v", err)
+		}
+	}
+	return hub, clk
+}
+
+// registeredIngest ingests reading of registered sensor 1ms after previous
+// one, value varies so min and max queues turn over
+func registeredIngest(hub *Hub, clk *clock.Fake, i int) {
+	clk.Advance(time.Millisecond)
+	hub.Ingest(SensorData{Sensor: "tip", Type: TypePressure, Value: float64(i%100) / 100, Timestamp: clk.Now()})
+}
+
+// TestRegisteredIngestDoesNotAllocate guards hot path of registered sensor
+// with stats windows, see docs/performance.md
+func TestRegisteredIngestDoesNotAllocate(t *testing.T) {
+	hub, clk := newStatsHub(t)
+	i := 0
+	// fill ring and windows first, steady state overwrites oldest reading
+	for ; i < 2*DefaultHistorySize; i++ {
+		registeredIngest(hub, clk, i)
+	}
+	if allocs := testing.AllocsPerRun(1000, func() {
+		registeredIngest(hub, clk, i)
+		i++
+	}); allocs != 0 {
+		t.Errorf("Ingest of registered sensor allocates %v times per reading", allocs)
+	}
+}
+
+// TestHubConcurrentUse ingests every type from own goroutine while others
+// read, run with -race. Streams lock separately, so each must still hold
+// its readings complete and in order.
+func TestHubConcurrentUse(t *testing.T) {
+	hub, err := NewHub()
+	if err != nil {
+		t.Fatalf("NewHub: %v", err)
+	}
+	defer hub.Shutdown()
+
+	types := []SensorType{TypeTouch, TypePressure, TypeMotion, TypeTemp}
+	const readings = 2 * DefaultHistorySize
+	var writers, readers sync.WaitGroup
+	done := make(chan struct{})
+	for _, typ := range types {
+		writers.Add(1)
+		go func() {
+			defer writers.Done()
+			for i := range readings {
+				hub.Ingest(SensorData{Type: typ, Value: float64(i)})
+			}
+		}()
+		readers.Add(1)
+		go func() {
+			defer readers.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				values := hub.GetSensorData(typ)
+				for i := 1; i < len(values); i++ {
+					if values[i] != values[i-1]+1 {
+						t.Errorf("%s readings out of order: %v then %v", typ, values[i-1], values[i])
+						return
+					}
+				}
+				hub.GetSensorTypes()
+				hub.Health()
+			}
+		}()
+	}
+	writers.Wait()
+	close(done)
+	readers.Wait()
+
+	for _, typ := range types {
+		values := hub.GetSensorData(typ)
+		if len(values) != DefaultHistorySize || values[len(values)-1] != readings-1 {
+			t.Errorf("%s kept %d readings ending %v, want %d ending %d", typ, len(values), values[len(values)-1], DefaultHistorySize, readings-1)
+		}
+	}
+}
+
+// TestStats checks rolling stats against readings counted from scratch
+// while window slides and readings overflow history
+func TestStats(t *testing.T) {
+	clk := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	cfg := DefaultConfig()
+	cfg.HistorySize = 50
+	hub, err := NewHubWithConfig(clk, cfg)
+	if err != nil {
+		t.Fatalf("NewHubWithConfig: %v", err)
+	}
+	defer hub.Shutdown()
+	if err := hub.RegisterSensor(SensorInfo{ID: "tip", Type: TypePressure}); err != nil {
+		t.Fatalf("RegisterSensor: %v", err)
+	}
+
+	const window = 3 * time.Second
+	type reading struct {
+		value float64
+		at    time.Time
+	}
+	var all []reading
+	rng := rand.New(rand.NewSource(1))
+	for i := range 300 {
+		v := float64(rng.Intn(20)) / 20 // repeats exercise equal min and max
+		all = append(all, reading{v, clk.Now()})
+		hub.Ingest(SensorData{Sensor: "tip", Type: TypePressure, Value: v, Timestamp: clk.Now()})
+		clk.Advance(time.Duration(rng.Intn(200)) * time.Millisecond)
+		if i%17 != 0 {
+			continue
+		}
+
+		got, err := hub.Stats("tip", window)
+		if err != nil {
+			t.Fatalf("Stats: %v", err)
+		}
+		var values []float64
+		cutoff := clk.Now().Add(-window)
+		for _, r := range all[max(len(all)-cfg.HistorySize, 0):] {
+			if r.at.After(cutoff) {
+				values = append(values, r.value)
+			}
+		}
+		if got.Count != len(values) {
+			t.Fatalf("reading %d: count = %d, want %d", i, got.Count, len(values))
+		}
+		if len(values) == 0 {
+			continue
+		}
+		sorted := slices.Sorted(slices.Values(values))
+		mean := 0.0
+		for _, v := range values {
+			mean += v
+		}
+		mean /= float64(len(values))
+		sq := 0.0
+		for _, v := range values {
+			sq += (v - mean) * (v - mean)
+		}
+		p50 := sorted[0]
+		if n := len(sorted); n > 1 {
+			pos := 0.5 * float64(n-1)
+			j := int(pos)
+			p50 = sorted[j] + (pos-float64(j))*(sorted[min(j+1, n-1)]-sorted[j])
+		}
+		want := Stats{Min: sorted[0], Max: sorted[len(sorted)-1], Mean: mean, StdDev: math.Sqrt(sq / float64(len(values))), P50: p50}
+		for _, c := range []struct {
+			name      string
+			got, want float64
+		}{
+			{"min", got.Min, want.Min},
+			{"max", got.Max, want.Max},
+			{"mean", got.Mean, want.Mean},
+			{"stddev", got.StdDev, want.StdDev},
+			{"p50", got.P50, want.P50},
+		} {
+			if math.Abs(c.got-c.want) > 1e-9 {
+				t.Errorf("reading %d: %s = %v, want %v", i, c.name, c.got, c.want)
+			}
+		}
+	}
+}
+
+func BenchmarkIngest(b *testing.B) {
+	hub := newBenchHub(b)
+	data := SensorData{Type: TypePressure, Value: 0.5, Timestamp: time.Now()}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		hub.Ingest(data)
+	}
+}
+
+// BenchmarkRegisteredIngest ingests readings of registered sensor with two
+// stats windows tracked, clock advance is included
+func BenchmarkRegisteredIngest(b *testing.B) {
+	hub, clk := newStatsHub(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		registeredIngest(hub, clk, i)
+	}
+}
+
+func BenchmarkIngestParallel(b *testing.B) {
+	hub := newBenchHub(b)
+	types := []SensorType{TypeTouch, TypePressure, TypeMotion, TypeTemp}
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			hub.Ingest(SensorData{Type: types[i%len(types)], Value: 0.5})
+			i++
+		}
+	})
+}
+
+// BenchmarkIngestVsRead ingests while API reads another stream every
+// hundredth op, reads copy and allocate
+func BenchmarkIngestVsRead(b *testing.B) {
+	hub := newBenchHub(b)
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			if i%100 == 0 {
+				hub.GetSensorData(TypeTouch)
+			} else {
+				hub.Ingest(SensorData{Type: TypePressure, Value: 0.5})
+			}
+			i++
+		}
+	})
+}
@@ -0,0 +1,161 @@
+package sensor
+
+import (
+	"fmt"
+
+	"github.com/sashalind/sex-artifical-intelligence/pkg/clock"
+)
+
+// MPR121 registers
+const (
+	mpr121FilteredReg = 0x04 // electrode counts, 10 bit little endian pairs
+	mpr121BaselineReg = 0x1e // electrode baselines, counts >> 2
+	mpr121FilterReg   = 0x2b // baseline filter settings, MHDR onwards
+	mpr121Config1Reg  = 0x5c
+	mpr121Config2Reg  = 0x5d
+	mpr121ECRReg      = 0x5e // electrode configuration, zero is stop mode
+	mpr121ResetReg    = 0x80
+
+	mpr121ResetValue   = 0x63
+	mpr121Config2Reset = 0x24 // CONFIG2 right after reset, tells part apart
+	mpr121Electrodes   = 12
+)
+
+// mpr121Filter is baseline filtering of datasheet quick start, rising and
+// falling halves: MHD, NHD, NCL, FDL each
+var mpr121Filter = []byte{0x01, 0x01, 0x0e, 0x00, 0x01, 0x05, 0x01, 0x00}
+
+// TouchZone is named body zone touch controller electrodes cover
+type TouchZone struct {
+	Zone       string
+	Sensor     SensorID // sensor readings of zone come from, empty is <type>_<zone>
+	Electrodes []int    // 0..11 on MPR121
+}
+
+// validateZones checks electrodes of touch controller are mapped to
+// distinct zones, each electrode to one zone
+func validateZones(typ SensorType, zones []TouchZone) error {
+	if len(zones) == 0 {
+		return fmt.Errorf("sensor channel %s: touch zones required", typ)
+	}
+	names := make(map[string]bool, len(zones))
+	sensors := make(map[SensorID]bool, len(zones))
+	owner := make(map[int]string)
+	for _, z := range zones {
+		if z.Zone == "" {
+			return fmt.Errorf("sensor channel %s: touch zone has no name", typ)
+		}
+		if names[z.Zone] || sensors[z.Sensor] {
+			return fmt.Errorf("sensor channel %s: touch zone %s or its sensor listed twice", typ, z.Zone)
+		}
+		names[z.Zone], sensors[z.Sensor] = true, true
+		if len(z.Electrodes) == 0 {
+			return fmt.Errorf("sensor channel %s: touch zone %s has no electrodes", typ, z.Zone)
+		}
+		for _, e := range z.Electrodes {
+			if e < 0 || e >= mpr121Electrodes {
+				return fmt.Errorf("sensor channel %s: electrode %d out of 0..%d", typ, e, mpr121Electrodes-1)
+			}
+			if other, ok := owner[e]; ok {
+				return fmt.Errorf("sensor channel %s: electrode %d in zones %s and %s", typ, e, other, z.Zone)
+			}
+			owner[e] = z.Zone
+		}
+	}
+	return nil
+}
+
+// MPR121 is Source reading NXP MPR121 capacitive touch controller. Each
+// Read gives one reading per zone: touch intensity 0..1 of its most
+// touched electrode, from how far electrode counts fell below baseline
+// the chip tracks.
+type MPR121 struct {
+	// ID is stamped on readings as their Source
+	ID string
+
+	clock clock.Clock
+	typ   SensorType
+	bus   I2CBus
+	addr  uint16
+	zones []TouchZone
+	span  float64
+	n     int // electrodes enabled, 0..n-1
+	buf   []byte
+	level [mpr121Electrodes]float64
+}
+
+// NewMPR121 resets controller at address of bus and starts sampling
+// electrodes of zones. Span is count drop read as full touch. Source owns
+// bus and closes it.
+func NewMPR121(clk clock.Clock, typ SensorType, bus I2CBus, addr uint16, zones []TouchZone, span float64) (*MPR121, error) {
+	if err := validateZones(typ, zones); err != nil {
+		return nil, err
+	}
+	s := &MPR121{clock: clock.OrReal(clk), ID: fmt.Sprintf("mpr121@0x%02x", addr), typ: typ, bus: bus, addr: addr, zones: zones, span: span}
+	for _, z := range zones {
+		for _, e := range z.Electrodes {
+			s.n = max(s.n, e+1)
+		}
+	}
+	// counts of enabled electrodes, then their baselines
+	s.buf = make([]byte, mpr121BaselineReg-mpr121FilteredReg+s.n)
+
+	if err := s.write(mpr121ResetReg, mpr121ResetValue); err != nil {
+		return nil, err
+	}
+	var cfg2 [1]byte
+	if err := bus.Tx(addr, []byte{mpr121Config2Reg}, cfg2[:]); err != nil {
+		return nil, fmt.Errorf("mpr121 0x%02x: %w", addr, err)
+	}
+	if cfg2[0] != mpr121Config2Reset {
+		return nil, fmt.Errorf("mpr121 0x%02x: unexpected config2 0x%02x after reset", addr, cfg2[0])
+	}
+
+	// chip is in stop mode after reset, configure then enable electrodes
+	// with baseline tracking loaded from first counts
+	if err := s.write(mpr121FilterReg, mpr121Filter...); err != nil {
+		return nil, err
+	}
+	if err := s.write(mpr121Config1Reg, 0x10, 0x20); err != nil { // 16µA charge, 0.5µs, 4 samples, 1ms period
+		return nil, err
+	}
+	if err := s.write(mpr121ECRReg, 0x80|byte(s.n)); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// write sets registers from reg on
+func (s *MPR121) write(reg byte, values ...byte) error {
+	if err := s.bus.Tx(s.addr, append([]byte{reg}, values...), nil); err != nil {
+		return fmt.Errorf("mpr121 0x%02x: %w", s.addr, err)
+	}
+	return nil
+}
+
+func (s *MPR121) Read(dst []SensorData) ([]SensorData, error) {
+	if err := s.bus.Tx(s.addr, []byte{mpr121FilteredReg}, s.buf); err != nil {
+		return dst, fmt.Errorf("mpr121 0x%02x: %w", s.addr, err)
+	}
+	base := s.buf[mpr121BaselineReg-mpr121FilteredReg:]
+	for e := 0; e < s.n; e++ {
+		counts := int(s.buf[2*e]) | int(s.buf[2*e+1]&0x03)<<8
+		s.level[e] = clamp01(float64(int(base[e])<<2-counts) / s.span)
+	}
+
+	now := s.clock.Now()
+	for _, z := range s.zones {
+		var level float64
+		for _, e := range z.Electrodes {
+			level = max(level, s.level[e])
+		}
+		dst = append(dst, SensorData{Type: s.typ, Value: level, Timestamp: now, Source: s.ID, Sensor: z.Sensor})
+	}
+	return dst, nil
+}
+
+func (s *MPR121) Close() error {
+	// stop mode, electrodes stop charging
+	s.write(mpr121ECRReg, 0)
+	return s.bus.Close()
+}
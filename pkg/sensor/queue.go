@@ -0,0 +1,83 @@
+package sensor
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// DefaultQueueSize is readings waiting for ingestion before oldest are
+// dropped
+const DefaultQueueSize = 4096
+
+// queue holds readings between sources and ingestion loop. Producers never
+// wait: when ingestion falls behind oldest reading makes room and is
+// counted, after shutdown readings are refused and counted alike.
+type queue struct {
+	mu     sync.Mutex
+	buf    []SensorData
+	head   int
+	size   int
+	closed bool
+
+	// ready wakes ingestion loop, holds one signal at most
+	ready   chan struct{}
+	dropped atomic.Uint64
+}
+
+func newQueue(size int) *queue {
+	if size == 0 {
+		size = DefaultQueueSize
+	}
+	return &queue{buf: make([]SensorData, size), ready: make(chan struct{}, 1)}
+}
+
+// push adds reading, dropping oldest one when full
+func (q *queue) push(data SensorData) {
+	q.mu.Lock()
+	switch {
+	case q.closed:
+		q.mu.Unlock()
+		q.dropped.Add(1)
+		return
+	case q.size == len(q.buf):
+		q.buf[q.head] = data
+		q.head = (q.head + 1) % len(q.buf)
+		q.mu.Unlock()
+		q.dropped.Add(1)
+	default:
+		q.buf[(q.head+q.size)%len(q.buf)] = data
+		q.size++
+		q.mu.Unlock()
+	}
+
+	select {
+	case q.ready <- struct{}{}:
+	default:
+	}
+}
+
+// drain moves waiting readings into dst in arrival order
+func (q *queue) drain(dst []SensorData) []SensorData {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for ; q.size > 0; q.size-- {
+		dst = append(dst, q.buf[q.head])
+		q.buf[q.head] = SensorData{}
+		q.head = (q.head + 1) % len(q.buf)
+	}
+	return dst
+}
+
+// len returns readings waiting
+func (q *queue) len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.size
+}
+
+// close refuses further readings, waiting ones are left to drain
+func (q *queue) close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.closed = true
+}
@@ -0,0 +1,140 @@
+package sensor
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/sashalind/sex-artifical-intelligence/pkg/core/errs"
+)
+
+// recordBuffer is readings recorder holds while disk is behind, more are
+// dropped and counted
+const recordBuffer = 4096
+
+// recordHeader is first row of recording file
+var recordHeader = []string{"time_ns", "type", "sensor", "source", "value"}
+
+var (
+	ErrRecording    = errs.New(errs.FailedPrecondition, "sensor recording already running")
+	ErrNotRecording = errs.New(errs.FailedPrecondition, "no sensor recording running")
+)
+
+// RecordStats tells how sensor recording went
+type RecordStats struct {
+	File     string // name of file recorded to, empty for other writers
+	Started  time.Time
+	Readings uint64 // written to file
+	Dropped  uint64 // lost because file fell behind
+}
+
+// recorder writes raw readings to CSV in background. Readings are taken as
+// they arrive, before decimation, calibration and filters, so replaying
+// them runs hub pipeline again.
+type recorder struct {
+	ch       chan SensorData
+	done     chan error
+	file     string
+	started  time.Time
+	readings atomic.Uint64
+	dropped  atomic.Uint64
+}
+
+// Record starts writing every reading hub receives to w as CSV rows of
+// time_ns,type,sensor,source,value, until StopRecord closes w
+func (h *Hub) Record(w io.WriteCloser) error {
+	rec := &recorder{ch: make(chan SensorData, recordBuffer), done: make(chan error, 1), started: h.clock.Now()}
+	if f, ok := w.(interface{ Name() string }); ok {
+		rec.file = f.Name()
+	}
+
+	h.recMu.Lock()
+	defer h.recMu.Unlock()
+	if h.recording.Load() != nil {
+		return ErrRecording
+	}
+	if h.stopped.Load() {
+		return ErrShutdown
+	}
+	h.recording.Store(rec)
+	go rec.write(w)
+	return nil
+}
+
+// StopRecord ends recording, flushing and closing its writer
+func (h *Hub) StopRecord() (RecordStats, error) {
+	// ingestion may be handing reading over, recMu keeps it off closed
+	// channel
+	h.recMu.Lock()
+	rec := h.recording.Swap(nil)
+	if rec != nil {
+		close(rec.ch)
+	}
+	h.recMu.Unlock()
+	if rec == nil {
+		return RecordStats{}, ErrNotRecording
+	}
+
+	err := <-rec.done
+	return rec.stats(), err
+}
+
+// Recording returns stats of running recording
+func (h *Hub) Recording() (RecordStats, bool) {
+	rec := h.recording.Load()
+	if rec == nil {
+		return RecordStats{}, false
+	}
+	return rec.stats(), true
+}
+
+// record hands reading to running recording without waiting for disk
+func (h *Hub) record(data SensorData) {
+	if h.recording.Load() == nil {
+		return
+	}
+	h.recMu.RLock()
+	defer h.recMu.RUnlock()
+	rec := h.recording.Load()
+	if rec == nil {
+		return
+	}
+	select {
+	case rec.ch <- data:
+	default:
+		rec.dropped.Add(1)
+	}
+}
+
+func (rec *recorder) stats() RecordStats {
+	return RecordStats{File: rec.file, Started: rec.started, Readings: rec.readings.Load(), Dropped: rec.dropped.Load()}
+}
+
+// write drains readings into w until channel closes. Failed writer keeps
+// draining so ingestion never blocks on it.
+func (rec *recorder) write(w io.WriteCloser) {
+	out := csv.NewWriter(w)
+	err := out.Write(recordHeader)
+
+	row := make([]string, len(recordHeader))
+	for data := range rec.ch {
+		if err != nil {
+			rec.dropped.Add(1)
+			continue
+		}
+		row[0] = strconv.FormatInt(data.Timestamp.UnixNano(), 10)
+		row[1] = string(data.Type)
+		row[2] = string(data.Sensor)
+		row[3] = data.Source
+		row[4] = strconv.FormatFloat(data.Value, 'g', -1, 64)
+		if err = out.Write(row); err == nil {
+			rec.readings.Add(1)
+		}
+	}
+	out.Flush()
+	err = errors.Join(err, out.Error(), w.Close())
+	rec.done <- err
+}
@@ -0,0 +1,297 @@
+package sensor
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/sashalind/sex-artifical-intelligence/pkg/core/errs"
+)
+
+// ErrUnknownSensor is returned for sensor ID nobody registered
+var ErrUnknownSensor = errs.New(errs.NotFound, "unknown sensor")
+
+// SensorID addresses one physical sensor, several may share type
+type SensorID string
+
+// SensorInfo describes registered sensor and where it sits
+type SensorInfo struct {
+	ID       SensorID
+	Type     SensorType
+	Zone     string // body zone sensor belongs to, readings aggregate by it
+	Location string // free form placement within zone
+	Unit     Unit   // unit of calibrated readings, checks and calibration must fit its physical range
+
+	// Filters smooth readings in order, after calibration and before
+	// anything consumes them
+	Filters []FilterSpec
+
+	// SampleRate caps readings per second hub takes from sensor, faster
+	// ones are merged as Decimation says. Zero keeps every reading.
+	SampleRate float64
+	Decimation Decimation
+
+	// Check detects dead, stuck and out of range sensor, whose readings
+	// then stay out of type streams and zone aggregates
+	Check HealthCheck
+}
+
+// Validate checks sensor description
+func (i SensorInfo) Validate() error {
+	if i.ID == "" {
+		return errs.New(errs.InvalidArgument, "sensor ID is empty")
+	}
+	if i.Type == "" {
+		return errs.New(errs.InvalidArgument, fmt.Sprintf("sensor %s has no type", i.ID))
+	}
+	for _, f := range i.Filters {
+		if err := f.Validate(); err != nil {
+			return fmt.Errorf("sensor %s: %w", i.ID, err)
+		}
+	}
+	if err := i.Check.Validate(); err != nil {
+		return fmt.Errorf("sensor %s: %w", i.ID, err)
+	}
+	if err := i.Unit.Validate(); err != nil {
+		return fmt.Errorf("sensor %s: %w", i.ID, err)
+	}
+	if i.Check.hasRange() && !(i.Unit.Contains(i.Check.Min) && i.Unit.Contains(i.Check.Max)) {
+		lo, hi := i.Unit.Bounds()
+		return errs.New(errs.InvalidArgument, fmt.Sprintf("sensor %s: range %g..%g outside %g..%g %s", i.ID, i.Check.Min, i.Check.Max, lo, hi, i.Unit))
+	}
+	return i.validateRate()
+}
+
+// instance is stream of one registered sensor, with its calibration,
+// latest raw readings for zero capture, filters, decimator, health and
+// rolling stats behind stream lock
+type instance struct {
+	info SensorInfo
+	stream
+
+	cal       *Calibration
+	raw       [zeroWindow]float64
+	rawCount  int
+	filters   []Filter
+	decimator *decimator
+	health    checkState
+	stats     []*rollingStats
+}
+
+// RegisterSensor adds sensor or updates zone, location, filters, sample
+// rate and checks of registered one; changed filters, rate and stuck
+// check start over. Type of sensor can't change,
+// its readings would mix.
+func (h *Hub) RegisterSensor(info SensorInfo) error {
+	if err := info.Validate(); err != nil {
+		return err
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return h.register(info)
+}
+
+// register adds or updates sensor, caller holds mu
+func (h *Hub) register(info SensorInfo) error {
+	if in, ok := h.instances[info.ID]; ok {
+		if in.info.Type != info.Type {
+			return errs.New(errs.FailedPrecondition, fmt.Sprintf("sensor %s is %s, not %s", info.ID, in.info.Type, info.Type))
+		}
+		in.mu.Lock()
+		if !slices.Equal(in.info.Filters, info.Filters) {
+			in.filters = newFilters(info.Filters)
+		}
+		if in.info.SampleRate != info.SampleRate || in.info.Decimation != info.Decimation {
+			in.decimator = newDecimator(info)
+		}
+		if in.info.Check != info.Check {
+			in.health.stuckSince = time.Time{}
+		}
+		in.info = info
+		in.mu.Unlock()
+		return nil
+	}
+	h.instances[info.ID] = h.newInstance(info)
+	if _, ok := h.sensors[info.Type]; !ok {
+		h.sensors[info.Type] = h.newStream()
+	}
+	h.announce(info, false)
+	return nil
+}
+
+// UnregisterSensor drops sensor and its readings, readings stay in
+// stream of its type
+func (h *Hub) UnregisterSensor(id SensorID) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if !h.unregister(id) {
+		return fmt.Errorf("%w: %s", ErrUnknownSensor, id)
+	}
+	return nil
+}
+
+// unregister drops sensor if registered, caller holds mu
+func (h *Hub) unregister(id SensorID) bool {
+	in, ok := h.instances[id]
+	if !ok {
+		return false
+	}
+	delete(h.instances, id)
+	in.mu.RLock()
+	info := in.info
+	in.mu.RUnlock()
+	h.forgetTemperature(id)
+	h.announce(info, true)
+	return true
+}
+
+// Sensors lists registered sensors sorted by ID
+func (h *Hub) Sensors() []SensorInfo {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	out := make([]SensorInfo, 0, len(h.instances))
+	for _, in := range h.instances {
+		in.mu.RLock()
+		info := in.info
+		info.Filters = slices.Clone(info.Filters)
+		in.mu.RUnlock()
+		out = append(out, info)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
+
+// SensorSamples returns readings of one sensor stamped within [from, to),
+// zero bound leaves that side open
+func (h *Hub) SensorSamples(id SensorID, from, to time.Time) ([]SensorData, error) {
+	h.mu.RLock()
+	in, ok := h.instances[id]
+	h.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownSensor, id)
+	}
+
+	in.mu.RLock()
+	defer in.mu.RUnlock()
+	return in.window(nil, from, to), nil
+}
+
+// ZoneSamples returns readings of every healthy sensor in zone stamped
+// within [from, to), merged in timestamp order. Type filters sensors when
+// not empty.
+func (h *Hub) ZoneSamples(zone string, sType SensorType, from, to time.Time) []SensorData {
+	h.mu.RLock()
+	all := make([]*instance, 0, len(h.instances))
+	for _, in := range h.instances {
+		all = append(all, in)
+	}
+	h.mu.RUnlock()
+
+	var out []SensorData
+	for _, in := range all {
+		in.mu.RLock()
+		if in.info.Zone == zone && (sType == "" || in.info.Type == sType) && in.healthy() {
+			out = in.window(out, from, to)
+		}
+		in.mu.RUnlock()
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Timestamp.Before(out[j].Timestamp) })
+	return out
+}
+
+// ZoneLevel is latest reading of zone: highest of its healthy sensors
+type ZoneLevel struct {
+	Zone  string
+	Value float64
+	Time  time.Time // of reading, latest one of sensors at Value
+}
+
+// ZoneLevels returns level of every zone with healthy sensor of type that
+// has readings, sorted by zone. With touch controllers mapping electrodes
+// to zones it tells how strongly each zone is touched.
+func (h *Hub) ZoneLevels(sType SensorType) []ZoneLevel {
+	h.mu.RLock()
+	all := make([]*instance, 0, len(h.instances))
+	for _, in := range h.instances {
+		all = append(all, in)
+	}
+	h.mu.RUnlock()
+
+	levels := make(map[string]ZoneLevel)
+	for _, in := range all {
+		in.mu.RLock()
+		if in.info.Zone != "" && in.info.Type == sType && in.healthy() && in.values.len() > 0 {
+			last := in.values.at(in.values.len() - 1)
+			cur, ok := levels[in.info.Zone]
+			if !ok || last.Value > cur.Value || (last.Value == cur.Value && last.Timestamp.After(cur.Time)) {
+				levels[in.info.Zone] = ZoneLevel{Zone: in.info.Zone, Value: last.Value, Time: last.Timestamp}
+			}
+		}
+		in.mu.RUnlock()
+	}
+
+	out := make([]ZoneLevel, 0, len(levels))
+	for _, l := range levels {
+		out = append(out, l)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Zone < out[j].Zone })
+	return out
+}
+
+// instance returns registered sensor reading comes from, registering
+// sensor unknown so far under type of reading
+func (h *Hub) instance(data SensorData) *instance {
+	h.mu.RLock()
+	in, ok := h.instances[data.Sensor]
+	h.mu.RUnlock()
+	if ok {
+		return in
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if in, ok = h.instances[data.Sensor]; !ok {
+		info := SensorInfo{ID: data.Sensor, Type: data.Type}
+		in = h.newInstance(info)
+		h.instances[data.Sensor] = in
+		h.announce(info, false)
+	}
+	return in
+}
+
+// newInstance creates stream of sensor with its calibration, filters and
+// decimator; stale check counts from now. Caller holds mu.
+func (h *Hub) newInstance(info SensorInfo) *instance {
+	in := &instance{
+		info:      info,
+		filters:   newFilters(info.Filters),
+		decimator: newDecimator(info),
+		health:    checkState{lastAt: h.clock.Now()},
+	}
+	in.stream.init(h.historySize, h.retention)
+	if cal, ok := h.calibrations[info.ID]; ok {
+		in.cal = &cal
+	}
+	return in
+}
+
+// newFilters creates filter pipeline of specs
+func newFilters(specs []FilterSpec) []Filter {
+	filters := make([]Filter, 0, len(specs))
+	for _, spec := range specs {
+		filters = append(filters, NewFilter(spec))
+	}
+	return filters
+}
+
+// filter passes reading through filters of sensor, caller holds in.mu
+func (in *instance) filter(v float64) float64 {
+	for _, f := range in.filters {
+		v = f.Filter(v)
+	}
+	return v
+}
@@ -0,0 +1,183 @@
+package sensor
+
+import (
+	"cmp"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/sashalind/sex-artifical-intelligence/pkg/clock"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/core/errs"
+)
+
+// ReplayOptions tune replay of recording
+type ReplayOptions struct {
+	Speed float64 // playback rate, 0 plays in real time
+	Loop  bool    // start over once recording ends
+}
+
+// replaySample is recorded reading with its offset from first one
+type replaySample struct {
+	at   time.Duration
+	data SensorData
+}
+
+// ReplaySource plays recording made by Hub.Record back into hub, keeping
+// recorded spacing of readings. Readings are stamped with replay time.
+// Implements Source.
+type ReplaySource struct {
+	mu      sync.Mutex
+	clock   clock.Clock
+	samples []replaySample
+	speed   float64
+	loop    bool
+	start   time.Time
+	next    int
+	closed  bool
+}
+
+// NewReplaySource reads recording from r and plays it from now
+func NewReplaySource(clk clock.Clock, r io.Reader, opts ReplayOptions) (*ReplaySource, error) {
+	if opts.Speed == 0 {
+		opts.Speed = 1
+	}
+	if !(opts.Speed > 0) || math.IsInf(opts.Speed, 0) {
+		return nil, errs.New(errs.InvalidArgument, "replay speed must be positive")
+	}
+
+	in := csv.NewReader(r)
+	in.FieldsPerRecord = len(recordHeader)
+	in.ReuseRecord = true
+	header, err := in.Read()
+	if err != nil {
+		return nil, fmt.Errorf("read recording: %w", err)
+	}
+	if !slices.Equal(header, recordHeader) {
+		return nil, errs.New(errs.InvalidArgument, "not sensor recording, header is "+fmt.Sprint(header))
+	}
+
+	var samples []replaySample
+	var first int64
+	for {
+		row, err := in.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read recording: %w", err)
+		}
+		ns, err := strconv.ParseInt(row[0], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("read recording: bad time %q", row[0])
+		}
+		value, err := strconv.ParseFloat(row[4], 64)
+		if err != nil {
+			return nil, fmt.Errorf("read recording: bad value %q", row[4])
+		}
+		if len(samples) == 0 {
+			first = ns
+		}
+		samples = append(samples, replaySample{
+			at: time.Duration(ns - first),
+			data: SensorData{
+				Type:   SensorType(row[1]),
+				Sensor: SensorID(row[2]),
+				Source: row[3],
+				Value:  value,
+			},
+		})
+	}
+	if len(samples) == 0 {
+		return nil, errs.New(errs.InvalidArgument, "recording has no readings")
+	}
+	// readings of concurrent sources may be written slightly out of order
+	slices.SortStableFunc(samples, func(a, b replaySample) int { return cmp.Compare(a.at, b.at) })
+	if samples[0].at < 0 {
+		for i := range samples[1:] {
+			samples[i+1].at -= samples[0].at
+		}
+		samples[0].at = 0
+	}
+
+	clk = clock.OrReal(clk)
+	return &ReplaySource{
+		clock:   clk,
+		samples: samples,
+		speed:   opts.Speed,
+		loop:    opts.Loop,
+		start:   clk.Now(),
+	}, nil
+}
+
+// OpenReplay plays recording file from now
+func OpenReplay(clk clock.Clock, path string, opts ReplayOptions) (*ReplaySource, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return NewReplaySource(clk, f, opts)
+}
+
+// Read appends readings whose recorded time has come
+func (s *ReplaySource) Read(dst []SensorData) ([]SensorData, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return dst, nil
+	}
+
+	now := s.clock.Now()
+	for {
+		elapsed := time.Duration(float64(now.Sub(s.start)) * s.speed)
+		for s.next < len(s.samples) && s.samples[s.next].at <= elapsed {
+			sample := s.samples[s.next]
+			sample.data.Timestamp = s.start.Add(time.Duration(float64(sample.at) / s.speed))
+			dst = append(dst, sample.data)
+			s.next++
+		}
+		if s.next < len(s.samples) || !s.loop {
+			return dst, nil
+		}
+
+		// next round starts average reading spacing after this one ended,
+		// rounds missed while nobody polled are skipped
+		span := s.samples[len(s.samples)-1].at
+		gap := time.Nanosecond
+		if len(s.samples) > 1 {
+			gap = max(gap, span/time.Duration(len(s.samples)-1))
+		}
+		round := max(time.Nanosecond, time.Duration(float64(span+gap)/s.speed))
+		s.start = s.start.Add(round)
+		if behind := now.Sub(s.start); behind > round {
+			s.start = s.start.Add(behind / round * round)
+		}
+		s.next = 0
+		if !s.start.Before(now) {
+			return dst, nil
+		}
+	}
+}
+
+// Done reports whether recording played to its end, never for looping
+// replay
+func (s *ReplaySource) Done() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.next == len(s.samples) && !s.loop
+}
+
+// Close stops replay
+func (s *ReplaySource) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	return nil
+}
@@ -0,0 +1,134 @@
+package sensor
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// maxTierBuckets bounds readings one retention tier keeps per stream
+const maxTierBuckets = 100000
+
+// RetentionTier keeps one reading per Resolution, average of readings
+// within it, for Horizon back. Tiers reach past HistorySize full-rate
+// readings, so trends can be read hours back in bounded memory.
+type RetentionTier struct {
+	Resolution time.Duration
+	Horizon    time.Duration
+}
+
+// DefaultRetention keeps 1s averages for 10 minutes and 1m averages for a
+// day
+func DefaultRetention() []RetentionTier {
+	return []RetentionTier{
+		{Resolution: time.Second, Horizon: 10 * time.Minute},
+		{Resolution: time.Minute, Horizon: 24 * time.Hour},
+	}
+}
+
+// validateRetention checks tiers are ordered from finest to coarsest
+func validateRetention(tiers []RetentionTier) error {
+	for i, t := range tiers {
+		if t.Resolution <= 0 || t.Horizon < t.Resolution {
+			return errors.New("sensor retention resolution must be positive and within horizon")
+		}
+		if t.Horizon/t.Resolution > maxTierBuckets {
+			return fmt.Errorf("sensor retention of %s over %s keeps over %d readings", t.Resolution, t.Horizon, maxTierBuckets)
+		}
+		if i > 0 && t.Resolution <= tiers[i-1].Resolution {
+			return errors.New("sensor retention tiers must go from finest to coarsest resolution")
+		}
+	}
+	return nil
+}
+
+// tier averages readings of stream into buckets of resolution, bucket is
+// kept as reading stamped with its start
+type tier struct {
+	resolution time.Duration
+	buckets    ring
+	cur        SensorData
+	sum        float64
+	n          int
+}
+
+// add counts reading into its bucket, completing previous one once
+// reading of later bucket comes. Late readings count into current bucket.
+func (t *tier) add(d SensorData) {
+	if math.IsNaN(d.Value) {
+		return
+	}
+	start := d.Timestamp.Truncate(t.resolution)
+	if t.n > 0 && start.After(t.cur.Timestamp) {
+		t.cur.Value = t.sum / float64(t.n)
+		t.buckets.push(t.cur)
+		t.sum, t.n = 0, 0
+	}
+	if t.n == 0 {
+		t.cur = SensorData{Type: d.Type, Timestamp: start, Source: d.Source, Sensor: d.Sensor}
+	} else {
+		// bucket of type stream mixes sensors
+		if t.cur.Sensor != d.Sensor {
+			t.cur.Sensor = ""
+		}
+		if t.cur.Source != d.Source {
+			t.cur.Source = ""
+		}
+	}
+	t.sum += d.Value
+	t.n++
+}
+
+// init sizes stream for history full-rate readings and retention tiers
+func (s *stream) init(history int, retention []RetentionTier) {
+	s.values = newRing(history)
+	s.tiers = make([]*tier, len(retention))
+	for i, r := range retention {
+		s.tiers[i] = &tier{resolution: r.Resolution, buckets: newRing(int(r.Horizon / r.Resolution))}
+	}
+}
+
+// push keeps reading at full rate and in every tier, caller holds s.mu
+func (s *stream) push(data SensorData) {
+	s.values.push(data)
+	for _, t := range s.tiers {
+		t.add(data)
+	}
+}
+
+// window appends readings stamped within [from, to) oldest first to dst,
+// zero bound leaves that side open. Time before oldest full-rate reading
+// is filled from tiers, each covering what finer one no longer holds, so
+// bucket may overlap start of finer readings but never leaves gap. Caller
+// holds s.mu.
+func (s *stream) window(dst []SensorData, from, to time.Time) []SensorData {
+	var parts [][]SensorData
+	cut, bounded := time.Time{}, s.values.len() > 0
+	if bounded {
+		cut = s.values.at(0).Timestamp
+	}
+	for _, t := range s.tiers {
+		var part []SensorData
+		for i := 0; i < t.buckets.len(); i++ {
+			b := t.buckets.at(i)
+			if bounded && !b.Timestamp.Before(cut) {
+				break
+			}
+			if (!from.IsZero() && b.Timestamp.Before(from)) || (!to.IsZero() && !b.Timestamp.Before(to)) {
+				continue
+			}
+			part = append(part, b)
+		}
+		parts = append(parts, part)
+		if t.buckets.len() > 0 {
+			if oldest := t.buckets.at(0).Timestamp; !bounded || oldest.Before(cut) {
+				cut, bounded = oldest, true
+			}
+		}
+	}
+	for i := len(parts) - 1; i >= 0; i-- {
+		dst = append(dst, parts[i]...)
+	}
+	return s.values.appendWindow(dst, from, to)
+}
@@ -0,0 +1,57 @@
+package sensor
+
+import "time"
+
+// ring is fixed-capacity circular buffer of readings. Once full, new
+// readings overwrite the oldest, so ingestion never allocates.
+type ring struct {
+	buf  []SensorData
+	head int // index of oldest reading
+	size int
+}
+
+func newRing(capacity int) ring {
+	return ring{buf: make([]SensorData, capacity)}
+}
+
+// push adds reading, dropping oldest when full
+func (r *ring) push(v SensorData) {
+	if r.size < len(r.buf) {
+		r.buf[(r.head+r.size)%len(r.buf)] = v
+		r.size++
+		return
+	}
+	r.buf[r.head] = v
+	r.head = (r.head + 1) % len(r.buf)
+}
+
+// at returns i-th reading, oldest is 0
+func (r *ring) at(i int) SensorData {
+	return r.buf[(r.head+i)%len(r.buf)]
+}
+
+// appendValues appends values of readings oldest first to dst
+func (r *ring) appendValues(dst []float64) []float64 {
+	for i := 0; i < r.size; i++ {
+		dst = append(dst, r.at(i).Value)
+	}
+	return dst
+}
+
+// appendWindow appends readings stamped within [from, to) oldest first to
+// dst, zero bound leaves that side open
+func (r *ring) appendWindow(dst []SensorData, from, to time.Time) []SensorData {
+	for i := 0; i < r.size; i++ {
+		v := r.at(i)
+		if (!from.IsZero() && v.Timestamp.Before(from)) || (!to.IsZero() && !v.Timestamp.Before(to)) {
+			continue
+		}
+		dst = append(dst, v)
+	}
+	return dst
+}
+
+// len returns number of stored readings
+func (r *ring) len() int {
+	return r.size
+}
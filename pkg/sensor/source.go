@@ -0,0 +1,111 @@
+package sensor
+
+import (
+	"fmt"
+	"log"
+	"sync/atomic"
+	"time"
+)
+
+// Source produces sensor readings. Hardware drivers and simulators implement
+// it and Hub polls them at configured interval.
+type Source interface {
+	// Read appends readings collected since previous call to dst and returns
+	// extended slice. Hub reuses dst between polls, so sources shouldn't
+	// allocate in steady state.
+	Read(dst []SensorData) ([]SensorData, error)
+
+	// Close releases underlying device
+	Close() error
+}
+
+// sourceFailureLimit is how many reads in a row may fail before poller
+// stops and reports source as failed
+const sourceFailureLimit = 50
+
+// poller polls one attached source
+type poller struct {
+	src      Source
+	interval time.Duration
+	running  atomic.Bool
+	failing  atomic.Bool // last read failed
+	
+	// channel is ID of hot-plugged channel source belongs to, empty for
+	// sources attached directly. Closing stop detaches it.
+	channel string
+	stop    chan struct{}
+}
+
+// AttachSource starts polling source at given interval until hub shuts down
+func (h *Hub) AttachSource(src Source, interval time.Duration) {
+	p := &poller{src: src, interval: interval}
+	
+	h.mu.Lock()
+	h.pollers = append(h.pollers, p)
+	h.mu.Unlock()
+	
+	p.running.Store(true)
+	go h.pollSource(p)
+}
+
+// pollSource runs poll loop and reports abnormal exit. Failed source stays
+// open so Restart can resume it, unless it belongs to channel: that one is
+// unplugged and waits for device to come back.
+func (h *Hub) pollSource(p *poller) {
+	err := h.pollLoop(p)
+	p.running.Store(false)
+	if err != nil && p.channel != "" {
+		h.unplug(p, err)
+		return
+	}
+	if err != nil {
+		h.fail(err)
+		return
+	}
+	p.src.Close()
+}
+
+// pollLoop feeds readings from source into hub until shutdown or failure
+func (h *Hub) pollLoop(p *poller) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("sensor source panic: %v", r)
+		}
+	}()
+	
+	ticker := h.clock.NewTicker(p.interval)
+	defer ticker.Stop()
+	
+	var buf []SensorData
+	failures := 0
+	
+	for {
+		select {
+		case <-h.done:
+			return nil
+		case <-p.stop:
+			return nil
+		case <-ticker.C():
+			readings, err := p.src.Read(buf[:0])
+			if err != nil {
+				p.failing.Store(true)
+				h.lastErr.Set(err)
+				failures++
+				if failures >= sourceFailureLimit {
+					return fmt.Errorf("sensor source failed %d reads in a row: %w", failures, err)
+				}
+				log.Printf("Sensor source read failed: %v", err)
+				continue
+			}
+			failures = 0
+			p.failing.Store(false)
+			buf = readings
+			
+			for _, data := range readings {
+				if data, ok := h.decimate(data); ok {
+					h.queue.push(data)
+				}
+			}
+		}
+	}
+}
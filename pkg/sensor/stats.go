@@ -0,0 +1,249 @@
+package sensor
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/sashalind/sex-artifical-intelligence/pkg/core/errs"
+)
+
+// maxStatWindows is windows tracked per sensor, least recently queried one
+// makes room for new one
+const maxStatWindows = 4
+
+// Stats summarizes readings of sensor within window ending now, at most
+// HistorySize latest ones
+type Stats struct {
+	Sensor   SensorID
+	Window   time.Duration
+	Count    int
+	Min, Max float64
+	Mean     float64
+	StdDev   float64 // population standard deviation
+	P50      float64
+	P90      float64
+	P99      float64
+	From, To time.Time // oldest and newest reading counted, zero when none
+}
+
+// statItem is reading counted by rolling stats, seq tells equal values
+// apart in min and max queues
+type statItem struct {
+	seq   uint64
+	value float64
+	time  time.Time
+}
+
+// statQueue is fixed-capacity double-ended queue of counted readings, so
+// counting never allocates
+type statQueue struct {
+	buf  []statItem
+	head int // index of front item
+	size int
+}
+
+func newStatQueue(capacity int) statQueue {
+	return statQueue{buf: make([]statItem, capacity)}
+}
+
+// at returns i-th item, front is 0
+func (q *statQueue) at(i int) statItem {
+	return q.buf[(q.head+i)%len(q.buf)]
+}
+
+// front returns first item, queue must not be empty
+func (q *statQueue) front() statItem {
+	return q.buf[q.head]
+}
+
+// back returns last item, queue must not be empty
+func (q *statQueue) back() statItem {
+	return q.at(q.size - 1)
+}
+
+// pushBack adds item after last, caller makes sure there is room
+func (q *statQueue) pushBack(it statItem) {
+	q.buf[(q.head+q.size)%len(q.buf)] = it
+	q.size++
+}
+
+// popFront drops first item
+func (q *statQueue) popFront() {
+	q.head = (q.head + 1) % len(q.buf)
+	q.size--
+}
+
+// popBack drops last item
+func (q *statQueue) popBack() {
+	q.size--
+}
+
+// rollingStats keeps statistics of readings in sliding time window up to
+// date as readings come and go: Welford mean and variance and monotonic
+// queues for min and max. Percentiles sort copy of window on query, so
+// readings cost the same whatever the window holds.
+type rollingStats struct {
+	window time.Duration
+	limit  int
+	used   time.Time // of last query
+
+	seq      uint64
+	items    statQueue // oldest first
+	minq     statQueue // increasing values, front is minimum
+	maxq     statQueue // decreasing values, front is maximum
+	mean, m2 float64
+	sorted   []float64 // scratch for percentiles
+}
+
+func newRollingStats(window time.Duration, limit int) *rollingStats {
+	// one more than limit, reading is added before oldest is dropped
+	capacity := max(limit, 0) + 1
+	return &rollingStats{
+		window: window,
+		limit:  limit,
+		items:  newStatQueue(capacity),
+		minq:   newStatQueue(capacity),
+		maxq:   newStatQueue(capacity),
+	}
+}
+
+// add counts reading, dropping oldest one over limit
+func (s *rollingStats) add(v float64, t time.Time) {
+	if math.IsNaN(v) {
+		return
+	}
+	s.seq++
+	it := statItem{seq: s.seq, value: v, time: t}
+	s.items.pushBack(it)
+
+	n := float64(s.items.size)
+	d := v - s.mean
+	s.mean += d / n
+	s.m2 += d * (v - s.mean)
+
+	for s.minq.size > 0 && s.minq.back().value >= v {
+		s.minq.popBack()
+	}
+	s.minq.pushBack(it)
+	for s.maxq.size > 0 && s.maxq.back().value <= v {
+		s.maxq.popBack()
+	}
+	s.maxq.pushBack(it)
+
+	if s.items.size > s.limit {
+		s.pop()
+	}
+}
+
+// expire drops readings older than window before now
+func (s *rollingStats) expire(now time.Time) {
+	cutoff := now.Add(-s.window)
+	for s.items.size > 0 && !s.items.front().time.After(cutoff) {
+		s.pop()
+	}
+}
+
+// pop drops oldest reading
+func (s *rollingStats) pop() {
+	it := s.items.front()
+	s.items.popFront()
+
+	if n := float64(s.items.size); n == 0 {
+		s.mean, s.m2 = 0, 0
+	} else {
+		d := it.value - s.mean
+		s.mean -= d / n
+		s.m2 = max(s.m2-d*(it.value-s.mean), 0)
+	}
+
+	if s.minq.front().seq == it.seq {
+		s.minq.popFront()
+	}
+	if s.maxq.front().seq == it.seq {
+		s.maxq.popFront()
+	}
+}
+
+// sortValues fills sorted with values of counted readings in order
+func (s *rollingStats) sortValues() {
+	s.sorted = s.sorted[:0]
+	for i := 0; i < s.items.size; i++ {
+		s.sorted = append(s.sorted, s.items.at(i).value)
+	}
+	slices.Sort(s.sorted)
+}
+
+// percentile interpolates p-th fraction of sorted values
+func (s *rollingStats) percentile(p float64) float64 {
+	pos := p * float64(len(s.sorted)-1)
+	i := int(pos)
+	if i+1 >= len(s.sorted) {
+		return s.sorted[len(s.sorted)-1]
+	}
+	return s.sorted[i] + (pos-float64(i))*(s.sorted[i+1]-s.sorted[i])
+}
+
+// stats returns current statistics
+func (s *rollingStats) stats(id SensorID) Stats {
+	st := Stats{Sensor: id, Window: s.window, Count: s.items.size}
+	if st.Count == 0 {
+		return st
+	}
+	st.Min, st.Max = s.minq.front().value, s.maxq.front().value
+	st.Mean = s.mean
+	st.StdDev = math.Sqrt(s.m2 / float64(st.Count))
+	s.sortValues()
+	st.P50, st.P90, st.P99 = s.percentile(0.5), s.percentile(0.9), s.percentile(0.99)
+	st.From, st.To = s.items.front().time, s.items.back().time
+	return st
+}
+
+// Stats returns statistics of readings of sensor within window ending now.
+// First query of window counts readings kept so far, from then on window
+// is tracked as readings arrive; later queries only sort window for
+// percentiles.
+func (h *Hub) Stats(id SensorID, window time.Duration) (Stats, error) {
+	if window <= 0 {
+		return Stats{}, errs.New(errs.InvalidArgument, "stats window must be positive")
+	}
+	h.mu.RLock()
+	in, ok := h.instances[id]
+	h.mu.RUnlock()
+	if !ok {
+		return Stats{}, fmt.Errorf("%w: %s", ErrUnknownSensor, id)
+	}
+
+	now := h.clock.Now()
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	s := in.statWindow(window, h.historySize)
+	s.used = now
+	s.expire(now)
+	return s.stats(id), nil
+}
+
+// statWindow returns rolling stats of window, starting them from stored
+// readings when window is new; caller holds in.mu
+func (in *instance) statWindow(window time.Duration, limit int) *rollingStats {
+	for _, s := range in.stats {
+		if s.window == window {
+			return s
+		}
+	}
+	s := newRollingStats(window, limit)
+	for i := 0; i < in.values.len(); i++ {
+		v := in.values.at(i)
+		s.add(v.Value, v.Timestamp)
+	}
+	if len(in.stats) < maxStatWindows {
+		in.stats = append(in.stats, s)
+		return s
+	}
+	// replace least recently queried
+	sort.Slice(in.stats, func(i, j int) bool { return in.stats[i].used.Before(in.stats[j].used) })
+	in.stats[0] = s
+	return s
+}
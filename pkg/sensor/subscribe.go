@@ -0,0 +1,170 @@
+package sensor
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"sync/atomic"
+
+	"github.com/sashalind/sex-artifical-intelligence/pkg/core/errs"
+)
+
+// Backpressure decides what happens to reading subscriber has no room for
+type Backpressure string
+
+const (
+	DropNewest Backpressure = "drop_newest" // reading is dropped, default
+	DropOldest Backpressure = "drop_oldest" // oldest buffered reading makes room
+	Block      Backpressure = "block"       // ingestion waits, slowing every source of hub
+)
+
+// Selector picks readings subscription receives, empty fields match all
+type Selector struct {
+	Type   SensorType
+	Sensor SensorID
+}
+
+func (s Selector) matches(data SensorData) bool {
+	return (s.Type == "" || s.Type == data.Type) && (s.Sensor == "" || s.Sensor == data.Sensor)
+}
+
+// Subscription receives readings as hub ingests them, calibrated and
+// filtered, in order of arrival
+type Subscription struct {
+	C <-chan SensorData
+
+	ch        chan SensorData
+	sel       Selector
+	policy    Backpressure
+	dropped   atomic.Uint64
+	hub       *Hub
+	cancelled chan struct{}
+	once      sync.Once
+
+	// mu keeps channel open while reading is being delivered
+	mu     sync.RWMutex
+	closed bool
+}
+
+// Dropped returns number of readings lost because subscriber was too slow
+func (s *Subscription) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+// Cancel unsubscribes and closes channel
+func (s *Subscription) Cancel() {
+	s.hub.subMu.Lock()
+	if subs := s.hub.subs.Load(); subs != nil {
+		rest := slices.DeleteFunc(slices.Clone(*subs), func(o *Subscription) bool { return o == s })
+		s.hub.subs.Store(&rest)
+	}
+	s.hub.subMu.Unlock()
+	s.close()
+}
+
+func (s *Subscription) close() {
+	s.once.Do(func() {
+		// wakes blocked delivery before waiting for it
+		close(s.cancelled)
+		s.mu.Lock()
+		s.closed = true
+		close(s.ch)
+		s.mu.Unlock()
+	})
+}
+
+// deliver hands reading to subscriber as its policy says
+func (s *Subscription) deliver(data SensorData, done <-chan struct{}) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		return
+	}
+
+	select {
+	case s.ch <- data:
+		return
+	default:
+	}
+	switch s.policy {
+	case Block:
+		select {
+		case s.ch <- data:
+		case <-s.cancelled:
+		case <-done:
+		}
+	case DropOldest:
+		select {
+		case <-s.ch:
+			s.dropped.Add(1)
+		default:
+		}
+		select {
+		case s.ch <- data:
+		default:
+			s.dropped.Add(1)
+		}
+	default:
+		s.dropped.Add(1)
+	}
+}
+
+// Subscribe streams readings selector matches with given channel buffer.
+// Unlike event bus, readings of one type or sensor only reach it and slow
+// reader gets chosen backpressure. Shutdown closes stream.
+func (h *Hub) Subscribe(sel Selector, buffer int, policy Backpressure) (*Subscription, error) {
+	switch policy {
+	case "":
+		policy = DropNewest
+	case DropNewest, DropOldest, Block:
+	default:
+		return nil, errs.New(errs.InvalidArgument, fmt.Sprintf("unknown backpressure policy %q", policy))
+	}
+	if buffer < 0 {
+		return nil, errs.New(errs.InvalidArgument, "subscription buffer must not be negative")
+	}
+
+	ch := make(chan SensorData, buffer)
+	sub := &Subscription{C: ch, ch: ch, sel: sel, policy: policy, hub: h, cancelled: make(chan struct{})}
+
+	h.subMu.Lock()
+	defer h.subMu.Unlock()
+	if h.stopped.Load() {
+		sub.close()
+		return sub, nil
+	}
+	var subs []*Subscription
+	if cur := h.subs.Load(); cur != nil {
+		subs = slices.Clone(*cur)
+	}
+	subs = append(subs, sub)
+	h.subs.Store(&subs)
+	return sub, nil
+}
+
+// publish delivers reading to matching subscriptions. Readings of
+// unhealthy sensor only reach subscriptions asking for that sensor.
+func (h *Hub) publish(data SensorData, healthy bool) {
+	subs := h.subs.Load()
+	if subs == nil {
+		return
+	}
+	for _, sub := range *subs {
+		if sub.sel.matches(data) && (healthy || sub.sel.Sensor != "") {
+			sub.deliver(data, h.done)
+		}
+	}
+}
+
+// closeSubscriptions ends every subscription on shutdown
+func (h *Hub) closeSubscriptions() {
+	h.subMu.Lock()
+	subs := h.subs.Swap(nil)
+	h.subMu.Unlock()
+	if subs == nil {
+		return
+	}
+	for _, sub := range *subs {
+		sub.close()
+	}
+}
@@ -0,0 +1,170 @@
+package sensor
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sashalind/sex-artifical-intelligence/pkg/core/errs"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/core/event"
+)
+
+// TempLevel is how hot sensor is against limit of its zone
+type TempLevel string
+
+const (
+	TempNormal   TempLevel = "normal"
+	TempWarning  TempLevel = "warning"  // at or over Warn
+	TempCritical TempLevel = "critical" // at or over Max, motors must stop
+)
+
+// TempLimit bounds temperature readings of sensors in zone, in °C. Limit
+// with empty zone covers sensors of zones without own limit and readings
+// of no sensor. Level drops back once reading is Hysteresis under limit.
+type TempLimit struct {
+	Zone       string
+	Warn       float64 // zero warns never
+	Max        float64
+	Hysteresis float64
+}
+
+// Validate checks temperature limit
+func (l TempLimit) Validate() error {
+	for _, v := range []float64{l.Warn, l.Max, l.Hysteresis} {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return errs.New(errs.InvalidArgument, fmt.Sprintf("temperature limit of zone %q must be finite", l.Zone))
+		}
+	}
+	if l.Warn != 0 && l.Warn >= l.Max {
+		return errs.New(errs.InvalidArgument, fmt.Sprintf("temperature limit of zone %q: warn must be below max", l.Zone))
+	}
+	if l.Hysteresis < 0 {
+		return errs.New(errs.InvalidArgument, fmt.Sprintf("temperature limit of zone %q: hysteresis must not be negative", l.Zone))
+	}
+	return nil
+}
+
+// level judges value against limit, coming from level prev
+func (l TempLimit) level(prev TempLevel, v float64) TempLevel {
+	switch {
+	case v >= l.Max || (prev == TempCritical && v > l.Max-l.Hysteresis):
+		return TempCritical
+	case l.Warn != 0 && (v >= l.Warn || (prev != TempNormal && v > l.Warn-l.Hysteresis)):
+		return TempWarning
+	}
+	return TempNormal
+}
+
+// OverTemp is published when temperature sensor changes level, Level
+// normal once it cooled down
+type OverTemp struct {
+	Zone   string
+	Sensor SensorID
+	Level  TempLevel
+	Value  float64
+	Limit  float64 // limit of Level, of level left once back to normal
+	Time   time.Time
+}
+
+// TopicOverTemp carries temperature level changes, safety stops motors on
+// critical ones
+var TopicOverTemp = event.NewTopic[OverTemp]("sensor.overtemp")
+
+// thermal holds temperature limits and level of every sensor
+type thermal struct {
+	mu     sync.Mutex
+	limits map[string]TempLimit
+	levels map[SensorID]TempLevel
+}
+
+// SetTempLimits replaces temperature limits, sensors keep their level
+// until their next reading
+func (h *Hub) SetTempLimits(limits []TempLimit) error {
+	byZone := make(map[string]TempLimit, len(limits))
+	for _, l := range limits {
+		if err := l.Validate(); err != nil {
+			return err
+		}
+		if _, ok := byZone[l.Zone]; ok {
+			return errs.New(errs.InvalidArgument, fmt.Sprintf("duplicate temperature limit of zone %q", l.Zone))
+		}
+		byZone[l.Zone] = l
+	}
+
+	h.thermal.mu.Lock()
+	defer h.thermal.mu.Unlock()
+	h.thermal.limits = byZone
+	return nil
+}
+
+// TempLimits lists temperature limits sorted by zone
+func (h *Hub) TempLimits() []TempLimit {
+	h.thermal.mu.Lock()
+	defer h.thermal.mu.Unlock()
+
+	out := make([]TempLimit, 0, len(h.thermal.limits))
+	for _, l := range h.thermal.limits {
+		out = append(out, l)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Zone < out[j].Zone })
+	return out
+}
+
+// checkTemperature judges healthy temperature reading against limit of
+// zone of its sensor and publishes level change
+func (h *Hub) checkTemperature(data SensorData, zone string) {
+	if data.Type != TypeTemp {
+		return
+	}
+	t := &h.thermal
+	t.mu.Lock()
+	limit, ok := t.limits[zone]
+	if !ok {
+		limit, ok = t.limits[""]
+	}
+	if !ok {
+		t.mu.Unlock()
+		return
+	}
+	if t.levels == nil {
+		t.levels = make(map[SensorID]TempLevel)
+	}
+	prev, seen := t.levels[data.Sensor]
+	if !seen {
+		prev = TempNormal
+	}
+	level := limit.level(prev, data.Value)
+	t.levels[data.Sensor] = level
+	t.mu.Unlock()
+	if level == prev {
+		return
+	}
+
+	over := OverTemp{Zone: zone, Sensor: data.Sensor, Level: level, Value: data.Value, Limit: limit.Max, Time: data.Timestamp}
+	if level == TempWarning || (level == TempNormal && prev == TempWarning) {
+		over.Limit = limit.Warn
+	}
+	event.Publish(h.bus.Load(), TopicOverTemp, over)
+}
+
+// forgetTemperature drops level of sensor gone away
+func (h *Hub) forgetTemperature(id SensorID) {
+	h.thermal.mu.Lock()
+	defer h.thermal.mu.Unlock()
+	delete(h.thermal.levels, id)
+}
+
+// overheated counts sensors at critical temperature
+func (h *Hub) overheated() int {
+	h.thermal.mu.Lock()
+	defer h.thermal.mu.Unlock()
+	n := 0
+	for _, l := range h.thermal.levels {
+		if l == TempCritical {
+			n++
+		}
+	}
+	return n
+}
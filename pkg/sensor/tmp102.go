@@ -0,0 +1,43 @@
+package sensor
+
+import (
+	"fmt"
+
+	"github.com/sashalind/sex-artifical-intelligence/pkg/clock"
+)
+
+// tmp102TempReg is temperature register, 12 bit left aligned, 0.0625°C/LSB
+const tmp102TempReg = 0x00
+
+// TMP102 is Source reading TI TMP102 temperature sensor, LM75 and other
+// parts with same temperature register read alike at lower resolution
+type TMP102 struct {
+	// ID is stamped on readings as their Source, Sensor as sensor they
+	// come from
+	ID     string
+	Sensor SensorID
+
+	clock clock.Clock
+	typ   SensorType
+	bus   I2CBus
+	addr  uint16
+	buf   [2]byte
+}
+
+// NewTMP102 reads sensor at address of bus as readings of given type in °C.
+// Source owns bus and closes it.
+func NewTMP102(clk clock.Clock, typ SensorType, bus I2CBus, addr uint16) *TMP102 {
+	return &TMP102{clock: clock.OrReal(clk), ID: fmt.Sprintf("tmp102@0x%02x", addr), typ: typ, bus: bus, addr: addr}
+}
+
+func (s *TMP102) Read(dst []SensorData) ([]SensorData, error) {
+	if err := s.bus.Tx(s.addr, []byte{tmp102TempReg}, s.buf[:]); err != nil {
+		return dst, fmt.Errorf("tmp102 0x%02x: %w", s.addr, err)
+	}
+	raw := int16(uint16(s.buf[0])<<8|uint16(s.buf[1])) >> 4
+	return append(dst, SensorData{Type: s.typ, Value: float64(raw) * 0.0625, Timestamp: s.clock.Now(), Source: s.ID, Sensor: s.Sensor}), nil
+}
+
+func (s *TMP102) Close() error {
+	return s.bus.Close()
+}
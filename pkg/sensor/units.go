@@ -0,0 +1,151 @@
+package sensor
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/sashalind/sex-artifical-intelligence/pkg/core/errs"
+)
+
+// Unit is physical unit of calibrated sensor readings
+type Unit string
+
+const (
+	UnitNone    Unit = ""      // not declared, readings are not converted
+	UnitRatio   Unit = "ratio" // normalized 0..1, what most sources give raw
+	UnitPercent Unit = "%"
+
+	UnitNewton        Unit = "N"
+	UnitKilogramForce Unit = "kgf"
+	UnitPoundForce    Unit = "lbf"
+
+	UnitPascal       Unit = "Pa"
+	UnitKilopascal   Unit = "kPa"
+	UnitPSI          Unit = "psi"
+	UnitMillimeterHg Unit = "mmHg"
+
+	UnitCelsius    Unit = "°C"
+	UnitFahrenheit Unit = "°F"
+	UnitKelvin     Unit = "K"
+
+	UnitMeterPerSecond2 Unit = "m/s²"
+	UnitGravity         Unit = "g" // standard gravity
+)
+
+// Dimension is physical quantity unit measures, units convert within one
+type Dimension string
+
+const (
+	DimRatio        Dimension = "ratio"
+	DimForce        Dimension = "force"
+	DimPressure     Dimension = "pressure"
+	DimTemperature  Dimension = "temperature"
+	DimAcceleration Dimension = "acceleration"
+)
+
+// unitDef places unit on scale of its dimension: base = v*scale + offset.
+// Bounds are physical limits of dimension in base unit.
+type unitDef struct {
+	dim           Dimension
+	scale, offset float64
+	min, max      float64
+}
+
+var units = map[Unit]unitDef{
+	UnitRatio:   {dim: DimRatio, scale: 1, min: 0, max: 1},
+	UnitPercent: {dim: DimRatio, scale: 0.01, min: 0, max: 1},
+
+	UnitNewton:        {dim: DimForce, scale: 1, min: math.Inf(-1), max: math.Inf(1)},
+	UnitKilogramForce: {dim: DimForce, scale: 9.80665, min: math.Inf(-1), max: math.Inf(1)},
+	UnitPoundForce:    {dim: DimForce, scale: 4.4482216152605, min: math.Inf(-1), max: math.Inf(1)},
+
+	// gauge pressure may go below zero, absolute bound is left to checks
+	UnitPascal:       {dim: DimPressure, scale: 1, min: math.Inf(-1), max: math.Inf(1)},
+	UnitKilopascal:   {dim: DimPressure, scale: 1000, min: math.Inf(-1), max: math.Inf(1)},
+	UnitPSI:          {dim: DimPressure, scale: 6894.757293168, min: math.Inf(-1), max: math.Inf(1)},
+	UnitMillimeterHg: {dim: DimPressure, scale: 133.322387415, min: math.Inf(-1), max: math.Inf(1)},
+
+	// kelvin is base, nothing is colder than absolute zero
+	UnitKelvin:     {dim: DimTemperature, scale: 1, min: 0, max: math.Inf(1)},
+	UnitCelsius:    {dim: DimTemperature, scale: 1, offset: 273.15, min: 0, max: math.Inf(1)},
+	UnitFahrenheit: {dim: DimTemperature, scale: 5.0 / 9, offset: 273.15 - 32*5.0/9, min: 0, max: math.Inf(1)},
+
+	UnitMeterPerSecond2: {dim: DimAcceleration, scale: 1, min: math.Inf(-1), max: math.Inf(1)},
+	UnitGravity:         {dim: DimAcceleration, scale: 9.80665, min: math.Inf(-1), max: math.Inf(1)},
+}
+
+// unitAliases are spellings without special characters config and query
+// strings use
+var unitAliases = map[string]Unit{
+	"percent": UnitPercent,
+	"C":       UnitCelsius,
+	"degC":    UnitCelsius,
+	"F":       UnitFahrenheit,
+	"degF":    UnitFahrenheit,
+	"m/s2":    UnitMeterPerSecond2,
+	"m/s^2":   UnitMeterPerSecond2,
+}
+
+// ParseUnit resolves unit name or its alias, empty is UnitNone
+func ParseUnit(s string) (Unit, error) {
+	if a, ok := unitAliases[s]; ok {
+		return a, nil
+	}
+	u := Unit(s)
+	if err := u.Validate(); err != nil {
+		return "", err
+	}
+	return u, nil
+}
+
+// Validate checks unit is known
+func (u Unit) Validate() error {
+	if u == UnitNone {
+		return nil
+	}
+	if _, ok := units[u]; !ok {
+		return errs.New(errs.InvalidArgument, fmt.Sprintf("unknown sensor unit %q", string(u)))
+	}
+	return nil
+}
+
+// Dimension returns quantity unit measures, empty for UnitNone
+func (u Unit) Dimension() Dimension {
+	return units[u].dim
+}
+
+// Bounds returns physical range of values in unit, infinite where
+// dimension has no limit
+func (u Unit) Bounds() (lo, hi float64) {
+	d, ok := units[u]
+	if !ok {
+		return math.Inf(-1), math.Inf(1)
+	}
+	return (d.min - d.offset) / d.scale, (d.max - d.offset) / d.scale
+}
+
+// Contains tells whether v is physically possible in unit
+func (u Unit) Contains(v float64) bool {
+	lo, hi := u.Bounds()
+	return v >= lo && v <= hi
+}
+
+// Convert converts v from unit to unit of same dimension. Same units,
+// UnitNone included, convert to themselves.
+func Convert(v float64, from, to Unit) (float64, error) {
+	if from == to {
+		return v, nil
+	}
+	f, ok := units[from]
+	if !ok {
+		return 0, errs.New(errs.InvalidArgument, fmt.Sprintf("cannot convert from unit %q", string(from)))
+	}
+	t, ok := units[to]
+	if !ok {
+		return 0, errs.New(errs.InvalidArgument, fmt.Sprintf("cannot convert to unit %q", string(to)))
+	}
+	if f.dim != t.dim {
+		return 0, errs.New(errs.InvalidArgument, fmt.Sprintf("cannot convert %s to %s", from, to))
+	}
+	return (v*f.scale + f.offset - t.offset) / t.scale, nil
+}
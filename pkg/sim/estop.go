@@ -0,0 +1,52 @@
+package sim
+
+import (
+	"sync"
+	"time"
+
+	"github.com/sashalind/sex-artifical-intelligence/pkg/clock"
+)
+
+// EStop simulates emergency stop button. It follows scenario timeline and
+// can also be pressed manually; manual press wins until released.
+type EStop struct {
+	mu       sync.Mutex
+	clock    clock.Clock
+	scenario *Scenario
+	start    time.Time
+	pressed  bool
+}
+
+// NewEStop creates simulated E-stop following scenario (may be nil)
+func NewEStop(clk clock.Clock, sc *Scenario) *EStop {
+	clk = clock.OrReal(clk)
+	return &EStop{clock: clk, scenario: sc, start: clk.Now()}
+}
+
+// Press engages E-stop manually
+func (e *EStop) Press() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.pressed = true
+}
+
+// Release clears manual press
+func (e *EStop) Release() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.pressed = false
+}
+
+// Engaged reports whether E-stop is active
+func (e *EStop) Engaged() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.pressed {
+		return true
+	}
+	if e.scenario == nil {
+		return false
+	}
+	return e.scenario.estopAt(e.scenario.elapsed(e.clock.Since(e.start)))
+}
@@ -0,0 +1,271 @@
+package harness
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/sashalind/sex-artifical-intelligence/pkg/clock"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/core"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/motion"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/safety"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/sensor"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/sim"
+)
+
+// DefaultStep is fake time advanced per harness iteration
+const DefaultStep = motion.TickInterval
+
+// DefaultTolerance is allowed position error in degrees
+const DefaultTolerance = 1.0
+
+// Options tune harness run
+type Options struct {
+	// Step is fake time advanced per iteration, rounded up to whole
+	// control ticks
+	Step time.Duration
+}
+
+// Sample is recorded motor state
+type Sample struct {
+	At       time.Duration `json:"at"`
+	Position float64       `json:"position"`
+	Speed    float64       `json:"speed"`
+}
+
+// Event is notable thing that happened during run
+type Event struct {
+	At     time.Duration `json:"at"`
+	Kind   string        `json:"kind"`
+	Detail string        `json:"detail"`
+}
+
+// Report is outcome of script run
+type Report struct {
+	Script       string              `json:"script"`
+	Trajectories map[string][]Sample `json:"trajectories"`
+	Events       []Event             `json:"events"`
+	Failures     []string            `json:"failures"`
+}
+
+// Passed reports whether all expectations held
+func (r *Report) Passed() bool {
+	return len(r.Failures) == 0
+}
+
+// Run boots full system against simulator and plays script on fake clock.
+// Motion controller ticks only when harness steps it, each tick done
+// before next one starts, so motor state at every step is same on every
+// run however busy the machine is.
+func Run(script *Script, opts Options) (*Report, error) {
+	ticks := max(1, int((opts.Step+motion.TickInterval-1)/motion.TickInterval))
+	if opts.Step <= 0 {
+		ticks = int(DefaultStep / motion.TickInterval)
+	}
+	step := time.Duration(ticks) * motion.TickInterval
+
+	clk := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	// self-test waits for motors to move, fake time stands still until
+	// script starts
+	cfg := core.DefaultConfig()
+	cfg.Clock = clk
+	cfg.SelfTest.Disabled = true
+	motionCfg, err := cfg.MotionConfig()
+	if err != nil {
+		return nil, err
+	}
+	ctrl, err := motion.NewManualController(clk, motionCfg)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Subsystems.Motion = ctrl
+	system, err := core.NewSystemWithConfig(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("boot system: %w", err)
+	}
+	defer system.Shutdown()
+
+	world := sim.NewWorld(clk, system.GetMotors(), script.Scenario)
+	if err := system.AttachMotionDriver(world.Motors); err != nil {
+		return nil, err
+	}
+	var src sensor.Source = world.Sensors
+	if script.ReplayFile != "" {
+		if src, err = sensor.OpenReplay(clk, script.ReplayFile, sensor.ReplayOptions{}); err != nil {
+			return nil, err
+		}
+	}
+	if err := system.AttachSensorSource(src, sim.DefaultSampleInterval); err != nil {
+		return nil, err
+	}
+	safety.InitializeSafetyProtocols(system)
+	safety.AttachEStop(world.EStop)
+
+	r := &runner{
+		script: script,
+		system: system,
+		world:  world,
+		clock:  clk,
+		start:  clk.Now(),
+		report: &Report{
+			Script:       script.Name,
+			Trajectories: make(map[string][]Sample),
+		},
+	}
+
+	actions := script.Actions
+	for elapsed := time.Duration(0); elapsed <= time.Duration(script.Duration); elapsed += step {
+		for len(actions) > 0 && time.Duration(actions[0].At) <= elapsed {
+			r.perform(actions[0], elapsed)
+			actions = actions[1:]
+		}
+
+		r.record(elapsed)
+
+		for range ticks {
+			clk.Advance(motion.TickInterval)
+			if err := ctrl.StepTick(); err != nil {
+				r.fail(elapsed, "motion control loop stopped: %v", err)
+				return r.report, nil
+			}
+			awaitTickers(clk)
+		}
+	}
+
+	return r.report, nil
+}
+
+// maxYields bounds how often harness yields waiting for ticker goroutines,
+// one busy elsewhere must not hang run
+const maxYields = 10000
+
+// awaitTickers yields until every goroutine ticking on clk took its tick,
+// so E-stop polling and safety checks keep up with controller however
+// few CPUs there are
+func awaitTickers(clk *clock.Fake) {
+	for i := 0; i < maxYields && clk.Pending() > 0; i++ {
+		runtime.Gosched()
+	}
+}
+
+// runner holds state of single harness run
+type runner struct {
+	script *Script
+	system *core.System
+	world  *sim.World
+	clock  *clock.Fake
+	start  time.Time
+	report *Report
+
+	lastBehavior string
+	lastSafety   safety.SafetyLevel
+}
+
+// perform executes action and checks its expectations
+func (r *runner) perform(a Action, at time.Duration) {
+	if a.EStop != nil {
+		if *a.EStop {
+			r.world.EStop.Press()
+		} else {
+			r.world.EStop.Release()
+		}
+		r.event(at, "estop", fmt.Sprintf("engaged=%v", *a.EStop))
+	}
+
+	var reply string
+	var cmdErr error
+	if a.Command != "" {
+		resp, err := r.system.ProcessCommand(context.Background(), a.Command)
+		cmdErr = err
+		if err == nil && resp != nil {
+			reply = resp.Text
+		}
+		r.event(at, "command", fmt.Sprintf("%q -> %q err=%v", a.Command, reply, err))
+	}
+
+	if a.Expect != nil {
+		r.check(a.Expect, at, reply, cmdErr)
+	}
+}
+
+// check verifies expectation against current system state
+func (r *runner) check(e *Expectation, at time.Duration, reply string, cmdErr error) {
+	if e.Motor != "" {
+		found := false
+		for _, m := range r.system.GetMotors() {
+			if string(m.ID) != e.Motor {
+				continue
+			}
+			found = true
+
+			tolerance := e.Tolerance
+			if tolerance <= 0 {
+				tolerance = DefaultTolerance
+			}
+			if e.Position != nil && math.Abs(m.Position-*e.Position) > tolerance {
+				r.fail(at, "motor %s position %.2f, want %.2f±%.2f", e.Motor, m.Position, *e.Position, tolerance)
+			}
+			if e.Moving != nil && (math.Abs(m.Speed) > 1e-6) != *e.Moving {
+				r.fail(at, "motor %s moving=%v, want %v", e.Motor, !*e.Moving, *e.Moving)
+			}
+		}
+		if !found {
+			r.fail(at, "motor %s not found", e.Motor)
+		}
+	}
+
+	if e.BehaviorState != "" {
+		if state := string(r.system.GetBehaviorState()); state != e.BehaviorState {
+			r.fail(at, "behavior state %s, want %s", state, e.BehaviorState)
+		}
+	}
+
+	if e.SafetyLevel != nil {
+		if level := safety.GetMonitor().GetCurrentLevel(); int(level) != *e.SafetyLevel {
+			r.fail(at, "safety level %d, want %d", level, *e.SafetyLevel)
+		}
+	}
+
+	if e.CommandError != nil && (cmdErr != nil) != *e.CommandError {
+		r.fail(at, "command error %v, want error=%v", cmdErr, *e.CommandError)
+	}
+
+	if e.ResponseContains != "" && !strings.Contains(reply, e.ResponseContains) {
+		r.fail(at, "response %q does not contain %q", reply, e.ResponseContains)
+	}
+}
+
+// record samples motor trajectories and notes state changes
+func (r *runner) record(at time.Duration) {
+	for _, m := range r.system.GetMotors() {
+		id := string(m.ID)
+		r.report.Trajectories[id] = append(r.report.Trajectories[id], Sample{
+			At:       at,
+			Position: m.Position,
+			Speed:    m.Speed,
+		})
+	}
+
+	if state := string(r.system.GetBehaviorState()); state != r.lastBehavior {
+		r.event(at, "behavior", state)
+		r.lastBehavior = state
+	}
+
+	if level := safety.GetMonitor().GetCurrentLevel(); level != r.lastSafety {
+		r.event(at, "safety", fmt.Sprintf("level=%d", level))
+		r.lastSafety = level
+	}
+}
+
+func (r *runner) event(at time.Duration, kind, detail string) {
+	r.report.Events = append(r.report.Events, Event{At: at, Kind: kind, Detail: detail})
+}
+
+func (r *runner) fail(at time.Duration, format string, args ...interface{}) {
+	r.report.Failures = append(r.report.Failures,
+		fmt.Sprintf("[%v] %s", at, fmt.Sprintf(format, args...)))
+}
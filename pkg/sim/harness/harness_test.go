@@ -0,0 +1,62 @@
+package harness
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+// TestScripts plays every end-to-end script against simulator. Scripts run
+// one at a time, safety monitor is process-wide.
+func TestScripts(t *testing.T) {
+	paths, err := filepath.Glob(filepath.Join("..", "scripts", "*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) == 0 {
+		t.Fatal("no scripts found")
+	}
+	for _, path := range paths {
+		script, err := LoadScript(path)
+		if err != nil {
+			t.Fatalf("load %s: %v", path, err)
+		}
+		t.Run(script.Name, func(t *testing.T) {
+			report, err := Run(script, Options{})
+			if err != nil {
+				t.Fatalf("run: %v", err)
+			}
+			for _, f := range report.Failures {
+				t.Error(f)
+			}
+			if t.Failed() {
+				for _, e := range report.Events {
+					t.Logf("[%v] %s %s", e.At, e.Kind, e.Detail)
+				}
+			}
+		})
+	}
+}
+
+func TestRunIsRepeatable(t *testing.T) {
+	script, err := LoadScript(filepath.Join("..", "scripts", "move_and_stop.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, err := Run(script, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := Run(script, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, b := first.Trajectories["servo_1"], second.Trajectories["servo_1"]
+	if len(a) != len(b) {
+		t.Fatalf("runs sampled %d and %d times", len(a), len(b))
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			t.Fatalf("runs differ at %v: %+v and %+v", a[i].At, a[i], b[i])
+		}
+	}
+}
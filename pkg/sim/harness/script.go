@@ -0,0 +1,94 @@
+package harness
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/sashalind/sex-artifical-intelligence/pkg/sim"
+)
+
+// Script is scripted end-to-end run: scenario drives simulated sensors while
+// actions issue commands and check system state at given times
+type Script struct {
+	Name     string        `json:"name"`
+	Scenario *sim.Scenario `json:"scenario,omitempty"`
+
+	// ScenarioFile is loaded relative to script file when Scenario is empty
+	ScenarioFile string `json:"scenario_file,omitempty"`
+
+	// ReplayFile is sensor recording, relative to script file, played
+	// instead of scenario sensors
+	ReplayFile string       `json:"replay_file,omitempty"`
+	Duration   sim.Duration `json:"duration"`
+	Actions    []Action     `json:"actions"`
+}
+
+// Action happens at given time: optional command, E-stop change and checks
+type Action struct {
+	At      sim.Duration `json:"at"`
+	Command string       `json:"command,omitempty"`
+	EStop   *bool        `json:"estop,omitempty"`
+	Expect  *Expectation `json:"expect,omitempty"`
+}
+
+// Expectation asserts on system state. Unset fields are not checked.
+type Expectation struct {
+	Motor     string   `json:"motor,omitempty"`
+	Position  *float64 `json:"position,omitempty"`
+	Tolerance float64  `json:"tolerance,omitempty"`
+	Moving    *bool    `json:"moving,omitempty"`
+
+	BehaviorState string `json:"behavior_state,omitempty"`
+	SafetyLevel   *int   `json:"safety_level,omitempty"`
+
+	// ResponseContains checks reply to command issued in same action
+	ResponseContains string `json:"response_contains,omitempty"`
+	CommandError     *bool  `json:"command_error,omitempty"`
+}
+
+// LoadScript reads script from JSON file
+func LoadScript(path string) (*Script, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var script Script
+	if err := json.Unmarshal(data, &script); err != nil {
+		return nil, fmt.Errorf("parse script %s: %w", path, err)
+	}
+
+	if script.Scenario == nil && script.ScenarioFile != "" {
+		scPath := script.ScenarioFile
+		if !filepath.IsAbs(scPath) {
+			scPath = filepath.Join(filepath.Dir(path), scPath)
+		}
+		sc, err := sim.LoadScenario(scPath)
+		if err != nil {
+			return nil, err
+		}
+		script.Scenario = sc
+	}
+
+	if script.ReplayFile != "" && !filepath.IsAbs(script.ReplayFile) {
+		script.ReplayFile = filepath.Join(filepath.Dir(path), script.ReplayFile)
+	}
+
+	if script.Name == "" {
+		script.Name = filepath.Base(path)
+	}
+
+	sort.SliceStable(script.Actions, func(i, j int) bool {
+		return script.Actions[i].At < script.Actions[j].At
+	})
+
+	if script.Duration == 0 && len(script.Actions) > 0 {
+		last := script.Actions[len(script.Actions)-1].At
+		script.Duration = sim.Duration(time.Duration(last) + time.Second)
+	}
+	return &script, nil
+}
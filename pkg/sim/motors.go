@@ -0,0 +1,267 @@
+package sim
+
+import (
+	"errors"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/sashalind/sex-artifical-intelligence/pkg/clock"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/motion"
+)
+
+// DefaultAcceleration is simulated motor acceleration in degrees/second²
+const DefaultAcceleration = 720.0
+
+// MotorDriver simulates motors with inertia. Motors accelerate towards
+// commanded speed and decelerate before reaching target, like real servos
+// under load would. Motor MaxAcceleration overrides driver acceleration.
+// Hard stops sit at motor position limits with endstop switches on them.
+// Implements motion.Driver, motion.EndstopDriver, motion.LoadDriver and
+// motion.MotorAttacher.
+type MotorDriver struct {
+	mu           sync.Mutex
+	clock        clock.Clock
+	acceleration float64
+	motors       map[motion.MotorID]*simMotor
+	lastUpdate   time.Time
+	closed       bool
+}
+
+type simMotor struct {
+	position float64
+	velocity float64
+	target   float64
+	maxSpeed float64
+	accel    float64
+	stopped  bool
+	min, max float64 // hard stops
+}
+
+// NewMotorDriver creates simulated driver for given motors, starting at their current positions
+func NewMotorDriver(clk clock.Clock, motors []motion.Motor, acceleration float64) *MotorDriver {
+	clk = clock.OrReal(clk)
+	if acceleration <= 0 {
+		acceleration = DefaultAcceleration
+	}
+
+	d := &MotorDriver{
+		motors:       make(map[motion.MotorID]*simMotor),
+		clock:        clk,
+		acceleration: acceleration,
+		lastUpdate:   clk.Now(),
+	}
+	for _, m := range motors {
+		d.motors[m.ID] = d.newMotor(m)
+	}
+	return d
+}
+
+// newMotor creates simulated motor resting at position of m
+func (d *MotorDriver) newMotor(m motion.Motor) *simMotor {
+	m = m.Shaft()
+	accel := m.MaxAcceleration
+	if accel <= 0 {
+		accel = d.acceleration
+	}
+	return &simMotor{
+		position: m.Position,
+		target:   m.Position,
+		maxSpeed: m.MaxSpeed,
+		accel:    accel,
+		stopped:  true,
+		min:      m.MinPosition,
+		max:      m.MaxPosition,
+	}
+}
+
+// AttachMotor adds simulated motor. Simulation has no hardware, so motors
+// with backend are refused.
+func (d *MotorDriver) AttachMotor(m motion.Motor, backend motion.Driver) error {
+	if backend != nil {
+		return errors.New("simulated driver can't route hardware backend")
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.closed {
+		return errors.New("driver closed")
+	}
+	if _, exists := d.motors[m.ID]; exists {
+		return &motion.MotorError{Motor: m.ID, Err: motion.ErrMotorExists}
+	}
+	d.advance()
+	d.motors[m.ID] = d.newMotor(m)
+	return nil
+}
+
+// DetachMotor removes simulated motor
+func (d *MotorDriver) DetachMotor(id motion.MotorID) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.advance()
+	delete(d.motors, id)
+	return nil
+}
+
+// SetTarget commands simulated motor
+func (d *MotorDriver) SetTarget(id motion.MotorID, position, speed float64) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	m, err := d.motor(id)
+	if err != nil {
+		return err
+	}
+
+	d.advance()
+	m.target = position
+	m.maxSpeed = math.Abs(speed)
+	m.stopped = false
+	return nil
+}
+
+// ReadState returns simulated position and velocity
+func (d *MotorDriver) ReadState(id motion.MotorID) (float64, float64, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	m, err := d.motor(id)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	d.advance()
+	return m.position, m.velocity, nil
+}
+
+// Stop halts simulated motor instantly, as if power was cut
+func (d *MotorDriver) Stop(id motion.MotorID) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	m, err := d.motor(id)
+	if err != nil {
+		return err
+	}
+
+	d.advance()
+	m.velocity = 0
+	m.target = m.position
+	m.stopped = true
+	return nil
+}
+
+// simulated load: motor at rest carries idleLoad, moving one up to
+// movingLoad at full speed and one pushing against hard stop stallLoad
+const (
+	idleLoad   = 0.1
+	movingLoad = 0.6
+	stallLoad  = 1.5
+)
+
+// ReadLoad reports simulated load as fraction of rated
+func (d *MotorDriver) ReadLoad(id motion.MotorID) (float64, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	m, err := d.motor(id)
+	if err != nil {
+		return 0, err
+	}
+
+	d.advance()
+	pushing := (m.target < m.min && m.position <= m.min) || (m.target > m.max && m.position >= m.max)
+	if pushing && !m.stopped {
+		return stallLoad, nil
+	}
+	load := idleLoad
+	if m.maxSpeed > 0 {
+		load += (movingLoad - idleLoad) * math.Min(math.Abs(m.velocity)/m.maxSpeed, 1)
+	}
+	return load, nil
+}
+
+// endstopTolerance is how close to hard stop endstop switch closes, degrees
+const endstopTolerance = 0.01
+
+// AtEndstop reports whether simulated motor presses switch at either stop
+func (d *MotorDriver) AtEndstop(id motion.MotorID) (bool, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	m, err := d.motor(id)
+	if err != nil {
+		return false, err
+	}
+
+	d.advance()
+	return m.position <= m.min+endstopTolerance || m.position >= m.max-endstopTolerance, nil
+}
+
+// Close marks driver closed
+func (d *MotorDriver) Close() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.closed = true
+	return nil
+}
+
+func (d *MotorDriver) motor(id motion.MotorID) (*simMotor, error) {
+	if d.closed {
+		return nil, errors.New("driver closed")
+	}
+	m, ok := d.motors[id]
+	if !ok {
+		return nil, &motion.MotorError{Motor: id, Err: motion.ErrMotorNotFound}
+	}
+	return m, nil
+}
+
+// advance integrates motor physics up to current time
+func (d *MotorDriver) advance() {
+	now := d.clock.Now()
+	dt := now.Sub(d.lastUpdate).Seconds()
+	d.lastUpdate = now
+
+	for _, m := range d.motors {
+		if !m.stopped {
+			m.step(dt, m.accel)
+		}
+	}
+}
+
+// step moves motor for dt seconds with acceleration-limited velocity
+func (m *simMotor) step(dt, accel float64) {
+	distance := m.target - m.position
+	if math.Abs(distance) < 1e-6 && math.Abs(m.velocity) < 1e-6 {
+		m.position = m.target
+		m.velocity = 0
+		return
+	}
+
+	// fastest speed from which we can still stop at target
+	brakeSpeed := math.Sqrt(2 * accel * math.Abs(distance))
+	desired := math.Copysign(math.Min(m.maxSpeed, brakeSpeed), distance)
+
+	maxDelta := accel * dt
+	delta := desired - m.velocity
+	if delta > maxDelta {
+		delta = maxDelta
+	} else if delta < -maxDelta {
+		delta = -maxDelta
+	}
+	m.velocity += delta
+
+	next := m.position + m.velocity*dt
+	// snap to target instead of overshooting due to coarse time steps
+	if (distance > 0 && next >= m.target) || (distance < 0 && next <= m.target) {
+		next = m.target
+		m.velocity = 0
+	}
+	if next < m.min || next > m.max {
+		// hit hard stop
+		next = math.Max(m.min, math.Min(next, m.max))
+		m.velocity = 0
+	}
+	m.position = next
+}
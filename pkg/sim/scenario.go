@@ -0,0 +1,263 @@
+package sim
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/sashalind/sex-artifical-intelligence/pkg/sensor"
+)
+
+// Duration is time.Duration that reads "1.5s" style strings from scenario files
+type Duration time.Duration
+
+// UnmarshalJSON parses duration string
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// MarshalJSON writes duration as string
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// Scenario describes scripted sensor signals and E-stop events over time
+type Scenario struct {
+	Name     string        `json:"name"`
+	Duration Duration      `json:"duration"`
+	Seed     int64         `json:"seed"`
+	Loop     bool          `json:"loop"`
+	Sensors  []SensorTrack `json:"sensors"`
+	EStop    []EStopEvent  `json:"estop"`
+}
+
+// SensorTrack is timeline of values for one sensor type. Waves add on top
+// of steps, or of zero when track has no steps. Min and Max clamp value
+// like real sensor range does, both zero leave it unclamped.
+type SensorTrack struct {
+	Type   sensor.SensorType `json:"type"`
+	Sensor sensor.SensorID   `json:"sensor,omitempty"` // registered sensor track plays, optional
+	Noise  float64           `json:"noise"`
+	Steps  []Step            `json:"steps"`
+	Waves  []Wave            `json:"waves,omitempty"`
+	Min    float64           `json:"min,omitempty"`
+	Max    float64           `json:"max,omitempty"`
+}
+
+// WaveKind is shape of generated signal
+type WaveKind string
+
+const (
+	WaveSine     WaveKind = "sine"
+	WaveSquare   WaveKind = "square"
+	WaveTriangle WaveKind = "triangle"
+	WaveSawtooth WaveKind = "sawtooth"
+
+	// WaveWalk is random walk pulled back to zero, wandering about
+	// Amplitude away from it and forgetting where it was over Period
+	WaveWalk WaveKind = "walk"
+)
+
+// Wave is signal added to track value between From and Until, zero Until
+// plays to end. Periodic waves swing Amplitude either side of zero, Phase
+// shifts them by fraction of Period.
+type Wave struct {
+	Kind      WaveKind `json:"kind"`
+	Amplitude float64  `json:"amplitude"`
+	Period    Duration `json:"period"`
+	Phase     float64  `json:"phase,omitempty"`
+	From      Duration `json:"from,omitempty"`
+	Until     Duration `json:"until,omitempty"`
+}
+
+// validate checks wave settings
+func (w Wave) validate() error {
+	switch w.Kind {
+	case WaveSine, WaveSquare, WaveTriangle, WaveSawtooth, WaveWalk:
+	default:
+		return fmt.Errorf("unknown wave kind %q", w.Kind)
+	}
+	if w.Period <= 0 {
+		return fmt.Errorf("%s wave needs positive period", w.Kind)
+	}
+	if math.IsNaN(w.Amplitude) || math.IsInf(w.Amplitude, 0) || math.IsNaN(w.Phase) || math.IsInf(w.Phase, 0) {
+		return fmt.Errorf("%s wave amplitude and phase must be finite", w.Kind)
+	}
+	if w.Until != 0 && w.Until <= w.From {
+		return fmt.Errorf("%s wave must end after it starts", w.Kind)
+	}
+	return nil
+}
+
+// active reports whether wave plays at scenario time
+func (w Wave) active(at time.Duration) bool {
+	return at >= time.Duration(w.From) && (w.Until == 0 || at < time.Duration(w.Until))
+}
+
+// periodic returns value of periodic wave at scenario time, zero for walk
+func (w Wave) periodic(at time.Duration) float64 {
+	if w.Period <= 0 {
+		return 0
+	}
+	// position within cycle, 0..1
+	x := float64(at-time.Duration(w.From))/float64(w.Period) + w.Phase
+	x -= math.Floor(x)
+	switch w.Kind {
+	case WaveSine:
+		return w.Amplitude * math.Sin(2*math.Pi*x)
+	case WaveSquare:
+		if x < 0.5 {
+			return w.Amplitude
+		}
+		return -w.Amplitude
+	case WaveTriangle:
+		return w.Amplitude * (1 - 4*math.Abs(x-0.5))
+	case WaveSawtooth:
+		return w.Amplitude * (2*x - 1)
+	}
+	return 0
+}
+
+// Step sets sensor value at given time. With Ramp value is linearly
+// interpolated from previous step instead of jumping.
+type Step struct {
+	At    Duration `json:"at"`
+	Value float64  `json:"value"`
+	Ramp  bool     `json:"ramp"`
+}
+
+// EStopEvent presses or releases emergency stop at given time
+type EStopEvent struct {
+	At      Duration `json:"at"`
+	Engaged bool     `json:"engaged"`
+}
+
+// LoadScenario reads scenario from JSON file
+func LoadScenario(path string) (*Scenario, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var sc Scenario
+	if err := json.Unmarshal(data, &sc); err != nil {
+		return nil, fmt.Errorf("parse scenario %s: %w", path, err)
+	}
+	if err := sc.normalize(); err != nil {
+		return nil, fmt.Errorf("scenario %s: %w", path, err)
+	}
+	return &sc, nil
+}
+
+// DefaultScenario is looping two-minute session used when simulation runs
+// without scenario file: touch and pressure rise, peak and fade, motion
+// follows them, so behavior analysis has something to chew on
+func DefaultScenario() *Scenario {
+	ramp := func(values ...float64) []Step {
+		steps := make([]Step, len(values))
+		for i, v := range values {
+			steps[i] = Step{At: Duration(time.Duration(i) * 20 * time.Second), Value: v, Ramp: i > 0}
+		}
+		return steps
+	}
+
+	sc := &Scenario{
+		Name:     "default",
+		Duration: Duration(2 * time.Minute),
+		Seed:     1,
+		Loop:     true,
+		Sensors: []SensorTrack{
+			{Type: sensor.TypeTouch, Noise: 0.03, Steps: ramp(0.1, 0.4, 0.7, 0.9, 0.5, 0.2, 0.1)},
+			{Type: sensor.TypePressure, Noise: 0.03, Steps: ramp(0.1, 0.3, 0.6, 0.85, 0.6, 0.3, 0.1)},
+			{Type: sensor.TypeMotion, Noise: 0.1, Steps: ramp(0.0, 0.2, 0.5, 0.8, 0.4, 0.1, 0.0)},
+			{Type: sensor.TypeTemp, Noise: 0.1, Steps: ramp(36.6, 36.7, 36.9, 37.1, 37.0, 36.8, 36.6)},
+		},
+	}
+	sc.normalize()
+	return sc
+}
+
+// normalize sorts timelines and validates scenario
+func (sc *Scenario) normalize() error {
+	for i := range sc.Sensors {
+		track := &sc.Sensors[i]
+		if track.Type == "" {
+			return fmt.Errorf("sensor track %d has no type", i)
+		}
+		for _, w := range track.Waves {
+			if err := w.validate(); err != nil {
+				return fmt.Errorf("sensor track %d: %w", i, err)
+			}
+		}
+		if (track.Min != 0 || track.Max != 0) && !(track.Min < track.Max) {
+			return fmt.Errorf("sensor track %d: min must be below max", i)
+		}
+		sort.SliceStable(track.Steps, func(a, b int) bool {
+			return track.Steps[a].At < track.Steps[b].At
+		})
+	}
+	sort.SliceStable(sc.EStop, func(a, b int) bool {
+		return sc.EStop[a].At < sc.EStop[b].At
+	})
+	return nil
+}
+
+// elapsed maps wall time since start to scenario time, wrapping when looping
+func (sc *Scenario) elapsed(since time.Duration) time.Duration {
+	if sc.Loop && sc.Duration > 0 {
+		return since % time.Duration(sc.Duration)
+	}
+	return since
+}
+
+// valueAt returns value of track steps at scenario time t. Track of waves
+// only plays zero from start.
+func (t *SensorTrack) valueAt(at time.Duration) (float64, bool) {
+	if len(t.Steps) == 0 {
+		return 0, len(t.Waves) > 0
+	}
+	if at < time.Duration(t.Steps[0].At) {
+		return 0, false
+	}
+
+	i := sort.Search(len(t.Steps), func(i int) bool {
+		return time.Duration(t.Steps[i].At) > at
+	})
+	// steps[i-1] is last step already reached
+	current := t.Steps[i-1]
+	if i == len(t.Steps) || !t.Steps[i].Ramp {
+		return current.Value, true
+	}
+
+	next := t.Steps[i]
+	span := time.Duration(next.At - current.At)
+	if span <= 0 {
+		return next.Value, true
+	}
+	frac := float64(at-time.Duration(current.At)) / float64(span)
+	return current.Value + (next.Value-current.Value)*frac, true
+}
+
+// estopAt returns E-stop state at scenario time t
+func (sc *Scenario) estopAt(at time.Duration) bool {
+	engaged := false
+	for _, ev := range sc.EStop {
+		if time.Duration(ev.At) > at {
+			break
+		}
+		engaged = ev.Engaged
+	}
+	return engaged
+}
@@ -0,0 +1,113 @@
+package sim
+
+import (
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/sashalind/sex-artifical-intelligence/pkg/clock"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/sensor"
+)
+
+// SensorSource replays scenario sensor tracks. Implements sensor.Source.
+type SensorSource struct {
+	mu       sync.Mutex
+	clock    clock.Clock
+	scenario *Scenario
+	start    time.Time
+	rng      *rand.Rand
+	source   string // built once, Read must not allocate
+	closed   bool
+
+	// random walk waves by track and wave, and when they last moved
+	walks [][]float64
+	moved time.Time
+}
+
+// NewSensorSource creates source playing scenario from now
+func NewSensorSource(clk clock.Clock, sc *Scenario) *SensorSource {
+	clk = clock.OrReal(clk)
+	s := &SensorSource{
+		clock:    clk,
+		scenario: sc,
+		start:    clk.Now(),
+		rng:      rand.New(rand.NewSource(sc.Seed)),
+		source:   "sim:" + sc.Name,
+		walks:    make([][]float64, len(sc.Sensors)),
+	}
+	s.moved = s.start
+	for i, track := range sc.Sensors {
+		s.walks[i] = make([]float64, len(track.Waves))
+	}
+	return s
+}
+
+// Read appends one sample per active sensor track
+func (s *SensorSource) Read(dst []sensor.SensorData) ([]sensor.SensorData, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return dst, nil
+	}
+
+	now := s.clock.Now()
+	at := s.scenario.elapsed(now.Sub(s.start))
+	dt := now.Sub(s.moved)
+	s.moved = now
+
+	readings := dst
+	for i := range s.scenario.Sensors {
+		track := &s.scenario.Sensors[i]
+		value, ok := track.valueAt(at)
+		if !ok {
+			continue
+		}
+		value += s.waves(i, at, dt)
+		if track.Noise > 0 {
+			value += s.rng.NormFloat64() * track.Noise
+		}
+		if track.Min != 0 || track.Max != 0 {
+			value = min(max(value, track.Min), track.Max)
+		}
+		readings = append(readings, sensor.SensorData{
+			Type:      track.Type,
+			Value:     value,
+			Timestamp: now,
+			Source:    s.source,
+			Sensor:    track.Sensor,
+		})
+	}
+	return readings, nil
+}
+
+// waves returns sum of waves of track i playing at scenario time, random
+// walks move by dt first
+func (s *SensorSource) waves(i int, at, dt time.Duration) float64 {
+	sum := 0.0
+	for j, w := range s.scenario.Sensors[i].Waves {
+		if !w.active(at) || w.Period <= 0 {
+			continue
+		}
+		if w.Kind != WaveWalk {
+			sum += w.periodic(at)
+			continue
+		}
+		// Ornstein-Uhlenbeck step, exact for any dt so slow polling
+		// doesn't blow it up; spread settles at Amplitude
+		decay := math.Exp(-dt.Seconds() / time.Duration(w.Period).Seconds())
+		x := &s.walks[i][j]
+		*x = *x*decay + w.Amplitude*math.Sqrt(1-decay*decay)*s.rng.NormFloat64()
+		sum += *x
+	}
+	return sum
+}
+
+// Close stops producing readings
+func (s *SensorSource) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	return nil
+}
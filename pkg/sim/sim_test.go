@@ -0,0 +1,257 @@
+package sim
+
+import (
+	"context"
+	"math"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sashalind/sex-artifical-intelligence/pkg/clock"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/motion"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/sensor"
+)
+
+var epoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// newWorld runs controller ticked by hand on simulated world
+func newWorld(t *testing.T, sc *Scenario) (*World, *motion.Controller, *clock.Fake) {
+	t.Helper()
+	clk := clock.NewFake(epoch)
+	cfg := motion.DefaultConfig()
+	ctrl, err := motion.NewManualController(clk, cfg)
+	if err != nil {
+		t.Fatalf("NewManualController: %v", err)
+	}
+	t.Cleanup(ctrl.Shutdown)
+	world := NewWorld(clk, cfg.Motors, sc)
+	ctrl.SetDriver(world.Motors)
+	return world, ctrl, clk
+}
+
+// step advances world by d in control ticks
+func step(t *testing.T, ctrl *motion.Controller, clk *clock.Fake, d time.Duration) {
+	t.Helper()
+	for elapsed := time.Duration(0); elapsed < d; elapsed += motion.TickInterval {
+		clk.Advance(motion.TickInterval)
+		if err := ctrl.StepTick(); err != nil {
+			t.Fatalf("tick: %v", err)
+		}
+	}
+}
+
+func motor(t *testing.T, ctrl *motion.Controller, id motion.MotorID) motion.Motor {
+	t.Helper()
+	for _, m := range ctrl.GetMotors() {
+		if m.ID == id {
+			return m
+		}
+	}
+	t.Fatalf("motor %s not found", id)
+	return motion.Motor{}
+}
+
+func TestControllerDrivesSimulatedMotors(t *testing.T) {
+	tests := []struct {
+		name     string
+		position float64
+		speed    float64
+		after    time.Duration
+		want     float64
+	}{
+		// 90° at 90°/s, accelerating at 720°/s² first
+		{"reaches target", 90, 90, 1500 * time.Millisecond, 90},
+		{"still on the way", 90, 90, 500 * time.Millisecond, 39.4},
+		{"full speed", 180, 180, time.Second, 157.5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, ctrl, clk := newWorld(t, nil)
+			err := ctrl.ExecuteCommand(context.Background(), motion.MotorCommand{ID: "servo_1", Position: tt.position, Speed: tt.speed})
+			if err != nil {
+				t.Fatalf("ExecuteCommand: %v", err)
+			}
+			step(t, ctrl, clk, tt.after)
+			if got := motor(t, ctrl, "servo_1").Position; math.Abs(got-tt.want) > 1 {
+				t.Errorf("position after %v = %.2f, want %.2f", tt.after, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSimulatedMotorAccelerates(t *testing.T) {
+	_, ctrl, clk := newWorld(t, nil)
+	if err := ctrl.ExecuteCommand(context.Background(), motion.MotorCommand{ID: "servo_2", Position: 180, Speed: 180}); err != nil {
+		t.Fatalf("ExecuteCommand: %v", err)
+	}
+	prev := 0.0
+	for range 10 {
+		step(t, ctrl, clk, motion.TickInterval)
+		speed := motor(t, ctrl, "servo_2").Speed
+		// 720°/s² gives at most 7.2°/s more per tick
+		if speed-prev > 7.2+1e-6 || speed > 180 {
+			t.Fatalf("speed jumped from %.2f to %.2f in one tick", prev, speed)
+		}
+		prev = speed
+	}
+	if prev <= 0 {
+		t.Fatal("motor did not start moving")
+	}
+	ctrl.StopAll()
+	step(t, ctrl, clk, 50*time.Millisecond)
+	if speed := motor(t, ctrl, "servo_2").Speed; speed != 0 {
+		t.Errorf("speed after stop = %.2f, want 0", speed)
+	}
+}
+
+func TestHardStops(t *testing.T) {
+	d := NewMotorDriver(clock.NewFake(epoch), motion.DefaultConfig().Motors, 0)
+	clk := d.clock.(*clock.Fake)
+	if err := d.SetTarget("servo_1", 250, 180); err != nil {
+		t.Fatalf("SetTarget: %v", err)
+	}
+	for range 300 {
+		clk.Advance(motion.TickInterval)
+		if _, _, err := d.ReadState("servo_1"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	pos, vel, _ := d.ReadState("servo_1")
+	if pos != 180 || vel != 0 {
+		t.Errorf("motor driven past stop at %.2f moving %.2f, want resting at 180", pos, vel)
+	}
+	if hit, _ := d.AtEndstop("servo_1"); !hit {
+		t.Error("endstop open at hard stop")
+	}
+	if load, _ := d.ReadLoad("servo_1"); load != stallLoad {
+		t.Errorf("load pushing hard stop = %.2f, want %.2f", load, stallLoad)
+	}
+	if _, _, err := d.ReadState("missing"); err == nil {
+		t.Error("unknown motor read without error")
+	}
+}
+
+func TestScenarioFiles(t *testing.T) {
+	paths, err := filepath.Glob(filepath.Join("scenarios", "*.json"))
+	if err != nil || len(paths) == 0 {
+		t.Fatalf("no scenarios: %v", err)
+	}
+	for _, path := range paths {
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			sc, err := LoadScenario(path)
+			if err != nil {
+				t.Fatalf("LoadScenario: %v", err)
+			}
+			src := NewSensorSource(clock.NewFake(epoch), sc)
+			readings, err := src.Read(nil)
+			if err != nil {
+				t.Fatalf("Read: %v", err)
+			}
+			for _, r := range readings {
+				if math.IsNaN(r.Value) || math.IsInf(r.Value, 0) {
+					t.Errorf("%s reading %v", r.Type, r.Value)
+				}
+			}
+		})
+	}
+}
+
+func TestSensorTrack(t *testing.T) {
+	sc := &Scenario{
+		Name: "ramp",
+		Sensors: []SensorTrack{{Type: sensor.TypePressure, Steps: []Step{
+			{At: 0, Value: 0.2},
+			{At: Duration(10 * time.Second), Value: 0.6, Ramp: true},
+			{At: Duration(20 * time.Second), Value: 0.1},
+		}}},
+	}
+	if err := sc.normalize(); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		at   time.Duration
+		want float64
+	}{
+		{0, 0.2},
+		{5 * time.Second, 0.4},
+		{10 * time.Second, 0.6},
+		{15 * time.Second, 0.6},
+		{20 * time.Second, 0.1},
+	}
+	clk := clock.NewFake(epoch)
+	src := NewSensorSource(clk, sc)
+	var last time.Duration
+	for _, tt := range tests {
+		clk.Advance(tt.at - last)
+		last = tt.at
+		readings, err := src.Read(nil)
+		if err != nil || len(readings) != 1 {
+			t.Fatalf("Read at %v = %v, %v", tt.at, readings, err)
+		}
+		if got := readings[0].Value; math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("pressure at %v = %.3f, want %.3f", tt.at, got, tt.want)
+		}
+	}
+}
+
+func TestEStopFollowsScenario(t *testing.T) {
+	sc, err := LoadScenario(filepath.Join("scenarios", "estop.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	clk := clock.NewFake(epoch)
+	e := NewEStop(clk, sc)
+	tests := []struct {
+		at      time.Duration
+		action  string
+		engaged bool
+	}{
+		{at: 0, engaged: false},
+		{at: 5 * time.Second, engaged: true},
+		{at: 9 * time.Second, engaged: true},
+		{at: 10 * time.Second, engaged: false},
+		{at: 11 * time.Second, action: "press", engaged: true},
+		{at: 12 * time.Second, action: "release", engaged: false},
+	}
+	var last time.Duration
+	for _, tt := range tests {
+		clk.Advance(tt.at - last)
+		last = tt.at
+		switch tt.action {
+		case "press":
+			e.Press()
+		case "release":
+			e.Release()
+		}
+		if got := e.Engaged(); got != tt.engaged {
+			t.Errorf("engaged at %v = %v, want %v", tt.at, got, tt.engaged)
+		}
+	}
+}
+
+// TestSensorSourceReadDoesNotAllocate guards hot path, see
+// docs/performance.md
+func TestSensorSourceReadDoesNotAllocate(t *testing.T) {
+	sc, err := LoadScenario(filepath.Join("scenarios", "signals.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	src := NewSensorSource(clock.NewFake(epoch), sc)
+	buf := make([]sensor.SensorData, 0, len(sc.Sensors))
+	if allocs := testing.AllocsPerRun(1000, func() { buf, _ = src.Read(buf[:0]) }); allocs != 0 {
+		t.Errorf("Read allocates %v times", allocs)
+	}
+}
+
+func BenchmarkSensorSourceRead(b *testing.B) {
+	sc, err := LoadScenario(filepath.Join("scenarios", "pressure_rise.json"))
+	if err != nil {
+		b.Fatal(err)
+	}
+	src := NewSensorSource(nil, sc)
+	buf := make([]sensor.SensorData, 0, 8)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		buf, _ = src.Read(buf[:0])
+	}
+}
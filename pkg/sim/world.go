@@ -0,0 +1,35 @@
+package sim
+
+import (
+	"time"
+
+	"github.com/sashalind/sex-artifical-intelligence/pkg/clock"
+	"github.com/sashalind/sex-artifical-intelligence/pkg/motion"
+)
+
+// DefaultSampleInterval is how often simulated sensors are polled
+const DefaultSampleInterval = 10 * time.Millisecond
+
+// World bundles simulated hardware for whole system
+type World struct {
+	Scenario *Scenario
+	Motors   *MotorDriver
+	Sensors  *SensorSource
+	EStop    *EStop
+}
+
+// NewWorld creates simulated hardware for given motors playing scenario.
+// Nil scenario gives idle sensors and released E-stop. Using same clock as
+// the system keeps simulated physics in step with controller ticks.
+func NewWorld(clk clock.Clock, motors []motion.Motor, sc *Scenario) *World {
+	if sc == nil {
+		sc = &Scenario{Name: "idle"}
+	}
+
+	return &World{
+		Scenario: sc,
+		Motors:   NewMotorDriver(clk, motors, DefaultAcceleration),
+		Sensors:  NewSensorSource(clk, sc),
+		EStop:    NewEStop(clk, sc),
+	}
+}
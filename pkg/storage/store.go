@@ -0,0 +1,410 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+	berrors "go.etcd.io/bbolt/errors"
+
+	"github.com/sashalind/sex-artifical-intelligence/pkg/secure"
+)
+
+// BucketName identifies logical table in the store
+type BucketName string
+
+const (
+	BucketCommands      BucketName = "commands"
+	BucketBehavior      BucketName = "behavior"
+	BucketPatterns      BucketName = "patterns"
+	BucketCalibration   BucketName = "calibration"
+	BucketProfiles      BucketName = "profiles"
+	BucketMetrics       BucketName = "metrics"
+	BucketRoutines      BucketName = "routines"
+	BucketHistory       BucketName = "history"
+	BucketPrograms      BucketName = "programs"
+	BucketMisunderstood BucketName = "misunderstood"
+)
+
+// FileName is database file store keeps in its directory
+const FileName = "sai.db"
+
+// lockTimeout is how long Open waits for other process holding database
+const lockTimeout = time.Second
+
+var ErrClosed = errors.New("store is closed")
+
+// Store is embedded key-value persistence shared by all subsystems, one
+// bbolt database with bucket per subsystem. Every write is its own
+// transaction and is on disk when call returns. With cipher values are
+// encrypted, keys stay readable so records keep their order.
+type Store struct {
+	mu      sync.Mutex
+	db      *bolt.DB
+	dir     string
+	cipher  *secure.Cipher
+	buckets map[BucketName]*Bucket
+	closed  bool
+}
+
+// Open opens store in given directory, creating it if needed.
+// Nil cipher stores data in plaintext.
+func Open(dir string, c *secure.Cipher) (*Store, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+
+	db, err := bolt.Open(filepath.Join(dir, FileName), 0600, &bolt.Options{Timeout: lockTimeout})
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %w", FileName, err)
+	}
+
+	return &Store{
+		db:      db,
+		dir:     dir,
+		cipher:  c,
+		buckets: make(map[BucketName]*Bucket),
+	}, nil
+}
+
+// Cipher returns cipher store encrypts with, nil for plaintext stores.
+// Lets other files kept next to the store share its key.
+func (s *Store) Cipher() *secure.Cipher {
+	return s.cipher
+}
+
+// Bucket returns named bucket, creating it on first access. Bucket file
+// left by older versions is imported then.
+func (s *Store) Bucket(name BucketName) (*Bucket, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return nil, ErrClosed
+	}
+	if b, ok := s.buckets[name]; ok {
+		return b, nil
+	}
+
+	b := &Bucket{name: name, db: s.db, cipher: s.cipher}
+	if err := s.db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists([]byte(name))
+		return err
+	}); err != nil {
+		return nil, fmt.Errorf("create bucket %s: %w", name, err)
+	}
+	if err := b.importFile(filepath.Join(s.dir, string(name)+".db")); err != nil {
+		return nil, fmt.Errorf("import bucket %s: %w", name, err)
+	}
+
+	s.buckets[name] = b
+	return b, nil
+}
+
+// Close closes database, buckets fail with ErrClosed afterwards
+func (s *Store) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	return s.db.Close()
+}
+
+// Bucket holds JSON-encoded records keyed by string, in key order
+type Bucket struct {
+	name   BucketName
+	db     *bolt.DB
+	cipher *secure.Cipher
+}
+
+// legacyFile is bucket as older versions kept it, whole in one JSON file
+type legacyFile struct {
+	Seq     uint64                     `json:"seq"`
+	Records map[string]json.RawMessage `json:"records"`
+}
+
+// importFile moves records of legacy bucket file into bucket and removes
+// the file
+func (b *Bucket) importFile(path string) error {
+	data, err := secure.ReadFile(path, b.cipher)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var file legacyFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return err
+	}
+	err = b.update(func(bkt *bolt.Bucket) error {
+		for key, value := range file.Records {
+			if err := b.put(bkt, key, value); err != nil {
+				return err
+			}
+		}
+		return bkt.SetSequence(max(bkt.Sequence(), file.Seq))
+	})
+	if err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
+
+// update runs fn in write transaction on bucket
+func (b *Bucket) update(fn func(*bolt.Bucket) error) error {
+	err := b.db.Update(func(tx *bolt.Tx) error {
+		return fn(tx.Bucket([]byte(b.name)))
+	})
+	return b.wrap(err)
+}
+
+// view runs fn in read transaction on bucket
+func (b *Bucket) view(fn func(*bolt.Bucket) error) error {
+	err := b.db.View(func(tx *bolt.Tx) error {
+		return fn(tx.Bucket([]byte(b.name)))
+	})
+	return b.wrap(err)
+}
+
+func (b *Bucket) wrap(err error) error {
+	if errors.Is(err, berrors.ErrDatabaseNotOpen) {
+		return ErrClosed
+	}
+	return err
+}
+
+// put stores encoded value, sealed when store is encrypted
+func (b *Bucket) put(bkt *bolt.Bucket, key string, data []byte) error {
+	if b.cipher != nil {
+		sealed, err := b.cipher.Seal(data)
+		if err != nil {
+			return err
+		}
+		data = sealed
+	}
+	return bkt.Put([]byte(key), data)
+}
+
+// open returns copy of stored value, decrypted when sealed. Plaintext
+// values written before encryption was enabled stay readable.
+func (b *Bucket) open(data []byte) (json.RawMessage, error) {
+	if !secure.IsEncrypted(data) {
+		return append(json.RawMessage(nil), data...), nil
+	}
+	if b.cipher == nil {
+		return nil, fmt.Errorf("bucket %s is encrypted but no key configured", b.name)
+	}
+	return b.cipher.Open(data)
+}
+
+// Name returns bucket name
+func (b *Bucket) Name() BucketName {
+	return b.name
+}
+
+// Put stores value under key
+func (b *Bucket) Put(key string, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return b.update(func(bkt *bolt.Bucket) error {
+		return b.put(bkt, key, data)
+	})
+}
+
+// Append stores value under next sequential key and returns that key.
+// Sequential keys sort in insertion order, useful for logs and histories.
+func (b *Bucket) Append(value interface{}) (string, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+
+	var key string
+	err = b.update(func(bkt *bolt.Bucket) error {
+		key, err = b.append(bkt, data)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+	return key, nil
+}
+
+// AppendTrim is Append followed by Trim in single transaction
+func (b *Bucket) AppendTrim(value interface{}, max int) (string, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+
+	var key string
+	err = b.update(func(bkt *bolt.Bucket) error {
+		if key, err = b.append(bkt, data); err != nil {
+			return err
+		}
+		return trim(bkt, max)
+	})
+	if err != nil {
+		return "", err
+	}
+	return key, nil
+}
+
+// append stores encoded value under next sequential key
+func (b *Bucket) append(bkt *bolt.Bucket, data []byte) (string, error) {
+	seq, err := bkt.NextSequence()
+	if err != nil {
+		return "", err
+	}
+	key := fmt.Sprintf("%016d", seq)
+	return key, b.put(bkt, key, data)
+}
+
+// Get decodes value stored under key into out, reporting whether key exists
+func (b *Bucket) Get(key string, out interface{}) (bool, error) {
+	var data json.RawMessage
+	err := b.view(func(bkt *bolt.Bucket) error {
+		raw := bkt.Get([]byte(key))
+		if raw == nil {
+			return nil
+		}
+		var err error
+		data, err = b.open(raw)
+		return err
+	})
+	if err != nil || data == nil {
+		return false, err
+	}
+	return true, json.Unmarshal(data, out)
+}
+
+// Delete removes key from bucket
+func (b *Bucket) Delete(key string) error {
+	return b.update(func(bkt *bolt.Bucket) error {
+		return bkt.Delete([]byte(key))
+	})
+}
+
+// DeleteFunc removes every record drop selects in single transaction and
+// returns how many it removed
+func (b *Bucket) DeleteFunc(drop func(key string, data json.RawMessage) bool) (int, error) {
+	var n int
+	err := b.update(func(bkt *bolt.Bucket) error {
+		// deleting under cursor skips records, collect keys first
+		var keys [][]byte
+		err := bkt.ForEach(func(k, v []byte) error {
+			data, err := b.open(v)
+			if err != nil {
+				return fmt.Errorf("record %s: %w", k, err)
+			}
+			if drop(string(k), data) {
+				keys = append(keys, k)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, k := range keys {
+			if err := bkt.Delete(k); err != nil {
+				return err
+			}
+		}
+		n = len(keys)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// Keys returns all keys in sorted order, none once store is closed
+func (b *Bucket) Keys() []string {
+	var keys []string
+	b.view(func(bkt *bolt.Bucket) error {
+		return bkt.ForEach(func(k, _ []byte) error {
+			keys = append(keys, string(k))
+			return nil
+		})
+	})
+	return keys
+}
+
+// Len returns number of records in bucket
+func (b *Bucket) Len() int {
+	n := 0
+	b.view(func(bkt *bolt.Bucket) error {
+		n = bkt.Stats().KeyN
+		return nil
+	})
+	return n
+}
+
+// ForEach calls fn for every record in key order, stopping on first error.
+// Records are read first, fn may write to bucket.
+func (b *Bucket) ForEach(fn func(key string, data json.RawMessage) error) error {
+	var keys []string
+	var records []json.RawMessage
+	err := b.view(func(bkt *bolt.Bucket) error {
+		return bkt.ForEach(func(k, v []byte) error {
+			data, err := b.open(v)
+			if err != nil {
+				return fmt.Errorf("record %s: %w", k, err)
+			}
+			keys = append(keys, string(k))
+			records = append(records, data)
+			return nil
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	for i, k := range keys {
+		if err := fn(k, records[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Trim keeps only newest max records by key order
+func (b *Bucket) Trim(max int) error {
+	return b.update(func(bkt *bolt.Bucket) error {
+		return trim(bkt, max)
+	})
+}
+
+// trim deletes oldest records past max. It walks back from newest record
+// as bucket stats miss records written in same transaction.
+func trim(bkt *bolt.Bucket, max int) error {
+	c := bkt.Cursor()
+	k, _ := c.Last()
+	for kept := 0; k != nil && kept < max; kept++ {
+		k, _ = c.Prev()
+	}
+	// deleting under cursor skips records, collect keys first
+	var oldest [][]byte
+	for ; k != nil; k, _ = c.Prev() {
+		oldest = append(oldest, k)
+	}
+	for _, k := range oldest {
+		if err := bkt.Delete(k); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,276 @@
+package storage
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/sashalind/sex-artifical-intelligence/pkg/secure"
+)
+
+type record struct {
+	Name  string  `json:"name"`
+	Value float64 `json:"value"`
+}
+
+func newCipher(t *testing.T) *secure.Cipher {
+	t.Helper()
+	c, err := secure.NewCipher(bytes.Repeat([]byte{7}, secure.KeySize))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func open(t *testing.T, dir string, c *secure.Cipher) *Store {
+	t.Helper()
+	s, err := Open(dir, c)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func table(t *testing.T, s *Store) *Table[record] {
+	t.Helper()
+	tbl, err := OpenTable[record](s, BucketProfiles)
+	if err != nil {
+		t.Fatalf("OpenTable: %v", err)
+	}
+	return tbl
+}
+
+func TestStoreSurvivesReopen(t *testing.T) {
+	tests := []struct {
+		name   string
+		cipher bool
+	}{
+		{"plaintext", false},
+		{"encrypted", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			var c *secure.Cipher
+			if tt.cipher {
+				c = newCipher(t)
+			}
+
+			s := open(t, dir, c)
+			tbl := table(t, s)
+			for _, r := range []record{{"b", 2}, {"a", 1}, {"c", 3}} {
+				if err := tbl.Put(r.Name, r); err != nil {
+					t.Fatalf("Put: %v", err)
+				}
+			}
+			if err := tbl.Delete("c"); err != nil {
+				t.Fatalf("Delete: %v", err)
+			}
+			if err := s.Close(); err != nil {
+				t.Fatalf("Close: %v", err)
+			}
+
+			// written records are on disk without flush
+			tbl = table(t, open(t, dir, c))
+			all, err := tbl.All()
+			if err != nil {
+				t.Fatalf("All: %v", err)
+			}
+			if want := []record{{"a", 1}, {"b", 2}}; !reflect.DeepEqual(all, want) {
+				t.Errorf("All = %v, want %v", all, want)
+			}
+			if got, ok, err := tbl.Get("b"); err != nil || !ok || got.Value != 2 {
+				t.Errorf("Get(b) = %v, %v, %v", got, ok, err)
+			}
+			if _, ok, err := tbl.Get("c"); err != nil || ok {
+				t.Errorf("deleted record found: %v, %v", ok, err)
+			}
+		})
+	}
+}
+
+func TestAppendAndTrim(t *testing.T) {
+	tests := []struct {
+		appends int
+		keep    int
+		want    []string
+	}{
+		{appends: 3, keep: 5, want: []string{"0000000000000001", "0000000000000002", "0000000000000003"}},
+		{appends: 5, keep: 2, want: []string{"0000000000000004", "0000000000000005"}},
+		{appends: 2, keep: 0, want: nil},
+	}
+	for _, tt := range tests {
+		dir := t.TempDir()
+		s := open(t, dir, nil)
+		tbl := table(t, s)
+		for i := range tt.appends {
+			if _, err := tbl.Append(record{Value: float64(i)}); err != nil {
+				t.Fatalf("Append: %v", err)
+			}
+		}
+		if err := tbl.Trim(tt.keep); err != nil {
+			t.Fatalf("Trim: %v", err)
+		}
+		if got := tbl.Bucket().Keys(); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%d appends trimmed to %d kept %v, want %v", tt.appends, tt.keep, got, tt.want)
+		}
+		s.Close()
+
+		// sequence continues after reopen, trimmed keys are not reused
+		key, err := table(t, open(t, dir, nil)).Append(record{})
+		if err != nil {
+			t.Fatalf("Append after reopen: %v", err)
+		}
+		if want := fmt.Sprintf("%016d", tt.appends+1); key != want {
+			t.Errorf("key after reopen = %s, want %s", key, want)
+		}
+	}
+}
+
+func TestAppendTrim(t *testing.T) {
+	tbl := table(t, open(t, t.TempDir(), nil))
+	for i := range 5 {
+		if _, err := tbl.AppendTrim(record{Value: float64(i)}, 2); err != nil {
+			t.Fatalf("AppendTrim: %v", err)
+		}
+	}
+	want := []string{"0000000000000004", "0000000000000005"}
+	if got := tbl.Bucket().Keys(); !reflect.DeepEqual(got, want) {
+		t.Errorf("keys = %v, want %v", got, want)
+	}
+}
+
+func TestDeleteFunc(t *testing.T) {
+	tbl := table(t, open(t, t.TempDir(), newCipher(t)))
+	for i := range 6 {
+		if _, err := tbl.Append(record{Value: float64(i)}); err != nil {
+			t.Fatalf("Append: %v", err)
+		}
+	}
+	n, err := tbl.DeleteFunc(func(r record) bool { return int(r.Value)%2 == 0 })
+	if err != nil || n != 3 {
+		t.Fatalf("DeleteFunc = %d, %v, want 3", n, err)
+	}
+	got, err := tbl.All()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []record{{Value: 1}, {Value: 3}, {Value: 5}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("kept %v, want %v", got, want)
+	}
+}
+
+func TestEncryption(t *testing.T) {
+	dir := t.TempDir()
+	secret := record{Name: "very private", Value: 1}
+
+	// record written before encryption was enabled
+	s := open(t, dir, nil)
+	if err := table(t, s).Put("old", secret); err != nil {
+		t.Fatal(err)
+	}
+	s.Close()
+
+	c := newCipher(t)
+	s = open(t, dir, c)
+	tbl := table(t, s)
+	if err := tbl.Put("new", secret); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"old", "new"} {
+		if got, ok, err := tbl.Get(key); err != nil || !ok || got != secret {
+			t.Errorf("Get(%s) = %v, %v, %v", key, got, ok, err)
+		}
+	}
+	s.Close()
+
+	// sealed records need key
+	tbl = table(t, open(t, dir, nil))
+	if _, _, err := tbl.Get("new"); err == nil {
+		t.Error("encrypted record read without key")
+	}
+	if _, err := tbl.All(); err == nil {
+		t.Error("encrypted bucket listed without key")
+	}
+}
+
+func TestEncryptedStoreHasNoPlaintext(t *testing.T) {
+	dir := t.TempDir()
+	s := open(t, dir, newCipher(t))
+	tbl := table(t, s)
+	if err := tbl.Put("k", record{Name: "very private"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tbl.Append(record{Name: "very private"}); err != nil {
+		t.Fatal(err)
+	}
+	s.Close()
+
+	data, err := os.ReadFile(filepath.Join(dir, FileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(data, []byte("very private")) {
+		t.Error("plaintext value found in encrypted database")
+	}
+}
+
+func TestImportLegacyBucketFile(t *testing.T) {
+	tests := []struct {
+		name   string
+		cipher bool
+	}{
+		{"plaintext", false},
+		{"encrypted", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			var c *secure.Cipher
+			if tt.cipher {
+				c = newCipher(t)
+			}
+			legacy := filepath.Join(dir, string(BucketProfiles)+".db")
+			data := []byte(`{"seq": 7, "records": {"0000000000000007": {"name": "x", "value": 7}}}`)
+			if err := secure.WriteFile(legacy, data, c); err != nil {
+				t.Fatal(err)
+			}
+
+			tbl := table(t, open(t, dir, c))
+			if got, ok, err := tbl.Get("0000000000000007"); err != nil || !ok || got != (record{"x", 7}) {
+				t.Errorf("imported record = %v, %v, %v", got, ok, err)
+			}
+			if key, err := tbl.Append(record{}); err != nil || key != "0000000000000008" {
+				t.Errorf("Append after import = %s, %v", key, err)
+			}
+			if _, err := os.Stat(legacy); !errors.Is(err, os.ErrNotExist) {
+				t.Errorf("legacy file left behind: %v", err)
+			}
+		})
+	}
+}
+
+func TestClosedStore(t *testing.T) {
+	s := open(t, t.TempDir(), nil)
+	tbl := table(t, s)
+	s.Close()
+
+	if _, err := s.Bucket(BucketHistory); !errors.Is(err, ErrClosed) {
+		t.Errorf("Bucket after Close = %v, want ErrClosed", err)
+	}
+	if err := tbl.Put("k", record{}); !errors.Is(err, ErrClosed) {
+		t.Errorf("Put after Close = %v, want ErrClosed", err)
+	}
+	if _, err := tbl.Append(record{}); !errors.Is(err, ErrClosed) {
+		t.Errorf("Append after Close = %v, want ErrClosed", err)
+	}
+	if err := s.Close(); err != nil {
+		t.Errorf("second Close: %v", err)
+	}
+}
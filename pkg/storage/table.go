@@ -0,0 +1,91 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Table is typed view over bucket so callers don't deal with raw JSON
+type Table[T any] struct {
+	bucket *Bucket
+}
+
+// OpenTable returns typed table backed by named bucket
+func OpenTable[T any](s *Store, name BucketName) (*Table[T], error) {
+	b, err := s.Bucket(name)
+	if err != nil {
+		return nil, err
+	}
+	return &Table[T]{bucket: b}, nil
+}
+
+// Put stores record under key
+func (t *Table[T]) Put(key string, value T) error {
+	return t.bucket.Put(key, value)
+}
+
+// Append stores record under next sequential key
+func (t *Table[T]) Append(value T) (string, error) {
+	return t.bucket.Append(value)
+}
+
+// AppendTrim stores record under next sequential key and keeps only newest
+// max records, in single transaction
+func (t *Table[T]) AppendTrim(value T, max int) (string, error) {
+	return t.bucket.AppendTrim(value, max)
+}
+
+// Get returns record stored under key
+func (t *Table[T]) Get(key string) (T, bool, error) {
+	var value T
+	ok, err := t.bucket.Get(key, &value)
+	return value, ok, err
+}
+
+// Delete removes record
+func (t *Table[T]) Delete(key string) error {
+	return t.bucket.Delete(key)
+}
+
+// DeleteFunc removes every record drop selects in single transaction and
+// returns how many it removed. Records that don't decode are kept and
+// reported.
+func (t *Table[T]) DeleteFunc(drop func(T) bool) (int, error) {
+	var decodeErr error
+	n, err := t.bucket.DeleteFunc(func(key string, data json.RawMessage) bool {
+		var value T
+		if err := json.Unmarshal(data, &value); err != nil {
+			decodeErr = fmt.Errorf("record %s: %w", key, err)
+			return false
+		}
+		return drop(value)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return n, decodeErr
+}
+
+// All returns every record in key order
+func (t *Table[T]) All() ([]T, error) {
+	values := make([]T, 0, t.bucket.Len())
+	err := t.bucket.ForEach(func(_ string, data json.RawMessage) error {
+		var value T
+		if err := json.Unmarshal(data, &value); err != nil {
+			return err
+		}
+		values = append(values, value)
+		return nil
+	})
+	return values, err
+}
+
+// Trim keeps only newest max records
+func (t *Table[T]) Trim(max int) error {
+	return t.bucket.Trim(max)
+}
+
+// Bucket returns underlying untyped bucket
+func (t *Table[T]) Bucket() *Bucket {
+	return t.bucket
+}